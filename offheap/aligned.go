@@ -0,0 +1,56 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+package offheap
+
+import (
+	"fmt"
+	"os"
+)
+
+// Allocates an object of type T whose address is a multiple of align. The
+// type T must not contain any pointers in any part of its type. If the type T
+// is found to contain pointers this function will panic.
+//
+// The value of align must be a power of two, no larger than the operating
+// system's page size, see os.Getpagesize. Because the object is allocated in
+// a size class which is a multiple of align, aligned allocations can use much
+// more memory than the size of T.
+//
+// Like AllocObject, the contents of the newly allocated object are arbitrary.
+// An aligned object must be freed with FreeAligned, using the same align.
+func AllocAligned[T any](s *Store, align int) RefObject[T] {
+	if err := typeInfoFor[T]().pointerErr; err != nil {
+		panic(fmt.Errorf("cannot allocate generic type containing pointers %w", err))
+	}
+
+	idx := alignedIndex[T](s, align)
+
+	pRef := s.alloc(idx, rawSizeForType[T]())
+	return newRefObject[T](pRef)
+}
+
+// Frees the allocation referenced by r, which must have been allocated by
+// AllocAligned with the same align. After this call returns r must never be
+// used again.
+func FreeAligned[T any](s *Store, r RefObject[T], align int) {
+	idx := alignedIndex[T](s, align)
+	s.free(idx, r.ref)
+}
+
+// Returns the index of the smallest size class which can hold a T, and whose
+// size is a multiple of align. Slabs are page aligned, so every slot in such
+// a size class is aligned to align.
+func alignedIndex[T any](s *Store, align int) int {
+	if align <= 0 || !isPowerOfTwo(align) || align > os.Getpagesize() {
+		panic(fmt.Errorf("alignment (%d) must be a power of two no larger than the page size (%d)", align, os.Getpagesize()))
+	}
+
+	for idx := s.sizeIndex(max(rawSizeForType[T](), align)); idx < len(s.sizedStores); idx++ {
+		if s.classSize(idx)%align == 0 {
+			return idx
+		}
+	}
+	panic(fmt.Errorf("no size class can hold an allocation of %d bytes aligned to %d", rawSizeForType[T](), align))
+}
@@ -0,0 +1,68 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+package offheap
+
+import (
+	"os"
+	"testing"
+	"unsafe"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Demonstrate that objects and slice elements are naturally aligned, for
+// Stores with default and custom size classes
+func Test_Alignment_Natural(t *testing.T) {
+	for _, s := range []*Store{New(), NewWithSizeClasses(1<<12, GeometricSizeClasses(1.25, 1<<12))} {
+		for range 100 {
+			r2 := AllocObject[[3]uint16](s)
+			r3 := AllocObject[fortyBytes](s)
+			r4 := AllocSlice[[5]uint32](s, 3, 7)
+
+			assert.Zero(t, uintptr(unsafe.Pointer(r2.Value()))%unsafe.Alignof([3]uint16{}))
+			assert.Zero(t, uintptr(unsafe.Pointer(r3.Value()))%unsafe.Alignof(fortyBytes{}))
+			for i := range r4.Value() {
+				assert.Zero(t, uintptr(unsafe.Pointer(&r4.Value()[i]))%unsafe.Alignof([5]uint32{}))
+			}
+		}
+		assert.NoError(t, s.Destroy())
+	}
+}
+
+// Demonstrate that AllocAligned returns objects aligned to each requested
+// alignment, which can be freed with FreeAligned
+func Test_AllocAligned(t *testing.T) {
+	for _, s := range []*Store{New(), NewWithSizeClasses(1<<12, GeometricSizeClasses(1.25, 1<<12))} {
+		for align := 1; align <= os.Getpagesize(); align *= 2 {
+			refs := []RefObject[[3]byte]{}
+			for range 50 {
+				r := AllocAligned[[3]byte](s, align)
+				assert.Zero(t, uintptr(unsafe.Pointer(r.Value()))%uintptr(align), "align %d", align)
+				*r.Value() = [3]byte{1, 2, 3}
+				refs = append(refs, r)
+			}
+			for _, r := range refs {
+				assert.Equal(t, [3]byte{1, 2, 3}, *r.Value())
+				FreeAligned(s, r, align)
+			}
+		}
+		assert.Equal(t, 0, s.TotalStats().Live)
+		assert.NoError(t, s.Destroy())
+	}
+}
+
+// Demonstrate that invalid alignments panic
+func Test_AllocAligned_Invalid(t *testing.T) {
+	s := New()
+	defer func() {
+		assert.NoError(t, s.Destroy())
+	}()
+
+	assert.Panics(t, func() { AllocAligned[int](s, 0) })
+	assert.Panics(t, func() { AllocAligned[int](s, -8) })
+	assert.Panics(t, func() { AllocAligned[int](s, 24) })
+	assert.Panics(t, func() { AllocAligned[int](s, os.Getpagesize()*2) })
+	assert.Panics(t, func() { AllocAligned[*int](s, 8) })
+}
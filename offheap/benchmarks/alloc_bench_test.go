@@ -0,0 +1,92 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+package benchmarks
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/fmstephe/memorymanager/offheap"
+)
+
+// The allocation sizes, in bytes, benchmarked for each size class
+var allocSizes = []int{8, 64, 512, 4096, 32768}
+
+// Assigning heap allocations to sinkBytes prevents the compiler from
+// optimising them away, or placing them on the stack
+var sinkBytes []byte
+
+func BenchmarkAllocFree_Offheap(b *testing.B) {
+	for _, size := range allocSizes {
+		b.Run(fmt.Sprintf("size=%d", size), func(b *testing.B) {
+			store := offheap.New()
+			defer store.Destroy()
+
+			b.ReportAllocs()
+			b.SetBytes(int64(size))
+			b.ResetTimer()
+			for range b.N {
+				r := offheap.AllocSlice[byte](store, size, size)
+				r.Value()[0] = 1
+				offheap.FreeSlice(store, r)
+			}
+		})
+	}
+}
+
+func BenchmarkAllocFree_Heap(b *testing.B) {
+	for _, size := range allocSizes {
+		b.Run(fmt.Sprintf("size=%d", size), func(b *testing.B) {
+			b.ReportAllocs()
+			b.SetBytes(int64(size))
+			for range b.N {
+				sinkBytes = make([]byte, size)
+				sinkBytes[0] = 1
+			}
+		})
+	}
+}
+
+// Benchmarks allocating many objects before freeing them all, so allocations
+// can't simply reuse the most recently freed slot
+func BenchmarkAllocManyFreeMany_Offheap(b *testing.B) {
+	const batch = 1024
+
+	store := offheap.New()
+	defer store.Destroy()
+
+	refs := make([]offheap.RefObject[fortyBytes], batch)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for range b.N / batch {
+		for i := range refs {
+			refs[i] = offheap.AllocObject[fortyBytes](store)
+		}
+		for i := range refs {
+			offheap.FreeObject(store, refs[i])
+		}
+	}
+}
+
+func BenchmarkAllocManyFreeMany_Heap(b *testing.B) {
+	const batch = 1024
+
+	refs := make([]*fortyBytes, batch)
+
+	b.ReportAllocs()
+	for range b.N / batch {
+		for i := range refs {
+			refs[i] = new(fortyBytes)
+		}
+		for i := range refs {
+			refs[i] = nil
+		}
+	}
+}
+
+type fortyBytes struct {
+	a, b, c, d, e int64
+}
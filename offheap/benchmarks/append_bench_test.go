@@ -0,0 +1,53 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+package benchmarks
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/fmstephe/memorymanager/offheap"
+)
+
+// The final lengths of the slices grown by the append benchmarks
+var appendLengths = []int{16, 1024, 65536}
+
+// Assigning heap slices to sinkInts prevents the compiler from optimising
+// them away
+var sinkInts []int64
+
+func BenchmarkAppend_Offheap(b *testing.B) {
+	for _, length := range appendLengths {
+		b.Run(fmt.Sprintf("length=%d", length), func(b *testing.B) {
+			store := offheap.New()
+			defer store.Destroy()
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for range b.N {
+				r := offheap.AllocSlice[int64](store, 0, 0)
+				for i := range length {
+					r = offheap.Append(store, r, int64(i))
+				}
+				offheap.FreeSlice(store, r)
+			}
+		})
+	}
+}
+
+func BenchmarkAppend_Heap(b *testing.B) {
+	for _, length := range appendLengths {
+		b.Run(fmt.Sprintf("length=%d", length), func(b *testing.B) {
+			b.ReportAllocs()
+			for range b.N {
+				var s []int64
+				for i := range length {
+					s = append(s, int64(i))
+				}
+				sinkInts = s
+			}
+		})
+	}
+}
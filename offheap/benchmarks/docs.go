@@ -0,0 +1,24 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+// Package benchmarks compares allocating with an offheap.Store against
+// allocating on the Go heap.
+//
+// The benchmarks live in this package's tests, and are run with
+//
+//	go test -bench . ./offheap/benchmarks
+//
+// Each benchmark has an Offheap and a Heap variant, doing the same work, so
+// the results can be compared directly. The benchmarks cover
+//
+//   - allocation and free throughput for each size class
+//   - the latency of reading an allocation through its reference
+//   - the time taken by a garbage collection while many objects are live
+//   - the cost of growing a slice by appending to it
+//
+// The garbage collection benchmarks report a gc-ns/op metric, the time taken
+// by a single forced garbage collection. Large numbers of offheap objects are
+// invisible to the garbage collector, while the same objects on the heap must
+// be scanned by every collection.
+package benchmarks
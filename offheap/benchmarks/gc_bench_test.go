@@ -0,0 +1,75 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+package benchmarks
+
+import (
+	"fmt"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/fmstephe/memorymanager/offheap"
+)
+
+// The number of live objects held while measuring garbage collections
+var liveObjectCounts = []int{100_000, 1_000_000}
+
+// A linked list node. Offheap nodes are linked by references, heap nodes by
+// pointers which the garbage collector must follow.
+type offheapNode struct {
+	value int64
+	next  offheap.RefObject[offheapNode]
+}
+
+type heapNode struct {
+	value int64
+	next  *heapNode
+}
+
+func BenchmarkGC_Offheap(b *testing.B) {
+	for _, count := range liveObjectCounts {
+		b.Run(fmt.Sprintf("live=%d", count), func(b *testing.B) {
+			store := offheap.New()
+			defer store.Destroy()
+
+			head := offheap.RefObject[offheapNode]{}
+			for i := range count {
+				head = offheap.AllocObjectFrom(store, offheapNode{value: int64(i), next: head})
+			}
+
+			measureGC(b)
+			runtime.KeepAlive(head)
+		})
+	}
+}
+
+func BenchmarkGC_Heap(b *testing.B) {
+	for _, count := range liveObjectCounts {
+		b.Run(fmt.Sprintf("live=%d", count), func(b *testing.B) {
+			var head *heapNode
+			for i := range count {
+				head = &heapNode{value: int64(i), next: head}
+			}
+
+			measureGC(b)
+			runtime.KeepAlive(head)
+		})
+	}
+}
+
+// Forces a garbage collection for each iteration of b, reporting the
+// average time taken by each collection as gc-ns/op
+func measureGC(b *testing.B) {
+	runtime.GC()
+
+	total := time.Duration(0)
+	b.ResetTimer()
+	for range b.N {
+		start := time.Now()
+		runtime.GC()
+		total += time.Since(start)
+	}
+	b.ReportMetric(float64(total.Nanoseconds())/float64(b.N), "gc-ns/op")
+}
@@ -0,0 +1,63 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+package benchmarks
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/fmstephe/memorymanager/offheap"
+)
+
+// The number of objects read from by the read benchmarks, large enough that
+// most reads miss the CPU caches
+const readObjects = 1 << 20
+
+// Returns the indices of readObjects objects, in a random order
+func readOrder() []int {
+	r := rand.New(rand.NewSource(1))
+	return r.Perm(readObjects)
+}
+
+func BenchmarkRead_Offheap(b *testing.B) {
+	store := offheap.New()
+	defer store.Destroy()
+
+	refs := make([]offheap.RefObject[fortyBytes], readObjects)
+	for i := range refs {
+		refs[i] = offheap.AllocObjectFrom(store, fortyBytes{a: int64(i)})
+	}
+	order := readOrder()
+
+	sum := int64(0)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := range b.N {
+		sum += refs[order[i%readObjects]].Value().a
+	}
+	b.StopTimer()
+	sinkInt = sum
+}
+
+func BenchmarkRead_Heap(b *testing.B) {
+	ptrs := make([]*fortyBytes, readObjects)
+	for i := range ptrs {
+		ptrs[i] = &fortyBytes{a: int64(i)}
+	}
+	order := readOrder()
+
+	sum := int64(0)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := range b.N {
+		sum += ptrs[order[i%readObjects]].a
+	}
+	b.StopTimer()
+	sinkInt = sum
+}
+
+// Assigning read results to sinkInt prevents the compiler from optimising
+// the reads away
+var sinkInt int64
@@ -0,0 +1,158 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+package offheap
+
+import (
+	"fmt"
+	"sync"
+)
+
+// A BufferPool hands out fixed size byte buffers, allocated from a Store.
+// Buffers are taken from the pool with Get and returned with Put. Returned
+// buffers are kept by the pool and handed out again by later calls to Get,
+// rather than being freed to the Store.
+//
+// This is the pattern commonly used by network servers for message buffers,
+// where a buffer is needed for a short time to read or write each message.
+//
+// A buffer returned by Put must never be used again. Put invalidates the
+// reference, so a best effort is made to panic if a buffer is used, or put,
+// after it has been returned to the pool.
+//
+// A BufferPool is safe for concurrent use.
+type BufferPool struct {
+	store    *Store
+	size     int
+	capacity int
+	zero     bool
+
+	lock        sync.Mutex
+	idle        []RefSlice[byte]
+	outstanding int
+	gets        int
+	puts        int
+	allocs      int
+}
+
+// The statistics for a BufferPool
+type BufferPoolStats struct {
+	// The number of buffers taken from the pool by Get
+	Gets int
+	// The number of buffers returned to the pool by Put
+	Puts int
+	// The number of buffers allocated from the Store, i.e. the number of
+	// calls to Get which could not reuse an idle buffer
+	Allocs int
+	// The number of buffers which have been taken by Get, and not yet
+	// returned by Put
+	Outstanding int
+	// The number of buffers held by the pool, waiting to be reused
+	Idle int
+}
+
+// Returns a new BufferPool whose buffers have a length of size bytes,
+// allocated from s.
+//
+// Like all offheap allocations, the contents of buffers returned by Get are
+// arbitrary, see NewZeroingBufferPool.
+func NewBufferPool(s *Store, size int) *BufferPool {
+	if size < 1 {
+		panic(fmt.Errorf("buffer size (%d) must be at least 1", size))
+	}
+
+	return &BufferPool{
+		store:    s,
+		size:     size,
+		capacity: capacityForSlice(size),
+	}
+}
+
+// Returns a new BufferPool, like NewBufferPool, which zeroes each buffer when
+// it is returned by Put. Every buffer returned by Get contains only zeroes.
+func NewZeroingBufferPool(s *Store, size int) *BufferPool {
+	p := NewBufferPool(s, size)
+	p.zero = true
+	return p
+}
+
+// Returns the length, in bytes, of the buffers in this pool
+func (p *BufferPool) Size() int {
+	return p.size
+}
+
+// Returns a buffer with a length of Size() bytes. An idle buffer is reused if
+// there is one, otherwise a new buffer is allocated from the Store.
+func (p *BufferPool) Get() RefSlice[byte] {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.gets++
+	p.outstanding++
+
+	if last := len(p.idle) - 1; last >= 0 {
+		r := p.idle[last]
+		p.idle = p.idle[:last]
+		return r
+	}
+
+	p.allocs++
+	r := AllocSlice[byte](p.store, p.size, p.size)
+	if p.zero {
+		clear(r.Value())
+	}
+	return r
+}
+
+// Returns r to the pool. The buffer r must have been taken from this pool by
+// Get, although its length may have been changed, e.g. by Truncate. After
+// this call returns r must never be used again.
+func (p *BufferPool) Put(r RefSlice[byte]) {
+	if r.capacity != p.capacity {
+		panic(fmt.Errorf("cannot put buffer with capacity %d into pool with buffer capacity %d", r.capacity, p.capacity))
+	}
+
+	// Accessing the buffer panics if r has already been freed, or put
+	buf := r.Value()
+	if p.zero {
+		clear(buf[:p.size])
+	}
+	r = newRefSlice[byte](p.size, p.capacity, r.ref.Realloc())
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.puts++
+	p.outstanding--
+	p.idle = append(p.idle, r)
+}
+
+// Frees every idle buffer back to the Store, returning the number of buffers
+// freed. Outstanding buffers are unaffected, and can still be returned by
+// Put.
+func (p *BufferPool) Release() int {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	released := len(p.idle)
+	for _, r := range p.idle {
+		FreeSlice(p.store, r)
+	}
+	p.idle = nil
+	return released
+}
+
+// Returns the statistics for this BufferPool
+func (p *BufferPool) Stats() BufferPoolStats {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	return BufferPoolStats{
+		Gets:        p.gets,
+		Puts:        p.puts,
+		Allocs:      p.allocs,
+		Outstanding: p.outstanding,
+		Idle:        len(p.idle),
+	}
+}
@@ -0,0 +1,134 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+package offheap
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Demonstrate that buffers returned by Put are reused by Get, and that the
+// statistics track outstanding and idle buffers
+func Test_BufferPool_GetPut(t *testing.T) {
+	s := New()
+	defer func() {
+		assert.NoError(t, s.Destroy())
+	}()
+
+	p := NewBufferPool(s, 100)
+	assert.Equal(t, 100, p.Size())
+
+	bufs := []RefSlice[byte]{}
+	for i := range 10 {
+		r := p.Get()
+		require.Len(t, r.Value(), 100)
+		r.Value()[0] = byte(i)
+		bufs = append(bufs, r)
+	}
+	assert.Equal(t, BufferPoolStats{Gets: 10, Allocs: 10, Outstanding: 10}, p.Stats())
+
+	for _, r := range bufs {
+		p.Put(r)
+	}
+	assert.Equal(t, BufferPoolStats{Gets: 10, Puts: 10, Allocs: 10, Idle: 10}, p.Stats())
+
+	// Buffers are reused, without allocating
+	for range 10 {
+		r := p.Get()
+		require.Len(t, r.Value(), 100)
+		p.Put(r)
+	}
+	assert.Equal(t, BufferPoolStats{Gets: 20, Puts: 20, Allocs: 10, Idle: 10}, p.Stats())
+	assert.Equal(t, 10, s.TotalStats().Live)
+
+	assert.Equal(t, 10, p.Release())
+	assert.Equal(t, 0, p.Stats().Idle)
+	assert.Equal(t, 0, s.TotalStats().Live)
+}
+
+// Demonstrate that a buffer can't be used, or put again, after it is put
+func Test_BufferPool_PutInvalidates(t *testing.T) {
+	s := New()
+	defer func() {
+		assert.NoError(t, s.Destroy())
+	}()
+
+	p := NewBufferPool(s, 16)
+	r := p.Get()
+	p.Put(r)
+
+	assert.Panics(t, func() { r.Value() })
+	assert.Panics(t, func() { p.Put(r) })
+
+	// A buffer with a different capacity can't be put
+	other := AllocSlice[byte](s, 1000, 1000)
+	assert.Panics(t, func() { p.Put(other) })
+
+	assert.Panics(t, func() { NewBufferPool(s, 0) })
+}
+
+// Demonstrate that a zeroing pool always returns zeroed buffers, with their
+// full length, even when buffers were truncated before being put
+func Test_BufferPool_Zeroing(t *testing.T) {
+	s := New()
+	defer func() {
+		assert.NoError(t, s.Destroy())
+	}()
+
+	// Dirty some memory in the size class used by the pool
+	FreeSlice(s, AllocSliceFromSlice(s, []byte("some dirty data to be reused by the pool")))
+
+	p := NewZeroingBufferPool(s, 40)
+	for range 3 {
+		r := p.Get()
+		assert.Equal(t, make([]byte, 40), r.Value())
+		for i := range r.Value() {
+			r.Value()[i] = 0xFF
+		}
+		p.Put(Truncate(s, r, 5))
+	}
+}
+
+// Demonstrate that a BufferPool can be used concurrently
+func Test_BufferPool_Concurrent(t *testing.T) {
+	s := New()
+	defer func() {
+		assert.NoError(t, s.Destroy())
+	}()
+
+	p := NewBufferPool(s, 64)
+
+	const goroutines = 8
+	const perGoroutine = 1000
+
+	wg := sync.WaitGroup{}
+	wg.Add(goroutines)
+	for g := range goroutines {
+		go func() {
+			defer wg.Done()
+			for range perGoroutine {
+				r := p.Get()
+				for i := range r.Value() {
+					r.Value()[i] = byte(g)
+				}
+				for _, b := range r.Value() {
+					assert.Equal(t, byte(g), b)
+				}
+				p.Put(r)
+			}
+		}()
+	}
+	wg.Wait()
+
+	stats := p.Stats()
+	assert.Equal(t, goroutines*perGoroutine, stats.Gets)
+	assert.Equal(t, goroutines*perGoroutine, stats.Puts)
+	assert.Equal(t, 0, stats.Outstanding)
+	assert.LessOrEqual(t, stats.Allocs, goroutines)
+	assert.Equal(t, stats.Allocs, stats.Idle)
+}
@@ -0,0 +1,81 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+package offheap
+
+import "cmp"
+
+// Returns true if r and other refer to the same allocation, false otherwise.
+// Two nil references are equal.
+//
+// Unlike comparing the pointers returned by Value(), this method doesn't
+// access the object, and can be used on references whose object has been
+// freed. A reference to a freed object is never equal to a reference to a
+// new object allocated in the same slot.
+func (r *RefObject[T]) Equals(other RefObject[T]) bool {
+	return r.ref == other.ref
+}
+
+// Returns -1 if r is ordered before other, 1 if r is ordered after other and
+// 0 if r.Equals(other). References are ordered by the address of their
+// allocation, so references to slots in the same slab are ordered by slot.
+// The order is arbitrary, but stable for as long as the objects are
+// allocated, and can be used to sort and deduplicate references, e.g. with
+// slices.SortFunc and slices.CompactFunc. The nil reference is ordered before
+// every other reference.
+//
+// Like Equals, this method doesn't access the object.
+func (r *RefObject[T]) Compare(other RefObject[T]) int {
+	return r.ref.Compare(other.ref)
+}
+
+// Returns true if r and other refer to the same allocation, false otherwise.
+// A view created by SubSlice shares its allocation with the RefSlice it was
+// created from. Two nil references share the same, nil, allocation.
+//
+// This method doesn't access the slice, and can be used on references whose
+// slice has been freed.
+func (r *RefSlice[T]) SameAllocation(other RefSlice[T]) bool {
+	return r.ref == other.ref
+}
+
+// Returns -1 if r is ordered before other, 1 if r is ordered after other and
+// 0 if they are identical references. References are ordered by the address of
+// their allocation, then by the offset and length of the slice
+// within that allocation. See RefObject.Compare.
+func (r *RefSlice[T]) Compare(other RefSlice[T]) int {
+	if c := r.ref.Compare(other.ref); c != 0 {
+		return c
+	}
+	if c := cmp.Compare(r.offset, other.offset); c != 0 {
+		return c
+	}
+	return cmp.Compare(r.length, other.length)
+}
+
+// Returns true if r and other refer to the same allocation, false otherwise.
+// A view created by SubString shares its allocation with the RefString it was
+// created from. Two nil references share the same, nil, allocation.
+//
+// This method doesn't access the string, and can be used on references whose
+// string has been freed.
+func (r *RefString) SameAllocation(other RefString) bool {
+	return r.ref == other.ref
+}
+
+// Returns -1 if r is ordered before other, 1 if r is ordered after other and
+// 0 if they are identical references. References are ordered by the address of
+// their allocation, then by the offset and length of the string
+// within that allocation. See RefObject.Compare.
+//
+// This compares references, not the contents of the strings they refer to.
+func (r *RefString) Compare(other RefString) int {
+	if c := r.ref.Compare(other.ref); c != 0 {
+		return c
+	}
+	if c := cmp.Compare(r.offset, other.offset); c != 0 {
+		return c
+	}
+	return cmp.Compare(r.length, other.length)
+}
@@ -0,0 +1,122 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+package offheap
+
+import (
+	"math/rand"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Demonstrate that RefObjects are equal only to references to the same
+// allocation, and that freed references can still be compared
+func Test_RefObject_Equals(t *testing.T) {
+	ss := NewSized(1 << 8)
+	defer func() {
+		assert.NoError(t, ss.Destroy())
+	}()
+
+	nilRef := RefObject[MutableStruct]{}
+	assert.True(t, nilRef.Equals(RefObject[MutableStruct]{}))
+
+	ref1 := AllocObject[MutableStruct](ss)
+	ref2 := AllocObject[MutableStruct](ss)
+	copied := ref1
+
+	assert.True(t, ref1.Equals(copied))
+	assert.False(t, ref1.Equals(ref2))
+	assert.False(t, ref1.Equals(nilRef))
+	assert.Equal(t, 0, ref1.Compare(copied))
+	assert.Equal(t, -1, nilRef.Compare(ref1))
+	assert.Equal(t, 1, ref1.Compare(nilRef))
+	assert.Equal(t, -ref1.Compare(ref2), ref2.Compare(ref1))
+
+	// A freed reference isn't equal to a new reference to the same slot
+	FreeObject(ss, ref1)
+	ref3 := AllocObject[MutableStruct](ss)
+	assert.Equal(t, 1, StatsForType[MutableStruct](ss).Reused)
+	assert.True(t, ref1.Equals(copied))
+	assert.False(t, ref1.Equals(ref3))
+	assert.NotEqual(t, 0, ref1.Compare(ref3))
+}
+
+// Demonstrate that RefObjects can be sorted and deduplicated
+func Test_RefObject_SortAndCompact(t *testing.T) {
+	ss := NewSized(1 << 8)
+	defer func() {
+		assert.NoError(t, ss.Destroy())
+	}()
+
+	unique := []RefObject[MutableStruct]{}
+	for range 100 {
+		unique = append(unique, AllocObject[MutableStruct](ss))
+	}
+
+	// Each reference appears three times, in a random order
+	refs := slices.Concat(unique, unique, unique)
+	rand.New(rand.NewSource(1)).Shuffle(len(refs), func(i, j int) {
+		refs[i], refs[j] = refs[j], refs[i]
+	})
+
+	compare := func(a, b RefObject[MutableStruct]) int { return a.Compare(b) }
+	equals := func(a, b RefObject[MutableStruct]) bool { return a.Equals(b) }
+
+	slices.SortFunc(refs, compare)
+	refs = slices.CompactFunc(refs, equals)
+
+	slices.SortFunc(unique, compare)
+	assert.Equal(t, unique, refs)
+}
+
+// Demonstrate that views of a RefSlice share its allocation, but are ordered
+// by their position in the allocation
+func Test_RefSlice_SameAllocation(t *testing.T) {
+	ss := NewSized(1 << 8)
+	defer func() {
+		assert.NoError(t, ss.Destroy())
+	}()
+
+	slice := ConcatSlices[int](ss, []int{1, 2, 3, 4, 5})
+	other := ConcatSlices[int](ss, []int{1, 2, 3, 4, 5})
+	view1 := SubSlice(ss, slice, 1, 3)
+	view2 := SubSlice(ss, slice, 2, 3)
+
+	assert.True(t, slice.SameAllocation(view1))
+	assert.True(t, view1.SameAllocation(view2))
+	assert.False(t, slice.SameAllocation(other))
+
+	assert.Equal(t, 0, slice.Compare(slice))
+	assert.Equal(t, -1, slice.Compare(view1))
+	assert.Equal(t, -1, view1.Compare(view2))
+	assert.Equal(t, 1, view2.Compare(view1))
+
+	// A freed slice can still be compared
+	FreeSlice(ss, slice)
+	assert.True(t, slice.SameAllocation(view1))
+}
+
+// Demonstrate that views of a RefString share its allocation, and that
+// strings are compared by reference not by content
+func Test_RefString_SameAllocation(t *testing.T) {
+	ss := NewSized(1 << 8)
+	defer func() {
+		assert.NoError(t, ss.Destroy())
+	}()
+
+	str := AllocStringFromString(ss, "hello world")
+	other := AllocStringFromString(ss, "hello world")
+	view := SubString(ss, str, 6, 11)
+
+	assert.True(t, str.SameAllocation(view))
+	assert.False(t, str.SameAllocation(other))
+	assert.NotEqual(t, 0, str.Compare(other))
+	assert.Equal(t, -1, str.Compare(view))
+
+	nilStr := RefString{}
+	assert.True(t, nilStr.SameAllocation(RefString{}))
+	assert.Equal(t, -1, nilStr.Compare(str))
+}
@@ -0,0 +1,71 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+package offheap
+
+// Returns a RefSlice[byte] which reuses the allocation of r, without copying
+// the string's bytes. If r is nil then a nil RefSlice is returned.
+//
+// Ownership of the allocation is handed over to the returned RefSlice. After
+// this call returns r must never be used again, a best effort has been made
+// to panic if it is. The returned RefSlice is freed with FreeSlice, and can
+// be appended to like any other RefSlice.
+//
+// A RefString created by SubString, or which is pinned, can't be converted,
+// this function will panic. Use CloneSlice, or convert the original
+// RefString, instead.
+func AsBytes(s *Store, r RefString) RefSlice[byte] {
+	if r.IsNil() {
+		return RefSlice[byte]{}
+	}
+	if r.view {
+		panic("cannot convert a RefString created by SubString")
+	}
+	if !r.ref.IsLive() {
+		panic("cannot convert a freed RefString")
+	}
+
+	// The slice's capacity is the whole of the string's size class, this
+	// ensures that FreeSlice frees it to the same size class
+	capacity := s.classSize(s.sizeIndex(r.length))
+	return newRefSlice[byte](r.length, capacity, r.ref.Realloc())
+}
+
+// Returns a RefString which reuses the allocation of r, without copying the
+// slice's bytes. If r is nil then a nil RefString is returned.
+//
+// Ownership of the allocation is handed over to the returned RefString.
+// After this call returns r must never be used again, a best effort has been
+// made to panic if it is. The returned RefString is freed with FreeString.
+//
+// A RefString must be allocated in the size class of its length. If the
+// length of r is too small for the size class of its capacity the bytes are
+// copied into a new, correctly sized, allocation and r is freed. This can
+// only happen if r was allocated with a capacity larger than its length.
+//
+// A RefSlice created by SubSlice, or which is pinned, can't be converted,
+// this function will panic.
+func AsString(s *Store, r RefSlice[byte]) RefString {
+	if r.IsNil() {
+		return RefString{}
+	}
+	if r.view {
+		panic("cannot convert a RefSlice created by SubSlice")
+	}
+	if !r.ref.IsLive() {
+		panic("cannot convert a freed RefSlice")
+	}
+
+	oldIdx := sliceIndex[byte](s, r.capacity)
+	if s.sizeIndex(r.length) == oldIdx {
+		return newRefString(r.length, r.ref.Realloc())
+	}
+
+	if r.ref.IsPinned() {
+		panic("cannot convert a pinned RefSlice")
+	}
+	sRef := AllocStringFromBytes(s, r.Value())
+	s.free(oldIdx, r.ref)
+	return sRef
+}
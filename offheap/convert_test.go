@@ -0,0 +1,103 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+package offheap
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Demonstrate that a RefString can be converted to a RefSlice[byte], and
+// back, without a new allocation
+func Test_Convert_RoundTrip(t *testing.T) {
+	for _, ss := range []*Store{New(), NewWithSizeClasses(1<<8, []int{16, 48, 128})} {
+		func() {
+			defer func() {
+				assert.NoError(t, ss.Destroy())
+			}()
+
+			for _, str := range []string{"", "a", "hello", "a string which is more than forty bytes long"} {
+				strRef := AllocStringFromString(ss, str)
+				allocs := ss.TotalStats().Allocs
+
+				bytesRef := AsBytes(ss, strRef)
+				assert.Equal(t, []byte(str), bytesRef.Value())
+				assert.Panics(t, func() { strRef.Value() })
+
+				// The bytes can be modified and appended to
+				bytesRef = Append(ss, bytesRef, '!')
+				bytesRef.Value()[0] = 'X'
+
+				strRef = AsString(ss, bytesRef)
+				expected := []byte(str + "!")
+				expected[0] = 'X'
+				assert.Equal(t, string(expected), strRef.Value())
+				assert.Panics(t, func() { bytesRef.Value() })
+
+				FreeString(ss, strRef)
+				// Append may have needed a larger allocation,
+				// which AsString may have copied into a smaller
+				// one, nothing else is allocated
+				assert.LessOrEqual(t, ss.TotalStats().Allocs-allocs, 2)
+				assert.Equal(t, 0, ss.TotalStats().Live)
+			}
+		}()
+	}
+}
+
+// Demonstrate that converting a RefSlice[byte] whose capacity is much larger
+// than its length copies the bytes into a correctly sized allocation
+func Test_Convert_AsString_LargeCapacity(t *testing.T) {
+	ss := NewSized(1 << 12)
+	defer func() {
+		assert.NoError(t, ss.Destroy())
+	}()
+
+	bytesRef := AllocSlice[byte](ss, 3, 1024)
+	copy(bytesRef.Value(), "abc")
+
+	strRef := AsString(ss, bytesRef)
+	assert.Equal(t, "abc", strRef.Value())
+	assert.NotEqual(t, bytesRef.ref, strRef.ref)
+	assert.Equal(t, 1, ss.TotalStats().Live)
+
+	FreeString(ss, strRef)
+	assert.Equal(t, 0, ss.TotalStats().Live)
+}
+
+// Demonstrate that nil references convert to nil references, and that views
+// and freed references can't be converted
+func Test_Convert_Invalid(t *testing.T) {
+	ss := NewSized(1 << 8)
+	defer func() {
+		assert.NoError(t, ss.Destroy())
+	}()
+
+	nilBytes := AsBytes(ss, RefString{})
+	assert.True(t, nilBytes.IsNil())
+	nilStr := AsString(ss, RefSlice[byte]{})
+	assert.True(t, nilStr.IsNil())
+
+	strRef := AllocStringFromString(ss, "hello")
+	strView := SubString(ss, strRef, 1, 3)
+	assert.Panics(t, func() { AsBytes(ss, strView) })
+
+	bytesRef := AllocSlice[byte](ss, 5, 5)
+	bytesView := SubSlice(ss, bytesRef, 1, 3)
+	assert.Panics(t, func() { AsString(ss, bytesView) })
+
+	FreeString(ss, strRef)
+	assert.Panics(t, func() { AsBytes(ss, strRef) })
+	FreeSlice(ss, bytesRef)
+	assert.Panics(t, func() { AsString(ss, bytesRef) })
+
+	// Converting a pinned reference panics
+	pinned := AllocStringFromString(ss, "pinned")
+	pinned.Pin()
+	assert.Panics(t, func() { AsBytes(ss, pinned) })
+	pinned.Unpin()
+	FreeString(ss, pinned)
+}
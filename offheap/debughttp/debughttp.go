@@ -0,0 +1,245 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+// The debughttp package serves the statistics of an offheap.Store over HTTP,
+// so that the memory used by a running service can be inspected without
+// writing any glue code.
+//
+//	store := offheap.New()
+//	http.Handle("/debug/offheap/", debughttp.NewHandler(store))
+//
+// The handler serves a simple HTML page, at the path it is mounted on, and
+// the following JSON endpoints below that path
+//
+//	stats         - the statistics for the Store, see metrics.Snapshot
+//	configs       - the allocation configuration of each size class
+//	fragmentation - the utilisation, fragmentation and rounding waste of
+//	                each size class in use, see offheap.Store.Report
+//	leaks         - the allocations which have not been freed since the
+//	                Store started recording, see offheap.Recording.Outstanding
+//
+// Leaks can only be reported while the Store is recording, see
+// offheap.Store.StartRecording. Otherwise the leaks endpoint responds with
+// 404 Not Found, and the HTML page omits leaks.
+//
+// Every response is generated when it is requested, so its values are always
+// current.
+package debughttp
+
+import (
+	"encoding/json"
+	"html/template"
+	"net/http"
+	"path"
+
+	"github.com/fmstephe/memorymanager/offheap"
+	"github.com/fmstephe/memorymanager/offheap/metrics"
+)
+
+// The allocation configuration of a single size class
+type AllocConfig struct {
+	Size              int  `json:"size"`
+	ObjectSize        int  `json:"object_size"`
+	ObjectsPerSlab    int  `json:"objects_per_slab"`
+	MetadataSize      int  `json:"metadata_size"`
+	TotalObjectSize   int  `json:"total_object_size"`
+	TotalMetadataSize int  `json:"total_metadata_size"`
+	TotalSlabSize     int  `json:"total_slab_size"`
+	GuardSize         int  `json:"guard_size"`
+	HugePages         bool `json:"huge_pages"`
+}
+
+// The fragmentation of a single size class, see offheap.ClassReport
+type Fragmentation struct {
+	Size          int     `json:"size"`
+	Live          int     `json:"live"`
+	Slabs         int     `json:"slabs"`
+	MappedBytes   int     `json:"mapped_bytes"`
+	LiveBytes     int     `json:"live_bytes"`
+	Utilization   float64 `json:"utilization_percent"`
+	Fragmentation float64 `json:"fragmentation_percent"`
+	Waste         float64 `json:"waste_percent"`
+}
+
+// A recorded allocation which has not been freed, see
+// offheap.Recording.Outstanding
+type Leak struct {
+	// The allocation size of the allocation's size class
+	Size      int   `json:"size"`
+	SizeClass int   `json:"size_class"`
+	Pool      int   `json:"pool"`
+	Slot      int   `json:"slot"`
+	Gen       uint8 `json:"gen"`
+	Requested int   `json:"requested"`
+}
+
+type handler struct {
+	store *offheap.Store
+}
+
+// Returns an http.Handler which serves the statistics of store. The handler
+// selects what to serve using the last element of the request's path, so it
+// can be mounted under any path without using http.StripPrefix.
+func NewHandler(store *offheap.Store) http.Handler {
+	return &handler{store: store}
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	switch path.Base(r.URL.Path) {
+	case "stats":
+		writeJSON(w, metrics.Snapshot(h.store))
+	case "configs":
+		writeJSON(w, Configs(h.store))
+	case "fragmentation":
+		writeJSON(w, Fragmentations(h.store))
+	case "leaks":
+		leaks, ok := Leaks(h.store)
+		if !ok {
+			http.Error(w, "store is not recording, leaks can't be reported", http.StatusNotFound)
+			return
+		}
+		writeJSON(w, leaks)
+	default:
+		h.writeHTML(w)
+	}
+}
+
+// Returns the allocation configuration of each size class of store
+func Configs(store *offheap.Store) []AllocConfig {
+	sizes := store.SizeClasses()
+	configs := []AllocConfig{}
+	for i, conf := range store.AllocConfigs() {
+		configs = append(configs, AllocConfig{
+			Size:              sizes[i],
+			ObjectSize:        int(conf.ObjectSize),
+			ObjectsPerSlab:    int(conf.ObjectsPerSlab),
+			MetadataSize:      int(conf.MetadataSize),
+			TotalObjectSize:   int(conf.TotalObjectSize),
+			TotalMetadataSize: int(conf.TotalMetadataSize),
+			TotalSlabSize:     int(conf.TotalSlabSize),
+			GuardSize:         int(conf.GuardSize),
+			HugePages:         conf.HugePages,
+		})
+	}
+	return configs
+}
+
+// Returns the fragmentation of each size class of store which has been
+// allocated from
+func Fragmentations(store *offheap.Store) []Fragmentation {
+	fragmentations := []Fragmentation{}
+	for _, report := range store.Report() {
+		fragmentations = append(fragmentations, Fragmentation(report))
+	}
+	return fragmentations
+}
+
+// Returns the allocations which have not been freed since store started
+// recording. If store is not recording false is returned.
+func Leaks(store *offheap.Store) ([]Leak, bool) {
+	rec := store.Recording()
+	if rec == nil {
+		return nil, false
+	}
+
+	sizes := store.SizeClasses()
+	leaks := []Leak{}
+	for _, event := range rec.Outstanding() {
+		leaks = append(leaks, Leak{
+			Size:      sizes[event.SizeClass],
+			SizeClass: event.SizeClass,
+			Pool:      event.Pool,
+			Slot:      event.Slot,
+			Gen:       event.Gen,
+			Requested: event.Requested,
+		})
+	}
+	return leaks, true
+}
+
+func writeJSON(w http.ResponseWriter, value any) {
+	data, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(data)
+}
+
+// The values rendered by page
+type pageData struct {
+	Stats          metrics.StoreStats
+	Fragmentations []Fragmentation
+	Configs        []AllocConfig
+	Recording      bool
+	Leaks          []Leak
+}
+
+func (h *handler) writeHTML(w http.ResponseWriter) {
+	data := pageData{
+		Stats:          metrics.Snapshot(h.store),
+		Fragmentations: Fragmentations(h.store),
+		Configs:        Configs(h.store),
+	}
+	data.Leaks, data.Recording = Leaks(h.store)
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := page.Execute(w, data); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+var page = template.Must(template.New("page").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<title>offheap</title>
+<style>
+table { border-collapse: collapse; margin-bottom: 1em; }
+th, td { border: 1px solid #ccc; padding: 2px 8px; text-align: right; }
+</style>
+</head>
+<body>
+<h1>offheap</h1>
+<p>JSON: <a href="stats">stats</a> <a href="configs">configs</a> <a href="fragmentation">fragmentation</a>{{if .Recording}} <a href="leaks">leaks</a>{{end}}</p>
+
+<h2>Totals</h2>
+<table>
+<tr><th>live</th><th>allocs</th><th>frees</th><th>reused</th><th>slabs</th><th>mapped</th><th>live-bytes</th><th>free-bytes</th><th>quarantined</th><th>cached</th></tr>
+{{with .Stats.Total}}<tr><td>{{.Live}}</td><td>{{.Allocs}}</td><td>{{.Frees}}</td><td>{{.Reused}}</td><td>{{.Slabs}}</td><td>{{.MappedBytes}}</td><td>{{.LiveBytes}}</td><td>{{.FreeBytes}}</td><td>{{.Quarantined}}</td><td>{{.Cached}}</td></tr>{{end}}
+</table>
+
+<h2>Fragmentation</h2>
+<table>
+<tr><th>size</th><th>live</th><th>slabs</th><th>mapped</th><th>live-bytes</th><th>util%</th><th>frag%</th><th>waste%</th></tr>
+{{range .Fragmentations}}<tr><td>{{.Size}}</td><td>{{.Live}}</td><td>{{.Slabs}}</td><td>{{.MappedBytes}}</td><td>{{.LiveBytes}}</td><td>{{printf "%.1f" .Utilization}}</td><td>{{printf "%.1f" .Fragmentation}}</td><td>{{printf "%.1f" .Waste}}</td></tr>
+{{end}}</table>
+
+{{if .Stats.Tags}}<h2>Tags</h2>
+<table>
+<tr><th>tag</th><th>live</th><th>allocs</th><th>frees</th><th>live-bytes</th><th>requested-bytes</th></tr>
+{{range .Stats.Tags}}<tr><td>{{.Tag}}</td><td>{{.Live}}</td><td>{{.Allocs}}</td><td>{{.Frees}}</td><td>{{.LiveBytes}}</td><td>{{.RequestedBytes}}</td></tr>
+{{end}}</table>
+{{end}}
+{{if .Recording}}<h2>Leaks</h2>
+<p>{{len .Leaks}} allocations have not been freed since recording started.</p>
+<table>
+<tr><th>size</th><th>pool</th><th>slot</th><th>gen</th><th>requested</th></tr>
+{{range .Leaks}}<tr><td>{{.Size}}</td><td>{{.Pool}}</td><td>{{.Slot}}</td><td>{{.Gen}}</td><td>{{.Requested}}</td></tr>
+{{end}}</table>
+{{end}}
+<h2>Size Classes</h2>
+<table>
+<tr><th>size</th><th>object-size</th><th>objects/slab</th><th>slab-size</th><th>guard</th><th>huge-pages</th></tr>
+{{range .Configs}}<tr><td>{{.Size}}</td><td>{{.ObjectSize}}</td><td>{{.ObjectsPerSlab}}</td><td>{{.TotalSlabSize}}</td><td>{{.GuardSize}}</td><td>{{.HugePages}}</td></tr>
+{{end}}</table>
+</body>
+</html>
+`))
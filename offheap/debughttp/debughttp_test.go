@@ -0,0 +1,152 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+package debughttp
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/fmstephe/memorymanager/offheap"
+	"github.com/fmstephe/memorymanager/offheap/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newServer(t *testing.T, store *offheap.Store) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.Handle("/debug/offheap/", NewHandler(store))
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func get(t *testing.T, server *httptest.Server, path string) (*http.Response, string) {
+	resp, err := http.Get(server.URL + "/debug/offheap/" + path)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return resp, string(body)
+}
+
+func getJSON(t *testing.T, server *httptest.Server, path string, value any) {
+	resp, body := get(t, server, path)
+	require.Equal(t, http.StatusOK, resp.StatusCode, body)
+	assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+	require.NoError(t, json.Unmarshal([]byte(body), value))
+}
+
+// Demonstrate that the JSON endpoints report the current state of the store
+func TestHandler_JSON(t *testing.T) {
+	store := offheap.NewSized(1 << 8)
+	defer func() {
+		assert.NoError(t, store.Destroy())
+	}()
+	server := newServer(t, store)
+
+	refs := []offheap.RefObject[int64]{}
+	for range 4 {
+		refs = append(refs, offheap.AllocObject[int64](store))
+	}
+	offheap.FreeObject(store, refs[0])
+
+	stats := metrics.StoreStats{}
+	getJSON(t, server, "stats", &stats)
+	assert.Equal(t, metrics.Snapshot(store), stats)
+	assert.Equal(t, 3, stats.Total.Live)
+
+	configs := []AllocConfig{}
+	getJSON(t, server, "configs", &configs)
+	assert.Len(t, configs, len(store.SizeClasses()))
+	assert.Equal(t, 8, configs[3].Size)
+	assert.Equal(t, 8, configs[3].ObjectSize)
+
+	fragmentations := []Fragmentation{}
+	getJSON(t, server, "fragmentation", &fragmentations)
+	require.Len(t, fragmentations, 1)
+	assert.Equal(t, 8, fragmentations[0].Size)
+	assert.Equal(t, 3, fragmentations[0].Live)
+	assert.Equal(t, 25.0, fragmentations[0].Fragmentation)
+}
+
+// Demonstrate that leaks are only reported while the store is recording
+func TestHandler_Leaks(t *testing.T) {
+	store := offheap.NewSized(1 << 8)
+	defer func() {
+		assert.NoError(t, store.Destroy())
+	}()
+	server := newServer(t, store)
+
+	resp, _ := get(t, server, "leaks")
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	store.StartRecording()
+	freed := offheap.AllocObject[int64](store)
+	offheap.AllocStringFromString(store, "leaked")
+	offheap.FreeObject(store, freed)
+
+	leaks := []Leak{}
+	getJSON(t, server, "leaks", &leaks)
+	require.Len(t, leaks, 1)
+	assert.Equal(t, 8, leaks[0].Size)
+	assert.Equal(t, len("leaked"), leaks[0].Requested)
+
+	_, body := get(t, server, "")
+	assert.Contains(t, body, "1 allocations have not been freed")
+
+	store.StopRecording()
+	_, body = get(t, server, "")
+	assert.NotContains(t, body, "Leaks")
+}
+
+// Show that a recorded slice which was appended to in place, and then freed,
+// is not reported as a leak
+func TestHandler_Leaks_Append(t *testing.T) {
+	store := offheap.NewSized(1 << 8)
+	defer func() {
+		assert.NoError(t, store.Destroy())
+	}()
+	server := newServer(t, store)
+
+	store.StartRecording()
+	slice := offheap.AllocSlice[int64](store, 1, 4)
+	slice = offheap.Append(store, slice, 2)
+	offheap.FreeSlice(store, slice)
+
+	leaks := []Leak{}
+	getJSON(t, server, "leaks", &leaks)
+	assert.Empty(t, leaks)
+
+	_, body := get(t, server, "")
+	assert.Contains(t, body, "0 allocations have not been freed")
+}
+
+// Demonstrate that the HTML page is served at the mounted path, and only GET
+// requests are served
+func TestHandler_HTML(t *testing.T) {
+	store := offheap.NewSized(1 << 8)
+	defer func() {
+		assert.NoError(t, store.Destroy())
+	}()
+	server := newServer(t, store)
+
+	offheap.AllocObjectTagged[int64](store, 7)
+
+	resp, body := get(t, server, "")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/html; charset=utf-8", resp.Header.Get("Content-Type"))
+	assert.Contains(t, body, "<h2>Fragmentation</h2>")
+	assert.Contains(t, body, "<h2>Tags</h2>")
+	assert.Contains(t, body, `<a href="stats">`)
+
+	resp, err := http.Post(server.URL+"/debug/offheap/stats", "text/plain", strings.NewReader(""))
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+}
@@ -93,6 +93,58 @@
 // allocating and retrieving objects managed by a Store has the same guarantees
 // and limitations that conventionally allocated Go objects have.
 //
+// # Alignment
+//
+// Every allocation is naturally aligned for its type. An object of type T is
+// aligned to at least unsafe.Alignof(T), and the elements of a RefSlice[T]
+// are aligned just like the elements of a []T. This holds for Stores with
+// custom size classes too, because every size class is either a power of two
+// or a multiple of 8.
+//
+// Allocations which need a stronger alignment, such as a cache line for
+// SIMD kernels or a page for O_DIRECT IO buffers, can be made with
+// AllocAligned.
+//
+// Small objects written by different goroutines can suffer from false
+// sharing when they share a cache line. A Store created by
+// NewWithCacheLinePadding places every small allocation on its own cache
+// line. Individual allocations can be padded with AllocAligned(s,
+// CacheLineSize).
+//
+// # Encoding References
+//
+// References are encoded by encoding/json and encoding/gob as their handle,
+// see RefObject.Handle. This allows a Go value containing references, such
+// as an index of the allocations in a Store, to be persisted and loaded again.
+//
+// A decoded reference is unresolved, it holds a handle but doesn't yet refer
+// to an allocation. Any use of an unresolved reference panics, as if its
+// allocation had been freed. Each decoded reference must be resolved against
+// the Store which allocated it, or a clone of that Store, using Rehydrate. For
+// example
+//
+//	type Index struct {
+//		Names map[string]offheap.RefString
+//		Root  offheap.RefObject[Node]
+//	}
+//
+//	index := Index{}
+//	err := json.Unmarshal(data, &index)
+//	...
+//	for name, ref := range index.Names {
+//		if err := ref.Rehydrate(store); err != nil {
+//			return err
+//		}
+//		index.Names[name] = ref
+//	}
+//	if err := index.Root.Rehydrate(store); err != nil {
+//		return err
+//	}
+//
+// Rehydrate validates each handle, like ResolveObjectHandle, so a handle
+// whose allocation has since been freed is rejected. Views, created by
+// SubSlice or SubString, can't be encoded.
+//
 // # Concurrency Guarantees
 //
 // 1: Independent Alloc/Free Safety
@@ -0,0 +1,140 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+// The encode package provides a compact binary encoding for offheap objects,
+// slices and strings. Values are written directly from, and read directly
+// into, their offheap allocations without creating any intermediate values on
+// the Go heap.
+//
+// Because offheap types contain no pointers their in-memory representation
+// can be copied byte for byte. The wire format is
+//
+//	object: the raw bytes of the object
+//	slice:  the number of elements, as a little endian uint64, followed by the
+//	        raw bytes of each element
+//	string: the length, as a little endian uint64, followed by the bytes of
+//	        the string
+//
+// Because the raw bytes of values are written, values can only be decoded on
+// a machine with the same byte order, and by a program where the type has the
+// same layout.
+//
+// Any RefObject, RefSlice or RefString fields inside an encoded value are
+// copied as they are. They refer to allocations in the Store the value was
+// encoded from, and are not meaningful when decoded in another Store.
+package encode
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"unsafe"
+
+	"github.com/fmstephe/memorymanager/offheap"
+)
+
+// The largest slice or string length which will be decoded
+const maxDecodeLength = 1 << 40
+
+// Writes the object referenced by r to w.
+func EncodeObject[T any](w io.Writer, r offheap.RefObject[T]) error {
+	_, err := w.Write(objectBytes(r.Value()))
+	return err
+}
+
+// Reads an object written by EncodeObject from r into a new allocation in s.
+func DecodeObject[T any](s *offheap.Store, r io.Reader) (offheap.RefObject[T], error) {
+	ref := offheap.AllocObject[T](s)
+	if _, err := io.ReadFull(r, objectBytes(ref.Value())); err != nil {
+		offheap.FreeObject(s, ref)
+		return offheap.RefObject[T]{}, err
+	}
+	return ref, nil
+}
+
+// Writes the slice referenced by r to w. A nil RefSlice is encoded as an
+// empty slice.
+func EncodeSlice[T any](w io.Writer, r offheap.RefSlice[T]) error {
+	if r.IsNil() {
+		return writeLength(w, 0)
+	}
+
+	slice := r.Value()
+	if err := writeLength(w, len(slice)); err != nil {
+		return err
+	}
+	_, err := w.Write(sliceBytes(slice))
+	return err
+}
+
+// Reads a slice written by EncodeSlice from r into a new allocation in s.
+func DecodeSlice[T any](s *offheap.Store, r io.Reader) (offheap.RefSlice[T], error) {
+	length, err := readLength(r)
+	if err != nil {
+		return offheap.RefSlice[T]{}, err
+	}
+
+	ref := offheap.AllocSlice[T](s, length, length)
+	if _, err := io.ReadFull(r, sliceBytes(ref.Value())); err != nil {
+		offheap.FreeSlice(s, ref)
+		return offheap.RefSlice[T]{}, err
+	}
+	return ref, nil
+}
+
+// Writes the string referenced by r to w. A nil RefString is encoded as an
+// empty string.
+func EncodeString(w io.Writer, r offheap.RefString) error {
+	str := r.Value()
+	if err := writeLength(w, len(str)); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, str)
+	return err
+}
+
+// Reads a string written by EncodeString from r into a new allocation in s.
+func DecodeString(s *offheap.Store, r io.Reader) (offheap.RefString, error) {
+	length, err := readLength(r)
+	if err != nil {
+		return offheap.RefString{}, err
+	}
+
+	// A RefString's allocation can't be written to directly, so the string
+	// is read into an offheap byte slice and then copied
+	buf := offheap.AllocSlice[byte](s, length, length)
+	defer offheap.FreeSlice(s, buf)
+	if _, err := io.ReadFull(r, buf.Value()); err != nil {
+		return offheap.RefString{}, err
+	}
+	return offheap.AllocStringFromBytes(s, buf.Value()), nil
+}
+
+func writeLength(w io.Writer, length int) error {
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], uint64(length))
+	_, err := w.Write(buf[:])
+	return err
+}
+
+func readLength(r io.Reader) (int, error) {
+	var buf [8]byte
+	if _, err := io.ReadFull(r, buf[:]); err != nil {
+		return 0, err
+	}
+	length := binary.LittleEndian.Uint64(buf[:])
+	if length > maxDecodeLength {
+		return 0, fmt.Errorf("encoded length %d is too large", length)
+	}
+	return int(length), nil
+}
+
+func objectBytes[T any](value *T) []byte {
+	return unsafe.Slice((*byte)(unsafe.Pointer(value)), unsafe.Sizeof(*value))
+}
+
+func sliceBytes[T any](slice []T) []byte {
+	var zero T
+	return unsafe.Slice((*byte)(unsafe.Pointer(unsafe.SliceData(slice))), len(slice)*int(unsafe.Sizeof(zero)))
+}
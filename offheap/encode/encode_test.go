@@ -0,0 +1,121 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+package encode
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/fmstephe/memorymanager/offheap"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type record struct {
+	id    int64
+	value float64
+	flags [3]byte
+}
+
+// Demonstrate that objects, slices and strings can be encoded from one store
+// and decoded into another
+func TestEncodeDecode(t *testing.T) {
+	from := offheap.NewSized(1 << 8)
+	to := offheap.NewSized(1 << 8)
+	defer func() {
+		assert.NoError(t, from.Destroy())
+		assert.NoError(t, to.Destroy())
+	}()
+
+	obj := offheap.AllocObjectFrom(from, record{id: 1, value: 2.5, flags: [3]byte{1, 2, 3}})
+	slice := offheap.ConcatSlices(from, []record{{id: 1}, {id: 2, value: 1.5}, {id: 3, flags: [3]byte{9, 9, 9}}})
+	str := offheap.AllocStringFromString(from, "an encoded string")
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, EncodeObject(buf, obj))
+	require.NoError(t, EncodeSlice(buf, slice))
+	require.NoError(t, EncodeString(buf, str))
+	require.NoError(t, EncodeSlice(buf, offheap.RefSlice[record]{}))
+	require.NoError(t, EncodeString(buf, offheap.RefString{}))
+
+	newObj, err := DecodeObject[record](to, buf)
+	require.NoError(t, err)
+	assert.Equal(t, *obj.Value(), *newObj.Value())
+
+	newSlice, err := DecodeSlice[record](to, buf)
+	require.NoError(t, err)
+	assert.Equal(t, slice.Value(), newSlice.Value())
+
+	newStr, err := DecodeString(to, buf)
+	require.NoError(t, err)
+	assert.Equal(t, "an encoded string", newStr.Value())
+
+	emptySlice, err := DecodeSlice[record](to, buf)
+	require.NoError(t, err)
+	assert.Empty(t, emptySlice.Value())
+
+	emptyStr, err := DecodeString(to, buf)
+	require.NoError(t, err)
+	assert.Equal(t, "", emptyStr.Value())
+
+	assert.Equal(t, 0, buf.Len())
+}
+
+// Demonstrate that truncated input is reported as an error, and that nothing
+// is leaked
+func TestDecode_Truncated(t *testing.T) {
+	s := offheap.NewSized(1 << 8)
+	defer func() {
+		assert.NoError(t, s.Destroy())
+	}()
+
+	buf := &bytes.Buffer{}
+	slice := offheap.ConcatSlices(s, []int64{1, 2, 3, 4})
+	require.NoError(t, EncodeSlice(buf, slice))
+	encoded := buf.Bytes()
+	offheap.FreeSlice(s, slice)
+
+	for i := range len(encoded) {
+		_, err := DecodeSlice[int64](s, bytes.NewReader(encoded[:i]))
+		assert.Error(t, err)
+	}
+
+	str := offheap.AllocStringFromString(s, "truncated")
+	buf.Reset()
+	require.NoError(t, EncodeString(buf, str))
+	_, err := DecodeString(s, bytes.NewReader(buf.Bytes()[:10]))
+	assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+	offheap.FreeString(s, str)
+
+	_, err = DecodeObject[record](s, bytes.NewReader([]byte{1, 2, 3}))
+	assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+
+	// A corrupt length is rejected without allocating
+	_, err = DecodeSlice[int64](s, bytes.NewReader([]byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}))
+	assert.Error(t, err)
+
+	for _, stats := range s.Stats() {
+		assert.Equal(t, 0, stats.Live)
+	}
+}
+
+// Demonstrate that a value can be encoded into, and decoded from, offheap
+// memory
+func TestEncodeDecode_Offheap(t *testing.T) {
+	s := offheap.NewSized(1 << 8)
+	defer func() {
+		assert.NoError(t, s.Destroy())
+	}()
+
+	slice := offheap.ConcatSlices(s, []record{{id: 1}, {id: 2}})
+	w := offheap.NewSliceWriter(s, offheap.RefSlice[byte]{})
+	require.NoError(t, EncodeSlice(w, slice))
+
+	encoded := w.RefSlice()
+	decoded, err := DecodeSlice[record](s, offheap.NewSliceReader(encoded))
+	require.NoError(t, err)
+	assert.Equal(t, slice.Value(), decoded.Value())
+}
@@ -0,0 +1,39 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+package offheap
+
+import (
+	"errors"
+
+	"github.com/fmstephe/memorymanager/offheap/internal/pointerstore"
+)
+
+// The errors returned by the non-panicking Try functions, such as
+// RefObject.TryValue and TryFreeObject. The panics raised when a Store, or a
+// reference, is misused are errors wrapping these too, so a recovered panic
+// can be tested with errors.Is.
+var (
+	// The reference is nil
+	ErrNilReference = errors.New("reference is nil")
+	// The allocation referenced has been freed
+	ErrFreedReference = pointerstore.ErrFreed
+	// The allocation referenced has been freed, and allocated again
+	ErrStaleReference = pointerstore.ErrStale
+	// The allocation referenced is pinned, see RefObject.Pin
+	ErrPinned = pointerstore.ErrPinned
+	// The reference is a view, created by SubSlice or SubString, and can't
+	// be freed
+	ErrView = errors.New("reference is a view")
+	// The Store has been sealed, see Store.Seal
+	ErrSealed = pointerstore.ErrSealed
+	// An allocation would be larger than the largest allowed allocation
+	ErrSizeLimit = pointerstore.ErrSizeLimit
+	// The reference was allocated by a different store, see
+	// TypedStore.TryFree
+	ErrForeignReference = pointerstore.ErrForeign
+	// A decoded handle doesn't identify a live allocation, see
+	// RefObject.Rehydrate
+	ErrInvalidHandle = errors.New("handle does not identify a live allocation")
+)
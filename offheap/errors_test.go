@@ -0,0 +1,107 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+package offheap
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Demonstrate that TryValue and the TryFree functions return errors where
+// Value and the Free functions would panic
+func Test_TryValue_TryFree(t *testing.T) {
+	s := New()
+	defer func() {
+		assert.NoError(t, s.Destroy())
+	}()
+
+	obj := AllocObjectFrom(s, MutableStruct{Field: 3})
+	slice := AllocSliceFromSlice(s, []int{1, 2, 3})
+	str := AllocStringFromString(s, "try")
+
+	value, err := obj.TryValue()
+	require.NoError(t, err)
+	assert.Equal(t, 3, value.Field)
+	sliceValue, err := slice.TryValue()
+	require.NoError(t, err)
+	assert.Equal(t, []int{1, 2, 3}, sliceValue)
+	strValue, err := str.TryValue()
+	require.NoError(t, err)
+	assert.Equal(t, "try", strValue)
+
+	// Views can't be freed
+	assert.ErrorIs(t, TryFreeSlice(s, SubSlice(s, slice, 0, 1)), ErrView)
+	assert.ErrorIs(t, TryFreeString(s, SubString(s, str, 0, 1)), ErrView)
+
+	// Pinned allocations can't be freed
+	obj.Pin()
+	assert.ErrorIs(t, TryFreeObject(s, obj), ErrPinned)
+	obj.Unpin()
+
+	require.NoError(t, TryFreeObject(s, obj))
+	require.NoError(t, TryFreeSlice(s, slice))
+	require.NoError(t, TryFreeString(s, str))
+	assert.Equal(t, 0, s.TotalStats().Live)
+
+	// Freed allocations can't be accessed or freed again
+	_, err = obj.TryValue()
+	assert.ErrorIs(t, err, ErrFreedReference)
+	_, err = slice.TryValue()
+	assert.ErrorIs(t, err, ErrFreedReference)
+	_, err = str.TryValue()
+	assert.ErrorIs(t, err, ErrFreedReference)
+	assert.ErrorIs(t, TryFreeObject(s, obj), ErrFreedReference)
+	assert.ErrorIs(t, TryFreeSlice(s, slice), ErrFreedReference)
+	assert.ErrorIs(t, TryFreeString(s, str), ErrFreedReference)
+
+	// Once the slot is reused the reference is stale
+	reused := AllocObject[MutableStruct](s)
+	_, err = obj.TryValue()
+	assert.ErrorIs(t, err, ErrStaleReference)
+	assert.ErrorIs(t, TryFreeObject(s, obj), ErrStaleReference)
+	assert.Equal(t, 1, s.TotalStats().Live)
+	FreeObject(s, reused)
+
+	// Nil references
+	nilObj := RefObject[MutableStruct]{}
+	_, err = nilObj.TryValue()
+	assert.ErrorIs(t, err, ErrNilReference)
+	assert.ErrorIs(t, TryFreeObject(s, nilObj), ErrNilReference)
+	nilStr := RefString{}
+	strValue, err = nilStr.TryValue()
+	assert.NoError(t, err)
+	assert.Equal(t, "", strValue)
+}
+
+// Demonstrate that the panics raised by misuse wrap the same errors returned
+// by the Try functions
+func Test_PanicErrors(t *testing.T) {
+	s := New()
+	defer func() {
+		assert.NoError(t, s.Destroy())
+	}()
+
+	recovered := func(f func()) (err error) {
+		defer func() {
+			err, _ = recover().(error)
+		}()
+		f()
+		return nil
+	}
+
+	obj := AllocObject[MutableStruct](s)
+	FreeObject(s, obj)
+	assert.ErrorIs(t, recovered(func() { obj.Value() }), ErrFreedReference)
+	assert.ErrorIs(t, recovered(func() { FreeObject(s, obj) }), ErrFreedReference)
+
+	reused := AllocObject[MutableStruct](s)
+	assert.ErrorIs(t, recovered(func() { obj.Value() }), ErrStaleReference)
+	assert.ErrorIs(t, recovered(func() { FreeObject(s, obj) }), ErrStaleReference)
+	FreeObject(s, reused)
+
+	assert.ErrorIs(t, recovered(func() { AllocSlice[byte](s, 0, maxAllocSize+1) }), ErrSizeLimit)
+}
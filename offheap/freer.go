@@ -0,0 +1,171 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+package offheap
+
+import (
+	"cmp"
+	"errors"
+	"slices"
+
+	"github.com/fmstephe/memorymanager/offheap/internal/pointerstore"
+)
+
+// A Freer collects references to allocations, and frees them all at once with
+// Flush.
+//
+// Freeing each allocation of a large structure separately takes a lock for
+// every allocation. Flush sorts the collected references by size class, and
+// frees each size class's references while taking its lock only once. This
+// also allows a structure to be torn down by a destructor which collects
+// every reference while traversing it, and frees them only once the
+// traversal is complete. For example a tree could be freed with
+//
+//	type Node struct {
+//		left  offheap.RefObject[Node]
+//		right offheap.RefObject[Node]
+//	}
+//
+//	var collect func(f *offheap.Freer, r offheap.RefObject[Node])
+//	collect = func(f *offheap.Freer, r offheap.RefObject[Node]) {
+//		if r.IsNil() {
+//			return
+//		}
+//		n := r.Value()
+//		collect(f, n.left)
+//		collect(f, n.right)
+//		offheap.CollectObject(f, r)
+//	}
+//
+//	f := offheap.Freer{}
+//	collect(&f, root)
+//	f.Flush(store)
+//
+// The zero value is ready to use. A Freer is not safe for concurrent use.
+type Freer struct {
+	pending []pendingFree
+}
+
+// An allocation waiting to be freed. The size class of the allocation can
+// only be found once the Store it belongs to is known, so index is called
+// with size during Flush.
+type pendingFree struct {
+	ref   pointerstore.RefPointer
+	size  int
+	index func(s *Store, size int) int
+}
+
+// A pendingFree, with the size class and pool it is freed to
+type indexedFree struct {
+	idx  int
+	pool int
+	ref  pointerstore.RefPointer
+}
+
+// Adds the object referenced by r to f, to be freed by the next Flush. Nil
+// references are ignored.
+func CollectObject[T any](f *Freer, r RefObject[T]) {
+	if r.IsNil() {
+		return
+	}
+	f.pending = append(f.pending, pendingFree{ref: r.ref, index: objectIndex[T]})
+}
+
+// Adds the slice referenced by r to f, to be freed by the next Flush. Nil
+// references are ignored.
+//
+// Like FreeSlice this panics if r is a view created by SubSlice.
+func CollectSlice[T any](f *Freer, r RefSlice[T]) {
+	if r.IsNil() {
+		return
+	}
+	if r.view {
+		panic("cannot free a RefSlice created by SubSlice, free the original RefSlice instead")
+	}
+	f.pending = append(f.pending, pendingFree{ref: r.ref, size: r.capacity, index: sliceIndex[T]})
+}
+
+// Adds the string referenced by r to f, to be freed by the next Flush. Nil
+// references are ignored.
+//
+// Like FreeString this panics if r is a view created by SubString.
+func CollectString(f *Freer, r RefString) {
+	if r.IsNil() {
+		return
+	}
+	if r.view {
+		panic("cannot free a RefString created by SubString, free the original RefString instead")
+	}
+	f.pending = append(f.pending, pendingFree{ref: r.ref, size: r.length, index: stringIndex})
+}
+
+// Returns the number of references waiting to be freed
+func (f *Freer) Len() int {
+	return len(f.pending)
+}
+
+// Frees every collected reference into s, and empties f. Panics, after
+// freeing every other reference, if any reference can't be freed.
+func (f *Freer) Flush(s *Store) {
+	if err := f.TryFlush(s); err != nil {
+		panic(err)
+	}
+}
+
+// Frees every collected reference into s, and empties f, like Flush. Instead
+// of panicking an error is returned, joining the errors for every reference
+// which couldn't be freed.
+func (f *Freer) TryFlush(s *Store) error {
+	indexed := make([]indexedFree, len(f.pending))
+	for i, pending := range f.pending {
+		indexed[i] = indexedFree{
+			idx:  s.storeIndex(pending.index(s, pending.size)),
+			pool: pending.ref.Pool(),
+			ref:  pending.ref,
+		}
+	}
+	f.pending = f.pending[:0]
+
+	// While recording each free must be recorded individually
+	if s.recording.Load() != nil {
+		errs := []error{}
+		for _, free := range indexed {
+			errs = append(errs, s.tryFree(free.idx, free.ref))
+		}
+		return errors.Join(errs...)
+	}
+
+	slices.SortFunc(indexed, func(a, b indexedFree) int {
+		return cmp.Or(cmp.Compare(a.pool, b.pool), cmp.Compare(a.idx, b.idx))
+	})
+
+	errs := []error{}
+	refs := []pointerstore.RefPointer{}
+	for start := 0; start < len(indexed); {
+		first := indexed[start]
+		end := start
+		refs = refs[:0]
+		for end < len(indexed) && indexed[end].pool == first.pool && indexed[end].idx == first.idx {
+			refs = append(refs, indexed[end].ref)
+			end++
+		}
+
+		for _, err := range s.classStore(first.pool, first.idx).TryFreeAll(refs) {
+			s.reportMisuse("free", first.idx, err)
+			errs = append(errs, err)
+		}
+		start = end
+	}
+	return errors.Join(errs...)
+}
+
+// Returns the index of the size class in s used for allocations of type T
+func objectIndex[T any](s *Store, _ int) int {
+	return typeIndex[T](s)
+}
+
+// Returns the index of the size class in s used for strings of length bytes
+func stringIndex(s *Store, length int) int {
+	return s.sizeIndex(length)
+}
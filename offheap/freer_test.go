@@ -0,0 +1,142 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+package offheap
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type freerNode struct {
+	name     RefString
+	values   RefSlice[int64]
+	children RefSlice[RefObject[freerNode]]
+}
+
+// Collects every allocation in the tree rooted at r
+func collectFreerNode(f *Freer, r RefObject[freerNode]) {
+	if r.IsNil() {
+		return
+	}
+	n := r.Value()
+	if !n.children.IsNil() {
+		for _, child := range n.children.Value() {
+			collectFreerNode(f, child)
+		}
+	}
+	CollectString(f, n.name)
+	CollectSlice(f, n.values)
+	CollectSlice(f, n.children)
+	CollectObject(f, r)
+}
+
+// Builds a tree of the given depth, where every node has width children
+func buildFreerTree(s *Store, depth, width int) RefObject[freerNode] {
+	node := freerNode{
+		name:   AllocStringFromString(s, "node"),
+		values: AllocSliceFromSlice(s, []int64{1, 2, 3}),
+	}
+	if depth > 0 {
+		node.children = AllocSlice[RefObject[freerNode]](s, 0, width)
+		for range width {
+			node.children = Append(s, node.children, buildFreerTree(s, depth-1, width))
+		}
+	}
+	return AllocObjectFrom(s, node)
+}
+
+// Demonstrate that a structure can be torn down by collecting each of its
+// references, and freeing them all with a single Flush
+func TestFreer(t *testing.T) {
+	os := NewSized(1 << 10)
+	defer func() {
+		assert.NoError(t, os.Destroy())
+	}()
+
+	root := buildFreerTree(os, 3, 3)
+	live := os.TotalStats().Live
+
+	f := Freer{}
+	collectFreerNode(&f, root)
+	// The leaves have no children slice, nil references are ignored
+	assert.Equal(t, live, f.Len())
+
+	f.Flush(os)
+	assert.Equal(t, 0, f.Len())
+	assert.Equal(t, 0, os.TotalStats().Live)
+	assert.Panics(t, func() { root.Value() })
+
+	// Flushing an empty Freer does nothing
+	f.Flush(os)
+	assert.Equal(t, live, os.TotalStats().Frees)
+}
+
+// Show that every valid reference is freed, even when some references can't
+// be freed, and each failure is reported
+func TestFreer_Errors(t *testing.T) {
+	os := NewSized(1 << 10)
+	defer func() {
+		assert.NoError(t, os.Destroy())
+	}()
+	reports := []MisuseReport{}
+	os.OnMisuse(func(report MisuseReport) {
+		reports = append(reports, report)
+	})
+
+	freed := AllocObject[int64](os)
+	FreeObject(os, freed)
+
+	f := Freer{}
+	CollectObject(&f, AllocObject[int64](os))
+	CollectObject(&f, freed)
+	CollectString(&f, AllocStringFromString(os, "hello"))
+	CollectObject(&f, freed)
+
+	err := f.TryFlush(os)
+	assert.ErrorIs(t, err, ErrFreedReference)
+	assert.Len(t, reports, 2)
+	assert.Equal(t, 0, os.TotalStats().Live)
+
+	CollectObject(&f, freed)
+	assert.Panics(t, func() { f.Flush(os) })
+
+	// Views can't be collected
+	str := AllocStringFromString(os, "hello")
+	assert.Panics(t, func() { CollectString(&f, SubString(os, str, 1, 3)) })
+	slice := AllocSlice[int64](os, 4, 4)
+	assert.Panics(t, func() { CollectSlice(&f, SubSlice(os, slice, 1, 3)) })
+}
+
+// Demonstrate that a Freer frees allocations back to the pool, and size class,
+// they came from, in Stores with pools, custom size classes and caches
+func TestFreer_StoreKinds(t *testing.T) {
+	for name, os := range map[string]*Store{
+		"pools":        NewWithPools(1<<10, 4),
+		"size-classes": NewWithSizeClasses(1<<10, GeometricSizeClasses(1.25, 256)),
+		"caches":       NewWithCaches(1<<10, 8),
+		"padding":      NewWithCacheLinePadding(1 << 12),
+	} {
+		t.Run(name, func(t *testing.T) {
+			defer func() {
+				assert.NoError(t, os.Destroy())
+			}()
+
+			f := Freer{}
+			for i := range 100 {
+				CollectObject(&f, AllocObject[int64](os))
+				CollectSlice(&f, AllocSlice[int32](os, i, i))
+				CollectString(&f, AllocStringFromString(os, string(make([]byte, i))))
+			}
+			require.NoError(t, f.TryFlush(os))
+
+			for _, stats := range os.Stats() {
+				assert.Equal(t, stats.Allocs, stats.Frees)
+			}
+			assert.Equal(t, 0, os.TotalStats().Live)
+		})
+	}
+}
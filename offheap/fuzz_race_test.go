@@ -0,0 +1,93 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+package offheap
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/fmstephe/memorymanager/testpkg/fuzzutil"
+)
+
+// The concurrent fuzzer test for offheap.
+//
+// The fuzz input is split between a number of goroutines, each of which
+// independently allocates, mutates, reads and frees its own objects in a
+// single shared Store. This exercises the concurrency guarantees described in
+// the package documentation. The fuzz input decides the number of goroutines,
+// the type of Store and every step taken by each goroutine, so failures can be
+// reproduced. Only the interleaving of the goroutines is left to chance.
+//
+// This test should be run with -race
+func FuzzConcurrentStore(f *testing.F) {
+	testCases := fuzzutil.MakeRandomTestCases()
+	for _, tc := range testCases {
+		f.Add(tc)
+	}
+	f.Fuzz(func(t *testing.T, bytes []byte) {
+		runConcurrentTest(bytes)
+	})
+}
+
+// The maximum number of goroutines used by a concurrent fuzz test
+const maxFuzzGoroutines = 8
+
+func runConcurrentTest(bytes []byte) {
+	byteConsumer := fuzzutil.NewByteConsumer(bytes)
+	header := byteConsumer.Byte()
+
+	goroutineCount := int(header%maxFuzzGoroutines) + 1
+
+	// The top bit chooses whether goroutines share pools, or use a Store
+	// with a pool for each goroutine
+	var store *Store
+	if header&0x80 == 0 {
+		store = New()
+	} else {
+		store = NewWithPools(defaultSlabSize, goroutineCount)
+	}
+	defer func() {
+		if err := store.Destroy(); err != nil {
+			panic(err)
+		}
+	}()
+
+	// Split the remaining input evenly between the goroutines
+	remaining := byteConsumer.Bytes(byteConsumer.Len())
+	chunk := len(remaining) / goroutineCount
+
+	runs := make([]*fuzzutil.TestRun, goroutineCount)
+	for i := range runs {
+		part := remaining[i*chunk : (i+1)*chunk]
+		if i == goroutineCount-1 {
+			part = remaining[i*chunk:]
+		}
+
+		objects := newObjectsInStore(store)
+		runs[i] = fuzzutil.NewTestRun(part, objectStepMaker(objects), objects.FreeAll)
+	}
+
+	barrier := sync.WaitGroup{}
+	barrier.Add(1)
+
+	complete := sync.WaitGroup{}
+	for _, run := range runs {
+		complete.Add(1)
+		go func() {
+			defer complete.Done()
+			barrier.Wait()
+			run.Run()
+		}()
+	}
+
+	barrier.Done()
+	complete.Wait()
+
+	// Every goroutine freed all of its objects
+	if live := store.TotalStats().Live; live != 0 {
+		panic(fmt.Sprintf("%d objects still live after every goroutine freed its objects", live))
+	}
+}
@@ -0,0 +1,867 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+package offheap
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/fmstephe/memorymanager/testpkg/fuzzutil"
+)
+
+// The fuzzer test for offheap slices and strings. This exercises the
+// resize-and-invalidate paths of Append, AppendSlice, InsertAt, DeleteRange,
+// Truncate and AppendString, along with the concatenating and cloning
+// allocations and frees.
+func FuzzSliceStore(f *testing.F) {
+	testCases := fuzzutil.MakeRandomTestCases()
+	for _, tc := range testCases {
+		f.Add(tc)
+	}
+	f.Fuzz(func(t *testing.T, bytes []byte) {
+		tr := NewSliceTestRun(bytes)
+		tr.RunOrFail(t)
+	})
+}
+
+// Show that the slice fuzzer runs cleanly when it chooses only the steps
+// which resize and invalidate slices, along with the allocations and frees
+// which feed them
+func TestSliceStore_ResizeWeights(t *testing.T) {
+	weights := map[string]int{
+		"alloc-string":   0,
+		"append-string":  0,
+		"concat-strings": 0,
+		"clone-string":   0,
+		"free-string":    0,
+		"append":         8,
+		"insert-at":      8,
+		"delete-range":   4,
+		"truncate":       4,
+	}
+	for _, bytes := range fuzzutil.MakeRandomTestCases()[:8] {
+		tr := NewSliceTestRunWithWeights(bytes, weights)
+		tr.RunOrFail(t)
+	}
+}
+
+func NewSliceTestRun(bytes []byte) *fuzzutil.TestRun {
+	return NewSliceTestRunWithWeights(bytes, nil)
+}
+
+// Returns a new slice TestRun, where the weight of each step named in weights
+// is replaced, see sliceStepKinds.
+func NewSliceTestRunWithWeights(bytes []byte, weights map[string]int) *fuzzutil.TestRun {
+	slices := NewSlices()
+
+	cleanup := func() {
+		slices.Cleanup()
+	}
+
+	kinds := fuzzutil.Reweight(sliceStepKinds(slices), weights)
+	return fuzzutil.NewTestRun(bytes, fuzzutil.NewWeightedStepMaker(kinds), cleanup)
+}
+
+// Returns a new TestRun made up of the steps in script, see TestRun.Script
+func NewSliceScriptTestRun(script fuzzutil.Script) (*fuzzutil.TestRun, error) {
+	slices := NewSlices()
+
+	cleanup := func() {
+		slices.Cleanup()
+	}
+
+	tr, err := fuzzutil.NewTestRunFromScript(script, fuzzutil.StepParsers(sliceStepKinds(slices)), cleanup)
+	if err != nil {
+		cleanup()
+	}
+	return tr, err
+}
+
+// Returns the kinds of step which allocate, modify and free slices and
+// strings. The steps which resize a slice or string, invalidating the old
+// reference, and the steps which free, allowing the freed slot to be reused,
+// are weighted most heavily.
+func sliceStepKinds(slices *Slices) []fuzzutil.StepKind {
+	return []fuzzutil.StepKind{
+		{
+			Name:   "alloc-slice",
+			Weight: 2,
+			Make: func(byteConsumer *fuzzutil.ByteConsumer) fuzzutil.Step {
+				return NewAllocSliceStep(slices, byteConsumer)
+			},
+			Parse: func(args *fuzzutil.ScriptArgs) fuzzutil.Step {
+				return &AllocSliceStep{slices: slices, length: args.Int(), extraCapacity: args.Int(), value: args.Uint32()}
+			},
+		},
+		{
+			Name:   "append",
+			Weight: 4,
+			Make: func(byteConsumer *fuzzutil.ByteConsumer) fuzzutil.Step {
+				return NewAppendStep(slices, byteConsumer)
+			},
+			Parse: func(args *fuzzutil.ScriptArgs) fuzzutil.Step {
+				return &AppendStep{slices: slices, index: args.Uint32(), value: args.Uint32()}
+			},
+		},
+		{
+			Name:   "append-slice",
+			Weight: 3,
+			Make: func(byteConsumer *fuzzutil.ByteConsumer) fuzzutil.Step {
+				return NewAppendSliceStep(slices, byteConsumer)
+			},
+			Parse: func(args *fuzzutil.ScriptArgs) fuzzutil.Step {
+				return &AppendSliceStep{slices: slices, index: args.Uint32(), length: args.Int(), value: args.Uint32()}
+			},
+		},
+		{
+			Name:   "insert-at",
+			Weight: 3,
+			Make: func(byteConsumer *fuzzutil.ByteConsumer) fuzzutil.Step {
+				return NewInsertAtStep(slices, byteConsumer)
+			},
+			Parse: func(args *fuzzutil.ScriptArgs) fuzzutil.Step {
+				return &InsertAtStep{slices: slices, index: args.Uint32(), at: args.Int(), length: args.Int(), value: args.Uint32()}
+			},
+		},
+		{
+			Name:   "delete-range",
+			Weight: 2,
+			Make: func(byteConsumer *fuzzutil.ByteConsumer) fuzzutil.Step {
+				return NewDeleteRangeStep(slices, byteConsumer)
+			},
+			Parse: func(args *fuzzutil.ScriptArgs) fuzzutil.Step {
+				return &DeleteRangeStep{slices: slices, index: args.Uint32(), start: args.Int(), length: args.Int()}
+			},
+		},
+		{
+			Name:   "truncate",
+			Weight: 2,
+			Make: func(byteConsumer *fuzzutil.ByteConsumer) fuzzutil.Step {
+				return NewTruncateStep(slices, byteConsumer)
+			},
+			Parse: func(args *fuzzutil.ScriptArgs) fuzzutil.Step {
+				return &TruncateStep{slices: slices, index: args.Uint32(), length: args.Int()}
+			},
+		},
+		{
+			Name:   "concat-slices",
+			Weight: 2,
+			Make: func(byteConsumer *fuzzutil.ByteConsumer) fuzzutil.Step {
+				return NewConcatSlicesStep(slices, byteConsumer)
+			},
+			Parse: func(args *fuzzutil.ScriptArgs) fuzzutil.Step {
+				step := &ConcatSlicesStep{slices: slices, value: args.Uint32()}
+				for args.Len() > 0 {
+					step.lengths = append(step.lengths, args.Int())
+				}
+				return step
+			},
+		},
+		{
+			Name:   "clone-slice",
+			Weight: 2,
+			Make: func(byteConsumer *fuzzutil.ByteConsumer) fuzzutil.Step {
+				return NewCloneSliceStep(slices, byteConsumer)
+			},
+			Parse: func(args *fuzzutil.ScriptArgs) fuzzutil.Step {
+				return &CloneSliceStep{slices: slices, index: args.Uint32()}
+			},
+		},
+		{
+			Name:   "free-slice",
+			Weight: 3,
+			Make: func(byteConsumer *fuzzutil.ByteConsumer) fuzzutil.Step {
+				return NewFreeSliceStep(slices, byteConsumer)
+			},
+			Parse: func(args *fuzzutil.ScriptArgs) fuzzutil.Step {
+				return &FreeSliceStep{slices: slices, index: args.Uint32()}
+			},
+		},
+		{
+			Name:   "alloc-string",
+			Weight: 2,
+			Make: func(byteConsumer *fuzzutil.ByteConsumer) fuzzutil.Step {
+				return NewAllocStringStep(slices, byteConsumer)
+			},
+			Parse: func(args *fuzzutil.ScriptArgs) fuzzutil.Step {
+				return &AllocStringStep{slices: slices, str: args.Text()}
+			},
+		},
+		{
+			Name:   "append-string",
+			Weight: 3,
+			Make: func(byteConsumer *fuzzutil.ByteConsumer) fuzzutil.Step {
+				return NewAppendStringStep(slices, byteConsumer)
+			},
+			Parse: func(args *fuzzutil.ScriptArgs) fuzzutil.Step {
+				return &AppendStringStep{slices: slices, index: args.Uint32(), value: args.Text()}
+			},
+		},
+		{
+			Name:   "concat-strings",
+			Weight: 2,
+			Make: func(byteConsumer *fuzzutil.ByteConsumer) fuzzutil.Step {
+				return NewConcatStringsStep(slices, byteConsumer)
+			},
+			Parse: func(args *fuzzutil.ScriptArgs) fuzzutil.Step {
+				step := &ConcatStringsStep{slices: slices}
+				for args.Len() > 0 {
+					step.strs = append(step.strs, args.Text())
+				}
+				return step
+			},
+		},
+		{
+			Name:   "clone-string",
+			Weight: 2,
+			Make: func(byteConsumer *fuzzutil.ByteConsumer) fuzzutil.Step {
+				return NewCloneStringStep(slices, byteConsumer)
+			},
+			Parse: func(args *fuzzutil.ScriptArgs) fuzzutil.Step {
+				return &CloneStringStep{slices: slices, index: args.Uint32()}
+			},
+		},
+		{
+			Name:   "free-string",
+			Weight: 3,
+			Make: func(byteConsumer *fuzzutil.ByteConsumer) fuzzutil.Step {
+				return NewFreeStringStep(slices, byteConsumer)
+			},
+			Parse: func(args *fuzzutil.ScriptArgs) fuzzutil.Step {
+				return &FreeStringStep{slices: slices, index: args.Uint32()}
+			},
+		},
+	}
+}
+
+type Slices struct {
+	store *Store
+
+	slices         []RefSlice[uint32]
+	expectedSlices [][]uint32
+	// Indicates whether a slice is still live (has not been freed)
+	liveSlices []bool
+
+	strings         []RefString
+	expectedStrings []string
+	// Indicates whether a string is still live (has not been freed)
+	liveStrings []bool
+}
+
+func NewSlices() *Slices {
+	return &Slices{
+		store: New(),
+	}
+}
+
+func (s *Slices) AllocSlice(length, extraCapacity int, value uint32) {
+	expected := generateSlice(length, value)
+
+	r := AllocSlice[uint32](s.store, length, length+extraCapacity)
+	copy(r.Value(), expected)
+
+	s.addSlice(r, expected)
+}
+
+func (s *Slices) ConcatSlices(lengths []int, value uint32) {
+	parts := make([][]uint32, len(lengths))
+	expected := []uint32{}
+	for i, length := range lengths {
+		parts[i] = generateSlice(length, value+uint32(i))
+		expected = append(expected, parts[i]...)
+	}
+
+	s.addSlice(ConcatSlices(s.store, parts...), expected)
+}
+
+func (s *Slices) Append(index uint32, value uint32) {
+	idx, ok := s.liveSliceIndex(index)
+	if !ok {
+		return
+	}
+
+	oldRef := s.slices[idx]
+	newRef := Append(s.store, oldRef, value)
+	mustBeInvalid(func() { oldRef.Value() }, "slice invalidated by Append")
+
+	s.slices[idx] = newRef
+	s.expectedSlices[idx] = append(s.expectedSlices[idx], value)
+}
+
+func (s *Slices) AppendSlice(index uint32, length int, value uint32) {
+	idx, ok := s.liveSliceIndex(index)
+	if !ok {
+		return
+	}
+
+	oldRef := s.slices[idx]
+	fromSlice := generateSlice(length, value)
+	newRef := AppendSlice(s.store, oldRef, fromSlice)
+	mustBeInvalid(func() { oldRef.Value() }, "slice invalidated by AppendSlice")
+
+	s.slices[idx] = newRef
+	s.expectedSlices[idx] = append(s.expectedSlices[idx], fromSlice...)
+}
+
+func (s *Slices) InsertAt(index uint32, at, length int, value uint32) {
+	idx, ok := s.liveSliceIndex(index)
+	if !ok {
+		return
+	}
+
+	oldRef := s.slices[idx]
+	at = at % (oldRef.Len() + 1)
+	values := generateSlice(length, value)
+	newRef := InsertAt(s.store, oldRef, at, values...)
+	mustBeInvalid(func() { oldRef.Value() }, "slice invalidated by InsertAt")
+
+	s.slices[idx] = newRef
+	s.expectedSlices[idx] = slices.Insert(s.expectedSlices[idx], at, values...)
+}
+
+func (s *Slices) DeleteRange(index uint32, start, length int) {
+	idx, ok := s.liveSliceIndex(index)
+	if !ok {
+		return
+	}
+
+	oldRef := s.slices[idx]
+	start = start % (oldRef.Len() + 1)
+	end := start + length%(oldRef.Len()-start+1)
+	newRef := DeleteRange(s.store, oldRef, start, end)
+	mustBeInvalid(func() { oldRef.Value() }, "slice invalidated by DeleteRange")
+
+	s.slices[idx] = newRef
+	s.expectedSlices[idx] = slices.Delete(s.expectedSlices[idx], start, end)
+}
+
+func (s *Slices) Truncate(index uint32, length int) {
+	idx, ok := s.liveSliceIndex(index)
+	if !ok {
+		return
+	}
+
+	oldRef := s.slices[idx]
+	length = length % (oldRef.Len() + 1)
+	newRef := Truncate(s.store, oldRef, length)
+	mustBeInvalid(func() { oldRef.Value() }, "slice invalidated by Truncate")
+
+	s.slices[idx] = newRef
+	s.expectedSlices[idx] = s.expectedSlices[idx][:length]
+}
+
+func (s *Slices) CloneSlice(index uint32) {
+	idx, ok := s.liveSliceIndex(index)
+	if !ok {
+		return
+	}
+
+	s.addSlice(CloneSlice(s.store, s.slices[idx]), slices.Clone(s.expectedSlices[idx]))
+}
+
+func (s *Slices) FreeSlice(index uint32) {
+	idx, ok := s.liveSliceIndex(index)
+	if !ok {
+		// See Objects.Free for why freed slices are not freed again
+		return
+	}
+
+	r := s.slices[idx]
+	FreeSlice(s.store, r)
+	mustBeInvalid(func() { r.Value() }, "freed slice")
+	s.liveSlices[idx] = false
+}
+
+func (s *Slices) AllocString(str string) {
+	s.addString(AllocStringFromString(s.store, str), str)
+}
+
+func (s *Slices) ConcatStrings(strs []string) {
+	expected := ""
+	for _, str := range strs {
+		expected += str
+	}
+
+	s.addString(ConcatStrings(s.store, strs...), expected)
+}
+
+func (s *Slices) AppendString(index uint32, value string) {
+	idx, ok := s.liveStringIndex(index)
+	if !ok {
+		return
+	}
+
+	oldRef := s.strings[idx]
+	newRef := AppendString(s.store, oldRef, value)
+	mustBeInvalid(func() { oldRef.Value() }, "string invalidated by AppendString")
+
+	s.strings[idx] = newRef
+	s.expectedStrings[idx] += value
+}
+
+func (s *Slices) CloneString(index uint32) {
+	idx, ok := s.liveStringIndex(index)
+	if !ok {
+		return
+	}
+
+	s.addString(CloneString(s.store, s.strings[idx]), s.expectedStrings[idx])
+}
+
+func (s *Slices) FreeString(index uint32) {
+	idx, ok := s.liveStringIndex(index)
+	if !ok {
+		// See Objects.Free for why freed strings are not freed again
+		return
+	}
+
+	r := s.strings[idx]
+	FreeString(s.store, r)
+	mustBeInvalid(func() { r.Value() }, "freed string")
+	s.liveStrings[idx] = false
+}
+
+func (s *Slices) CheckAll() {
+	for idx := range s.slices {
+		if !s.liveSlices[idx] {
+			continue
+		}
+		value := s.slices[idx].Value()
+		expected := s.expectedSlices[idx]
+		if len(value) != len(expected) || (len(value) > 0 && !reflect.DeepEqual(value, expected)) {
+			panic(fmt.Sprintf("Unequal slices found \n\t%v \n\t%v", value, expected))
+		}
+	}
+
+	for idx := range s.strings {
+		if !s.liveStrings[idx] {
+			continue
+		}
+		value := s.strings[idx].Value()
+		expected := s.expectedStrings[idx]
+		if value != expected {
+			panic(fmt.Sprintf("Unequal strings found \n\t%q \n\t%q", value, expected))
+		}
+	}
+}
+
+func (s *Slices) Cleanup() {
+	if err := s.store.Destroy(); err != nil {
+		panic(err)
+	}
+}
+
+func (s *Slices) addSlice(r RefSlice[uint32], expected []uint32) {
+	s.slices = append(s.slices, r)
+	s.expectedSlices = append(s.expectedSlices, expected)
+	s.liveSlices = append(s.liveSlices, true)
+}
+
+func (s *Slices) addString(r RefString, expected string) {
+	s.strings = append(s.strings, r)
+	s.expectedStrings = append(s.expectedStrings, expected)
+	s.liveStrings = append(s.liveStrings, true)
+}
+
+// Normalises index so it points into our slice of slices, and indicates
+// whether the slice at that index is live
+func (s *Slices) liveSliceIndex(index uint32) (int, bool) {
+	if len(s.slices) == 0 {
+		return 0, false
+	}
+	idx := int(index % uint32(len(s.slices)))
+	return idx, s.liveSlices[idx]
+}
+
+// Normalises index so it points into our slice of strings, and indicates
+// whether the string at that index is live
+func (s *Slices) liveStringIndex(index uint32) (int, bool) {
+	if len(s.strings) == 0 {
+		return 0, false
+	}
+	idx := int(index % uint32(len(s.strings)))
+	return idx, s.liveStrings[idx]
+}
+
+// Generates a slice of length elements, each element derived from value and
+// its position in the slice
+func generateSlice(length int, value uint32) []uint32 {
+	slice := make([]uint32, length)
+	for i := range slice {
+		slice[i] = value + uint32(i)
+	}
+	return slice
+}
+
+// Panics unless calling f panics, i.e. f uses a reference which has been
+// correctly invalidated
+func mustBeInvalid(f func(), description string) {
+	if !panics(f) {
+		panic(fmt.Sprintf("use of %s did not panic", description))
+	}
+}
+
+// Indicates whether calling f panics
+func panics(f func()) (didPanic bool) {
+	defer func() {
+		if recover() != nil {
+			didPanic = true
+		}
+	}()
+	f()
+	return false
+}
+
+// Allocate a slice
+type AllocSliceStep struct {
+	slices        *Slices
+	length        int
+	extraCapacity int
+	value         uint32
+}
+
+func NewAllocSliceStep(slices *Slices, byteConsumer *fuzzutil.ByteConsumer) *AllocSliceStep {
+	return &AllocSliceStep{
+		slices:        slices,
+		length:        int(byteConsumer.Byte()),
+		extraCapacity: int(byteConsumer.Byte()),
+		value:         byteConsumer.Uint32(),
+	}
+}
+
+func (s *AllocSliceStep) DoStep() {
+	s.slices.AllocSlice(s.length, s.extraCapacity, s.value)
+	s.slices.CheckAll()
+}
+
+func (s *AllocSliceStep) ScriptLine() fuzzutil.ScriptLine {
+	return fuzzutil.NewScriptLine("alloc-slice", s.length, s.extraCapacity, s.value)
+}
+
+// Allocate a slice by concatenating several slices
+type ConcatSlicesStep struct {
+	slices  *Slices
+	lengths []int
+	value   uint32
+}
+
+func NewConcatSlicesStep(slices *Slices, byteConsumer *fuzzutil.ByteConsumer) *ConcatSlicesStep {
+	lengths := make([]int, byteConsumer.Byte()%4)
+	for i := range lengths {
+		lengths[i] = int(byteConsumer.Byte())
+	}
+	return &ConcatSlicesStep{
+		slices:  slices,
+		lengths: lengths,
+		value:   byteConsumer.Uint32(),
+	}
+}
+
+func (s *ConcatSlicesStep) DoStep() {
+	s.slices.ConcatSlices(s.lengths, s.value)
+	s.slices.CheckAll()
+}
+
+func (s *ConcatSlicesStep) ScriptLine() fuzzutil.ScriptLine {
+	args := []any{s.value}
+	for _, length := range s.lengths {
+		args = append(args, length)
+	}
+	return fuzzutil.NewScriptLine("concat-slices", args...)
+}
+
+// Append a single element to a slice
+type AppendStep struct {
+	slices *Slices
+	index  uint32
+	value  uint32
+}
+
+func NewAppendStep(slices *Slices, byteConsumer *fuzzutil.ByteConsumer) *AppendStep {
+	return &AppendStep{
+		slices: slices,
+		index:  byteConsumer.Uint32(),
+		value:  byteConsumer.Uint32(),
+	}
+}
+
+func (s *AppendStep) DoStep() {
+	s.slices.Append(s.index, s.value)
+	s.slices.CheckAll()
+}
+
+func (s *AppendStep) ScriptLine() fuzzutil.ScriptLine {
+	return fuzzutil.NewScriptLine("append", s.index, s.value)
+}
+
+// Append a slice of elements to a slice
+type AppendSliceStep struct {
+	slices *Slices
+	index  uint32
+	length int
+	value  uint32
+}
+
+func NewAppendSliceStep(slices *Slices, byteConsumer *fuzzutil.ByteConsumer) *AppendSliceStep {
+	return &AppendSliceStep{
+		slices: slices,
+		index:  byteConsumer.Uint32(),
+		length: int(byteConsumer.Byte()),
+		value:  byteConsumer.Uint32(),
+	}
+}
+
+func (s *AppendSliceStep) DoStep() {
+	s.slices.AppendSlice(s.index, s.length, s.value)
+	s.slices.CheckAll()
+}
+
+func (s *AppendSliceStep) ScriptLine() fuzzutil.ScriptLine {
+	return fuzzutil.NewScriptLine("append-slice", s.index, s.length, s.value)
+}
+
+// Insert elements into a slice
+type InsertAtStep struct {
+	slices *Slices
+	index  uint32
+	at     int
+	length int
+	value  uint32
+}
+
+func NewInsertAtStep(slices *Slices, byteConsumer *fuzzutil.ByteConsumer) *InsertAtStep {
+	return &InsertAtStep{
+		slices: slices,
+		index:  byteConsumer.Uint32(),
+		at:     int(byteConsumer.Byte()),
+		length: int(byteConsumer.Byte() % 16),
+		value:  byteConsumer.Uint32(),
+	}
+}
+
+func (s *InsertAtStep) DoStep() {
+	s.slices.InsertAt(s.index, s.at, s.length, s.value)
+	s.slices.CheckAll()
+}
+
+func (s *InsertAtStep) ScriptLine() fuzzutil.ScriptLine {
+	return fuzzutil.NewScriptLine("insert-at", s.index, s.at, s.length, s.value)
+}
+
+// Delete a range of elements from a slice
+type DeleteRangeStep struct {
+	slices *Slices
+	index  uint32
+	start  int
+	length int
+}
+
+func NewDeleteRangeStep(slices *Slices, byteConsumer *fuzzutil.ByteConsumer) *DeleteRangeStep {
+	return &DeleteRangeStep{
+		slices: slices,
+		index:  byteConsumer.Uint32(),
+		start:  int(byteConsumer.Byte()),
+		length: int(byteConsumer.Byte()),
+	}
+}
+
+func (s *DeleteRangeStep) DoStep() {
+	s.slices.DeleteRange(s.index, s.start, s.length)
+	s.slices.CheckAll()
+}
+
+func (s *DeleteRangeStep) ScriptLine() fuzzutil.ScriptLine {
+	return fuzzutil.NewScriptLine("delete-range", s.index, s.start, s.length)
+}
+
+// Shorten a slice
+type TruncateStep struct {
+	slices *Slices
+	index  uint32
+	length int
+}
+
+func NewTruncateStep(slices *Slices, byteConsumer *fuzzutil.ByteConsumer) *TruncateStep {
+	return &TruncateStep{
+		slices: slices,
+		index:  byteConsumer.Uint32(),
+		length: int(byteConsumer.Byte()),
+	}
+}
+
+func (s *TruncateStep) DoStep() {
+	s.slices.Truncate(s.index, s.length)
+	s.slices.CheckAll()
+}
+
+func (s *TruncateStep) ScriptLine() fuzzutil.ScriptLine {
+	return fuzzutil.NewScriptLine("truncate", s.index, s.length)
+}
+
+// Allocate a copy of a slice
+type CloneSliceStep struct {
+	slices *Slices
+	index  uint32
+}
+
+func NewCloneSliceStep(slices *Slices, byteConsumer *fuzzutil.ByteConsumer) *CloneSliceStep {
+	return &CloneSliceStep{
+		slices: slices,
+		index:  byteConsumer.Uint32(),
+	}
+}
+
+func (s *CloneSliceStep) DoStep() {
+	s.slices.CloneSlice(s.index)
+	s.slices.CheckAll()
+}
+
+func (s *CloneSliceStep) ScriptLine() fuzzutil.ScriptLine {
+	return fuzzutil.NewScriptLine("clone-slice", s.index)
+}
+
+// Free a slice
+type FreeSliceStep struct {
+	slices *Slices
+	index  uint32
+}
+
+func NewFreeSliceStep(slices *Slices, byteConsumer *fuzzutil.ByteConsumer) *FreeSliceStep {
+	return &FreeSliceStep{
+		slices: slices,
+		index:  byteConsumer.Uint32(),
+	}
+}
+
+func (s *FreeSliceStep) DoStep() {
+	s.slices.FreeSlice(s.index)
+	s.slices.CheckAll()
+}
+
+func (s *FreeSliceStep) ScriptLine() fuzzutil.ScriptLine {
+	return fuzzutil.NewScriptLine("free-slice", s.index)
+}
+
+// Allocate a string
+type AllocStringStep struct {
+	slices *Slices
+	str    string
+}
+
+func NewAllocStringStep(slices *Slices, byteConsumer *fuzzutil.ByteConsumer) *AllocStringStep {
+	return &AllocStringStep{
+		slices: slices,
+		str:    string(byteConsumer.Bytes(int(byteConsumer.Byte()))),
+	}
+}
+
+func (s *AllocStringStep) DoStep() {
+	s.slices.AllocString(s.str)
+	s.slices.CheckAll()
+}
+
+func (s *AllocStringStep) ScriptLine() fuzzutil.ScriptLine {
+	return fuzzutil.NewScriptLine("alloc-string", s.str)
+}
+
+// Allocate a string by concatenating several strings
+type ConcatStringsStep struct {
+	slices *Slices
+	strs   []string
+}
+
+func NewConcatStringsStep(slices *Slices, byteConsumer *fuzzutil.ByteConsumer) *ConcatStringsStep {
+	strs := make([]string, byteConsumer.Byte()%4)
+	for i := range strs {
+		strs[i] = string(byteConsumer.Bytes(int(byteConsumer.Byte())))
+	}
+	return &ConcatStringsStep{
+		slices: slices,
+		strs:   strs,
+	}
+}
+
+func (s *ConcatStringsStep) DoStep() {
+	s.slices.ConcatStrings(s.strs)
+	s.slices.CheckAll()
+}
+
+func (s *ConcatStringsStep) ScriptLine() fuzzutil.ScriptLine {
+	args := []any{}
+	for _, str := range s.strs {
+		args = append(args, str)
+	}
+	return fuzzutil.NewScriptLine("concat-strings", args...)
+}
+
+// Append to a string
+type AppendStringStep struct {
+	slices *Slices
+	index  uint32
+	value  string
+}
+
+func NewAppendStringStep(slices *Slices, byteConsumer *fuzzutil.ByteConsumer) *AppendStringStep {
+	return &AppendStringStep{
+		slices: slices,
+		index:  byteConsumer.Uint32(),
+		value:  string(byteConsumer.Bytes(int(byteConsumer.Byte()))),
+	}
+}
+
+func (s *AppendStringStep) DoStep() {
+	s.slices.AppendString(s.index, s.value)
+	s.slices.CheckAll()
+}
+
+func (s *AppendStringStep) ScriptLine() fuzzutil.ScriptLine {
+	return fuzzutil.NewScriptLine("append-string", s.index, s.value)
+}
+
+// Allocate a copy of a string
+type CloneStringStep struct {
+	slices *Slices
+	index  uint32
+}
+
+func NewCloneStringStep(slices *Slices, byteConsumer *fuzzutil.ByteConsumer) *CloneStringStep {
+	return &CloneStringStep{
+		slices: slices,
+		index:  byteConsumer.Uint32(),
+	}
+}
+
+func (s *CloneStringStep) DoStep() {
+	s.slices.CloneString(s.index)
+	s.slices.CheckAll()
+}
+
+func (s *CloneStringStep) ScriptLine() fuzzutil.ScriptLine {
+	return fuzzutil.NewScriptLine("clone-string", s.index)
+}
+
+// Free a string
+type FreeStringStep struct {
+	slices *Slices
+	index  uint32
+}
+
+func NewFreeStringStep(slices *Slices, byteConsumer *fuzzutil.ByteConsumer) *FreeStringStep {
+	return &FreeStringStep{
+		slices: slices,
+		index:  byteConsumer.Uint32(),
+	}
+}
+
+func (s *FreeStringStep) DoStep() {
+	s.slices.FreeString(s.index)
+	s.slices.CheckAll()
+}
+
+func (s *FreeStringStep) ScriptLine() fuzzutil.ScriptLine {
+	return fuzzutil.NewScriptLine("free-string", s.index)
+}
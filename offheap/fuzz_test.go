@@ -6,6 +6,8 @@ package offheap
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
 	"reflect"
 	"testing"
 
@@ -20,31 +22,139 @@ func FuzzObjectStore(f *testing.F) {
 	}
 	f.Fuzz(func(t *testing.T, bytes []byte) {
 		tr := NewTestRun(bytes)
-		tr.Run()
+		tr.RunOrFail(t)
 	})
 }
 
+// Runs every script saved in testdata/fuzzscript. A failing fuzz run reports
+// its script, which can be minimised and saved in the directory named after
+// its fuzz test to become a regression test.
+func TestFuzzScripts(t *testing.T) {
+	scriptRuns := map[string]func(fuzzutil.Script) (*fuzzutil.TestRun, error){
+		"FuzzObjectStore": NewScriptTestRun,
+		"FuzzSliceStore":  NewSliceScriptTestRun,
+	}
+
+	for fuzzName, newRun := range scriptRuns {
+		paths, err := filepath.Glob(filepath.Join("testdata", "fuzzscript", fuzzName, "*"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, path := range paths {
+			t.Run(fuzzName+"/"+filepath.Base(path), func(t *testing.T) {
+				text, err := os.ReadFile(path)
+				if err != nil {
+					t.Fatal(err)
+				}
+				script, err := fuzzutil.ParseScript(string(text))
+				if err != nil {
+					t.Fatal(err)
+				}
+				tr, err := newRun(script)
+				if err != nil {
+					t.Fatal(err)
+				}
+				tr.RunOrFail(t)
+			})
+		}
+	}
+}
+
+// Demonstrate that every fuzz run can be written as a script, which runs the
+// same steps when it is parsed back into a new run
+func TestFuzzScripts_RoundTrip(t *testing.T) {
+	for _, bytes := range fuzzutil.MakeRandomTestCases()[:6] {
+		for _, tr := range []*fuzzutil.TestRun{NewTestRun(bytes), NewSliceTestRun(bytes)} {
+			script, err := tr.Script()
+			if err != nil {
+				t.Fatal(err)
+			}
+			parsed, err := fuzzutil.ParseScript(script.String())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if parsed.String() != script.String() {
+				t.Fatalf("script changed by parsing\n%s\n%s", script, parsed)
+			}
+			tr.RunOrFail(t)
+		}
+	}
+}
+
 func NewTestRun(bytes []byte) *fuzzutil.TestRun {
+	return NewTestRunWithWeights(bytes, nil)
+}
+
+// Returns a new TestRun, where the weight of each step named in weights is
+// replaced, see objectStepKinds.
+func NewTestRunWithWeights(bytes []byte, weights map[string]int) *fuzzutil.TestRun {
 	objects := NewObjects()
 
-	stepMaker := func(byteConsumer *fuzzutil.ByteConsumer) fuzzutil.Step {
-		chooser := byteConsumer.Byte()
-		switch chooser % 3 {
-		case 0:
-			return NewAllocStep(objects, byteConsumer)
-		case 1:
-			return NewFreeStep(objects, byteConsumer)
-		case 2:
-			return NewMutateStep(objects, byteConsumer)
-		}
-		panic("Unreachable")
+	cleanup := func() {
+		objects.Cleanup()
 	}
 
+	kinds := fuzzutil.Reweight(objectStepKinds(objects), weights)
+	return fuzzutil.NewTestRun(bytes, fuzzutil.NewWeightedStepMaker(kinds), cleanup)
+}
+
+// Returns a new TestRun made up of the steps in script, see TestRun.Script
+func NewScriptTestRun(script fuzzutil.Script) (*fuzzutil.TestRun, error) {
+	objects := NewObjects()
+
 	cleanup := func() {
 		objects.Cleanup()
 	}
 
-	return fuzzutil.NewTestRun(bytes, stepMaker, cleanup)
+	tr, err := fuzzutil.NewTestRunFromScript(script, fuzzutil.StepParsers(objectStepKinds(objects)), cleanup)
+	if err != nil {
+		cleanup()
+	}
+	return tr, err
+}
+
+// Returns the kinds of step which allocate, free and mutate objects. Frees
+// are weighted as heavily as allocations so that freed slots are regularly
+// reused.
+func objectStepKinds(objects *Objects) []fuzzutil.StepKind {
+	return []fuzzutil.StepKind{
+		{
+			Name:   "alloc",
+			Weight: 2,
+			Make: func(byteConsumer *fuzzutil.ByteConsumer) fuzzutil.Step {
+				return NewAllocStep(objects, byteConsumer)
+			},
+			Parse: func(args *fuzzutil.ScriptArgs) fuzzutil.Step {
+				return newAllocStep(objects, args.Uint32(), args.Byte())
+			},
+		},
+		{
+			Name:   "free",
+			Weight: 2,
+			Make: func(byteConsumer *fuzzutil.ByteConsumer) fuzzutil.Step {
+				return NewFreeStep(objects, byteConsumer)
+			},
+			Parse: func(args *fuzzutil.ScriptArgs) fuzzutil.Step {
+				return &FreeStep{objects: objects, index: args.Uint32()}
+			},
+		},
+		{
+			Name:   "mutate",
+			Weight: 1,
+			Make: func(byteConsumer *fuzzutil.ByteConsumer) fuzzutil.Step {
+				return NewMutateStep(objects, byteConsumer)
+			},
+			Parse: func(args *fuzzutil.ScriptArgs) fuzzutil.Step {
+				return &MutateStep{objects: objects, index: args.Uint32(), newValue: args.Byte()}
+			},
+		},
+	}
+}
+
+// Returns a step maker which makes steps allocating, freeing and mutating
+// objects
+func objectStepMaker(objects *Objects) func(*fuzzutil.ByteConsumer) fuzzutil.Step {
+	return fuzzutil.NewWeightedStepMaker(objectStepKinds(objects))
 }
 
 type Objects struct {
@@ -56,8 +166,14 @@ type Objects struct {
 }
 
 func NewObjects() *Objects {
+	return newObjectsInStore(New())
+}
+
+// Returns a new Objects which allocates from store. Many Objects can share a
+// single store.
+func newObjectsInStore(store *Store) *Objects {
 	return &Objects{
-		store:       New(),
+		store:       store,
 		allocations: make([]*MultitypeAllocation, 0),
 		expected:    make([][]byte, 0),
 		live:        make([]bool, 0),
@@ -160,6 +276,13 @@ func (o *Objects) checkObject(index int) {
 	}
 }
 
+// Frees every live object
+func (o *Objects) FreeAll() {
+	for idx := range o.allocations {
+		o.Free(uint32(idx))
+	}
+}
+
 func (o *Objects) Cleanup() {
 	if err := o.store.Destroy(); err != nil {
 		panic(err)
@@ -169,17 +292,22 @@ func (o *Objects) Cleanup() {
 // Allocate an object
 type AllocStep struct {
 	objects   *Objects
+	selector  uint32
 	allocFunc func(*Store) *MultitypeAllocation
 	value     byte
 }
 
 func NewAllocStep(objects *Objects, byteConsumer *fuzzutil.ByteConsumer) *AllocStep {
-	step := &AllocStep{
+	return newAllocStep(objects, byteConsumer.Uint32(), byteConsumer.Byte())
+}
+
+func newAllocStep(objects *Objects, selector uint32, value byte) *AllocStep {
+	return &AllocStep{
 		objects:   objects,
-		allocFunc: multitypeAllocFunc(int(byteConsumer.Uint32())),
-		value:     byteConsumer.Byte(),
+		selector:  selector,
+		allocFunc: multitypeAllocFunc(int(selector)),
+		value:     value,
 	}
-	return step
 }
 
 func (s *AllocStep) DoStep() {
@@ -187,6 +315,10 @@ func (s *AllocStep) DoStep() {
 	s.objects.CheckAll()
 }
 
+func (s *AllocStep) ScriptLine() fuzzutil.ScriptLine {
+	return fuzzutil.NewScriptLine("alloc", s.selector, s.value)
+}
+
 // Free an object
 type FreeStep struct {
 	objects *Objects
@@ -206,6 +338,10 @@ func (s *FreeStep) DoStep() {
 	s.objects.CheckAll()
 }
 
+func (s *FreeStep) ScriptLine() fuzzutil.ScriptLine {
+	return fuzzutil.NewScriptLine("free", s.index)
+}
+
 type MutateStep struct {
 	objects  *Objects
 	index    uint32
@@ -225,3 +361,7 @@ func (s *MutateStep) DoStep() {
 	s.objects.Mutate(s.index, s.newValue)
 	s.objects.CheckAll()
 }
+
+func (s *MutateStep) ScriptLine() fuzzutil.ScriptLine {
+	return fuzzutil.NewScriptLine("mutate", s.index, s.newValue)
+}
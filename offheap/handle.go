@@ -0,0 +1,44 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+package offheap
+
+// Returns a numeric handle for the object referenced by r. Handles are plain
+// integers, which makes them useful as identifiers outside of the Go process,
+// or in data structures which can only hold integers. A handle can be
+// resolved back into a RefObject with ResolveObjectHandle. If r is nil 0 is
+// returned.
+//
+// A handle is only valid for the Store which allocated the object. Because
+// Compact moves objects, compacting a Store changes the handles of every
+// object it moves.
+func (r *RefObject[T]) Handle() uint64 {
+	return r.ref.Handle()
+}
+
+// Returns the RefObject identified by handle, and true, if the object is
+// still allocated in s. If the object has been freed a nil RefObject and
+// false are returned.
+//
+// The handle must have been created by a RefObject[T] allocated in s, or in
+// the Store which s was cloned from, see Store.Clone.
+// Handles created by another Store, or for objects of another type, are only
+// rejected if they don't identify a live allocation of T's size class.
+//
+// Checking whether the object is still allocated relies on the same
+// generation checks used by Weak. This is a best effort check. If the object
+// has been freed and re-allocated a multiple of 256 times the newly allocated
+// object will be returned.
+//
+// Resolving a handle while another goroutine frees the object is a data
+// race, just like calling RefObject.Value() concurrently with
+// FreeObject(...).
+func ResolveObjectHandle[T any](s *Store, handle uint64) (RefObject[T], bool) {
+	idx := typeIndex[T](s)
+	ref, ok := s.resolve(idx, handle)
+	if !ok {
+		return RefObject[T]{}, false
+	}
+	return newRefObject[T](ref), true
+}
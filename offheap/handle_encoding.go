@@ -0,0 +1,259 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+package offheap
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/fmstephe/memorymanager/offheap/internal/pointerstore"
+)
+
+// The JSON encoding of a RefSlice
+type sliceHandle struct {
+	Handle   uint64 `json:"handle"`
+	Length   int    `json:"length"`
+	Capacity int    `json:"capacity"`
+}
+
+// The JSON encoding of a RefString
+type stringHandle struct {
+	Handle uint64 `json:"handle"`
+	Length int    `json:"length"`
+}
+
+// Encodes r as its handle. A nil reference is encoded as null.
+func (r RefObject[T]) MarshalJSON() ([]byte, error) {
+	if r.IsNil() {
+		return []byte("null"), nil
+	}
+	return strconv.AppendUint(nil, r.Handle(), 10), nil
+}
+
+// Decodes a reference encoded by MarshalJSON. The decoded reference is
+// unresolved, see Rehydrate.
+func (r *RefObject[T]) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		*r = RefObject[T]{}
+		return nil
+	}
+	handle, err := strconv.ParseUint(string(data), 10, 64)
+	if err != nil {
+		return fmt.Errorf("cannot decode RefObject handle %s: %w", data, err)
+	}
+	*r = RefObject[T]{ref: pointerstore.NewUnresolved(handle)}
+	return nil
+}
+
+// Encodes r as its handle
+func (r RefObject[T]) GobEncode() ([]byte, error) {
+	return binary.AppendUvarint(nil, r.Handle()), nil
+}
+
+// Decodes a reference encoded by GobEncode. The decoded reference is
+// unresolved, see Rehydrate.
+func (r *RefObject[T]) GobDecode(data []byte) error {
+	values, err := decodeUvarints(data, 1)
+	if err != nil {
+		return fmt.Errorf("cannot decode RefObject: %w", err)
+	}
+	*r = RefObject[T]{ref: pointerstore.NewUnresolved(values[0])}
+	return nil
+}
+
+// Resolves r, decoded by UnmarshalJSON or GobDecode, against s. Returns an
+// error wrapping ErrInvalidHandle, and leaves r unresolved, if r's handle
+// doesn't identify a live allocation of T in s. References which are nil, or
+// already resolved, are unchanged.
+func (r *RefObject[T]) Rehydrate(s *Store) error {
+	handle, ok := r.ref.Unresolved()
+	if !ok {
+		return nil
+	}
+	ref, ok := s.resolve(typeIndex[T](s), handle)
+	if !ok {
+		return fmt.Errorf("cannot rehydrate RefObject handle %d: %w", handle, ErrInvalidHandle)
+	}
+	*r = newRefObject[T](ref)
+	return nil
+}
+
+// Encodes r as its handle, length and capacity. A nil reference is encoded
+// as null. Returns an error wrapping ErrView if r is a view.
+func (r RefSlice[T]) MarshalJSON() ([]byte, error) {
+	if r.IsNil() {
+		return []byte("null"), nil
+	}
+	if r.view {
+		return nil, fmt.Errorf("cannot encode RefSlice created by SubSlice: %w", ErrView)
+	}
+	return json.Marshal(sliceHandle{Handle: r.ref.Handle(), Length: r.length, Capacity: r.capacity})
+}
+
+// Decodes a reference encoded by MarshalJSON. The decoded reference is
+// unresolved, see Rehydrate.
+func (r *RefSlice[T]) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		*r = RefSlice[T]{}
+		return nil
+	}
+	handle := sliceHandle{}
+	if err := json.Unmarshal(data, &handle); err != nil {
+		return fmt.Errorf("cannot decode RefSlice: %w", err)
+	}
+	*r = unresolvedSlice[T](handle.Handle, handle.Length, handle.Capacity)
+	return nil
+}
+
+// Encodes r as its handle, length and capacity. Returns an error wrapping
+// ErrView if r is a view.
+func (r RefSlice[T]) GobEncode() ([]byte, error) {
+	if r.view {
+		return nil, fmt.Errorf("cannot encode RefSlice created by SubSlice: %w", ErrView)
+	}
+	data := binary.AppendUvarint(nil, r.ref.Handle())
+	data = binary.AppendUvarint(data, uint64(r.length))
+	return binary.AppendUvarint(data, uint64(r.capacity)), nil
+}
+
+// Decodes a reference encoded by GobEncode. The decoded reference is
+// unresolved, see Rehydrate.
+func (r *RefSlice[T]) GobDecode(data []byte) error {
+	values, err := decodeUvarints(data, 3)
+	if err != nil {
+		return fmt.Errorf("cannot decode RefSlice: %w", err)
+	}
+	*r = unresolvedSlice[T](values[0], int(values[1]), int(values[2]))
+	return nil
+}
+
+func unresolvedSlice[T any](handle uint64, length, capacity int) RefSlice[T] {
+	if handle == 0 {
+		return RefSlice[T]{}
+	}
+	return RefSlice[T]{
+		length:   length,
+		capacity: capacity,
+		ref:      pointerstore.NewUnresolved(handle),
+	}
+}
+
+// Resolves r, decoded by UnmarshalJSON or GobDecode, against s. Returns an
+// error wrapping ErrInvalidHandle, and leaves r unresolved, if r's handle
+// doesn't identify a live allocation of a []T with r's capacity in s.
+// References which are nil, or already resolved, are unchanged.
+func (r *RefSlice[T]) Rehydrate(s *Store) error {
+	handle, ok := r.ref.Unresolved()
+	if !ok {
+		return nil
+	}
+	if r.length < 0 || r.length > r.capacity || r.capacity != capacityForSlice(r.capacity) ||
+		r.capacity > maxAllocSize/max(rawSizeForType[T](), 1) {
+		return fmt.Errorf("cannot rehydrate RefSlice handle %d with length %d and capacity %d: %w", handle, r.length, r.capacity, ErrInvalidHandle)
+	}
+	ref, ok := s.resolve(sliceIndex[T](s, r.capacity), handle)
+	if !ok {
+		return fmt.Errorf("cannot rehydrate RefSlice handle %d: %w", handle, ErrInvalidHandle)
+	}
+	*r = newRefSlice[T](r.length, r.capacity, ref)
+	return nil
+}
+
+// Encodes r as its handle and length. A nil reference is encoded as null.
+// Returns an error wrapping ErrView if r is a view.
+func (r RefString) MarshalJSON() ([]byte, error) {
+	if r.IsNil() {
+		return []byte("null"), nil
+	}
+	if r.view {
+		return nil, fmt.Errorf("cannot encode RefString created by SubString: %w", ErrView)
+	}
+	return json.Marshal(stringHandle{Handle: r.ref.Handle(), Length: r.length})
+}
+
+// Decodes a reference encoded by MarshalJSON. The decoded reference is
+// unresolved, see Rehydrate.
+func (r *RefString) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		*r = RefString{}
+		return nil
+	}
+	handle := stringHandle{}
+	if err := json.Unmarshal(data, &handle); err != nil {
+		return fmt.Errorf("cannot decode RefString: %w", err)
+	}
+	*r = unresolvedString(handle.Handle, handle.Length)
+	return nil
+}
+
+// Encodes r as its handle and length. Returns an error wrapping ErrView if r
+// is a view.
+func (r RefString) GobEncode() ([]byte, error) {
+	if r.view {
+		return nil, fmt.Errorf("cannot encode RefString created by SubString: %w", ErrView)
+	}
+	data := binary.AppendUvarint(nil, r.ref.Handle())
+	return binary.AppendUvarint(data, uint64(r.length)), nil
+}
+
+// Decodes a reference encoded by GobEncode. The decoded reference is
+// unresolved, see Rehydrate.
+func (r *RefString) GobDecode(data []byte) error {
+	values, err := decodeUvarints(data, 2)
+	if err != nil {
+		return fmt.Errorf("cannot decode RefString: %w", err)
+	}
+	*r = unresolvedString(values[0], int(values[1]))
+	return nil
+}
+
+func unresolvedString(handle uint64, length int) RefString {
+	if handle == 0 {
+		return RefString{}
+	}
+	return RefString{
+		length: length,
+		ref:    pointerstore.NewUnresolved(handle),
+	}
+}
+
+// Resolves r, decoded by UnmarshalJSON or GobDecode, against s. Returns an
+// error wrapping ErrInvalidHandle, and leaves r unresolved, if r's handle
+// doesn't identify a live allocation of a string of r's length in s.
+// References which are nil, or already resolved, are unchanged.
+func (r *RefString) Rehydrate(s *Store) error {
+	handle, ok := r.ref.Unresolved()
+	if !ok {
+		return nil
+	}
+	if r.length < 0 || r.length > maxAllocSize {
+		return fmt.Errorf("cannot rehydrate RefString handle %d with length %d: %w", handle, r.length, ErrInvalidHandle)
+	}
+	ref, ok := s.resolve(s.sizeIndex(r.length), handle)
+	if !ok {
+		return fmt.Errorf("cannot rehydrate RefString handle %d: %w", handle, ErrInvalidHandle)
+	}
+	*r = newRefString(r.length, ref)
+	return nil
+}
+
+// Decodes exactly count uvarints from data
+func decodeUvarints(data []byte, count int) ([]uint64, error) {
+	values := make([]uint64, count)
+	for i := range values {
+		value, n := binary.Uvarint(data)
+		if n <= 0 {
+			return nil, fmt.Errorf("malformed value %d of %d", i, count)
+		}
+		values[i] = value
+		data = data[n:]
+	}
+	if len(data) != 0 {
+		return nil, fmt.Errorf("%d unexpected trailing bytes", len(data))
+	}
+	return values, nil
+}
@@ -0,0 +1,200 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+package offheap
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type encodedIndex struct {
+	Root   RefObject[MutableStruct]
+	Values RefSlice[int64]
+	Names  map[string]RefString
+	Empty  RefObject[MutableStruct]
+}
+
+func newEncodedIndex(s *Store) encodedIndex {
+	root := AllocObject[MutableStruct](s)
+	root.Value().Field = 42
+	return encodedIndex{
+		Root:   root,
+		Values: AllocSliceFromSlice(s, []int64{1, 2, 3}),
+		Names: map[string]RefString{
+			"a": AllocStringFromString(s, "alpha"),
+			"b": AllocStringFromString(s, "beta"),
+		},
+	}
+}
+
+func rehydrateIndex(s *Store, index *encodedIndex) error {
+	if err := index.Root.Rehydrate(s); err != nil {
+		return err
+	}
+	if err := index.Values.Rehydrate(s); err != nil {
+		return err
+	}
+	if err := index.Empty.Rehydrate(s); err != nil {
+		return err
+	}
+	for name, ref := range index.Names {
+		if err := ref.Rehydrate(s); err != nil {
+			return err
+		}
+		index.Names[name] = ref
+	}
+	return nil
+}
+
+func assertIndex(t *testing.T, expected, actual encodedIndex) {
+	assert.Equal(t, expected.Root, actual.Root)
+	assert.Equal(t, 42, actual.Root.Value().Field)
+	assert.Equal(t, expected.Values, actual.Values)
+	assert.Equal(t, []int64{1, 2, 3}, actual.Values.Value())
+	assert.Equal(t, expected.Names, actual.Names)
+	for name, expected := range map[string]string{"a": "alpha", "b": "beta"} {
+		ref := actual.Names[name]
+		assert.Equal(t, expected, ref.Value())
+	}
+	assert.True(t, actual.Empty.IsNil())
+}
+
+// Demonstrate that references encoded as JSON can be decoded and rehydrated
+// against the Store which allocated them
+func TestEncoding_JSON(t *testing.T) {
+	os := NewSized(1 << 10)
+	defer func() {
+		assert.NoError(t, os.Destroy())
+	}()
+
+	index := newEncodedIndex(os)
+	data, err := json.Marshal(index)
+	require.NoError(t, err)
+
+	decoded := encodedIndex{}
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	assert.True(t, decoded.Empty.IsNil())
+
+	require.NoError(t, rehydrateIndex(os, &decoded))
+	assertIndex(t, index, decoded)
+
+	// Rehydrating a resolved reference does nothing
+	require.NoError(t, rehydrateIndex(os, &decoded))
+	assertIndex(t, index, decoded)
+}
+
+// Demonstrate that references encoded by gob can be decoded and rehydrated
+// against the Store which allocated them
+func TestEncoding_Gob(t *testing.T) {
+	os := NewWithPools(1<<10, 4)
+	defer func() {
+		assert.NoError(t, os.Destroy())
+	}()
+
+	index := newEncodedIndex(os)
+	buf := bytes.Buffer{}
+	require.NoError(t, gob.NewEncoder(&buf).Encode(index))
+
+	decoded := encodedIndex{}
+	require.NoError(t, gob.NewDecoder(&buf).Decode(&decoded))
+
+	require.NoError(t, rehydrateIndex(os, &decoded))
+	assertIndex(t, index, decoded)
+}
+
+// Demonstrate that references can be rehydrated against a clone of the Store
+// which allocated them, resolving to the clone's allocations
+func TestEncoding_RehydrateClone(t *testing.T) {
+	os := NewSized(1 << 10)
+	defer func() {
+		assert.NoError(t, os.Destroy())
+	}()
+
+	index := newEncodedIndex(os)
+	data, err := json.Marshal(index)
+	require.NoError(t, err)
+
+	clone := os.Clone()
+	defer func() {
+		assert.NoError(t, clone.Destroy())
+	}()
+
+	decoded := encodedIndex{}
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	require.NoError(t, rehydrateIndex(clone, &decoded))
+
+	assert.Equal(t, index.Root.Handle(), decoded.Root.Handle())
+	assert.Equal(t, 42, decoded.Root.Value().Field)
+	assert.Equal(t, []int64{1, 2, 3}, decoded.Values.Value())
+	name := decoded.Names["a"]
+	assert.Equal(t, "alpha", name.Value())
+
+	// The rehydrated references refer to the clone, not the original
+	decoded.Root.Value().Field = 7
+	assert.Equal(t, 42, index.Root.Value().Field)
+}
+
+// Show that an unresolved reference can't be used, and that rehydrating a
+// handle which doesn't identify a live allocation fails, leaving the
+// reference unresolved
+func TestEncoding_Invalid(t *testing.T) {
+	os := NewSized(1 << 10)
+	defer func() {
+		assert.NoError(t, os.Destroy())
+	}()
+
+	ref := AllocObject[MutableStruct](os)
+	data, err := json.Marshal(ref)
+	require.NoError(t, err)
+	FreeObject(os, ref)
+
+	decoded := RefObject[MutableStruct]{}
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	assert.False(t, decoded.IsNil())
+	assert.Panics(t, func() { decoded.Value() })
+
+	err = decoded.Rehydrate(os)
+	assert.ErrorIs(t, err, ErrInvalidHandle)
+	assert.Panics(t, func() { decoded.Value() })
+
+	// A slice whose capacity doesn't match its handle's size class
+	slice := AllocSlice[int64](os, 2, 4)
+	data, err = json.Marshal(slice)
+	require.NoError(t, err)
+	data = bytes.Replace(data, []byte(`"capacity":4`), []byte(`"capacity":3`), 1)
+	decodedSlice := RefSlice[int64]{}
+	require.NoError(t, json.Unmarshal(data, &decodedSlice))
+	assert.ErrorIs(t, decodedSlice.Rehydrate(os), ErrInvalidHandle)
+
+	// Malformed encodings are rejected
+	assert.Error(t, json.Unmarshal([]byte(`"ref"`), &decoded))
+	assert.Error(t, decoded.GobDecode([]byte{}))
+	assert.Error(t, decoded.GobDecode([]byte{1, 2}))
+}
+
+// Show that views can't be encoded
+func TestEncoding_Views(t *testing.T) {
+	os := NewSized(1 << 10)
+	defer func() {
+		assert.NoError(t, os.Destroy())
+	}()
+
+	slice := AllocSlice[int64](os, 4, 4)
+	_, err := json.Marshal(SubSlice(os, slice, 1, 3))
+	assert.ErrorIs(t, err, ErrView)
+	_, err = SubSlice(os, slice, 1, 3).GobEncode()
+	assert.ErrorIs(t, err, ErrView)
+
+	str := AllocStringFromString(os, "hello")
+	_, err = json.Marshal(SubString(os, str, 1, 3))
+	assert.ErrorIs(t, err, ErrView)
+	_, err = SubString(os, str, 1, 3).GobEncode()
+	assert.ErrorIs(t, err, ErrView)
+}
@@ -0,0 +1,78 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+package offheap
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Demonstrate that a handle resolves to its object until the object is freed
+func Test_Handle_ResolveFree(t *testing.T) {
+	ss := NewSized(1 << 8)
+	defer func() {
+		assert.NoError(t, ss.Destroy())
+	}()
+
+	nilRef := RefObject[MutableStruct]{}
+	assert.Equal(t, uint64(0), nilRef.Handle())
+	_, ok := ResolveObjectHandle[MutableStruct](ss, 0)
+	assert.False(t, ok)
+
+	ref := AllocObject[MutableStruct](ss)
+	ref.Value().Field = 42
+	handle := ref.Handle()
+	assert.NotEqual(t, uint64(0), handle)
+
+	resolved, ok := ResolveObjectHandle[MutableStruct](ss, handle)
+	require.True(t, ok)
+	assert.Equal(t, ref, resolved)
+	assert.Equal(t, 42, resolved.Value().Field)
+
+	FreeObject(ss, ref)
+
+	resolved, ok = ResolveObjectHandle[MutableStruct](ss, handle)
+	assert.False(t, ok)
+	assert.True(t, resolved.IsNil())
+
+	// The freed slot is reused, but the old handle still doesn't resolve
+	newRef := AllocObject[MutableStruct](ss)
+	assert.Equal(t, 1, StatsForType[MutableStruct](ss).Reused)
+	_, ok = ResolveObjectHandle[MutableStruct](ss, handle)
+	assert.False(t, ok)
+
+	resolved, ok = ResolveObjectHandle[MutableStruct](ss, newRef.Handle())
+	assert.True(t, ok)
+	assert.Equal(t, newRef, resolved)
+}
+
+// Demonstrate that handles resolve to the correct object in a Store with
+// multiple pools
+func Test_Handle_Pools(t *testing.T) {
+	ss := NewWithPools(1<<8, 4)
+	defer func() {
+		assert.NoError(t, ss.Destroy())
+	}()
+
+	refs := []RefObject[MutableStruct]{}
+	for i := range 1000 {
+		ref := AllocObject[MutableStruct](ss)
+		ref.Value().Field = i
+		refs = append(refs, ref)
+	}
+
+	for i, ref := range refs {
+		resolved, ok := ResolveObjectHandle[MutableStruct](ss, ref.Handle())
+		require.True(t, ok)
+		assert.Equal(t, ref, resolved)
+		assert.Equal(t, i, resolved.Value().Field)
+	}
+
+	// A handle for a pool the store doesn't have is not resolved
+	_, ok := ResolveObjectHandle[MutableStruct](ss, refs[0].Handle()|0xFFFF<<40)
+	assert.False(t, ok)
+}
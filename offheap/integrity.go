@@ -0,0 +1,92 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+package offheap
+
+import (
+	"fmt"
+
+	"github.com/fmstephe/memorymanager/offheap/internal/pointerstore"
+)
+
+// Records a checksum of the object referenced by r, which Store.Verify uses
+// to detect modifications to the object. Each time the object is
+// legitimately modified it must be committed again.
+//
+// Integrity checking is opt-in, only allocations which have been committed
+// are verified. Committing doesn't change the cost of any other operation.
+func (r *RefObject[T]) Commit(s *Store) {
+	s.commit(typeIndex[T](s), r.ref)
+}
+
+// Records a checksum of the slice referenced by r, which Store.Verify uses
+// to detect modifications to the slice, see RefObject.Commit. Views created
+// by SubSlice can't be committed, commit the original RefSlice instead.
+func (r *RefSlice[T]) Commit(s *Store) {
+	if r.view {
+		panic("cannot commit a RefSlice created by SubSlice, commit the original RefSlice instead")
+	}
+	s.commit(sliceIndex[T](s, r.capacity), r.ref)
+}
+
+// Records a checksum of the string referenced by r, which Store.Verify uses
+// to detect modifications to the string, see RefObject.Commit. Views created
+// by SubString can't be committed, commit the original RefString instead.
+func (r *RefString) Commit(s *Store) {
+	if r.view {
+		panic("cannot commit a RefString created by SubString, commit the original RefString instead")
+	}
+	s.commit(s.sizeIndex(r.length), r.ref)
+}
+
+// A committed allocation whose contents have changed since it was committed
+type CorruptAllocation struct {
+	// The size class of the allocation
+	ClassSize int
+	// The handle of the allocation, see RefObject.Handle
+	Handle uint64
+}
+
+// Returned by Store.Verify when committed allocations have been modified
+type IntegrityError struct {
+	Corrupted []CorruptAllocation
+}
+
+func (e *IntegrityError) Error() string {
+	return fmt.Sprintf("%d committed allocations have been modified since they were committed", len(e.Corrupted))
+}
+
+// Scans every allocation in the Store, checking that each committed
+// allocation still matches the checksum recorded when it was committed. If
+// any committed allocation has been modified an *IntegrityError is returned,
+// otherwise nil is returned.
+//
+// This detects corruption, such as stray writes through stale references,
+// which is otherwise silent. Verify reads every live allocation, so it is
+// best run periodically, or when debugging.
+//
+// Verify must not be called concurrently with writes to any allocation in
+// the Store, or with allocating or freeing.
+func (s *Store) Verify() error {
+	corrupted := []CorruptAllocation{}
+	for _, classes := range s.allPools() {
+		for idx, class := range classes {
+			store := class.loaded()
+			if store == nil {
+				continue
+			}
+			store.Verify(func(ref pointerstore.RefPointer) {
+				corrupted = append(corrupted, CorruptAllocation{
+					ClassSize: s.classSize(idx),
+					Handle:    ref.Handle(),
+				})
+			})
+		}
+	}
+
+	if len(corrupted) > 0 {
+		return &IntegrityError{Corrupted: corrupted}
+	}
+	return nil
+}
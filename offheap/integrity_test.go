@@ -0,0 +1,114 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+package offheap
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Demonstrate that Verify detects modifications to committed objects, slices
+// and strings, for Stores with and without pools
+func Test_Verify(t *testing.T) {
+	for _, s := range []*Store{New(), NewWithPools(1<<12, 2)} {
+		objects := []RefObject[MutableStruct]{}
+		for i := range 100 {
+			r := AllocObjectFrom(s, MutableStruct{Field: i})
+			r.Commit(s)
+			objects = append(objects, r)
+		}
+		slice := AllocSliceFromSlice(s, []int32{1, 2, 3})
+		slice.Commit(s)
+		str := AllocStringFromString(s, "committed")
+		str.Commit(s)
+		uncommitted := AllocObject[MutableStruct](s)
+
+		require.NoError(t, s.Verify())
+
+		// Modifying uncommitted objects is allowed
+		uncommitted.Value().Field = 1
+		require.NoError(t, s.Verify())
+
+		// Stray writes to committed allocations are detected
+		objects[50].Value().Field = -1
+		slice.Value()[1] = -1
+		err := s.Verify()
+		integrityErr := &IntegrityError{}
+		require.True(t, errors.As(err, &integrityErr))
+		require.Len(t, integrityErr.Corrupted, 2)
+
+		handles := []uint64{}
+		for _, c := range integrityErr.Corrupted {
+			handles = append(handles, c.Handle)
+		}
+		assert.Contains(t, handles, objects[50].Handle())
+		r, ok := ResolveObjectHandle[MutableStruct](s, objects[50].Handle())
+		require.True(t, ok)
+		assert.Equal(t, objects[50], r)
+
+		// Committing again accepts the modifications
+		objects[50].Commit(s)
+		slice.Commit(s)
+		require.NoError(t, s.Verify())
+
+		// Views can't be committed
+		view := SubSlice(s, slice, 0, 1)
+		assert.Panics(t, func() { view.Commit(s) })
+		strView := SubString(s, str, 0, 1)
+		assert.Panics(t, func() { strView.Commit(s) })
+
+		// Freed allocations can't be committed, and aren't verified
+		FreeObject(s, objects[0])
+		assert.Panics(t, func() { objects[0].Commit(s) })
+		require.NoError(t, s.Verify())
+
+		assert.NoError(t, s.Destroy())
+	}
+}
+
+// Demonstrate that appending to a committed slice or string, either in place
+// or by moving it to a new size class, leaves an uncommitted allocation which
+// Verify accepts, just like a new allocation
+func Test_Verify_AppendAfterCommit(t *testing.T) {
+	s := New()
+	defer func() {
+		assert.NoError(t, s.Destroy())
+	}()
+
+	// Both of these appends stay in the same size class
+	inPlaceSlice := AllocSlice[int32](s, 1, 4)
+	inPlaceSlice.Commit(s)
+	inPlaceSlice = Append(s, inPlaceSlice, 2)
+	inPlaceStr := AllocStringFromString(s, "abc")
+	inPlaceStr.Commit(s)
+	inPlaceStr = AppendString(s, inPlaceStr, "d")
+
+	// Both of these appends move to a larger size class
+	movedSlice := AllocSliceFromSlice(s, []int32{1})
+	movedSlice.Commit(s)
+	movedSlice = Append(s, movedSlice, 2)
+	movedStr := AllocStringFromString(s, "a")
+	movedStr.Commit(s)
+	movedStr = AppendString(s, movedStr, "bcd")
+
+	require.NoError(t, s.Verify())
+
+	// The appended allocations can be committed and verified again
+	inPlaceSlice.Commit(s)
+	inPlaceStr.Commit(s)
+	movedSlice.Commit(s)
+	movedStr.Commit(s)
+	require.NoError(t, s.Verify())
+
+	inPlaceSlice.Value()[0] = -1
+	err := s.Verify()
+	integrityErr := &IntegrityError{}
+	require.True(t, errors.As(err, &integrityErr))
+	require.Len(t, integrityErr.Corrupted, 1)
+	assert.Equal(t, inPlaceSlice.ref.Handle(), integrityErr.Corrupted[0].Handle)
+}
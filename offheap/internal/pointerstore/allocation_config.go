@@ -5,6 +5,8 @@
 package pointerstore
 
 import (
+	"fmt"
+	"os"
 	"unsafe"
 
 	"github.com/fmstephe/flib/fmath"
@@ -20,11 +22,94 @@ type AllocConfig struct {
 	MetadataSize      uint64
 	TotalMetadataSize uint64
 	TotalSlabSize     uint64
+
+	// Indicates whether slabs should be backed by huge pages, where the
+	// operating system supports it
+	HugePages bool
+
+	// The size of the inaccessible guard region between the objects and
+	// the metadata of each slab, 0 if slabs have no guard region, see
+	// NewAllocConfigWithGuardPage
+	GuardSize uint64
+
+	// The number of objects each slab was sized to hold, and the largest
+	// size a slab could be given, when the slab size was chosen by
+	// NewAllocConfigForObjectsPerSlab. Both are 0 when the slab size was
+	// requested directly.
+	TargetObjectsPerSlab uint64
+	MaxSlabSize          uint64
 }
 
+// The size of a huge page on most systems. Huge pages can only back slabs,
+// or the parts of slabs, which are at least this large.
+const HugePageSize = 1 << 21
+
 func NewAllocConfigBySize(requestedObjectSize uint64, requestedSlabSize uint64) AllocConfig {
 	objectSize := uint64(fmath.NxtPowerOfTwo(int64(requestedObjectSize)))
+	return newAllocConfig(requestedObjectSize, objectSize, requestedSlabSize)
+}
+
+// Returns an AllocConfig whose object size is exactly objectSize, rather than
+// being rounded up to a power of two.
+//
+// Objects are laid out contiguously in each slab, so the caller must ensure
+// that objectSize is either a power of two or a multiple of 8 to keep every
+// object, and the metadata following them, correctly aligned.
+func NewAllocConfigByExactSize(objectSize uint64, requestedSlabSize uint64) AllocConfig {
+	if objectSize == 0 {
+		panic("cannot create AllocConfig with 0 object size")
+	}
+	return newAllocConfig(objectSize, objectSize, requestedSlabSize)
+}
+
+// Returns an AllocConfig where each slab holds a single object, followed by
+// an inaccessible guard page. Any access past the end of the object faults
+// immediately, rather than silently corrupting a neighbouring object.
+//
+// The object size is rounded up to a power of two, and then up to a multiple
+// of the page size, so that the end of each object is the start of its guard
+// page.
+func NewAllocConfigWithGuardPage(requestedObjectSize uint64) AllocConfig {
+	pageSize := uint64(os.Getpagesize())
+	objectSize := uint64(fmath.NxtPowerOfTwo(int64(requestedObjectSize)))
+	objectSize = (objectSize + pageSize - 1) / pageSize * pageSize
+
+	conf := newAllocConfig(requestedObjectSize, objectSize, objectSize)
+	conf.GuardSize = pageSize
+	conf.TotalSlabSize += pageSize
+	return conf
+}
+
+// Returns an AllocConfig whose slab size is chosen so that each slab holds
+// objectsPerSlab objects. The slab size is at least minSlabSize, so that
+// slabs of tiny objects aren't smaller than a page, and at most maxSlabSize,
+// so that slabs of large objects don't become huge mappings. A slab always
+// holds at least one object.
+//
+// Like every slab size, the chosen slab size is rounded up to a power of two.
+func NewAllocConfigForObjectsPerSlab(requestedObjectSize, objectsPerSlab, minSlabSize, maxSlabSize uint64) AllocConfig {
+	if objectsPerSlab == 0 {
+		panic("cannot create AllocConfig with 0 objects per slab")
+	}
+	if minSlabSize > maxSlabSize {
+		panic(fmt.Errorf("minimum slab size (%d) is larger than the maximum slab size (%d)", minSlabSize, maxSlabSize))
+	}
+
+	objectSize := uint64(fmath.NxtPowerOfTwo(int64(requestedObjectSize)))
+
+	// Avoid overflowing when objectSize*objectsPerSlab is very large
+	slabSize := maxSlabSize
+	if objectsPerSlab <= maxSlabSize/objectSize {
+		slabSize = max(objectSize*objectsPerSlab, minSlabSize)
+	}
+
+	conf := newAllocConfig(requestedObjectSize, objectSize, slabSize)
+	conf.TargetObjectsPerSlab = objectsPerSlab
+	conf.MaxSlabSize = maxSlabSize
+	return conf
+}
 
+func newAllocConfig(requestedObjectSize, objectSize, requestedSlabSize uint64) AllocConfig {
 	totalObjectSize := uint64(fmath.NxtPowerOfTwo(int64(requestedSlabSize)))
 
 	if totalObjectSize < objectSize {
@@ -34,6 +119,9 @@ func NewAllocConfigBySize(requestedObjectSize uint64, requestedSlabSize uint64)
 	}
 
 	objectsPerSlab := totalObjectSize / objectSize
+	// If objectSize is not a power of two there may be some unused space
+	// at the end of the slab, we don't include it
+	totalObjectSize = objectsPerSlab * objectSize
 
 	// TODO have a think about this - we don't strictly _need_ the metadata
 	// to be aligned by a power of 2 (do we?)
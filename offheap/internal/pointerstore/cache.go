@@ -0,0 +1,213 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+package pointerstore
+
+import (
+	"fmt"
+	"sync"
+)
+
+// A Cache holds a small number of free slots taken from a Store, so that
+// allocations and frees can be made without taking the Store's free list
+// lock.
+//
+// Each Cache has its own lock, so goroutines which each use a different
+// Cache rarely contend with each other. When a Cache is empty it is refilled
+// with a batch of slots from the Store's free list, and when it is full the
+// oldest half of its slots are returned to the free list in a single batch.
+//
+// Cached slots are free. A stale reference to a cached slot is detected
+// exactly as it would be for a slot in the free list, and cached slots are
+// counted as free, not live, in Stats.
+type Cache struct {
+	store *Store
+	// lock protects slots, and the metadata of every slot in slots
+	lock  sync.Mutex
+	slots []RefPointer
+	// Keeps Caches used on different CPUs on different cache lines
+	_ [64]byte
+}
+
+// Creates count Caches, each holding at most size free slots, see Cache.
+// EnableCaches must be called before the store is used.
+func (s *Store) EnableCaches(count, size int) {
+	if count < 1 {
+		panic(fmt.Errorf("cache count (%d) must be at least 1", count))
+	}
+	if size < 2 {
+		panic(fmt.Errorf("cache size (%d) must be at least 2", size))
+	}
+
+	s.caches = make([]Cache, count)
+	for i := range s.caches {
+		s.caches[i].store = s
+		s.caches[i].slots = make([]RefPointer, 0, size)
+	}
+}
+
+// Returns the Cache at idx, see EnableCaches
+func (s *Store) Cache(idx int) *Cache {
+	return &s.caches[idx]
+}
+
+func (c *Cache) Alloc() RefPointer {
+	return c.AllocRequested(c.store.allocConf.ObjectSize)
+}
+
+// Allocates like Store.AllocRequested, taking a free slot from the Cache if
+// there is one.
+func (c *Cache) AllocRequested(requested uint64) RefPointer {
+	s := c.store
+	s.checkSealed("allocate")
+
+	c.lock.Lock()
+	if len(c.slots) > 0 {
+		s.cacheHits.Add(1)
+	} else {
+		s.cacheMisses.Add(1)
+		c.refill()
+		if len(c.slots) == 0 {
+			// The free list is empty too, allocate a new slot
+			c.lock.Unlock()
+			return s.AllocRequested(requested)
+		}
+	}
+	r := c.slots[len(c.slots)-1]
+	c.slots = c.slots[:len(c.slots)-1]
+	r.AllocFromFree()
+	c.lock.Unlock()
+
+	s.allocs.Add(1)
+	s.requestedBytes.Add(requested)
+	s.reused.Add(1)
+	return r
+}
+
+// Allocates like Store.AllocPadded, taking a free slot from the Cache if
+// there is one.
+func (c *Cache) AllocPadded(requested uint64) RefPointer {
+	c.store.paddedAllocs.Add(1)
+	return c.AllocRequested(requested)
+}
+
+func (c *Cache) Free(r RefPointer) {
+	if err := c.TryFree(r); err != nil {
+		panic(err)
+	}
+}
+
+// Frees the allocation referenced by r into the Cache, returning an error
+// like Store.TryFree if r can't be freed. If the store has a quarantine r is
+// freed into the quarantine instead, see Store.SetQuarantine.
+func (c *Cache) TryFree(r RefPointer) error {
+	s := c.store
+	if err := s.sealedErr("free"); err != nil {
+		return err
+	}
+	if s.quarantining.Load() {
+		return s.TryFree(r)
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	// The slot is marked as free, but is not linked into the free list
+	if err := r.TryFree(RefPointer{}); err != nil {
+		return err
+	}
+	if len(c.slots) == cap(c.slots) {
+		c.flush(len(c.slots) / 2)
+	}
+	c.slots = append(c.slots, r)
+
+	s.frees.Add(1)
+	s.countTagFree(r)
+	return nil
+}
+
+// Moves up to half of the Cache's capacity of slots from the free list into
+// the Cache. The caller must hold c.lock.
+func (c *Cache) refill() {
+	s := c.store
+	s.freeLock.Lock()
+	defer s.freeLock.Unlock()
+
+	for len(c.slots) < cap(c.slots)/2 && !s.rootFree.IsNil() {
+		r := s.rootFree
+		s.rootFree = unlinkFree(r)
+		c.slots = append(c.slots, r)
+	}
+}
+
+// Returns the oldest n slots in the Cache to the free list. The caller must
+// hold c.lock.
+func (c *Cache) flush(n int) {
+	s := c.store
+	s.freeLock.Lock()
+	for _, r := range c.slots[:n] {
+		s.linkFree(r)
+	}
+	s.freeLock.Unlock()
+
+	c.slots = append(c.slots[:0], c.slots[n:]...)
+}
+
+// Removes r, which must be the first slot in the free list, from the free
+// list. r remains free. Returns the next slot in the free list.
+func unlinkFree(r RefPointer) RefPointer {
+	meta := r.metadata()
+	next := meta.nextFree
+	meta.nextFree = r
+	if next == r {
+		return RefPointer{}
+	}
+	return next
+}
+
+// Links r, which must be free but not in the free list, into the free list.
+// The caller must hold freeLock.
+func (s *Store) linkFree(r RefPointer) {
+	if !s.rootFree.IsNil() {
+		r.metadata().nextFree = s.rootFree
+	}
+	s.rootFree = r
+}
+
+// Locks every Cache. Caches must be locked before freeLock.
+func (s *Store) lockCaches() {
+	for i := range s.caches {
+		s.caches[i].lock.Lock()
+	}
+}
+
+func (s *Store) unlockCaches() {
+	for i := range s.caches {
+		s.caches[i].lock.Unlock()
+	}
+}
+
+// Returns every cached slot to the free list. The caller must hold every
+// Cache's lock, and freeLock.
+func (s *Store) drainCaches() {
+	for i := range s.caches {
+		c := &s.caches[i]
+		for _, r := range c.slots {
+			s.linkFree(r)
+		}
+		c.slots = c.slots[:0]
+	}
+}
+
+// Returns the number of slots held in every Cache
+func (s *Store) cachedSlots() int {
+	cached := 0
+	for i := range s.caches {
+		c := &s.caches[i]
+		c.lock.Lock()
+		cached += len(c.slots)
+		c.lock.Unlock()
+	}
+	return cached
+}
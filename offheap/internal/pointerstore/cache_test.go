@@ -0,0 +1,184 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+package pointerstore
+
+import (
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Demonstrate that freed slots are held in a Cache, and are allocated again
+// from that Cache, while the Cache's batches are taken from, and returned to,
+// the free list
+func TestCache(t *testing.T) {
+	conf := NewAllocConfigBySize(8, 32*8)
+	store := New(conf)
+	defer func() {
+		assert.NoError(t, store.Destroy())
+	}()
+	store.EnableCaches(2, 4)
+	cache := store.Cache(0)
+
+	refs := []RefPointer{}
+	for range 10 {
+		refs = append(refs, cache.Alloc())
+	}
+	// The free list was empty, so every allocation missed
+	stats := store.Stats()
+	assert.Equal(t, 10, stats.CacheMisses)
+	assert.Equal(t, 0, stats.CacheHits)
+	assert.Equal(t, 10, stats.RawAllocs)
+
+	// The first four frees fill the cache, the fifth returns the oldest
+	// two cached slots to the free list
+	for _, ref := range refs[:5] {
+		cache.Free(ref)
+	}
+	stats = store.Stats()
+	assert.Equal(t, 3, stats.Cached)
+	assert.Equal(t, 5, stats.Live)
+	assert.Equal(t, 5*8, stats.LiveBytes)
+
+	// Cached slots are free, stale references are detected
+	for _, ref := range refs[:5] {
+		assert.False(t, ref.IsLive())
+		assert.Panics(t, func() { ref.DataPtr() })
+		assert.Panics(t, func() { cache.Free(ref) })
+		assert.Panics(t, func() { store.Free(ref) })
+	}
+
+	// The most recently freed slots are allocated first
+	assert.Equal(t, refs[4].Slot(), allocCacheSlot(cache))
+	assert.Equal(t, refs[3].Slot(), allocCacheSlot(cache))
+	assert.Equal(t, refs[2].Slot(), allocCacheSlot(cache))
+	assert.Equal(t, 3, store.Stats().CacheHits)
+
+	// The empty cache is refilled with half its size of slots from the
+	// free list
+	assert.ElementsMatch(t, []int{refs[0].Slot(), refs[1].Slot()}, []int{allocCacheSlot(cache), allocCacheSlot(cache)})
+	stats = store.Stats()
+	assert.Equal(t, 11, stats.CacheMisses)
+	assert.Equal(t, 4, stats.CacheHits)
+	assert.Equal(t, 5, stats.Reused)
+	assert.Equal(t, 0, stats.Cached)
+
+	// The other cache shares the same slots
+	assert.Equal(t, 10, allocCacheSlot(store.Cache(1)))
+}
+
+// Allocates from cache, returning the slot of the new allocation
+func allocCacheSlot(cache *Cache) int {
+	ref := cache.Alloc()
+	return ref.Slot()
+}
+
+// Show that frees through a Cache go to the quarantine while the store has
+// one
+func TestCache_Quarantine(t *testing.T) {
+	conf := NewAllocConfigBySize(8, 32*8)
+	store := New(conf)
+	defer func() {
+		assert.NoError(t, store.Destroy())
+	}()
+	store.EnableCaches(1, 4)
+	store.SetQuarantine(2)
+	cache := store.Cache(0)
+
+	ref := cache.Alloc()
+	cache.Free(ref)
+	stats := store.Stats()
+	assert.Equal(t, 1, stats.Quarantined)
+	assert.Equal(t, 0, stats.Cached)
+
+	// The quarantined slot isn't reused
+	assert.NotEqual(t, ref.Slot(), allocCacheSlot(cache))
+}
+
+// Demonstrate that compacting, cloning and reclaiming a store treat cached
+// slots as free slots
+func TestCache_StoreOperations(t *testing.T) {
+	// Each slot is a whole page, so free slots can be reclaimed
+	pageSize := uint64(os.Getpagesize())
+	conf := NewAllocConfigBySize(pageSize, 8*pageSize)
+	store := New(conf)
+	defer func() {
+		assert.NoError(t, store.Destroy())
+	}()
+	store.EnableCaches(1, 8)
+	cache := store.Cache(0)
+
+	refs := []RefPointer{}
+	for range 6 {
+		refs = append(refs, cache.Alloc())
+	}
+	for _, ref := range refs[:3] {
+		cache.Free(ref)
+	}
+	require.Equal(t, 3, store.Stats().Cached)
+
+	// The slots cached in store are in the clone's free list
+	clone := store.Clone()
+	defer func() {
+		assert.NoError(t, clone.Destroy())
+	}()
+	assert.Equal(t, 0, clone.Stats().Cached)
+	cloneCache := clone.Cache(0)
+	assert.ElementsMatch(t, []int{0, 1, 2}, []int{allocCacheSlot(cloneCache), allocCacheSlot(cloneCache), allocCacheSlot(cloneCache)})
+	assert.Equal(t, 6, allocCacheSlot(cloneCache))
+
+	// Reclaim advises the cached slots
+	_, err := store.Reclaim(0, false)
+	require.NoError(t, err)
+	assert.Greater(t, store.Stats().AdvisedBytes, 0)
+
+	// Compaction empties the caches
+	moved := 0
+	store.Compact(func(_, _ RefPointer) { moved++ })
+	assert.Equal(t, 3, moved)
+	assert.Equal(t, 0, store.Stats().Cached)
+	assert.Equal(t, 3, store.Stats().Live)
+	assert.ElementsMatch(t, []int{3, 4, 5}, []int{allocCacheSlot(cache), allocCacheSlot(cache), allocCacheSlot(cache)})
+}
+
+// Show that concurrent allocations and frees through different Caches never
+// hand out the same slot twice
+func TestCache_Concurrent(t *testing.T) {
+	conf := NewAllocConfigBySize(8, 32*8)
+	store := New(conf)
+	defer func() {
+		assert.NoError(t, store.Destroy())
+	}()
+	store.EnableCaches(4, 8)
+
+	wg := sync.WaitGroup{}
+	for i := range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			cache := store.Cache(i)
+			for round := range 100 {
+				refs := []RefPointer{}
+				for range 1 + round%20 {
+					ref := cache.Alloc()
+					ref.Bytes(1)[0] = byte(i)
+					refs = append(refs, ref)
+				}
+				for _, ref := range refs {
+					assert.Equal(t, byte(i), ref.Bytes(1)[0])
+					cache.Free(ref)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	stats := store.Stats()
+	assert.Equal(t, 0, stats.Live)
+	assert.Equal(t, stats.Allocs, stats.CacheHits+stats.CacheMisses)
+}
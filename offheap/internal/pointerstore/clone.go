@@ -0,0 +1,103 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+package pointerstore
+
+import (
+	"unsafe"
+)
+
+// Returns a new Store which is an independent copy of s. Every slab, and the
+// contents and metadata of every allocation slot, is copied. Each allocation
+// in the new Store occupies the same slot, with the same generation, as it
+// does in s, so a handle resolves to the same allocation in both stores, see
+// RefPointer.Handle.
+//
+// References are addresses, so every existing reference still refers to the
+// allocation in s. References to the copied allocations are found using
+// Resolve on the new Store.
+//
+// Clone must not be called concurrently with any other use of the store.
+func (s *Store) Clone() *Store {
+	s.lockCaches()
+	defer s.unlockCaches()
+	s.freeLock.Lock()
+	defer s.freeLock.Unlock()
+	s.objectsLock.RLock()
+	defer s.objectsLock.RUnlock()
+
+	clone := NewInPool(s.allocConf, int(s.pool))
+	for range s.objects {
+		objects, metas, hugePages := mmapSlab(s.allocConf)
+		clone.objects = append(clone.objects, objects)
+		clone.metadata = append(clone.metadata, metas)
+		clone.hugePages = append(clone.hugePages, hugePages)
+	}
+
+	for i := range s.objects {
+		cloneRegions := slabRegions(clone.objects[i][0], s.allocConf)
+		for j, region := range slabRegions(s.objects[i][0], s.allocConf) {
+			copy(cloneRegions[j], region)
+		}
+	}
+
+	// The free list is made of references to slots in s, each must be
+	// replaced with a reference to the same slot in the clone
+	for i := range clone.metadata {
+		for _, meta := range clone.metadata[i] {
+			m := (*metadata)(unsafe.Pointer(meta))
+			if !m.nextFree.IsNil() {
+				m.nextFree = clone.cloneReference(m.nextFree)
+			}
+		}
+	}
+	if !s.rootFree.IsNil() {
+		clone.rootFree = clone.cloneReference(s.rootFree)
+	}
+	for _, ref := range s.quarantine {
+		clone.quarantine = append(clone.quarantine, clone.cloneReference(ref))
+	}
+	clone.maxQuarantine = s.maxQuarantine
+	clone.quarantining.Store(s.quarantining.Load())
+
+	// The clone's caches start empty, so the slots cached in s are free
+	// slots in the clone's free list. s may be sealed, so s's caches are
+	// left untouched.
+	if len(s.caches) > 0 {
+		clone.EnableCaches(len(s.caches), cap(s.caches[0].slots))
+	}
+	for i := range s.caches {
+		for _, ref := range s.caches[i].slots {
+			clone.linkFree(clone.cloneReference(ref))
+		}
+	}
+
+	clone.allocs.Store(s.allocs.Load())
+	clone.frees.Store(s.frees.Load())
+	clone.reused.Store(s.reused.Load())
+	clone.requestedBytes.Store(s.requestedBytes.Load())
+	clone.advisedBytes.Store(s.advisedBytes.Load())
+	clone.paddedAllocs.Store(s.paddedAllocs.Load())
+	clone.cacheHits.Store(s.cacheHits.Load())
+	clone.cacheMisses.Store(s.cacheMisses.Load())
+	s.tags.Range(func(tag, counters any) bool {
+		clone.tags.Store(tag, counters.(*tagCounters).clone())
+		return true
+	})
+	clone.allocIdx.Store(s.allocIdx.Load())
+
+	return clone
+}
+
+// Returns a reference, with the same generation, to the slot in s which ref
+// refers to in the store s was cloned from
+func (s *Store) cloneReference(ref RefPointer) RefPointer {
+	slot := uint64(ref.metadata().slot)
+	slabIdx := slot / s.allocConf.ObjectsPerSlab
+	offsetIdx := slot % s.allocConf.ObjectsPerSlab
+
+	cloned := NewReference(s.objects[slabIdx][offsetIdx], s.metadata[slabIdx][offsetIdx])
+	cloned.setGen(ref.Gen())
+	return cloned
+}
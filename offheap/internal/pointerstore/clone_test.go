@@ -0,0 +1,72 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+package pointerstore
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Demonstrate that a cloned store contains a copy of every allocation, found
+// by handle, and that the two stores are independent afterwards
+func TestClone(t *testing.T) {
+	conf := NewAllocConfigBySize(8, 32*8)
+	store := NewInPool(conf, 3)
+	defer func() {
+		assert.NoError(t, store.Destroy())
+	}()
+
+	all := []RefPointer{}
+	for i := range 100 {
+		ref := store.Alloc()
+		ref.Bytes(8)[0] = byte(i)
+		all = append(all, ref)
+	}
+	refs := []RefPointer{}
+	for i, ref := range all {
+		if i%3 == 0 {
+			store.Free(ref)
+		} else {
+			refs = append(refs, ref)
+		}
+	}
+
+	clone := store.Clone()
+	defer func() {
+		assert.NoError(t, clone.Destroy())
+	}()
+	assert.Equal(t, store.Stats(), clone.Stats())
+
+	cloned := []RefPointer{}
+	for _, ref := range refs {
+		c, ok := clone.Resolve(ref.Handle())
+		require.True(t, ok)
+		assert.NotEqual(t, ref.DataPtr(), c.DataPtr())
+		assert.Equal(t, ref.Bytes(8), c.Bytes(8))
+		cloned = append(cloned, c)
+	}
+
+	// Modifying the clone doesn't modify the original
+	for i, c := range cloned {
+		c.Bytes(8)[0] = 0xFF
+		assert.NotEqual(t, byte(0xFF), refs[i].Bytes(8)[0])
+	}
+
+	// The clone's free list is its own, freed slots are reused from the
+	// clone's slabs
+	for range 34 {
+		c := clone.Alloc()
+		_, ok := store.Resolve(c.Handle())
+		assert.False(t, ok)
+	}
+	assert.Equal(t, store.Stats().Slabs, clone.Stats().Slabs)
+	for _, c := range cloned {
+		clone.Free(c)
+	}
+	assert.Equal(t, 34, clone.Stats().Live)
+	assert.Equal(t, len(refs), store.Stats().Live)
+}
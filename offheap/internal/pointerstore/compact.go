@@ -0,0 +1,154 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+package pointerstore
+
+// Moves live allocations into the free slots at the start of the store, and
+// releases every slab which no longer contains any live allocations. Returns
+// the number of slabs released.
+//
+// Pinned allocations are never moved. A slab containing a pinned allocation,
+// or preceding a slab containing a pinned allocation, is not released.
+//
+// Each time an allocation is moved, moved is called with the old reference
+// and the new reference. After compaction the old reference is invalid, the
+// caller must use moved to update every copy of it. The callback must not
+// allocate from, or free to, this store.
+//
+// Compact must not be called concurrently with any other use of the store,
+// including reading allocations via their references.
+func (s *Store) Compact(moved func(oldRef, newRef RefPointer)) int {
+	s.checkSealed("compact")
+	s.lockCaches()
+	defer s.unlockCaches()
+	s.freeLock.Lock()
+	defer s.freeLock.Unlock()
+	s.objectsLock.Lock()
+	defer s.objectsLock.Unlock()
+
+	// Cached slots are free slots like any other, the free list is rebuilt
+	// from every free slot below
+	s.drainCaches()
+
+	allocated := int(s.allocIdx.Load())
+	live := int(s.allocs.Load() - s.frees.Load())
+
+	// Fill free slots at the start of the store with live allocations taken
+	// from the end of the store
+	lo, hi := 0, allocated-1
+	for {
+		for lo < hi && !s.slotIsFree(lo) {
+			lo++
+		}
+		for lo < hi && (s.slotIsFree(hi) || s.slotIsPinned(hi)) {
+			hi--
+		}
+		if lo >= hi {
+			break
+		}
+
+		oldRef := s.slotReference(hi)
+		newRef := s.slotReference(lo)
+
+		// Claim the free slot, invalidating any stale references to
+		// it
+		newMeta := newRef.metadata()
+		newMeta.nextFree = RefPointer{}
+		newMeta.gen++
+		newRef.setGen(newMeta.gen)
+
+		size := int(s.allocConf.ObjectSize)
+		copy(newRef.Bytes(size), oldRef.Bytes(size))
+
+		// The moved allocation keeps its committed checksum, and its tag
+		oldMeta := oldRef.metadata()
+		newMeta.committed = oldMeta.committed
+		newMeta.checksum = oldMeta.checksum
+		newMeta.tag = oldMeta.tag
+
+		// Mark the old slot as free, so it is reset below
+		oldRef.metadata().nextFree = oldRef
+
+		moved(oldRef, newRef)
+		lo++
+		hi--
+	}
+
+	// Every live allocation, except those which are pinned, is now in the
+	// first live slots. Pinned allocations may remain after these.
+	used := live
+	for i := allocated - 1; i >= live; i-- {
+		if !s.slotIsFree(i) {
+			used = i + 1
+			break
+		}
+	}
+
+	perSlab := int(s.allocConf.ObjectsPerSlab)
+	keptSlabs := (used + perSlab - 1) / perSlab
+
+	// Free slots before the last used slot, which can only exist if an
+	// allocation was pinned, are returned to the free list. Quarantined
+	// slots are free slots like any other, so the quarantine is emptied.
+	s.rootFree = RefPointer{}
+	s.quarantine = nil
+	for i := range used {
+		if s.slotIsFree(i) {
+			ref := s.slotReference(i)
+			ref.metadata().nextFree = RefPointer{}
+			ref.Free(s.rootFree)
+			s.rootFree = ref
+		}
+	}
+
+	// Reset the slots after the last used slot in the last kept slab, so
+	// they can be allocated from again. Incrementing the generation
+	// invalidates any stale references to these slots.
+	for i := used; i < min(allocated, keptSlabs*perSlab); i++ {
+		ref := s.slotReference(i)
+		meta := ref.metadata()
+		meta.nextFree = RefPointer{}
+		meta.gen++
+	}
+
+	released := 0
+	for _, slab := range s.objects[keptSlabs:] {
+		if err := MunmapSlab(slab[0], s.allocConf); err != nil {
+			panic(err)
+		}
+		released++
+	}
+	s.objects = s.objects[:keptSlabs]
+	s.metadata = s.metadata[:keptSlabs]
+	s.hugePages = s.hugePages[:keptSlabs]
+
+	s.allocIdx.Store(uint64(used))
+
+	return released
+}
+
+// Indicates whether the slot at idx, which must have been allocated at least
+// once, is currently free
+func (s *Store) slotIsFree(idx int) bool {
+	ref := s.slotReference(idx)
+	return !ref.metadata().nextFree.IsNil()
+}
+
+// Indicates whether the slot at idx, which must have been allocated at least
+// once, is pinned
+func (s *Store) slotIsPinned(idx int) bool {
+	ref := s.slotReference(idx)
+	return ref.IsPinned()
+}
+
+// Returns a reference to the slot at idx, with the slot's current generation
+func (s *Store) slotReference(idx int) RefPointer {
+	perSlab := int(s.allocConf.ObjectsPerSlab)
+	slabIdx := idx / perSlab
+	offsetIdx := idx % perSlab
+
+	ref := NewReference(s.objects[slabIdx][offsetIdx], s.metadata[slabIdx][offsetIdx])
+	ref.setGen(ref.metadata().gen)
+	return ref
+}
@@ -0,0 +1,26 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+package pointerstore
+
+import (
+	"errors"
+)
+
+// The errors returned, or wrapped by the panics raised, when a reference or
+// store is misused. These can be tested for with errors.Is.
+var (
+	// The allocation referenced has been freed
+	ErrFreed = errors.New("allocation has been freed")
+	// The allocation referenced has been freed, and allocated again
+	ErrStale = errors.New("reference is stale")
+	// The allocation referenced is pinned, see RefPointer.Pin
+	ErrPinned = errors.New("allocation is pinned")
+	// The store has been sealed, see Store.Seal
+	ErrSealed = errors.New("store is sealed")
+	// An allocation, or a store, would exceed a size limit
+	ErrSizeLimit = errors.New("size limit exceeded")
+	// The allocation referenced was made by a different store
+	ErrForeign = errors.New("allocation belongs to another store")
+)
@@ -0,0 +1,138 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+package pointerstore
+
+import (
+	"math"
+)
+
+// A handle packs the generation, pool and slot of an allocation into a
+// uint64. The slot is stored plus one, so that the zero handle is never the
+// handle of a real allocation.
+//
+//	bits 56-63 - generation
+//	bits 40-55 - pool
+//	bits 0-39  - slot + 1
+const (
+	handleGenShift  = 56
+	handlePoolShift = 40
+	handleSlotMask  = 1<<handlePoolShift - 1
+
+	// The maximum number of slots in a single Store, limited by the
+	// size of metadata.slot
+	maxSlots = math.MaxUint32 + 1
+)
+
+// Returns a handle for the allocation referenced by r. A handle is a plain
+// integer which can be stored anywhere, and resolved back into a RefPointer
+// using Store.Resolve. If r is nil 0 is returned.
+func (r *RefPointer) Handle() uint64 {
+	if r.IsNil() {
+		return 0
+	}
+	if handle, ok := r.Unresolved(); ok {
+		return handle
+	}
+	meta := r.metadata()
+	return uint64(r.Gen())<<handleGenShift | uint64(meta.pool)<<handlePoolShift | (uint64(meta.slot) + 1)
+}
+
+// Returns the pool of the allocation identified by handle, see NewInPool.
+func HandlePool(handle uint64) int {
+	return int((handle >> handlePoolShift) & math.MaxUint16)
+}
+
+// Returns a RefPointer for the allocation identified by handle. If handle
+// doesn't identify a live allocation in this Store, because the allocation
+// has been freed or reallocated, or handle was created by a different
+// Store, false is returned.
+//
+// Like the generation checks made by RefPointer, this check is best effort.
+// If the allocation has been freed and reallocated a multiple of 256 times
+// the new allocation is returned.
+func (s *Store) Resolve(handle uint64) (RefPointer, bool) {
+	slot := handle & handleSlotMask
+	if slot == 0 || HandlePool(handle) != int(s.pool) {
+		return RefPointer{}, false
+	}
+	slot--
+
+	if slot >= s.allocIdx.Load() {
+		// This slot has never been allocated
+		return RefPointer{}, false
+	}
+
+	s.objectsLock.RLock()
+	slabIdx := slot / s.allocConf.ObjectsPerSlab
+	offsetIdx := slot % s.allocConf.ObjectsPerSlab
+	if slabIdx >= uint64(len(s.objects)) {
+		s.objectsLock.RUnlock()
+		return RefPointer{}, false
+	}
+	ref := NewReference(s.objects[slabIdx][offsetIdx], s.metadata[slabIdx][offsetIdx])
+	s.objectsLock.RUnlock()
+
+	ref.setGen(uint8(handle >> handleGenShift))
+	if !ref.IsLive() {
+		return RefPointer{}, false
+	}
+	return ref, true
+}
+
+// Returns a *MisuseError wrapping ErrForeign, describing the attempt to
+// perform op using r, if r doesn't reference an allocation made by this
+// store. Otherwise returns nil.
+func (s *Store) CheckOwner(r RefPointer, op string) error {
+	meta := r.metadata()
+	slot := uint64(meta.slot)
+	if meta.pool == s.pool && slot < s.allocIdx.Load() {
+		s.objectsLock.RLock()
+		slabIdx := slot / s.allocConf.ObjectsPerSlab
+		offsetIdx := slot % s.allocConf.ObjectsPerSlab
+		owned := slabIdx < uint64(len(s.metadata)) && s.metadata[slabIdx][offsetIdx] == r.metadataPtr()
+		s.objectsLock.RUnlock()
+		if owned {
+			return nil
+		}
+	}
+	return misuse(r, op, ErrForeign)
+}
+
+// The address of the metadata shared by every unresolved reference, see
+// NewUnresolved. It is permanently marked as free, so any use of an
+// unresolved reference is detected as a use of a freed allocation. Like all
+// metadata it lives outside the Go heap, in its own small slab.
+var unresolvedMeta = func() uintptr {
+	_, metadata := MmapSlab(NewAllocConfigBySize(1, 1))
+	r := RefPointer{metaAddress: uint64(metadata[0])}
+	r.metadata().nextFree = r
+	return metadata[0]
+}()
+
+// Returns a RefPointer holding handle, which has not yet been resolved
+// against a Store. This allows a handle to be decoded before the Store it
+// belongs to is known. The handle is recovered with Unresolved, and must be
+// resolved with Store.Resolve before the allocation can be used. Any other
+// use of the RefPointer is detected as a use of a freed allocation.
+//
+// If handle is 0 a nil RefPointer is returned.
+func NewUnresolved(handle uint64) RefPointer {
+	if handle == 0 {
+		return RefPointer{}
+	}
+	return RefPointer{
+		dataAddress: handle,
+		metaAddress: uint64(unresolvedMeta),
+	}
+}
+
+// Returns the handle held by r, and true, if r was created by NewUnresolved.
+// Otherwise 0 and false are returned.
+func (r *RefPointer) Unresolved() (uint64, bool) {
+	if r.metadataPtr() != unresolvedMeta {
+		return 0, false
+	}
+	return r.dataAddress, true
+}
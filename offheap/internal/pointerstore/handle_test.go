@@ -0,0 +1,130 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+package pointerstore
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Show that the handle of every live allocation resolves back to the same
+// allocation, across several slabs
+func TestHandle_Resolve(t *testing.T) {
+	conf := NewAllocConfigBySize(8, 1<<8)
+	store := New(conf)
+	defer func() {
+		assert.NoError(t, store.Destroy())
+	}()
+
+	nilRef := RefPointer{}
+	assert.Equal(t, uint64(0), nilRef.Handle())
+	_, ok := store.Resolve(0)
+	assert.False(t, ok)
+
+	refs := []RefPointer{}
+	handles := map[uint64]bool{}
+	for range conf.ObjectsPerSlab * 3 {
+		ref := store.Alloc()
+		refs = append(refs, ref)
+		handles[ref.Handle()] = true
+	}
+	// Every handle is unique
+	assert.Equal(t, len(refs), len(handles))
+
+	for _, ref := range refs {
+		resolved, ok := store.Resolve(ref.Handle())
+		require.True(t, ok)
+		assert.Equal(t, ref, resolved)
+	}
+}
+
+// Show that the handle of a freed allocation doesn't resolve, even after its
+// slot has been reallocated
+func TestHandle_Freed(t *testing.T) {
+	store := New(NewAllocConfigBySize(8, 1<<8))
+	defer func() {
+		assert.NoError(t, store.Destroy())
+	}()
+
+	ref := store.Alloc()
+	handle := ref.Handle()
+	store.Free(ref)
+
+	_, ok := store.Resolve(handle)
+	assert.False(t, ok)
+
+	// The slot is reused, but the handle has a new generation
+	newRef := store.Alloc()
+	assert.Equal(t, ref.metadataPtr(), newRef.metadataPtr())
+	assert.NotEqual(t, handle, newRef.Handle())
+
+	_, ok = store.Resolve(handle)
+	assert.False(t, ok)
+	resolved, ok := store.Resolve(newRef.Handle())
+	assert.True(t, ok)
+	assert.Equal(t, newRef, resolved)
+}
+
+// Show that handles which were never issued by a store, or were issued by a
+// store in a different pool, don't resolve
+func TestHandle_Invalid(t *testing.T) {
+	conf := NewAllocConfigBySize(8, 1<<8)
+	store := NewInPool(conf, 3)
+	defer func() {
+		assert.NoError(t, store.Destroy())
+	}()
+	other := NewInPool(conf, 4)
+	defer func() {
+		assert.NoError(t, other.Destroy())
+	}()
+
+	ref := store.Alloc()
+	handle := ref.Handle()
+	assert.Equal(t, 3, HandlePool(handle))
+
+	_, ok := other.Resolve(handle)
+	assert.False(t, ok)
+
+	// A slot beyond any allocation
+	_, ok = store.Resolve(handle + 100)
+	assert.False(t, ok)
+
+	// A slot beyond any slab
+	_, ok = store.Resolve(handle + conf.ObjectsPerSlab*100)
+	assert.False(t, ok)
+}
+
+// Demonstrate that an unresolved reference keeps its handle, and that any use
+// of it is detected as the use of a freed allocation
+func TestNewUnresolved(t *testing.T) {
+	store := New(NewAllocConfigBySize(8, 1<<8))
+	defer func() {
+		assert.NoError(t, store.Destroy())
+	}()
+
+	nilRef := NewUnresolved(0)
+	assert.True(t, nilRef.IsNil())
+
+	ref := store.Alloc()
+	unresolved := NewUnresolved(ref.Handle())
+	assert.False(t, unresolved.IsNil())
+	handle, ok := unresolved.Unresolved()
+	assert.True(t, ok)
+	assert.Equal(t, ref.Handle(), handle)
+	assert.Equal(t, ref.Handle(), unresolved.Handle())
+
+	_, ok = ref.Unresolved()
+	assert.False(t, ok)
+
+	assert.False(t, unresolved.IsLive())
+	assert.Panics(t, func() { unresolved.DataPtr() })
+	assert.ErrorIs(t, store.TryFree(unresolved), ErrFreed)
+
+	resolved, ok := store.Resolve(handle)
+	require.True(t, ok)
+	assert.Equal(t, ref, resolved)
+}
@@ -0,0 +1,39 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+//go:build linux
+
+package pointerstore
+
+import (
+	"golang.org/x/sys/unix"
+)
+
+// Maps the memory for a slab. If conf.HugePages is set we first try to map
+// the slab directly from the huge page pool, using MAP_HUGETLB. This only
+// works if the slab size is a multiple of the huge page size, and the system
+// has a pool of huge pages configured. Otherwise the slab is mapped normally
+// and transparent huge pages are requested using madvise. If both of these
+// fail the slab is used without huge pages.
+func mmapSlabData(conf AllocConfig) (data []byte, hugePages bool, err error) {
+	if !conf.HugePages {
+		data, err = mmapSlabPlain(conf)
+		return data, false, err
+	}
+
+	if conf.TotalSlabSize%HugePageSize == 0 {
+		data, err = unix.Mmap(-1, 0, int(conf.TotalSlabSize), unix.PROT_READ|unix.PROT_WRITE, unix.MAP_ANON|unix.MAP_PRIVATE|unix.MAP_HUGETLB)
+		if err == nil {
+			return data, true, nil
+		}
+	}
+
+	data, err = mmapSlabPlain(conf)
+	if err != nil {
+		return nil, false, err
+	}
+
+	// If transparent huge pages aren't supported we carry on without them
+	return data, unix.Madvise(data, unix.MADV_HUGEPAGE) == nil, nil
+}
@@ -0,0 +1,14 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+//go:build !linux
+
+package pointerstore
+
+// Maps the memory for a slab. Huge pages are only supported on Linux, on
+// other systems conf.HugePages is ignored.
+func mmapSlabData(conf AllocConfig) (data []byte, hugePages bool, err error) {
+	data, err = mmapSlabPlain(conf)
+	return data, false, err
+}
@@ -0,0 +1,53 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+package pointerstore
+
+import (
+	"hash/crc32"
+)
+
+var checksumTable = crc32.MakeTable(crc32.Castagnoli)
+
+// Records a checksum of the entire slot of the allocation referenced by r in
+// the allocation's metadata. Verify reports every committed allocation whose
+// contents no longer match its checksum. Each time a committed allocation is
+// legitimately modified it must be committed again.
+//
+// Panics if r has been freed or is stale.
+func (s *Store) Commit(r RefPointer) {
+	meta := r.metadata()
+	meta.checksum = s.checksum(r)
+	meta.committed = true
+}
+
+// Indicates whether the allocation referenced by r has been committed, see
+// Commit.
+func (r *RefPointer) IsCommitted() bool {
+	return r.metadata().committed
+}
+
+// Scans every live allocation in the store, calling corrupted with each
+// committed allocation whose contents no longer match the checksum recorded
+// by Commit. Returns the number of corrupted allocations found.
+//
+// Verify must not be called concurrently with writes to any allocation in
+// the store, or with allocating or freeing.
+func (s *Store) Verify(corrupted func(ref RefPointer)) int {
+	count := 0
+	s.ForEach(func(ref RefPointer) bool {
+		meta := ref.metadata()
+		if meta.committed && meta.checksum != s.checksum(ref) {
+			count++
+			corrupted(ref)
+		}
+		return true
+	})
+	return count
+}
+
+// Returns the checksum of the entire slot of the allocation referenced by r
+func (s *Store) checksum(r RefPointer) uint32 {
+	return crc32.Checksum(r.Bytes(int(s.allocConf.ObjectSize)), checksumTable)
+}
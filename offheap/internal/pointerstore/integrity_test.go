@@ -0,0 +1,72 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+package pointerstore
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Demonstrate that Verify reports committed allocations which are modified,
+// and ignores allocations which were never committed
+func TestVerify(t *testing.T) {
+	conf := NewAllocConfigBySize(16, 32*16)
+	store := New(conf)
+	defer func() {
+		assert.NoError(t, store.Destroy())
+	}()
+
+	refs := []RefPointer{}
+	for i := range 100 {
+		ref := store.Alloc()
+		ref.Bytes(16)[0] = byte(i)
+		if i%2 == 0 {
+			store.Commit(ref)
+			assert.True(t, ref.IsCommitted())
+		}
+		refs = append(refs, ref)
+	}
+	assert.Equal(t, 0, store.Verify(func(ref RefPointer) { t.Fatalf("unexpected corruption") }))
+
+	// Modify a committed, and an uncommitted, allocation. Only the
+	// committed allocation is reported
+	refs[10].Bytes(16)[15] = 0xFF
+	refs[11].Bytes(16)[15] = 0xFF
+	corrupted := []RefPointer{}
+	assert.Equal(t, 1, store.Verify(func(ref RefPointer) {
+		corrupted = append(corrupted, ref)
+	}))
+	assert.Equal(t, []RefPointer{refs[10]}, corrupted)
+
+	// Committing again accepts the modification
+	store.Commit(refs[10])
+	assert.Equal(t, 0, store.Verify(func(ref RefPointer) {}))
+
+	// Freed allocations aren't verified, and reallocated slots are not
+	// committed
+	store.Free(refs[20])
+	reused := store.Alloc()
+	assert.False(t, reused.IsCommitted())
+	reused.Bytes(16)[0] = 0xFF
+	assert.Equal(t, 0, store.Verify(func(ref RefPointer) {}))
+
+	// Compaction keeps the checksum of moved allocations
+	for _, ref := range refs[:50] {
+		if ref != refs[20] {
+			store.Free(ref)
+		}
+	}
+	committed := 0
+	store.Compact(func(oldRef, newRef RefPointer) {
+		if newRef.IsCommitted() {
+			committed++
+		}
+	})
+	assert.Greater(t, committed, 0)
+	assert.Equal(t, 0, store.Verify(func(ref RefPointer) {}))
+
+	assert.Panics(t, func() { store.Commit(refs[0]) })
+}
@@ -0,0 +1,39 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+package pointerstore
+
+// Calls fn with a reference to every live allocation in the store, in slot
+// order. Iteration stops early if fn returns false. Returns false if
+// iteration was stopped early, true otherwise.
+//
+// It is safe for fn to free the allocation it is visiting. Allocations made
+// by fn may, or may not, be visited.
+//
+// ForEach must not be called concurrently with any other use of the store.
+func (s *Store) ForEach(fn func(ref RefPointer) bool) bool {
+	s.objectsLock.RLock()
+	objects := s.objects
+	metadata := s.metadata
+	s.objectsLock.RUnlock()
+
+	perSlab := s.allocConf.ObjectsPerSlab
+	allocated := min(s.allocIdx.Load(), uint64(len(objects))*perSlab)
+	for idx := range allocated {
+		slabIdx := idx / perSlab
+		offsetIdx := idx % perSlab
+
+		ref := NewReference(objects[slabIdx][offsetIdx], metadata[slabIdx][offsetIdx])
+		meta := ref.metadata()
+		if !meta.nextFree.IsNil() {
+			continue
+		}
+		ref.setGen(meta.gen)
+
+		if !fn(ref) {
+			return false
+		}
+	}
+	return true
+}
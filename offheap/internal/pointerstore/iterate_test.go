@@ -0,0 +1,54 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+package pointerstore
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Demonstrate that ForEach visits every live allocation, across many slabs,
+// and skips freed allocations
+func TestForEach(t *testing.T) {
+	conf := NewAllocConfigBySize(8, 32*8)
+	store := New(conf)
+	defer func() {
+		assert.NoError(t, store.Destroy())
+	}()
+
+	live := map[RefPointer]bool{}
+	for i := range 100 {
+		ref := store.Alloc()
+		if i%3 == 0 {
+			store.Free(ref)
+		} else {
+			live[ref] = true
+		}
+	}
+
+	visited := map[RefPointer]bool{}
+	assert.True(t, store.ForEach(func(ref RefPointer) bool {
+		assert.True(t, ref.IsLive())
+		visited[ref] = true
+		return true
+	}))
+	assert.Equal(t, live, visited)
+
+	// Iteration can be stopped early
+	count := 0
+	assert.False(t, store.ForEach(func(ref RefPointer) bool {
+		count++
+		return count < 10
+	}))
+	assert.Equal(t, 10, count)
+
+	// Allocations can be freed while they are visited
+	assert.True(t, store.ForEach(func(ref RefPointer) bool {
+		store.Free(ref)
+		return true
+	}))
+	assert.Equal(t, 0, store.Stats().Live)
+}
@@ -0,0 +1,53 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+package pointerstore
+
+import (
+	"fmt"
+)
+
+// Describes the misuse of a reference, such as accessing or freeing an
+// allocation which has already been freed. A MisuseError is returned, or
+// raised as a panic, whenever a misuse is detected. It wraps one of
+// ErrFreed, ErrStale, ErrPinned or ErrForeign.
+type MisuseError struct {
+	// The operation which was attempted, one of "access", "free" or
+	// "realloc"
+	Operation string
+	// The pool of the store which owns the allocation, see NewInPool
+	Pool int
+	// The slot of the allocation, see RefPointer.Handle
+	Slot int
+	// The current generation of the allocation
+	AllocationGen uint8
+	// The generation recorded in the reference, this differs from
+	// AllocationGen if the reference is stale
+	ReferenceGen uint8
+	// The kind of misuse
+	Err error
+}
+
+func (e *MisuseError) Error() string {
+	return fmt.Sprintf("attempted to %s allocation (pool %d, slot %d, generation %d) using reference with generation %d: %s",
+		e.Operation, e.Pool, e.Slot, e.AllocationGen, e.ReferenceGen, e.Err)
+}
+
+func (e *MisuseError) Unwrap() error {
+	return e.Err
+}
+
+// Returns a *MisuseError describing the attempt to perform op using r. NB: r
+// is passed by value, see DataPtr() for details.
+func misuse(r RefPointer, op string, err error) error {
+	meta := r.metadata()
+	return &MisuseError{
+		Operation:     op,
+		Pool:          int(meta.pool),
+		Slot:          int(meta.slot),
+		AllocationGen: meta.gen,
+		ReferenceGen:  r.Gen(),
+		Err:           err,
+	}
+}
@@ -7,15 +7,25 @@ package pointerstore
 import (
 	"fmt"
 	"unsafe"
-
-	"golang.org/x/sys/unix"
 )
 
 func MmapSlab(conf AllocConfig) (objects, metadata []uintptr) {
-	data, err := unix.Mmap(-1, 0, int(conf.TotalSlabSize), unix.PROT_READ|unix.PROT_WRITE, unix.MAP_ANON|unix.MAP_PRIVATE)
+	objects, metadata, _ = mmapSlab(conf)
+	return objects, metadata
+}
+
+// Maps a new slab, and indicates whether huge pages were successfully
+// requested for it.
+func mmapSlab(conf AllocConfig) (objects, metadata []uintptr, hugePages bool) {
+	data, hugePages, err := mmapSlabData(conf)
 	if err != nil {
 		panic(fmt.Errorf("cannot allocate %#v via mmap because %s", conf, err))
 	}
+	if conf.GuardSize != 0 {
+		if err := protectNone(data[conf.TotalObjectSize : conf.TotalObjectSize+conf.GuardSize]); err != nil {
+			panic(fmt.Errorf("cannot protect guard page for %#v because %s", conf, err))
+		}
+	}
 
 	// Collect pointers to each object allocation slot
 	objects = make([]uintptr, conf.ObjectsPerSlab)
@@ -27,16 +37,29 @@ func MmapSlab(conf AllocConfig) (objects, metadata []uintptr) {
 	// Collect pointers to each metadata slot
 	metadata = make([]uintptr, conf.ObjectsPerSlab)
 	for i := range metadata {
-		idx := conf.TotalObjectSize + (uint64(i) * conf.MetadataSize)
+		idx := conf.TotalObjectSize + conf.GuardSize + (uint64(i) * conf.MetadataSize)
 		metadata[i] = (uintptr)((unsafe.Pointer)(&data[idx]))
 	}
 
-	return objects, metadata
+	return objects, metadata, hugePages
 }
 
 func MunmapSlab(ptr uintptr, allocConf AllocConfig) error {
 	b := pointerToBytes(ptr, int(allocConf.TotalSlabSize))
-	return unix.Munmap(b)
+	return munmapSlabData(b)
+}
+
+// Returns the accessible regions of the slab starting at ptr, the objects
+// and the metadata. If the slab has no guard region this is the whole slab.
+func slabRegions(ptr uintptr, conf AllocConfig) [][]byte {
+	if conf.GuardSize == 0 {
+		return [][]byte{pointerToBytes(ptr, int(conf.TotalSlabSize))}
+	}
+	metadataStart := ptr + uintptr(conf.TotalObjectSize+conf.GuardSize)
+	return [][]byte{
+		pointerToBytes(ptr, int(conf.TotalObjectSize)),
+		pointerToBytes(metadataStart, int(conf.TotalMetadataSize)),
+	}
 }
 
 func pointerToBytes(ptr uintptr, size int) []byte {
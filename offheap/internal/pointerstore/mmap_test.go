@@ -0,0 +1,115 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+package pointerstore
+
+import (
+	"os"
+	"runtime"
+	"runtime/debug"
+	"testing"
+	"unsafe"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Demonstrates that a newly mapped slab is fully committed, zeroed and
+// writable, and that the whole slab can be released again.
+func TestMmapSlab_CommitAndRelease(t *testing.T) {
+	conf := NewAllocConfigBySize(64, 8*1024)
+
+	data, err := mmapSlabPlain(conf)
+	require.NoError(t, err)
+	require.Len(t, data, int(conf.TotalSlabSize))
+
+	for i := range data {
+		require.Zero(t, data[i])
+		data[i] = 0xFF
+	}
+	for i := range data {
+		require.Equal(t, byte(0xFF), data[i])
+	}
+
+	assert.NoError(t, munmapSlabData(data))
+}
+
+// Demonstrates that object and metadata slots are laid out across the
+// mapped slab, and that the slab can be released through its first object.
+func TestMmapSlab_Slots(t *testing.T) {
+	conf := NewAllocConfigBySize(64, 8*1024)
+
+	objects, metadata := MmapSlab(conf)
+	require.Len(t, objects, int(conf.ObjectsPerSlab))
+	require.Len(t, metadata, int(conf.ObjectsPerSlab))
+
+	for i := 1; i < len(objects); i++ {
+		assert.Equal(t, uintptr(conf.ObjectSize), objects[i]-objects[i-1])
+		assert.Equal(t, uintptr(conf.MetadataSize), metadata[i]-metadata[i-1])
+	}
+	assert.Equal(t, uintptr(conf.TotalObjectSize), metadata[0]-objects[0])
+
+	assert.NoError(t, MunmapSlab(objects[0], conf))
+}
+
+// Demonstrates that each slab of a guard page config holds a single object,
+// that the whole object can be used, and that any access past the end of the
+// object faults. Sealing and cloning leave the guard page in place.
+func TestMmapSlab_GuardPage(t *testing.T) {
+	if runtime.GOOS != "linux" && runtime.GOOS != "darwin" {
+		t.Skip("guard pages are only tested on linux and darwin")
+	}
+
+	pageSize := uint64(os.Getpagesize())
+	conf := NewAllocConfigWithGuardPage(100)
+	assert.Equal(t, pageSize, conf.ObjectSize)
+	assert.Equal(t, uint64(1), conf.ObjectsPerSlab)
+	assert.Equal(t, pageSize, conf.GuardSize)
+
+	store := New(conf)
+	defer func() {
+		assert.NoError(t, store.Destroy())
+	}()
+
+	refs := []RefPointer{}
+	for i := range 10 {
+		ref := store.Alloc()
+		data := ref.Bytes(int(conf.ObjectSize))
+		for j := range data {
+			data[j] = byte(i)
+		}
+		refs = append(refs, ref)
+	}
+	store.Free(refs[0])
+	refs = refs[1:]
+	assert.Equal(t, 10, store.Stats().Slabs)
+
+	pastEnd := func(ref RefPointer) *byte {
+		data := ref.Bytes(int(conf.ObjectSize))
+		return (*byte)(unsafe.Add(unsafe.Pointer(&data[0]), len(data)))
+	}
+
+	// Faults are observed as panics with SetPanicOnFault
+	defer debug.SetPanicOnFault(debug.SetPanicOnFault(true))
+	for _, ref := range refs {
+		assert.Panics(t, func() { *pastEnd(ref) = 0xFF })
+	}
+
+	clone := store.Clone()
+	defer func() {
+		assert.NoError(t, clone.Destroy())
+	}()
+	for i, ref := range refs {
+		c, ok := clone.Resolve(ref.Handle())
+		require.True(t, ok)
+		assert.Equal(t, byte(i+1), c.Bytes(int(conf.ObjectSize))[conf.ObjectSize-1])
+		assert.Panics(t, func() { _ = *pastEnd(c) })
+	}
+
+	require.NoError(t, store.Seal())
+	for i, ref := range refs {
+		assert.Equal(t, byte(i+1), ref.Bytes(int(conf.ObjectSize))[0])
+		assert.Panics(t, func() { _ = *pastEnd(ref) })
+	}
+}
@@ -0,0 +1,21 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+//go:build unix
+
+package pointerstore
+
+import (
+	"golang.org/x/sys/unix"
+)
+
+// Maps the memory for a slab without huge pages
+func mmapSlabPlain(conf AllocConfig) ([]byte, error) {
+	return unix.Mmap(-1, 0, int(conf.TotalSlabSize), unix.PROT_READ|unix.PROT_WRITE, unix.MAP_ANON|unix.MAP_PRIVATE)
+}
+
+// Unmaps the memory for a slab, data must be the entire slab
+func munmapSlabData(data []byte) error {
+	return unix.Munmap(data)
+}
@@ -0,0 +1,54 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+//go:build windows
+
+package pointerstore
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+// Constants from the Windows memory management API
+//
+// https://learn.microsoft.com/en-us/windows/win32/api/memoryapi/nf-memoryapi-virtualalloc
+const (
+	memCommit     = 0x00001000
+	memReserve    = 0x00002000
+	memRelease    = 0x00008000
+	pageReadWrite = 0x04
+)
+
+var (
+	kernel32         = syscall.NewLazyDLL("kernel32.dll")
+	procVirtualAlloc = kernel32.NewProc("VirtualAlloc")
+	procVirtualFree  = kernel32.NewProc("VirtualFree")
+)
+
+// Maps the memory for a slab without huge pages. The address range is
+// reserved and committed in a single call, so the slab is immediately usable,
+// and like an anonymous mmap the committed pages are zeroed.
+func mmapSlabPlain(conf AllocConfig) ([]byte, error) {
+	size := uintptr(conf.TotalSlabSize)
+	addr, _, err := procVirtualAlloc.Call(0, size, memReserve|memCommit, pageReadWrite)
+	if addr == 0 {
+		return nil, err
+	}
+	return pointerToBytes(addr, int(size)), nil
+}
+
+// Unmaps the memory for a slab, data must be the entire slab. Releasing a
+// reservation decommits its pages and frees the address range in one call.
+// The size passed to VirtualFree must be 0 when releasing.
+func munmapSlabData(data []byte) error {
+	if len(data) == 0 {
+		return syscall.EINVAL
+	}
+	ok, _, err := procVirtualFree.Call(uintptr(unsafe.Pointer(&data[0])), 0, memRelease)
+	if ok == 0 {
+		return err
+	}
+	return nil
+}
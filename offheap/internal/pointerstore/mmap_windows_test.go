@@ -0,0 +1,31 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+//go:build windows
+
+package pointerstore
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Demonstrates that a released slab's address range is no longer reserved,
+// so releasing it a second time fails.
+func TestMunmapSlabData_ReleaseTwice(t *testing.T) {
+	conf := NewAllocConfigBySize(64, 8*1024)
+
+	data, err := mmapSlabPlain(conf)
+	require.NoError(t, err)
+
+	assert.NoError(t, munmapSlabData(data))
+	assert.Error(t, munmapSlabData(data))
+}
+
+// Demonstrates that an empty slab can't be released
+func TestMunmapSlabData_Empty(t *testing.T) {
+	assert.Error(t, munmapSlabData(nil))
+}
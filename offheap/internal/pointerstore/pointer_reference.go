@@ -5,7 +5,9 @@
 package pointerstore
 
 import (
+	"cmp"
 	"fmt"
+	"math"
 	"unsafe"
 )
 
@@ -31,9 +33,32 @@ type RefPointer struct {
 // An object's metadata has a gen field. Only references with the same gen
 // value can access/free objects they point to. This is a best-effort safety
 // check to try to catch use-after-free type errors.
+//
+// An object's metadata has a pins field, counting the number of times the
+// object has been pinned. A pinned object can't be freed, reallocated or moved
+// by compaction.
+//
+// An object's metadata has a pool field, identifying the pool of the Store
+// which owns the object's slab, see NewInPool.
+//
+// An object's metadata has a tag field, identifying the owner the object's
+// allocation is attributed to, see Store.Tag. Untagged objects have a tag of 0.
+//
+// An object's metadata has a slot field, the position of the object's slot
+// across every slab of its Store, see RefPointer.Handle.
+//
+// An object's metadata has committed and checksum fields, recording the
+// checksum of the object's contents when it was last committed, see
+// Store.Commit.
 type metadata struct {
-	nextFree RefPointer
-	gen      uint8
+	nextFree  RefPointer
+	gen       uint8
+	committed bool
+	pool      uint16
+	pins      uint16
+	tag       uint16
+	slot      uint32
+	checksum  uint32
 }
 
 func NewReference(pAddress, pMetadata uintptr) RefPointer {
@@ -72,6 +97,10 @@ func (r *RefPointer) AllocFromFree() (nextFree RefPointer) {
 		nextFree = RefPointer{}
 	}
 
+	// A newly allocated object has not been committed, or tagged
+	meta.committed = false
+	meta.tag = 0
+
 	// Increment the generation for the object and set that generation in
 	// the Reference
 	meta.gen++
@@ -81,14 +110,28 @@ func (r *RefPointer) AllocFromFree() (nextFree RefPointer) {
 }
 
 func (r *RefPointer) Free(oldFree RefPointer) {
+	if err := r.TryFree(oldFree); err != nil {
+		panic(err)
+	}
+}
+
+// Frees the allocation referenced by r, like Free. Instead of panicking an
+// error wrapping ErrFreed, ErrStale or ErrPinned is returned if r can't be
+// freed.
+func (r *RefPointer) TryFree(oldFree RefPointer) error {
 	meta := r.metadata()
 
 	if !meta.nextFree.IsNil() {
-		panic(fmt.Errorf("attempted to Free freed allocation %v", r))
+		// NB: We make a copy of r here, see DataPtr() for details
+		return misuse(*r, "free", ErrFreed)
 	}
 
 	if meta.gen != r.Gen() {
-		panic(fmt.Errorf("attempt to free allocation (%d) using stale reference (%d)", meta.gen, r.Gen()))
+		return misuse(*r, "free", ErrStale)
+	}
+
+	if meta.pins != 0 {
+		return misuse(*r, "free", ErrPinned)
 	}
 
 	if oldFree.IsNil() {
@@ -96,6 +139,7 @@ func (r *RefPointer) Free(oldFree RefPointer) {
 	} else {
 		meta.nextFree = oldFree
 	}
+	return nil
 }
 
 func (r *RefPointer) IsNil() bool {
@@ -113,15 +157,88 @@ func (r *RefPointer) DataPtr() uintptr {
 		// call, but if we don't take a copy of r in the fmt call, then
 		// every call will allocate regardless of whether the method
 		// panics or not
-		panic(fmt.Errorf("attempted to get freed allocation %v", *r))
+		panic(misuse(*r, "access", ErrFreed))
 	}
 
 	if meta.gen != r.Gen() {
-		panic(fmt.Errorf("attempt to get value (%d) using stale reference (%d)", meta.gen, r.Gen()))
+		panic(misuse(*r, "access", ErrStale))
 	}
 	return (uintptr)(r.dataAddress & pointerMask)
 }
 
+// Returns the address of the allocation referenced by r, like DataPtr.
+// Instead of panicking an error wrapping ErrFreed or ErrStale is returned if
+// r can't be used to access its allocation.
+func (r *RefPointer) TryDataPtr() (uintptr, error) {
+	meta := r.metadata()
+
+	if !meta.nextFree.IsNil() {
+		// NB: We make a copy of r here, see DataPtr() for details
+		return 0, misuse(*r, "access", ErrFreed)
+	}
+
+	if meta.gen != r.Gen() {
+		return 0, misuse(*r, "access", ErrStale)
+	}
+	return (uintptr)(r.dataAddress & pointerMask), nil
+}
+
+// Indicates whether r still refers to a live allocation. Returns false if the
+// allocation has been freed, or freed and then allocated again.
+//
+// Because the generation is only 8 bits wide this is best effort. If the
+// allocation has been freed and re-allocated a multiple of 256 times this
+// method will incorrectly return true.
+func (r *RefPointer) IsLive() bool {
+	meta := r.metadata()
+	return meta.nextFree.IsNil() && meta.gen == r.Gen()
+}
+
+// Pins the allocation referenced by r. While an allocation is pinned it can't
+// be freed, reallocated or moved by compaction, so its address is stable.
+// Pins are counted, each call to Pin must be matched by a call to Unpin.
+//
+// Panics if r has been freed or is stale, or if the allocation is already
+// pinned math.MaxUint16 times.
+func (r *RefPointer) Pin() {
+	// DataPtr panics if r has been freed or is stale
+	r.DataPtr()
+	meta := r.metadata()
+	if meta.pins == math.MaxUint16 {
+		panic(fmt.Errorf("attempted to Pin allocation which is already pinned %d times %v", meta.pins, *r))
+	}
+	meta.pins++
+}
+
+// Removes one pin from the allocation referenced by r.
+//
+// Panics if r has been freed, is stale or is not pinned.
+func (r *RefPointer) Unpin() {
+	// DataPtr panics if r has been freed or is stale
+	r.DataPtr()
+	meta := r.metadata()
+	if meta.pins == 0 {
+		panic(fmt.Errorf("attempted to Unpin allocation which is not pinned %v", *r))
+	}
+	meta.pins--
+}
+
+// Indicates whether the allocation referenced by r is pinned.
+func (r *RefPointer) IsPinned() bool {
+	return r.metadata().pins != 0
+}
+
+// Returns the pool of the Store which owns the allocation referenced by r.
+func (r *RefPointer) Pool() int {
+	return int(r.metadata().pool)
+}
+
+// Returns the slot of the allocation referenced by r, its position among all
+// of the allocations made by its Store.
+func (r *RefPointer) Slot() int {
+	return int(r.metadata().slot)
+}
+
 // Convenient method to retrieve raw data of an allocation
 func (r *RefPointer) Bytes(size int) []byte {
 	ptr := r.DataPtr()
@@ -146,11 +263,40 @@ func (r *RefPointer) setGen(gen uint8) {
 
 // This method re-allocates the memory location. When this method returns r
 // will no longer be a valid reference.  The reference returned _will_ be a
-// valid reference to the same location.
+// valid reference to the same location. Like a newly allocated object, the
+// re-allocated object has not been committed.
 func (r *RefPointer) Realloc() RefPointer {
 	newRef := *r
 	meta := r.metadata()
+	if meta.pins != 0 {
+		panic(misuse(*r, "realloc", ErrPinned))
+	}
+	meta.committed = false
 	meta.gen++
 	newRef.setGen(meta.gen)
 	return newRef
 }
+
+// Returns a reference to the address offset bytes into the allocation
+// referenced by r. The returned reference shares r's metadata and
+// generation, so it can be used exactly as long as r can. It must never be
+// freed or reallocated, only r can be.
+func (r *RefPointer) Offset(offset int) RefPointer {
+	newRef := *r
+	newRef.dataAddress += uint64(offset)
+	return newRef
+}
+
+// Returns -1 if r is ordered before other, 1 if r is ordered after other and
+// 0 if they are the same reference. References are ordered by the address of
+// their allocation, then by generation. The nil reference is ordered before
+// every other reference.
+//
+// This method doesn't access the allocation, and can be used on freed
+// references.
+func (r *RefPointer) Compare(other RefPointer) int {
+	if c := cmp.Compare(r.dataAddress, other.dataAddress); c != 0 {
+		return c
+	}
+	return cmp.Compare(r.metaAddress, other.metaAddress)
+}
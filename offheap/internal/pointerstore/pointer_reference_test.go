@@ -6,6 +6,7 @@ package pointerstore
 
 import (
 	"testing"
+	"unsafe"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -92,3 +93,79 @@ func TestRealloc(t *testing.T) {
 	assert.Panics(t, func() { r1.DataPtr() })
 	assert.NotPanics(t, func() { r2.DataPtr() })
 }
+
+// Demonstrate that an offset reference points into its original allocation,
+// and is invalidated along with it
+func TestOffset(t *testing.T) {
+	s := New(NewAllocConfigBySize(64, 32*64))
+	defer s.Destroy()
+
+	r := s.Alloc()
+	o := r.Offset(24)
+	assert.Equal(t, r.DataPtr()+24, o.DataPtr())
+	assert.True(t, o.IsLive())
+
+	r2 := r.Realloc()
+	assert.Panics(t, func() { o.DataPtr() })
+	assert.False(t, o.IsLive())
+
+	o2 := r2.Offset(8)
+	s.Free(r2)
+	assert.Panics(t, func() { o2.DataPtr() })
+}
+
+// Demonstrate that a reference is live until it is freed, and stays not live
+// after its allocation is reused
+func TestIsLive(t *testing.T) {
+	s := New(NewAllocConfigBySize(8, 32*8))
+	defer s.Destroy()
+
+	r1 := s.Alloc()
+	assert.True(t, r1.IsLive())
+
+	s.Free(r1)
+	assert.False(t, r1.IsLive())
+
+	// The allocation slot is reused, but r1 is still not live
+	r2 := s.Alloc()
+	assert.Equal(t, r1.metadataPtr(), r2.metadataPtr())
+	assert.True(t, r2.IsLive())
+	assert.False(t, r1.IsLive())
+
+	// A realloc'd reference is live, but the old reference is not
+	r3 := r2.Realloc()
+	assert.True(t, r3.IsLive())
+	assert.False(t, r2.IsLive())
+}
+
+// Demonstrate that a pinned allocation can't be freed or reallocated until it
+// is unpinned
+func TestPin(t *testing.T) {
+	// The pin count, and slot, don't increase the size of the metadata
+	// beyond the 32 bytes reserved for each slot
+	assert.LessOrEqual(t, int(unsafe.Sizeof(metadata{})), 32)
+
+	store := New(NewAllocConfigBySize(8, 1<<8))
+	defer func() {
+		assert.NoError(t, store.Destroy())
+	}()
+
+	r := store.Alloc()
+	assert.False(t, r.IsPinned())
+	assert.Panics(t, func() { r.Unpin() })
+
+	r.Pin()
+	r.Pin()
+	assert.True(t, r.IsPinned())
+	assert.Panics(t, func() { store.Free(r) })
+	assert.Panics(t, func() { r.Realloc() })
+
+	r.Unpin()
+	assert.True(t, r.IsPinned())
+	r.Unpin()
+	assert.False(t, r.IsPinned())
+
+	r = r.Realloc()
+	store.Free(r)
+	assert.Panics(t, func() { r.Pin() })
+}
@@ -5,8 +5,11 @@
 package pointerstore
 
 import (
+	"fmt"
+	"math"
 	"sync"
 	"sync/atomic"
+	"unsafe"
 )
 
 type Stats struct {
@@ -16,23 +19,142 @@ type Stats struct {
 	Live      int
 	Reused    int
 	Slabs     int
+
+	// The number of bytes mapped from the operating system, including
+	// object metadata
+	MappedBytes int
+	// The number of bytes used by live allocations
+	LiveBytes int
+	// The number of bytes of object space which are mapped but not used by
+	// a live allocation
+	FreeBytes int
+
+	// The number of bytes asked for by every allocation ever made. Because
+	// allocations are rounded up to the size of their size class this is
+	// usually smaller than AllocatedBytes, the difference is the memory
+	// wasted by rounding
+	RequestedBytes int
+	// The number of bytes given out by every allocation ever made,
+	// i.e. Allocs * the object size
+	AllocatedBytes int
+
+	// The number of slabs for which huge pages were successfully
+	// requested, see AllocConfig.HugePages
+	HugePageSlabs int
+
+	// The number of bytes of free object space which have been advised to
+	// the operating system as unneeded, see Store.Reclaim. This is a
+	// running total, pages which are advised by more than one call to
+	// Reclaim are counted each time
+	AdvisedBytes int
+
+	// The number of allocations which were padded into this size class,
+	// from a smaller size class, see AllocPadded
+	PaddedAllocs int
+
+	// The number of freed slots held in quarantine, which can't be
+	// allocated yet, see SetQuarantine
+	Quarantined int
+
+	// The number of free slots held in Caches, see EnableCaches
+	Cached int
+	// The number of allocations made from a Cache which already held a
+	// free slot
+	CacheHits int
+	// The number of allocations made from an empty Cache, which had to be
+	// refilled from the free list
+	CacheMisses int
+}
+
+// Returns the sum of each of the fields in s and other
+func (s Stats) Add(other Stats) Stats {
+	return Stats{
+		Allocs:         s.Allocs + other.Allocs,
+		Frees:          s.Frees + other.Frees,
+		RawAllocs:      s.RawAllocs + other.RawAllocs,
+		Live:           s.Live + other.Live,
+		Reused:         s.Reused + other.Reused,
+		Slabs:          s.Slabs + other.Slabs,
+		MappedBytes:    s.MappedBytes + other.MappedBytes,
+		LiveBytes:      s.LiveBytes + other.LiveBytes,
+		FreeBytes:      s.FreeBytes + other.FreeBytes,
+		RequestedBytes: s.RequestedBytes + other.RequestedBytes,
+		AllocatedBytes: s.AllocatedBytes + other.AllocatedBytes,
+		HugePageSlabs:  s.HugePageSlabs + other.HugePageSlabs,
+		AdvisedBytes:   s.AdvisedBytes + other.AdvisedBytes,
+		PaddedAllocs:   s.PaddedAllocs + other.PaddedAllocs,
+		Quarantined:    s.Quarantined + other.Quarantined,
+		Cached:         s.Cached + other.Cached,
+		CacheHits:      s.CacheHits + other.CacheHits,
+		CacheMisses:    s.CacheMisses + other.CacheMisses,
+	}
+}
+
+// Returns the difference between each of the fields in s and other
+func (s Stats) Sub(other Stats) Stats {
+	return Stats{
+		Allocs:         s.Allocs - other.Allocs,
+		Frees:          s.Frees - other.Frees,
+		RawAllocs:      s.RawAllocs - other.RawAllocs,
+		Live:           s.Live - other.Live,
+		Reused:         s.Reused - other.Reused,
+		Slabs:          s.Slabs - other.Slabs,
+		MappedBytes:    s.MappedBytes - other.MappedBytes,
+		LiveBytes:      s.LiveBytes - other.LiveBytes,
+		FreeBytes:      s.FreeBytes - other.FreeBytes,
+		RequestedBytes: s.RequestedBytes - other.RequestedBytes,
+		AllocatedBytes: s.AllocatedBytes - other.AllocatedBytes,
+		HugePageSlabs:  s.HugePageSlabs - other.HugePageSlabs,
+		AdvisedBytes:   s.AdvisedBytes - other.AdvisedBytes,
+		PaddedAllocs:   s.PaddedAllocs - other.PaddedAllocs,
+		Quarantined:    s.Quarantined - other.Quarantined,
+		Cached:         s.Cached - other.Cached,
+		CacheHits:      s.CacheHits - other.CacheHits,
+		CacheMisses:    s.CacheMisses - other.CacheMisses,
+	}
 }
 
 type Store struct {
 	// Immutable fields
 	allocConf AllocConfig
+	pool      uint16
 
 	// Accounting fields
 	allocs atomic.Uint64
 	frees  atomic.Uint64
 	reused atomic.Uint64
+	// The sum of the sizes requested by every allocation
+	requestedBytes atomic.Uint64
+	// The sum of the bytes advised by every call to Reclaim
+	advisedBytes atomic.Uint64
+	// The number of allocations made by AllocPadded
+	paddedAllocs atomic.Uint64
+	// The number of allocations which did, and didn't, find a free slot in
+	// a Cache
+	cacheHits   atomic.Uint64
+	cacheMisses atomic.Uint64
+	// Maps each tag used by an allocation to its *tagCounters, see Tag
+	tags sync.Map
+
+	// Set by Seal, after which every slab is read-only
+	sealed atomic.Bool
 
 	// allIdx provides unique allocation locations for each new allocation
 	allocIdx atomic.Uint64
 
-	// freeRWLock protects rootFree
+	// freeRWLock protects rootFree, and the quarantine
 	freeLock sync.Mutex
 	rootFree RefPointer
+	// Freed slots waiting to be added to the free list, oldest first, see
+	// SetQuarantine
+	quarantine    []RefPointer
+	maxQuarantine int
+	// Indicates whether maxQuarantine is greater than 0, so Caches can
+	// check for a quarantine without taking freeLock
+	quarantining atomic.Bool
+
+	// Empty unless EnableCaches has been called
+	caches []Cache
 
 	// objectsLock protects objects
 	// Allocating to an existing slab with a free slot only needs a read lock
@@ -40,6 +162,9 @@ type Store struct {
 	objectsLock sync.RWMutex
 	metadata    [][]uintptr
 	objects     [][]uintptr
+	// Indicates, for each slab, whether huge pages were successfully
+	// requested for it
+	hugePages []bool
 }
 
 func New(allocConf AllocConfig) *Store {
@@ -51,8 +176,29 @@ func New(allocConf AllocConfig) *Store {
 	}
 }
 
+// Returns a new Store, where every allocation records that it belongs to pool.
+// This allows the owner of a set of Stores to identify which Store an
+// allocation must be freed to, using RefPointer.Pool.
+func NewInPool(allocConf AllocConfig, pool int) *Store {
+	if pool < 0 || pool > math.MaxUint16 {
+		panic(fmt.Errorf("pool %d must be between 0 and %d", pool, math.MaxUint16))
+	}
+	s := New(allocConf)
+	s.pool = uint16(pool)
+	return s
+}
+
 func (s *Store) Alloc() RefPointer {
+	return s.AllocRequested(s.allocConf.ObjectSize)
+}
+
+// Allocates like Alloc, recording in Stats that only requested bytes of the
+// allocation were asked for. This allows the memory wasted by rounding
+// allocations up to ObjectSize to be measured.
+func (s *Store) AllocRequested(requested uint64) RefPointer {
+	s.checkSealed("allocate")
 	s.allocs.Add(1)
+	s.requestedBytes.Add(requested)
 
 	if r, ok := s.allocFromFree(); ok {
 		s.reused.Add(1)
@@ -63,14 +209,72 @@ func (s *Store) Alloc() RefPointer {
 	return s.allocFromOffset()
 }
 
+// Allocates like AllocRequested, recording in Stats that this allocation
+// would have fit in a smaller size class, but was padded into this one.
+func (s *Store) AllocPadded(requested uint64) RefPointer {
+	s.paddedAllocs.Add(1)
+	return s.AllocRequested(requested)
+}
+
 func (s *Store) Free(r RefPointer) {
+	if err := s.TryFree(r); err != nil {
+		panic(err)
+	}
+}
+
+// Frees the allocation referenced by r, like Free. Instead of panicking an
+// error wrapping ErrFreed, ErrStale, ErrPinned or ErrSealed is returned if r
+// can't be freed.
+func (s *Store) TryFree(r RefPointer) error {
+	if err := s.sealedErr("free"); err != nil {
+		return err
+	}
+	s.freeLock.Lock()
+	defer s.freeLock.Unlock()
+
+	return s.freeLocked(r)
+}
+
+// Frees each of the allocations referenced by refs, like TryFree, but takes
+// the free list lock only once. Returns an error for each reference which
+// couldn't be freed, every other reference is freed.
+func (s *Store) TryFreeAll(refs []RefPointer) []error {
+	if err := s.sealedErr("free"); err != nil {
+		errs := make([]error, len(refs))
+		for i := range errs {
+			errs[i] = err
+		}
+		return errs
+	}
 	s.freeLock.Lock()
 	defer s.freeLock.Unlock()
 
-	r.Free(s.rootFree)
-	s.rootFree = r
+	var errs []error
+	for _, r := range refs {
+		if err := s.freeLocked(r); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+// Frees r into the quarantine, or the free list. The caller must hold
+// freeLock.
+func (s *Store) freeLocked(r RefPointer) error {
+	if s.maxQuarantine > 0 {
+		if err := s.quarantineFree(r); err != nil {
+			return err
+		}
+	} else {
+		if err := r.TryFree(s.rootFree); err != nil {
+			return err
+		}
+		s.rootFree = r
+	}
 
 	s.frees.Add(1)
+	s.countTagFree(r)
+	return nil
 }
 
 func (s *Store) Destroy() error {
@@ -79,6 +283,7 @@ func (s *Store) Destroy() error {
 	defer func() {
 		s.objects = nil
 		s.metadata = nil
+		s.hugePages = nil
 	}()
 
 	for _, slab := range s.objects {
@@ -103,15 +308,42 @@ func (s *Store) Stats() Stats {
 	// make sure the size of s.objects doesn't change
 	s.objectsLock.RLock()
 	slabs := len(s.objects)
+	hugePageSlabs := 0
+	for _, huge := range s.hugePages {
+		if huge {
+			hugePageSlabs++
+		}
+	}
 	s.objectsLock.RUnlock()
 
+	s.freeLock.Lock()
+	quarantined := len(s.quarantine)
+	s.freeLock.Unlock()
+
+	cached := s.cachedSlots()
+
+	live := int(allocs - frees)
+	liveBytes := live * int(s.allocConf.ObjectSize)
+
 	return Stats{
-		Allocs:    int(allocs),
-		Frees:     int(frees),
-		RawAllocs: int(allocs - reused),
-		Live:      int(allocs - frees),
-		Reused:    int(reused),
-		Slabs:     slabs,
+		Allocs:         int(allocs),
+		Frees:          int(frees),
+		RawAllocs:      int(allocs - reused),
+		Live:           live,
+		Reused:         int(reused),
+		Slabs:          slabs,
+		MappedBytes:    slabs * int(s.allocConf.TotalSlabSize),
+		LiveBytes:      liveBytes,
+		FreeBytes:      slabs*int(s.allocConf.TotalObjectSize) - liveBytes,
+		RequestedBytes: int(s.requestedBytes.Load()),
+		AllocatedBytes: int(allocs) * int(s.allocConf.ObjectSize),
+		HugePageSlabs:  hugePageSlabs,
+		AdvisedBytes:   int(s.advisedBytes.Load()),
+		PaddedAllocs:   int(s.paddedAllocs.Load()),
+		Quarantined:    quarantined,
+		Cached:         cached,
+		CacheHits:      int(s.cacheHits.Load()),
+		CacheMisses:    int(s.cacheMisses.Load()),
 	}
 }
 
@@ -156,6 +388,11 @@ func (s *Store) allocFromOffset() RefPointer {
 	s.objectsLock.RUnlock()
 
 	ref := NewReference(obj, meta)
+	// Slots reset by Compact may have a non-zero generation, and may have
+	// been committed or tagged
+	ref.setGen(ref.metadata().gen)
+	ref.metadata().committed = false
+	ref.metadata().tag = 0
 	return ref
 }
 
@@ -174,9 +411,21 @@ func (s *Store) growObjects(targetLen int) {
 	s.objectsLock.Lock()
 	for len(s.objects) < targetLen {
 		// Create a new slab
-		objects, metadata := MmapSlab(s.allocConf)
+		objects, metas, hugePages := mmapSlab(s.allocConf)
+		firstSlot := uint64(len(s.objects)) * s.allocConf.ObjectsPerSlab
+		if firstSlot+s.allocConf.ObjectsPerSlab > maxSlots {
+			panic(fmt.Errorf("cannot allocate more than %d slots in a single Store: %w", uint64(maxSlots), ErrSizeLimit))
+		}
+		// Record the owning pool, and the position of the slot, in
+		// every slot of the new slab
+		for i, meta := range metas {
+			m := (*metadata)(unsafe.Pointer(meta))
+			m.pool = s.pool
+			m.slot = uint32(firstSlot + uint64(i))
+		}
 		s.objects = append(s.objects, objects)
-		s.metadata = append(s.metadata, metadata)
+		s.metadata = append(s.metadata, metas)
+		s.hugePages = append(s.hugePages, hugePages)
 	}
 
 	// Release write lock
@@ -9,6 +9,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestSlabIntegrity(t *testing.T) {
@@ -58,39 +59,363 @@ func TestSlabIntegrity(t *testing.T) {
 		(1 << 15) + 1,
 	} {
 		t.Run(fmt.Sprintf("Test allocation integrity for %d", objectSize), func(t *testing.T) {
-			conf := NewAllocConfigBySize(objectSize, 1<<16)
-			store := New(conf)
-			defer func() {
-				assert.NoError(t, store.Destroy())
-			}()
-
-			// Force 3 slabs to be created for this object size
-			// Test that the allocations for each slab are correct
-			for range 3 {
-				refs := []RefPointer{}
-				for range conf.ObjectsPerSlab {
-					refs = append(refs, store.Alloc())
-				}
-
-				baseSlabData := refs[0].DataPtr()
-				baseSlabMetadata := refs[0].metadataPtr()
-
-				// Check that the metadata is allocated immediately _after_ the data
-				assert.Equal(t, baseSlabMetadata, baseSlabData+uintptr(conf.TotalObjectSize))
-
-				// Check all the allocations for their integrity
-				for i, ref := range refs {
-					// Check that the data allocations are spaced out appropriately
-					dataPtr := ref.DataPtr()
-					expectedDataOffset := uintptr(conf.ObjectSize) * uintptr(i)
-					assert.Equal(t, baseSlabData+expectedDataOffset, dataPtr)
-
-					// Check that the metadata allocations are spaced out appropriately
-					metaPtr := ref.metadataPtr()
-					expectedMetaOffset := uintptr(conf.MetadataSize) * uintptr(i)
-					assert.Equal(t, baseSlabMetadata+expectedMetaOffset, metaPtr)
-				}
-			}
+			testSlabIntegrity(t, NewAllocConfigBySize(objectSize, 1<<16))
 		})
 	}
 }
+
+// Test slab integrity for object sizes which are not powers of two
+func TestSlabIntegrity_ExactSize(t *testing.T) {
+	for _, objectSize := range []uint64{
+		24,
+		40,
+		56,
+		(1 << 10) + 8,
+		3 * (1 << 12),
+		(1 << 16) + 8,
+	} {
+		t.Run(fmt.Sprintf("Test allocation integrity for %d", objectSize), func(t *testing.T) {
+			conf := NewAllocConfigByExactSize(objectSize, 1<<16)
+			assert.Equal(t, objectSize, conf.ObjectSize)
+			assert.Equal(t, conf.ObjectsPerSlab*objectSize, conf.TotalObjectSize)
+			testSlabIntegrity(t, conf)
+		})
+	}
+}
+
+func testSlabIntegrity(t *testing.T, conf AllocConfig) {
+	store := New(conf)
+	defer func() {
+		assert.NoError(t, store.Destroy())
+	}()
+
+	// Force 3 slabs to be created for this object size
+	// Test that the allocations for each slab are correct
+	for range 3 {
+		refs := []RefPointer{}
+		for range conf.ObjectsPerSlab {
+			refs = append(refs, store.Alloc())
+		}
+
+		baseSlabData := refs[0].DataPtr()
+		baseSlabMetadata := refs[0].metadataPtr()
+
+		// Check that the metadata is allocated immediately _after_ the data
+		assert.Equal(t, baseSlabMetadata, baseSlabData+uintptr(conf.TotalObjectSize))
+
+		// Check all the allocations for their integrity
+		for i, ref := range refs {
+			// Check that the data allocations are spaced out appropriately
+			dataPtr := ref.DataPtr()
+			expectedDataOffset := uintptr(conf.ObjectSize) * uintptr(i)
+			assert.Equal(t, baseSlabData+expectedDataOffset, dataPtr)
+
+			// Check that the metadata allocations are spaced out appropriately
+			metaPtr := ref.metadataPtr()
+			expectedMetaOffset := uintptr(conf.MetadataSize) * uintptr(i)
+			assert.Equal(t, baseSlabMetadata+expectedMetaOffset, metaPtr)
+		}
+	}
+}
+
+// Demonstrate that the byte accounting in Stats tracks slabs and live
+// allocations
+func TestStats_Bytes(t *testing.T) {
+	conf := NewAllocConfigByExactSize(40, 1<<10)
+	store := New(conf)
+	defer func() {
+		assert.NoError(t, store.Destroy())
+	}()
+
+	assert.Equal(t, Stats{}, store.Stats())
+
+	refs := []RefPointer{}
+	for range conf.ObjectsPerSlab + 1 {
+		refs = append(refs, store.Alloc())
+	}
+	for _, ref := range refs[:10] {
+		store.Free(ref)
+	}
+
+	stats := store.Stats()
+	live := int(conf.ObjectsPerSlab) + 1 - 10
+	assert.Equal(t, 2, stats.Slabs)
+	assert.Equal(t, live, stats.Live)
+	assert.Equal(t, 2*int(conf.TotalSlabSize), stats.MappedBytes)
+	assert.Equal(t, live*40, stats.LiveBytes)
+	assert.Equal(t, 2*int(conf.TotalObjectSize)-live*40, stats.FreeBytes)
+	// Alloc requests the entire object
+	assert.Equal(t, (int(conf.ObjectsPerSlab)+1)*40, stats.RequestedBytes)
+	assert.Equal(t, (int(conf.ObjectsPerSlab)+1)*40, stats.AllocatedBytes)
+
+	// Adding stats sums every field
+	doubled := stats.Add(stats)
+	assert.Equal(t, 2*stats.Allocs, doubled.Allocs)
+	assert.Equal(t, 2*stats.Slabs, doubled.Slabs)
+	assert.Equal(t, 2*stats.MappedBytes, doubled.MappedBytes)
+	assert.Equal(t, 2*stats.LiveBytes, doubled.LiveBytes)
+	assert.Equal(t, 2*stats.FreeBytes, doubled.FreeBytes)
+
+	// Subtracting stats reverses adding them
+	assert.Equal(t, stats, doubled.Sub(stats))
+	assert.Equal(t, Stats{}, stats.Sub(stats))
+}
+
+// Demonstrate that compaction moves live allocations to the start of the
+// store, releases empty slabs and invalidates stale references
+func TestCompact(t *testing.T) {
+	conf := NewAllocConfigBySize(8, 1<<8)
+	store := New(conf)
+	defer func() {
+		assert.NoError(t, store.Destroy())
+	}()
+
+	perSlab := int(conf.ObjectsPerSlab)
+
+	refs := []RefPointer{}
+	for i := range perSlab * 3 {
+		r := store.Alloc()
+		r.Bytes(8)[0] = byte(i)
+		refs = append(refs, r)
+	}
+
+	// Free everything except the last allocation in each slab
+	live := map[RefPointer]byte{}
+	for i, r := range refs {
+		if i%perSlab == perSlab-1 {
+			live[r] = byte(i)
+		} else {
+			store.Free(r)
+		}
+	}
+
+	moves := 0
+	released := store.Compact(func(oldRef, newRef RefPointer) {
+		moves++
+		live[newRef] = live[oldRef]
+		delete(live, oldRef)
+	})
+
+	// The live allocations now fit in a single slab
+	assert.Equal(t, 2, released)
+	assert.Equal(t, 1, store.Stats().Slabs)
+	// The last allocation in the first slab is moved to the first slot
+	assert.Equal(t, 3, moves)
+	for r, value := range live {
+		assert.Equal(t, value, r.Bytes(8)[0])
+	}
+
+	// The moved allocation's old slot can't be accessed with a stale
+	// reference
+	assert.False(t, refs[perSlab-1].IsLive())
+
+	// Allocating fills the first slab before creating a new one
+	for range perSlab - 3 {
+		r := store.Alloc()
+		assert.True(t, r.IsLive())
+	}
+	assert.Equal(t, 1, store.Stats().Slabs)
+	store.Alloc()
+	assert.Equal(t, 2, store.Stats().Slabs)
+
+	// Compacting a store with no live allocations releases every slab
+	empty := New(conf)
+	for range perSlab * 2 {
+		r := empty.Alloc()
+		empty.Free(r)
+	}
+	assert.Equal(t, 1, empty.Compact(func(_, _ RefPointer) {}))
+	assert.Equal(t, 0, empty.Stats().Slabs)
+	assert.NoError(t, empty.Destroy())
+}
+
+// Demonstrate that compaction leaves pinned allocations in place, and that
+// free slots before a pinned allocation can still be allocated from
+func TestCompact_Pinned(t *testing.T) {
+	conf := NewAllocConfigBySize(8, 1<<8)
+	store := New(conf)
+	defer func() {
+		assert.NoError(t, store.Destroy())
+	}()
+
+	perSlab := int(conf.ObjectsPerSlab)
+
+	refs := []RefPointer{}
+	for range perSlab * 3 {
+		refs = append(refs, store.Alloc())
+	}
+
+	// Keep the first allocation of the last slab pinned, and one other
+	// allocation which can be moved
+	pinned := refs[perSlab*2]
+	pinned.Pin()
+	movable := refs[perSlab*2+1]
+	for _, r := range refs {
+		if r != pinned && r != movable {
+			store.Free(r)
+		}
+	}
+
+	moved := []RefPointer{}
+	released := store.Compact(func(oldRef, newRef RefPointer) {
+		assert.Equal(t, movable, oldRef)
+		moved = append(moved, newRef)
+	})
+
+	// No slabs can be released, because the pinned allocation is in the
+	// last slab
+	assert.Equal(t, 0, released)
+	assert.Equal(t, 3, store.Stats().Slabs)
+	assert.True(t, pinned.IsLive())
+	require.Len(t, moved, 1)
+	// The movable allocation was moved into the first slot
+	assert.Equal(t, uintptr(refs[0].dataAddress&pointerMask), moved[0].DataPtr())
+
+	// Every free slot is allocated from before a new slab is created
+	for range perSlab*3 - 2 {
+		refs = append(refs, store.Alloc())
+	}
+	assert.Equal(t, 3, store.Stats().Slabs)
+	store.Alloc()
+	assert.Equal(t, 4, store.Stats().Slabs)
+}
+
+// Demonstrate that AllocRequested records the requested bytes, allowing the
+// bytes wasted by rounding to be measured
+func TestStats_RequestedBytes(t *testing.T) {
+	conf := NewAllocConfigByExactSize(40, 1<<10)
+	store := New(conf)
+	defer func() {
+		assert.NoError(t, store.Destroy())
+	}()
+
+	store.AllocRequested(33)
+	store.Free(store.AllocRequested(40))
+	store.AllocRequested(0)
+
+	stats := store.Stats()
+	assert.Equal(t, 73, stats.RequestedBytes)
+	assert.Equal(t, 120, stats.AllocatedBytes)
+
+	// Frees don't change the requested or allocated bytes
+	assert.Equal(t, 2, stats.Live)
+}
+
+// Demonstrate that AllocPadded allocates normally, and is counted in
+// PaddedAllocs
+func TestStats_PaddedAllocs(t *testing.T) {
+	conf := NewAllocConfigBySize(64, 1<<10)
+	store := New(conf)
+	defer func() {
+		assert.NoError(t, store.Destroy())
+	}()
+
+	store.AllocPadded(8)
+	store.Free(store.AllocPadded(16))
+	store.AllocRequested(64)
+
+	stats := store.Stats()
+	assert.Equal(t, 3, stats.Allocs)
+	assert.Equal(t, 2, stats.PaddedAllocs)
+	assert.Equal(t, 88, stats.RequestedBytes)
+	assert.Equal(t, 2, stats.Add(stats).Sub(stats).PaddedAllocs)
+}
+
+// Demonstrate that TryFreeAll frees every valid reference, and returns an
+// error for each reference which can't be freed
+func TestTryFreeAll(t *testing.T) {
+	conf := NewAllocConfigBySize(8, 32*8)
+	store := New(conf)
+	defer func() {
+		assert.NoError(t, store.Destroy())
+	}()
+
+	refs := []RefPointer{}
+	for range 10 {
+		refs = append(refs, store.Alloc())
+	}
+	store.Free(refs[3])
+
+	errs := store.TryFreeAll(refs)
+	require.Len(t, errs, 1)
+	assert.ErrorIs(t, errs[0], ErrFreed)
+	assert.Equal(t, 0, store.Stats().Live)
+	assert.Equal(t, 10, store.Stats().Frees)
+
+	// Every slot is reused
+	slots := map[int]bool{}
+	for range 10 {
+		ref := store.Alloc()
+		slots[ref.Slot()] = true
+	}
+	assert.Len(t, slots, 10)
+	assert.Equal(t, 10, store.Stats().Reused)
+}
+
+// Demonstrate that a store requesting huge pages works normally, whether or
+// not huge pages are available on this system
+func TestHugePages(t *testing.T) {
+	conf := NewAllocConfigBySize(1<<10, HugePageSize)
+	conf.HugePages = true
+	store := New(conf)
+	defer func() {
+		assert.NoError(t, store.Destroy())
+	}()
+
+	refs := []RefPointer{}
+	for i := range conf.ObjectsPerSlab + 1 {
+		ref := store.Alloc()
+		ref.Bytes(8)[0] = byte(i)
+		refs = append(refs, ref)
+	}
+	for i, ref := range refs {
+		assert.Equal(t, byte(i), ref.Bytes(8)[0])
+	}
+
+	stats := store.Stats()
+	assert.Equal(t, 2, stats.Slabs)
+	assert.LessOrEqual(t, stats.HugePageSlabs, stats.Slabs)
+
+	// Releasing slabs also releases their huge pages
+	for _, ref := range refs {
+		store.Free(ref)
+	}
+	assert.Equal(t, 2, store.Compact(nil))
+	assert.Equal(t, 0, store.Stats().HugePageSlabs)
+
+	// A store which doesn't request huge pages never uses them
+	plain := New(NewAllocConfigBySize(1<<10, HugePageSize))
+	defer func() {
+		assert.NoError(t, plain.Destroy())
+	}()
+	plain.Alloc()
+	assert.Equal(t, 0, plain.Stats().HugePageSlabs)
+}
+
+// Demonstrate that every allocation records the pool of the store it was
+// allocated from
+func TestNewInPool(t *testing.T) {
+	assert.Panics(t, func() { NewInPool(NewAllocConfigBySize(8, 1<<8), -1) })
+	assert.Panics(t, func() { NewInPool(NewAllocConfigBySize(8, 1<<8), 1<<16) })
+
+	conf := NewAllocConfigBySize(8, 1<<8)
+	store := NewInPool(conf, 7)
+	defer func() {
+		assert.NoError(t, store.Destroy())
+	}()
+
+	for range conf.ObjectsPerSlab * 2 {
+		ref := store.Alloc()
+		assert.Equal(t, 7, ref.Pool())
+		store.Free(ref)
+		ref = store.Alloc()
+		assert.Equal(t, 7, ref.Pool())
+	}
+
+	plain := New(conf)
+	defer func() {
+		assert.NoError(t, plain.Destroy())
+	}()
+	ref := plain.Alloc()
+	assert.Equal(t, 0, ref.Pool())
+}
@@ -0,0 +1,53 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+package pointerstore
+
+// Sets the maximum number of freed slots held in quarantine. While a slot is
+// in quarantine it can't be allocated. Once the quarantine holds more than
+// maxSlots slots the oldest slot is released, and can be allocated again. A
+// maxSlots of 0 disables the quarantine, releasing every quarantined slot.
+//
+// Without a quarantine the most recently freed slot is the next to be
+// allocated. So a stale reference to a freed slot will usually refer to a
+// new allocation with a different generation, and any use of it is
+// detected. But the generation is only 8 bits wide, so a slot which is freed
+// and reallocated very frequently can return to the generation of a stale
+// reference, and using the stale reference then appears to work. Delaying
+// the reuse of freed slots makes this much less likely, and means that every
+// use of a stale reference while its slot is in quarantine is detected.
+func (s *Store) SetQuarantine(maxSlots int) {
+	s.freeLock.Lock()
+	defer s.freeLock.Unlock()
+
+	s.maxQuarantine = max(maxSlots, 0)
+	s.quarantining.Store(s.maxQuarantine > 0)
+	s.trimQuarantine()
+}
+
+// Frees r into the quarantine, releasing the oldest quarantined slot if the
+// quarantine is full. The caller must hold freeLock.
+func (s *Store) quarantineFree(r RefPointer) error {
+	// The slot is marked as free, but is not linked into the free list
+	if err := r.TryFree(RefPointer{}); err != nil {
+		return err
+	}
+	s.quarantine = append(s.quarantine, r)
+	s.trimQuarantine()
+	return nil
+}
+
+// Releases the oldest quarantined slots into the free list until the
+// quarantine is no larger than maxQuarantine. The caller must hold freeLock.
+func (s *Store) trimQuarantine() {
+	for len(s.quarantine) > s.maxQuarantine {
+		r := s.quarantine[0]
+		s.quarantine[0] = RefPointer{}
+		s.quarantine = s.quarantine[1:]
+		s.linkFree(r)
+	}
+	if len(s.quarantine) == 0 {
+		s.quarantine = nil
+	}
+}
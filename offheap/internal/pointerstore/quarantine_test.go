@@ -0,0 +1,121 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+package pointerstore
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Allocates from store, returning the slot of the new allocation
+func allocSlot(store *Store) int {
+	ref := store.Alloc()
+	return ref.Slot()
+}
+
+// Demonstrate that freed slots are held in quarantine, oldest first, and
+// can't be allocated until they are released
+func TestQuarantine(t *testing.T) {
+	conf := NewAllocConfigBySize(8, 32*8)
+	store := New(conf)
+	defer func() {
+		assert.NoError(t, store.Destroy())
+	}()
+	store.SetQuarantine(3)
+
+	refs := []RefPointer{}
+	for range 10 {
+		refs = append(refs, store.Alloc())
+	}
+	for _, ref := range refs[:5] {
+		store.Free(ref)
+	}
+	assert.Equal(t, 3, store.Stats().Quarantined)
+	assert.Equal(t, 5, store.Stats().Live)
+
+	// Quarantined slots are freed, stale references are detected
+	for _, ref := range refs[2:5] {
+		assert.False(t, ref.IsLive())
+		assert.Panics(t, func() { ref.DataPtr() })
+		assert.Panics(t, func() { store.Free(ref) })
+	}
+
+	// Only the released slots, refs[0] and refs[1], are reused
+	reused := []int{allocSlot(store), allocSlot(store)}
+	assert.ElementsMatch(t, []int{refs[0].Slot(), refs[1].Slot()}, reused)
+	assert.Equal(t, len(refs), allocSlot(store))
+
+	// Removing the quarantine releases every quarantined slot
+	store.SetQuarantine(0)
+	assert.Equal(t, 0, store.Stats().Quarantined)
+	reused = []int{allocSlot(store), allocSlot(store), allocSlot(store)}
+	assert.ElementsMatch(t, []int{refs[2].Slot(), refs[3].Slot(), refs[4].Slot()}, reused)
+}
+
+// Demonstrate that a slot stays in quarantine, and a stale reference to it is
+// detected, no matter how many other allocations are made and freed, until
+// the quarantine is full
+func TestQuarantine_StaleReference(t *testing.T) {
+	conf := NewAllocConfigBySize(8, 32*8)
+	store := New(conf)
+	defer func() {
+		assert.NoError(t, store.Destroy())
+	}()
+	store.SetQuarantine(1000)
+
+	stale := store.Alloc()
+	store.Free(stale)
+	for range 999 {
+		ref := store.Alloc()
+		require.NotEqual(t, stale.Slot(), ref.Slot())
+		store.Free(ref)
+	}
+	assert.False(t, stale.IsLive())
+
+	// The quarantine is full, the stale slot is released and reused
+	ref := store.Alloc()
+	store.Free(ref)
+	assert.Equal(t, stale.Slot(), allocSlot(store))
+}
+
+// Demonstrate that Compact releases every quarantined slot, and that Clone
+// copies the quarantine
+func TestQuarantine_CompactAndClone(t *testing.T) {
+	conf := NewAllocConfigBySize(8, 32*8)
+	store := New(conf)
+	defer func() {
+		assert.NoError(t, store.Destroy())
+	}()
+	store.SetQuarantine(10)
+
+	refs := []RefPointer{}
+	for range 100 {
+		refs = append(refs, store.Alloc())
+	}
+	for _, ref := range refs[:5] {
+		store.Free(ref)
+	}
+
+	clone := store.Clone()
+	defer func() {
+		assert.NoError(t, clone.Destroy())
+	}()
+	assert.Equal(t, 5, clone.Stats().Quarantined)
+	for range 6 {
+		clone.Free(clone.Alloc())
+	}
+	assert.Equal(t, 10, clone.Stats().Quarantined)
+
+	store.Compact(func(_, _ RefPointer) {})
+	assert.Equal(t, 0, store.Stats().Quarantined)
+	assert.Equal(t, 95, store.Stats().Live)
+
+	// The quarantine is still in place after compacting
+	ref := store.Alloc()
+	store.Free(ref)
+	assert.Equal(t, 1, store.Stats().Quarantined)
+}
@@ -0,0 +1,106 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+package pointerstore
+
+import (
+	"os"
+)
+
+// Advises the operating system that the memory used by free slots is no
+// longer needed, in every slab where at least minFreeFraction of the slots
+// are free. Returns the number of bytes advised.
+//
+// Unlike Compact no allocations are moved and no slabs are unmapped, the
+// address range of each slab remains reserved and every reference remains
+// valid. Only whole pages which contain nothing but free slots are advised,
+// so slabs of small objects with live allocations scattered throughout may
+// not have any pages advised. Slot metadata is never advised.
+//
+// If lazy is true the pages are advised using MADV_FREE, where supported,
+// which allows the operating system to reclaim them only when it is under
+// memory pressure. Otherwise the pages are advised using MADV_DONTNEED, which
+// releases them immediately. Either way the contents of free slots are lost,
+// and the pages are faulted back in when their slots are allocated again.
+//
+// Reclaim blocks allocations and frees while it runs, but may be called
+// concurrently with reading and writing live allocations.
+func (s *Store) Reclaim(minFreeFraction float64, lazy bool) (int, error) {
+	// Cached slots are free, and may be advised, so no Cache may allocate
+	// them while Reclaim runs
+	s.lockCaches()
+	defer s.unlockCaches()
+	s.freeLock.Lock()
+	defer s.freeLock.Unlock()
+	s.objectsLock.Lock()
+	defer s.objectsLock.Unlock()
+
+	allocated := int(s.allocIdx.Load())
+	perSlab := int(s.allocConf.ObjectsPerSlab)
+	objectSize := int(s.allocConf.ObjectSize)
+
+	advised := 0
+	defer func() {
+		s.advisedBytes.Add(uint64(advised))
+	}()
+
+	for slabIdx := range s.objects {
+		first := slabIdx * perSlab
+
+		free := 0
+		for i := range perSlab {
+			if s.slotIsUnused(first+i, allocated) {
+				free++
+			}
+		}
+		if free == 0 || float64(free) < minFreeFraction*float64(perSlab) {
+			continue
+		}
+
+		// Advise each run of consecutive free slots in this slab
+		runStart := -1
+		for i := 0; i <= perSlab; i++ {
+			if i < perSlab && s.slotIsUnused(first+i, allocated) {
+				if runStart < 0 {
+					runStart = i
+				}
+				continue
+			}
+			if runStart >= 0 {
+				n, err := s.adviseObjects(slabIdx, runStart*objectSize, i*objectSize, lazy)
+				advised += n
+				if err != nil {
+					return advised, err
+				}
+				runStart = -1
+			}
+		}
+	}
+
+	return advised, nil
+}
+
+// Indicates whether the slot at idx is free, or has never been allocated.
+// allocated is the number of slots which have been allocated from.
+func (s *Store) slotIsUnused(idx, allocated int) bool {
+	return idx >= allocated || s.slotIsFree(idx)
+}
+
+// Advises the whole pages lying between the start and end offsets of the
+// object space of a slab. Returns the number of bytes advised.
+func (s *Store) adviseObjects(slabIdx, start, end int, lazy bool) (int, error) {
+	pageSize := uintptr(os.Getpagesize())
+	base := s.objects[slabIdx][0]
+
+	from := (base + uintptr(start) + pageSize - 1) &^ (pageSize - 1)
+	to := (base + uintptr(end)) &^ (pageSize - 1)
+	if from >= to {
+		return 0, nil
+	}
+
+	if err := adviseUnused(pointerToBytes(from, int(to-from)), lazy); err != nil {
+		return 0, err
+	}
+	return int(to - from), nil
+}
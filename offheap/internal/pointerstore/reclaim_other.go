@@ -0,0 +1,17 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+//go:build !(darwin || dragonfly || freebsd || linux || netbsd || openbsd || solaris || windows)
+
+package pointerstore
+
+import (
+	"errors"
+)
+
+// Advising the operating system about unused pages is not supported on this
+// system.
+func adviseUnused(data []byte, lazy bool) error {
+	return errors.ErrUnsupported
+}
@@ -0,0 +1,118 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+package pointerstore
+
+import (
+	"os"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Demonstrate that the pages of free slots are advised, while live
+// allocations are untouched and freed slots can be allocated again.
+func TestReclaim(t *testing.T) {
+	for _, lazy := range []bool{false, true} {
+		pageSize := os.Getpagesize()
+		conf := NewAllocConfigBySize(uint64(pageSize), uint64(pageSize*8))
+		store := New(conf)
+		defer func() {
+			assert.NoError(t, store.Destroy())
+		}()
+
+		refs := []RefPointer{}
+		for i := range conf.ObjectsPerSlab {
+			ref := store.Alloc()
+			ref.Bytes(8)[0] = byte(i + 1)
+			refs = append(refs, ref)
+		}
+
+		// Free every slot except the first and the sixth
+		for i, ref := range refs {
+			if i != 0 && i != 5 {
+				store.Free(ref)
+			}
+		}
+
+		advised, err := store.Reclaim(0.5, lazy)
+		require.NoError(t, err)
+		assert.Equal(t, 6*pageSize, advised)
+		assert.Equal(t, 6*pageSize, store.Stats().AdvisedBytes)
+
+		// Live allocations are unchanged
+		assert.Equal(t, byte(1), refs[0].Bytes(8)[0])
+		assert.Equal(t, byte(6), refs[5].Bytes(8)[0])
+
+		// Freed slots can be allocated and used again
+		for range 6 {
+			ref := store.Alloc()
+			if runtime.GOOS == "linux" && !lazy {
+				// MADV_DONTNEED discards the contents immediately
+				assert.Equal(t, byte(0), ref.Bytes(8)[0])
+			}
+			ref.Bytes(8)[0] = 0xFF
+			assert.Equal(t, byte(0xFF), ref.Bytes(8)[0])
+		}
+		assert.Equal(t, 1, store.Stats().Slabs)
+	}
+}
+
+// Demonstrate that slabs with fewer free slots than minFreeFraction are not
+// advised
+func TestReclaim_MinFreeFraction(t *testing.T) {
+	pageSize := os.Getpagesize()
+	conf := NewAllocConfigBySize(uint64(pageSize), uint64(pageSize*8))
+	store := New(conf)
+	defer func() {
+		assert.NoError(t, store.Destroy())
+	}()
+
+	for range conf.ObjectsPerSlab {
+		store.Alloc()
+	}
+	// A second slab with a single allocation
+	store.Alloc()
+
+	// Only the second slab has enough free slots
+	advised, err := store.Reclaim(0.75, false)
+	require.NoError(t, err)
+	assert.Equal(t, 7*pageSize, advised)
+
+	advised, err = store.Reclaim(1, false)
+	require.NoError(t, err)
+	assert.Equal(t, 0, advised)
+
+	// Advised bytes are a running total
+	assert.Equal(t, 7*pageSize, store.Stats().AdvisedBytes)
+}
+
+// Demonstrate that pages shared with live allocations are never advised
+func TestReclaim_PartialPages(t *testing.T) {
+	pageSize := os.Getpagesize()
+	conf := NewAllocConfigBySize(8, uint64(pageSize*4))
+	store := New(conf)
+	defer func() {
+		assert.NoError(t, store.Destroy())
+	}()
+
+	perPage := pageSize / 8
+	refs := []RefPointer{}
+	for range conf.ObjectsPerSlab {
+		refs = append(refs, store.Alloc())
+	}
+	// Leave a single live allocation in each page
+	for i, ref := range refs {
+		if i%perPage != 0 {
+			store.Free(ref)
+		}
+	}
+
+	advised, err := store.Reclaim(0, false)
+	require.NoError(t, err)
+	assert.Equal(t, 0, advised)
+	assert.Equal(t, 0, store.Stats().AdvisedBytes)
+}
@@ -0,0 +1,21 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd || solaris
+
+package pointerstore
+
+import (
+	"golang.org/x/sys/unix"
+)
+
+// Advises the operating system that the pages in data are not needed. If
+// lazy is true MADV_FREE is tried first, older kernels which don't support
+// it fall back to MADV_DONTNEED.
+func adviseUnused(data []byte, lazy bool) error {
+	if lazy && unix.Madvise(data, unix.MADV_FREE) == nil {
+		return nil
+	}
+	return unix.Madvise(data, unix.MADV_DONTNEED)
+}
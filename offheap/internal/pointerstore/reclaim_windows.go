@@ -0,0 +1,41 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+//go:build windows
+
+package pointerstore
+
+import (
+	"unsafe"
+)
+
+const (
+	memDecommit = 0x00004000
+	memReset    = 0x00080000
+)
+
+// Advises the operating system that the pages in data are not needed. If
+// lazy is true the pages are reset with MEM_RESET, which allows them to be
+// discarded under memory pressure. Otherwise the pages are decommitted and
+// immediately committed again, which releases them while keeping them
+// usable.
+func adviseUnused(data []byte, lazy bool) error {
+	addr := uintptr(unsafe.Pointer(&data[0]))
+	size := uintptr(len(data))
+
+	if lazy {
+		if ok, _, err := procVirtualAlloc.Call(addr, size, memReset, pageReadWrite); ok == 0 {
+			return err
+		}
+		return nil
+	}
+
+	if ok, _, err := procVirtualFree.Call(addr, size, memDecommit); ok == 0 {
+		return err
+	}
+	if ok, _, err := procVirtualAlloc.Call(addr, size, memCommit, pageReadWrite); ok == 0 {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+package pointerstore
+
+import (
+	"fmt"
+)
+
+// Makes every slab in the store read-only, at the operating system level.
+// After Seal returns every call to Alloc, Free or Compact panics, and any
+// write to an allocation, or to an allocation's metadata, faults.
+//
+// Reading allocations is unaffected. A sealed store can still be cloned, and
+// the clone is not sealed, see Clone.
+//
+// If the operating system doesn't support making memory read-only an error
+// is returned, and the store is not sealed. Seal must not be called
+// concurrently with any other use of the store.
+func (s *Store) Seal() error {
+	s.freeLock.Lock()
+	defer s.freeLock.Unlock()
+	s.objectsLock.Lock()
+	defer s.objectsLock.Unlock()
+
+	if s.sealed.Load() {
+		return nil
+	}
+
+	// Guard regions are left inaccessible
+	regions := [][]byte{}
+	for _, slab := range s.objects {
+		regions = append(regions, slabRegions(slab[0], s.allocConf)...)
+	}
+	for i, region := range regions {
+		if err := protectReadOnly(region); err != nil {
+			// Restore the regions which were already protected
+			for _, protected := range regions[:i] {
+				if restoreErr := protectReadWrite(protected); restoreErr != nil {
+					panic(restoreErr)
+				}
+			}
+			return err
+		}
+	}
+
+	s.sealed.Store(true)
+	return nil
+}
+
+// Indicates whether Seal has been called on this store
+func (s *Store) IsSealed() bool {
+	return s.sealed.Load()
+}
+
+// Panics if the store is sealed, op describes the operation which was
+// attempted
+func (s *Store) checkSealed(op string) {
+	if err := s.sealedErr(op); err != nil {
+		panic(err)
+	}
+}
+
+// Returns an error wrapping ErrSealed if the store is sealed, op describes
+// the operation which was attempted
+func (s *Store) sealedErr(op string) error {
+	if s.sealed.Load() {
+		return fmt.Errorf("cannot %s in a sealed store: %w", op, ErrSealed)
+	}
+	return nil
+}
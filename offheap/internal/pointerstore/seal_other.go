@@ -0,0 +1,27 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+//go:build !(darwin || dragonfly || freebsd || linux || netbsd || openbsd || solaris || windows)
+
+package pointerstore
+
+import (
+	"errors"
+)
+
+// Making memory read-only is not supported on this system.
+func protectReadOnly(data []byte) error {
+	return errors.ErrUnsupported
+}
+
+// Making memory read-only is not supported on this system, so there is never
+// any need to restore it.
+func protectReadWrite(data []byte) error {
+	return errors.ErrUnsupported
+}
+
+// Making memory inaccessible is not supported on this system.
+func protectNone(data []byte) error {
+	return errors.ErrUnsupported
+}
@@ -0,0 +1,65 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+package pointerstore
+
+import (
+	"runtime/debug"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Demonstrate that a sealed store can be read, but can't be allocated from,
+// freed to or written to
+func TestSeal(t *testing.T) {
+	conf := NewAllocConfigBySize(8, 32*8)
+	store := New(conf)
+	defer func() {
+		assert.NoError(t, store.Destroy())
+	}()
+
+	refs := []RefPointer{}
+	for i := range 100 {
+		ref := store.Alloc()
+		ref.Bytes(8)[0] = byte(i)
+		refs = append(refs, ref)
+	}
+	store.Free(refs[0])
+	refs = refs[1:]
+
+	if err := store.Seal(); err != nil {
+		t.Skipf("sealing is not supported %s", err)
+	}
+	assert.True(t, store.IsSealed())
+	// Sealing twice is allowed
+	require.NoError(t, store.Seal())
+
+	for i, ref := range refs {
+		assert.Equal(t, byte(i+1), ref.Bytes(8)[0])
+	}
+
+	assert.Panics(t, func() { store.Alloc() })
+	assert.Panics(t, func() { store.Free(refs[0]) })
+	assert.Panics(t, func() { store.Compact(func(_, _ RefPointer) {}) })
+
+	// Writes fault, SetPanicOnFault allows us to observe this as a panic
+	defer debug.SetPanicOnFault(debug.SetPanicOnFault(true))
+	assert.Panics(t, func() { refs[0].Bytes(8)[0] = 0xFF })
+	assert.Panics(t, func() { refs[0].Pin() })
+	assert.Equal(t, byte(1), refs[0].Bytes(8)[0])
+
+	// A clone of a sealed store is not sealed
+	clone := store.Clone()
+	defer func() {
+		assert.NoError(t, clone.Destroy())
+	}()
+	assert.False(t, clone.IsSealed())
+	c, ok := clone.Resolve(refs[0].Handle())
+	require.True(t, ok)
+	c.Bytes(8)[0] = 0xFF
+	clone.Free(c)
+	clone.Alloc()
+}
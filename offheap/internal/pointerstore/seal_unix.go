@@ -0,0 +1,26 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd || solaris
+
+package pointerstore
+
+import (
+	"golang.org/x/sys/unix"
+)
+
+// Makes the pages in data read-only
+func protectReadOnly(data []byte) error {
+	return unix.Mprotect(data, unix.PROT_READ)
+}
+
+// Makes the pages in data readable and writable
+func protectReadWrite(data []byte) error {
+	return unix.Mprotect(data, unix.PROT_READ|unix.PROT_WRITE)
+}
+
+// Makes the pages in data inaccessible, any access will fault
+func protectNone(data []byte) error {
+	return unix.Mprotect(data, unix.PROT_NONE)
+}
@@ -0,0 +1,43 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+//go:build windows
+
+package pointerstore
+
+import (
+	"unsafe"
+)
+
+const (
+	pageNoAccess = 0x01
+	pageReadOnly = 0x02
+)
+
+var procVirtualProtect = kernel32.NewProc("VirtualProtect")
+
+// Makes the pages in data read-only
+func protectReadOnly(data []byte) error {
+	return virtualProtect(data, pageReadOnly)
+}
+
+// Makes the pages in data readable and writable
+func protectReadWrite(data []byte) error {
+	return virtualProtect(data, pageReadWrite)
+}
+
+// Makes the pages in data inaccessible, any access will fault
+func protectNone(data []byte) error {
+	return virtualProtect(data, pageNoAccess)
+}
+
+func virtualProtect(data []byte, protect uintptr) error {
+	var oldProtect uint32
+	addr := uintptr(unsafe.Pointer(&data[0]))
+	ok, _, err := procVirtualProtect.Call(addr, uintptr(len(data)), protect, uintptr(unsafe.Pointer(&oldProtect)))
+	if ok == 0 {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+package pointerstore
+
+import (
+	"fmt"
+	"math"
+	"sync/atomic"
+)
+
+// The largest tag which can be given to an allocation, see Store.Tag
+const MaxTag = math.MaxUint16
+
+// The statistics for every allocation given a single tag, see Store.Tag
+type TagStats struct {
+	Allocs int
+	Frees  int
+	Live   int
+	// The number of bytes used by live allocations with this tag
+	LiveBytes int
+	// The number of bytes asked for by every allocation ever given this tag
+	RequestedBytes int
+}
+
+// Returns the sum of each of the fields in s and other
+func (s TagStats) Add(other TagStats) TagStats {
+	return TagStats{
+		Allocs:         s.Allocs + other.Allocs,
+		Frees:          s.Frees + other.Frees,
+		Live:           s.Live + other.Live,
+		LiveBytes:      s.LiveBytes + other.LiveBytes,
+		RequestedBytes: s.RequestedBytes + other.RequestedBytes,
+	}
+}
+
+type tagCounters struct {
+	allocs         atomic.Uint64
+	frees          atomic.Uint64
+	requestedBytes atomic.Uint64
+}
+
+func (c *tagCounters) clone() *tagCounters {
+	clone := &tagCounters{}
+	clone.allocs.Store(c.allocs.Load())
+	clone.frees.Store(c.frees.Load())
+	clone.requestedBytes.Store(c.requestedBytes.Load())
+	return clone
+}
+
+// Records that the allocation referenced by r, which has just been allocated
+// with requested bytes, belongs to tag. The allocation is counted in the
+// TagStats for tag until it is freed. Tag must be called at most once for
+// each allocation, before r is shared with any other goroutine.
+//
+// Tags attribute the memory used by a store to its owners, such as the
+// tenants of a service. tag must be between 1 and MaxTag, allocations which
+// are never tagged are untagged.
+func (s *Store) Tag(r RefPointer, tag int, requested uint64) {
+	if tag < 1 || tag > MaxTag {
+		panic(fmt.Errorf("tag %d must be between 1 and %d", tag, MaxTag))
+	}
+	r.metadata().tag = uint16(tag)
+
+	counters := s.tagCounters(uint16(tag))
+	counters.allocs.Add(1)
+	counters.requestedBytes.Add(requested)
+}
+
+// Returns the tag of the allocation referenced by r, or 0 if r is untagged
+func (r *RefPointer) Tag() int {
+	return int(r.metadata().tag)
+}
+
+// Returns the statistics for each tag which has been given to an
+// allocation in this store
+func (s *Store) TagStats() map[int]TagStats {
+	tagStats := map[int]TagStats{}
+	s.tags.Range(func(tag, value any) bool {
+		counters := value.(*tagCounters)
+		allocs := counters.allocs.Load()
+		frees := counters.frees.Load()
+		live := int(allocs - frees)
+		tagStats[int(tag.(uint16))] = TagStats{
+			Allocs:         int(allocs),
+			Frees:          int(frees),
+			Live:           live,
+			LiveBytes:      live * int(s.allocConf.ObjectSize),
+			RequestedBytes: int(counters.requestedBytes.Load()),
+		}
+		return true
+	})
+	return tagStats
+}
+
+// Counts the free of r against its tag, if r is tagged
+func (s *Store) countTagFree(r RefPointer) {
+	if tag := r.metadata().tag; tag != 0 {
+		s.tagCounters(tag).frees.Add(1)
+	}
+}
+
+func (s *Store) tagCounters(tag uint16) *tagCounters {
+	if counters, ok := s.tags.Load(tag); ok {
+		return counters.(*tagCounters)
+	}
+	counters, _ := s.tags.LoadOrStore(tag, &tagCounters{})
+	return counters.(*tagCounters)
+}
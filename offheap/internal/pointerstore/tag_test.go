@@ -0,0 +1,66 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+package pointerstore
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Demonstrate that tagged allocations are counted against their tag until
+// they are freed, however they are freed, and that a reused slot doesn't keep
+// its old tag
+func TestTag(t *testing.T) {
+	conf := NewAllocConfigBySize(8, 32*8)
+	store := New(conf)
+	defer func() {
+		assert.NoError(t, store.Destroy())
+	}()
+	store.EnableCaches(1, 4)
+
+	refs := []RefPointer{}
+	for i := range 6 {
+		ref := store.AllocRequested(5)
+		store.Tag(ref, 1+i%2, 5)
+		refs = append(refs, ref)
+	}
+	assert.Equal(t, 1, refs[0].Tag())
+	assert.Equal(t, 2, refs[1].Tag())
+	assert.Panics(t, func() { store.Tag(store.Alloc(), 0, 8) })
+	assert.Panics(t, func() { store.Tag(store.Alloc(), MaxTag+1, 8) })
+
+	store.Free(refs[0])
+	store.Cache(0).Free(refs[2])
+	assert.Empty(t, store.TryFreeAll(refs[3:4]))
+
+	assert.Equal(t, map[int]TagStats{
+		1: {Allocs: 3, Frees: 2, Live: 1, LiveBytes: 8, RequestedBytes: 15},
+		2: {Allocs: 3, Frees: 1, Live: 2, LiveBytes: 16, RequestedBytes: 15},
+	}, store.TagStats())
+
+	// Reused slots are untagged
+	for range 3 {
+		ref := store.Cache(0).Alloc()
+		assert.Equal(t, 0, ref.Tag())
+		store.Free(ref)
+	}
+	assert.Equal(t, 2, store.TagStats()[1].Frees)
+
+	// Tags are kept by compaction and cloning
+	taggedMoves := 0
+	store.Compact(func(oldRef, newRef RefPointer) {
+		assert.Equal(t, oldRef.Tag(), newRef.Tag())
+		if newRef.Tag() != 0 {
+			taggedMoves++
+		}
+	})
+	assert.Greater(t, taggedMoves, 0)
+	clone := store.Clone()
+	defer func() {
+		assert.NoError(t, clone.Destroy())
+	}()
+	assert.Equal(t, store.TagStats(), clone.TagStats())
+}
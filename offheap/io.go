@@ -0,0 +1,150 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+package offheap
+
+import (
+	"errors"
+	"io"
+
+	"github.com/fmstephe/flib/funsafe"
+)
+
+// A SliceReader implements io.Reader and io.ReaderAt, reading from the
+// contents of a RefSlice[byte] or a RefString.
+//
+// The reader doesn't own the allocation it reads from. The allocation must not
+// be freed, or invalidated by an append, while the reader is in use.
+type SliceReader struct {
+	bytes []byte
+	pos   int
+}
+
+// Returns a SliceReader which reads the contents of ref.
+func NewSliceReader(ref RefSlice[byte]) *SliceReader {
+	if ref.IsNil() {
+		return &SliceReader{}
+	}
+	return &SliceReader{
+		bytes: ref.Value(),
+	}
+}
+
+// Returns a SliceReader which reads the contents of ref.
+func NewStringReader(ref RefString) *SliceReader {
+	return &SliceReader{
+		bytes: funsafe.StringToBytes(ref.Value()),
+	}
+}
+
+// Returns the number of bytes which have not yet been read
+func (r *SliceReader) Len() int {
+	return max(len(r.bytes)-r.pos, 0)
+}
+
+// Reads up to len(p) bytes into p. Returns io.EOF when there are no bytes
+// left to read.
+func (r *SliceReader) Read(p []byte) (int, error) {
+	if r.pos >= len(r.bytes) {
+		return 0, io.EOF
+	}
+	n := copy(p, r.bytes[r.pos:])
+	r.pos += n
+	return n, nil
+}
+
+// Reads len(p) bytes into p, starting at offset off. Doesn't affect the
+// position used by Read. If fewer than len(p) bytes are available io.EOF is
+// returned.
+func (r *SliceReader) ReadAt(p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, errors.New("offheap.SliceReader.ReadAt: negative offset")
+	}
+	if off >= int64(len(r.bytes)) {
+		return 0, io.EOF
+	}
+	n := copy(p, r.bytes[off:])
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+// A SliceWriter implements io.Writer and io.WriterAt, writing into a
+// RefSlice[byte] which grows as needed.
+//
+// Growing the slice invalidates the RefSlice the writer was created with, the
+// current RefSlice is available via RefSlice.
+type SliceWriter struct {
+	store *Store
+	ref   RefSlice[byte]
+}
+
+// Returns a SliceWriter which appends to ref. If ref is nil the first write
+// will allocate a new slice. Panics if ref is a view created by SubSlice.
+func NewSliceWriter(s *Store, ref RefSlice[byte]) *SliceWriter {
+	if ref.view {
+		panic("cannot write to a RefSlice created by SubSlice")
+	}
+	return &SliceWriter{
+		store: s,
+		ref:   ref,
+	}
+}
+
+// Returns the number of bytes in the slice being written to
+func (w *SliceWriter) Len() int {
+	return w.ref.length
+}
+
+// Returns the slice written to. Any RefSlice previously returned by this
+// method, or passed to NewSliceWriter, may have been invalidated by growing
+// the slice and must not be used.
+func (w *SliceWriter) RefSlice() RefSlice[byte] {
+	return w.ref
+}
+
+// Appends p to the slice. The returned error is always nil.
+func (w *SliceWriter) Write(p []byte) (int, error) {
+	return w.writeAt(p, w.ref.length), nil
+}
+
+// Appends str to the slice. The returned error is always nil.
+func (w *SliceWriter) WriteString(str string) (int, error) {
+	return w.writeAt(funsafe.StringToBytes(str), w.ref.length), nil
+}
+
+// Writes p into the slice, starting at offset off, growing the slice if
+// needed. If off is beyond the end of the slice the gap is filled with zeroes.
+func (w *SliceWriter) WriteAt(p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, errors.New("offheap.SliceWriter.WriteAt: negative offset")
+	}
+	return w.writeAt(p, int(off)), nil
+}
+
+func (w *SliceWriter) writeAt(p []byte, off int) int {
+	oldLength := w.ref.length
+	w.extend(off + len(p))
+
+	slice := w.ref.Value()
+	if off > oldLength {
+		clear(slice[oldLength:off])
+	}
+	return copy(slice[off:], p)
+}
+
+// Grows the slice, if needed, so that its length is at least newLength
+func (w *SliceWriter) extend(newLength int) {
+	if w.ref.IsNil() {
+		w.ref = AllocSlice[byte](w.store, newLength, newLength)
+		return
+	}
+	if newLength <= w.ref.length {
+		return
+	}
+
+	pRef, newCapacity := resizeAndInvalidate[byte](w.store, w.ref.ref, w.ref.capacity, w.ref.length, newLength-w.ref.length)
+	w.ref = newRefSlice[byte](newLength, newCapacity, pRef)
+}
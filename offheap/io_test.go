@@ -0,0 +1,149 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+package offheap
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Demonstrate that a SliceReader reads the contents of slices and strings
+func Test_SliceReader(t *testing.T) {
+	os := NewSized(1 << 8)
+	defer func() {
+		assert.NoError(t, os.Destroy())
+	}()
+
+	sRef := ConcatSlices(os, []byte("hello offheap world"))
+	defer FreeSlice(os, sRef)
+
+	r := NewSliceReader(sRef)
+	assert.Equal(t, 19, r.Len())
+
+	p := make([]byte, 5)
+	n, err := r.Read(p)
+	assert.NoError(t, err)
+	assert.Equal(t, 5, n)
+	assert.Equal(t, "hello", string(p))
+	assert.Equal(t, 14, r.Len())
+
+	rest, err := io.ReadAll(r)
+	assert.NoError(t, err)
+	assert.Equal(t, " offheap world", string(rest))
+
+	n, err = r.Read(p)
+	assert.Equal(t, io.EOF, err)
+	assert.Equal(t, 0, n)
+
+	// ReadAt is independent of the read position
+	n, err = r.ReadAt(p, 6)
+	assert.NoError(t, err)
+	assert.Equal(t, "offhe", string(p[:n]))
+
+	n, err = r.ReadAt(p, 16)
+	assert.Equal(t, io.EOF, err)
+	assert.Equal(t, "rld", string(p[:n]))
+
+	_, err = r.ReadAt(p, -1)
+	assert.Error(t, err)
+
+	strRef := AllocStringFromString(os, "a string")
+	defer FreeString(os, strRef)
+
+	all, err := io.ReadAll(NewStringReader(strRef))
+	assert.NoError(t, err)
+	assert.Equal(t, "a string", string(all))
+
+	// Nil references are read as empty
+	all, err = io.ReadAll(NewSliceReader(RefSlice[byte]{}))
+	assert.NoError(t, err)
+	assert.Empty(t, all)
+	all, err = io.ReadAll(NewStringReader(RefString{}))
+	assert.NoError(t, err)
+	assert.Empty(t, all)
+}
+
+// Demonstrate that a SliceWriter grows the slice it writes to
+func Test_SliceWriter(t *testing.T) {
+	os := NewSized(1 << 8)
+	defer func() {
+		assert.NoError(t, os.Destroy())
+	}()
+
+	w := NewSliceWriter(os, RefSlice[byte]{})
+	expected := strings.Builder{}
+	for i := range 1000 {
+		value := strings.Repeat("x", i%10) + "\n"
+		n, err := w.WriteString(value)
+		require.NoError(t, err)
+		require.Equal(t, len(value), n)
+		expected.WriteString(value)
+	}
+	ref := w.RefSlice()
+	assert.Equal(t, expected.String(), string(ref.Value()))
+	assert.Equal(t, expected.Len(), w.Len())
+
+	// Writing at an offset overwrites, and extends, the slice
+	n, err := w.WriteAt([]byte("abc"), int64(w.Len()-1))
+	assert.NoError(t, err)
+	assert.Equal(t, 3, n)
+	ref = w.RefSlice()
+	assert.True(t, strings.HasSuffix(string(ref.Value()), "xxxxxxxxxabc"))
+
+	// Writing beyond the end of the slice fills the gap with zeroes
+	length := w.Len()
+	_, err = w.WriteAt([]byte("z"), int64(length+3))
+	assert.NoError(t, err)
+	ref = w.RefSlice()
+	assert.Equal(t, []byte{0, 0, 0, 'z'}, ref.Value()[length:])
+
+	_, err = w.WriteAt([]byte("z"), -1)
+	assert.Error(t, err)
+
+	FreeSlice(os, w.RefSlice())
+
+	// Views can't be written to
+	sRef := ConcatSlices(os, []byte("view"))
+	assert.Panics(t, func() { NewSliceWriter(os, SubSlice(os, sRef, 0, 2)) })
+	FreeSlice(os, sRef)
+}
+
+// Demonstrate that the readers and writers work with standard library
+// encoders and compressors
+func Test_SliceReaderWriter_StandardLibrary(t *testing.T) {
+	os := NewSized(1 << 8)
+	defer func() {
+		assert.NoError(t, os.Destroy())
+	}()
+
+	type record struct {
+		Name  string
+		Count int
+	}
+	records := []record{}
+	for i := range 100 {
+		records = append(records, record{Name: strings.Repeat("name", i%5), Count: i})
+	}
+
+	// Compress the JSON encoding of records offheap
+	w := NewSliceWriter(os, RefSlice[byte]{})
+	gz := gzip.NewWriter(w)
+	require.NoError(t, json.NewEncoder(gz).Encode(records))
+	require.NoError(t, gz.Close())
+	compressed := w.RefSlice()
+	defer FreeSlice(os, compressed)
+
+	gr, err := gzip.NewReader(NewSliceReader(compressed))
+	require.NoError(t, err)
+	decoded := []record{}
+	require.NoError(t, json.NewDecoder(gr).Decode(&decoded))
+	assert.Equal(t, records, decoded)
+}
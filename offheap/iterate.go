@@ -0,0 +1,41 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+package offheap
+
+import (
+	"github.com/fmstephe/memorymanager/offheap/internal/pointerstore"
+)
+
+// Calls fn for every live allocation in the size class used by objects of
+// type T, passing a reference to the allocation and a pointer to its value.
+// Iteration stops early if fn returns false. This is intended for debugging,
+// and for migrating the contents of a Store.
+//
+// The Store doesn't record the type of an allocation. Every live allocation
+// in T's size class is visited, including allocations of other types, and
+// slices and strings, which share that size class. ForEachObject is only
+// useful when the caller knows that T's size class contains only objects of
+// type T, for example when T is the only type of its size allocated in s.
+//
+// It is safe for fn to free the object it is visiting. Objects allocated by
+// fn may, or may not, be visited.
+//
+// ForEachObject must not be called concurrently with any other use of s.
+func ForEachObject[T any](s *Store, fn func(RefObject[T], *T) bool) {
+	idx := s.storeIndex(typeIndex[T](s))
+	for _, classes := range s.allPools() {
+		store := classes[idx].loaded()
+		if store == nil {
+			continue
+		}
+		more := store.ForEach(func(ref pointerstore.RefPointer) bool {
+			r := newRefObject[T](ref)
+			return fn(r, r.Value())
+		})
+		if !more {
+			return
+		}
+	}
+}
@@ -0,0 +1,53 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+package offheap
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Demonstrate that ForEachObject visits every live object of a type, for
+// Stores with and without pools
+func Test_ForEachObject(t *testing.T) {
+	for _, s := range []*Store{New(), NewWithPools(1<<12, 4)} {
+		expected := map[RefObject[MutableStruct]]int{}
+		for i := range 1000 {
+			r := AllocObject[MutableStruct](s)
+			r.Value().Field = i
+			if i%4 == 0 {
+				FreeObject(s, r)
+			} else {
+				expected[r] = i
+			}
+		}
+
+		visited := map[RefObject[MutableStruct]]int{}
+		ForEachObject(s, func(r RefObject[MutableStruct], value *MutableStruct) bool {
+			assert.Equal(t, r.Value(), value)
+			visited[r] = value.Field
+			return true
+		})
+		assert.Equal(t, expected, visited)
+
+		// Iteration stops when fn returns false
+		count := 0
+		ForEachObject(s, func(r RefObject[MutableStruct], value *MutableStruct) bool {
+			count++
+			return count < 7
+		})
+		assert.Equal(t, 7, count)
+
+		// Every object can be freed during iteration
+		ForEachObject(s, func(r RefObject[MutableStruct], value *MutableStruct) bool {
+			FreeObject(s, r)
+			return true
+		})
+		assert.Equal(t, 0, StatsForType[MutableStruct](s).Live)
+
+		assert.NoError(t, s.Destroy())
+	}
+}
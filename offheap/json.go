@@ -0,0 +1,38 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+package offheap
+
+import (
+	"encoding/json"
+)
+
+// Allocates a string containing the JSON encoding of v. The encoding is the
+// same as json.Marshal, but it is written directly into offheap memory rather
+// than being returned in a []byte on the Go heap.
+//
+// If v can't be encoded the error is returned, and nothing is allocated.
+func AllocStringFromJSON(s *Store, v any) (RefString, error) {
+	builder := NewStringBuilder(s)
+	if err := json.NewEncoder(builder).Encode(v); err != nil {
+		builder.Reset()
+		return RefString{}, err
+	}
+
+	// Encode terminates the value with a newline, json.Marshal doesn't
+	builder.length--
+	return builder.Build(), nil
+}
+
+// Returns a new RefSlice whose contents are into.Value() followed by the JSON
+// encoding of v and a newline. The value is encoded directly into offheap
+// memory, through a SliceWriter. If into is nil a new slice is allocated.
+//
+// Like Append, into is no longer valid after this function returns. If v
+// can't be encoded the error is returned, and into is returned unchanged.
+func AppendJSON(s *Store, into RefSlice[byte], v any) (RefSlice[byte], error) {
+	w := NewSliceWriter(s, into)
+	err := json.NewEncoder(w).Encode(v)
+	return w.RefSlice(), err
+}
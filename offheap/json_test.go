@@ -0,0 +1,84 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+package offheap
+
+import (
+	"encoding/json"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type jsonRecord struct {
+	Name   string
+	Values []int
+	Nested map[string]bool
+}
+
+// Demonstrate that AllocStringFromJSON produces the same encoding as
+// json.Marshal
+func Test_AllocStringFromJSON(t *testing.T) {
+	os := NewSized(1 << 8)
+	defer func() {
+		assert.NoError(t, os.Destroy())
+	}()
+
+	for _, v := range []any{
+		nil,
+		1,
+		"<escaped & html>",
+		jsonRecord{Name: "record", Values: []int{1, 2, 3}, Nested: map[string]bool{"a": true}},
+		[]jsonRecord{{Name: strings.Repeat("long", 100)}, {}},
+	} {
+		expected, err := json.Marshal(v)
+		require.NoError(t, err)
+
+		ref, err := AllocStringFromJSON(os, v)
+		require.NoError(t, err)
+		assert.Equal(t, string(expected), ref.Value())
+		FreeString(os, ref)
+	}
+
+	// Values which can't be encoded return an error and allocate nothing
+	_, err := AllocStringFromJSON(os, math.Inf(1))
+	assert.Error(t, err)
+	for _, stats := range os.Stats() {
+		assert.Equal(t, 0, stats.Live)
+	}
+}
+
+// Demonstrate that AppendJSON can be used to stream a sequence of JSON values
+// into an offheap slice
+func Test_AppendJSON(t *testing.T) {
+	os := NewSized(1 << 8)
+	defer func() {
+		assert.NoError(t, os.Destroy())
+	}()
+
+	ref := RefSlice[byte]{}
+	expected := strings.Builder{}
+	for i := range 100 {
+		record := jsonRecord{Name: "record", Values: []int{i}}
+		var err error
+		ref, err = AppendJSON(os, ref, record)
+		require.NoError(t, err)
+
+		encoded, err := json.Marshal(record)
+		require.NoError(t, err)
+		expected.Write(encoded)
+		expected.WriteString("\n")
+	}
+	assert.Equal(t, expected.String(), string(ref.Value()))
+
+	// A failed append leaves the slice unchanged
+	ref, err := AppendJSON(os, ref, math.NaN())
+	assert.Error(t, err)
+	assert.Equal(t, expected.String(), string(ref.Value()))
+
+	FreeSlice(os, ref)
+}
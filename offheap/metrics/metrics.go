@@ -0,0 +1,150 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+// The metrics package exposes the statistics of an offheap.Store as expvar
+// variables, so that a service using a Store can monitor it without writing
+// any glue code.
+//
+//	store := offheap.New()
+//	metrics.Publish("offheap", store)
+//
+// The published variable is evaluated each time it is read, for example when
+// /debug/vars is served, so its values are always current.
+package metrics
+
+import (
+	"cmp"
+	"expvar"
+	"slices"
+
+	"github.com/fmstephe/memorymanager/offheap"
+	"github.com/fmstephe/memorymanager/offheap/internal/pointerstore"
+)
+
+// The statistics for a single size class, or the totals for a Store
+type ClassStats struct {
+	Size           int     `json:"size,omitempty"`
+	Allocs         int     `json:"allocs"`
+	Frees          int     `json:"frees"`
+	Live           int     `json:"live"`
+	Reused         int     `json:"reused"`
+	ReuseRatio     float64 `json:"reuse_ratio"`
+	Slabs          int     `json:"slabs"`
+	MappedBytes    int     `json:"mapped_bytes"`
+	LiveBytes      int     `json:"live_bytes"`
+	FreeBytes      int     `json:"free_bytes"`
+	RequestedBytes int     `json:"requested_bytes"`
+	AllocatedBytes int     `json:"allocated_bytes"`
+	HugePageSlabs  int     `json:"huge_page_slabs"`
+	AdvisedBytes   int     `json:"advised_bytes"`
+	PaddedAllocs   int     `json:"padded_allocs"`
+	Quarantined    int     `json:"quarantined"`
+	Cached         int     `json:"cached"`
+	CacheHits      int     `json:"cache_hits"`
+	CacheMisses    int     `json:"cache_misses"`
+	CacheHitRatio  float64 `json:"cache_hit_ratio"`
+}
+
+// The statistics for the allocations with a single tag, see
+// offheap.AllocObjectTagged
+type TagStats struct {
+	Tag            int `json:"tag"`
+	Allocs         int `json:"allocs"`
+	Frees          int `json:"frees"`
+	Live           int `json:"live"`
+	LiveBytes      int `json:"live_bytes"`
+	RequestedBytes int `json:"requested_bytes"`
+}
+
+// The statistics for a Store, as published to expvar
+type StoreStats struct {
+	Total   ClassStats   `json:"total"`
+	Classes []ClassStats `json:"classes"`
+	Tags    []TagStats   `json:"tags,omitempty"`
+}
+
+// Publishes the statistics of store as an expvar variable with the given
+// name. Like expvar.Publish this panics if name is already in use.
+func Publish(name string, store *offheap.Store) {
+	expvar.Publish(name, Var(store))
+}
+
+// Returns an expvar.Var which reports the statistics of store. This can be
+// used to publish store's statistics inside an existing expvar.Map.
+func Var(store *offheap.Store) expvar.Var {
+	return expvar.Func(func() any {
+		return Snapshot(store)
+	})
+}
+
+// Returns the current statistics for store. Size classes which have never
+// been used are omitted. Tags are reported in increasing order.
+func Snapshot(store *offheap.Store) StoreStats {
+	sizes := store.SizeClasses()
+	allStats := store.Stats()
+
+	classes := []ClassStats{}
+	for i, stats := range allStats {
+		if stats.Allocs == 0 && stats.Slabs == 0 {
+			continue
+		}
+		class := classStats(stats)
+		class.Size = sizes[i]
+		classes = append(classes, class)
+	}
+
+	var tags []TagStats
+	for tag, stats := range store.TagStats() {
+		tags = append(tags, TagStats{
+			Tag:            tag,
+			Allocs:         stats.Allocs,
+			Frees:          stats.Frees,
+			Live:           stats.Live,
+			LiveBytes:      stats.LiveBytes,
+			RequestedBytes: stats.RequestedBytes,
+		})
+	}
+	slices.SortFunc(tags, func(a, b TagStats) int {
+		return cmp.Compare(a.Tag, b.Tag)
+	})
+
+	return StoreStats{
+		Total:   classStats(store.TotalStats()),
+		Classes: classes,
+		Tags:    tags,
+	}
+}
+
+func classStats(stats pointerstore.Stats) ClassStats {
+	reuseRatio := 0.0
+	if stats.Allocs > 0 {
+		reuseRatio = float64(stats.Reused) / float64(stats.Allocs)
+	}
+	cacheHitRatio := 0.0
+	if cacheAllocs := stats.CacheHits + stats.CacheMisses; cacheAllocs > 0 {
+		cacheHitRatio = float64(stats.CacheHits) / float64(cacheAllocs)
+	}
+
+	return ClassStats{
+		Allocs:         stats.Allocs,
+		Frees:          stats.Frees,
+		Live:           stats.Live,
+		Reused:         stats.Reused,
+		ReuseRatio:     reuseRatio,
+		Slabs:          stats.Slabs,
+		MappedBytes:    stats.MappedBytes,
+		LiveBytes:      stats.LiveBytes,
+		FreeBytes:      stats.FreeBytes,
+		RequestedBytes: stats.RequestedBytes,
+		AllocatedBytes: stats.AllocatedBytes,
+		HugePageSlabs:  stats.HugePageSlabs,
+		AdvisedBytes:   stats.AdvisedBytes,
+		PaddedAllocs:   stats.PaddedAllocs,
+		Quarantined:    stats.Quarantined,
+		Cached:         stats.Cached,
+		CacheHits:      stats.CacheHits,
+		CacheMisses:    stats.CacheMisses,
+		CacheHitRatio:  cacheHitRatio,
+	}
+}
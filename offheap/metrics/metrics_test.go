@@ -0,0 +1,89 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+package metrics
+
+import (
+	"encoding/json"
+	"expvar"
+	"testing"
+
+	"github.com/fmstephe/memorymanager/offheap"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Demonstrate that the published statistics are updated as the store is used
+func TestPublish(t *testing.T) {
+	store := offheap.NewSized(1 << 8)
+	defer func() {
+		assert.NoError(t, store.Destroy())
+	}()
+
+	Publish("metrics_test_store", store)
+	v := expvar.Get("metrics_test_store")
+	require.NotNil(t, v)
+
+	stats := readStats(t, v)
+	assert.Equal(t, ClassStats{}, stats.Total)
+	assert.Empty(t, stats.Classes)
+
+	refs := []offheap.RefObject[int64]{}
+	for range 4 {
+		refs = append(refs, offheap.AllocObject[int64](store))
+	}
+	offheap.FreeObject(store, refs[0])
+	offheap.AllocObject[int64](store)
+
+	stats = readStats(t, v)
+	require.Len(t, stats.Classes, 1)
+
+	class := stats.Classes[0]
+	assert.Equal(t, 8, class.Size)
+	assert.Equal(t, 5, class.Allocs)
+	assert.Equal(t, 1, class.Frees)
+	assert.Equal(t, 4, class.Live)
+	assert.Equal(t, 1, class.Reused)
+	assert.Equal(t, 0.2, class.ReuseRatio)
+	assert.Equal(t, 1, class.Slabs)
+	assert.Equal(t, 32, class.LiveBytes)
+	assert.Equal(t, 40, class.RequestedBytes)
+	assert.Equal(t, 40, class.AllocatedBytes)
+	assert.Greater(t, class.MappedBytes, 0)
+
+	class.Size = 0
+	assert.Equal(t, class, stats.Total)
+
+	// Publishing the same name twice panics, just like expvar
+	assert.Panics(t, func() { Publish("metrics_test_store", store) })
+}
+
+func readStats(t *testing.T, v expvar.Var) StoreStats {
+	stats := StoreStats{}
+	require.NoError(t, json.Unmarshal([]byte(v.String()), &stats))
+	return stats
+}
+
+// Demonstrate that the statistics for each tag, and the cache hit ratio, are
+// reported
+func TestSnapshot_TagsAndCaches(t *testing.T) {
+	store := offheap.NewWithCaches(1<<8, 4)
+	defer func() {
+		assert.NoError(t, store.Destroy())
+	}()
+
+	r := offheap.AllocObjectTagged[int64](store, 7)
+	offheap.FreeObject(store, r)
+	offheap.AllocObjectTagged[int64](store, 3)
+
+	stats := Snapshot(store)
+	assert.Equal(t, []TagStats{
+		{Tag: 3, Allocs: 1, Live: 1, LiveBytes: 8, RequestedBytes: 8},
+		{Tag: 7, Allocs: 1, Frees: 1, RequestedBytes: 8},
+	}, stats.Tags)
+
+	assert.Equal(t, 1, stats.Total.CacheHits)
+	assert.Equal(t, 1, stats.Total.CacheMisses)
+	assert.Equal(t, 0.5, stats.Total.CacheHitRatio)
+}
@@ -0,0 +1,184 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+package offheap
+
+import (
+	"github.com/fmstephe/memorymanager/offheap/internal/pointerstore"
+)
+
+// A Migrator deep copies a graph of allocations from one Store into another.
+//
+// The Store has no knowledge of the references contained inside an
+// allocation, so the caller guides the migration. Each allocation is copied
+// with MigrateObject, MigrateSlice or MigrateString. The copied value is then
+// passed to a fixup function, supplied by the caller, which migrates each of
+// the references the value contains. For example a linked list could be
+// migrated with
+//
+//	type Node struct {
+//		value int
+//		next  offheap.RefObject[Node]
+//	}
+//
+//	var fixup func(m *offheap.Migrator, n *Node)
+//	fixup = func(m *offheap.Migrator, n *Node) {
+//		n.next = offheap.MigrateObject(m, n.next, fixup)
+//	}
+//
+//	m := offheap.NewMigrator(from, to)
+//	newHead := offheap.MigrateObject(m, head, fixup)
+//
+// Every allocation is copied exactly once, no matter how many times it is
+// referenced. This means that shared and cyclic structures are preserved in
+// the destination Store.
+//
+// Once migration is complete the original allocations can be freed with
+// FreeOriginals. A Migrator is not safe for concurrent use.
+type Migrator struct {
+	from *Store
+	to   *Store
+
+	// Maps each original allocation to its copy
+	migrated map[pointerstore.RefPointer]pointerstore.RefPointer
+
+	// The original allocations, with their size class index, in the order
+	// they were migrated
+	originals []migratedOriginal
+}
+
+type migratedOriginal struct {
+	idx int
+	ref pointerstore.RefPointer
+}
+
+// Returns a new Migrator which copies allocations from the Store from into
+// the Store to.
+func NewMigrator(from, to *Store) *Migrator {
+	return &Migrator{
+		from:     from,
+		to:       to,
+		migrated: map[pointerstore.RefPointer]pointerstore.RefPointer{},
+	}
+}
+
+// Returns the number of allocations migrated so far
+func (m *Migrator) Len() int {
+	return len(m.migrated)
+}
+
+// Frees every original allocation which has been migrated. After this method
+// is called none of the original references can be used, only the migrated
+// references remain valid.
+//
+// Views created by SubSlice or SubString aren't freed, because they don't own
+// their allocation.
+func (m *Migrator) FreeOriginals() {
+	for _, original := range m.originals {
+		m.from.free(original.idx, original.ref)
+	}
+	m.originals = nil
+}
+
+// Copies the object referenced by r into the destination Store, and returns a
+// reference to the copy. If fixup is not nil it is called with the copied
+// value, allowing the caller to migrate any references it contains.
+//
+// If r has already been migrated, the existing copy is returned and fixup is
+// not called. If r is nil a nil reference is returned.
+func MigrateObject[T any](m *Migrator, r RefObject[T], fixup func(m *Migrator, value *T)) RefObject[T] {
+	if r.IsNil() {
+		return RefObject[T]{}
+	}
+
+	if migrated, ok := m.migrated[r.ref]; ok {
+		return RefObject[T]{ref: migrated}
+	}
+
+	newRef := CloneObject(m.to, r)
+	// Record the migration before calling fixup, so that cycles back to
+	// this object find the copy
+	m.record(typeIndex[T](m.from), r.ref, newRef.ref)
+
+	if fixup != nil {
+		fixup(m, newRef.Value())
+	}
+	return newRef
+}
+
+// Copies the slice referenced by r into the destination Store, and returns a
+// reference to the copy. If fixup is not nil it is called with each element of
+// the copied slice, allowing the caller to migrate any references they
+// contain.
+//
+// If r has already been migrated, the existing copy is returned and fixup is
+// not called. If r is nil a nil reference is returned.
+//
+// Views created by SubSlice are copied into independent slices each time they
+// are migrated.
+func MigrateSlice[T any](m *Migrator, r RefSlice[T], fixup func(m *Migrator, value *T)) RefSlice[T] {
+	if r.IsNil() {
+		return RefSlice[T]{}
+	}
+
+	if !r.view {
+		if migrated, ok := m.migrated[r.ref]; ok {
+			return newRefSlice[T](r.length, r.capacity, migrated)
+		}
+	}
+
+	newRef := CloneSlice(m.to, r)
+	if !r.view {
+		m.record(sliceIndex[T](m.from, r.capacity), r.ref, newRef.ref)
+	}
+
+	if fixup != nil {
+		newSlice := newRef.Value()
+		for i := range newSlice {
+			fixup(m, &newSlice[i])
+		}
+	}
+	return newRef
+}
+
+// Copies the string referenced by r into the destination Store, and returns a
+// reference to the copy.
+//
+// If r has already been migrated, the existing copy is returned. If r is nil
+// a nil reference is returned.
+//
+// Views created by SubString are copied into independent strings each time
+// they are migrated.
+func MigrateString(m *Migrator, r RefString) RefString {
+	if r.IsNil() {
+		return RefString{}
+	}
+
+	if !r.view {
+		if migrated, ok := m.migrated[r.ref]; ok {
+			return newRefString(r.length, migrated)
+		}
+	}
+
+	newRef := CloneString(m.to, r)
+	if !r.view {
+		m.record(m.from.sizeIndex(r.length), r.ref, newRef.ref)
+	}
+	return newRef
+}
+
+// Returns the copy of r, and true, if r has been migrated. Otherwise a nil
+// reference and false are returned.
+func MigratedObject[T any](m *Migrator, r RefObject[T]) (RefObject[T], bool) {
+	migrated, ok := m.migrated[r.ref]
+	if !ok {
+		return RefObject[T]{}, false
+	}
+	return RefObject[T]{ref: migrated}, true
+}
+
+func (m *Migrator) record(idx int, original, migrated pointerstore.RefPointer) {
+	m.migrated[original] = migrated
+	m.originals = append(m.originals, migratedOriginal{idx: idx, ref: original})
+}
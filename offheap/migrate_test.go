@@ -0,0 +1,161 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+package offheap
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type migrateNode struct {
+	id       int
+	name     RefString
+	next     RefObject[migrateNode]
+	children RefSlice[RefObject[migrateNode]]
+}
+
+func migrateNodeFixup(m *Migrator, n *migrateNode) {
+	n.name = MigrateString(m, n.name)
+	n.next = MigrateObject(m, n.next, migrateNodeFixup)
+	n.children = MigrateSlice(m, n.children, func(m *Migrator, child *RefObject[migrateNode]) {
+		*child = MigrateObject(m, *child, migrateNodeFixup)
+	})
+}
+
+// Demonstrate that a cyclic linked list can be migrated between stores, and
+// that the original allocations can be freed afterwards
+func TestMigrate_CyclicList(t *testing.T) {
+	from := NewSized(1 << 8)
+	to := NewSized(1 << 8)
+	defer func() {
+		assert.NoError(t, from.Destroy())
+		assert.NoError(t, to.Destroy())
+	}()
+
+	const count = 100
+	head := AllocObjectFrom(from, migrateNode{id: 0, name: AllocStringFromString(from, "node-0")})
+	prev := head
+	for i := 1; i < count; i++ {
+		node := AllocObjectFrom(from, migrateNode{id: i, name: AllocStringFromString(from, fmt.Sprintf("node-%d", i))})
+		prev.Value().next = node
+		prev = node
+	}
+	// Close the cycle
+	prev.Value().next = head
+
+	m := NewMigrator(from, to)
+	newHead := MigrateObject(m, head, migrateNodeFixup)
+	// Each node and each name has been migrated exactly once
+	assert.Equal(t, count*2, m.Len())
+
+	migratedHead, ok := MigratedObject(m, head)
+	assert.True(t, ok)
+	assert.Equal(t, newHead, migratedHead)
+
+	m.FreeOriginals()
+	for _, stats := range from.Stats() {
+		assert.Equal(t, 0, stats.Live)
+	}
+
+	node := newHead
+	for i := range count {
+		value := node.Value()
+		require.Equal(t, i, value.id)
+		require.Equal(t, fmt.Sprintf("node-%d", i), value.name.Value())
+		node = value.next
+	}
+	// The cycle is preserved
+	assert.Equal(t, newHead, node)
+}
+
+// Demonstrate that allocations shared by several references are only
+// migrated once, and remain shared after migration
+func TestMigrate_SharedReferences(t *testing.T) {
+	from := NewSized(1 << 8)
+	to := NewSized(1 << 8)
+	defer func() {
+		assert.NoError(t, from.Destroy())
+		assert.NoError(t, to.Destroy())
+	}()
+
+	sharedName := AllocStringFromString(from, "shared")
+	leaf := AllocObjectFrom(from, migrateNode{id: 1, name: sharedName})
+
+	children := AllocSlice[RefObject[migrateNode]](from, 3, 4)
+	for i := range children.Value() {
+		children.Value()[i] = leaf
+	}
+	root := AllocObjectFrom(from, migrateNode{id: 0, name: sharedName, children: children})
+
+	m := NewMigrator(from, to)
+	newRoot := MigrateObject(m, root, migrateNodeFixup)
+	// root, leaf, the name and the children slice
+	assert.Equal(t, 4, m.Len())
+
+	newChildren := newRoot.Value().children
+	assert.Equal(t, 3, len(newChildren.Value()))
+	assert.Equal(t, 4, cap(newChildren.Value()))
+
+	newLeaf := newChildren.Value()[0]
+	for _, child := range newChildren.Value() {
+		assert.Equal(t, newLeaf, child)
+	}
+	assert.Equal(t, newRoot.Value().name, newLeaf.Value().name)
+	assert.Equal(t, "shared", newLeaf.Value().name.Value())
+
+	// Nothing in the migrated graph refers to the original store
+	assert.NotEqual(t, leaf, newLeaf)
+	assert.NotEqual(t, sharedName, newLeaf.Value().name)
+
+	m.FreeOriginals()
+	for _, stats := range from.Stats() {
+		assert.Equal(t, 0, stats.Live)
+	}
+}
+
+// Demonstrate that nil references and views are migrated, views being copied
+// into independent allocations
+func TestMigrate_NilAndViews(t *testing.T) {
+	from := NewSized(1 << 8)
+	to := NewSized(1 << 8)
+	defer func() {
+		assert.NoError(t, from.Destroy())
+		assert.NoError(t, to.Destroy())
+	}()
+
+	m := NewMigrator(from, to)
+
+	nilObject := MigrateObject[migrateNode](m, RefObject[migrateNode]{}, nil)
+	assert.True(t, nilObject.IsNil())
+	nilSlice := MigrateSlice[int](m, RefSlice[int]{}, nil)
+	assert.True(t, nilSlice.IsNil())
+	nilString := MigrateString(m, RefString{})
+	assert.True(t, nilString.IsNil())
+	assert.Equal(t, 0, m.Len())
+
+	str := AllocStringFromString(from, "hello world")
+	subStr := SubString(from, str, 6, 11)
+	newSubStr := MigrateString(m, subStr)
+	assert.Equal(t, "world", newSubStr.Value())
+
+	slice := ConcatSlices(from, []int{1, 2, 3, 4, 5})
+	subSlice := SubSlice(from, slice, 1, 3)
+	newSubSlice := MigrateSlice(m, subSlice, nil)
+	assert.Equal(t, []int{2, 3}, newSubSlice.Value())
+
+	// Views are not recorded, so FreeOriginals doesn't free them
+	assert.Equal(t, 0, m.Len())
+	m.FreeOriginals()
+
+	// The migrated views can be freed independently
+	FreeString(to, newSubStr)
+	FreeSlice(to, newSubSlice)
+
+	FreeString(from, str)
+	FreeSlice(from, slice)
+}
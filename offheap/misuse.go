@@ -0,0 +1,79 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+package offheap
+
+import (
+	"errors"
+
+	"github.com/fmstephe/memorymanager/offheap/internal/pointerstore"
+)
+
+// The error returned, or raised as a panic, when a reference is misused,
+// such as accessing or freeing an allocation which has already been freed.
+// It wraps one of ErrFreedReference, ErrStaleReference, ErrPinned or
+// ErrForeignReference, and records the slot and generations involved.
+type MisuseError = pointerstore.MisuseError
+
+// Describes a misuse detected by a Store, see Store.OnMisuse.
+type MisuseReport struct {
+	// The operation which was attempted, e.g. "free"
+	Operation string
+	// The size class of the allocation
+	SizeClass int
+	// The pool of the Store which owns the allocation, see NewWithPools
+	Pool int
+	// The slot of the allocation within its size class and pool
+	Slot int
+	// The current generation of the allocation
+	ExpectedGen uint8
+	// The generation recorded in the misused reference
+	ActualGen uint8
+	// The error which will be returned, or raised as a panic
+	Err error
+}
+
+// Registers hook to be called with a report of each misuse detected by s,
+// before the misuse panics, or is returned as an error by a Try function.
+// This allows services to log rich diagnostics, or count misuses, before
+// crashing or recovering. Calling OnMisuse with nil removes the hook.
+//
+// The hook is called for misuses detected by functions which are given the
+// Store, such as FreeObject and TryFreeSlice. References don't record which
+// Store they were allocated from, so misuses detected by methods like
+// RefObject.Value can't be reported to the hook. These panic with a
+// *MisuseError carrying the same information.
+//
+// The hook may be called concurrently, by different goroutines, and must not
+// use s.
+func (s *Store) OnMisuse(hook func(MisuseReport)) {
+	if hook == nil {
+		s.misuseHook.Store(nil)
+		return
+	}
+	s.misuseHook.Store(&hook)
+}
+
+// Calls the misuse hook, if there is one, with a report of the error err
+// detected while attempting op on an allocation in the size class idx
+func (s *Store) reportMisuse(op string, idx int, err error) {
+	hook := s.misuseHook.Load()
+	if hook == nil {
+		return
+	}
+
+	report := MisuseReport{
+		Operation: op,
+		SizeClass: s.classSize(idx),
+		Err:       err,
+	}
+	var misuse *MisuseError
+	if errors.As(err, &misuse) {
+		report.Pool = misuse.Pool
+		report.Slot = misuse.Slot
+		report.ExpectedGen = misuse.AllocationGen
+		report.ActualGen = misuse.ReferenceGen
+	}
+	(*hook)(report)
+}
@@ -0,0 +1,85 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+package offheap
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Demonstrate that the misuse hook receives a report of each misuse detected
+// by the Store, before the misuse panics or is returned
+func Test_OnMisuse(t *testing.T) {
+	s := NewWithPools(1<<12, 2)
+	defer func() {
+		assert.NoError(t, s.Destroy())
+	}()
+
+	reports := []MisuseReport{}
+	s.OnMisuse(func(report MisuseReport) {
+		reports = append(reports, report)
+	})
+
+	obj := AllocObject[MutableStruct](s)
+	FreeObject(s, obj)
+	assert.Empty(t, reports)
+
+	// Double free
+	assert.Panics(t, func() { FreeObject(s, obj) })
+	require.Len(t, reports, 1)
+	report := reports[0]
+	assert.Equal(t, "free", report.Operation)
+	assert.Equal(t, obj.AllocatedBytes(s), report.SizeClass)
+	assert.Equal(t, obj.ref.Pool(), report.Pool)
+	assert.ErrorIs(t, report.Err, ErrFreedReference)
+
+	// Free using a stale reference. Allocations are spread across the
+	// Store's pools, so allocate until obj's slot, in obj's pool, is
+	// reused.
+	others := []RefObject[MutableStruct]{}
+	reused := AllocObject[MutableStruct](s)
+	for reused.ref.Pool() != obj.ref.Pool() {
+		others = append(others, reused)
+		reused = AllocObject[MutableStruct](s)
+	}
+	require.Equal(t, obj.ref.Slot(), reused.ref.Slot())
+	assert.ErrorIs(t, TryFreeObject(s, obj), ErrStaleReference)
+	require.Len(t, reports, 2)
+	report = reports[1]
+	assert.Equal(t, reused.ref.Gen(), report.ExpectedGen)
+	assert.Equal(t, obj.ref.Gen(), report.ActualGen)
+
+	// Freeing a nil reference
+	assert.ErrorIs(t, TryFreeSlice(s, RefSlice[byte]{}), ErrNilReference)
+	require.Len(t, reports, 3)
+	assert.ErrorIs(t, reports[2].Err, ErrNilReference)
+
+	// Misuse detected by references is not reported, but panics with a
+	// *MisuseError
+	func() {
+		defer func() {
+			misuse := &MisuseError{}
+			require.True(t, errors.As(recover().(error), &misuse))
+			assert.Equal(t, "access", misuse.Operation)
+			assert.Equal(t, reused.ref.Gen(), misuse.AllocationGen)
+			assert.Equal(t, obj.ref.Gen(), misuse.ReferenceGen)
+		}()
+		obj.Value()
+	}()
+	assert.Len(t, reports, 3)
+
+	// The hook can be removed
+	s.OnMisuse(nil)
+	assert.Panics(t, func() { FreeObject(s, obj) })
+	assert.Len(t, reports, 3)
+
+	FreeObject(s, reused)
+	for _, other := range others {
+		FreeObject(s, other)
+	}
+}
@@ -27,6 +27,21 @@ func ExampleAllocObject() {
 	// Output: This is correct, i1 and i2 are pointers to the same int location
 }
 
+// Calling AllocObjectFrom allocates an object which is initialised with a
+// copy of the value passed in
+func ExampleAllocObjectFrom() {
+	type Point struct {
+		X, Y int
+	}
+
+	var store *offheap.Store = offheap.New()
+
+	var ref offheap.RefObject[Point] = offheap.AllocObjectFrom(store, Point{X: 1, Y: 2})
+
+	fmt.Printf("Allocated %+v", *ref.Value())
+	// Output: Allocated {X:1 Y:2}
+}
+
 // You can free memory used by an an allocated object by calling
 // FreeObject(...). The RefObject can no longer be used, and the use of the
 // actual object pointed to will have unpredicatable results.
@@ -166,3 +181,22 @@ func ExampleAllocObject_badTypePointer() {
 	offheap.AllocObject[BadStruct](store)
 	// Output: Can't allocate pointers
 }
+
+// A Weak reference can be kept after its object is freed, Get reports whether
+// the object is still allocated
+func ExampleMakeWeak() {
+	var store *offheap.Store = offheap.New()
+
+	var ref offheap.RefObject[int] = offheap.AllocObject[int](store)
+	var weak offheap.Weak[int] = offheap.MakeWeak(ref)
+
+	_, ok := weak.Get(store)
+	fmt.Printf("Before free %v\n", ok)
+
+	offheap.FreeObject(store, ref)
+
+	_, ok = weak.Get(store)
+	fmt.Printf("After free %v\n", ok)
+	// Output: Before free true
+	// After free false
+}
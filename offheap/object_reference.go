@@ -19,26 +19,52 @@ import (
 // Go allocations objects acquired via AllocObject do _not_ have their contents
 // zeroed out.
 func AllocObject[T any](s *Store) RefObject[T] {
-	// TODO this is not fast - we _need_ to cache this type data
-	if err := containsNoPointers[T](); err != nil {
+	info := typeInfoFor[T]()
+	if err := info.pointerErr; err != nil {
 		panic(fmt.Errorf("cannot allocate generic type containing pointers %w", err))
 	}
 
-	idx := indexForType[T]()
+	idx := info.typeIndex(s)
 
-	pRef := s.alloc(idx)
+	pRef := s.alloc(idx, info.rawSize)
 	oRef := newRefObject[T](pRef)
 	return oRef
 }
 
+// Allocates an object of type T, whose contents are a copy of value. The type
+// T must not contain any pointers in any part of its type. If the type T is
+// found to contain pointers this function will panic.
+func AllocObjectFrom[T any](s *Store, value T) RefObject[T] {
+	r := AllocObject[T](s)
+	*r.Value() = value
+	return r
+}
+
+// Allocates a new object in s, whose contents are a copy of the object
+// referenced by r. The store s can be the Store that r was allocated in, or
+// any other Store. If r is nil then a nil RefObject is returned.
+func CloneObject[T any](s *Store, r RefObject[T]) RefObject[T] {
+	if r.IsNil() {
+		return RefObject[T]{}
+	}
+	return AllocObjectFrom(s, *r.Value())
+}
+
 // Frees the allocation referenced by r. After this call returns r must never
 // be used again. Any use of the object referenced by r will have
 // unpredicatable behaviour.
 func FreeObject[T any](s *Store, r RefObject[T]) {
-	idx := indexForType[T]()
+	idx := typeIndex[T](s)
 	s.free(idx, r.ref)
 }
 
+// Frees the allocation referenced by r, like FreeObject. Instead of
+// panicking an error is returned if r is nil, if the object has already been
+// freed, if the object is pinned or if s is sealed.
+func TryFreeObject[T any](s *Store, r RefObject[T]) error {
+	return s.tryFree(typeIndex[T](s), r.ref)
+}
+
 // A reference to a typed object. This reference allows us to gain access to an
 // allocated object directly.
 //
@@ -67,11 +93,33 @@ func (r *RefObject[T]) Value() *T {
 	return (*T)((unsafe.Pointer)(r.ref.DataPtr()))
 }
 
+// Returns a pointer to the object referenced by r, like Value. Instead of
+// panicking an error is returned if r is nil, or if the object has been
+// freed, see ErrFreedReference and ErrStaleReference.
+func (r *RefObject[T]) TryValue() (*T, error) {
+	if r.IsNil() {
+		return nil, ErrNilReference
+	}
+	ptr, err := r.ref.TryDataPtr()
+	if err != nil {
+		return nil, err
+	}
+	return (*T)((unsafe.Pointer)(ptr)), nil
+}
+
 // Returns true if this RefObject does not point to an allocated object, false otherwise.
 func (r *RefObject[T]) IsNil() bool {
 	return r.ref.IsNil()
 }
 
+// Returns the number of bytes allocated in s for the object referenced by r.
+// This is the size of T's size class, which may be larger than the size of
+// T. The object itself is not accessed, so this can be called on a nil
+// RefObject.
+func (r *RefObject[T]) AllocatedBytes(s *Store) int {
+	return s.classSize(s.storeIndex(typeIndex[T](s)))
+}
+
 // Returns the stats for the allocation size of type T.
 //
 // It is important to note that these statistics apply to the size class
@@ -79,7 +127,7 @@ func (r *RefObject[T]) IsNil() bool {
 // this _size_ including allocations for types other than T.
 func StatsForType[T any](s *Store) pointerstore.Stats {
 	stats := s.Stats()
-	idx := indexForType[T]()
+	idx := s.storeIndex(typeIndex[T](s))
 	return stats[idx]
 }
 
@@ -90,6 +138,6 @@ func StatsForType[T any](s *Store) pointerstore.Stats {
 // allocations for types other than T.
 func ConfForType[T any](s *Store) pointerstore.AllocConfig {
 	configs := s.AllocConfigs()
-	idx := indexForType[T]()
+	idx := s.storeIndex(typeIndex[T](s))
 	return configs[idx]
 }
@@ -327,6 +327,10 @@ func testSizedStats[T any](t *testing.T, os *Store) {
 		// Larger objects will require a slab per allocation
 		expectedStats.Slabs = 2
 	}
+	expectedStats.MappedBytes = expectedStats.Slabs * int(conf.TotalSlabSize)
+	expectedStats.FreeBytes = expectedStats.Slabs * int(conf.TotalObjectSize)
+	expectedStats.RequestedBytes = 3 * rawSizeForType[T]()
+	expectedStats.AllocatedBytes = 3 * int(conf.ObjectSize)
 
 	actualStats := StatsForType[T](os)
 
@@ -316,6 +316,26 @@ func Test_Object_CheckGenericTypeForPointersInAlloc(t *testing.T) {
 	assert.NotPanics(t, func() { AllocObject[int](os) })
 }
 
+// Demonstrate that AllocObjectFrom allocates an object containing a copy of
+// the value passed in
+func Test_Object_AllocObjectFrom(t *testing.T) {
+	os := NewSized(1 << 8)
+	defer func() {
+		assert.NoError(t, os.Destroy())
+	}()
+
+	value := fortyBytes{1, 2, 3, 4, 5}
+	r := AllocObjectFrom(os, value)
+	assert.Equal(t, value, *r.Value())
+
+	// The allocated object is a copy, independent of value
+	value.a = 100
+	assert.Equal(t, int64(1), r.Value().a)
+
+	// If generic type contains pointers, AllocObjectFrom will panic
+	assert.Panics(t, func() { AllocObjectFrom[*int](os, nil) })
+}
+
 func Test_Object_CannotAllocateVeryBigStruct(t *testing.T) {
 	// In principle this code should panic - but Go won't even compile a
 	// type this large.  At the time when this test was written the Store's
@@ -328,27 +348,23 @@ func Test_Object_CannotAllocateVeryBigStruct(t *testing.T) {
 }
 
 // This is a very odd looking test. It is a response to an intermittent failure
-// case with zero sized types.  The problem occurs because when we get the data
-// portion of the Reference we add the size of the meta-data to the pointer and
-// treat that as pointing to the data-portion of the allocation. In the case of
-// zero sized types this data-portion does not exist. In most cases the
-// data-portion will now point to the beginning of the next allocation's
-// meta-data, but for the last allocation in a slab it will point just past the
-// allocated memory region. If we get lucky the address just after the
-// allocated region is another allocated region, and everything works. If we
-// are unlucky the address just after the allocated region is invalid memory
-// and we segfault.
+// case with zero sized types.
 //
-// Because we cannot rely on being lucky, we now allocate a single byte to
-// ensure we can safely point to valid memory even though the zero sized type
-// won't use it to read/write.
+// Each slab is laid out as a region of objects, one slot after another,
+// followed by an array holding the meta-data of each slot. A pointer to the
+// data of an allocation points to its slot in the objects region. If zero
+// sized types were given zero sized slots, the data pointer of the last
+// allocation in a slab would point just past the objects region. Depending on
+// the slab's configuration that address may be the start of the meta-data
+// array, a guard region, or memory which isn't mapped at all, and
+// dereferencing it could segfault.
 //
-// This test should alert us if this problem ever reappears.
+// Because we cannot rely on being lucky, zero sized types are given a single
+// byte slot. So every data pointer points to valid memory inside the objects
+// region, even though the zero sized type won't use it to read/write.
 //
-// NB: In the future meta-data will likely be moved to a separate allocation
-// space and some details described above will become out of date. The test
-// will still be useful though. Zero sized types are a likely source of
-// edge-case bugs for all eternity.
+// This test should alert us if this problem ever reappears. Zero sized types
+// are a likely source of edge-case bugs for all eternity.
 func Test_Object_ZeroSizedType_FullSlab(t *testing.T) {
 	os := New()
 	defer func() {
@@ -366,3 +382,55 @@ func Test_Object_ZeroSizedType_FullSlab(t *testing.T) {
 
 	assert.Equal(t, 0, lenTotal)
 }
+
+// Demonstrate that CloneObject creates an independent copy of an object, in
+// the same Store or a different Store
+func Test_Object_Clone(t *testing.T) {
+	os := NewSized(1 << 8)
+	otherOs := NewSized(1 << 8)
+	defer func() {
+		assert.NoError(t, os.Destroy())
+		assert.NoError(t, otherOs.Destroy())
+	}()
+
+	r := AllocObjectFrom(os, fortyBytes{1, 2, 3, 4, 5})
+
+	for _, store := range []*Store{os, otherOs} {
+		clone := CloneObject(store, r)
+		assert.Equal(t, *r.Value(), *clone.Value())
+		assert.NotSame(t, r.Value(), clone.Value())
+
+		// Modifying the clone doesn't affect the original
+		clone.Value().a = 100
+		assert.Equal(t, int64(1), r.Value().a)
+
+		FreeObject(store, clone)
+	}
+
+	// The original is unaffected by freeing its clones
+	assert.Equal(t, fortyBytes{1, 2, 3, 4, 5}, *r.Value())
+
+	// Cloning a nil reference gives a nil reference
+	nilClone := CloneObject(os, RefObject[fortyBytes]{})
+	assert.True(t, nilClone.IsNil())
+}
+
+// Demonstrate that AllocatedBytes reports the size class of an object,
+// including any unused space beyond the size of the object's type
+func Test_Object_AllocatedBytes(t *testing.T) {
+	os := NewSized(1 << 8)
+	classOs := NewWithSizeClasses(1<<8, []int{16, 48, 128})
+	defer func() {
+		assert.NoError(t, os.Destroy())
+		assert.NoError(t, classOs.Destroy())
+	}()
+
+	r := AllocObject[fortyBytes](os)
+	assert.Equal(t, 64, r.AllocatedBytes(os))
+	assert.Equal(t, 48, r.AllocatedBytes(classOs))
+
+	// A nil reference reports the size class of its type
+	nilRef := RefObject[int64]{}
+	assert.Equal(t, 8, nilRef.AllocatedBytes(os))
+	assert.Equal(t, 16, nilRef.AllocatedBytes(classOs))
+}
@@ -5,21 +5,82 @@
 package offheap
 
 import (
+	"fmt"
+	"math"
+	"os"
+	"runtime"
+	"slices"
+	"sync"
+	"sync/atomic"
+
 	"github.com/fmstephe/memorymanager/offheap/internal/pointerstore"
 )
 
 const defaultSlabSize = 1 << 13
 
 type Store struct {
-	sizedStores []*pointerstore.Store
+	sizedStores []*sizeClass
+	// The allocation size of each of the sizedStores, in increasing order.
+	// This is nil for the default power of two size classes.
+	sizeClasses []int
+
+	// The size class stores for each pool, for Stores created by
+	// NewWithPools. The first pool is sizedStores. This is nil for Stores
+	// without pools.
+	pools [][]*sizeClass
+	// Holds a *poolToken for each P, identifying the pool, and cache, it
+	// allocates from
+	poolTokens sync.Pool
+	// Used to assign pools to new poolTokens round-robin
+	nextPool atomic.Uint64
+
+	// The number of caches in each size class, and the number of free
+	// slots each cache holds, for Stores created by NewWithCaches. Both are
+	// 0 for Stores without caches.
+	caches    int
+	cacheSize int
+	// Used to assign caches to new poolTokens round-robin
+	nextCache atomic.Uint64
+
+	// Called with a report of each misuse detected by this Store, see
+	// OnMisuse
+	misuseHook atomic.Pointer[func(MisuseReport)]
+
+	// Records every allocation and free, see StartRecording
+	recording atomic.Pointer[Recording]
+
+	// Every running Watermark, stopped by Destroy, see OnWatermark
+	watermarkLock sync.Mutex
+	watermarks    []*Watermark
+
+	// Allocations in a size class smaller than minIndex are padded into
+	// the size class at minIndex. This is 0 unless the Store was created by
+	// NewWithCacheLinePadding.
+	minIndex int
+
+	// Serialises the creation of each size class's store with the
+	// settings below, so a newly created store is never missing a setting,
+	// see classStore
+	classLock  sync.Mutex
+	quarantine QuarantinePolicy
+	sealed     atomic.Bool
+}
+
+// Identifies the pool, and cache, allocated from by the goroutines running on
+// a P
+type poolToken struct {
+	pool  int
+	cache int
 }
 
 // Returns a new *Store.
 //
 // This store manages allocation and freeing of any offheap allocated objects.
+// Each size class is only set up when it is first allocated from, so a Store
+// which only allocates a few different sizes stays small.
 func New() *Store {
 	return &Store{
-		sizedStores: initSizeStore(defaultSlabSize),
+		sizedStores: initSizeStore(defaultSlabSize, false),
 	}
 }
 
@@ -36,26 +97,455 @@ func New() *Store {
 // will probably prefer to use the default New() above.
 func NewSized(slabSize int) *Store {
 	return &Store{
-		sizedStores: initSizeStore(slabSize),
+		sizedStores: initSizeStore(slabSize, false),
+	}
+}
+
+// Returns a new *Store whose slabs are backed by huge pages, where possible.
+//
+// For very large Stores the translation of virtual addresses, using the TLB,
+// can become a measurable cost. Backing slabs with huge pages reduces the
+// number of TLB entries needed to cover the Store's memory.
+//
+// Huge pages can only back memory in chunks of pointerstore.HugePageSize, 2MB
+// on most systems, so slabSize is increased to at least this size. On Linux
+// each slab is mapped from the huge page pool with MAP_HUGETLB, if a pool is
+// configured and the slab is an exact multiple of the huge page size,
+// otherwise transparent huge pages are requested with madvise. On other
+// systems, or if huge pages are unavailable, the Store falls back to normal
+// pages. The HugePageSlabs statistic reports the number of slabs for which
+// huge pages were successfully requested.
+func NewWithHugePages(slabSize int) *Store {
+	return &Store{
+		sizedStores: initSizeStore(max(slabSize, pointerstore.HugePageSize), true),
+	}
+}
+
+// Controls how the slab size of each size class is chosen, see
+// NewWithSlabTuning.
+type SlabTuning struct {
+	// The number of objects each slab should hold
+	ObjectsPerSlab int
+	// No slab is smaller than MinSlabSize, so that slabs of tiny objects
+	// aren't smaller than a page. If 0 the page size is used.
+	MinSlabSize int
+	// No slab is larger than MaxSlabSize, unless it holds a single object
+	// which is larger than MaxSlabSize.
+	MaxSlabSize int
+}
+
+// The SlabTuning used by NewWithSlabTuning when every field is 0.
+var DefaultSlabTuning = SlabTuning{
+	ObjectsPerSlab: 64,
+	MaxSlabSize:    1 << 22,
+}
+
+// Returns a new *Store where the slab size is chosen separately for each size
+// class, so that each slab holds tuning.ObjectsPerSlab objects.
+//
+// A Store created by New, or NewSized, uses the same slab size for every size
+// class. So a slab of tiny objects holds thousands of objects, while every
+// allocation of 8KB or more is given its own slab, and allocating or freeing
+// these large objects frequently maps and unmaps slabs. Choosing the slab size
+// for each size class gives every size class a similar number of objects per
+// slab, bounded by tuning.MinSlabSize and tuning.MaxSlabSize.
+//
+// The chosen slab sizes are reported by AllocConfigs. If tuning is the zero
+// value DefaultSlabTuning is used.
+func NewWithSlabTuning(tuning SlabTuning) *Store {
+	if tuning == (SlabTuning{}) {
+		tuning = DefaultSlabTuning
+	}
+	if tuning.ObjectsPerSlab < 1 {
+		panic(fmt.Errorf("ObjectsPerSlab (%d) must be at least 1", tuning.ObjectsPerSlab))
+	}
+	if tuning.MinSlabSize == 0 {
+		tuning.MinSlabSize = os.Getpagesize()
+	}
+	if tuning.MaxSlabSize < tuning.MinSlabSize {
+		panic(fmt.Errorf("MaxSlabSize (%d) must be at least MinSlabSize (%d)", tuning.MaxSlabSize, tuning.MinSlabSize))
+	}
+
+	confs := make([]pointerstore.AllocConfig, maxAllocationBits())
+	for i := range confs {
+		confs[i] = pointerstore.NewAllocConfigForObjectsPerSlab(1<<i, uint64(tuning.ObjectsPerSlab), uint64(tuning.MinSlabSize), uint64(tuning.MaxSlabSize))
+	}
+
+	return &Store{
+		sizedStores: newSizeClasses(confs, 0),
+	}
+}
+
+// Returns a new *Store, using custom size classes.
+//
+// By default every allocation is rounded up to a power of two in size. This
+// can waste a lot of memory, up to 50%, if the most common allocation sizes
+// are just above a power of two. The size of each allocation will instead be
+// rounded up to the nearest size in sizeClasses. Allocations which are larger
+// than every size in sizeClasses are rounded up to a power of two.
+//
+// The sizeClasses must be in strictly increasing order. To ensure that all
+// allocations are correctly aligned each size class must be either a power of
+// two or a multiple of 8. GeometricSizeClasses will generate a suitable set
+// of size classes.
+//
+// The statistics and allocation configs for a Store with custom size classes
+// are reported for each custom size class, see SizeClasses.
+func NewWithSizeClasses(slabSize int, sizeClasses []int) *Store {
+	classes, err := completeSizeClasses(sizeClasses)
+	if err != nil {
+		panic(err)
+	}
+
+	confs := make([]pointerstore.AllocConfig, len(classes))
+	for i, size := range classes {
+		confs[i] = pointerstore.NewAllocConfigByExactSize(uint64(size), uint64(slabSize))
+	}
+
+	return &Store{
+		sizedStores: newSizeClasses(confs, 0),
+		sizeClasses: classes,
+	}
+}
+
+// The size, in bytes, of a CPU cache line. This is correct for most amd64
+// and arm64 CPUs.
+const CacheLineSize = 64
+
+// Returns a new *Store which pads every allocation to at least
+// CacheLineSize bytes.
+//
+// When different goroutines frequently write to small objects which share a
+// cache line, every write invalidates that cache line for every other
+// goroutine. This false sharing can severely limit the performance of
+// concurrent programs. Allocations smaller than CacheLineSize are allocated
+// in the CacheLineSize size class, and because slabs are page aligned every
+// allocation then starts on its own cache line. Allocations of
+// CacheLineSize or more are unaffected.
+//
+// Padding wastes memory for small allocations. The PaddedAllocs statistic
+// counts the allocations which were padded. To pad individual allocations,
+// rather than every allocation in a Store, use AllocAligned with an align of
+// CacheLineSize.
+func NewWithCacheLinePadding(slabSize int) *Store {
+	return &Store{
+		sizedStores: initSizeStore(slabSize, false),
+		minIndex:    indexForSize(CacheLineSize),
+	}
+}
+
+// Returns a new *Store which places each allocation of threshold bytes or
+// more in its own slab, immediately followed by an inaccessible guard page.
+//
+// A buffer overrun past the end of such an allocation, e.g. by unsafe code or
+// a system call given the wrong length, faults immediately instead of
+// silently corrupting the neighbouring allocation. Because slices are
+// allocated with a power of two capacity, the end of a slice's capacity is
+// usually the end of its allocation.
+//
+// Each guarded allocation is rounded up to a whole number of pages, and uses
+// its own mapping, so this is very wasteful. It is intended for tests and
+// staging builds. threshold is rounded up to the page size, and allocations
+// smaller than threshold are made normally, in slabs of slabSize.
+//
+// Guard pages are only supported on systems which can make memory
+// inaccessible, on other systems the first guarded allocation will panic.
+func NewWithGuardPages(slabSize int, threshold int) *Store {
+	stores := initSizeStore(slabSize, false)
+	for i := range stores {
+		if 1<<i >= threshold && 1<<i >= os.Getpagesize() {
+			stores[i].conf = pointerstore.NewAllocConfigWithGuardPage(1 << i)
+		}
+	}
+
+	return &Store{
+		sizedStores: stores,
+	}
+}
+
+// Returns a new *Store which allocates from a number of independent pools of
+// slabs.
+//
+// In a Store created by New every allocation of a given size class comes from
+// the same shared slabs. On a multi-socket server this causes memory to be
+// shared between CPUs on different NUMA nodes. A Store with pools gives each
+// pool its own slabs, and allocations are made from the pool assigned to the
+// P (see runtime.GOMAXPROCS) running the allocating goroutine. An allocation
+// is always freed back to the pool it came from. Because memory is usually
+// placed on the NUMA node of the CPU which first touches it, each pool's
+// slabs tend to be local to the CPUs allocating from it.
+//
+// Go doesn't expose which CPU, or NUMA node, a goroutine is running on. Pools
+// are assigned to Ps using the per-P caching of sync.Pool, so this is a best
+// effort which usually, but not always, allocates from the same pool on the
+// same P. A pools value of runtime.GOMAXPROCS(0) gives one pool per P.
+//
+// The API of a Store with pools is unchanged. Stats and TotalStats report the
+// statistics summed across all pools, PoolStats reports the statistics for
+// each pool.
+func NewWithPools(slabSize int, pools int) *Store {
+	if pools < 1 || pools > math.MaxUint16+1 {
+		panic(fmt.Errorf("pools (%d) must be between 1 and %d", pools, math.MaxUint16+1))
+	}
+
+	confs := make([]pointerstore.AllocConfig, maxAllocationBits())
+	for i := range confs {
+		confs[i] = pointerstore.NewAllocConfigBySize(1<<i, uint64(slabSize))
+	}
+
+	poolStores := make([][]*sizeClass, pools)
+	for pool := range poolStores {
+		poolStores[pool] = newSizeClasses(confs, pool)
+	}
+
+	return &Store{
+		sizedStores: poolStores[0],
+		pools:       poolStores,
+	}
+}
+
+// Returns a new *Store where each size class has a cache of free slots for
+// each P (see runtime.GOMAXPROCS), each cache holding at most cacheSize
+// slots.
+//
+// In a Store created by New every allocation and free of a given size class
+// takes the same lock, which limits how well concurrent allocation scales. A
+// Store with caches allocates from, and frees to, the cache assigned to the P
+// running the goroutine. Only when a cache is empty is it refilled with a
+// batch of free slots from the size class's shared free list, and only when a
+// cache is full is half of it returned to the free list. Each cache has its
+// own lock, which is rarely contended.
+//
+// Like NewWithPools, caches are assigned to Ps using the per-P caching of
+// sync.Pool, so this is a best effort. Slots held in a cache are free, and
+// any use of a stale reference to one is detected as usual. While a
+// quarantine is set frees bypass the caches, see SetQuarantine.
+//
+// The Cached statistic counts the free slots held in caches, and the
+// CacheHits and CacheMisses statistics count the allocations which did, and
+// didn't, find a free slot in their cache.
+func NewWithCaches(slabSize int, cacheSize int) *Store {
+	if cacheSize < 2 {
+		panic(fmt.Errorf("cacheSize (%d) must be at least 2", cacheSize))
+	}
+
+	return &Store{
+		sizedStores: initSizeStore(slabSize, false),
+		caches:      runtime.GOMAXPROCS(0),
+		cacheSize:   cacheSize,
 	}
 }
 
-func initSizeStore(slabSize int) []*pointerstore.Store {
-	slabs := make([]*pointerstore.Store, maxAllocationBits())
+// Returns a set of size classes, suitable for NewWithSizeClasses, where each
+// size class is approximately factor times larger than the last. Size classes
+// are generated up to maxSize.
+//
+// Size classes up to 8 bytes are powers of two. Size classes above 8 are
+// rounded up to a multiple of 8. For example a factor of 1.25 produces
+//
+//	1, 2, 4, 8, 16, 24, 32, 40, 56, 72, 96, 120, 152, ...
+func GeometricSizeClasses(factor float64, maxSize int) []int {
+	if !(factor > 1) {
+		panic(fmt.Errorf("size class factor (%f) must be greater than 1", factor))
+	}
+
+	classes := []int{}
+	for size := 1; size < 8 && size <= maxSize; size *= 2 {
+		classes = append(classes, size)
+	}
+
+	for size := 8; size <= maxSize; {
+		classes = append(classes, size)
+		next := int(math.Ceil(float64(size) * factor))
+		// Round up to a multiple of 8
+		next = (next + 7) &^ 7
+		size = max(next, size+8)
+	}
+
+	return classes
+}
 
-	for i := range slabs {
-		slabs[i] = pointerstore.New(pointerstore.NewAllocConfigBySize(1<<i, uint64(slabSize)))
+// Returns the allocation size of each size class used by this Store. The
+// values returned by Stats and AllocConfigs are reported in the same order.
+func (s *Store) SizeClasses() []int {
+	if s.sizeClasses != nil {
+		return slices.Clone(s.sizeClasses)
 	}
 
-	return slabs
+	classes := make([]int, len(s.sizedStores))
+	for i := range classes {
+		classes[i] = 1 << i
+	}
+	return classes
 }
 
-func (s *Store) alloc(idx int) pointerstore.RefPointer {
-	return s.sizedStores[idx].Alloc()
+// Validates sizeClasses and appends power of two size classes up to the
+// maximum allocation size
+func completeSizeClasses(sizeClasses []int) ([]int, error) {
+	if len(sizeClasses) == 0 {
+		return nil, fmt.Errorf("cannot create Store with no size classes")
+	}
+
+	for i, size := range sizeClasses {
+		if size <= 0 || size > maxAllocSize {
+			return nil, fmt.Errorf("size class %d must be between 1 and %d", size, maxAllocSize)
+		}
+		if i > 0 && size <= sizeClasses[i-1] {
+			return nil, fmt.Errorf("size classes must be strictly increasing, found %d after %d", size, sizeClasses[i-1])
+		}
+		if !isPowerOfTwo(size) && size%8 != 0 {
+			return nil, fmt.Errorf("size class %d must be a power of two or a multiple of 8", size)
+		}
+	}
+
+	classes := slices.Clone(sizeClasses)
+	for size := nextPowerOfTwo(classes[len(classes)-1] + 1); size <= maxAllocSize; size *= 2 {
+		classes = append(classes, size)
+	}
+	return classes, nil
+}
+
+// Returns the index of the size class used for allocations of size bytes
+func (s *Store) sizeIndex(size int) int {
+	if s.sizeClasses == nil {
+		return indexForSize(size)
+	}
+
+	// residentObjectSize checks that size is a legal allocation size
+	residentObjectSize(size)
+	idx, _ := slices.BinarySearch(s.sizeClasses, size)
+	return idx
+}
+
+// Returns the allocation size of the size class at idx
+func (s *Store) classSize(idx int) int {
+	if s.sizeClasses == nil {
+		return 1 << idx
+	}
+	return s.sizeClasses[idx]
+}
+
+func initSizeStore(slabSize int, hugePages bool) []*sizeClass {
+	confs := make([]pointerstore.AllocConfig, maxAllocationBits())
+	for i := range confs {
+		confs[i] = pointerstore.NewAllocConfigBySize(1<<i, uint64(slabSize))
+		confs[i].HugePages = hugePages
+	}
+
+	return newSizeClasses(confs, 0)
+}
+
+// Allocates from the size class idx, recording that requested bytes were
+// asked for.
+func (s *Store) alloc(idx int, requested int) pointerstore.RefPointer {
+	if rec := s.recording.Load(); rec != nil {
+		return rec.recordAlloc(s, idx, requested)
+	}
+	return s.allocUnrecorded(s.localToken(), idx, requested)
+}
+
+// Allocates from the size class idx, using the pool, or cache, of token.
+func (s *Store) allocUnrecorded(token poolToken, idx int, requested int) pointerstore.RefPointer {
+	if idx < s.minIndex {
+		return s.classStore(0, s.minIndex).AllocPadded(uint64(requested))
+	}
+	if s.caches > 0 {
+		return s.classStore(0, idx).Cache(token.cache).AllocRequested(uint64(requested))
+	}
+	return s.classStore(token.pool, idx).AllocRequested(uint64(requested))
+}
+
+// Returns the index of the size class which allocations for the size class
+// at idx are actually made from, see minIndex
+func (s *Store) storeIndex(idx int) int {
+	return max(idx, s.minIndex)
 }
 
 func (s *Store) free(idx int, r pointerstore.RefPointer) {
-	s.sizedStores[idx].Free(r)
+	if err := s.tryFree(idx, r); err != nil {
+		panic(err)
+	}
+}
+
+func (s *Store) tryFree(idx int, r pointerstore.RefPointer) error {
+	if rec := s.recording.Load(); rec != nil {
+		return rec.recordFree(s, idx, r)
+	}
+	return s.tryFreeUnrecorded(s.localToken(), idx, r)
+}
+
+// Frees r from the size class idx. With caches r is freed into the cache of
+// token.
+func (s *Store) tryFreeUnrecorded(token poolToken, idx int, r pointerstore.RefPointer) error {
+	idx = s.storeIndex(idx)
+	if r.IsNil() {
+		s.reportMisuse("free", idx, ErrNilReference)
+		return ErrNilReference
+	}
+
+	var err error
+	if s.caches > 0 {
+		err = s.classStore(0, idx).Cache(token.cache).TryFree(r)
+	} else if s.pools == nil {
+		err = s.classStore(0, idx).TryFree(r)
+	} else {
+		// Allocations are always returned to the pool they came from
+		err = s.classStore(r.Pool(), idx).TryFree(r)
+	}
+	if err != nil {
+		s.reportMisuse("free", idx, err)
+	}
+	return err
+}
+
+func (s *Store) commit(idx int, r pointerstore.RefPointer) {
+	idx = s.storeIndex(idx)
+	if s.pools == nil {
+		s.classStore(0, idx).Commit(r)
+		return
+	}
+	s.classStore(r.Pool(), idx).Commit(r)
+}
+
+func (s *Store) resolve(idx int, handle uint64) (pointerstore.RefPointer, bool) {
+	idx = s.storeIndex(idx)
+	pools := s.allPools()
+	pool := pointerstore.HandlePool(handle)
+	if pool >= len(pools) {
+		return pointerstore.RefPointer{}, false
+	}
+	// A size class which has never been used has nothing to resolve
+	store := pools[pool][idx].loaded()
+	if store == nil {
+		return pointerstore.RefPointer{}, false
+	}
+	return store.Resolve(handle)
+}
+
+// Returns the pool, and cache, which the current P allocates from
+func (s *Store) localToken() poolToken {
+	token, ok := s.poolTokens.Get().(*poolToken)
+	if !ok {
+		token = &poolToken{}
+		if s.pools != nil {
+			token.pool = int((s.nextPool.Add(1) - 1) % uint64(len(s.pools)))
+		}
+		if s.caches > 0 {
+			token.cache = int((s.nextCache.Add(1) - 1) % uint64(s.caches))
+		}
+	}
+	local := *token
+	s.poolTokens.Put(token)
+	return local
+}
+
+// Returns the size class stores of each pool in this Store. A Store without
+// pools has a single pool.
+func (s *Store) allPools() [][]*sizeClass {
+	if s.pools == nil {
+		return [][]*sizeClass{s.sizedStores}
+	}
+	return s.pools
 }
 
 // Releases the memory allocated by the Store back to the operating system.
@@ -68,36 +558,302 @@ func (s *Store) free(idx int, r pointerstore.RefPointer) {
 // that most (all?) Stores will live for the entire lifecycle of the program
 // they are used in, so this method probably won't be used in most cases.
 func (s *Store) Destroy() error {
-	for i := range s.sizedStores {
-		if err := s.sizedStores[i].Destroy(); err != nil {
-			return err
+	s.stopWatermarks()
+
+	for _, classes := range s.allPools() {
+		for _, class := range classes {
+			if store := class.loaded(); store != nil {
+				if err := store.Destroy(); err != nil {
+					return err
+				}
+			}
 		}
 	}
 
 	return nil
 }
 
+// Returns a new *Store which is an independent copy of s, with the same size
+// classes and pools. The contents of every allocation are copied into the
+// new Store, which supports snapshot-and-modify workflows, such as trying
+// out a bulk mutation on a copy before applying it to the original.
+//
+// Every allocation occupies the same slot in the new Store as it does in s.
+// But references are addresses, so every existing RefObject, RefSlice and
+// RefString still refers to the allocation in s. The copy of an object in
+// the new Store is found with ResolveObjectHandle, using the handle of the
+// original object, see RefObject.Handle. In the same way, references stored
+// inside allocations still refer to allocations in s. To copy a graph of
+// allocations, with each reference updated, use a Migrator.
+//
+// Clone must not be called concurrently with any other use of s.
+func (s *Store) Clone() *Store {
+	clone := &Store{
+		sizeClasses: s.sizeClasses,
+		minIndex:    s.minIndex,
+		caches:      s.caches,
+		cacheSize:   s.cacheSize,
+		quarantine:  s.quarantine,
+	}
+
+	if s.pools == nil {
+		clone.sizedStores = cloneSizeClasses(s.sizedStores)
+		return clone
+	}
+
+	clone.pools = make([][]*sizeClass, len(s.pools))
+	for pool, classes := range s.pools {
+		clone.pools[pool] = cloneSizeClasses(classes)
+	}
+	clone.sizedStores = clone.pools[0]
+	return clone
+}
+
+// Returns a copy of classes, where every size class which has been used is
+// cloned
+func cloneSizeClasses(classes []*sizeClass) []*sizeClass {
+	clones := make([]*sizeClass, len(classes))
+	for i, class := range classes {
+		clones[i] = &sizeClass{
+			conf: class.conf,
+			pool: class.pool,
+		}
+		if store := class.loaded(); store != nil {
+			clones[i].store.Store(store.Clone())
+		}
+	}
+	return clones
+}
+
+// A ReclaimPolicy controls which free memory is advised to the operating
+// system as unneeded by Store.Reclaim.
+type ReclaimPolicy struct {
+	// The fraction of slots in a slab which must be free before that
+	// slab's free slots are advised. With a MinFreeFraction of 0 every
+	// slab with free pages is advised.
+	MinFreeFraction float64
+	// Lazy reclamation uses MADV_FREE, which allows the operating system
+	// to reclaim the pages only when it is under memory pressure. Otherwise
+	// MADV_DONTNEED is used, which releases the pages immediately.
+	Lazy bool
+}
+
+// Advises the operating system that the memory used by free allocation slots
+// is no longer needed, in every slab selected by policy. Returns the number of
+// bytes advised, which is also recorded in the AdvisedBytes statistic.
+//
+// This is a middle ground between keeping every freed slot's memory, and
+// unmapping slabs entirely. No allocations are moved and the address space of
+// each slab stays reserved, so every reference remains valid. The pages are
+// faulted back in when their slots are allocated again. Only whole pages
+// containing nothing but free slots can be advised.
+//
+// Reclaim blocks allocations and frees while it runs, but may be called
+// concurrently with reading and writing live allocations.
+func (s *Store) Reclaim(policy ReclaimPolicy) (int, error) {
+	advised := 0
+	for _, classes := range s.allPools() {
+		for _, class := range classes {
+			store := class.loaded()
+			if store == nil {
+				continue
+			}
+			n, err := store.Reclaim(policy.MinFreeFraction, policy.Lazy)
+			advised += n
+			if err != nil {
+				return advised, err
+			}
+		}
+	}
+	return advised, nil
+}
+
+// A QuarantinePolicy limits the freed slots held in quarantine by each size
+// class, see Store.SetQuarantine. A zero QuarantinePolicy disables the
+// quarantine.
+type QuarantinePolicy struct {
+	// The maximum number of freed slots held in quarantine by each size
+	// class. With a Slots of 0 the number of slots is not limited.
+	Slots int
+	// The maximum number of bytes of freed slots held in quarantine by
+	// each size class. With a Bytes of 0 the number of bytes is not
+	// limited. Size classes larger than Bytes have no quarantine.
+	Bytes int
+}
+
+// Returns the number of slots, of size bytes each, which may be held in
+// quarantine
+func (p QuarantinePolicy) slotsFor(size int) int {
+	slots := p.Slots
+	if p.Bytes > 0 {
+		bySize := p.Bytes / size
+		if slots == 0 || bySize < slots {
+			slots = bySize
+		}
+	}
+	return slots
+}
+
+// Holds freed allocation slots in a quarantine, limited by policy, before
+// they can be allocated again. Once a size class's quarantine is full the
+// oldest slot is released, and can be allocated again. The Quarantined
+// statistic reports the number of slots held in quarantine.
+//
+// Normally the most recently freed slot is the next to be allocated, which
+// makes use-after-free bugs frequently appear to work. A stale reference to a
+// slot in quarantine always fails the generation check, so a quarantine
+// raises the chance that a use-after-free is detected, at the cost of
+// holding on to the quarantined memory. This is intended for tests and
+// staging builds.
+//
+// Setting a zero QuarantinePolicy releases every quarantined slot. Compact
+// also releases every quarantined slot.
+func (s *Store) SetQuarantine(policy QuarantinePolicy) {
+	s.classLock.Lock()
+	defer s.classLock.Unlock()
+
+	s.quarantine = policy
+	for _, classes := range s.allPools() {
+		for i, class := range classes {
+			if store := class.loaded(); store != nil {
+				store.SetQuarantine(policy.slotsFor(s.classSize(i)))
+			}
+		}
+	}
+}
+
+// Makes all of the memory in the Store read-only, at the operating system
+// level. This provides a hard guarantee that a dataset, once loaded, is not
+// modified while it is being used.
+//
+// After Seal returns every allocation, or free, from the Store panics. Any
+// write to an allocation faults, crashing the program, rather than silently
+// corrupting data. This includes functions which modify an allocation's
+// metadata, such as Pin, Append and Realloc based functions like Truncate.
+// Reading allocations is unaffected, and a sealed Store can be cloned to
+// create a modifiable copy, see Clone.
+//
+// If the operating system doesn't support making memory read-only an error
+// is returned. If an error is returned the Store may be partially sealed.
+// Seal must not be called concurrently with any other use of the Store.
+func (s *Store) Seal() error {
+	s.classLock.Lock()
+	defer s.classLock.Unlock()
+
+	for _, classes := range s.allPools() {
+		for _, class := range classes {
+			if store := class.loaded(); store != nil {
+				if err := store.Seal(); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	s.sealed.Store(true)
+	return nil
+}
+
+// Indicates whether Seal has been called on this Store
+func (s *Store) IsSealed() bool {
+	return s.sealed.Load()
+}
+
 // Returns the statistics across all allocation size classes for this Store.
 //
 // There are helper methods which allow the user to easily get the statistics
 // for a single size class for object, slices and string allocations.
+//
+// For a Store created by NewWithPools the statistics are summed across all
+// pools.
 func (s *Store) Stats() []pointerstore.Stats {
 	sizedStats := make([]pointerstore.Stats, len(s.sizedStores))
-	for i := range s.sizedStores {
-		sizedStats[i] = s.sizedStores[i].Stats()
+	for _, stats := range s.PoolStats() {
+		for i := range sizedStats {
+			sizedStats[i] = sizedStats[i].Add(stats[i])
+		}
 	}
 	return sizedStats
 }
 
+// Returns a snapshot of the statistics for each size class, for each pool in
+// this Store, see NewWithPools. This shows how allocations are distributed
+// across the pools. A Store without pools has a single pool.
+func (s *Store) PoolStats() []StatsSnapshot {
+	pools := s.allPools()
+	poolStats := make([]StatsSnapshot, len(pools))
+	for pool, classes := range pools {
+		stats := make(StatsSnapshot, len(classes))
+		for i, class := range classes {
+			stats[i] = class.stats()
+		}
+		poolStats[pool] = stats
+	}
+	return poolStats
+}
+
+// Returns the statistics for this Store, summed across all allocation size
+// classes.
+func (s *Store) TotalStats() pointerstore.Stats {
+	return s.StatsSnapshot().Total()
+}
+
+// A snapshot of the statistics for each size class of a Store, see
+// Store.StatsSnapshot.
+type StatsSnapshot []pointerstore.Stats
+
+// Returns a snapshot of the statistics for each size class of this Store.
+//
+// Snapshots taken before and after some operation can be subtracted, to find
+// the change in allocations caused by that operation
+//
+//	before := store.StatsSnapshot()
+//	// ... allocate and free ...
+//	delta := store.StatsSnapshot().Sub(before)
+func (s *Store) StatsSnapshot() StatsSnapshot {
+	return StatsSnapshot(s.Stats())
+}
+
+// Returns a snapshot containing the difference between the statistics in
+// this snapshot and before, for each size class. Both snapshots must be taken
+// from the same Store.
+func (ss StatsSnapshot) Sub(before StatsSnapshot) StatsSnapshot {
+	return StatsSnapshot(StatsDelta(before, ss))
+}
+
+// Returns the statistics in this snapshot summed across all size classes.
+func (ss StatsSnapshot) Total() pointerstore.Stats {
+	total := pointerstore.Stats{}
+	for _, stats := range ss {
+		total = total.Add(stats)
+	}
+	return total
+}
+
+// Returns the difference between after and before for each size class. The
+// statistics must have been taken from the same Store, this function panics if
+// they have a different number of size classes.
+func StatsDelta(before, after []pointerstore.Stats) []pointerstore.Stats {
+	if len(before) != len(after) {
+		panic(fmt.Errorf("cannot compare stats with %d size classes to stats with %d size classes", len(before), len(after)))
+	}
+
+	delta := make([]pointerstore.Stats, len(after))
+	for i := range after {
+		delta[i] = after[i].Sub(before[i])
+	}
+	return delta
+}
+
 // Returns the allocation config across all allocation size classes for this
-// Store.
+// Store. The config of a size class is reported even if nothing has been
+// allocated from it yet.
 //
 // There are helper methods which allow the user to easily get the config for a
 // single size class for object, slices and string allocations.
 func (s *Store) AllocConfigs() []pointerstore.AllocConfig {
 	sizedAllocConfigs := make([]pointerstore.AllocConfig, len(s.sizedStores))
-	for i := range s.sizedStores {
-		sizedAllocConfigs[i] = s.sizedStores[i].AllocConfig()
+	for i, class := range s.sizedStores {
+		sizedAllocConfigs[i] = class.conf
 	}
 	return sizedAllocConfigs
 }
@@ -0,0 +1,752 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+package offheap
+
+import (
+	"fmt"
+	"math/rand"
+	"runtime"
+	"runtime/debug"
+	"sync"
+	"testing"
+	"unsafe"
+
+	"github.com/fmstephe/memorymanager/offheap/internal/pointerstore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGeometricSizeClasses(t *testing.T) {
+	assert.Equal(t, []int{1, 2, 4, 8, 16, 24, 32, 40, 56, 72, 96, 120, 152, 192}, GeometricSizeClasses(1.25, 200))
+	assert.Equal(t, []int{1, 2, 4, 8, 16, 32, 64, 128}, GeometricSizeClasses(2, 200))
+	assert.Equal(t, []int{1, 2, 4}, GeometricSizeClasses(2, 7))
+
+	assert.Panics(t, func() { GeometricSizeClasses(1, 200) })
+	assert.Panics(t, func() { GeometricSizeClasses(0.5, 200) })
+}
+
+// Demonstrate that illegal size classes are rejected
+func TestNewWithSizeClasses_Invalid(t *testing.T) {
+	for _, sizeClasses := range [][]int{
+		{},
+		{0, 8},
+		{-8},
+		{8, 8},
+		{16, 8},
+		{8, 12},
+		{maxAllocSize * 2},
+	} {
+		t.Run(fmt.Sprintf("%v", sizeClasses), func(t *testing.T) {
+			assert.Panics(t, func() { NewWithSizeClasses(1<<8, sizeClasses) })
+		})
+	}
+}
+
+// Demonstrate that the size classes of a Store are extended with power of two
+// size classes up to the maximum allocation size
+func TestSizeClasses(t *testing.T) {
+	os := New()
+	defer func() {
+		assert.NoError(t, os.Destroy())
+	}()
+
+	classes := os.SizeClasses()
+	assert.Len(t, classes, maxAllocationBits())
+	for i, size := range classes {
+		assert.Equal(t, 1<<i, size)
+	}
+
+	cs := NewWithSizeClasses(1<<8, []int{8, 40, 48})
+	defer func() {
+		assert.NoError(t, cs.Destroy())
+	}()
+
+	classes = cs.SizeClasses()
+	assert.Equal(t, []int{8, 40, 48, 64, 128}, classes[:5])
+	assert.Equal(t, maxAllocSize, classes[len(classes)-1])
+	assert.Len(t, cs.Stats(), len(classes))
+	assert.Len(t, cs.AllocConfigs(), len(classes))
+}
+
+// Demonstrate that a Store backed by huge pages can be used like any other
+// Store, whether or not huge pages are available on this system
+func TestNewWithHugePages(t *testing.T) {
+	os := NewWithHugePages(0)
+	defer func() {
+		assert.NoError(t, os.Destroy())
+	}()
+
+	assert.True(t, ConfForType[int64](os).HugePages)
+	assert.GreaterOrEqual(t, ConfForType[int64](os).RequestedSlabSize, uint64(pointerstore.HugePageSize))
+
+	refs := []RefObject[int64]{}
+	for i := range 1000 {
+		r := AllocObject[int64](os)
+		*r.Value() = int64(i)
+		refs = append(refs, r)
+	}
+	str := AllocStringFromString(os, "huge pages")
+
+	for i, r := range refs {
+		assert.Equal(t, int64(i), *r.Value())
+	}
+	assert.Equal(t, "huge pages", str.Value())
+
+	total := os.TotalStats()
+	assert.Equal(t, 2, total.Slabs)
+	assert.LessOrEqual(t, total.HugePageSlabs, total.Slabs)
+}
+
+// Demonstrate that Reclaim advises the free slabs selected by its policy,
+// without disturbing live allocations
+// Demonstrate that NewWithSlabTuning chooses a slab size for each size class,
+// so that each slab holds the target number of objects within the slab size
+// bounds
+func TestNewWithSlabTuning(t *testing.T) {
+	os := NewWithSlabTuning(SlabTuning{ObjectsPerSlab: 64, MinSlabSize: 1 << 12, MaxSlabSize: 1 << 22})
+	defer func() {
+		assert.NoError(t, os.Destroy())
+	}()
+
+	configs := os.AllocConfigs()
+	for i, conf := range configs {
+		size := uint64(1) << i
+		assert.Equal(t, uint64(64), conf.TargetObjectsPerSlab)
+		assert.Equal(t, uint64(1<<22), conf.MaxSlabSize)
+		switch {
+		case size*64 <= 1<<12:
+			// Tiny objects fill the minimum slab size
+			assert.Equal(t, uint64(1<<12), conf.TotalObjectSize, "size %d", size)
+		case size*64 <= 1<<22:
+			assert.Equal(t, uint64(64), conf.ObjectsPerSlab, "size %d", size)
+		case size <= 1<<22:
+			// Slabs are limited to the maximum slab size
+			assert.Equal(t, uint64(1<<22), conf.TotalObjectSize, "size %d", size)
+		default:
+			// Objects larger than the maximum slab size have a slab each
+			assert.Equal(t, uint64(1), conf.ObjectsPerSlab, "size %d", size)
+		}
+	}
+
+	// 64 allocations of 32KB fit in a single slab
+	refs := []RefSlice[byte]{}
+	for range 64 {
+		refs = append(refs, AllocSlice[byte](os, 1<<15, 1<<15))
+	}
+	assert.Equal(t, 1, StatsForSlice[byte](os, 1<<15).Slabs)
+	for _, r := range refs {
+		FreeSlice(os, r)
+	}
+
+	// The zero value uses the defaults
+	defaults := NewWithSlabTuning(SlabTuning{})
+	defer func() {
+		assert.NoError(t, defaults.Destroy())
+	}()
+	assert.Equal(t, uint64(DefaultSlabTuning.ObjectsPerSlab), ConfForSlice[byte](defaults, 1<<15).ObjectsPerSlab)
+
+	assert.Panics(t, func() { NewWithSlabTuning(SlabTuning{ObjectsPerSlab: 0, MaxSlabSize: 1 << 20}) })
+	assert.Panics(t, func() { NewWithSlabTuning(SlabTuning{ObjectsPerSlab: 64, MinSlabSize: 1 << 20, MaxSlabSize: 1 << 12}) })
+}
+
+func TestReclaim(t *testing.T) {
+	os := NewSized(1 << 16)
+	defer func() {
+		assert.NoError(t, os.Destroy())
+	}()
+
+	conf := ConfForType[int64](os)
+	perSlab := int(conf.ObjectsPerSlab)
+
+	refs := []RefObject[int64]{}
+	for i := range perSlab * 4 {
+		r := AllocObject[int64](os)
+		*r.Value() = int64(i)
+		refs = append(refs, r)
+	}
+
+	// Keep the first slab full, and a single allocation in the last slab
+	for _, r := range refs[perSlab : len(refs)-1] {
+		FreeObject(os, r)
+	}
+
+	// The last slab isn't free enough to be advised
+	advised, err := os.Reclaim(ReclaimPolicy{MinFreeFraction: 1})
+	require.NoError(t, err)
+	assert.Equal(t, 2*int(conf.TotalObjectSize), advised)
+
+	// Every page of the last slab, except the one holding the last
+	// allocation, is advised as well
+	lazyAdvised, err := os.Reclaim(ReclaimPolicy{Lazy: true})
+	require.NoError(t, err)
+	assert.Greater(t, lazyAdvised, advised)
+	assert.Less(t, lazyAdvised, 3*int(conf.TotalObjectSize))
+
+	for i, r := range refs[:perSlab] {
+		assert.Equal(t, int64(i), *r.Value())
+	}
+	last := refs[len(refs)-1]
+	assert.Equal(t, int64(len(refs)-1), *last.Value())
+
+	assert.Equal(t, advised+lazyAdvised, os.TotalStats().AdvisedBytes)
+	assert.Equal(t, 4, os.TotalStats().Slabs)
+}
+
+// Demonstrate that SetQuarantine delays the reuse of freed allocations, limited
+// by slots or by bytes, so stale references are detected
+func TestSetQuarantine(t *testing.T) {
+	os := New()
+	defer func() {
+		assert.NoError(t, os.Destroy())
+	}()
+	os.SetQuarantine(QuarantinePolicy{Slots: 100, Bytes: 1 << 10})
+
+	// The int64 size class is limited by slots, the 256 byte size class
+	// by bytes
+	for range 200 {
+		FreeObject(os, AllocObject[int64](os))
+		FreeSlice(os, AllocSlice[byte](os, 256, 256))
+	}
+	assert.Equal(t, 100, StatsForType[int64](os).Quarantined)
+	assert.Equal(t, 4, StatsForSlice[byte](os, 256).Quarantined)
+	assert.Equal(t, 104, os.TotalStats().Quarantined)
+
+	// Size classes larger than Bytes have no quarantine
+	FreeSlice(os, AllocSlice[byte](os, 2048, 2048))
+	assert.Equal(t, 0, StatsForSlice[byte](os, 2048).Quarantined)
+
+	// A stale reference is detected while its slot is in quarantine
+	stale := AllocObject[int64](os)
+	FreeObject(os, stale)
+	for range 99 {
+		FreeObject(os, AllocObject[int64](os))
+	}
+	assert.Panics(t, func() { stale.Value() })
+
+	os.SetQuarantine(QuarantinePolicy{})
+	assert.Equal(t, 0, os.TotalStats().Quarantined)
+}
+
+// Demonstrate that allocations in a Store with pools are always freed back
+// to the pool they were allocated from, even when freed by a different
+// goroutine
+func TestNewWithPools(t *testing.T) {
+	assert.Panics(t, func() { NewWithPools(1<<8, 0) })
+
+	os := NewWithPools(1<<8, 4)
+	defer func() {
+		assert.NoError(t, os.Destroy())
+	}()
+
+	const goroutines = 8
+	const perGoroutine = 1000
+
+	allocated := make(chan []RefObject[int64], goroutines)
+	wg := sync.WaitGroup{}
+	wg.Add(goroutines)
+	for g := range goroutines {
+		go func() {
+			defer wg.Done()
+			refs := []RefObject[int64]{}
+			for i := range perGoroutine {
+				r := AllocObject[int64](os)
+				*r.Value() = int64(g*perGoroutine + i)
+				refs = append(refs, r)
+			}
+			allocated <- refs
+		}()
+	}
+	wg.Wait()
+	close(allocated)
+
+	all := [][]RefObject[int64]{}
+	for refs := range allocated {
+		all = append(all, refs)
+	}
+
+	total := os.TotalStats()
+	assert.Equal(t, goroutines*perGoroutine, total.Allocs)
+	assert.Equal(t, goroutines*perGoroutine, total.Live)
+
+	// Free every allocation from a different goroutine than allocated it
+	wg.Add(len(all))
+	for i := range all {
+		go func() {
+			defer wg.Done()
+			for _, r := range all[(i+1)%len(all)] {
+				FreeObject(os, r)
+			}
+		}()
+	}
+	wg.Wait()
+
+	poolStats := os.PoolStats()
+	require.Len(t, poolStats, 4)
+	summed := pointerstore.Stats{}
+	for _, stats := range poolStats {
+		poolTotal := stats.Total()
+		// Every pool has had each of its allocations returned to it
+		assert.Equal(t, poolTotal.Allocs, poolTotal.Frees)
+		assert.Equal(t, 0, poolTotal.Live)
+		summed = summed.Add(poolTotal)
+	}
+	assert.Equal(t, os.TotalStats(), summed)
+	assert.Equal(t, goroutines*perGoroutine, summed.Frees)
+
+	// A Store without pools has a single pool
+	plain := NewSized(1 << 8)
+	defer func() {
+		assert.NoError(t, plain.Destroy())
+	}()
+	AllocObject[int64](plain)
+	require.Len(t, plain.PoolStats(), 1)
+	assert.Equal(t, plain.StatsSnapshot(), plain.PoolStats()[0])
+}
+
+// Demonstrate that a Store with caches reuses freed slots from its caches,
+// including slots freed by a different goroutine, and that cached slots are
+// counted as free
+func TestNewWithCaches(t *testing.T) {
+	assert.Panics(t, func() { NewWithCaches(1<<8, 1) })
+
+	os := NewWithCaches(1<<8, 16)
+	defer func() {
+		assert.NoError(t, os.Destroy())
+	}()
+
+	const goroutines = 8
+	const perGoroutine = 1000
+
+	// Each goroutine allocates, and frees, repeatedly so most allocations
+	// are served from its cache
+	wg := sync.WaitGroup{}
+	wg.Add(goroutines)
+	for g := range goroutines {
+		go func() {
+			defer wg.Done()
+			refs := []RefObject[int64]{}
+			for i := range perGoroutine {
+				r := AllocObject[int64](os)
+				*r.Value() = int64(g*perGoroutine + i)
+				refs = append(refs, r)
+				if len(refs) == 8 {
+					for j, r := range refs {
+						assert.Equal(t, int64(g*perGoroutine+i-7+j), *r.Value())
+						FreeObject(os, r)
+					}
+					refs = refs[:0]
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	total := os.TotalStats()
+	assert.Equal(t, goroutines*perGoroutine, total.Allocs)
+	assert.Equal(t, 0, total.Live)
+	assert.Equal(t, total.Allocs, total.CacheHits+total.CacheMisses)
+	assert.Greater(t, total.CacheHits, total.CacheMisses)
+	assert.Greater(t, total.Cached, 0)
+	assert.Equal(t, total.Slabs*(1<<8), total.FreeBytes)
+
+	// A cached slot can't be freed again
+	r := AllocObject[int64](os)
+	FreeObject(os, r)
+	assert.Panics(t, func() { FreeObject(os, r) })
+
+	// Clones have their own caches
+	clone := os.Clone()
+	defer func() {
+		assert.NoError(t, clone.Destroy())
+	}()
+	cloned := AllocObject[int64](clone)
+	*cloned.Value() = 7
+	FreeObject(clone, cloned)
+	assert.Equal(t, 1, clone.TotalStats().Allocs-os.TotalStats().Allocs)
+}
+
+// Demonstrate that a Store created by NewWithCacheLinePadding places every
+// small allocation on its own cache line, and counts the padded allocations
+func TestNewWithCacheLinePadding(t *testing.T) {
+	os := NewWithCacheLinePadding(1 << 12)
+	defer func() {
+		assert.NoError(t, os.Destroy())
+	}()
+
+	refs := []RefObject[int64]{}
+	for i := range 100 {
+		r := AllocObject[int64](os)
+		*r.Value() = int64(i)
+		assert.Zero(t, uintptr(unsafe.Pointer(r.Value()))%CacheLineSize)
+		refs = append(refs, r)
+	}
+	for i := 1; i < len(refs); i++ {
+		prev := uintptr(unsafe.Pointer(refs[i-1].Value()))
+		next := uintptr(unsafe.Pointer(refs[i].Value()))
+		assert.GreaterOrEqual(t, int(max(prev, next)-min(prev, next)), CacheLineSize)
+	}
+
+	stats := StatsForType[int64](os)
+	assert.Equal(t, 100, stats.Allocs)
+	assert.Equal(t, 100, stats.PaddedAllocs)
+	assert.Equal(t, uint64(CacheLineSize), ConfForType[int64](os).ObjectSize)
+	assert.Equal(t, CacheLineSize, refs[0].AllocatedBytes(os))
+
+	// Slices and strings are padded too, and large allocations are not
+	slice := AllocSlice[byte](os, 3, 3)
+	str := AllocStringFromString(os, "abc")
+	large := AllocSlice[byte](os, 1000, 1000)
+	assert.Zero(t, uintptr(unsafe.Pointer(&slice.Value()[0]))%CacheLineSize)
+	assert.Equal(t, "abc", str.Value())
+	assert.Equal(t, 102, os.TotalStats().PaddedAllocs)
+	assert.Equal(t, 0, StatsForSlice[byte](os, 1000).PaddedAllocs)
+
+	// Padded allocations are freed back into the padded size class, and
+	// reused
+	for i, r := range refs {
+		assert.Equal(t, int64(i), *r.Value())
+		FreeObject(os, r)
+	}
+	FreeSlice(os, slice)
+	FreeString(os, str)
+	FreeSlice(os, large)
+	assert.Equal(t, 0, os.TotalStats().Live)
+
+	reused := AllocObject[int64](os)
+	assert.Equal(t, 1, StatsForType[int64](os).Reused)
+	FreeObject(os, reused)
+
+	// A default Store doesn't pad any allocations
+	plain := New()
+	defer func() {
+		assert.NoError(t, plain.Destroy())
+	}()
+	AllocObject[int64](plain)
+	assert.Equal(t, 0, plain.TotalStats().PaddedAllocs)
+}
+
+// Demonstrate that a Store created by NewWithGuardPages places large
+// allocations on their own slabs, and that writing past the end of a large
+// slice faults
+func TestNewWithGuardPages(t *testing.T) {
+	if runtime.GOOS != "linux" && runtime.GOOS != "darwin" {
+		t.Skip("guard pages are only tested on linux and darwin")
+	}
+
+	os := NewWithGuardPages(1<<12, 1<<13)
+	defer func() {
+		assert.NoError(t, os.Destroy())
+	}()
+
+	// Small allocations share slabs
+	for range 10 {
+		AllocSlice[byte](os, 1<<10, 1<<10)
+	}
+	assert.Equal(t, 10, StatsForSlice[byte](os, 1<<10).Live)
+	assert.Less(t, StatsForSlice[byte](os, 1<<10).Slabs, 10)
+	assert.Zero(t, ConfForSlice[byte](os, 1<<10).GuardSize)
+
+	slices := []RefSlice[byte]{}
+	for range 10 {
+		r := AllocSlice[byte](os, 5000, 5000)
+		full := r.Value()[:cap(r.Value())]
+		for i := range full {
+			full[i] = 1
+		}
+		slices = append(slices, r)
+	}
+	assert.Equal(t, 10, StatsForSlice[byte](os, 5000).Slabs)
+	assert.NotZero(t, ConfForSlice[byte](os, 5000).GuardSize)
+
+	defer debug.SetPanicOnFault(debug.SetPanicOnFault(true))
+	for _, r := range slices {
+		value := r.Value()
+		assert.Panics(t, func() {
+			*(*byte)(unsafe.Add(unsafe.Pointer(&value[0]), cap(value))) = 0xFF
+		})
+		FreeSlice(os, r)
+	}
+}
+
+// Demonstrate that a cloned Store contains a copy of every allocation, found
+// by handle, and can be modified independently of the original
+func TestClone(t *testing.T) {
+	for _, os := range []*Store{New(), NewWithPools(1<<12, 3), NewWithSizeClasses(1<<12, GeometricSizeClasses(1.25, 1<<12))} {
+		refs := []RefObject[MutableStruct]{}
+		for i := range 500 {
+			r := AllocObject[MutableStruct](os)
+			r.Value().Field = i
+			refs = append(refs, r)
+		}
+		for _, r := range refs[:100] {
+			FreeObject(os, r)
+		}
+		refs = refs[100:]
+		str := AllocStringFromString(os, "not cloned by handle")
+
+		clone := os.Clone()
+		assert.Equal(t, os.StatsSnapshot(), clone.StatsSnapshot())
+		assert.Equal(t, os.SizeClasses(), clone.SizeClasses())
+
+		for _, r := range refs {
+			c, ok := ResolveObjectHandle[MutableStruct](clone, r.Handle())
+			require.True(t, ok)
+			assert.Equal(t, *r.Value(), *c.Value())
+			c.Value().Field = -1
+			assert.NotEqual(t, -1, r.Value().Field)
+			FreeObject(clone, c)
+		}
+		assert.Equal(t, 1, clone.TotalStats().Live)
+
+		// The original is unchanged
+		assert.Equal(t, len(refs)+1, os.TotalStats().Live)
+		assert.Equal(t, "not cloned by handle", str.Value())
+		for i, r := range refs {
+			assert.Equal(t, i+100, r.Value().Field)
+		}
+
+		// Both stores allocate independently
+		for range 1000 {
+			AllocObject[MutableStruct](clone)
+		}
+		assert.Equal(t, len(refs)+1, os.TotalStats().Live)
+
+		assert.NoError(t, os.Destroy())
+		assert.NoError(t, clone.Destroy())
+	}
+}
+
+// Demonstrate that a sealed Store can be read, but not allocated from, freed
+// to or written to
+func TestSeal(t *testing.T) {
+	os := New()
+	defer func() {
+		assert.NoError(t, os.Destroy())
+	}()
+
+	obj := AllocObjectFrom(os, MutableStruct{Field: 7})
+	slice := AllocSliceFromSlice(os, []int64{1, 2, 3})
+	str := AllocStringFromString(os, "sealed")
+	assert.False(t, os.IsSealed())
+
+	if err := os.Seal(); err != nil {
+		t.Skipf("sealing is not supported %s", err)
+	}
+	assert.True(t, os.IsSealed())
+
+	assert.Equal(t, 7, obj.Value().Field)
+	assert.Equal(t, []int64{1, 2, 3}, slice.Value())
+	assert.Equal(t, "sealed", str.Value())
+
+	assert.Panics(t, func() { AllocObject[MutableStruct](os) })
+	assert.Panics(t, func() { FreeObject(os, obj) })
+	assert.Panics(t, func() { FreeString(os, str) })
+	assert.Panics(t, func() { AllocSlice[int64](os, 10, 100) })
+
+	// Writes fault, SetPanicOnFault allows us to observe this as a panic
+	defer debug.SetPanicOnFault(debug.SetPanicOnFault(true))
+	assert.Panics(t, func() { obj.Value().Field = 8 })
+	assert.Panics(t, func() { slice.Value()[0] = 8 })
+	assert.Equal(t, 7, obj.Value().Field)
+}
+
+// Demonstrate that TotalStats sums the statistics of every size class
+func TestTotalStats(t *testing.T) {
+	os := NewSized(1 << 8)
+	defer func() {
+		assert.NoError(t, os.Destroy())
+	}()
+
+	assert.Equal(t, 0, os.TotalStats().MappedBytes)
+
+	for range 100 {
+		AllocObject[int64](os)
+	}
+	for range 50 {
+		FreeObject(os, AllocObject[fortyBytes](os))
+	}
+	AllocStringFromString(os, "a string which is longer than forty bytes")
+
+	total := os.TotalStats()
+	assert.Equal(t, 151, total.Allocs)
+	assert.Equal(t, 50, total.Frees)
+	assert.Equal(t, 101, total.Live)
+	assert.Equal(t, 100*8+64, total.LiveBytes)
+
+	summed := pointerstore.Stats{}
+	for _, stats := range os.Stats() {
+		summed = summed.Add(stats)
+	}
+	assert.Equal(t, summed, total)
+	assert.Greater(t, total.MappedBytes, total.LiveBytes+total.FreeBytes)
+}
+
+// Demonstrate that the difference between two snapshots captures exactly the
+// allocations made between them
+func TestStatsSnapshot(t *testing.T) {
+	os := NewSized(1 << 8)
+	defer func() {
+		assert.NoError(t, os.Destroy())
+	}()
+
+	// Allocations made before the first snapshot are not included
+	for range 10 {
+		AllocObject[int64](os)
+	}
+	FreeObject(os, AllocObject[fortyBytes](os))
+
+	before := os.StatsSnapshot()
+	for range 3 {
+		AllocObject[int64](os)
+	}
+	FreeObject(os, AllocObject[fortyBytes](os))
+	after := os.StatsSnapshot()
+
+	delta := after.Sub(before)
+	assert.Equal(t, []pointerstore.Stats(delta), StatsDelta(before, after))
+
+	int64Delta := delta[typeIndex[int64](os)]
+	assert.Equal(t, 3, int64Delta.Allocs)
+	assert.Equal(t, 3, int64Delta.Live)
+	assert.Equal(t, 0, int64Delta.Slabs)
+	assert.Equal(t, 24, int64Delta.LiveBytes)
+
+	fortyDelta := delta[typeIndex[fortyBytes](os)]
+	assert.Equal(t, 1, fortyDelta.Allocs)
+	assert.Equal(t, 1, fortyDelta.Frees)
+	assert.Equal(t, 1, fortyDelta.Reused)
+	assert.Equal(t, 0, fortyDelta.Live)
+
+	total := delta.Total()
+	assert.Equal(t, 4, total.Allocs)
+	assert.Equal(t, 1, total.Frees)
+	assert.Equal(t, os.TotalStats(), after.Total())
+
+	assert.Panics(t, func() { StatsDelta(before, before[1:]) })
+}
+
+type fortyBytes struct {
+	a, b, c, d, e int64
+}
+
+// Demonstrate that objects are allocated in the smallest fitting custom size
+// class, and that stats are reported for that size class
+func TestNewWithSizeClasses_Objects(t *testing.T) {
+	cs := NewWithSizeClasses(1<<12, []int{8, 16, 24, 32, 40, 48})
+	defer func() {
+		assert.NoError(t, cs.Destroy())
+	}()
+
+	assert.Equal(t, uint64(40), ConfForType[fortyBytes](cs).ObjectSize)
+
+	refs := []RefObject[fortyBytes]{}
+	for i := range 1000 {
+		r := AllocObject[fortyBytes](cs)
+		*r.Value() = fortyBytes{int64(i), int64(i + 1), int64(i + 2), int64(i + 3), int64(i + 4)}
+		refs = append(refs, r)
+	}
+
+	for i, r := range refs {
+		assert.Equal(t, fortyBytes{int64(i), int64(i + 1), int64(i + 2), int64(i + 3), int64(i + 4)}, *r.Value())
+		FreeObject(cs, r)
+	}
+
+	stats := StatsForType[fortyBytes](cs)
+	assert.Equal(t, 1000, stats.Allocs)
+	assert.Equal(t, 1000, stats.Frees)
+
+	// Nothing was allocated in the 48 byte size class
+	assert.Equal(t, 0, cs.Stats()[5].Allocs)
+}
+
+// Randomly allocate, append to and free slices and strings in a Store with
+// custom size classes, checking that their contents are never corrupted
+func TestNewWithSizeClasses_SlicesAndStrings(t *testing.T) {
+	cs := NewWithSizeClasses(1<<12, GeometricSizeClasses(1.25, 1<<12))
+	defer func() {
+		assert.NoError(t, cs.Destroy())
+	}()
+
+	r := rand.New(rand.NewSource(1))
+
+	type sliceAndExpected struct {
+		ref      RefSlice[int32]
+		expected []int32
+	}
+	type stringAndExpected struct {
+		ref      RefString
+		expected string
+	}
+
+	slices := []sliceAndExpected{}
+	strs := []stringAndExpected{}
+
+	for i := range 2000 {
+		switch r.Intn(6) {
+		case 0:
+			length := r.Intn(100)
+			ref := AllocSlice[int32](cs, length, length)
+			value := ref.Value()
+			for j := range value {
+				value[j] = int32(i + j)
+			}
+			slices = append(slices, sliceAndExpected{ref, append([]int32{}, value...)})
+		case 1:
+			if len(slices) > 0 {
+				idx := r.Intn(len(slices))
+				slices[idx].ref = Append(cs, slices[idx].ref, int32(i))
+				slices[idx].expected = append(slices[idx].expected, int32(i))
+			}
+		case 2:
+			if len(slices) > 0 {
+				idx := r.Intn(len(slices))
+				require.Equal(t, slices[idx].expected, slices[idx].ref.Value())
+				FreeSlice(cs, slices[idx].ref)
+				slices = append(slices[:idx], slices[idx+1:]...)
+			}
+		case 3:
+			value := fmt.Sprintf("%d-%s", i, make([]byte, r.Intn(100)))
+			strs = append(strs, stringAndExpected{AllocStringFromString(cs, value), value})
+		case 4:
+			if len(strs) > 0 {
+				idx := r.Intn(len(strs))
+				value := fmt.Sprintf("+%d", i)
+				strs[idx].ref = AppendString(cs, strs[idx].ref, value)
+				strs[idx].expected += value
+			}
+		case 5:
+			if len(strs) > 0 {
+				idx := r.Intn(len(strs))
+				require.Equal(t, strs[idx].expected, strs[idx].ref.Value())
+				FreeString(cs, strs[idx].ref)
+				strs = append(strs[:idx], strs[idx+1:]...)
+			}
+		}
+	}
+
+	for _, s := range slices {
+		assert.Equal(t, s.expected, s.ref.Value())
+		FreeSlice(cs, s.ref)
+	}
+	for _, s := range strs {
+		assert.Equal(t, s.expected, s.ref.Value())
+		FreeString(cs, s.ref)
+	}
+
+	// Every allocation was freed in the same size class it was allocated in
+	for _, stats := range cs.Stats() {
+		assert.Equal(t, stats.Allocs, stats.Frees)
+	}
+
+	// A string builder also works with custom size classes
+	builder := NewStringBuilder(cs)
+	for i := range 1000 {
+		builder.WriteString(fmt.Sprintf("%d,", i))
+	}
+	expected := builder.String()
+	ref := builder.Build()
+	assert.Equal(t, expected, ref.Value())
+	FreeString(cs, ref)
+}
@@ -0,0 +1,67 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+package offheap
+
+// Pinning
+//
+// The memory of an allocation is never moved by the Go runtime, so a pointer
+// to an allocation can be passed to cgo or to a syscall. But the allocation
+// could be freed, reallocated by Append or AppendString, or moved by
+// compaction while that pointer is still in use. Pinning an allocation
+// guarantees that none of these happen until it is unpinned. Any attempt to
+// free or reallocate a pinned allocation panics, and compaction leaves pinned
+// allocations where they are.
+//
+// Pins are counted, so an allocation can be pinned by several users at once.
+// Each call to Pin must be matched by a call to Unpin. Pinning a view
+// created by SubSlice or SubString pins the whole allocation it shares.
+
+// Pins the object referenced by r. Panics if r has been freed.
+func (r *RefObject[T]) Pin() {
+	r.ref.Pin()
+}
+
+// Unpins the object referenced by r. Panics if r has been freed or is not
+// pinned.
+func (r *RefObject[T]) Unpin() {
+	r.ref.Unpin()
+}
+
+// Indicates whether the object referenced by r is pinned.
+func (r *RefObject[T]) IsPinned() bool {
+	return r.ref.IsPinned()
+}
+
+// Pins the slice referenced by r. Panics if r has been freed.
+func (r *RefSlice[T]) Pin() {
+	r.ref.Pin()
+}
+
+// Unpins the slice referenced by r. Panics if r has been freed or is not
+// pinned.
+func (r *RefSlice[T]) Unpin() {
+	r.ref.Unpin()
+}
+
+// Indicates whether the slice referenced by r is pinned.
+func (r *RefSlice[T]) IsPinned() bool {
+	return r.ref.IsPinned()
+}
+
+// Pins the string referenced by r. Panics if r has been freed.
+func (r *RefString) Pin() {
+	r.ref.Pin()
+}
+
+// Unpins the string referenced by r. Panics if r has been freed or is not
+// pinned.
+func (r *RefString) Unpin() {
+	r.ref.Unpin()
+}
+
+// Indicates whether the string referenced by r is pinned.
+func (r *RefString) IsPinned() bool {
+	return r.ref.IsPinned()
+}
@@ -0,0 +1,96 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+package offheap
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Demonstrate that pinned objects, slices and strings can't be freed or
+// appended to until they are unpinned
+func Test_Pin(t *testing.T) {
+	os := NewSized(1 << 8)
+	defer func() {
+		assert.NoError(t, os.Destroy())
+	}()
+
+	o := AllocObjectFrom(os, 1)
+	o.Pin()
+	assert.True(t, o.IsPinned())
+	assert.Panics(t, func() { FreeObject(os, o) })
+	o.Unpin()
+	assert.False(t, o.IsPinned())
+	FreeObject(os, o)
+
+	sl := AllocSlice[int](os, 1, 2)
+	sl.Pin()
+	assert.Panics(t, func() { Append(os, sl, 1) })
+	assert.Panics(t, func() { AppendSlice(os, sl, []int{1, 2, 3}) })
+	assert.Panics(t, func() { FreeSlice(os, sl) })
+
+	// Pinning a view pins the allocation it shares
+	view := SubSlice(os, sl, 0, 1)
+	view.Pin()
+	sl.Unpin()
+	assert.True(t, sl.IsPinned())
+	view.Unpin()
+	assert.False(t, sl.IsPinned())
+	sl = Append(os, sl, 1)
+	FreeSlice(os, sl)
+
+	str := AllocStringFromString(os, "pinned")
+	str.Pin()
+	assert.Panics(t, func() { AppendString(os, str, "!") })
+	assert.Panics(t, func() { FreeString(os, str) })
+	str.Unpin()
+	assert.Panics(t, func() { str.Unpin() })
+	str = AppendString(os, str, "!")
+	assert.Equal(t, "pinned!", str.Value())
+	FreeString(os, str)
+
+	// Nothing was leaked by the failed appends
+	for _, stats := range os.Stats() {
+		assert.Equal(t, 0, stats.Live)
+	}
+}
+
+// Demonstrate that compacting a TypedStore leaves pinned objects in place
+func Test_TypedStore_CompactPinned(t *testing.T) {
+	ts := NewTypedStoreSized[int64](1 << 8)
+	defer func() {
+		assert.NoError(t, ts.Destroy())
+	}()
+
+	refs := []RefObject[int64]{}
+	for i := range int(ts.AllocConfig().ObjectsPerSlab) * 4 {
+		r := ts.Alloc()
+		*r.Value() = int64(i)
+		refs = append(refs, r)
+	}
+	for _, r := range refs[:len(refs)-1] {
+		ts.Free(r)
+	}
+
+	pinned := refs[len(refs)-1]
+	pinned.Pin()
+	address := pinned.Value()
+
+	released := ts.Compact(func(_, _ RefObject[int64]) {
+		assert.Fail(t, "pinned object was moved")
+	})
+	assert.Equal(t, 0, released)
+	assert.Equal(t, address, pinned.Value())
+	assert.Equal(t, int64(len(refs)-1), *pinned.Value())
+
+	// Once unpinned the object can be moved, and the slabs released
+	pinned.Unpin()
+	released = ts.Compact(func(oldRef, newRef RefObject[int64]) {
+		pinned = newRef
+	})
+	assert.Equal(t, 3, released)
+	assert.Equal(t, int64(len(refs)-1), *pinned.Value())
+}
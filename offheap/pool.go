@@ -0,0 +1,152 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+package offheap
+
+import (
+	"sync"
+)
+
+// A Pool hands out objects of type T, allocated from a TypedStore. Objects
+// are taken from the pool with Acquire and returned with Release. Released
+// objects are kept by the pool and handed out again by later calls to
+// Acquire, rather than being freed to the TypedStore.
+//
+// This is similar to sync.Pool, except that idle objects are never discarded
+// by the garbage collector. They stay in the pool until Drain is called.
+//
+// Each newly allocated object is passed to the construct function, so that
+// its fields can be initialised. Each released object is passed to the reset
+// function, so that it is ready to be acquired again. Either function may be
+// nil.
+//
+// An object returned by Release must never be used again. Release
+// invalidates the reference, so a best effort is made to panic if an object
+// is used, or released, after it has been returned to the pool.
+//
+// A Pool is safe for concurrent use.
+type Pool[T any] struct {
+	store     *TypedStore[T]
+	construct func(*T)
+	reset     func(*T)
+
+	lock        sync.Mutex
+	idle        []RefObject[T]
+	outstanding int
+	acquires    int
+	releases    int
+	allocs      int
+}
+
+// The statistics for a Pool
+type PoolStats struct {
+	// The number of objects taken from the pool by Acquire
+	Acquires int
+	// The number of objects returned to the pool by Release
+	Releases int
+	// The number of objects allocated from the TypedStore, i.e. the number
+	// of calls to Acquire which could not reuse an idle object
+	Allocs int
+	// The number of objects which have been taken by Acquire, and not yet
+	// returned by Release
+	Outstanding int
+	// The number of objects held by the pool, waiting to be reused
+	Idle int
+}
+
+// Returns the fraction of calls to Acquire which reused an idle object,
+// between 0 and 1. If Acquire has never been called 0 is returned.
+func (s PoolStats) ReuseRate() float64 {
+	if s.Acquires == 0 {
+		return 0
+	}
+	return float64(s.Acquires-s.Allocs) / float64(s.Acquires)
+}
+
+// Returns a new Pool which allocates objects from store. construct is called
+// with every newly allocated object, and reset is called with every released
+// object. Either function may be nil.
+//
+// Like all offheap allocations, the fields of newly allocated objects are
+// arbitrary until they are set by construct.
+func NewPool[T any](store *TypedStore[T], construct, reset func(*T)) *Pool[T] {
+	return &Pool[T]{
+		store:     store,
+		construct: construct,
+		reset:     reset,
+	}
+}
+
+// Returns an object, and a pointer to it. An idle object is reused if there
+// is one, otherwise a new object is allocated from the TypedStore.
+func (p *Pool[T]) Acquire() (RefObject[T], *T) {
+	p.lock.Lock()
+
+	p.acquires++
+	p.outstanding++
+
+	if last := len(p.idle) - 1; last >= 0 {
+		r := p.idle[last]
+		p.idle = p.idle[:last]
+		p.lock.Unlock()
+		return r, r.Value()
+	}
+
+	p.allocs++
+	p.lock.Unlock()
+
+	r := p.store.Alloc()
+	value := r.Value()
+	if p.construct != nil {
+		p.construct(value)
+	}
+	return r, value
+}
+
+// Returns r to the pool. The object r must have been taken from this pool by
+// Acquire. After this call returns r must never be used again.
+func (p *Pool[T]) Release(r RefObject[T]) {
+	// Accessing the object panics if r has already been freed, or released
+	value := r.Value()
+	if p.reset != nil {
+		p.reset(value)
+	}
+	r = newRefObject[T](r.ref.Realloc())
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.releases++
+	p.outstanding--
+	p.idle = append(p.idle, r)
+}
+
+// Frees every idle object back to the TypedStore, returning the number of
+// objects freed. Outstanding objects are unaffected, and can still be
+// returned by Release.
+func (p *Pool[T]) Drain() int {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	drained := len(p.idle)
+	for _, r := range p.idle {
+		p.store.Free(r)
+	}
+	p.idle = nil
+	return drained
+}
+
+// Returns the statistics for this Pool
+func (p *Pool[T]) Stats() PoolStats {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	return PoolStats{
+		Acquires:    p.acquires,
+		Releases:    p.releases,
+		Allocs:      p.allocs,
+		Outstanding: p.outstanding,
+		Idle:        len(p.idle),
+	}
+}
@@ -0,0 +1,136 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+package offheap
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Demonstrate that objects released to a Pool are reset and reused by
+// Acquire, that new objects are constructed, and that the statistics track
+// reuse
+func Test_Pool_AcquireRelease(t *testing.T) {
+	ts := NewTypedStore[MutableStruct]()
+	defer func() {
+		assert.NoError(t, ts.Destroy())
+	}()
+
+	constructed := 0
+	p := NewPool(ts,
+		func(v *MutableStruct) {
+			constructed++
+			v.Field = -1
+		},
+		func(v *MutableStruct) {
+			v.Field = -2
+		})
+
+	refs := []RefObject[MutableStruct]{}
+	for i := range 10 {
+		r, v := p.Acquire()
+		assert.Equal(t, -1, v.Field)
+		assert.Equal(t, v, r.Value())
+		v.Field = i
+		refs = append(refs, r)
+	}
+	assert.Equal(t, 10, constructed)
+	assert.Equal(t, PoolStats{Acquires: 10, Allocs: 10, Outstanding: 10}, p.Stats())
+	assert.Equal(t, 0.0, p.Stats().ReuseRate())
+
+	for _, r := range refs {
+		p.Release(r)
+	}
+	assert.Equal(t, PoolStats{Acquires: 10, Releases: 10, Allocs: 10, Idle: 10}, p.Stats())
+
+	// Objects are reused, after being reset, without being constructed
+	for range 10 {
+		_, v := p.Acquire()
+		assert.Equal(t, -2, v.Field)
+	}
+	assert.Equal(t, 10, constructed)
+	assert.Equal(t, PoolStats{Acquires: 20, Releases: 10, Allocs: 10, Outstanding: 10}, p.Stats())
+	assert.Equal(t, 0.5, p.Stats().ReuseRate())
+	assert.Equal(t, 10, ts.Stats().Live)
+}
+
+// Demonstrate that an object can't be used, or released again, after it is
+// released
+func Test_Pool_ReleaseInvalidates(t *testing.T) {
+	ts := NewTypedStore[MutableStruct]()
+	defer func() {
+		assert.NoError(t, ts.Destroy())
+	}()
+
+	p := NewPool(ts, nil, nil)
+	r, _ := p.Acquire()
+	p.Release(r)
+
+	assert.Panics(t, func() { r.Value() })
+	assert.Panics(t, func() { p.Release(r) })
+}
+
+// Demonstrate that Drain frees idle objects, leaving outstanding objects
+// untouched
+func Test_Pool_Drain(t *testing.T) {
+	ts := NewTypedStore[MutableStruct]()
+	defer func() {
+		assert.NoError(t, ts.Destroy())
+	}()
+
+	p := NewPool(ts, nil, nil)
+	kept, _ := p.Acquire()
+	for range 5 {
+		r, _ := p.Acquire()
+		p.Release(r)
+	}
+	released, _ := p.Acquire()
+	p.Release(released)
+
+	assert.Equal(t, 1, p.Drain())
+	assert.Equal(t, 0, p.Stats().Idle)
+	assert.Equal(t, 1, ts.Stats().Live)
+
+	p.Release(kept)
+	assert.Equal(t, 1, p.Drain())
+	assert.Equal(t, 0, ts.Stats().Live)
+}
+
+// Demonstrate that a Pool can be used concurrently
+func Test_Pool_Concurrent(t *testing.T) {
+	ts := NewTypedStore[MutableStruct]()
+	defer func() {
+		assert.NoError(t, ts.Destroy())
+	}()
+
+	p := NewPool(ts, nil, func(v *MutableStruct) { v.Field = 0 })
+
+	const goroutines = 8
+	const perGoroutine = 1000
+
+	wg := sync.WaitGroup{}
+	wg.Add(goroutines)
+	for g := range goroutines {
+		go func() {
+			defer wg.Done()
+			for range perGoroutine {
+				r, v := p.Acquire()
+				v.Field = g
+				assert.Equal(t, g, r.Value().Field)
+				p.Release(r)
+			}
+		}()
+	}
+	wg.Wait()
+
+	stats := p.Stats()
+	assert.Equal(t, goroutines*perGoroutine, stats.Acquires)
+	assert.Equal(t, goroutines*perGoroutine, stats.Releases)
+	assert.Equal(t, 0, stats.Outstanding)
+	assert.LessOrEqual(t, stats.Allocs, goroutines)
+	assert.Equal(t, stats.Allocs, stats.Idle)
+}
@@ -0,0 +1,386 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+package offheap
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"sync"
+
+	"github.com/fmstephe/memorymanager/offheap/internal/pointerstore"
+)
+
+// The kind of operation recorded by an AllocEvent
+type EventKind uint8
+
+const (
+	// An allocation
+	EventAlloc EventKind = iota + 1
+	// A free
+	EventFree
+)
+
+func (k EventKind) String() string {
+	switch k {
+	case EventAlloc:
+		return "alloc"
+	case EventFree:
+		return "free"
+	default:
+		return fmt.Sprintf("EventKind(%d)", k)
+	}
+}
+
+// A single allocation or free, recorded by a Recording
+type AllocEvent struct {
+	Kind EventKind
+	// The index of the size class, see Store.SizeClasses
+	SizeClass int
+	// The pool of the Store which owns the allocation, see NewWithPools
+	Pool int
+	// The slot of the allocation within its size class and pool
+	Slot int
+	// The generation of the allocation
+	Gen uint8
+	// The number of bytes asked for, only recorded for allocations
+	Requested int
+	// The Cache the allocation was taken from, or freed into, see
+	// NewWithCaches. Always 0 for a Store without caches.
+	Cache int
+}
+
+// A Recording holds the sequence of every allocation and free made by a
+// Store, see Store.StartRecording. A Recording can be written to a compact
+// binary log with WriteTo, read back with ReadRecording, and replayed into a
+// new Store with Replay.
+//
+// When a use-after-free or double free is detected in production, replaying
+// the allocation history that led up to it reproduces the exact layout of the
+// Store, with every allocation in the same slot with the same generation.
+// This allows the misused slot to be traced back through every allocation
+// and free which touched it.
+type Recording struct {
+	// lock is held while each recorded operation is performed, so events
+	// are recorded in the same order as the operations took place
+	lock   sync.Mutex
+	events []AllocEvent
+}
+
+// Starts recording every allocation and free made by s, returning the new
+// Recording. If s was already recording, the previous Recording is stopped.
+//
+// To be replayed, a recording must be started before anything has been
+// allocated from s, see Replay.
+//
+// While recording, allocations and frees from s are serialised, so that the
+// order of the recorded events is exact. This, and the memory used to hold
+// the events, makes recording a debugging tool rather than something to
+// leave running permanently.
+func (s *Store) StartRecording() *Recording {
+	rec := &Recording{}
+	s.recording.Store(rec)
+	return rec
+}
+
+// Stops recording the allocations and frees made by s, returning the
+// Recording which was stopped, or nil if s was not recording.
+func (s *Store) StopRecording() *Recording {
+	return s.recording.Swap(nil)
+}
+
+// Returns the Recording currently recording the allocations and frees made
+// by s, or nil if s is not recording.
+func (s *Store) Recording() *Recording {
+	return s.recording.Load()
+}
+
+// Returns a copy of every event recorded, in the order they took place
+func (rec *Recording) Events() []AllocEvent {
+	rec.lock.Lock()
+	defer rec.lock.Unlock()
+
+	return slices.Clone(rec.events)
+}
+
+// Returns the recorded allocations which have not been freed, in the order
+// they were allocated. Allocations made while the Store was recording, and
+// still outstanding long after they should have been freed, are likely to be
+// leaks.
+//
+// Frees of allocations made before the recording started are ignored.
+// Reallocations, such as appending to a slice in place, are not recorded and
+// change an allocation's generation. So allocations are matched with their
+// frees by slot alone, ignoring the generation.
+func (rec *Recording) Outstanding() []AllocEvent {
+	rec.lock.Lock()
+	defer rec.lock.Unlock()
+
+	// The index, in events, of each allocation which has not been freed. A
+	// slot can only hold one allocation at a time, so the newest
+	// allocation in each slot replaces any earlier one.
+	live := map[AllocEvent]int{}
+	for i, event := range rec.events {
+		key := AllocEvent{SizeClass: event.SizeClass, Pool: event.Pool, Slot: event.Slot}
+		switch event.Kind {
+		case EventAlloc:
+			live[key] = i
+		case EventFree:
+			delete(live, key)
+		}
+	}
+
+	indexes := make([]int, 0, len(live))
+	for _, i := range live {
+		indexes = append(indexes, i)
+	}
+	slices.Sort(indexes)
+
+	outstanding := make([]AllocEvent, len(indexes))
+	for i, idx := range indexes {
+		outstanding[i] = rec.events[idx]
+	}
+	return outstanding
+}
+
+// Allocates from the size class idx of s, and records the allocation
+func (rec *Recording) recordAlloc(s *Store, idx int, requested int) pointerstore.RefPointer {
+	rec.lock.Lock()
+	defer rec.lock.Unlock()
+
+	token := s.localToken()
+	r := s.allocUnrecorded(token, idx, requested)
+	rec.events = append(rec.events, AllocEvent{
+		Kind:      EventAlloc,
+		SizeClass: idx,
+		Pool:      r.Pool(),
+		Slot:      r.Slot(),
+		Gen:       r.Gen(),
+		Requested: requested,
+		Cache:     token.cache,
+	})
+	return r
+}
+
+// Frees r from the size class idx of s, and records the free. Frees which
+// fail are not recorded.
+func (rec *Recording) recordFree(s *Store, idx int, r pointerstore.RefPointer) error {
+	rec.lock.Lock()
+	defer rec.lock.Unlock()
+
+	token := s.localToken()
+	if err := s.tryFreeUnrecorded(token, idx, r); err != nil {
+		return err
+	}
+	rec.events = append(rec.events, AllocEvent{
+		Kind:      EventFree,
+		SizeClass: idx,
+		Pool:      r.Pool(),
+		Slot:      r.Slot(),
+		Gen:       r.Gen(),
+		Cache:     token.cache,
+	})
+	return nil
+}
+
+// Identifies a recording log, followed by the version of the log's format
+const recordingMagic = "OHRC\x01"
+
+// Writes rec to w as a compact binary log, which can be read back by
+// ReadRecording. Returns the number of bytes written.
+func (rec *Recording) WriteTo(w io.Writer) (int64, error) {
+	rec.lock.Lock()
+	buf := []byte(recordingMagic)
+	for _, event := range rec.events {
+		buf = append(buf, byte(event.Kind))
+		buf = binary.AppendUvarint(buf, uint64(event.SizeClass))
+		buf = binary.AppendUvarint(buf, uint64(event.Pool))
+		buf = binary.AppendUvarint(buf, uint64(event.Slot))
+		buf = append(buf, event.Gen)
+		buf = binary.AppendUvarint(buf, uint64(event.Cache))
+		if event.Kind == EventAlloc {
+			buf = binary.AppendUvarint(buf, uint64(event.Requested))
+		}
+	}
+	rec.lock.Unlock()
+
+	n, err := w.Write(buf)
+	return int64(n), err
+}
+
+// Reads a Recording from r, which must contain a log written by
+// Recording.WriteTo.
+func ReadRecording(r io.Reader) (*Recording, error) {
+	br := bufio.NewReader(r)
+
+	magic := make([]byte, len(recordingMagic))
+	if _, err := io.ReadFull(br, magic); err != nil {
+		return nil, fmt.Errorf("cannot read recording header: %w", err)
+	}
+	if string(magic) != recordingMagic {
+		return nil, fmt.Errorf("not a recording, found header %q", magic)
+	}
+
+	rec := &Recording{}
+	for {
+		kind, err := br.ReadByte()
+		if errors.Is(err, io.EOF) {
+			return rec, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		event, err := readEvent(br, EventKind(kind))
+		if err != nil {
+			return nil, fmt.Errorf("cannot read event %d: %w", len(rec.events), err)
+		}
+		rec.events = append(rec.events, event)
+	}
+}
+
+// Reads the fields of a single event of kind from br
+func readEvent(br *bufio.Reader, kind EventKind) (AllocEvent, error) {
+	if kind != EventAlloc && kind != EventFree {
+		return AllocEvent{}, fmt.Errorf("unknown event kind %d", kind)
+	}
+
+	var fields [3]uint64
+	for i := range fields {
+		field, err := binary.ReadUvarint(br)
+		if err != nil {
+			return AllocEvent{}, unexpectedEOF(err)
+		}
+		fields[i] = field
+	}
+	gen, err := br.ReadByte()
+	if err != nil {
+		return AllocEvent{}, unexpectedEOF(err)
+	}
+	cache, err := binary.ReadUvarint(br)
+	if err != nil {
+		return AllocEvent{}, unexpectedEOF(err)
+	}
+
+	event := AllocEvent{
+		Kind:      kind,
+		SizeClass: int(fields[0]),
+		Pool:      int(fields[1]),
+		Slot:      int(fields[2]),
+		Gen:       gen,
+		Cache:     int(cache),
+	}
+	if kind == EventAlloc {
+		requested, err := binary.ReadUvarint(br)
+		if err != nil {
+			return AllocEvent{}, unexpectedEOF(err)
+		}
+		event.Requested = int(requested)
+	}
+	return event, nil
+}
+
+// An event ends part way through if the log runs out
+func unexpectedEOF(err error) error {
+	if errors.Is(err, io.EOF) {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// Returned by Replay when the replayed Store diverges from the recording
+type ReplayError struct {
+	// The position of the event in the recording
+	Index int
+	// The event which could not be replayed
+	Event AllocEvent
+	// Describes how the replay diverged
+	Reason string
+}
+
+func (e *ReplayError) Error() string {
+	return fmt.Sprintf("cannot replay event %d, %s in size class %d (pool %d, slot %d, generation %d): %s",
+		e.Index, e.Event.Kind, e.Event.SizeClass, e.Event.Pool, e.Event.Slot, e.Event.Gen, e.Reason)
+}
+
+// Identifies a live allocation during a replay
+type replaySlot struct {
+	pool int
+	idx  int
+	slot int
+}
+
+// Replays every allocation and free in rec into s, reproducing the layout of
+// the recorded Store. s must be a new, empty, Store created in the same way
+// as the recorded Store, e.g. with the same slab size and size classes. After
+// Replay returns every allocation which was live at the end of the recording
+// is allocated in the same slot of s, with the same generation. Live
+// allocations can be found with ForEachObject, or with ResolveObjectHandle.
+// The contents of allocations are not recorded, so the contents of every
+// allocation in s are arbitrary.
+//
+// Reallocations, such as appending to a slice in place, increment an
+// allocation's generation without being recorded. These are reproduced when
+// the allocation is freed. Allocations moved by Compact are not recorded, so
+// a recording which spans a call to Compact can't be replayed.
+//
+// A Store with caches, see NewWithCaches, is replayed through the same
+// caches as the recorded Store, so s must have at least as many caches as the
+// recorded Store. The number of caches is set by GOMAXPROCS.
+//
+// If s diverges from the recording a *ReplayError is returned.
+func Replay(s *Store, rec *Recording) error {
+	pools := s.allPools()
+	live := map[replaySlot]pointerstore.RefPointer{}
+
+	for i, event := range rec.Events() {
+		if event.Pool >= len(pools) || event.SizeClass >= len(s.sizedStores) {
+			return &ReplayError{Index: i, Event: event, Reason: "size class or pool doesn't exist"}
+		}
+		if event.Cache >= max(s.caches, 1) {
+			return &ReplayError{Index: i, Event: event, Reason: "cache doesn't exist"}
+		}
+		idx := s.storeIndex(event.SizeClass)
+		key := replaySlot{pool: event.Pool, idx: idx, slot: event.Slot}
+		token := poolToken{pool: event.Pool, cache: event.Cache}
+
+		switch event.Kind {
+		case EventAlloc:
+			r := s.allocUnrecorded(token, event.SizeClass, event.Requested)
+			if r.Slot() != event.Slot || r.Gen() != event.Gen {
+				return &ReplayError{
+					Index:  i,
+					Event:  event,
+					Reason: fmt.Sprintf("allocated slot %d with generation %d", r.Slot(), r.Gen()),
+				}
+			}
+			live[key] = r
+
+		case EventFree:
+			r, ok := live[key]
+			if !ok {
+				return &ReplayError{Index: i, Event: event, Reason: "slot is not allocated"}
+			}
+			// Catch up with any unrecorded reallocations
+			for range 256 {
+				if r.Gen() == event.Gen {
+					break
+				}
+				r = r.Realloc()
+			}
+			if err := s.tryFreeUnrecorded(token, event.SizeClass, r); err != nil {
+				return &ReplayError{Index: i, Event: event, Reason: err.Error()}
+			}
+			delete(live, key)
+
+		default:
+			return &ReplayError{Index: i, Event: event, Reason: "unknown event kind"}
+		}
+	}
+	return nil
+}
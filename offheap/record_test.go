@@ -0,0 +1,315 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+package offheap
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Makes a random mix of allocations, appends and frees in s, returning the
+// objects which are still live
+func randomAllocations(s *Store, r *rand.Rand) []RefObject[MutableStruct] {
+	objects := []RefObject[MutableStruct]{}
+	slices := []RefSlice[int]{}
+	strs := []RefString{}
+
+	for range 2000 {
+		switch r.Intn(6) {
+		case 0:
+			objects = append(objects, AllocObject[MutableStruct](s))
+		case 1:
+			slices = append(slices, AllocSlice[int](s, 1, r.Intn(10)+1))
+		case 2:
+			strs = append(strs, ConcatStrings(s, "string-", fmt.Sprint(r.Intn(1000))))
+		case 3:
+			if len(slices) > 0 {
+				i := r.Intn(len(slices))
+				slices[i] = Append(s, slices[i], 1)
+			}
+		case 4:
+			if len(objects) > 0 {
+				i := r.Intn(len(objects))
+				FreeObject(s, objects[i])
+				objects = append(objects[:i], objects[i+1:]...)
+			}
+		case 5:
+			if len(slices) > 0 {
+				i := r.Intn(len(slices))
+				FreeSlice(s, slices[i])
+				slices = append(slices[:i], slices[i+1:]...)
+			}
+			if len(strs) > 0 {
+				i := r.Intn(len(strs))
+				FreeString(s, strs[i])
+				strs = append(strs[:i], strs[i+1:]...)
+			}
+		}
+	}
+	return objects
+}
+
+// Demonstrate that a recording can be written, read back and replayed into a
+// new Store, reproducing the exact layout of the recorded Store
+func Test_Recording_Replay(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		newStore func() *Store
+	}{
+		{"default", func() *Store { return NewSized(1 << 10) }},
+		{"size classes", func() *Store { return NewWithSizeClasses(1<<10, GeometricSizeClasses(1.5, 256)) }},
+		{"padded", func() *Store { return NewWithCacheLinePadding(1 << 10) }},
+		{"caches", func() *Store { return NewWithCaches(1<<10, 8) }},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			s := tc.newStore()
+			defer func() {
+				assert.NoError(t, s.Destroy())
+			}()
+
+			rec := s.StartRecording()
+			objects := randomAllocations(s, rand.New(rand.NewSource(1)))
+			assert.Equal(t, rec, s.StopRecording())
+			assert.Nil(t, s.StopRecording())
+
+			// Allocations after the recording is stopped are not
+			// recorded
+			events := len(rec.Events())
+			AllocObject[MutableStruct](s)
+			assert.Equal(t, events, len(rec.Events()))
+
+			buf := &bytes.Buffer{}
+			n, err := rec.WriteTo(buf)
+			require.NoError(t, err)
+			assert.Equal(t, int64(buf.Len()), n)
+
+			read, err := ReadRecording(buf)
+			require.NoError(t, err)
+			assert.Equal(t, rec.Events(), read.Events())
+
+			replayed := tc.newStore()
+			defer func() {
+				assert.NoError(t, replayed.Destroy())
+			}()
+			require.NoError(t, Replay(replayed, read))
+
+			// Every live object is in the same slot, with the same
+			// generation, in the replayed Store
+			for _, o := range objects {
+				_, ok := ResolveObjectHandle[MutableStruct](replayed, o.Handle())
+				assert.True(t, ok)
+			}
+
+			// Undo the allocation made after recording stopped
+			stats := s.Stats()
+			objectStats := &stats[s.storeIndex(typeIndex[MutableStruct](s))]
+			objectStats.Allocs--
+			objectStats.Live--
+			replayedStats := replayed.Stats()
+			for i := range stats {
+				assert.Equal(t, stats[i].Allocs, replayedStats[i].Allocs)
+				assert.Equal(t, stats[i].Frees, replayedStats[i].Frees)
+				assert.Equal(t, stats[i].Live, replayedStats[i].Live)
+			}
+		})
+	}
+}
+
+// Demonstrate that a recording of a Store with caches, made by many
+// goroutines allocating from different caches, can be replayed
+func Test_Recording_ReplayCaches(t *testing.T) {
+	s := NewWithCaches(1<<10, 4)
+	defer func() {
+		assert.NoError(t, s.Destroy())
+	}()
+
+	rec := s.StartRecording()
+	results := make([][]RefObject[MutableStruct], 8)
+	wg := sync.WaitGroup{}
+	for i := range results {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = randomAllocations(s, rand.New(rand.NewSource(int64(i))))
+		}()
+	}
+	wg.Wait()
+	s.StopRecording()
+
+	buf := &bytes.Buffer{}
+	_, err := rec.WriteTo(buf)
+	require.NoError(t, err)
+	read, err := ReadRecording(buf)
+	require.NoError(t, err)
+	assert.Equal(t, rec.Events(), read.Events())
+
+	replayed := NewWithCaches(1<<10, 4)
+	defer func() {
+		assert.NoError(t, replayed.Destroy())
+	}()
+	require.NoError(t, Replay(replayed, read))
+
+	for _, objects := range results {
+		for _, o := range objects {
+			_, ok := ResolveObjectHandle[MutableStruct](replayed, o.Handle())
+			assert.True(t, ok)
+		}
+	}
+
+	// A Store without caches can't replay events from a cache it doesn't
+	// have
+	bad := &Recording{events: []AllocEvent{{Kind: EventAlloc, SizeClass: 3, Cache: 1}}}
+	var replayErr *ReplayError
+	require.True(t, errors.As(Replay(New(), bad), &replayErr))
+	assert.Equal(t, "cache doesn't exist", replayErr.Reason)
+}
+
+// Demonstrate that a recording shows the history of a slot which was used
+// after it was freed
+func Test_Recording_UseAfterFree(t *testing.T) {
+	s := New()
+	defer func() {
+		assert.NoError(t, s.Destroy())
+	}()
+
+	rec := s.StartRecording()
+	r := AllocObject[MutableStruct](s)
+	FreeObject(s, r)
+	AllocObject[MutableStruct](s)
+	assert.Panics(t, func() { FreeObject(s, r) })
+
+	// The failed free is not recorded
+	idx := typeIndex[MutableStruct](s)
+	assert.Equal(t, []AllocEvent{
+		{Kind: EventAlloc, SizeClass: idx, Slot: 0, Gen: r.ref.Gen(), Requested: rawSizeForType[MutableStruct]()},
+		{Kind: EventFree, SizeClass: idx, Slot: 0, Gen: r.ref.Gen()},
+		{Kind: EventAlloc, SizeClass: idx, Slot: 0, Gen: r.ref.Gen() + 1, Requested: rawSizeForType[MutableStruct]()},
+	}, rec.Events())
+}
+
+// Demonstrate that the outstanding allocations of a recording are those which
+// were recorded and not freed, in the order they were allocated
+func Test_Recording_Outstanding(t *testing.T) {
+	s := New()
+	defer func() {
+		assert.NoError(t, s.Destroy())
+	}()
+
+	before := AllocObject[MutableStruct](s)
+	assert.Nil(t, s.Recording())
+
+	rec := s.StartRecording()
+	assert.Equal(t, rec, s.Recording())
+	assert.Empty(t, rec.Outstanding())
+
+	first := AllocObject[MutableStruct](s)
+	second := AllocObject[MutableStruct](s)
+	str := AllocStringFromString(s, "leaked")
+	FreeObject(s, first)
+	// The slot is reused, the new allocation is outstanding
+	reused := AllocObject[MutableStruct](s)
+	// Freeing an allocation made before recording is ignored
+	FreeObject(s, before)
+
+	outstanding := rec.Outstanding()
+	require.Len(t, outstanding, 3)
+	assert.Equal(t, second.ref.Slot(), outstanding[0].Slot)
+	assert.Equal(t, str.ref.Slot(), outstanding[1].Slot)
+	assert.Equal(t, s.sizeIndex(len("leaked")), outstanding[1].SizeClass)
+	assert.Equal(t, reused.ref.Slot(), outstanding[2].Slot)
+	assert.Equal(t, reused.ref.Gen(), outstanding[2].Gen)
+
+	s.StopRecording()
+	assert.Nil(t, s.Recording())
+}
+
+// Show that an allocation which was reallocated in place, by appending, and
+// then freed is not outstanding
+func Test_Recording_Outstanding_Append(t *testing.T) {
+	s := New()
+	defer func() {
+		assert.NoError(t, s.Destroy())
+	}()
+
+	rec := s.StartRecording()
+	slice := AllocSlice[int](s, 1, 4)
+	slice = Append(s, slice, 2)
+	FreeSlice(s, slice)
+
+	str := AllocStringFromString(s, "abc")
+	str = AppendString(s, str, "d")
+	FreeString(s, str)
+
+	assert.Empty(t, rec.Outstanding())
+}
+
+// Demonstrate that Replay reports a recording which doesn't match the Store
+// being replayed into
+func Test_Recording_ReplayDiverges(t *testing.T) {
+	s := New()
+	defer func() {
+		assert.NoError(t, s.Destroy())
+	}()
+
+	rec := s.StartRecording()
+	FreeObject(s, AllocObject[MutableStruct](s))
+	s.StopRecording()
+
+	// The replayed Store isn't empty
+	replayed := New()
+	defer func() {
+		assert.NoError(t, replayed.Destroy())
+	}()
+	AllocObject[MutableStruct](replayed)
+
+	err := Replay(replayed, rec)
+	var replayErr *ReplayError
+	require.True(t, errors.As(err, &replayErr))
+	assert.Equal(t, 0, replayErr.Index)
+	assert.Equal(t, EventAlloc, replayErr.Event.Kind)
+
+	// A free of a slot which was never allocated
+	bad := &Recording{events: []AllocEvent{{Kind: EventFree, SizeClass: 3, Slot: 7}}}
+	require.True(t, errors.As(Replay(New(), bad), &replayErr))
+	assert.Equal(t, "slot is not allocated", replayErr.Reason)
+}
+
+// Demonstrate that ReadRecording rejects logs which weren't written by
+// Recording.WriteTo, or which are truncated
+func Test_Recording_ReadErrors(t *testing.T) {
+	_, err := ReadRecording(bytes.NewReader([]byte("not a recording")))
+	assert.Error(t, err)
+
+	_, err = ReadRecording(bytes.NewReader(nil))
+	assert.Error(t, err)
+
+	s := New()
+	defer func() {
+		assert.NoError(t, s.Destroy())
+	}()
+	rec := s.StartRecording()
+	AllocSlice[byte](s, 1000, 1000)
+	s.StopRecording()
+
+	buf := &bytes.Buffer{}
+	_, err = rec.WriteTo(buf)
+	require.NoError(t, err)
+
+	_, err = ReadRecording(bytes.NewReader(buf.Bytes()[:buf.Len()-1]))
+	assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+
+	corrupt := bytes.Clone(buf.Bytes())
+	corrupt[len(recordingMagic)] = 99
+	_, err = ReadRecording(bytes.NewReader(corrupt))
+	assert.Error(t, err)
+}
@@ -0,0 +1,144 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+package offheap
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/fmstephe/memorymanager/offheap/internal/pointerstore"
+)
+
+// Writes a human readable breakdown of the memory used by this Store to w.
+//
+// There is one row for each size class which has been allocated from, and a
+// final row with the totals for the Store. The columns are
+//
+//	size       - the allocation size of the size class
+//	live       - the number of live allocations
+//	slabs      - the number of slabs mapped
+//	mapped     - the number of bytes mapped, including metadata
+//	live-bytes - the number of bytes used by live allocations
+//	util%      - live bytes as a percentage of the mapped object space
+//	frag%      - freed slots, waiting to be reused, as a percentage of all
+//	             slots which have been allocated from
+//	waste%     - the bytes wasted by rounding allocations up to the size of
+//	             their size class, as a percentage of all bytes allocated
+//
+// A high frag% indicates that many allocations have been freed, but their
+// slots are scattered among live allocations and their slabs can't be
+// released.
+//
+// Allocations are not tracked by call site, so no allocation profile is
+// produced.
+func (s *Store) WriteReport(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', tabwriter.AlignRight)
+
+	if _, err := fmt.Fprintln(tw, "size\tlive\tslabs\tmapped\tlive-bytes\tutil%\tfrag%\twaste%\t"); err != nil {
+		return err
+	}
+
+	for _, report := range s.Report() {
+		if err := writeReportRow(tw, fmt.Sprintf("%d", report.Size), report); err != nil {
+			return err
+		}
+	}
+
+	if err := writeReportRow(tw, "total", newClassReport(0, s.TotalStats())); err != nil {
+		return err
+	}
+
+	return tw.Flush()
+}
+
+// A single row of the report written by WriteReport, describing the memory
+// used by one size class
+type ClassReport struct {
+	// The allocation size of the size class
+	Size        int
+	Live        int
+	Slabs       int
+	MappedBytes int
+	LiveBytes   int
+	// Live bytes as a percentage of the mapped object space
+	Utilization float64
+	// Freed slots, waiting to be reused, as a percentage of all slots which
+	// have been allocated from
+	Fragmentation float64
+	// The bytes wasted by rounding allocations up to the size of their size
+	// class, as a percentage of all bytes allocated
+	Waste float64
+}
+
+// Returns a ClassReport for each size class which has been allocated from,
+// in increasing order of size. These are the rows written by WriteReport,
+// without the totals.
+func (s *Store) Report() []ClassReport {
+	reports := []ClassReport{}
+	sizes := s.SizeClasses()
+	for i, stats := range s.Stats() {
+		if stats.Allocs == 0 && stats.Slabs == 0 {
+			continue
+		}
+		reports = append(reports, newClassReport(sizes[i], stats))
+	}
+	return reports
+}
+
+func newClassReport(size int, stats pointerstore.Stats) ClassReport {
+	return ClassReport{
+		Size:          size,
+		Live:          stats.Live,
+		Slabs:         stats.Slabs,
+		MappedBytes:   stats.MappedBytes,
+		LiveBytes:     stats.LiveBytes,
+		Utilization:   utilization(stats),
+		Fragmentation: fragmentation(stats),
+		Waste:         roundingWaste(stats),
+	}
+}
+
+func writeReportRow(w io.Writer, label string, report ClassReport) error {
+	_, err := fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%d\t%.1f\t%.1f\t%.1f\t\n",
+		label,
+		report.Live,
+		report.Slabs,
+		report.MappedBytes,
+		report.LiveBytes,
+		report.Utilization,
+		report.Fragmentation,
+		report.Waste)
+	return err
+}
+
+// Returns the percentage of mapped object space used by live allocations
+func utilization(stats pointerstore.Stats) float64 {
+	objectBytes := stats.LiveBytes + stats.FreeBytes
+	if objectBytes == 0 {
+		return 0
+	}
+	return 100 * float64(stats.LiveBytes) / float64(objectBytes)
+}
+
+// Returns the percentage of slots, which have ever been allocated, which are
+// currently free and waiting to be reused
+func fragmentation(stats pointerstore.Stats) float64 {
+	freeSlots := stats.Frees - stats.Reused
+	usedSlots := stats.Live + freeSlots
+	if usedSlots == 0 {
+		return 0
+	}
+	return 100 * float64(freeSlots) / float64(usedSlots)
+}
+
+// Returns the percentage of allocated bytes which were not requested, and
+// were wasted by rounding allocations up to the size of their size class
+func roundingWaste(stats pointerstore.Stats) float64 {
+	if stats.AllocatedBytes == 0 {
+		return 0
+	}
+	return 100 * float64(stats.AllocatedBytes-stats.RequestedBytes) / float64(stats.AllocatedBytes)
+}
@@ -0,0 +1,94 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+package offheap
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Demonstrate that the report contains a row for each size class in use, and
+// a row of totals
+func TestWriteReport(t *testing.T) {
+	os := NewSized(1 << 8)
+	defer func() {
+		assert.NoError(t, os.Destroy())
+	}()
+
+	refs := []RefObject[int64]{}
+	for range 32 {
+		refs = append(refs, AllocObject[int64](os))
+	}
+	// Free every other allocation, leaving half the slots as holes
+	for i := 0; i < len(refs); i += 2 {
+		FreeObject(os, refs[i])
+	}
+	AllocStringFromString(os, "sixteen bytes!!!")
+
+	sb := &strings.Builder{}
+	require.NoError(t, os.WriteReport(sb))
+
+	lines := strings.Split(strings.TrimRight(sb.String(), "\n"), "\n")
+	require.Len(t, lines, 4)
+	assert.Equal(t, []string{"size", "live", "slabs", "mapped", "live-bytes", "util%", "frag%", "waste%"}, strings.Fields(lines[0]))
+
+	conf := ConfForType[int64](os)
+	int64Row := strings.Fields(lines[1])
+	assert.Equal(t, "8", int64Row[0])
+	assert.Equal(t, "16", int64Row[1])
+	assert.Equal(t, "1", int64Row[2])
+	assert.Equal(t, "128", int64Row[4])
+	assert.Equal(t, "50.0", int64Row[6])
+	assert.Equal(t, "0.0", int64Row[7])
+	assert.Equal(t, int(conf.TotalSlabSize), os.Stats()[3].MappedBytes)
+
+	stringRow := strings.Fields(lines[2])
+	assert.Equal(t, "16", stringRow[0])
+	assert.Equal(t, "1", stringRow[1])
+	assert.Equal(t, "0.0", stringRow[6])
+
+	totalRow := strings.Fields(lines[3])
+	assert.Equal(t, "total", totalRow[0])
+	assert.Equal(t, "17", totalRow[1])
+	assert.Equal(t, "2", totalRow[2])
+	assert.Equal(t, "144", totalRow[4])
+}
+
+// Show that Report returns the same rows as WriteReport, for the size classes
+// in use
+func TestReport(t *testing.T) {
+	os := NewSized(1 << 8)
+	defer func() {
+		assert.NoError(t, os.Destroy())
+	}()
+
+	assert.Empty(t, os.Report())
+
+	refs := []RefObject[int64]{}
+	for range 32 {
+		refs = append(refs, AllocObject[int64](os))
+	}
+	for i := 0; i < len(refs); i += 2 {
+		FreeObject(os, refs[i])
+	}
+	AllocStringFromString(os, "sixteen bytes!!!")
+
+	reports := os.Report()
+	require.Len(t, reports, 2)
+
+	assert.Equal(t, 8, reports[0].Size)
+	assert.Equal(t, 16, reports[0].Live)
+	assert.Equal(t, 1, reports[0].Slabs)
+	assert.Equal(t, 128, reports[0].LiveBytes)
+	assert.Equal(t, 50.0, reports[0].Fragmentation)
+	assert.Equal(t, 0.0, reports[0].Waste)
+
+	assert.Equal(t, 16, reports[1].Size)
+	assert.Equal(t, 1, reports[1].Live)
+	assert.Equal(t, 0.0, reports[1].Fragmentation)
+}
@@ -0,0 +1,212 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+package offheap
+
+import (
+	"fmt"
+	"unsafe"
+
+	"github.com/fmstephe/memorymanager/offheap/internal/pointerstore"
+)
+
+// A Scratch is an allocator for temporary data, such as data which only lives
+// for the duration of a single request or frame. Allocations are made by
+// bumping an offset through large chunks, which are allocated from a Store.
+// This makes each allocation very cheap. Allocations are never freed
+// individually, instead Reset recycles every allocation at once.
+//
+// Allocations made by a Scratch are ordinary RefObject, RefSlice and
+// RefString values, but they must never be freed. Slices and strings are
+// views, like those created by SubSlice and SubString, so FreeSlice,
+// FreeString and Append will panic. Calling FreeObject on an object
+// allocated by a Scratch has unpredictable behaviour.
+//
+// Reset invalidates every allocation made by the Scratch. A best effort has
+// been made to panic if an allocation is used after a Reset, just like any
+// other freed allocation.
+//
+// A Scratch is not safe for concurrent use.
+type Scratch struct {
+	store     *Store
+	chunkSize int
+
+	// Every chunk allocated by this Scratch. Chunks before current are
+	// full, chunks after current are unused since the last Reset
+	chunks  []RefSlice[byte]
+	current int
+	offset  int
+
+	// Allocations too large to fit in a chunk are given a dedicated chunk
+	// of their own, which is freed by Reset
+	large []RefSlice[byte]
+
+	allocs    int
+	usedBytes int
+	resets    int
+}
+
+// The statistics for a Scratch
+type ScratchStats struct {
+	// The number of chunks held by the Scratch, including dedicated chunks
+	// for large allocations
+	Chunks int
+	// The total size, in bytes, of the chunks held by the Scratch
+	ChunkBytes int
+	// The number of allocations since the last Reset
+	Allocs int
+	// The number of bytes allocated since the last Reset
+	UsedBytes int
+	// The number of times the Scratch has been Reset
+	Resets int
+}
+
+// Returns a new Scratch which allocates chunks of chunkSize bytes from s.
+// Allocations larger than chunkSize are each given their own chunk.
+func NewScratch(s *Store, chunkSize int) *Scratch {
+	if chunkSize < 1 {
+		panic(fmt.Errorf("chunk size (%d) must be at least 1", chunkSize))
+	}
+
+	return &Scratch{
+		store:     s,
+		chunkSize: chunkSize,
+	}
+}
+
+// Allocates an object of type T from sc. The type T must not contain any
+// pointers in any part of its type. If the type T is found to contain
+// pointers this function will panic.
+//
+// The values of fields in the newly allocated object will be arbitrary. The
+// object must never be freed, see Scratch.Reset.
+func ScratchObject[T any](sc *Scratch) RefObject[T] {
+	info := typeInfoFor[T]()
+	if err := info.pointerErr; err != nil {
+		panic(fmt.Errorf("cannot allocate generic type containing pointers %w", err))
+	}
+
+	var zero T
+	return newRefObject[T](sc.alloc(info.rawSize, int(unsafe.Alignof(zero))))
+}
+
+// Allocates a slice of type T, with length and capacity, from sc. The type T
+// must not contain any pointers in any part of its type. If the type T is
+// found to contain pointers this function will panic.
+//
+// The values of the elements in the newly allocated slice will be arbitrary.
+// The slice is a view, it must never be freed or appended to, see
+// Scratch.Reset.
+func ScratchSlice[T any](sc *Scratch, length, capacity int) RefSlice[T] {
+	info := typeInfoFor[T]()
+	if err := info.pointerErr; err != nil {
+		panic(fmt.Errorf("cannot allocate generic type containing pointers %w", err))
+	}
+	if length < 0 || capacity < length {
+		panic(fmt.Errorf("length (%d) and capacity (%d) out of range", length, capacity))
+	}
+
+	var zero T
+	return RefSlice[T]{
+		length:   length,
+		capacity: capacity,
+		view:     true,
+		ref:      sc.alloc(info.rawSize*capacity, int(unsafe.Alignof(zero))),
+	}
+}
+
+// Allocates a copy of str from sc. The string is a view, it must never be
+// freed or appended to, see Scratch.Reset.
+func ScratchString(sc *Scratch, str string) RefString {
+	ref := sc.alloc(len(str), 1)
+	copy(ref.Bytes(len(str)), str)
+	return RefString{
+		length: len(str),
+		view:   true,
+		ref:    ref,
+	}
+}
+
+// Recycles every allocation made by sc. After this call returns every
+// allocation made by sc before the Reset must never be used again.
+//
+// Chunks are kept, and reused by later allocations, except for the dedicated
+// chunks of large allocations which are freed. Because generations are only 8
+// bits wide, detecting the use of an allocation after a Reset is best
+// effort.
+//
+// Reset panics if any allocation made by sc is pinned.
+func (sc *Scratch) Reset() {
+	for i := range sc.chunks {
+		chunk := &sc.chunks[i]
+		chunk.ref = chunk.ref.Realloc()
+	}
+	for _, chunk := range sc.large {
+		FreeSlice(sc.store, chunk)
+	}
+
+	sc.large = nil
+	sc.current = 0
+	sc.offset = 0
+	sc.allocs = 0
+	sc.usedBytes = 0
+	sc.resets++
+}
+
+// Frees every chunk held by sc back to its Store. After this call returns
+// every allocation made by sc must never be used again. The Scratch itself
+// can still be used, new chunks will be allocated as needed.
+func (sc *Scratch) Free() {
+	sc.Reset()
+	for _, chunk := range sc.chunks {
+		FreeSlice(sc.store, chunk)
+	}
+	sc.chunks = nil
+}
+
+// Returns the statistics for this Scratch
+func (sc *Scratch) Stats() ScratchStats {
+	chunkBytes := len(sc.chunks) * sc.chunkSize
+	for _, chunk := range sc.large {
+		chunkBytes += chunk.length
+	}
+
+	return ScratchStats{
+		Chunks:     len(sc.chunks) + len(sc.large),
+		ChunkBytes: chunkBytes,
+		Allocs:     sc.allocs,
+		UsedBytes:  sc.usedBytes,
+		Resets:     sc.resets,
+	}
+}
+
+// Returns a reference to size bytes, aligned to align, within one of sc's
+// chunks. Chunks are at least 8 byte aligned, see the Alignment section of
+// the package documentation, so aligning the offset within a chunk aligns
+// the allocation for any Go type.
+func (sc *Scratch) alloc(size, align int) pointerstore.RefPointer {
+	sc.allocs++
+	sc.usedBytes += size
+
+	if size > sc.chunkSize {
+		chunk := AllocSlice[byte](sc.store, size, size)
+		sc.large = append(sc.large, chunk)
+		return chunk.ref
+	}
+
+	for {
+		if sc.current == len(sc.chunks) {
+			sc.chunks = append(sc.chunks, AllocSlice[byte](sc.store, sc.chunkSize, sc.chunkSize))
+		}
+
+		start := (sc.offset + align - 1) &^ (align - 1)
+		if start+size <= sc.chunkSize {
+			sc.offset = start + size
+			return sc.chunks[sc.current].ref.Offset(start)
+		}
+
+		sc.current++
+		sc.offset = 0
+	}
+}
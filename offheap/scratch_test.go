@@ -0,0 +1,128 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+package offheap
+
+import (
+	"fmt"
+	"testing"
+	"unsafe"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Demonstrate that objects, slices and strings can be allocated from a
+// Scratch, are naturally aligned, and don't overwrite each other
+func Test_Scratch_Alloc(t *testing.T) {
+	s := New()
+	defer func() {
+		assert.NoError(t, s.Destroy())
+	}()
+
+	sc := NewScratch(s, 1<<10)
+
+	objects := []RefObject[MutableStruct]{}
+	slices := []RefSlice[uint32]{}
+	strs := []RefString{}
+	for i := range 200 {
+		o := ScratchObject[MutableStruct](sc)
+		o.Value().Field = i
+		assert.Zero(t, uintptr(unsafe.Pointer(o.Value()))%unsafe.Alignof(MutableStruct{}))
+		objects = append(objects, o)
+
+		str := ScratchString(sc, fmt.Sprint(i))
+		strs = append(strs, str)
+
+		sl := ScratchSlice[uint32](sc, i%7, 7)
+		for j := range sl.Value() {
+			sl.Value()[j] = uint32(i)
+		}
+		require.Len(t, sl.Value(), i%7)
+		require.Equal(t, 7, cap(sl.Value()))
+		slices = append(slices, sl)
+	}
+
+	for i := range 200 {
+		assert.Equal(t, i, objects[i].Value().Field)
+		assert.Equal(t, fmt.Sprint(i), strs[i].Value())
+		for _, v := range slices[i].Value() {
+			assert.Equal(t, uint32(i), v)
+		}
+	}
+
+	stats := sc.Stats()
+	assert.Equal(t, 600, stats.Allocs)
+	assert.Greater(t, stats.Chunks, 1)
+	assert.Equal(t, stats.Chunks*(1<<10), stats.ChunkBytes)
+	assert.LessOrEqual(t, stats.UsedBytes, stats.ChunkBytes)
+
+	// Only the chunks are allocated from the Store
+	assert.Equal(t, stats.Chunks, s.TotalStats().Live)
+
+	sc.Free()
+	assert.Equal(t, 0, s.TotalStats().Live)
+}
+
+// Demonstrate that Reset invalidates every allocation, and that chunks are
+// reused after a Reset
+func Test_Scratch_Reset(t *testing.T) {
+	s := New()
+	defer func() {
+		assert.NoError(t, s.Destroy())
+	}()
+
+	sc := NewScratch(s, 1<<8)
+	for range 100 {
+		ScratchObject[int64](sc)
+	}
+	o := ScratchObject[int64](sc)
+	sl := ScratchSlice[byte](sc, 10, 10)
+	str := ScratchString(sc, "scratch")
+	large := ScratchSlice[byte](sc, 1000, 1000)
+
+	chunks := sc.Stats().Chunks
+	sc.Reset()
+
+	assert.Panics(t, func() { o.Value() })
+	assert.Panics(t, func() { sl.Value() })
+	assert.Panics(t, func() { str.Value() })
+	assert.Panics(t, func() { large.Value() })
+
+	// The large allocation's chunk was freed, the others are kept
+	stats := sc.Stats()
+	assert.Equal(t, chunks-1, stats.Chunks)
+	assert.Equal(t, 0, stats.Allocs)
+	assert.Equal(t, 1, stats.Resets)
+	assert.Equal(t, chunks-1, s.TotalStats().Live)
+
+	// New allocations reuse the existing chunks
+	allocs := s.TotalStats().Allocs
+	for range 100 {
+		r := ScratchObject[int64](sc)
+		*r.Value() = 1
+	}
+	assert.Equal(t, allocs, s.TotalStats().Allocs)
+
+	sc.Free()
+	assert.Equal(t, 0, s.TotalStats().Live)
+}
+
+// Demonstrate that scratch slices and strings can't be freed or appended to
+func Test_Scratch_ViewsPanic(t *testing.T) {
+	s := New()
+	defer func() {
+		assert.NoError(t, s.Destroy())
+	}()
+
+	sc := NewScratch(s, 1<<8)
+	sl := ScratchSlice[byte](sc, 1, 4)
+	str := ScratchString(sc, "abc")
+
+	assert.Panics(t, func() { FreeSlice(s, sl) })
+	assert.Panics(t, func() { Append(s, sl, 1) })
+	assert.Panics(t, func() { FreeString(s, str) })
+	assert.Panics(t, func() { ScratchSlice[byte](sc, 2, 1) })
+	assert.Panics(t, func() { NewScratch(s, 0) })
+}
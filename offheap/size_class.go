@@ -0,0 +1,94 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+package offheap
+
+import (
+	"sync/atomic"
+
+	"github.com/fmstephe/memorymanager/offheap/internal/pointerstore"
+)
+
+// A single size class of a single pool of a Store.
+//
+// Most programs only allocate from a handful of the size classes available,
+// so the pointerstore.Store for a size class is only created when it is first
+// needed, see Store.classStore. Until then the size class has no statistics,
+// and is skipped by every operation which visits allocations.
+type sizeClass struct {
+	conf pointerstore.AllocConfig
+	pool int
+	// nil until the size class is first used. Once published the store is
+	// never replaced.
+	store atomic.Pointer[pointerstore.Store]
+}
+
+// Returns a size class for each of confs, in pool
+func newSizeClasses(confs []pointerstore.AllocConfig, pool int) []*sizeClass {
+	classes := make([]*sizeClass, len(confs))
+	for i := range confs {
+		classes[i] = &sizeClass{
+			conf: confs[i],
+			pool: pool,
+		}
+	}
+	return classes
+}
+
+// Returns the store for this size class, or nil if the size class has never
+// been used
+func (c *sizeClass) loaded() *pointerstore.Store {
+	return c.store.Load()
+}
+
+// Returns the statistics for this size class. A size class which has never
+// been used has zero statistics.
+func (c *sizeClass) stats() pointerstore.Stats {
+	if store := c.loaded(); store != nil {
+		return store.Stats()
+	}
+	return pointerstore.Stats{}
+}
+
+// Returns the store for the size class idx in pool, creating it if this is
+// the first time the size class has been used.
+func (s *Store) classStore(pool, idx int) *pointerstore.Store {
+	classes := s.sizedStores
+	if s.pools != nil {
+		classes = s.pools[pool]
+	}
+	class := classes[idx]
+	if store := class.loaded(); store != nil {
+		return store
+	}
+	return s.initClassStore(class, idx)
+}
+
+// Creates, and publishes, the store for class, applying every setting which
+// has been applied to the Store's existing size classes.
+func (s *Store) initClassStore(class *sizeClass, idx int) *pointerstore.Store {
+	s.classLock.Lock()
+	defer s.classLock.Unlock()
+
+	// Another goroutine may have created the store while we waited
+	if store := class.loaded(); store != nil {
+		return store
+	}
+
+	store := pointerstore.NewInPool(class.conf, class.pool)
+	if s.caches > 0 {
+		store.EnableCaches(s.caches, s.cacheSize)
+	}
+	if s.quarantine != (QuarantinePolicy{}) {
+		store.SetQuarantine(s.quarantine.slotsFor(s.classSize(idx)))
+	}
+	if s.sealed.Load() {
+		// A store with no slabs has no memory to protect
+		if err := store.Seal(); err != nil {
+			panic(err)
+		}
+	}
+	class.store.Store(store)
+	return store
+}
@@ -0,0 +1,136 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+package offheap
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/fmstephe/memorymanager/offheap/internal/pointerstore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Returns the indexes of the size classes of s which have been used
+func loadedClasses(s *Store) []int {
+	loaded := []int{}
+	for idx, class := range s.sizedStores {
+		if class.loaded() != nil {
+			loaded = append(loaded, idx)
+		}
+	}
+	return loaded
+}
+
+// Demonstrate that a size class is only created when it is first allocated
+// from, and that unused size classes still report their config and zero
+// statistics
+func TestSizeClass_Lazy(t *testing.T) {
+	os := New()
+	defer func() {
+		assert.NoError(t, os.Destroy())
+	}()
+
+	assert.Empty(t, loadedClasses(os))
+
+	// Every size class reports its config before it is used
+	configs := os.AllocConfigs()
+	require.Len(t, configs, len(os.sizedStores))
+	for i, conf := range configs {
+		assert.Equal(t, pointerstore.NewAllocConfigBySize(1<<i, defaultSlabSize), conf)
+	}
+
+	r := AllocObject[int64](os)
+	idx := indexForSize(8)
+	assert.Equal(t, []int{idx}, loadedClasses(os))
+
+	stats := os.Stats()
+	for i := range stats {
+		if i == idx {
+			assert.Equal(t, 1, stats[i].Live)
+		} else {
+			assert.Equal(t, pointerstore.Stats{}, stats[i])
+		}
+	}
+
+	// Looking up a handle in an unused size class doesn't create it
+	_, ok := ResolveObjectHandle[[16]byte](os, 1)
+	assert.False(t, ok)
+	assert.Equal(t, []int{idx}, loadedClasses(os))
+
+	// Visiting and verifying allocations skips unused size classes
+	ForEachObject(os, func(RefObject[[16]byte], *[16]byte) bool {
+		t.Fatal("no [16]byte objects allocated")
+		return true
+	})
+	assert.NoError(t, os.Verify())
+
+	FreeObject(os, r)
+}
+
+// Show that size classes created after Seal or SetQuarantine have the same
+// settings as the size classes which already existed
+func TestSizeClass_LazySettings(t *testing.T) {
+	os := New()
+	defer func() {
+		assert.NoError(t, os.Destroy())
+	}()
+
+	os.SetQuarantine(QuarantinePolicy{Slots: 4})
+
+	r := AllocObject[int64](os)
+	FreeObject(os, r)
+	assert.Equal(t, 1, StatsForType[int64](os).Quarantined)
+
+	// A clone creates its unused size classes with the same quarantine
+	clone := os.Clone()
+	defer func() {
+		assert.NoError(t, clone.Destroy())
+	}()
+	FreeObject(clone, AllocObject[[32]byte](clone))
+	assert.Equal(t, 1, StatsForType[[32]byte](clone).Quarantined)
+
+	require.NoError(t, os.Seal())
+	assert.True(t, os.IsSealed())
+
+	// A size class which had never been used is sealed too
+	assert.PanicsWithError(t, "cannot allocate in a sealed store: "+ErrSealed.Error(), func() {
+		AllocObject[[64]byte](os)
+	})
+
+	// A clone of a sealed Store is not sealed
+	assert.False(t, clone.IsSealed())
+}
+
+// Show that when many goroutines allocate from a size class for the first
+// time, a single store is created for the size class
+func TestSizeClass_ConcurrentFirstUse(t *testing.T) {
+	os := New()
+	defer func() {
+		assert.NoError(t, os.Destroy())
+	}()
+
+	const goroutines = 8
+	const allocs = 100
+
+	start := sync.WaitGroup{}
+	start.Add(1)
+	done := sync.WaitGroup{}
+	for range goroutines {
+		done.Add(1)
+		go func() {
+			defer done.Done()
+			start.Wait()
+			for range allocs {
+				AllocObject[int64](os)
+			}
+		}()
+	}
+	start.Done()
+	done.Wait()
+
+	assert.Equal(t, goroutines*allocs, StatsForType[int64](os).Live)
+	assert.Equal(t, []int{indexForSize(8)}, loadedClasses(os))
+}
@@ -129,3 +129,15 @@ func ExampleAppendSlice_oldRef() {
 	ref1.Value()
 	// Output: The RefSlice passed into AppendSlice cannot be used after
 }
+
+// SubSlice creates a view into an existing RefSlice without copying
+func ExampleSubSlice() {
+	var store *offheap.Store = offheap.New()
+
+	var ref offheap.RefSlice[int] = offheap.ConcatSlices(store, []int{1, 2, 3, 4, 5})
+
+	var view offheap.RefSlice[int] = offheap.SubSlice(store, ref, 1, 4)
+
+	fmt.Printf("Slice of %v", view.Value())
+	// Output: Slice of [2 3 4]
+}
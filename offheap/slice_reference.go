@@ -18,21 +18,29 @@ import (
 // The contents of the slice will be arbitrary. Unlike Go slices acquired via
 // AllocSlice do _not_ have their contents zeroed out.
 func AllocSlice[T any](s *Store, length, requestedCapacity int) RefSlice[T] {
-	// TODO this is not fast - we _need_ to cache this type data
-	if err := containsNoPointers[T](); err != nil {
+	info := typeInfoFor[T]()
+	if err := info.pointerErr; err != nil {
 		panic(fmt.Errorf("cannot allocate generic type containing pointers %w", err))
 	}
 
 	// Round the requested capacity up to a power of 2
 	actualCapacity := capacityForSlice(requestedCapacity)
 
-	idx := indexForSlice[T](actualCapacity)
+	idx := info.sliceIndex(s, actualCapacity)
 
-	pRef := s.alloc(idx)
+	pRef := s.alloc(idx, info.rawSize*requestedCapacity)
 	sRef := newRefSlice[T](length, actualCapacity, pRef)
 	return sRef
 }
 
+// Allocates a new slice whose length and contents are the same as src. The
+// capacity of the new slice is len(src) rounded up, as for AllocSlice.
+func AllocSliceFromSlice[T any](s *Store, src []T) RefSlice[T] {
+	r := AllocSlice[T](s, len(src), len(src))
+	copy(r.Value(), src)
+	return r
+}
+
 // Allocates a new slice which contains the elements of slices concatenated together
 func ConcatSlices[T any](s *Store, slices ...[]T) RefSlice[T] {
 	totalLength := 0
@@ -52,6 +60,24 @@ func ConcatSlices[T any](s *Store, slices ...[]T) RefSlice[T] {
 	return r
 }
 
+// Allocates a new slice in s, whose length, capacity and contents are the same
+// as the slice referenced by r. The store s can be the Store that r was
+// allocated in, or any other Store. If r is nil then a nil RefSlice is
+// returned.
+//
+// Cloning a view created by SubSlice creates an independent slice, which can
+// be appended to and freed like any other.
+func CloneSlice[T any](s *Store, r RefSlice[T]) RefSlice[T] {
+	if r.IsNil() {
+		return RefSlice[T]{}
+	}
+
+	from := r.Value()
+	newRef := AllocSlice[T](s, len(from), cap(from))
+	copy(newRef.Value(), from)
+	return newRef
+}
+
 // Returns a new RefSlice pointing to a slice whose size and contents is the
 // same as append(into.Value(), value).
 //
@@ -61,6 +87,10 @@ func ConcatSlices[T any](s *Store, slices ...[]T) RefSlice[T] {
 // externally this function behaves as if a new allocation is made and the old
 // one freed.
 func Append[T any](s *Store, into RefSlice[T], value T) RefSlice[T] {
+	if into.view {
+		panic("cannot append to a RefSlice created by SubSlice")
+	}
+
 	pRef, newCapacity := resizeAndInvalidate[T](s, into.ref, into.capacity, into.length, 1)
 
 	// We have the capacity available, append the element
@@ -81,6 +111,10 @@ func Append[T any](s *Store, into RefSlice[T], value T) RefSlice[T] {
 // externally this function behaves as if a new allocation is made and the old
 // one freed.
 func AppendSlice[T any](s *Store, into RefSlice[T], fromSlice []T) RefSlice[T] {
+	if into.view {
+		panic("cannot append to a RefSlice created by SubSlice")
+	}
+
 	pRef, newCapacity := resizeAndInvalidate[T](s, into.ref, into.capacity, into.length, len(fromSlice))
 
 	// We have the capacity available, append the slice
@@ -92,14 +126,133 @@ func AppendSlice[T any](s *Store, into RefSlice[T], fromSlice []T) RefSlice[T] {
 	return newRef
 }
 
+// Returns a new RefSlice pointing to a slice whose size and contents is the
+// same as slices.Insert(into.Value(), idx, values...). Panics if idx is out
+// of range.
+//
+// After this function returns into is no longer a valid RefSlice, and will
+// behave as if Free(...) was called on it. Like Append, the existing
+// allocation slot _may_ be reused if it has enough capacity.
+func InsertAt[T any](s *Store, into RefSlice[T], idx int, values ...T) RefSlice[T] {
+	if into.view {
+		panic("cannot insert into a RefSlice created by SubSlice")
+	}
+	if idx < 0 || idx > len(into.Value()) {
+		panic(fmt.Errorf("InsertAt index %d out of range for slice of length %d", idx, into.length))
+	}
+	if into.ref.IsPinned() {
+		panic("cannot insert into a pinned RefSlice")
+	}
+
+	pRef, newCapacity := resizeAndInvalidate[T](s, into.ref, into.capacity, into.length, len(values))
+
+	// We have the capacity available, shift the tail and insert the values
+	newRef := newRefSlice[T](into.length, newCapacity, pRef)
+	newRef.length += len(values)
+	slice := newRef.Value()
+	copy(slice[idx+len(values):], slice[idx:into.length])
+	copy(slice[idx:], values)
+
+	return newRef
+}
+
+// Returns a new RefSlice pointing to a slice whose size and contents is the
+// same as slices.Delete(into.Value(), start, end). The capacity of the slice
+// is unchanged. Panics if [start:end] is out of range.
+//
+// After this function returns into is no longer a valid RefSlice, and will
+// behave as if Free(...) was called on it. The existing allocation slot is
+// always reused.
+func DeleteRange[T any](s *Store, into RefSlice[T], start, end int) RefSlice[T] {
+	if into.view {
+		panic("cannot delete from a RefSlice created by SubSlice")
+	}
+	slice := into.Value()
+	if start < 0 || end < start || end > len(slice) {
+		panic(fmt.Errorf("DeleteRange [%d:%d] out of range for slice of length %d", start, end, len(slice)))
+	}
+	if into.ref.IsPinned() {
+		panic("cannot delete from a pinned RefSlice")
+	}
+
+	copy(slice[start:], slice[end:])
+
+	newRef := newRefSlice[T](into.length-(end-start), into.capacity, into.ref.Realloc())
+	return newRef
+}
+
+// Returns a new RefSlice pointing to the first length elements of into. The
+// capacity of the slice is unchanged. Panics if length is out of range.
+//
+// After this function returns into is no longer a valid RefSlice, and will
+// behave as if Free(...) was called on it. The existing allocation slot is
+// always reused.
+func Truncate[T any](s *Store, into RefSlice[T], length int) RefSlice[T] {
+	if into.view {
+		panic("cannot truncate a RefSlice created by SubSlice")
+	}
+	if length < 0 || length > len(into.Value()) {
+		panic(fmt.Errorf("Truncate length %d out of range for slice of length %d", length, into.length))
+	}
+	if into.ref.IsPinned() {
+		panic("cannot truncate a pinned RefSlice")
+	}
+
+	return newRefSlice[T](length, into.capacity, into.ref.Realloc())
+}
+
+// Returns a RefSlice which is a view of the elements [start:end] of ref. No
+// new allocation is made and nothing is copied, the returned RefSlice shares
+// ref's allocation. The capacity of the view is the same as its length, so
+// appending to the slice returned by the view's Value() method will never
+// overwrite elements of ref.
+//
+// The returned view is only valid for as long as ref is. Once ref is freed, or
+// invalidated by Append, AppendSlice, InsertAt, DeleteRange or Truncate, the
+// view must never be used again. A best effort has been made to panic if a
+// view is used after its original RefSlice is freed, just like any other
+// RefSlice.
+//
+// A view can't be freed, or modified by Append, AppendSlice, InsertAt,
+// DeleteRange or Truncate, these functions will panic. Views of views are
+// allowed.
+func SubSlice[T any](s *Store, ref RefSlice[T], start, end int) RefSlice[T] {
+	slice := ref.Value()
+	if start < 0 || end < start || end > len(slice) {
+		panic(fmt.Errorf("SubSlice [%d:%d] out of range for slice of length %d", start, end, len(slice)))
+	}
+
+	return RefSlice[T]{
+		length:   end - start,
+		capacity: end - start,
+		offset:   ref.offset + start,
+		view:     true,
+		ref:      ref.ref,
+	}
+}
+
 // Frees the allocation referenced by r. After this call returns r must never
 // be used again. Any use of the slice referenced by r will have unpredicatable
 // behaviour.
 func FreeSlice[T any](s *Store, r RefSlice[T]) {
-	idx := indexForSlice[T](r.capacity)
+	if r.view {
+		panic("cannot free a RefSlice created by SubSlice, free the original RefSlice instead")
+	}
+
+	idx := sliceIndex[T](s, r.capacity)
 	s.free(idx, r.ref)
 }
 
+// Frees the allocation referenced by r, like FreeSlice. Instead of panicking
+// an error is returned if r is nil or a view, if the slice has already been
+// freed, if the slice is pinned or if s is sealed.
+func TryFreeSlice[T any](s *Store, r RefSlice[T]) error {
+	if r.view {
+		return ErrView
+	}
+	return s.tryFree(sliceIndex[T](s, r.capacity), r.ref)
+}
+
 // A reference to a slice. This reference allows us to gain access to an
 // allocated slice directly.
 //
@@ -109,7 +262,12 @@ func FreeSlice[T any](s *Store, r RefSlice[T]) {
 type RefSlice[T any] struct {
 	length   int
 	capacity int
-	ref      pointerstore.RefPointer
+	// The position of this slice's first element within its allocation,
+	// only non-zero for views created by SubSlice
+	offset int
+	// Indicates whether this RefSlice was created by SubSlice
+	view bool
+	ref  pointerstore.RefPointer
 }
 
 func newRefSlice[T any](length, capacity int, ref pointerstore.RefPointer) RefSlice[T] {
@@ -129,8 +287,23 @@ func newRefSlice[T any](length, capacity int, ref pointerstore.RefPointer) RefSl
 // Care must be taken not to use this slice after FreeSlice(...) has been
 // called on this RefSlice.
 func (r *RefSlice[T]) Value() []T {
-	slice := unsafe.Slice((*T)(unsafe.Pointer(r.ref.DataPtr())), r.capacity)
-	return slice[:r.length]
+	slice := unsafe.Slice((*T)(unsafe.Pointer(r.ref.DataPtr())), r.offset+r.capacity)
+	return slice[r.offset : r.offset+r.length : r.offset+r.capacity]
+}
+
+// Returns the raw slice pointed to by this RefSlice, like Value. Instead of
+// panicking an error is returned if r is nil, or if the slice has been freed,
+// see ErrFreedReference and ErrStaleReference.
+func (r *RefSlice[T]) TryValue() ([]T, error) {
+	if r.IsNil() {
+		return nil, ErrNilReference
+	}
+	ptr, err := r.ref.TryDataPtr()
+	if err != nil {
+		return nil, err
+	}
+	slice := unsafe.Slice((*T)(unsafe.Pointer(ptr)), r.offset+r.capacity)
+	return slice[r.offset : r.offset+r.length : r.offset+r.capacity], nil
 }
 
 // Returns true if this RefSlice does not point to an allocated slice, false otherwise.
@@ -138,6 +311,20 @@ func (r *RefSlice[T]) IsNil() bool {
 	return r.ref.IsNil()
 }
 
+// Returns the length of the slice referenced by r. Unlike len(r.Value()) this
+// doesn't access the slice. The length of a nil RefSlice is 0.
+func (r *RefSlice[T]) Len() int {
+	return r.length
+}
+
+// Returns the capacity of the slice referenced by r. Unlike cap(r.Value())
+// this doesn't access the slice. The capacity of a nil RefSlice is 0.
+//
+// The capacity of a view created by SubSlice is its length.
+func (r *RefSlice[T]) Cap() int {
+	return r.capacity
+}
+
 // Returns the stats for the allocation size of a []T with capacity.
 //
 // It is important to note that these statistics apply to the size class
@@ -145,7 +332,7 @@ func (r *RefSlice[T]) IsNil() bool {
 // this _size_ including allocations for non-slice types.
 func StatsForSlice[T any](s *Store, capacity int) pointerstore.Stats {
 	stats := s.Stats()
-	idx := indexForSlice[T](capacity)
+	idx := s.storeIndex(sliceIndex[T](s, capacity))
 	return stats[idx]
 }
 
@@ -157,11 +344,15 @@ func StatsForSlice[T any](s *Store, capacity int) pointerstore.Stats {
 // allocations for non-slice types.
 func ConfForSlice[T any](s *Store, capacity int) pointerstore.AllocConfig {
 	configs := s.AllocConfigs()
-	idx := indexForSlice[T](capacity)
+	idx := s.storeIndex(sliceIndex[T](s, capacity))
 	return configs[idx]
 }
 
 func resizeAndInvalidate[T any](s *Store, oldRef pointerstore.RefPointer, oldCapacity, oldLength, extra int) (newRef pointerstore.RefPointer, newCapacity int) {
+	if oldRef.IsPinned() {
+		panic("cannot append to a pinned RefSlice")
+	}
+
 	// Calculate the new length
 	newLength := oldLength + extra
 	// TODO test this overflow case We first need to introduce a new option
@@ -181,16 +372,18 @@ func resizeAndInvalidate[T any](s *Store, oldRef pointerstore.RefPointer, oldCap
 		return oldRef.Realloc(), oldCapacity
 	}
 
-	newIdx := indexForSlice[T](newCapacity)
-	newRef = s.alloc(newIdx)
+	info := typeInfoFor[T]()
+	newIdx := info.sliceIndex(s, newCapacity)
+	newRef = s.alloc(newIdx, info.rawSize*newLength)
+	s.retag(newIdx, newRef, oldRef, info.rawSize*newLength)
 
 	// Copy the content of the old allocation into the new
-	oldCapacitySize := sizeForSlice[T](oldCapacity)
+	oldCapacitySize := info.rawSize * oldCapacity
 	oldValue := oldRef.Bytes(oldCapacitySize)
 	newValue := newRef.Bytes(oldCapacitySize)
 	copy(newValue, oldValue)
 
-	oldIdx := indexForSlice[T](oldCapacity)
+	oldIdx := info.sliceIndex(s, oldCapacity)
 	s.free(oldIdx, oldRef)
 
 	return newRef, newCapacity
@@ -6,6 +6,8 @@ package offheap
 
 import (
 	"fmt"
+	"math/rand"
+	"slices"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -204,6 +206,10 @@ func Test_Slice_SizedStats(t *testing.T) {
 				// Larger objects will require a slab per allocation
 				expectedStats.Slabs = 2
 			}
+			expectedStats.MappedBytes = expectedStats.Slabs * int(conf.TotalSlabSize)
+			expectedStats.FreeBytes = expectedStats.Slabs * int(conf.TotalObjectSize)
+			expectedStats.RequestedBytes = 3 * capacity * rawSizeForType[MutableStruct]()
+			expectedStats.AllocatedBytes = 3 * int(conf.ObjectSize)
 
 			actualStats := StatsForSlice[MutableStruct](os, capacity)
 
@@ -460,3 +466,233 @@ func Test_Slice_ConcatSlices(t *testing.T) {
 		assert.Equal(t, expectedSlice, r.Value())
 	}
 }
+
+// Demonstrate that InsertAt, DeleteRange and Truncate behave like their
+// equivalents on Go slices, and invalidate the reference they modify
+func Test_Slice_InsertDeleteTruncate(t *testing.T) {
+	os := New()
+	defer func() {
+		assert.NoError(t, os.Destroy())
+	}()
+
+	r := rand.New(rand.NewSource(1))
+	ref := AllocSlice[int64](os, 0, 0)
+	expected := []int64{}
+
+	for i := range 10_000 {
+		oldRef := ref
+		switch r.Intn(4) {
+		case 0, 1:
+			idx := r.Intn(len(expected) + 1)
+			values := make([]int64, r.Intn(10))
+			for j := range values {
+				values[j] = int64(i*10 + j)
+			}
+			ref = InsertAt(os, ref, idx, values...)
+			expected = slices.Insert(expected, idx, values...)
+		case 2:
+			start := r.Intn(len(expected) + 1)
+			end := start + r.Intn(len(expected)-start+1)
+			oldCap := ref.Cap()
+			ref = DeleteRange(os, ref, start, end)
+			expected = slices.Delete(expected, start, end)
+			assert.Equal(t, oldCap, ref.Cap())
+		case 3:
+			length := r.Intn(len(expected) + 1)
+			oldCap := ref.Cap()
+			ref = Truncate(os, ref, length)
+			expected = expected[:length]
+			assert.Equal(t, oldCap, ref.Cap())
+		}
+
+		require.Equal(t, expected, ref.Value())
+		require.Panics(t, func() { oldRef.Value() })
+	}
+
+	FreeSlice(os, ref)
+	assert.Equal(t, 0, os.TotalStats().Live)
+}
+
+// Demonstrate that InsertAt, DeleteRange and Truncate panic for out of range
+// arguments, views and pinned slices
+func Test_Slice_InsertDeleteTruncate_Panics(t *testing.T) {
+	os := New()
+	defer func() {
+		assert.NoError(t, os.Destroy())
+	}()
+
+	ref := ConcatSlices[int64](os, []int64{1, 2, 3})
+
+	assert.Panics(t, func() { InsertAt(os, ref, -1, 1) })
+	assert.Panics(t, func() { InsertAt(os, ref, 4, 1) })
+	assert.Panics(t, func() { DeleteRange(os, ref, -1, 2) })
+	assert.Panics(t, func() { DeleteRange(os, ref, 2, 1) })
+	assert.Panics(t, func() { DeleteRange(os, ref, 0, 4) })
+	assert.Panics(t, func() { Truncate(os, ref, -1) })
+	assert.Panics(t, func() { Truncate(os, ref, 4) })
+
+	view := SubSlice(os, ref, 0, 2)
+	assert.Panics(t, func() { InsertAt(os, view, 0, 1) })
+	assert.Panics(t, func() { DeleteRange(os, view, 0, 1) })
+	assert.Panics(t, func() { Truncate(os, view, 1) })
+
+	ref.Pin()
+	assert.Panics(t, func() { InsertAt(os, ref, 0, 1) })
+	assert.Panics(t, func() { DeleteRange(os, ref, 0, 1) })
+	assert.Panics(t, func() { Truncate(os, ref, 1) })
+	ref.Unpin()
+
+	// None of the failed calls modified the slice
+	assert.Equal(t, []int64{1, 2, 3}, ref.Value())
+}
+
+// Demonstrate that AllocSliceFromSlice creates a copy of its source slice
+func Test_Slice_AllocSliceFromSlice(t *testing.T) {
+	os := New()
+	defer func() {
+		assert.NoError(t, os.Destroy())
+	}()
+
+	for _, src := range [][]int64{nil, {}, {1}, {1, 2, 3}, {1, 2, 3, 4, 5}} {
+		r := AllocSliceFromSlice(os, src)
+		assert.Equal(t, len(src), r.Len())
+		assert.GreaterOrEqual(t, r.Cap(), len(src))
+		assert.Equal(t, len(src), len(r.Value()))
+		for i := range src {
+			assert.Equal(t, src[i], r.Value()[i])
+		}
+
+		// Modifying the copy doesn't affect the source
+		if len(src) > 0 {
+			r.Value()[0] = 100
+			assert.Equal(t, int64(1), src[0])
+		}
+
+		FreeSlice(os, r)
+	}
+
+	assert.Panics(t, func() { AllocSliceFromSlice(os, []*int{nil}) })
+}
+
+// Demonstrate that SubSlice creates views which share the original slice's
+// allocation, including views of views
+func Test_Slice_SubSlice(t *testing.T) {
+	ss := NewSized(1 << 8)
+	defer func() {
+		assert.NoError(t, ss.Destroy())
+	}()
+
+	ref := ConcatSlices[int64](ss, []int64{0, 1, 2, 3, 4, 5, 6, 7, 8, 9})
+
+	for start := 0; start <= 10; start++ {
+		for end := start; end <= 10; end++ {
+			view := SubSlice(ss, ref, start, end)
+			assert.Equal(t, ref.Value()[start:end], view.Value())
+			assert.Equal(t, end-start, cap(view.Value()))
+			assert.Equal(t, end-start, view.Len())
+			assert.Equal(t, end-start, view.Cap())
+
+			// Views of views
+			for subStart := 0; subStart <= end-start; subStart++ {
+				for subEnd := subStart; subEnd <= end-start; subEnd++ {
+					subView := SubSlice(ss, view, subStart, subEnd)
+					assert.Equal(t, ref.Value()[start+subStart:start+subEnd], subView.Value())
+				}
+			}
+		}
+	}
+
+	// Modifications are shared between the original and its views
+	view := SubSlice(ss, ref, 3, 6)
+	view.Value()[0] = 30
+	assert.Equal(t, int64(30), ref.Value()[3])
+
+	// Appending to the view's Go slice doesn't overwrite the original
+	_ = append(view.Value(), 100)
+	assert.Equal(t, int64(6), ref.Value()[6])
+
+	// Out of range views panic
+	assert.Panics(t, func() { SubSlice(ss, ref, -1, 5) })
+	assert.Panics(t, func() { SubSlice(ss, ref, 6, 5) })
+	assert.Panics(t, func() { SubSlice(ss, ref, 0, 11) })
+	assert.Panics(t, func() { SubSlice(ss, view, 0, 4) })
+
+	// Views can't be freed or appended to
+	assert.Panics(t, func() { FreeSlice(ss, view) })
+	assert.Panics(t, func() { Append(ss, view, 1) })
+	assert.Panics(t, func() { AppendSlice(ss, view, []int64{1}) })
+
+	// Once the original is freed the view can't be used
+	FreeSlice(ss, ref)
+	assert.Panics(t, func() { view.Value() })
+}
+
+// Demonstrate that Len and Cap report the geometry of a slice without
+// accessing it
+func Test_Slice_LenCap(t *testing.T) {
+	ss := NewSized(1 << 8)
+	defer func() {
+		assert.NoError(t, ss.Destroy())
+	}()
+
+	nilRef := RefSlice[int64]{}
+	assert.Equal(t, 0, nilRef.Len())
+	assert.Equal(t, 0, nilRef.Cap())
+
+	ref := AllocSlice[int64](ss, 3, 5)
+	assert.Equal(t, len(ref.Value()), ref.Len())
+	assert.Equal(t, cap(ref.Value()), ref.Cap())
+	assert.Equal(t, 3, ref.Len())
+	// The capacity is rounded up to a power of two
+	assert.Equal(t, 8, ref.Cap())
+
+	ref = AppendSlice(ss, ref, []int64{1, 2, 3, 4, 5, 6})
+	assert.Equal(t, 9, ref.Len())
+	assert.Equal(t, 16, ref.Cap())
+
+	// Len and Cap can be called after the slice is freed
+	FreeSlice(ss, ref)
+	assert.Equal(t, 9, ref.Len())
+	assert.Equal(t, 16, ref.Cap())
+}
+
+// Demonstrate that CloneSlice creates an independent copy of a slice, in the
+// same Store or a different Store
+func Test_Slice_Clone(t *testing.T) {
+	ss := NewSized(1 << 8)
+	otherSs := NewSized(1 << 8)
+	defer func() {
+		assert.NoError(t, ss.Destroy())
+		assert.NoError(t, otherSs.Destroy())
+	}()
+
+	r := AllocSlice[int64](ss, 5, 7)
+	copy(r.Value(), []int64{1, 2, 3, 4, 5})
+	view := SubSlice(ss, r, 1, 3)
+
+	for _, store := range []*Store{ss, otherSs} {
+		clone := CloneSlice(store, r)
+		assert.Equal(t, r.Value(), clone.Value())
+		assert.Equal(t, cap(r.Value()), cap(clone.Value()))
+
+		// Modifying the clone doesn't affect the original
+		clone.Value()[0] = 100
+		assert.Equal(t, int64(1), r.Value()[0])
+		FreeSlice(store, clone)
+
+		// Cloning a view gives an ordinary slice, which can be
+		// appended to and freed
+		viewClone := CloneSlice(store, view)
+		assert.Equal(t, []int64{2, 3}, viewClone.Value())
+		viewClone = Append(store, viewClone, 4)
+		assert.Equal(t, []int64{2, 3, 4}, viewClone.Value())
+		FreeSlice(store, viewClone)
+	}
+
+	// The original is unaffected by freeing its clones
+	assert.Equal(t, []int64{1, 2, 3, 4, 5}, r.Value())
+
+	// Cloning a nil reference gives a nil reference
+	nilClone := CloneSlice(ss, RefSlice[int64]{})
+	assert.True(t, nilClone.IsNil())
+}
@@ -0,0 +1,60 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+package offheap
+
+import "sort"
+
+// Sorts the slice referenced by ref, in place, in the ascending order
+// defined by less. The sort is not guaranteed to be stable.
+//
+// The slice is sorted directly in offheap memory, no copy is made and no
+// reference to the slice is retained.
+func SortSlice[T any](s *Store, ref RefSlice[T], less func(a, b T) bool) {
+	sort.Sort(lessSorter[T]{
+		slice: ref.Value(),
+		less:  less,
+	})
+}
+
+// Indicates whether the slice referenced by ref is sorted in the ascending
+// order defined by less.
+func IsSortedSlice[T any](s *Store, ref RefSlice[T], less func(a, b T) bool) bool {
+	return sort.IsSorted(lessSorter[T]{
+		slice: ref.Value(),
+		less:  less,
+	})
+}
+
+// Searches for target in the slice referenced by ref, which must be sorted
+// in the ascending order defined by less. Returns the position where target
+// is found, or the position where target would appear in the sort order, and
+// whether target was found.
+func SearchSlice[T any](s *Store, ref RefSlice[T], target T, less func(a, b T) bool) (int, bool) {
+	slice := ref.Value()
+	idx := sort.Search(len(slice), func(i int) bool {
+		return !less(slice[i], target)
+	})
+	return idx, idx < len(slice) && !less(target, slice[idx])
+}
+
+// Adapts a slice and a less function to sort.Interface. Using sort.Sort,
+// rather than sort.Slice, avoids the reflection based swapping of
+// sort.Slice.
+type lessSorter[T any] struct {
+	slice []T
+	less  func(a, b T) bool
+}
+
+func (l lessSorter[T]) Len() int {
+	return len(l.slice)
+}
+
+func (l lessSorter[T]) Less(i, j int) bool {
+	return l.less(l.slice[i], l.slice[j])
+}
+
+func (l lessSorter[T]) Swap(i, j int) {
+	l.slice[i], l.slice[j] = l.slice[j], l.slice[i]
+}
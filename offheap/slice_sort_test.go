@@ -0,0 +1,68 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+package offheap
+
+import (
+	"math/rand"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Demonstrate that SortSlice sorts slices of many lengths, and that
+// SearchSlice finds every element of the sorted slice
+func Test_SortSlice_SearchSlice(t *testing.T) {
+	os := New()
+	defer func() {
+		assert.NoError(t, os.Destroy())
+	}()
+
+	r := rand.New(rand.NewSource(1))
+	less := func(a, b int64) bool { return a < b }
+
+	for _, length := range testSizeRanges {
+		expected := make([]int64, length)
+		for i := range expected {
+			// Even values only, so odd values are never found
+			expected[i] = int64(r.Intn(length+1) * 2)
+		}
+		ref := AllocSliceFromSlice(os, expected)
+		slices.Sort(expected)
+
+		SortSlice(os, ref, less)
+		require.Equal(t, expected, ref.Value())
+		require.True(t, IsSortedSlice(os, ref, less))
+
+		for _, value := range expected {
+			idx, found := SearchSlice(os, ref, value, less)
+			require.True(t, found)
+			require.Equal(t, value, ref.Value()[idx])
+
+			expectedIdx, _ := slices.BinarySearch(expected, value+1)
+			idx, found = SearchSlice(os, ref, value+1, less)
+			require.False(t, found)
+			require.Equal(t, expectedIdx, idx)
+		}
+
+		FreeSlice(os, ref)
+	}
+}
+
+// Demonstrate that SortSlice sorts only the elements of a view
+func Test_SortSlice_View(t *testing.T) {
+	os := New()
+	defer func() {
+		assert.NoError(t, os.Destroy())
+	}()
+
+	ref := ConcatSlices[int](os, []int{9, 8, 7, 6, 5, 4, 3, 2, 1})
+	view := SubSlice(os, ref, 2, 7)
+
+	SortSlice(os, view, func(a, b int) bool { return a < b })
+	assert.Equal(t, []int{9, 8, 3, 4, 5, 6, 7, 2, 1}, ref.Value())
+	assert.False(t, IsSortedSlice(os, ref, func(a, b int) bool { return a < b }))
+}
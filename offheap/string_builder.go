@@ -0,0 +1,153 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+package offheap
+
+import (
+	"fmt"
+
+	"github.com/fmstephe/flib/funsafe"
+	"github.com/fmstephe/memorymanager/offheap/internal/pointerstore"
+)
+
+// A StringBuilder is used to efficiently build a RefString, in the same way
+// that a strings.Builder is used to build a string.
+//
+// The string is accumulated in a single offheap allocation, which grows by
+// doubling as needed. Unlike AppendString no RefString is created, and no
+// existing RefString is invalidated, until Build is called.
+//
+// The zero value of a StringBuilder can't be used, use NewStringBuilder.
+type StringBuilder struct {
+	store    *Store
+	length   int
+	capacity int
+	ref      pointerstore.RefPointer
+}
+
+// Returns a new, empty, StringBuilder which allocates from s.
+func NewStringBuilder(s *Store) *StringBuilder {
+	return &StringBuilder{
+		store: s,
+	}
+}
+
+// Returns the number of bytes written to this builder
+func (b *StringBuilder) Len() int {
+	return b.length
+}
+
+// Returns the string written to this builder so far.
+//
+// The returned string is only valid until the next time this builder is
+// written to, or Build is called.
+func (b *StringBuilder) String() string {
+	if b.ref.IsNil() {
+		return ""
+	}
+	return funsafe.BytesToString(b.ref.Bytes(b.length))
+}
+
+// Grows the builder's allocation, if necessary, so that another n bytes can
+// be written without any further allocation.
+func (b *StringBuilder) Grow(n int) {
+	if n < 0 {
+		panic(fmt.Errorf("cannot grow StringBuilder by negative count %d", n))
+	}
+	b.grow(n)
+}
+
+// Appends the contents of str to this builder. The returned error is always
+// nil.
+func (b *StringBuilder) WriteString(str string) (int, error) {
+	return b.WriteBytes(funsafe.StringToBytes(str))
+}
+
+// Appends the contents of bytes to this builder. The returned error is always
+// nil.
+func (b *StringBuilder) WriteBytes(bytes []byte) (int, error) {
+	b.grow(len(bytes))
+	copy(b.ref.Bytes(b.capacity)[b.length:], bytes)
+	b.length += len(bytes)
+	return len(bytes), nil
+}
+
+// Appends the contents of p to this builder, allowing a StringBuilder to be
+// used as an io.Writer. The returned error is always nil.
+func (b *StringBuilder) Write(p []byte) (int, error) {
+	return b.WriteBytes(p)
+}
+
+// Appends c to this builder. The returned error is always nil.
+func (b *StringBuilder) WriteByte(c byte) error {
+	b.grow(1)
+	b.ref.Bytes(b.capacity)[b.length] = c
+	b.length++
+	return nil
+}
+
+// Returns a RefString containing everything written to this builder. The
+// builder's allocation is handed over to the RefString where possible, so
+// building doesn't usually copy the string.
+//
+// After Build is called the builder is empty and can be reused to build
+// another string.
+func (b *StringBuilder) Build() RefString {
+	if b.ref.IsNil() {
+		return AllocStringFromString(b.store, "")
+	}
+
+	var sRef RefString
+	if b.store.sizeIndex(b.length) == b.store.sizeIndex(b.capacity) {
+		// The allocation is exactly the size a RefString of this
+		// length expects, we can hand it over directly
+		sRef = newRefString(b.length, b.ref)
+	} else {
+		// The allocation is too large, which can happen after a call
+		// to Grow, copy the string into a correctly sized allocation
+		sRef = AllocStringFromBytes(b.store, b.ref.Bytes(b.length))
+		b.store.free(b.store.sizeIndex(b.capacity), b.ref)
+	}
+
+	b.length = 0
+	b.capacity = 0
+	b.ref = pointerstore.RefPointer{}
+	return sRef
+}
+
+// Frees the builder's allocation, discarding everything written to it. The
+// builder is empty and can be reused.
+func (b *StringBuilder) Reset() {
+	if !b.ref.IsNil() {
+		b.store.free(b.store.sizeIndex(b.capacity), b.ref)
+	}
+	b.length = 0
+	b.capacity = 0
+	b.ref = pointerstore.RefPointer{}
+}
+
+func (b *StringBuilder) grow(extra int) {
+	newLength := b.length + extra
+	if newLength < b.length {
+		panic(fmt.Errorf("StringBuilder grow (length %d extra %d) has overflowed int", b.length, extra))
+	}
+
+	if newLength <= b.capacity && !b.ref.IsNil() {
+		return
+	}
+
+	// Grow by at least doubling the capacity, so that writing n bytes
+	// costs amortized O(n)
+	newIdx := b.store.sizeIndex(max(newLength, b.capacity*2))
+	newCapacity := b.store.classSize(newIdx)
+	newRef := b.store.alloc(newIdx, newLength)
+
+	if !b.ref.IsNil() {
+		copy(newRef.Bytes(b.length), b.ref.Bytes(b.length))
+		b.store.free(b.store.sizeIndex(b.capacity), b.ref)
+	}
+
+	b.ref = newRef
+	b.capacity = newCapacity
+}
@@ -0,0 +1,123 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+package offheap
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/fmstephe/memorymanager/testpkg/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+// Test that a StringBuilder builds the same strings as a strings.Builder for
+// a wide range of string sizes
+func Test_StringBuilder_Build(t *testing.T) {
+	ss := NewSized(1 << 8)
+	defer func() {
+		assert.NoError(t, ss.Destroy())
+	}()
+
+	rsm := testutil.NewRandomStringMaker()
+
+	for _, length := range testSizeRanges {
+		t.Run(fmt.Sprintf("Build string %d", length), func(t *testing.T) {
+			builder := NewStringBuilder(ss)
+			expected := strings.Builder{}
+
+			// Write a mixture of strings, bytes and single bytes
+			for expected.Len() < length {
+				value := rsm.MakeSizedString(min(length-expected.Len(), 7))
+				switch expected.Len() % 3 {
+				case 0:
+					builder.WriteString(value)
+				case 1:
+					builder.WriteBytes([]byte(value))
+				case 2:
+					value = value[:1]
+					builder.WriteByte(value[0])
+				}
+				expected.WriteString(value)
+				assert.Equal(t, expected.String(), builder.String())
+				assert.Equal(t, expected.Len(), builder.Len())
+			}
+
+			ref := builder.Build()
+			assert.Equal(t, expected.String(), ref.Value())
+
+			// The builder is empty after building
+			assert.Equal(t, 0, builder.Len())
+			assert.Equal(t, "", builder.String())
+
+			// The built string can be freed like any other
+			FreeString(ss, ref)
+			assert.Panics(t, func() { ref.Value() })
+		})
+	}
+}
+
+// Test that strings built after a call to Grow are correctly sized, and can
+// be freed
+func Test_StringBuilder_Grow(t *testing.T) {
+	ss := NewSized(1 << 8)
+	defer func() {
+		assert.NoError(t, ss.Destroy())
+	}()
+
+	for _, length := range testSizeRanges {
+		builder := NewStringBuilder(ss)
+		builder.Grow(1024)
+		builder.WriteString(strings.Repeat("a", length))
+
+		ref := builder.Build()
+		assert.Equal(t, strings.Repeat("a", length), ref.Value())
+
+		FreeString(ss, ref)
+	}
+
+	// All allocations have been freed
+	for _, stats := range ss.Stats() {
+		assert.Equal(t, stats.Allocs, stats.Frees)
+	}
+
+	assert.Panics(t, func() { NewStringBuilder(ss).Grow(-1) })
+}
+
+// Test that a builder can be reused after being reset or built
+func Test_StringBuilder_Reuse(t *testing.T) {
+	ss := NewSized(1 << 8)
+	defer func() {
+		assert.NoError(t, ss.Destroy())
+	}()
+
+	builder := NewStringBuilder(ss)
+
+	builder.WriteString("discarded")
+	builder.Reset()
+	assert.Equal(t, 0, builder.Len())
+
+	builder.WriteString("first")
+	first := builder.Build()
+
+	builder.WriteString("second")
+	second := builder.Build()
+
+	// Building an empty builder gives an empty string
+	empty := builder.Build()
+
+	assert.Equal(t, "first", first.Value())
+	assert.Equal(t, "second", second.Value())
+	assert.Equal(t, "", empty.Value())
+
+	FreeString(ss, first)
+	FreeString(ss, second)
+	FreeString(ss, empty)
+
+	// All allocations have been freed
+	for _, stats := range ss.Stats() {
+		assert.Equal(t, stats.Allocs, stats.Frees)
+	}
+}
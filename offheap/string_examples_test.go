@@ -85,3 +85,32 @@ func ExampleAppendString_oldRef() {
 	ref1.Value()
 	// Output: The RefString passed into AppendString cannot be used after
 }
+
+// A StringBuilder accumulates a string offheap, producing a RefString when
+// Build is called
+func ExampleStringBuilder() {
+	var store *offheap.Store = offheap.New()
+
+	var builder *offheap.StringBuilder = offheap.NewStringBuilder(store)
+	builder.WriteString("allo")
+	builder.WriteBytes([]byte("cat"))
+	builder.WriteByte('e')
+	builder.WriteByte('d')
+
+	var ref offheap.RefString = builder.Build()
+
+	fmt.Printf("String of %q", ref.Value())
+	// Output: String of "allocated"
+}
+
+// SubString creates a view into an existing RefString without copying
+func ExampleSubString() {
+	var store *offheap.Store = offheap.New()
+
+	var ref offheap.RefString = offheap.AllocStringFromString(store, "allocated")
+
+	var view offheap.RefString = offheap.SubString(store, ref, 2, 5)
+
+	fmt.Printf("String of %q", view.Value())
+	// Output: String of "loc"
+}
@@ -5,6 +5,7 @@
 package offheap
 
 import (
+	"fmt"
 	"unsafe"
 
 	"github.com/fmstephe/flib/funsafe"
@@ -20,10 +21,10 @@ func AllocStringFromString(s *Store, str string) RefString {
 // Allocates a new string whose size and contents will be the same as found in
 // bytes.
 func AllocStringFromBytes(s *Store, bytes []byte) RefString {
-	idx := indexForSize(len(bytes))
+	idx := s.sizeIndex(len(bytes))
 
 	// Allocate the string
-	pRef := s.alloc(idx)
+	pRef := s.alloc(idx, len(bytes))
 	sRef := newRefString(len(bytes), pRef)
 
 	// Copy the byte data across to the allocated string
@@ -43,8 +44,8 @@ func ConcatStrings(s *Store, strs ...string) RefString {
 	}
 
 	// Allocate the string
-	idx := indexForSize(totalLength)
-	pRef := s.alloc(idx)
+	idx := s.sizeIndex(totalLength)
+	pRef := s.alloc(idx, totalLength)
 	sRef := newRefString(totalLength, pRef)
 
 	// Copy the byte data across to the allocated string
@@ -57,6 +58,19 @@ func ConcatStrings(s *Store, strs ...string) RefString {
 	return sRef
 }
 
+// Allocates a new string in s, whose contents are the same as the string
+// referenced by r. The store s can be the Store that r was allocated in, or
+// any other Store. If r is nil then a nil RefString is returned.
+//
+// Cloning a view created by SubString creates an independent string, which
+// can be appended to and freed like any other.
+func CloneString(s *Store, r RefString) RefString {
+	if r.IsNil() {
+		return RefString{}
+	}
+	return AllocStringFromString(s, r.Value())
+}
+
 // Returns a new RefString pointing to a string whose size and contents is the
 // same as into.Value() + value.
 //
@@ -66,25 +80,87 @@ func ConcatStrings(s *Store, strs ...string) RefString {
 // externally this function behaves as if a new allocation is made and the old
 // one freed.
 func AppendString(s *Store, into RefString, value string) RefString {
-	pRef, newCapacity := resizeAndInvalidate[byte](s, into.ref, capacityForSlice(into.length), into.length, len(value))
+	if into.view {
+		panic("cannot append to a RefString created by SubString")
+	}
+	if into.ref.IsPinned() {
+		panic("cannot append to a pinned RefString")
+	}
+
+	newLength := into.length + len(value)
+	if newLength < into.length {
+		panic(fmt.Errorf("AppendString (length %d extra %d) has overflowed int", into.length, len(value)))
+	}
+
+	oldIdx := s.sizeIndex(into.length)
+	newIdx := s.sizeIndex(newLength)
+
+	var pRef pointerstore.RefPointer
+	if oldIdx == newIdx {
+		// The current allocation slot has enough space, we just
+		// re-alloc the current reference
+		pRef = into.ref.Realloc()
+	} else {
+		pRef = s.alloc(newIdx, newLength)
+		s.retag(newIdx, pRef, into.ref, newLength)
+		copy(pRef.Bytes(into.length), into.ref.Bytes(into.length))
+		s.free(oldIdx, into.ref)
+	}
 
-	// We have the capacity available, append the element
-	newRef := newRefString(into.length, pRef)
-	str := newRef.ref.Bytes(newCapacity)
-	copy(str[into.length:], value)
-	newRef.length += len(value)
+	// We have the capacity available, append the string
+	newRef := newRefString(newLength, pRef)
+	copy(newRef.ref.Bytes(newLength)[into.length:], value)
 
 	return newRef
 }
 
+// Returns a RefString which is a view of the bytes [start:end] of ref. No new
+// allocation is made and nothing is copied, the returned RefString shares
+// ref's allocation.
+//
+// The returned view is only valid for as long as ref is. Once ref is freed, or
+// invalidated by AppendString, the view must never be used again. A best
+// effort has been made to panic if a view is used after its original RefString
+// is freed, just like any other RefString.
+//
+// A view can't be freed, or appended to, these functions will panic. Views of
+// views are allowed.
+func SubString(s *Store, ref RefString, start, end int) RefString {
+	str := ref.Value()
+	if start < 0 || end < start || end > len(str) {
+		panic(fmt.Errorf("SubString [%d:%d] out of range for string of length %d", start, end, len(str)))
+	}
+
+	return RefString{
+		length: end - start,
+		offset: ref.offset + start,
+		view:   true,
+		ref:    ref.ref,
+	}
+}
+
 // Frees the allocation referenced by r. After this call returns r must never
 // be used again. Any use of the string referenced by r will have
 // unpredicatable behaviour.
 func FreeString(s *Store, r RefString) {
-	idx := indexForSize(r.length)
+	if r.view {
+		panic("cannot free a RefString created by SubString, free the original RefString instead")
+	}
+
+	idx := s.sizeIndex(r.length)
 	s.free(idx, r.ref)
 }
 
+// Frees the allocation referenced by r, like FreeString. Instead of
+// panicking an error is returned if r is nil or a view, if the string has
+// already been freed, if the string is pinned or if s is sealed.
+func TryFreeString(s *Store, r RefString) error {
+	if r.view {
+		return ErrView
+	}
+	return s.tryFree(s.sizeIndex(r.length), r.ref)
+}
+
 // A reference to a string. This reference allows us to gain access to an
 // allocated string directly.
 //
@@ -93,7 +169,12 @@ func FreeString(s *Store, r RefString) {
 // contain any conventional Go pointers, unlike native strings.
 type RefString struct {
 	length int
-	ref    pointerstore.RefPointer
+	// The position of this string within its allocation, only non-zero for
+	// views created by SubString
+	offset int
+	// Indicates whether this RefString was created by SubString
+	view bool
+	ref  pointerstore.RefPointer
 }
 
 func newRefString(length int, ref pointerstore.RefPointer) RefString {
@@ -115,7 +196,22 @@ func (r *RefString) Value() string {
 	if r.IsNil() {
 		return ""
 	}
-	return unsafe.String((*byte)((unsafe.Pointer)(r.ref.DataPtr())), r.length)
+	return unsafe.String((*byte)(unsafe.Add((unsafe.Pointer)(r.ref.DataPtr()), r.offset)), r.length)
+}
+
+// Returns the raw string pointed to by this RefString, like Value. Instead of
+// panicking an error is returned if the string has been freed, see
+// ErrFreedReference and ErrStaleReference. Like Value, a nil RefString
+// returns the empty string.
+func (r *RefString) TryValue() (string, error) {
+	if r.IsNil() {
+		return "", nil
+	}
+	ptr, err := r.ref.TryDataPtr()
+	if err != nil {
+		return "", err
+	}
+	return unsafe.String((*byte)(unsafe.Add((unsafe.Pointer)(ptr), r.offset)), r.length), nil
 }
 
 // Returns true if this RefString does not point to an allocated string, false
@@ -124,6 +220,13 @@ func (r *RefString) IsNil() bool {
 	return r.ref.IsNil()
 }
 
+// Returns the length, in bytes, of the string referenced by r. Unlike
+// len(r.Value()) this doesn't access the string. The length of a nil
+// RefString is 0.
+func (r *RefString) Len() int {
+	return r.length
+}
+
 // Returns the stats for the allocations size of a string of length.
 //
 // It is important to note that these statistics apply to the size class
@@ -131,7 +234,7 @@ func (r *RefString) IsNil() bool {
 // this _size_ including allocations for non-slice types.
 func StatsForString(s *Store, length int) pointerstore.Stats {
 	stats := s.Stats()
-	idx := indexForSize(length)
+	idx := s.storeIndex(s.sizeIndex(length))
 	return stats[idx]
 }
 
@@ -142,6 +245,6 @@ func StatsForString(s *Store, length int) pointerstore.Stats {
 // allocations for non-string types.
 func ConfForString(s *Store, length int) pointerstore.AllocConfig {
 	configs := s.AllocConfigs()
-	idx := indexForSize(length)
+	idx := s.storeIndex(s.sizeIndex(length))
 	return configs[idx]
 }
@@ -192,6 +192,10 @@ func Test_String_SizedStats(t *testing.T) {
 				// Larger objects will require a slab per allocation
 				expectedStats.Slabs = 2
 			}
+			expectedStats.MappedBytes = expectedStats.Slabs * int(conf.TotalSlabSize)
+			expectedStats.FreeBytes = expectedStats.Slabs * int(conf.TotalObjectSize)
+			expectedStats.RequestedBytes = 3 * length
+			expectedStats.AllocatedBytes = 3 * int(conf.ObjectSize)
 
 			actualStats := StatsForString(os, length)
 
@@ -200,6 +204,30 @@ func Test_String_SizedStats(t *testing.T) {
 	}
 }
 
+// Demonstrate that the bytes wasted by rounding strings up to the size of
+// their size class are measurable
+func Test_String_RequestedBytes(t *testing.T) {
+	os := NewSized(1 << 8)
+	defer func() {
+		assert.NoError(t, os.Destroy())
+	}()
+
+	// Strings of length 5 to 8 are all allocated in the 8 byte size class
+	for _, str := range []string{"12345", "123456", "1234567", "12345678"} {
+		AllocStringFromString(os, str)
+	}
+
+	stats := StatsForString(os, 8)
+	assert.Equal(t, 5+6+7+8, stats.RequestedBytes)
+	assert.Equal(t, 4*8, stats.AllocatedBytes)
+
+	// Slices record the bytes of the capacity requested
+	AllocSlice[int32](os, 0, 3)
+	stats = StatsForSlice[int32](os, 3)
+	assert.Equal(t, 3*4, stats.RequestedBytes)
+	assert.Equal(t, 16, stats.AllocatedBytes)
+}
+
 func Test_String_AppendString(t *testing.T) {
 	os := New()
 	defer func() {
@@ -287,3 +315,87 @@ func Test_String_ConcatStrings(t *testing.T) {
 		assert.Equal(t, expectedString, r.Value())
 	}
 }
+
+// Demonstrate that SubString creates views which share the original string's
+// allocation, including views of views
+func Test_String_SubString(t *testing.T) {
+	ss := NewSized(1 << 8)
+	defer func() {
+		assert.NoError(t, ss.Destroy())
+	}()
+
+	value := "0123456789"
+	ref := AllocStringFromString(ss, value)
+
+	for start := 0; start <= len(value); start++ {
+		for end := start; end <= len(value); end++ {
+			view := SubString(ss, ref, start, end)
+			assert.Equal(t, value[start:end], view.Value())
+			assert.Equal(t, end-start, view.Len())
+
+			// Views of views
+			for subStart := 0; subStart <= end-start; subStart++ {
+				for subEnd := subStart; subEnd <= end-start; subEnd++ {
+					subView := SubString(ss, view, subStart, subEnd)
+					assert.Equal(t, value[start+subStart:start+subEnd], subView.Value())
+				}
+			}
+		}
+	}
+
+	view := SubString(ss, ref, 3, 6)
+
+	// Out of range views panic
+	assert.Panics(t, func() { SubString(ss, ref, -1, 5) })
+	assert.Panics(t, func() { SubString(ss, ref, 6, 5) })
+	assert.Panics(t, func() { SubString(ss, ref, 0, 11) })
+	assert.Panics(t, func() { SubString(ss, view, 0, 4) })
+
+	// Views can't be freed or appended to
+	assert.Panics(t, func() { FreeString(ss, view) })
+	assert.Panics(t, func() { AppendString(ss, view, "more") })
+
+	// Once the original is freed the view can't be used, but its length
+	// can still be read
+	FreeString(ss, ref)
+	assert.Panics(t, func() { view.Value() })
+	assert.Equal(t, 3, view.Len())
+
+	nilRef := RefString{}
+	assert.Equal(t, 0, nilRef.Len())
+}
+
+// Demonstrate that CloneString creates an independent copy of a string, in the
+// same Store or a different Store
+func Test_String_Clone(t *testing.T) {
+	ss := NewSized(1 << 8)
+	otherSs := NewSized(1 << 8)
+	defer func() {
+		assert.NoError(t, ss.Destroy())
+		assert.NoError(t, otherSs.Destroy())
+	}()
+
+	r := AllocStringFromString(ss, "cloned string")
+	view := SubString(ss, r, 0, 6)
+
+	for _, store := range []*Store{ss, otherSs} {
+		clone := CloneString(store, r)
+		assert.Equal(t, r.Value(), clone.Value())
+		FreeString(store, clone)
+
+		// Cloning a view gives an ordinary string, which can be
+		// appended to and freed
+		viewClone := CloneString(store, view)
+		assert.Equal(t, "cloned", viewClone.Value())
+		viewClone = AppendString(store, viewClone, "!")
+		assert.Equal(t, "cloned!", viewClone.Value())
+		FreeString(store, viewClone)
+	}
+
+	// The original is unaffected by freeing its clones
+	assert.Equal(t, "cloned string", r.Value())
+
+	// Cloning a nil reference gives a nil reference
+	nilClone := CloneString(ss, RefString{})
+	assert.True(t, nilClone.IsNil())
+}
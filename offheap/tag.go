@@ -0,0 +1,91 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+package offheap
+
+import (
+	"fmt"
+
+	"github.com/fmstephe/memorymanager/offheap/internal/pointerstore"
+)
+
+// The largest tag which can be given to an allocation, see AllocObjectTagged
+const MaxTag = pointerstore.MaxTag
+
+// Allocates an object of type T, like AllocObject, attributing the allocation
+// to tag.
+//
+// Tags allow the memory used by a Store to be attributed to its owners, such
+// as the tenants or subsystems of a service, without keeping separate
+// accounts. Each allocation is counted in the TagStats for its tag until it
+// is freed. tag must be between 1 and MaxTag, allocations made without a tag
+// aren't counted in any TagStats.
+func AllocObjectTagged[T any](s *Store, tag int) RefObject[T] {
+	checkTag(tag)
+	r := AllocObject[T](s)
+	s.tag(typeIndex[T](s), r.ref, tag, rawSizeForType[T]())
+	return r
+}
+
+// Allocates a slice of T, like AllocSlice, attributing the allocation to tag,
+// see AllocObjectTagged. When the slice grows into a new allocation, e.g. by
+// Append, the new allocation has the same tag. The same is true of strings
+// grown by AppendString.
+func AllocSliceTagged[T any](s *Store, length, requestedCapacity int, tag int) RefSlice[T] {
+	checkTag(tag)
+	r := AllocSlice[T](s, length, requestedCapacity)
+	s.tag(sliceIndex[T](s, r.capacity), r.ref, tag, rawSizeForType[T]()*requestedCapacity)
+	return r
+}
+
+// Allocates a copy of str, like AllocStringFromString, attributing the
+// allocation to tag, see AllocObjectTagged.
+func AllocStringTagged(s *Store, str string, tag int) RefString {
+	checkTag(tag)
+	r := AllocStringFromString(s, str)
+	s.tag(s.sizeIndex(len(str)), r.ref, tag, len(str))
+	return r
+}
+
+// Returns the statistics for each tag given to an allocation in this Store,
+// summed across every size class and pool. Tags which have never been used
+// are omitted.
+func (s *Store) TagStats() map[int]pointerstore.TagStats {
+	tagStats := map[int]pointerstore.TagStats{}
+	for _, classes := range s.allPools() {
+		for _, class := range classes {
+			store := class.loaded()
+			if store == nil {
+				continue
+			}
+			for tag, stats := range store.TagStats() {
+				tagStats[tag] = tagStats[tag].Add(stats)
+			}
+		}
+	}
+	return tagStats
+}
+
+// Records that r, just allocated from the size class idx with requested
+// bytes, belongs to tag
+func (s *Store) tag(idx int, r pointerstore.RefPointer, tag int, requested int) {
+	s.classStore(r.Pool(), s.storeIndex(idx)).Tag(r, tag, uint64(requested))
+}
+
+// Gives r, just allocated from the size class idx with requested bytes to
+// replace the allocation old, the same tag as old. This keeps a tagged slice,
+// or string, attributed to its tag as it grows.
+func (s *Store) retag(idx int, r, old pointerstore.RefPointer, requested int) {
+	if tag := old.Tag(); tag != 0 {
+		s.tag(idx, r, tag, requested)
+	}
+}
+
+// Panics if tag can't be given to an allocation. This is checked before
+// allocating, so an invalid tag doesn't leak an allocation.
+func checkTag(tag int) {
+	if tag < 1 || tag > MaxTag {
+		panic(fmt.Errorf("tag %d must be between 1 and %d", tag, MaxTag))
+	}
+}
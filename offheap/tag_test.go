@@ -0,0 +1,92 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+package offheap
+
+import (
+	"testing"
+
+	"github.com/fmstephe/memorymanager/offheap/internal/pointerstore"
+	"github.com/stretchr/testify/assert"
+)
+
+// Demonstrate that the memory used by each tag is reported by TagStats, across
+// every size class, and that tagged slices and strings keep their tag as they
+// grow
+func TestTagStats(t *testing.T) {
+	os := NewSized(1 << 10)
+	defer func() {
+		assert.NoError(t, os.Destroy())
+	}()
+
+	const tenantA = 1
+	const tenantB = 2
+
+	assert.Panics(t, func() { AllocObjectTagged[int64](os, 0) })
+	assert.Panics(t, func() { AllocObjectTagged[int64](os, MaxTag+1) })
+	assert.Equal(t, 0, os.TotalStats().Allocs)
+
+	obj := AllocObjectTagged[int64](os, tenantA)
+	slice := AllocSliceTagged[int32](os, 0, 2, tenantA)
+	str := AllocStringTagged(os, "hello", tenantB)
+	AllocObject[int64](os)
+
+	assert.Equal(t, map[int]pointerstore.TagStats{
+		tenantA: {Allocs: 2, Live: 2, LiveBytes: 16, RequestedBytes: 16},
+		tenantB: {Allocs: 1, Live: 1, LiveBytes: 8, RequestedBytes: 5},
+	}, os.TagStats())
+
+	// Growing moves the slice, and the string, into larger allocations
+	// which keep their tag
+	for i := range 10 {
+		slice = Append(os, slice, int32(i))
+	}
+	str = AppendString(os, str, " world")
+	a := os.TagStats()[tenantA]
+	assert.Equal(t, 2, a.Live)
+	assert.Equal(t, 8+16*4, a.LiveBytes)
+	b := os.TagStats()[tenantB]
+	assert.Equal(t, 1, b.Live)
+	assert.Equal(t, 16, b.LiveBytes)
+
+	FreeObject(os, obj)
+	FreeSlice(os, slice)
+	FreeString(os, str)
+	for tag, stats := range os.TagStats() {
+		assert.Equal(t, 0, stats.Live, tag)
+		assert.Equal(t, stats.Allocs, stats.Frees, tag)
+	}
+	assert.Equal(t, 1, os.TotalStats().Live)
+}
+
+// Show that tags are reported for Stores with pools and caches
+func TestTagStats_StoreKinds(t *testing.T) {
+	for name, os := range map[string]*Store{
+		"pools":  NewWithPools(1<<10, 4),
+		"caches": NewWithCaches(1<<10, 8),
+	} {
+		t.Run(name, func(t *testing.T) {
+			defer func() {
+				assert.NoError(t, os.Destroy())
+			}()
+
+			refs := []RefObject[int64]{}
+			for i := range 100 {
+				refs = append(refs, AllocObjectTagged[int64](os, 1+i%4))
+			}
+			for _, r := range refs[:50] {
+				FreeObject(os, r)
+			}
+
+			tagStats := os.TagStats()
+			assert.Len(t, tagStats, 4)
+			live := 0
+			for _, stats := range tagStats {
+				assert.Equal(t, 25, stats.Allocs)
+				live += stats.Live
+			}
+			assert.Equal(t, 50, live)
+		})
+	}
+}
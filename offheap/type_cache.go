@@ -0,0 +1,51 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+package offheap
+
+import (
+	"reflect"
+	"sync"
+)
+
+// Information about a type which is needed on every allocation. Gathering
+// this information requires reflection, which is far too slow to perform on
+// every allocation, so it is gathered once for each type and cached.
+type typeInfo struct {
+	// The size of the type, the same as unsafe.Sizeof
+	rawSize int
+	// The size of the type rounded up to a power of two, see
+	// residentObjectSize
+	residentSize int
+	// The index of the default power of two size class for this type
+	index int
+	// If the type contains pointers this error describes them, otherwise
+	// nil
+	pointerErr error
+}
+
+// Maps reflect.Type to *typeInfo
+var typeInfoCache sync.Map
+
+// Returns the cached typeInfo for T, creating it if this is the first time
+// the type has been seen.
+func typeInfoFor[T any]() *typeInfo {
+	t := reflect.TypeFor[T]()
+	if info, ok := typeInfoCache.Load(t); ok {
+		return info.(*typeInfo)
+	}
+
+	rawSize := int(t.Size())
+	residentSize := residentObjectSize(rawSize)
+	info := &typeInfo{
+		rawSize:      rawSize,
+		residentSize: residentSize,
+		index:        indexForSize(residentSize),
+		pointerErr:   containsNoPointers[T](),
+	}
+
+	// If another goroutine raced us to create the typeInfo, we use theirs
+	actual, _ := typeInfoCache.LoadOrStore(t, info)
+	return actual.(*typeInfo)
+}
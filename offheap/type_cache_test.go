@@ -0,0 +1,120 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+package offheap
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Demonstrate that the typeInfo for a type is created once and then reused
+func TestTypeInfoFor(t *testing.T) {
+	info := typeInfoFor[fortyBytes]()
+	assert.Same(t, info, typeInfoFor[fortyBytes]())
+
+	assert.Equal(t, 40, info.rawSize)
+	assert.Equal(t, 64, info.residentSize)
+	assert.Equal(t, 6, info.index)
+	assert.NoError(t, info.pointerErr)
+
+	// Distinct types have distinct typeInfo, even if they are the same size
+	assert.NotSame(t, info, typeInfoFor[[40]byte]())
+
+	// Pointer errors are cached too
+	assert.EqualError(t, typeInfoFor[badStruct]().pointerErr, "found pointer(s): (offheap.badStruct)badField<string>")
+	assert.Same(t, typeInfoFor[badStruct](), typeInfoFor[badStruct]())
+}
+
+// Demonstrate that allocating, accessing and freeing objects and slices
+// doesn't allocate on the Go heap
+func TestAllocPathDoesNotAllocate(t *testing.T) {
+	os := New()
+	defer func() {
+		assert.NoError(t, os.Destroy())
+	}()
+
+	// Warm up the type cache
+	FreeObject(os, AllocObject[fortyBytes](os))
+	FreeSlice(os, AllocSlice[fortyBytes](os, 10, 10))
+
+	assert.Equal(t, 0.0, testing.AllocsPerRun(1000, func() {
+		r := AllocObject[fortyBytes](os)
+		r.Value().a = 1
+		FreeObject(os, r)
+	}))
+
+	assert.Equal(t, 0.0, testing.AllocsPerRun(1000, func() {
+		r := AllocSlice[fortyBytes](os, 10, 10)
+		r.Value()[0].a = 1
+		FreeSlice(os, r)
+	}))
+}
+
+func BenchmarkAllocFreeObject(b *testing.B) {
+	os := New()
+	defer os.Destroy()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for range b.N {
+		FreeObject(os, AllocObject[fortyBytes](os))
+	}
+}
+
+func BenchmarkAllocFreeSlice(b *testing.B) {
+	os := New()
+	defer os.Destroy()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for range b.N {
+		FreeSlice(os, AllocSlice[fortyBytes](os, 10, 10))
+	}
+}
+
+func BenchmarkAllocFreeSlice_Sizes(b *testing.B) {
+	os := New()
+	defer os.Destroy()
+
+	for _, capacity := range []int{1, 10, 100, 1000} {
+		b.Run(fmt.Sprintf("capacity-%d", capacity), func(b *testing.B) {
+			b.ReportAllocs()
+			for range b.N {
+				FreeSlice(os, AllocSlice[fortyBytes](os, capacity, capacity))
+			}
+		})
+	}
+}
+
+func BenchmarkAppend(b *testing.B) {
+	os := New()
+	defer os.Destroy()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for range b.N {
+		r := AllocSlice[fortyBytes](os, 0, 1)
+		for range 16 {
+			r = Append(os, r, fortyBytes{})
+		}
+		FreeSlice(os, r)
+	}
+}
+
+func BenchmarkTypeInfoFor(b *testing.B) {
+	b.ReportAllocs()
+	for range b.N {
+		typeInfoFor[fortyBytes]()
+	}
+}
+
+func BenchmarkContainsNoPointers(b *testing.B) {
+	b.ReportAllocs()
+	for range b.N {
+		_ = containsNoPointers[fortyBytes]()
+	}
+}
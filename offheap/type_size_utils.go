@@ -7,7 +7,6 @@ package offheap
 import (
 	"fmt"
 	"math/bits"
-	"reflect"
 	"unsafe"
 )
 
@@ -61,24 +60,45 @@ func wordSizeBits() int {
 	return int(unsafe.Sizeof(uintptr(0)) * 8)
 }
 
-func indexForType[T any]() int {
-	size := sizeForType[T]()
-	return indexForSize(size)
+// Returns the index of the size class in s used for allocations of type T
+func typeIndex[T any](s *Store) int {
+	return typeInfoFor[T]().typeIndex(s)
+}
+
+// Returns the index of the size class in s used for allocations of []T with
+// capacity
+func sliceIndex[T any](s *Store, capacity int) int {
+	return typeInfoFor[T]().sliceIndex(s, capacity)
+}
+
+// Returns the index of the size class in s used for allocations of the type
+// described by info
+func (info *typeInfo) typeIndex(s *Store) int {
+	if s.sizeClasses == nil {
+		return info.index
+	}
+	return s.sizeIndex(info.rawSize)
 }
 
-func indexForSlice[T any](capacity int) int {
-	sliceSize := sizeForSlice[T](capacity)
-	return indexForSize(sliceSize)
+// Returns the index of the size class in s used for allocations of a slice,
+// with capacity, of the type described by info
+func (info *typeInfo) sliceIndex(s *Store, capacity int) int {
+	if s.sizeClasses == nil {
+		return indexForSize(residentObjectSize(info.residentSize * capacity))
+	}
+	return s.sizeIndex(info.rawSize * capacity)
 }
 
-func sizeForSlice[T any](capacity int) int {
-	tSize := sizeForType[T]()
-	return residentObjectSize(tSize * capacity)
+func rawSizeForType[T any]() int {
+	return typeInfoFor[T]().rawSize
+}
+
+func indexForType[T any]() int {
+	return typeInfoFor[T]().index
 }
 
 func sizeForType[T any]() int {
-	t := reflect.TypeFor[T]()
-	return residentObjectSize(int(t.Size()))
+	return typeInfoFor[T]().residentSize
 }
 
 func indexForSize(size int) int {
@@ -110,10 +130,10 @@ func residentObjectSize(requestedSize int) int {
 	}
 	residentSize := nextPowerOfTwo(requestedSize)
 	if residentSize > maxAllocSize {
-		panic(fmt.Errorf("allocation size (%d, resident %d) too large, can't exceed %d", requestedSize, residentSize, maxAllocSize))
+		panic(fmt.Errorf("allocation size (%d, resident %d) too large, can't exceed %d: %w", requestedSize, residentSize, maxAllocSize, ErrSizeLimit))
 	}
 	if residentSize < 0 {
-		panic(fmt.Errorf("allocation size (%d, resident %d) too large, has overflowed int", requestedSize, residentSize))
+		panic(fmt.Errorf("allocation size (%d, resident %d) too large, has overflowed int: %w", requestedSize, residentSize, ErrSizeLimit))
 	}
 	return residentSize
 }
@@ -0,0 +1,148 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+package offheap
+
+import (
+	"fmt"
+
+	"github.com/fmstephe/memorymanager/offheap/internal/pointerstore"
+)
+
+// A TypedStore allocates objects of exactly one type, T. It offers a smaller
+// API than Store, and is a little faster, because the size of T and the check
+// that T contains no pointers are performed once when the TypedStore is
+// created rather than on every allocation.
+//
+// Each allocation is exactly the size of T, where T's size is a multiple of 8
+// or a power of two, otherwise the size is rounded up to a power of two. So a
+// TypedStore can also use less memory than a Store, which always rounds
+// allocations up to a power of two.
+//
+// The RefObject values allocated by a TypedStore are ordinary RefObjects, but
+// they must only be freed by the TypedStore which allocated them, freeing them
+// to any other TypedStore panics. Like Store, a best effort has been made to
+// panic if an object is freed twice or if a freed object is accessed.
+//
+// A TypedStore has the same concurrency guarantees as a Store.
+type TypedStore[T any] struct {
+	store *pointerstore.Store
+}
+
+// Returns a new TypedStore for allocating objects of type T. If the type T
+// contains pointers this function will panic.
+func NewTypedStore[T any]() *TypedStore[T] {
+	return NewTypedStoreSized[T](defaultSlabSize)
+}
+
+// Returns a new TypedStore for allocating objects of type T, whose slabs are
+// at least slabSize. If the type T contains pointers this function will
+// panic.
+func NewTypedStoreSized[T any](slabSize int) *TypedStore[T] {
+	if err := typeInfoFor[T]().pointerErr; err != nil {
+		panic(fmt.Errorf("cannot create TypedStore for generic type containing pointers %w", err))
+	}
+
+	size := max(rawSizeForType[T](), 1)
+	var conf pointerstore.AllocConfig
+	if isPowerOfTwo(size) || size%8 == 0 {
+		conf = pointerstore.NewAllocConfigByExactSize(uint64(size), uint64(slabSize))
+	} else {
+		conf = pointerstore.NewAllocConfigBySize(uint64(size), uint64(slabSize))
+	}
+
+	return &TypedStore[T]{
+		store: pointerstore.New(conf),
+	}
+}
+
+// Allocates an object of type T.
+//
+// The values of fields in the newly allocated object will be arbitrary. Unlike
+// Go allocations objects acquired via Alloc do _not_ have their contents
+// zeroed out.
+func (s *TypedStore[T]) Alloc() RefObject[T] {
+	return newRefObject[T](s.store.Alloc())
+}
+
+// Frees the allocation referenced by r. After this call returns r must never
+// be used again. Any use of the object referenced by r will have
+// unpredicatable behaviour. Panics if r was not allocated by this TypedStore.
+func (s *TypedStore[T]) Free(r RefObject[T]) {
+	if err := s.TryFree(r); err != nil {
+		panic(err)
+	}
+}
+
+// Frees the allocation referenced by r, like Free. Instead of panicking an
+// error is returned if r can't be freed. The error wraps
+// ErrForeignReference if r was not allocated by this TypedStore.
+func (s *TypedStore[T]) TryFree(r RefObject[T]) error {
+	if r.ref.IsNil() {
+		return ErrNilReference
+	}
+	if err := s.store.CheckOwner(r.ref, "free"); err != nil {
+		return err
+	}
+	return s.store.TryFree(r.ref)
+}
+
+// Returns a pointer to the object referenced by r. This is the same as
+// calling r.Value().
+func (s *TypedStore[T]) Value(r RefObject[T]) *T {
+	return r.Value()
+}
+
+// Moves live objects into the free slots at the start of the TypedStore, and
+// releases every slab which no longer contains any live objects back to the
+// operating system. Returns the number of slabs released.
+//
+// Over time a TypedStore where many objects are freed can end up with a
+// small number of live objects spread across a large number of slabs.
+// Because a slab is only released when it is completely empty, Compact is
+// the only way to reduce the memory used by such a TypedStore.
+//
+// Pinned objects are never moved, see RefObject.Pin.
+//
+// Each time an object is moved, moved is called with its old and new
+// references. After Compact returns every old reference which was passed to
+// moved is invalid, and must be replaced by the new reference. The moved
+// callback must not allocate from, or free to, this TypedStore.
+//
+// Compact must not be called concurrently with any other use of this
+// TypedStore, or of any of its objects.
+func (s *TypedStore[T]) Compact(moved func(oldRef, newRef RefObject[T])) int {
+	return s.store.Compact(func(oldRef, newRef pointerstore.RefPointer) {
+		moved(newRefObject[T](oldRef), newRefObject[T](newRef))
+	})
+}
+
+// Advises the operating system that the memory used by free object slots is
+// no longer needed, in every slab selected by policy. Returns the number of
+// bytes advised. Unlike Compact no objects are moved, see Store.Reclaim.
+func (s *TypedStore[T]) Reclaim(policy ReclaimPolicy) (int, error) {
+	return s.store.Reclaim(policy.MinFreeFraction, policy.Lazy)
+}
+
+// Holds freed object slots in a quarantine, limited by policy, before they
+// can be allocated again, see Store.SetQuarantine.
+func (s *TypedStore[T]) SetQuarantine(policy QuarantinePolicy) {
+	s.store.SetQuarantine(policy.slotsFor(int(s.store.AllocConfig().ObjectSize)))
+}
+
+// Returns the statistics for this TypedStore.
+func (s *TypedStore[T]) Stats() pointerstore.Stats {
+	return s.store.Stats()
+}
+
+// Returns the allocation config for this TypedStore.
+func (s *TypedStore[T]) AllocConfig() pointerstore.AllocConfig {
+	return s.store.AllocConfig()
+}
+
+// Releases the memory allocated by the TypedStore back to the operating
+// system. After this method is called the TypedStore is completely unusable.
+func (s *TypedStore[T]) Destroy() error {
+	return s.store.Destroy()
+}
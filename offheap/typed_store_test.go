@@ -0,0 +1,205 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+package offheap
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Demonstrate that we can allocate, modify and get objects from a TypedStore.
+// We allocate enough objects to need more than one slab.
+func Test_TypedStore_NewModifyGet(t *testing.T) {
+	ts := NewTypedStoreSized[MutableStruct](1 << 8)
+	defer func() {
+		assert.NoError(t, ts.Destroy())
+	}()
+
+	refs := make([]RefObject[MutableStruct], ts.AllocConfig().ObjectsPerSlab*3)
+	for i := range refs {
+		r := ts.Alloc()
+		ts.Value(r).Field = i
+		refs[i] = r
+	}
+
+	stats := ts.Stats()
+	assert.Equal(t, len(refs), stats.Allocs)
+	assert.Equal(t, len(refs), stats.Live)
+	assert.Equal(t, 3, stats.Slabs)
+
+	for i, r := range refs {
+		assert.Equal(t, i, r.Value().Field)
+		ts.Free(r)
+	}
+
+	stats = ts.Stats()
+	assert.Equal(t, 0, stats.Live)
+	assert.Equal(t, len(refs), stats.Frees)
+}
+
+// Demonstrate that freed objects can't be accessed or freed again
+func Test_TypedStore_FreePanics(t *testing.T) {
+	ts := NewTypedStoreSized[MutableStruct](1 << 8)
+	defer func() {
+		assert.NoError(t, ts.Destroy())
+	}()
+
+	r := ts.Alloc()
+	ts.Free(r)
+
+	assert.Panics(t, func() { ts.Value(r) })
+	assert.Panics(t, func() { ts.Free(r) })
+
+	// The slot is reused, but the old reference still can't be used
+	newR := ts.Alloc()
+	assert.Panics(t, func() { ts.Value(r) })
+	assert.Panics(t, func() { ts.Free(r) })
+	assert.NotPanics(t, func() { ts.Free(newR) })
+}
+
+// Demonstrate that objects are allocated using the exact size of their type,
+// Demonstrate that an object can only be freed by the TypedStore which
+// allocated it
+func Test_TypedStore_FreeForeign(t *testing.T) {
+	ts := NewTypedStoreSized[MutableStruct](1 << 8)
+	other := NewTypedStoreSized[MutableStruct](1 << 8)
+	os := NewSized(1 << 8)
+	defer func() {
+		assert.NoError(t, ts.Destroy())
+		assert.NoError(t, other.Destroy())
+		assert.NoError(t, os.Destroy())
+	}()
+
+	// Both references are in slot 0 of pool 0 of their store
+	r := ts.Alloc()
+	foreign := other.Alloc()
+	require.Equal(t, r.ref.Slot(), foreign.ref.Slot())
+
+	var misuseErr *MisuseError
+	err := ts.TryFree(foreign)
+	assert.ErrorIs(t, err, ErrForeignReference)
+	require.ErrorAs(t, err, &misuseErr)
+	assert.Equal(t, "free", misuseErr.Operation)
+	assert.Panics(t, func() { ts.Free(foreign) })
+
+	// An object allocated by a Store is foreign too
+	assert.ErrorIs(t, ts.TryFree(AllocObject[MutableStruct](os)), ErrForeignReference)
+	assert.ErrorIs(t, ts.TryFree(RefObject[MutableStruct]{}), ErrNilReference)
+
+	// Nothing was freed, and each object can still be freed by its own
+	// TypedStore
+	assert.Equal(t, 0, ts.Stats().Frees)
+	assert.Equal(t, 0, other.Stats().Frees)
+	assert.NoError(t, other.TryFree(foreign))
+	ts.Free(r)
+	assert.ErrorIs(t, ts.TryFree(r), ErrFreedReference)
+}
+
+// where possible
+func Test_TypedStore_Sizes(t *testing.T) {
+	forty := NewTypedStore[fortyBytes]()
+	assert.Equal(t, uint64(40), forty.AllocConfig().ObjectSize)
+	assert.NoError(t, forty.Destroy())
+
+	// Types which aren't a multiple of 8 are rounded up to a power of two
+	three := NewTypedStore[[3]byte]()
+	assert.Equal(t, uint64(4), three.AllocConfig().ObjectSize)
+	assert.NoError(t, three.Destroy())
+
+	twelve := NewTypedStore[[3]int32]()
+	assert.Equal(t, uint64(16), twelve.AllocConfig().ObjectSize)
+	assert.NoError(t, twelve.Destroy())
+
+	// Zero sized types occupy a single byte
+	zero := NewTypedStore[struct{}]()
+	assert.Equal(t, uint64(1), zero.AllocConfig().ObjectSize)
+	zero.Free(zero.Alloc())
+	assert.NoError(t, zero.Destroy())
+}
+
+// Demonstrate that we can't create a TypedStore for a type containing
+// pointers
+// Demonstrate that compacting a TypedStore releases empty slabs, and that
+// every moved object is reported with its new reference
+func Test_TypedStore_Compact(t *testing.T) {
+	ts := NewTypedStoreSized[int64](1 << 8)
+	defer func() {
+		assert.NoError(t, ts.Destroy())
+	}()
+
+	perSlab := int(ts.AllocConfig().ObjectsPerSlab)
+	count := perSlab * 10
+
+	refs := []RefObject[int64]{}
+	for i := range count {
+		r := ts.Alloc()
+		*r.Value() = int64(i)
+		refs = append(refs, r)
+	}
+
+	// Keep every fourth object, scattering the live objects across every
+	// slab
+	live := map[RefObject[int64]]int64{}
+	freed := []RefObject[int64]{}
+	for i, r := range refs {
+		if i%4 == 3 {
+			live[r] = int64(i)
+		} else {
+			ts.Free(r)
+			freed = append(freed, r)
+		}
+	}
+	assert.Equal(t, 10, ts.Stats().Slabs)
+
+	released := ts.Compact(func(oldRef, newRef RefObject[int64]) {
+		value, ok := live[oldRef]
+		require.True(t, ok)
+		delete(live, oldRef)
+		live[newRef] = value
+	})
+
+	assert.Equal(t, 10-(len(live)+perSlab-1)/perSlab, released)
+	assert.Equal(t, 10-released, ts.Stats().Slabs)
+	assert.Equal(t, count/4, ts.Stats().Live)
+
+	for r, value := range live {
+		assert.Equal(t, value, *r.Value())
+	}
+
+	// The first slot was freed, and has now been filled by a moved
+	// object, so the stale reference can't be used
+	assert.Panics(t, func() { freed[0].Value() })
+
+	// The store can still be used after compaction
+	for range count {
+		r := ts.Alloc()
+		*r.Value() = -1
+		live[r] = -1
+	}
+	for r, value := range live {
+		assert.Equal(t, value, *r.Value())
+		ts.Free(r)
+	}
+	assert.Equal(t, 0, ts.Stats().Live)
+}
+
+func Test_TypedStore_CheckGenericTypeForPointers(t *testing.T) {
+	assert.Panics(t, func() { NewTypedStore[*int]() })
+	assert.Panics(t, func() { NewTypedStore[string]() })
+	assert.Panics(t, func() { NewTypedStore[[]int]() })
+}
+
+func BenchmarkTypedStore_AllocFree(b *testing.B) {
+	ts := NewTypedStore[MutableStruct]()
+	defer ts.Destroy()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for range b.N {
+		ts.Free(ts.Alloc())
+	}
+}
@@ -0,0 +1,272 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+package offheap
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+)
+
+// The memory measured by a WatermarkPolicy
+type WatermarkMetric uint8
+
+const (
+	// The bytes mapped by a Store, the MappedBytes of Store.TotalStats
+	WatermarkMappedBytes WatermarkMetric = iota + 1
+	// The bytes used by live allocations, the LiveBytes of Store.TotalStats
+	WatermarkLiveBytes
+)
+
+func (m WatermarkMetric) String() string {
+	switch m {
+	case WatermarkMappedBytes:
+		return "mapped-bytes"
+	case WatermarkLiveBytes:
+		return "live-bytes"
+	default:
+		return fmt.Sprintf("WatermarkMetric(%d)", m)
+	}
+}
+
+// The watermark a Store's memory has reached, see Store.OnWatermark
+type WatermarkLevel uint8
+
+const (
+	// Below the soft watermark
+	WatermarkNormal WatermarkLevel = iota
+	// At or above the soft watermark
+	WatermarkSoft
+	// At or above the hard watermark
+	WatermarkHard
+)
+
+func (l WatermarkLevel) String() string {
+	switch l {
+	case WatermarkNormal:
+		return "normal"
+	case WatermarkSoft:
+		return "soft"
+	case WatermarkHard:
+		return "hard"
+	default:
+		return fmt.Sprintf("WatermarkLevel(%d)", l)
+	}
+}
+
+// A WatermarkPolicy configures the watermarks watched by Store.OnWatermark
+type WatermarkPolicy struct {
+	// The memory to watch
+	Metric WatermarkMetric
+	// The soft and hard watermarks, in bytes. A watermark of 0 is not
+	// watched. When both are watched Soft must be less than Hard.
+	Soft int
+	Hard int
+	// The fraction of a watermark which the memory must fall below, after
+	// reaching that watermark, before the watermark is considered to be
+	// left. With a Hysteresis of 0.1 and a Soft watermark of 1000 bytes, the
+	// soft watermark is reached at 1000 bytes and left at 900 bytes. This
+	// prevents memory which hovers around a watermark from repeatedly
+	// calling the hook. Hysteresis must be between 0 and 1.
+	Hysteresis float64
+	// How often the memory is measured. With an Interval of 0 the memory is
+	// measured every 100 milliseconds.
+	Interval time.Duration
+	// The minimum time between calls to the hook. Changes in level within
+	// this time are combined into a single call, reporting the latest
+	// level. With a MinInterval of 0 the hook calls are limited only by
+	// Interval.
+	MinInterval time.Duration
+}
+
+// The default time between measurements, see WatermarkPolicy.Interval
+const defaultWatermarkInterval = 100 * time.Millisecond
+
+func (p WatermarkPolicy) validate() error {
+	switch {
+	case p.Metric != WatermarkMappedBytes && p.Metric != WatermarkLiveBytes:
+		return fmt.Errorf("unknown watermark metric %s", p.Metric)
+	case p.Soft < 0 || p.Hard < 0:
+		return fmt.Errorf("watermarks (soft %d, hard %d) must not be negative", p.Soft, p.Hard)
+	case p.Soft == 0 && p.Hard == 0:
+		return fmt.Errorf("at least one of the soft and hard watermarks must be set")
+	case p.Soft != 0 && p.Hard != 0 && p.Soft >= p.Hard:
+		return fmt.Errorf("soft watermark (%d) must be less than hard watermark (%d)", p.Soft, p.Hard)
+	case p.Hysteresis < 0 || p.Hysteresis >= 1:
+		return fmt.Errorf("hysteresis (%f) must be at least 0 and less than 1", p.Hysteresis)
+	case p.Interval < 0 || p.MinInterval < 0:
+		return fmt.Errorf("intervals (%s, %s) must not be negative", p.Interval, p.MinInterval)
+	}
+	return nil
+}
+
+// Describes a change in the watermark reached by a Store's memory, see
+// Store.OnWatermark
+type WatermarkEvent struct {
+	Metric WatermarkMetric
+	// The level reached, and the level previously reported to the hook
+	Level    WatermarkLevel
+	Previous WatermarkLevel
+	// The measured memory, in bytes
+	Bytes int
+}
+
+// A Watermark watches the memory of a Store, created by Store.OnWatermark
+type Watermark struct {
+	store  *Store
+	policy WatermarkPolicy
+	hook   func(WatermarkEvent)
+
+	// The level last reported to the hook, and when. These are only used
+	// by the watching goroutine.
+	level    WatermarkLevel
+	reported time.Time
+
+	stopOnce sync.Once
+	done     chan struct{}
+	stopped  chan struct{}
+}
+
+// Registers hook to be called when the memory of s, measured by policy,
+// reaches or leaves a soft or hard watermark. This allows a service to react
+// to growing memory use before it becomes a problem, for example evicting
+// entries from a cache at the soft watermark and shedding load at the hard
+// watermark.
+//
+// The memory is measured periodically, by a goroutine started by
+// OnWatermark, and hook is called from that goroutine. So hook is called
+// asynchronously, not by the allocation or free which crossed a watermark,
+// and may use s. A change in the level reached must persist until the memory
+// is measured to be reported. Each call reports the level reached and the
+// level previously reported. Hook is not called for the initial level if it
+// is WatermarkNormal.
+//
+// Panics if policy is invalid. The returned Watermark is stopped with Stop,
+// or by destroying s.
+func (s *Store) OnWatermark(policy WatermarkPolicy, hook func(WatermarkEvent)) *Watermark {
+	if err := policy.validate(); err != nil {
+		panic(fmt.Errorf("invalid watermark policy %+v: %w", policy, err))
+	}
+	if policy.Interval == 0 {
+		policy.Interval = defaultWatermarkInterval
+	}
+
+	w := &Watermark{
+		store:   s,
+		policy:  policy,
+		hook:    hook,
+		done:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+
+	s.watermarkLock.Lock()
+	s.watermarks = append(s.watermarks, w)
+	s.watermarkLock.Unlock()
+
+	go w.watch()
+	return w
+}
+
+// Stops watching the memory of the Store. Once Stop returns the hook will
+// not be called again. Stop must not be called by the hook.
+func (w *Watermark) Stop() {
+	w.stopOnce.Do(func() {
+		close(w.done)
+	})
+	<-w.stopped
+
+	s := w.store
+	s.watermarkLock.Lock()
+	s.watermarks = slices.DeleteFunc(s.watermarks, func(other *Watermark) bool {
+		return other == w
+	})
+	s.watermarkLock.Unlock()
+}
+
+// Stops every Watermark watching s
+func (s *Store) stopWatermarks() {
+	s.watermarkLock.Lock()
+	watermarks := slices.Clone(s.watermarks)
+	s.watermarkLock.Unlock()
+
+	for _, w := range watermarks {
+		w.Stop()
+	}
+}
+
+func (w *Watermark) watch() {
+	defer close(w.stopped)
+
+	ticker := time.NewTicker(w.policy.Interval)
+	defer ticker.Stop()
+
+	for {
+		w.observe(w.measure(), time.Now())
+		select {
+		case <-w.done:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Returns the current memory of the Store, as measured by the policy
+func (w *Watermark) measure() int {
+	stats := w.store.TotalStats()
+	if w.policy.Metric == WatermarkMappedBytes {
+		return stats.MappedBytes
+	}
+	return stats.LiveBytes
+}
+
+// Calls the hook if bytes, measured at now, has reached a new level and the
+// hook is not rate limited
+func (w *Watermark) observe(bytes int, now time.Time) {
+	level := w.policy.level(w.level, bytes)
+	if level == w.level {
+		return
+	}
+	if !w.reported.IsZero() && now.Sub(w.reported) < w.policy.MinInterval {
+		return
+	}
+
+	event := WatermarkEvent{
+		Metric:   w.policy.Metric,
+		Level:    level,
+		Previous: w.level,
+		Bytes:    bytes,
+	}
+	w.level = level
+	w.reported = now
+	w.hook(event)
+}
+
+// Returns the level reached by bytes, given that current is the level
+// already reached. A level is only left once bytes has fallen below its
+// watermark by the Hysteresis fraction.
+func (p WatermarkPolicy) level(current WatermarkLevel, bytes int) WatermarkLevel {
+	level := WatermarkNormal
+	if p.reached(p.Soft, current >= WatermarkSoft, bytes) {
+		level = WatermarkSoft
+	}
+	if p.reached(p.Hard, current == WatermarkHard, bytes) {
+		level = WatermarkHard
+	}
+	return level
+}
+
+// Indicates whether bytes is at or above watermark. If the watermark was
+// already reached it is still reached until bytes falls below the watermark
+// by the Hysteresis fraction. A watermark of 0 is never reached.
+func (p WatermarkPolicy) reached(watermark int, alreadyReached bool, bytes int) bool {
+	if watermark == 0 {
+		return false
+	}
+	if alreadyReached {
+		return float64(bytes) >= float64(watermark)*(1-p.Hysteresis)
+	}
+	return bytes >= watermark
+}
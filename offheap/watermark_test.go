@@ -0,0 +1,238 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+package offheap
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Returns a Watermark which isn't watching any Store, and the events passed
+// to its hook. Measurements are made by calling observe directly.
+func newTestWatermark(policy WatermarkPolicy) (*Watermark, *[]WatermarkEvent) {
+	events := &[]WatermarkEvent{}
+	w := &Watermark{
+		policy: policy,
+		hook: func(event WatermarkEvent) {
+			*events = append(*events, event)
+		},
+	}
+	return w, events
+}
+
+// Returns the level of each event
+func levels(events []WatermarkEvent) []WatermarkLevel {
+	levels := []WatermarkLevel{}
+	for _, event := range events {
+		levels = append(levels, event.Level)
+	}
+	return levels
+}
+
+// Demonstrate that the hook is called each time a watermark is reached, and
+// left, and that memory hovering around a watermark doesn't repeatedly call
+// the hook
+func TestWatermark_Hysteresis(t *testing.T) {
+	w, events := newTestWatermark(WatermarkPolicy{
+		Metric:     WatermarkLiveBytes,
+		Soft:       1000,
+		Hard:       2000,
+		Hysteresis: 0.1,
+	})
+	now := time.Now()
+
+	// Below the soft watermark nothing is reported
+	w.observe(0, now)
+	w.observe(999, now)
+	assert.Empty(t, *events)
+
+	w.observe(1000, now)
+	assert.Equal(t, []WatermarkEvent{
+		{Metric: WatermarkLiveBytes, Level: WatermarkSoft, Previous: WatermarkNormal, Bytes: 1000},
+	}, *events)
+
+	// Hovering around the soft watermark, above 900 bytes, stays soft
+	w.observe(950, now)
+	w.observe(1050, now)
+	w.observe(901, now)
+	w.observe(1000, now)
+	assert.Len(t, *events, 1)
+
+	// Falling below 900 bytes leaves the soft watermark
+	w.observe(899, now)
+	assert.Equal(t, []WatermarkLevel{WatermarkSoft, WatermarkNormal}, levels(*events))
+
+	// Hovering just below the soft watermark stays normal
+	w.observe(950, now)
+	w.observe(999, now)
+	assert.Len(t, *events, 2)
+
+	// Jumping straight to the hard watermark is reported once
+	w.observe(2500, now)
+	w.observe(1900, now)
+	assert.Equal(t, []WatermarkLevel{WatermarkSoft, WatermarkNormal, WatermarkHard}, levels(*events))
+	assert.Equal(t, WatermarkNormal, (*events)[2].Previous)
+
+	// Falling below 1800 bytes leaves the hard watermark, but not the soft
+	w.observe(1799, now)
+	w.observe(1000, now)
+	assert.Equal(t, []WatermarkLevel{WatermarkSoft, WatermarkNormal, WatermarkHard, WatermarkSoft}, levels(*events))
+
+	// Falling all the way below the soft watermark is reported once
+	w.observe(2000, now)
+	w.observe(0, now)
+	assert.Equal(t, []WatermarkLevel{WatermarkSoft, WatermarkNormal, WatermarkHard, WatermarkSoft, WatermarkHard, WatermarkNormal}, levels(*events))
+}
+
+// Show that a policy with only a hard watermark, and no hysteresis, is
+// reached and left exactly at the watermark
+func TestWatermark_HardOnly(t *testing.T) {
+	w, events := newTestWatermark(WatermarkPolicy{
+		Metric: WatermarkMappedBytes,
+		Hard:   100,
+	})
+	now := time.Now()
+
+	w.observe(99, now)
+	w.observe(100, now)
+	w.observe(100, now)
+	w.observe(99, now)
+	assert.Equal(t, []WatermarkLevel{WatermarkHard, WatermarkNormal}, levels(*events))
+}
+
+// Demonstrate that changes in level are combined while the hook is rate
+// limited, and that the latest level is reported once the limit expires
+func TestWatermark_RateLimited(t *testing.T) {
+	w, events := newTestWatermark(WatermarkPolicy{
+		Metric:      WatermarkLiveBytes,
+		Soft:        1000,
+		Hard:        2000,
+		MinInterval: time.Second,
+	})
+	now := time.Now()
+
+	w.observe(1000, now)
+	require.Len(t, *events, 1)
+
+	// Within the limit, changes of level are not reported
+	w.observe(2000, now.Add(100*time.Millisecond))
+	w.observe(0, now.Add(200*time.Millisecond))
+	w.observe(2000, now.Add(300*time.Millisecond))
+	assert.Len(t, *events, 1)
+
+	// Returning to the reported level within the limit reports nothing
+	w.observe(1000, now.Add(time.Second))
+	assert.Len(t, *events, 1)
+
+	// After the limit expires the level is reported, and the limit starts
+	// again
+	w.observe(2000, now.Add(time.Second))
+	w.observe(0, now.Add(1500*time.Millisecond))
+	assert.Equal(t, []WatermarkEvent{
+		{Metric: WatermarkLiveBytes, Level: WatermarkSoft, Previous: WatermarkNormal, Bytes: 1000},
+		{Metric: WatermarkLiveBytes, Level: WatermarkHard, Previous: WatermarkSoft, Bytes: 2000},
+	}, *events)
+
+	w.observe(0, now.Add(2*time.Second))
+	assert.Equal(t, []WatermarkLevel{WatermarkSoft, WatermarkHard, WatermarkNormal}, levels(*events))
+}
+
+// Demonstrate that a Watermark watching a Store reports the memory used by
+// that Store, asynchronously, and stops when the Store is destroyed
+func TestWatermark_Store(t *testing.T) {
+	os := NewSized(1 << 12)
+
+	events := make(chan WatermarkEvent, 16)
+	os.OnWatermark(WatermarkPolicy{
+		Metric: WatermarkLiveBytes,
+		Soft:   1 << 10,
+		Hard:   1 << 11,
+	}, func(event WatermarkEvent) {
+		events <- event
+	})
+
+	next := func() WatermarkEvent {
+		select {
+		case event := <-events:
+			return event
+		case <-time.After(10 * time.Second):
+			require.FailNow(t, "timed out waiting for watermark")
+			return WatermarkEvent{}
+		}
+	}
+
+	refs := []RefObject[[64]byte]{}
+	for range 16 {
+		refs = append(refs, AllocObject[[64]byte](os))
+	}
+	event := next()
+	assert.Equal(t, WatermarkSoft, event.Level)
+	assert.Equal(t, 1<<10, event.Bytes)
+
+	for range 16 {
+		refs = append(refs, AllocObject[[64]byte](os))
+	}
+	event = next()
+	assert.Equal(t, WatermarkHard, event.Level)
+	assert.Equal(t, WatermarkSoft, event.Previous)
+
+	for _, ref := range refs {
+		FreeObject(os, ref)
+	}
+	event = next()
+	assert.Equal(t, WatermarkNormal, event.Level)
+	assert.Equal(t, WatermarkHard, event.Previous)
+
+	assert.NoError(t, os.Destroy())
+	assert.Empty(t, os.watermarks)
+}
+
+// Show that a stopped Watermark no longer calls its hook
+func TestWatermark_Stop(t *testing.T) {
+	os := NewSized(1 << 12)
+	defer func() {
+		assert.NoError(t, os.Destroy())
+	}()
+
+	calls := 0
+	w := os.OnWatermark(WatermarkPolicy{
+		Metric:   WatermarkMappedBytes,
+		Soft:     1,
+		Interval: time.Millisecond,
+	}, func(WatermarkEvent) {
+		calls++
+	})
+	w.Stop()
+	// Stopping twice is harmless
+	w.Stop()
+	assert.Empty(t, os.watermarks)
+
+	AllocObject[int64](os)
+	time.Sleep(10 * time.Millisecond)
+	assert.Equal(t, 0, calls)
+}
+
+// Show that invalid policies are rejected
+func TestWatermark_InvalidPolicy(t *testing.T) {
+	os := NewSized(1 << 12)
+	defer func() {
+		assert.NoError(t, os.Destroy())
+	}()
+
+	for _, policy := range []WatermarkPolicy{
+		{Soft: 100},
+		{Metric: WatermarkLiveBytes},
+		{Metric: WatermarkLiveBytes, Soft: -1},
+		{Metric: WatermarkLiveBytes, Soft: 100, Hard: 100},
+		{Metric: WatermarkLiveBytes, Soft: 100, Hysteresis: 1},
+		{Metric: WatermarkLiveBytes, Soft: 100, Interval: -1},
+	} {
+		assert.Panics(t, func() { os.OnWatermark(policy, func(WatermarkEvent) {}) }, "%+v", policy)
+	}
+	assert.Empty(t, os.watermarks)
+}
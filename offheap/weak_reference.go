@@ -0,0 +1,55 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+package offheap
+
+import (
+	"github.com/fmstephe/memorymanager/offheap/internal/pointerstore"
+)
+
+// A weak reference to a typed object. Unlike a RefObject a Weak reference can
+// be kept after the object it refers to has been freed. Before the object can
+// be accessed the Weak reference must be upgraded, via Get, which will report
+// whether the object is still allocated.
+//
+// This allows datastructures like caches to hold references to objects
+// managed elsewhere, without preventing those objects being freed.
+//
+// Checking whether the object is still allocated relies on the same
+// generation checks used to detect use-after-free for RefObject. This is a
+// best effort check. If the allocation has been freed and re-allocated a
+// multiple of 256 times Get will incorrectly report that the object is still
+// allocated.
+//
+// Like RefObject, Weak contains no conventional Go pointers and can be used
+// in fields of types managed by a Store.
+type Weak[T any] struct {
+	ref pointerstore.RefPointer
+}
+
+// Returns a new Weak reference to the object referred to by r.
+func MakeWeak[T any](r RefObject[T]) Weak[T] {
+	return Weak[T]{
+		ref: r.ref,
+	}
+}
+
+// Returns a RefObject for the object this Weak reference refers to, and true,
+// if the object is still allocated. If the object has been freed, or freed and
+// re-allocated, then a nil RefObject and false are returned.
+//
+// Calling Get while another goroutine frees the object is a data race, just
+// like calling RefObject.Value() concurrently with FreeObject(...).
+func (w *Weak[T]) Get(s *Store) (RefObject[T], bool) {
+	if w.ref.IsNil() || !w.ref.IsLive() {
+		return RefObject[T]{}, false
+	}
+	return RefObject[T]{ref: w.ref}, true
+}
+
+// Returns true if this Weak reference was never assigned a RefObject, false
+// otherwise.
+func (w *Weak[T]) IsNil() bool {
+	return w.ref.IsNil()
+}
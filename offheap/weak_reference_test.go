@@ -0,0 +1,76 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+package offheap
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Demonstrate that a Weak reference can be upgraded until its object is freed
+func Test_Weak_GetFree(t *testing.T) {
+	ss := NewSized(1 << 8)
+	defer func() {
+		assert.NoError(t, ss.Destroy())
+	}()
+
+	ref := AllocObject[MutableStruct](ss)
+	ref.Value().Field = 42
+	weak := MakeWeak(ref)
+	assert.False(t, weak.IsNil())
+
+	upgraded, ok := weak.Get(ss)
+	assert.True(t, ok)
+	assert.Equal(t, ref, upgraded)
+	assert.Equal(t, 42, upgraded.Value().Field)
+
+	FreeObject(ss, ref)
+
+	upgraded, ok = weak.Get(ss)
+	assert.False(t, ok)
+	assert.True(t, upgraded.IsNil())
+}
+
+// Demonstrate that a Weak reference can't be upgraded after its allocation is
+// reused by a new object
+func Test_Weak_GetReallocated(t *testing.T) {
+	ss := NewSized(1 << 8)
+	defer func() {
+		assert.NoError(t, ss.Destroy())
+	}()
+
+	ref := AllocObject[MutableStruct](ss)
+	weak := MakeWeak(ref)
+	FreeObject(ss, ref)
+
+	// This allocation reuses the freed slot
+	newRef := AllocObject[MutableStruct](ss)
+	assert.Equal(t, 1, StatsForType[MutableStruct](ss).Reused)
+
+	_, ok := weak.Get(ss)
+	assert.False(t, ok)
+
+	// A Weak reference to the new object can be upgraded
+	newWeak := MakeWeak(newRef)
+	upgraded, ok := newWeak.Get(ss)
+	assert.True(t, ok)
+	assert.Equal(t, newRef, upgraded)
+}
+
+// Demonstrate that the zero value of a Weak reference can't be upgraded
+func Test_Weak_Nil(t *testing.T) {
+	ss := NewSized(1 << 8)
+	defer func() {
+		assert.NoError(t, ss.Destroy())
+	}()
+
+	var weak Weak[MutableStruct]
+	assert.True(t, weak.IsNil())
+
+	upgraded, ok := weak.Get(ss)
+	assert.False(t, ok)
+	assert.True(t, upgraded.IsNil())
+}
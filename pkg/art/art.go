@@ -0,0 +1,327 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+// The art package provides an adaptive radix tree, a map from string keys to
+// values, whose keys and values are stored offheap.
+//
+// Each node in the tree holds the part of a key shared by all of the keys
+// below it. Keys which share a prefix share the nodes for that prefix, which
+// makes the tree compact for sets of keys with long common prefixes, such as
+// URLs or qualified symbol names. A run of nodes with only a single child is
+// compressed into a single node, whose prefix is stored as an offheap string.
+//
+// Nodes adapt the storage for their children to the number of children they
+// have. Nodes with a few children store them in small sorted arrays, while
+// nodes with many children index them directly by the next byte of the key.
+// Gets, inserts and deletes take time proportional to the length of the key,
+// regardless of the number of keys in the tree.
+//
+// Keys are ordered by their bytes, so iterating over the tree visits keys in
+// the same order as sorting them with strings.Compare.
+package art
+
+import (
+	"strings"
+
+	"github.com/fmstephe/memorymanager/offheap"
+)
+
+// A map from string keys to values of type V. The type V must not contain
+// any pointers.
+//
+// A Tree is not safe for concurrent use.
+type Tree[V any] struct {
+	store *offheap.Store
+	// The root node always has an empty prefix, and is never removed
+	root offheap.RefObject[node[V]]
+	// The number of keys in the tree
+	length int
+}
+
+// Returns a new, empty, Tree.
+func New[V any]() *Tree[V] {
+	return NewWithStore[V](offheap.New())
+}
+
+// Returns a new, empty, Tree which allocates from store. This allows many
+// trees to share the same offheap memory. See New.
+func NewWithStore[V any](store *offheap.Store) *Tree[V] {
+	t := &Tree[V]{
+		store: store,
+	}
+	t.root = t.newNode("")
+	return t
+}
+
+// Returns the number of keys in the tree
+func (t *Tree[V]) Len() int {
+	return t.length
+}
+
+// Returns the value stored for key. If key is not in the tree the zero value
+// of V and false are returned.
+func (t *Tree[V]) Get(key string) (V, bool) {
+	r := t.root
+	for {
+		n := r.Value()
+		prefix := n.prefix.Value()
+		if !strings.HasPrefix(key, prefix) {
+			break
+		}
+		key = key[len(prefix):]
+
+		if len(key) == 0 {
+			if !n.hasValue {
+				break
+			}
+			return n.value, true
+		}
+
+		r = n.findChild(key[0])
+		if r.IsNil() {
+			break
+		}
+		key = key[1:]
+	}
+
+	var zero V
+	return zero, false
+}
+
+// Stores value for key. If key is already in the tree its value is replaced
+// and true is returned, otherwise false is returned.
+func (t *Tree[V]) Insert(key string, value V) bool {
+	r := t.root
+	for {
+		n := r.Value()
+		common := commonPrefix(n.prefix.Value(), key)
+		if common < n.prefix.Len() {
+			t.split(n, common)
+		}
+		key = key[common:]
+
+		if len(key) == 0 {
+			replaced := n.hasValue
+			n.hasValue = true
+			n.value = value
+			if !replaced {
+				t.length++
+			}
+			return replaced
+		}
+
+		child := n.findChild(key[0])
+		if child.IsNil() {
+			child = t.newNode(key[1:])
+			c := child.Value()
+			c.hasValue = true
+			c.value = value
+			t.addChild(n, key[0], child)
+			t.length++
+			return false
+		}
+
+		r = child
+		key = key[1:]
+	}
+}
+
+// Removes key from the tree. Returns false if key was not in the tree.
+func (t *Tree[V]) Delete(key string) bool {
+	if !t.delete(t.root, key) {
+		return false
+	}
+	t.length--
+	return true
+}
+
+// Calls fun with every key, and its value, in the tree in key order.
+// Iteration stops early if fun returns false. Returns false if iteration was
+// stopped early, true otherwise.
+func (t *Tree[V]) Survey(fun func(key string, value V) bool) bool {
+	return t.SurveyPrefix("", fun)
+}
+
+// Calls fun with every key which starts with prefix, and its value, in key
+// order. Iteration stops early if fun returns false. Returns false if
+// iteration was stopped early, true otherwise.
+//
+// The key passed to fun is an ordinary Go string, allocated for each call.
+func (t *Tree[V]) SurveyPrefix(prefix string, fun func(key string, value V) bool) bool {
+	key := []byte{}
+	r := t.root
+	for {
+		n := r.Value()
+		nodePrefix := n.prefix.Value()
+
+		// prefix ends within this node, every key below it matches
+		if len(prefix) <= len(nodePrefix) {
+			if !strings.HasPrefix(nodePrefix, prefix) {
+				return true
+			}
+			return t.survey(r, key, fun)
+		}
+
+		if !strings.HasPrefix(prefix, nodePrefix) {
+			return true
+		}
+		key = append(key, nodePrefix...)
+		prefix = prefix[len(nodePrefix):]
+
+		r = n.findChild(prefix[0])
+		if r.IsNil() {
+			return true
+		}
+		key = append(key, prefix[0])
+		prefix = prefix[1:]
+	}
+}
+
+// Frees all of the memory used by this tree. After this method is called the
+// tree must not be used again.
+func (t *Tree[V]) Free() {
+	t.freeNode(t.root)
+	t.root = offheap.RefObject[node[V]]{}
+	t.length = 0
+}
+
+// Removes key from the subtree rooted at r, returning false if key was not
+// found. Nodes left without a value or children are freed, and nodes left
+// without a value and with a single child are merged with that child.
+func (t *Tree[V]) delete(r offheap.RefObject[node[V]], key string) bool {
+	n := r.Value()
+	prefix := n.prefix.Value()
+	if !strings.HasPrefix(key, prefix) {
+		return false
+	}
+	key = key[len(prefix):]
+
+	if len(key) == 0 {
+		if !n.hasValue {
+			return false
+		}
+		n.hasValue = false
+		return true
+	}
+
+	b := key[0]
+	child := n.findChild(b)
+	if child.IsNil() || !t.delete(child, key[1:]) {
+		return false
+	}
+
+	c := child.Value()
+	switch {
+	case c.hasValue:
+	case c.count == 0:
+		t.removeChild(n, b)
+		t.freeNode(child)
+	case c.count == 1:
+		t.merge(c)
+	}
+	return true
+}
+
+// Splits the prefix of n at position common. n keeps the first common bytes
+// of its prefix, and everything else n held is moved into a new child below
+// it.
+func (t *Tree[V]) split(n *node[V], common int) {
+	prefix := n.prefix.Value()
+	b := prefix[common]
+
+	child := offheap.AllocObject[node[V]](t.store)
+	c := child.Value()
+	*c = *n
+	c.prefix = t.allocPrefix(prefix[common+1:])
+
+	newPrefix := t.allocPrefix(prefix[:common])
+	t.freePrefix(n.prefix)
+	*n = node[V]{
+		prefix: newPrefix,
+		kind:   leaf,
+	}
+	t.addChild(n, b, child)
+}
+
+// Merges n, which has no value, with its only child. The child's prefix is
+// appended to n's prefix, and n takes over the child's value and children.
+func (t *Tree[V]) merge(n *node[V]) {
+	var b byte
+	var child offheap.RefObject[node[V]]
+	n.eachChild(func(childB byte, childR offheap.RefObject[node[V]]) bool {
+		b = childB
+		child = childR
+		return false
+	})
+
+	c := child.Value()
+	prefix := offheap.ConcatStrings(t.store, n.prefix.Value(), string([]byte{b}), c.prefix.Value())
+
+	t.freePrefix(n.prefix)
+	t.freeChildren(n)
+	t.freePrefix(c.prefix)
+	*n = *c
+	n.prefix = prefix
+	offheap.FreeObject(t.store, child)
+}
+
+// Calls fun with every key in the subtree rooted at r. key holds the bytes of
+// the key leading up to r's prefix.
+func (t *Tree[V]) survey(r offheap.RefObject[node[V]], key []byte, fun func(key string, value V) bool) bool {
+	n := r.Value()
+	key = append(key, n.prefix.Value()...)
+	if n.hasValue && !fun(string(key), n.value) {
+		return false
+	}
+	return n.eachChild(func(b byte, child offheap.RefObject[node[V]]) bool {
+		return t.survey(child, append(key, b), fun)
+	})
+}
+
+// Returns a new node, without a value or children
+func (t *Tree[V]) newNode(prefix string) offheap.RefObject[node[V]] {
+	r := offheap.AllocObject[node[V]](t.store)
+	n := r.Value()
+	*n = node[V]{
+		prefix: t.allocPrefix(prefix),
+		kind:   leaf,
+	}
+	return r
+}
+
+// Frees the node r, and every node below it
+func (t *Tree[V]) freeNode(r offheap.RefObject[node[V]]) {
+	n := r.Value()
+	n.eachChild(func(_ byte, child offheap.RefObject[node[V]]) bool {
+		t.freeNode(child)
+		return true
+	})
+	t.freeChildren(n)
+	t.freePrefix(n.prefix)
+	offheap.FreeObject(t.store, r)
+}
+
+// Returns an offheap copy of prefix, or a nil RefString if prefix is empty
+func (t *Tree[V]) allocPrefix(prefix string) offheap.RefString {
+	if prefix == "" {
+		return offheap.RefString{}
+	}
+	return offheap.AllocStringFromString(t.store, prefix)
+}
+
+// Frees prefix, unless it is nil
+func (t *Tree[V]) freePrefix(prefix offheap.RefString) {
+	if !prefix.IsNil() {
+		offheap.FreeString(t.store, prefix)
+	}
+}
+
+// Returns the length of the longest common prefix of a and b
+func commonPrefix(a, b string) int {
+	i := 0
+	for i < len(a) && i < len(b) && a[i] == b[i] {
+		i++
+	}
+	return i
+}
@@ -0,0 +1,236 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+package art
+
+import (
+	"fmt"
+	"math/rand"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/fmstephe/memorymanager/offheap"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Returns every key with prefix, in the order they are surveyed
+func keysWithPrefix(tree *Tree[int], prefix string) []string {
+	keys := []string{}
+	tree.SurveyPrefix(prefix, func(key string, value int) bool {
+		keys = append(keys, key)
+		return true
+	})
+	return keys
+}
+
+// Show that keys can be inserted, got and deleted, including keys which are
+// prefixes of other keys and the empty key
+func TestInsertGetDelete(t *testing.T) {
+	tree := New[int]()
+	defer tree.Free()
+
+	_, ok := tree.Get("a")
+	assert.False(t, ok)
+	assert.False(t, tree.Delete("a"))
+
+	keys := []string{"romane", "romanus", "romulus", "rubens", "ruber", "rubicon", "rubicundus", "rom", "", "r"}
+	for i, key := range keys {
+		assert.False(t, tree.Insert(key, i))
+	}
+	assert.Equal(t, len(keys), tree.Len())
+
+	for i, key := range keys {
+		value, ok := tree.Get(key)
+		require.True(t, ok, key)
+		assert.Equal(t, i, value)
+	}
+	for _, key := range []string{"ro", "roman", "romanes", "rubi", "x"} {
+		_, ok := tree.Get(key)
+		assert.False(t, ok, key)
+	}
+
+	// Inserting an existing key replaces its value
+	assert.True(t, tree.Insert("rubens", 100))
+	value, ok := tree.Get("rubens")
+	require.True(t, ok)
+	assert.Equal(t, 100, value)
+	assert.Equal(t, len(keys), tree.Len())
+
+	assert.True(t, tree.Delete("rom"))
+	assert.False(t, tree.Delete("rom"))
+	assert.False(t, tree.Delete("roma"))
+	_, ok = tree.Get("rom")
+	assert.False(t, ok)
+	value, ok = tree.Get("romane")
+	require.True(t, ok)
+	assert.Equal(t, 0, value)
+
+	assert.True(t, tree.Delete(""))
+	assert.Equal(t, len(keys)-2, tree.Len())
+}
+
+// Show that surveys visit keys in sorted order, that SurveyPrefix only
+// visits keys with the prefix and that surveys can be stopped early
+func TestSurveyPrefix(t *testing.T) {
+	tree := New[int]()
+	defer tree.Free()
+
+	keys := []string{"/", "/api", "/api/users", "/api/users/1", "/api/users/2", "/api/groups", "/static/app.js", "/static/app.css"}
+	for i, key := range keys {
+		tree.Insert(key, i)
+	}
+
+	sorted := slices.Clone(keys)
+	slices.Sort(sorted)
+	assert.Equal(t, sorted, keysWithPrefix(tree, ""))
+
+	assert.Equal(t, []string{"/api/users", "/api/users/1", "/api/users/2"}, keysWithPrefix(tree, "/api/u"))
+	assert.Equal(t, []string{"/static/app.css", "/static/app.js"}, keysWithPrefix(tree, "/static/app."))
+	assert.Equal(t, []string{"/api/groups"}, keysWithPrefix(tree, "/api/groups"))
+	assert.Equal(t, []string{}, keysWithPrefix(tree, "/api/groupsx"))
+	assert.Equal(t, []string{}, keysWithPrefix(tree, "/x"))
+
+	visited := []string{}
+	assert.False(t, tree.Survey(func(key string, value int) bool {
+		visited = append(visited, key)
+		return len(visited) < 3
+	}))
+	assert.Equal(t, sorted[:3], visited)
+}
+
+// Show that nodes grow, and shrink, through every kind of node as children
+// are added and removed
+func TestNodeKinds(t *testing.T) {
+	tree := New[int]()
+	defer tree.Free()
+
+	rootKind := func() nodeKind {
+		return tree.root.Value().kind
+	}
+
+	assert.Equal(t, leaf, rootKind())
+	for b := range 256 {
+		tree.Insert(string([]byte{byte(255 - b)}), b)
+		assert.Equal(t, kindFor(b+1), rootKind())
+	}
+	assert.Equal(t, node256, rootKind())
+
+	// Every child is still found, and visited in order
+	for b := range 256 {
+		value, ok := tree.Get(string([]byte{byte(255 - b)}))
+		require.True(t, ok)
+		assert.Equal(t, b, value)
+	}
+	keys := keysWithPrefix(tree, "")
+	assert.True(t, slices.IsSorted(keys))
+	assert.Len(t, keys, 256)
+
+	kinds := []nodeKind{}
+	for b := range 256 {
+		require.True(t, tree.Delete(string([]byte{byte(b)})))
+		if len(kinds) == 0 || kinds[len(kinds)-1] != rootKind() {
+			kinds = append(kinds, rootKind())
+		}
+		for remaining := b + 1; remaining < 256; remaining += 17 {
+			_, ok := tree.Get(string([]byte{byte(remaining)}))
+			require.True(t, ok)
+		}
+	}
+	assert.Equal(t, []nodeKind{node256, node48, node16, node4, leaf}, kinds)
+	assert.Equal(t, 0, tree.Len())
+}
+
+// Show that Free releases every allocation made by the tree
+func TestFree(t *testing.T) {
+	store := offheap.New()
+	defer func() {
+		assert.NoError(t, store.Destroy())
+	}()
+
+	tree := NewWithStore[int](store)
+	for i := range 1000 {
+		tree.Insert(fmt.Sprintf("key/%d/%d", i%7, i), i)
+	}
+	for i := range 500 {
+		tree.Delete(fmt.Sprintf("key/%d/%d", i%7, i))
+	}
+	assert.Greater(t, store.TotalStats().Live, 0)
+
+	tree.Free()
+	assert.Equal(t, 0, store.TotalStats().Live)
+}
+
+// Show that deleting every key frees every node except the root
+func TestDeleteFreesNodes(t *testing.T) {
+	store := offheap.New()
+	defer func() {
+		assert.NoError(t, store.Destroy())
+	}()
+
+	tree := NewWithStore[int](store)
+	live := store.TotalStats().Live
+
+	for i := range 1000 {
+		tree.Insert(fmt.Sprint(i*7919), i)
+	}
+	for i := range 1000 {
+		require.True(t, tree.Delete(fmt.Sprint(i*7919)))
+	}
+	assert.Equal(t, live, store.TotalStats().Live)
+	tree.Free()
+}
+
+// Randomly insert and delete keys, checking the tree's contents against a
+// map
+func TestRandomOperations(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	tree := New[int]()
+	defer tree.Free()
+
+	// Keys are built from a small alphabet, so they share many prefixes
+	randomKey := func() string {
+		b := strings.Builder{}
+		for range r.Intn(8) {
+			b.WriteByte("abc/"[r.Intn(4)])
+		}
+		return b.String()
+	}
+
+	expected := map[string]int{}
+	for i := range 50_000 {
+		key := randomKey()
+		if r.Intn(3) == 0 {
+			_, ok := expected[key]
+			delete(expected, key)
+			require.Equal(t, ok, tree.Delete(key))
+		} else {
+			_, ok := expected[key]
+			expected[key] = i
+			require.Equal(t, ok, tree.Insert(key, i))
+		}
+		require.Equal(t, len(expected), tree.Len())
+	}
+
+	expectedKeys := []string{}
+	for key, value := range expected {
+		expectedKeys = append(expectedKeys, key)
+		actual, ok := tree.Get(key)
+		require.True(t, ok)
+		assert.Equal(t, value, actual)
+	}
+	slices.Sort(expectedKeys)
+	assert.Equal(t, expectedKeys, keysWithPrefix(tree, ""))
+
+	for _, prefix := range []string{"a", "ab", "c/", "/a/b"} {
+		withPrefix := []string{}
+		for _, key := range expectedKeys {
+			if strings.HasPrefix(key, prefix) {
+				withPrefix = append(withPrefix, key)
+			}
+		}
+		assert.Equal(t, withPrefix, keysWithPrefix(tree, prefix))
+	}
+}
@@ -0,0 +1,262 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+package art
+
+import (
+	"github.com/fmstephe/memorymanager/offheap"
+)
+
+// The kind of a node determines how its children are stored
+type nodeKind uint8
+
+const (
+	// A node with no children
+	leaf nodeKind = iota
+	// Up to 4 children, keys holds the sorted edge bytes and children[i]
+	// is the child for keys[i]
+	node4
+	// Up to 16 children, stored like node4
+	node16
+	// Up to 48 children, keys has 256 entries indexed by edge byte,
+	// holding the position of the child in children plus one, or 0 if
+	// there is no child for that edge byte
+	node48
+	// Up to 256 children, children is indexed directly by edge byte
+	node256
+)
+
+// Returns the maximum number of children a node of kind k can hold
+func (k nodeKind) capacity() int {
+	switch k {
+	case node4:
+		return 4
+	case node16:
+		return 16
+	case node48:
+		return 48
+	case node256:
+		return 256
+	default:
+		return 0
+	}
+}
+
+// Returns the smallest kind of node which can hold count children
+func kindFor(count int) nodeKind {
+	switch {
+	case count == 0:
+		return leaf
+	case count <= 4:
+		return node4
+	case count <= 16:
+		return node16
+	case count <= 48:
+		return node48
+	default:
+		return node256
+	}
+}
+
+// Returns the kind a node of kind k should shrink to once it holds only count
+// children. Nodes shrink only once they are well below the capacity of the
+// smaller kind, so that alternately adding and removing a child doesn't
+// rebuild the node every time.
+func shrinkKind(k nodeKind, count int) nodeKind {
+	switch {
+	case count == 0:
+		return leaf
+	case k == node16 && count <= 3:
+		return node4
+	case k == node48 && count <= 12:
+		return node16
+	case k == node256 && count <= 37:
+		return node48
+	default:
+		return k
+	}
+}
+
+// A single node in the tree. The key of a node is the key of its parent,
+// followed by the edge byte leading to the node, followed by prefix.
+type node[V any] struct {
+	// The compressed path below the edge byte, nil if empty
+	prefix   offheap.RefString
+	kind     nodeKind
+	hasValue bool
+	// The number of children
+	count    int
+	keys     offheap.RefSlice[byte]
+	children offheap.RefSlice[offheap.RefObject[node[V]]]
+	value    V
+}
+
+// Returns the child of n for edge byte b, or a nil reference if there is no
+// such child
+func (n *node[V]) findChild(b byte) offheap.RefObject[node[V]] {
+	switch n.kind {
+	case node4, node16:
+		keys := n.keys.Value()
+		for i := range n.count {
+			if keys[i] == b {
+				return n.children.Value()[i]
+			}
+			if keys[i] > b {
+				break
+			}
+		}
+	case node48:
+		if i := n.keys.Value()[b]; i != 0 {
+			return n.children.Value()[i-1]
+		}
+	case node256:
+		return n.children.Value()[b]
+	}
+	return offheap.RefObject[node[V]]{}
+}
+
+// Calls fun with every child of n, in edge byte order. Iteration stops early
+// if fun returns false. Returns false if iteration was stopped early, true
+// otherwise.
+func (n *node[V]) eachChild(fun func(b byte, child offheap.RefObject[node[V]]) bool) bool {
+	switch n.kind {
+	case node4, node16:
+		keys := n.keys.Value()
+		children := n.children.Value()
+		for i := range n.count {
+			if !fun(keys[i], children[i]) {
+				return false
+			}
+		}
+	case node48:
+		children := n.children.Value()
+		for b, i := range n.keys.Value() {
+			if i != 0 && !fun(byte(b), children[i-1]) {
+				return false
+			}
+		}
+	case node256:
+		for b, child := range n.children.Value() {
+			if !child.IsNil() && !fun(byte(b), child) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Adds child to n for edge byte b. There must not already be a child for b.
+// If n is full it is rebuilt as a larger kind of node.
+func (t *Tree[V]) addChild(n *node[V], b byte, child offheap.RefObject[node[V]]) {
+	if n.count == n.kind.capacity() {
+		t.rebuild(n, kindFor(n.count+1))
+	}
+
+	switch n.kind {
+	case node4, node16:
+		keys := n.keys.Value()
+		children := n.children.Value()
+		i := 0
+		for i < n.count && keys[i] < b {
+			i++
+		}
+		copy(keys[i+1:n.count+1], keys[i:n.count])
+		copy(children[i+1:n.count+1], children[i:n.count])
+		keys[i] = b
+		children[i] = child
+	case node48:
+		n.children.Value()[n.count] = child
+		n.keys.Value()[b] = byte(n.count + 1)
+	case node256:
+		n.children.Value()[b] = child
+	}
+	n.count++
+}
+
+// Removes the child of n for edge byte b. There must be a child for b. If n
+// becomes sparse enough it is rebuilt as a smaller kind of node.
+func (t *Tree[V]) removeChild(n *node[V], b byte) {
+	switch n.kind {
+	case node4, node16:
+		keys := n.keys.Value()
+		children := n.children.Value()
+		i := 0
+		for keys[i] != b {
+			i++
+		}
+		copy(keys[i:n.count-1], keys[i+1:n.count])
+		copy(children[i:n.count-1], children[i+1:n.count])
+	case node48:
+		// Move the last child into the removed child's position, keeping
+		// the children contiguous
+		keys := n.keys.Value()
+		children := n.children.Value()
+		i := keys[b]
+		keys[b] = 0
+		last := byte(n.count)
+		if i != last {
+			children[i-1] = children[last-1]
+			for lastB := range keys {
+				if keys[lastB] == last {
+					keys[lastB] = i
+					break
+				}
+			}
+		}
+	case node256:
+		n.children.Value()[b] = offheap.RefObject[node[V]]{}
+	}
+	n.count--
+
+	if kind := shrinkKind(n.kind, n.count); kind != n.kind {
+		t.rebuild(n, kind)
+	}
+}
+
+// Replaces the children of n with the storage for a node of kind, moving
+// every existing child across.
+func (t *Tree[V]) rebuild(n *node[V], kind nodeKind) {
+	var keys [256]byte
+	var children [256]offheap.RefObject[node[V]]
+	count := 0
+	n.eachChild(func(b byte, child offheap.RefObject[node[V]]) bool {
+		keys[count] = b
+		children[count] = child
+		count++
+		return true
+	})
+
+	t.freeChildren(n)
+	n.kind = kind
+	n.count = 0
+
+	switch kind {
+	case node4, node16:
+		n.keys = offheap.AllocSlice[byte](t.store, kind.capacity(), kind.capacity())
+		n.children = offheap.AllocSlice[offheap.RefObject[node[V]]](t.store, kind.capacity(), kind.capacity())
+	case node48:
+		n.keys = offheap.AllocSlice[byte](t.store, 256, 256)
+		clear(n.keys.Value())
+		n.children = offheap.AllocSlice[offheap.RefObject[node[V]]](t.store, kind.capacity(), kind.capacity())
+	case node256:
+		n.children = offheap.AllocSlice[offheap.RefObject[node[V]]](t.store, 256, 256)
+		clear(n.children.Value())
+	}
+
+	for i := range count {
+		t.addChild(n, keys[i], children[i])
+	}
+}
+
+// Frees the storage for the children of n, but not the children themselves
+func (t *Tree[V]) freeChildren(n *node[V]) {
+	if !n.keys.IsNil() {
+		offheap.FreeSlice(t.store, n.keys)
+	}
+	if !n.children.IsNil() {
+		offheap.FreeSlice(t.store, n.children)
+	}
+	n.keys = offheap.RefSlice[byte]{}
+	n.children = offheap.RefSlice[offheap.RefObject[node[V]]]{}
+}
@@ -0,0 +1,193 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+// The bitset package provides sets of integers, represented as bits, whose
+// storage is allocated offheap.
+//
+// A Bitset stores one bit for every position up to its largest set bit, in a
+// single offheap slice of words. This is compact and fast for dense sets,
+// such as the set of live IDs in a large dataset.
+//
+// A Sparse set is a compressed bitmap, in the style of roaring bitmaps. The
+// 32 bit values are split into chunks of 65536, and each chunk which contains
+// any values is stored either as a sorted array of values or as a bitmap,
+// whichever is smaller. This makes a Sparse set much smaller than a Bitset
+// when values are spread thinly across a large range.
+//
+// Both types support set operations between sets of the same type, along with
+// rank and select.
+package bitset
+
+import (
+	"fmt"
+	"math/bits"
+
+	"github.com/fmstephe/memorymanager/offheap"
+)
+
+// The number of words in a new Bitset
+const initialWords = 4
+
+// A dense set of non-negative ints.
+//
+// A Bitset is not safe for concurrent use.
+type Bitset struct {
+	store *offheap.Store
+	// The bits of the set, bit i is bit i%64 of word i/64. Words which
+	// have never been written to are zero.
+	words offheap.RefSlice[uint64]
+}
+
+// Returns a new, empty, Bitset.
+func New() *Bitset {
+	return NewWithStore(offheap.New())
+}
+
+// Returns a new, empty, Bitset which allocates from store. This allows many
+// sets to share the same offheap memory.
+func NewWithStore(store *offheap.Store) *Bitset {
+	words := offheap.AllocSlice[uint64](store, initialWords, initialWords)
+	clear(words.Value())
+	return &Bitset{
+		store: store,
+		words: words,
+	}
+}
+
+// Adds i to the set
+func (b *Bitset) Set(i int) {
+	checkPosition(i)
+	b.grow(i/64 + 1)
+	b.words.Value()[i/64] |= 1 << (i % 64)
+}
+
+// Removes i from the set
+func (b *Bitset) Clear(i int) {
+	checkPosition(i)
+	words := b.words.Value()
+	if i/64 < len(words) {
+		words[i/64] &^= 1 << (i % 64)
+	}
+}
+
+// Indicates whether i is in the set
+func (b *Bitset) Test(i int) bool {
+	checkPosition(i)
+	words := b.words.Value()
+	return i/64 < len(words) && words[i/64]&(1<<(i%64)) != 0
+}
+
+// Returns the number of values in the set
+func (b *Bitset) Count() int {
+	count := 0
+	for _, word := range b.words.Value() {
+		count += bits.OnesCount64(word)
+	}
+	return count
+}
+
+// Removes every value from this set which is not in other
+func (b *Bitset) And(other *Bitset) {
+	words := b.words.Value()
+	otherWords := other.words.Value()
+	for i := range words {
+		if i < len(otherWords) {
+			words[i] &= otherWords[i]
+		} else {
+			words[i] = 0
+		}
+	}
+}
+
+// Adds every value in other to this set
+func (b *Bitset) Or(other *Bitset) {
+	otherWords := other.words.Value()
+	b.grow(len(otherWords))
+	words := b.words.Value()
+	for i := range otherWords {
+		words[i] |= otherWords[i]
+	}
+}
+
+// Removes every value in other from this set
+func (b *Bitset) AndNot(other *Bitset) {
+	words := b.words.Value()
+	otherWords := other.words.Value()
+	for i := range min(len(words), len(otherWords)) {
+		words[i] &^= otherWords[i]
+	}
+}
+
+// Returns the number of values in the set which are less than i
+func (b *Bitset) Rank(i int) int {
+	checkPosition(i)
+	words := b.words.Value()
+
+	rank := 0
+	for _, word := range words[:min(i/64, len(words))] {
+		rank += bits.OnesCount64(word)
+	}
+	if i/64 < len(words) {
+		rank += bits.OnesCount64(words[i/64] & (1<<(i%64) - 1))
+	}
+	return rank
+}
+
+// Returns the n'th smallest value in the set, counting from 0. If the set has
+// n or fewer values then false is returned.
+func (b *Bitset) Select(n int) (int, bool) {
+	if n < 0 {
+		return 0, false
+	}
+
+	for i, word := range b.words.Value() {
+		count := bits.OnesCount64(word)
+		if n < count {
+			return i*64 + selectInWord(word, n), true
+		}
+		n -= count
+	}
+	return 0, false
+}
+
+// Frees all of the memory used by this set. After this method is called the
+// set must not be used again.
+func (b *Bitset) Free() {
+	offheap.FreeSlice(b.store, b.words)
+	*b = Bitset{}
+}
+
+// Ensures the set has at least wordCount words, at least doubling the number
+// of words when it grows
+func (b *Bitset) grow(wordCount int) {
+	words := b.words.Value()
+	if wordCount <= len(words) {
+		return
+	}
+
+	newCount := max(wordCount, len(words)*2)
+	newWordsRef := offheap.AllocSlice[uint64](b.store, newCount, newCount)
+	newWords := newWordsRef.Value()
+	copy(newWords, words)
+	clear(newWords[len(words):])
+
+	offheap.FreeSlice(b.store, b.words)
+	b.words = newWordsRef
+}
+
+func checkPosition(i int) {
+	if i < 0 {
+		panic(fmt.Errorf("bitset position (%d) must not be negative", i))
+	}
+}
+
+// Returns the position of the n'th set bit in word, counting from 0. word
+// must have more than n bits set.
+func selectInWord(word uint64, n int) int {
+	for range n {
+		// Clear the lowest set bit
+		word &= word - 1
+	}
+	return bits.TrailingZeros64(word)
+}
@@ -0,0 +1,263 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+package bitset
+
+import (
+	"math/rand"
+	"slices"
+	"testing"
+
+	"github.com/fmstephe/memorymanager/offheap"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Show that values can be set, tested and cleared
+func TestSetClearTest(t *testing.T) {
+	b := New()
+	defer b.Free()
+
+	assert.False(t, b.Test(0))
+	assert.False(t, b.Test(1_000_000))
+	assert.Equal(t, 0, b.Count())
+
+	for _, i := range []int{0, 1, 63, 64, 65, 1000, 100_000} {
+		b.Set(i)
+		assert.True(t, b.Test(i))
+	}
+	assert.Equal(t, 7, b.Count())
+	assert.False(t, b.Test(2))
+
+	b.Clear(64)
+	b.Clear(1_000_000)
+	assert.False(t, b.Test(64))
+	assert.Equal(t, 6, b.Count())
+
+	assert.Panics(t, func() { b.Set(-1) })
+	assert.Panics(t, func() { b.Test(-1) })
+	assert.Panics(t, func() { b.Clear(-1) })
+}
+
+// Show that And, Or and AndNot behave like the equivalent set operations
+func TestSetOperations(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	store := offheap.New()
+	defer func() {
+		assert.NoError(t, store.Destroy())
+	}()
+
+	for _, op := range []struct {
+		name    string
+		apply   func(a, b *Bitset)
+		include func(inA, inB bool) bool
+	}{
+		{"And", (*Bitset).And, func(inA, inB bool) bool { return inA && inB }},
+		{"Or", (*Bitset).Or, func(inA, inB bool) bool { return inA || inB }},
+		{"AndNot", (*Bitset).AndNot, func(inA, inB bool) bool { return inA && !inB }},
+	} {
+		t.Run(op.name, func(t *testing.T) {
+			// The sets have different sizes, in both orders
+			for _, sizes := range [][2]int{{1000, 5000}, {5000, 1000}} {
+				a, aValues := randomBitset(r, store, sizes[0])
+				b, bValues := randomBitset(r, store, sizes[1])
+
+				op.apply(a, b)
+				for i := range max(sizes[0], sizes[1]) {
+					assert.Equal(t, op.include(aValues[i], bValues[i]), a.Test(i), "value %d", i)
+				}
+
+				a.Free()
+				b.Free()
+			}
+		})
+	}
+	assert.Equal(t, 0, store.TotalStats().Live)
+}
+
+// Show that Rank counts the values before a position, and Select finds the
+// position of each value
+func TestRankSelect(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	b, values := randomBitset(r, offheap.New(), 10_000)
+	defer b.Free()
+
+	rank := 0
+	for i := range 10_000 {
+		require.Equal(t, rank, b.Rank(i))
+		if values[i] {
+			selected, ok := b.Select(rank)
+			require.True(t, ok)
+			require.Equal(t, i, selected)
+			rank++
+		}
+	}
+	assert.Equal(t, rank, b.Rank(1_000_000))
+	assert.Equal(t, rank, b.Count())
+
+	_, ok := b.Select(rank)
+	assert.False(t, ok)
+	_, ok = b.Select(-1)
+	assert.False(t, ok)
+}
+
+// Returns a Bitset containing random values less than size, along with a
+// record of which values are in the set
+func randomBitset(r *rand.Rand, store *offheap.Store, size int) (*Bitset, map[int]bool) {
+	b := NewWithStore(store)
+	values := map[int]bool{}
+	for range size / 2 {
+		i := r.Intn(size)
+		b.Set(i)
+		values[i] = true
+	}
+	return b, values
+}
+
+// Randomly set and clear values in a Sparse set, checking its contents
+// against a map, across chunks stored as both arrays and bitmaps
+func TestSparse_RandomOperations(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	store := offheap.New()
+	defer func() {
+		assert.NoError(t, store.Destroy())
+	}()
+
+	s := NewSparseWithStore(store)
+	values := map[uint32]bool{}
+
+	// Three chunks, the first will become dense enough to be stored as a
+	// bitmap before being cleared back to an array
+	randomValue := func() uint32 {
+		return uint32(r.Intn(3))<<16 | uint32(r.Intn(maxArrayValues*3))
+	}
+	for i := range 100_000 {
+		value := randomValue()
+		if i < 60_000 || r.Intn(2) == 0 {
+			s.Set(value)
+			values[value] = true
+		} else {
+			s.Clear(value)
+			delete(values, value)
+		}
+		if i%1000 == 0 {
+			require.Equal(t, len(values), s.Count())
+		}
+	}
+
+	sawBitmap := false
+	for _, c := range s.usedChunks() {
+		sawBitmap = sawBitmap || c.isBitmap()
+	}
+	assert.True(t, sawBitmap)
+
+	checkSparse(t, s, values)
+
+	// Clear most values, converting chunks back to arrays
+	for value := range values {
+		if r.Intn(10) != 0 {
+			s.Clear(value)
+			delete(values, value)
+		}
+	}
+	for _, c := range s.usedChunks() {
+		assert.False(t, c.isBitmap())
+	}
+	checkSparse(t, s, values)
+
+	s.Free()
+	assert.Equal(t, 0, store.TotalStats().Live)
+}
+
+// Show that And, Or and AndNot on Sparse sets behave like the equivalent set
+// operations
+func TestSparse_SetOperations(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	store := offheap.New()
+	defer func() {
+		assert.NoError(t, store.Destroy())
+	}()
+
+	for _, op := range []struct {
+		name    string
+		apply   func(a, b *Sparse)
+		include func(inA, inB bool) bool
+	}{
+		{"And", (*Sparse).And, func(inA, inB bool) bool { return inA && inB }},
+		{"Or", (*Sparse).Or, func(inA, inB bool) bool { return inA || inB }},
+		{"AndNot", (*Sparse).AndNot, func(inA, inB bool) bool { return inA && !inB }},
+	} {
+		t.Run(op.name, func(t *testing.T) {
+			a, aValues := randomSparse(r, store, 0)
+			b, bValues := randomSparse(r, store, 2)
+
+			op.apply(a, b)
+
+			expected := map[uint32]bool{}
+			for value := range aValues {
+				if op.include(true, bValues[value]) {
+					expected[value] = true
+				}
+			}
+			for value := range bValues {
+				if op.include(aValues[value], true) {
+					expected[value] = true
+				}
+			}
+			checkSparse(t, a, expected)
+
+			// b is unchanged
+			checkSparse(t, b, bValues)
+
+			a.Free()
+			b.Free()
+		})
+	}
+	assert.Equal(t, 0, store.TotalStats().Live)
+}
+
+// Returns a Sparse set containing random values across four chunks, starting
+// at firstChunk. Each chunk is either sparse, and stored as an array, or dense
+// and stored as a bitmap.
+func randomSparse(r *rand.Rand, store *offheap.Store, firstChunk int) (*Sparse, map[uint32]bool) {
+	s := NewSparseWithStore(store)
+	values := map[uint32]bool{}
+	for chunk := firstChunk; chunk < firstChunk+4; chunk++ {
+		count := 100
+		if r.Intn(2) == 0 {
+			count = maxArrayValues * 4
+		}
+		for range count {
+			value := uint32(chunk)<<16 | uint32(r.Intn(maxArrayValues*4))
+			s.Set(value)
+			values[value] = true
+		}
+	}
+	return s, values
+}
+
+// Checks that s contains exactly values, and that Rank and Select agree with
+// values
+func checkSparse(t *testing.T, s *Sparse, values map[uint32]bool) {
+	sorted := []uint32{}
+	for value := range values {
+		sorted = append(sorted, value)
+	}
+	slices.Sort(sorted)
+
+	require.Equal(t, len(sorted), s.Count())
+	for rank, value := range sorted {
+		require.True(t, s.Test(value))
+		require.Equal(t, rank, s.Rank(value))
+		selected, ok := s.Select(rank)
+		require.True(t, ok)
+		require.Equal(t, value, selected)
+		if !values[value+1] {
+			require.False(t, s.Test(value+1))
+		}
+	}
+
+	_, ok := s.Select(len(sorted))
+	assert.False(t, ok)
+}
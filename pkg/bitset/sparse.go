@@ -0,0 +1,475 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+package bitset
+
+import (
+	"math/bits"
+	"sort"
+
+	"github.com/fmstephe/memorymanager/offheap"
+)
+
+const (
+	// The number of values in each chunk of a Sparse set
+	chunkSize = 1 << 16
+	// The number of words in a chunk stored as a bitmap
+	bitmapWords = chunkSize / 64
+	// A chunk with more values than this is stored as a bitmap, otherwise
+	// it is stored as a sorted array. At this size both take 8KB.
+	maxArrayValues = 4096
+	// The capacity of a new chunk's array
+	initialArrayCapacity = 4
+	// The number of chunks in a new Sparse set
+	initialChunks = 4
+)
+
+// A chunk of a Sparse set, containing the values whose high 16 bits are key
+type chunk struct {
+	key uint16
+	// The number of values in this chunk
+	count int
+	// The sorted low 16 bits of each value, only the first count elements
+	// are in use. Only allocated when count <= maxArrayValues.
+	array offheap.RefSlice[uint16]
+	// The bits of the low 16 bits of each value. Only allocated when
+	// count > maxArrayValues.
+	bitmap offheap.RefSlice[uint64]
+}
+
+// A compressed set of uint32 values.
+//
+// A Sparse set is not safe for concurrent use.
+type Sparse struct {
+	store *offheap.Store
+	// The chunks containing values, sorted by key. Only the first
+	// chunkCount chunks are in use.
+	chunks     offheap.RefSlice[chunk]
+	chunkCount int
+}
+
+// Returns a new, empty, Sparse set.
+func NewSparse() *Sparse {
+	return NewSparseWithStore(offheap.New())
+}
+
+// Returns a new, empty, Sparse set which allocates from store. This allows
+// many sets to share the same offheap memory.
+func NewSparseWithStore(store *offheap.Store) *Sparse {
+	return &Sparse{
+		store:  store,
+		chunks: offheap.AllocSlice[chunk](store, initialChunks, initialChunks),
+	}
+}
+
+// Adds value to the set
+func (s *Sparse) Set(value uint32) {
+	key, low := split(value)
+	idx, found := s.findChunk(key)
+	if !found {
+		s.insertChunk(idx, newArrayChunk(s.store, key, initialArrayCapacity))
+	}
+	s.chunks.Value()[idx].add(s.store, low)
+}
+
+// Removes value from the set
+func (s *Sparse) Clear(value uint32) {
+	key, low := split(value)
+	idx, found := s.findChunk(key)
+	if !found {
+		return
+	}
+
+	c := &s.chunks.Value()[idx]
+	c.remove(s.store, low)
+	if c.count == 0 {
+		s.removeChunk(idx)
+	}
+}
+
+// Indicates whether value is in the set
+func (s *Sparse) Test(value uint32) bool {
+	key, low := split(value)
+	idx, found := s.findChunk(key)
+	return found && s.chunks.Value()[idx].contains(low)
+}
+
+// Returns the number of values in the set
+func (s *Sparse) Count() int {
+	count := 0
+	for _, c := range s.usedChunks() {
+		count += c.count
+	}
+	return count
+}
+
+// Returns the number of values in the set which are less than value
+func (s *Sparse) Rank(value uint32) int {
+	key, low := split(value)
+
+	rank := 0
+	for _, c := range s.usedChunks() {
+		if c.key > key {
+			break
+		}
+		if c.key < key {
+			rank += c.count
+			continue
+		}
+		rank += c.rank(low)
+	}
+	return rank
+}
+
+// Returns the n'th smallest value in the set, counting from 0. If the set has
+// n or fewer values then false is returned.
+func (s *Sparse) Select(n int) (uint32, bool) {
+	if n < 0 {
+		return 0, false
+	}
+
+	for _, c := range s.usedChunks() {
+		if n < c.count {
+			return uint32(c.key)<<16 | uint32(c.selectValue(n)), true
+		}
+		n -= c.count
+	}
+	return 0, false
+}
+
+// Removes every value from this set which is not in other
+func (s *Sparse) And(other *Sparse) {
+	s.combine(other, func(a, b uint64) uint64 { return a & b }, false, false)
+}
+
+// Adds every value in other to this set
+func (s *Sparse) Or(other *Sparse) {
+	s.combine(other, func(a, b uint64) uint64 { return a | b }, true, true)
+}
+
+// Removes every value in other from this set
+func (s *Sparse) AndNot(other *Sparse) {
+	s.combine(other, func(a, b uint64) uint64 { return a &^ b }, true, false)
+}
+
+// Frees all of the memory used by this set. After this method is called the
+// set must not be used again.
+func (s *Sparse) Free() {
+	for i := range s.usedChunks() {
+		s.chunks.Value()[i].free(s.store)
+	}
+	offheap.FreeSlice(s.store, s.chunks)
+	*s = Sparse{}
+}
+
+// Replaces the chunks of this set with the result of combining them, word by
+// word, with the chunks of other using op. Chunks whose key is only found in
+// this set are kept if keepOwn is true, and chunks only found in other are
+// copied if keepOther is true.
+func (s *Sparse) combine(other *Sparse, op func(a, b uint64) uint64, keepOwn, keepOther bool) {
+	own := s.usedChunks()
+	others := other.usedChunks()
+
+	combined := NewSparseWithStore(s.store)
+	var ownBits, otherBits [bitmapWords]uint64
+
+	i, j := 0, 0
+	for i < len(own) || j < len(others) {
+		switch {
+		case j == len(others) || (i < len(own) && own[i].key < others[j].key):
+			if keepOwn {
+				combined.appendChunk(own[i])
+			} else {
+				own[i].free(s.store)
+			}
+			i++
+		case i == len(own) || others[j].key < own[i].key:
+			if keepOther {
+				combined.appendChunk(others[j].clone(s.store))
+			}
+			j++
+		default:
+			own[i].toBitmap(&ownBits)
+			others[j].toBitmap(&otherBits)
+			for w := range ownBits {
+				ownBits[w] = op(ownBits[w], otherBits[w])
+			}
+			if c, ok := chunkFromBitmap(s.store, own[i].key, &ownBits); ok {
+				combined.appendChunk(c)
+			}
+			own[i].free(s.store)
+			i++
+			j++
+		}
+	}
+
+	// Every chunk of this set has now been moved into combined, or freed
+	offheap.FreeSlice(s.store, s.chunks)
+	*s = *combined
+}
+
+// Returns the chunks in use
+func (s *Sparse) usedChunks() []chunk {
+	return s.chunks.Value()[:s.chunkCount]
+}
+
+// Returns the position of the chunk for key, and whether it exists. If it
+// doesn't exist the position is where it would be inserted.
+func (s *Sparse) findChunk(key uint16) (int, bool) {
+	chunks := s.usedChunks()
+	idx := sort.Search(len(chunks), func(i int) bool {
+		return chunks[i].key >= key
+	})
+	return idx, idx < len(chunks) && chunks[idx].key == key
+}
+
+// Inserts c at position idx, growing the chunks slice if needed
+func (s *Sparse) insertChunk(idx int, c chunk) {
+	s.growChunks()
+	chunks := s.chunks.Value()
+	copy(chunks[idx+1:s.chunkCount+1], chunks[idx:s.chunkCount])
+	chunks[idx] = c
+	s.chunkCount++
+}
+
+// Appends c after every other chunk, c's key must be greater than every
+// other chunk's key
+func (s *Sparse) appendChunk(c chunk) {
+	s.insertChunk(s.chunkCount, c)
+}
+
+// Frees and removes the chunk at position idx
+func (s *Sparse) removeChunk(idx int) {
+	chunks := s.chunks.Value()
+	chunks[idx].free(s.store)
+	copy(chunks[idx:], chunks[idx+1:s.chunkCount])
+	s.chunkCount--
+}
+
+// If every chunk is in use, doubles the number of chunks
+func (s *Sparse) growChunks() {
+	chunks := s.chunks.Value()
+	if s.chunkCount < len(chunks) {
+		return
+	}
+
+	newChunksRef := offheap.AllocSlice[chunk](s.store, len(chunks)*2, len(chunks)*2)
+	copy(newChunksRef.Value(), chunks)
+
+	offheap.FreeSlice(s.store, s.chunks)
+	s.chunks = newChunksRef
+}
+
+// Splits value into its chunk key and the value within that chunk
+func split(value uint32) (key, low uint16) {
+	return uint16(value >> 16), uint16(value)
+}
+
+// Returns a new, empty, chunk stored as an array
+func newArrayChunk(store *offheap.Store, key uint16, capacity int) chunk {
+	return chunk{
+		key:   key,
+		array: offheap.AllocSlice[uint16](store, capacity, capacity),
+	}
+}
+
+// Returns a chunk containing the values in words. If words is empty no chunk
+// is allocated and false is returned.
+func chunkFromBitmap(store *offheap.Store, key uint16, words *[bitmapWords]uint64) (chunk, bool) {
+	count := 0
+	for _, word := range words {
+		count += bits.OnesCount64(word)
+	}
+	if count == 0 {
+		return chunk{}, false
+	}
+
+	if count > maxArrayValues {
+		bitmap := offheap.AllocSliceFromSlice(store, words[:])
+		return chunk{key: key, count: count, bitmap: bitmap}, true
+	}
+
+	c := newArrayChunk(store, key, count)
+	array := c.array.Value()[:0]
+	for i, word := range words {
+		for word != 0 {
+			array = append(array, uint16(i*64+bits.TrailingZeros64(word)))
+			word &= word - 1
+		}
+	}
+	c.count = count
+	return c, true
+}
+
+// Indicates whether this chunk is stored as a bitmap
+func (c *chunk) isBitmap() bool {
+	return !c.bitmap.IsNil()
+}
+
+// Returns the used part of this chunk's array
+func (c *chunk) values() []uint16 {
+	return c.array.Value()[:c.count]
+}
+
+func (c *chunk) contains(low uint16) bool {
+	if c.isBitmap() {
+		return c.bitmap.Value()[low/64]&(1<<(low%64)) != 0
+	}
+	_, found := c.search(low)
+	return found
+}
+
+// Returns the position of low in this chunk's array, and whether it is
+// there. If it isn't the position is where it would be inserted.
+func (c *chunk) search(low uint16) (int, bool) {
+	values := c.values()
+	idx := sort.Search(len(values), func(i int) bool {
+		return values[i] >= low
+	})
+	return idx, idx < len(values) && values[idx] == low
+}
+
+// Adds low to this chunk, converting the chunk to a bitmap if it grows too
+// large for an array
+func (c *chunk) add(store *offheap.Store, low uint16) {
+	if c.isBitmap() {
+		word := &c.bitmap.Value()[low/64]
+		if *word&(1<<(low%64)) == 0 {
+			*word |= 1 << (low % 64)
+			c.count++
+		}
+		return
+	}
+
+	idx, found := c.search(low)
+	if found {
+		return
+	}
+
+	if c.count == maxArrayValues {
+		c.convertToBitmap(store)
+		c.add(store, low)
+		return
+	}
+
+	array := c.array.Value()
+	if c.count == len(array) {
+		newCapacity := min(len(array)*2, maxArrayValues)
+		newArrayRef := offheap.AllocSlice[uint16](store, newCapacity, newCapacity)
+		copy(newArrayRef.Value(), array)
+		offheap.FreeSlice(store, c.array)
+		c.array = newArrayRef
+		array = newArrayRef.Value()
+	}
+
+	copy(array[idx+1:c.count+1], array[idx:c.count])
+	array[idx] = low
+	c.count++
+}
+
+// Removes low from this chunk, converting the chunk to an array if it
+// shrinks small enough
+func (c *chunk) remove(store *offheap.Store, low uint16) {
+	if c.isBitmap() {
+		word := &c.bitmap.Value()[low/64]
+		if *word&(1<<(low%64)) != 0 {
+			*word &^= 1 << (low % 64)
+			c.count--
+		}
+		if c.count == maxArrayValues {
+			c.convertToArray(store)
+		}
+		return
+	}
+
+	idx, found := c.search(low)
+	if !found {
+		return
+	}
+	array := c.array.Value()
+	copy(array[idx:], array[idx+1:c.count])
+	c.count--
+}
+
+// Returns the number of values in this chunk less than low
+func (c *chunk) rank(low uint16) int {
+	if !c.isBitmap() {
+		idx, _ := c.search(low)
+		return idx
+	}
+
+	rank := 0
+	bitmap := c.bitmap.Value()
+	for _, word := range bitmap[:low/64] {
+		rank += bits.OnesCount64(word)
+	}
+	return rank + bits.OnesCount64(bitmap[low/64]&(1<<(low%64)-1))
+}
+
+// Returns the n'th smallest value in this chunk, n must be less than count
+func (c *chunk) selectValue(n int) uint16 {
+	if !c.isBitmap() {
+		return c.values()[n]
+	}
+
+	for i, word := range c.bitmap.Value() {
+		count := bits.OnesCount64(word)
+		if n < count {
+			return uint16(i*64 + selectInWord(word, n))
+		}
+		n -= count
+	}
+	panic("unreachable, n must be less than count")
+}
+
+// Writes the values of this chunk into words as a bitmap
+func (c *chunk) toBitmap(words *[bitmapWords]uint64) {
+	if c.isBitmap() {
+		copy(words[:], c.bitmap.Value())
+		return
+	}
+
+	clear(words[:])
+	for _, low := range c.values() {
+		words[low/64] |= 1 << (low % 64)
+	}
+}
+
+func (c *chunk) convertToBitmap(store *offheap.Store) {
+	bitmap := offheap.AllocSlice[uint64](store, bitmapWords, bitmapWords)
+	words := (*[bitmapWords]uint64)(bitmap.Value())
+	c.toBitmap(words)
+
+	offheap.FreeSlice(store, c.array)
+	c.array = offheap.RefSlice[uint16]{}
+	c.bitmap = bitmap
+}
+
+func (c *chunk) convertToArray(store *offheap.Store) {
+	words := (*[bitmapWords]uint64)(c.bitmap.Value())
+	converted, _ := chunkFromBitmap(store, c.key, words)
+
+	offheap.FreeSlice(store, c.bitmap)
+	*c = converted
+}
+
+// Returns a copy of this chunk, allocated from store
+func (c *chunk) clone(store *offheap.Store) chunk {
+	cloned := *c
+	if c.isBitmap() {
+		cloned.bitmap = offheap.CloneSlice(store, c.bitmap)
+	} else {
+		cloned.array = offheap.CloneSlice(store, c.array)
+	}
+	return cloned
+}
+
+// Frees the memory used by this chunk
+func (c *chunk) free(store *offheap.Store) {
+	if c.isBitmap() {
+		offheap.FreeSlice(store, c.bitmap)
+	} else {
+		offheap.FreeSlice(store, c.array)
+	}
+}
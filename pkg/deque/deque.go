@@ -0,0 +1,224 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+// The deque package provides a double ended queue whose elements are stored
+// offheap. A Deque can be used as a FIFO queue, a stack, or both at once.
+//
+// Elements are stored in fixed size chunks, each chunk is a single offheap
+// slice allocation. The chunks are organised in a ring, itself an offheap
+// slice, which is doubled in size when it fills up. Pushing or popping at
+// either end of the deque is amortized O(1), and elements are never moved
+// once they have been pushed.
+//
+// Compared to a linkedlist, which allocates a node for every element, a Deque
+// allocates once per CHUNK_SIZE elements, making it much cheaper for queues
+// of large numbers of small elements.
+package deque
+
+import (
+	"github.com/fmstephe/memorymanager/offheap"
+)
+
+// The number of elements stored in each chunk
+const CHUNK_SIZE = 128
+
+// The number of chunks in a new Deque's ring of chunks
+const initialRingSize = 4
+
+// A double ended queue of elements of type T. The type T must not contain any
+// pointers.
+//
+// A Deque is not safe for concurrent use.
+type Deque[T any] struct {
+	store *offheap.Store
+
+	// A ring of chunk references. Only the chunkCount references starting
+	// at firstChunk (wrapping around the end of the ring) are allocated.
+	ring offheap.RefSlice[offheap.RefSlice[T]]
+
+	// The position in ring of the chunk containing the front element
+	firstChunk int
+	// The number of chunks currently allocated
+	chunkCount int
+	// The position of the front element within the first chunk
+	front int
+	// The number of elements in the deque
+	length int
+}
+
+// Returns a new, empty, Deque.
+func New[T any]() *Deque[T] {
+	return NewWithStore[T](offheap.New())
+}
+
+// Returns a new, empty, Deque which allocates from store. This allows many
+// deques to share the same offheap memory.
+func NewWithStore[T any](store *offheap.Store) *Deque[T] {
+	return &Deque[T]{
+		store: store,
+		ring:  offheap.AllocSlice[offheap.RefSlice[T]](store, initialRingSize, initialRingSize),
+	}
+}
+
+// Returns the number of elements in the deque
+func (d *Deque[T]) Len() int {
+	return d.length
+}
+
+// Pushes value onto the front of the deque
+func (d *Deque[T]) PushFront(value T) {
+	if d.front == 0 {
+		// The first chunk is full, or there are no chunks, add a
+		// new chunk before it
+		d.growRing()
+		ring := d.ring.Value()
+		d.firstChunk = (d.firstChunk - 1 + len(ring)) % len(ring)
+		ring[d.firstChunk] = offheap.AllocSlice[T](d.store, CHUNK_SIZE, CHUNK_SIZE)
+		d.chunkCount++
+		d.front = CHUNK_SIZE
+	}
+
+	d.front--
+	d.length++
+	*d.at(0) = value
+}
+
+// Pushes value onto the back of the deque
+func (d *Deque[T]) PushBack(value T) {
+	if d.front+d.length == d.chunkCount*CHUNK_SIZE {
+		// The last chunk is full, or there are no chunks, add a new
+		// chunk after it
+		d.growRing()
+		ring := d.ring.Value()
+		last := (d.firstChunk + d.chunkCount) % len(ring)
+		ring[last] = offheap.AllocSlice[T](d.store, CHUNK_SIZE, CHUNK_SIZE)
+		d.chunkCount++
+	}
+
+	d.length++
+	*d.at(d.length - 1) = value
+}
+
+// Removes the front element of the deque and returns it. If the deque is
+// empty the zero value of T and false are returned.
+func (d *Deque[T]) PopFront() (T, bool) {
+	if d.length == 0 {
+		var zero T
+		return zero, false
+	}
+
+	value := *d.at(0)
+	d.front++
+	d.length--
+
+	if d.front == CHUNK_SIZE {
+		// The first chunk is now empty, free it
+		ring := d.ring.Value()
+		offheap.FreeSlice(d.store, ring[d.firstChunk])
+		ring[d.firstChunk] = offheap.RefSlice[T]{}
+		d.firstChunk = (d.firstChunk + 1) % len(ring)
+		d.chunkCount--
+		d.front = 0
+	}
+
+	return value, true
+}
+
+// Removes the back element of the deque and returns it. If the deque is
+// empty the zero value of T and false are returned.
+func (d *Deque[T]) PopBack() (T, bool) {
+	if d.length == 0 {
+		var zero T
+		return zero, false
+	}
+
+	value := *d.at(d.length - 1)
+	d.length--
+
+	if d.front+d.length == (d.chunkCount-1)*CHUNK_SIZE {
+		// The last chunk is now empty, free it
+		ring := d.ring.Value()
+		last := (d.firstChunk + d.chunkCount - 1) % len(ring)
+		offheap.FreeSlice(d.store, ring[last])
+		ring[last] = offheap.RefSlice[T]{}
+		d.chunkCount--
+		if d.chunkCount == 0 {
+			d.front = 0
+		}
+	}
+
+	return value, true
+}
+
+// Returns a pointer to the front element of the deque. If the deque is
+// empty nil is returned.
+//
+// The pointer must not be used after the element has been popped.
+func (d *Deque[T]) PeekFront() *T {
+	if d.length == 0 {
+		return nil
+	}
+	return d.at(0)
+}
+
+// Returns a pointer to the back element of the deque. If the deque is empty
+// nil is returned.
+//
+// The pointer must not be used after the element has been popped.
+func (d *Deque[T]) PeekBack() *T {
+	if d.length == 0 {
+		return nil
+	}
+	return d.at(d.length - 1)
+}
+
+// Returns a pointer to the element at position i, where the front element is
+// at position 0. Panics if i is out of range.
+//
+// The pointer must not be used after the element has been popped.
+func (d *Deque[T]) Get(i int) *T {
+	if i < 0 || i >= d.length {
+		panic("deque index out of range")
+	}
+	return d.at(i)
+}
+
+// Frees all of the memory used by this deque. After this method is called
+// the deque must not be used again.
+func (d *Deque[T]) Free() {
+	ring := d.ring.Value()
+	for i := range d.chunkCount {
+		offheap.FreeSlice(d.store, ring[(d.firstChunk+i)%len(ring)])
+	}
+	offheap.FreeSlice(d.store, d.ring)
+	*d = Deque[T]{}
+}
+
+// Returns a pointer to the element at position i
+func (d *Deque[T]) at(i int) *T {
+	pos := d.front + i
+	ring := d.ring.Value()
+	chunk := &ring[(d.firstChunk+pos/CHUNK_SIZE)%len(ring)]
+	return &chunk.Value()[pos%CHUNK_SIZE]
+}
+
+// If every position in the ring is in use, doubles the size of the ring. The
+// chunks in use are copied to the start of the new ring.
+func (d *Deque[T]) growRing() {
+	ring := d.ring.Value()
+	if d.chunkCount < len(ring) {
+		return
+	}
+
+	newRingRef := offheap.AllocSlice[offheap.RefSlice[T]](d.store, len(ring)*2, len(ring)*2)
+	newRing := newRingRef.Value()
+	for i := range d.chunkCount {
+		newRing[i] = ring[(d.firstChunk+i)%len(ring)]
+	}
+	clear(newRing[d.chunkCount:])
+
+	offheap.FreeSlice(d.store, d.ring)
+	d.ring = newRingRef
+	d.firstChunk = 0
+}
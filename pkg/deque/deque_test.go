@@ -0,0 +1,150 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+package deque
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testData struct {
+	id    int
+	value float64
+}
+
+// Show that an empty deque can't be popped or peeked
+func TestEmpty(t *testing.T) {
+	d := New[testData]()
+	defer d.Free()
+
+	assert.Equal(t, 0, d.Len())
+	assert.Nil(t, d.PeekFront())
+	assert.Nil(t, d.PeekBack())
+
+	value, ok := d.PopFront()
+	assert.False(t, ok)
+	assert.Equal(t, testData{}, value)
+
+	value, ok = d.PopBack()
+	assert.False(t, ok)
+	assert.Equal(t, testData{}, value)
+
+	assert.Panics(t, func() { d.Get(0) })
+}
+
+// Show that a deque can be used as a FIFO queue
+func TestQueue(t *testing.T) {
+	d := New[testData]()
+	defer d.Free()
+
+	count := CHUNK_SIZE*10 + 7
+	for i := range count {
+		d.PushBack(testData{id: i})
+	}
+	assert.Equal(t, count, d.Len())
+
+	for i := range count {
+		assert.Equal(t, i, d.PeekFront().id)
+		value, ok := d.PopFront()
+		require.True(t, ok)
+		assert.Equal(t, i, value.id)
+	}
+	assert.Equal(t, 0, d.Len())
+}
+
+// Show that a deque can be used as a stack
+func TestStack(t *testing.T) {
+	d := New[testData]()
+	defer d.Free()
+
+	count := CHUNK_SIZE*10 + 7
+	for i := range count {
+		d.PushFront(testData{id: i})
+	}
+	assert.Equal(t, count, d.Len())
+
+	for i := count - 1; i >= 0; i-- {
+		value, ok := d.PopFront()
+		require.True(t, ok)
+		assert.Equal(t, i, value.id)
+	}
+	assert.Equal(t, 0, d.Len())
+}
+
+// Show that a queue which is repeatedly filled and drained, crossing chunk
+// boundaries, doesn't leak chunks
+func TestRepeatedFillAndDrain(t *testing.T) {
+	d := New[testData]()
+	defer d.Free()
+
+	for range 100 {
+		for i := range CHUNK_SIZE * 3 {
+			d.PushBack(testData{id: i})
+		}
+		for i := range CHUNK_SIZE * 3 {
+			value, ok := d.PopFront()
+			require.True(t, ok)
+			assert.Equal(t, i, value.id)
+		}
+		assert.LessOrEqual(t, d.chunkCount, 1)
+	}
+}
+
+// Randomly push and pop at both ends of a deque, checking its contents
+// against a simple slice based deque
+func TestRandomOperations(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+
+	d := New[testData]()
+	defer d.Free()
+	expected := []testData{}
+
+	for i := range 100_000 {
+		switch r.Intn(5) {
+		case 0, 1:
+			// Pushes are a little more likely than pops so the
+			// deque will grow over time
+			value := testData{id: i, value: r.Float64()}
+			if r.Intn(2) == 0 {
+				d.PushFront(value)
+				expected = append([]testData{value}, expected...)
+			} else {
+				d.PushBack(value)
+				expected = append(expected, value)
+			}
+		case 2:
+			value := testData{id: i, value: r.Float64()}
+			d.PushBack(value)
+			expected = append(expected, value)
+		case 3:
+			value, ok := d.PopFront()
+			assert.Equal(t, len(expected) > 0, ok)
+			if ok {
+				assert.Equal(t, expected[0], value)
+				expected = expected[1:]
+			}
+		case 4:
+			value, ok := d.PopBack()
+			assert.Equal(t, len(expected) > 0, ok)
+			if ok {
+				assert.Equal(t, expected[len(expected)-1], value)
+				expected = expected[:len(expected)-1]
+			}
+		}
+
+		require.Equal(t, len(expected), d.Len())
+		if len(expected) > 0 {
+			assert.Equal(t, expected[0], *d.PeekFront())
+			assert.Equal(t, expected[len(expected)-1], *d.PeekBack())
+		}
+	}
+
+	for i := range expected {
+		assert.Equal(t, expected[i], *d.Get(i))
+	}
+}
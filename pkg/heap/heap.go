@@ -0,0 +1,148 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+// The heap package provides a priority queue whose elements are stored
+// offheap.
+//
+// A Heap is a binary heap, ordered by a user supplied less function. The
+// elements are stored in a single offheap slice, which is doubled in size when
+// it fills up. Pushing and popping are O(log n), and peeking at the smallest
+// element is O(1). Because the elements are stored offheap a Heap can hold
+// millions of pending items, such as timers or events, without adding to the
+// cost of garbage collection.
+package heap
+
+import (
+	"github.com/fmstephe/memorymanager/offheap"
+)
+
+// The capacity of a new Heap's slice of elements
+const initialCapacity = 16
+
+// A binary heap of elements of type T, where the element which is less than
+// every other element, according to less, is always at the top of the heap.
+// The type T must not contain any pointers.
+//
+// A Heap is not safe for concurrent use.
+type Heap[T any] struct {
+	store *offheap.Store
+	less  func(a, b T) bool
+
+	// The elements of the heap, only the first length elements are in use
+	elements offheap.RefSlice[T]
+	// The number of elements in the heap
+	length int
+}
+
+// Returns a new, empty, Heap ordered by less. less must return true if a
+// must be popped before b.
+func New[T any](less func(a, b T) bool) *Heap[T] {
+	return NewWithStore[T](offheap.New(), less)
+}
+
+// Returns a new, empty, Heap which allocates from store. This allows many
+// heaps to share the same offheap memory. See New.
+func NewWithStore[T any](store *offheap.Store, less func(a, b T) bool) *Heap[T] {
+	return &Heap[T]{
+		store:    store,
+		less:     less,
+		elements: offheap.AllocSlice[T](store, initialCapacity, initialCapacity),
+	}
+}
+
+// Returns the number of elements in the heap
+func (h *Heap[T]) Len() int {
+	return h.length
+}
+
+// Pushes value onto the heap
+func (h *Heap[T]) Push(value T) {
+	h.grow()
+
+	elements := h.elements.Value()
+	elements[h.length] = value
+	h.length++
+	h.up(elements, h.length-1)
+}
+
+// Removes the smallest element from the heap, returning it. If the heap is
+// empty the zero value of T and false are returned.
+func (h *Heap[T]) Pop() (T, bool) {
+	if h.length == 0 {
+		var zero T
+		return zero, false
+	}
+
+	elements := h.elements.Value()
+	value := elements[0]
+	h.length--
+	elements[0] = elements[h.length]
+	h.down(elements, 0)
+	return value, true
+}
+
+// Returns a pointer to the smallest element in the heap. If the heap is empty
+// nil is returned.
+//
+// The element must not be modified in a way which changes its order, and the
+// pointer must not be used after the heap is pushed to or popped from.
+func (h *Heap[T]) Peek() *T {
+	if h.length == 0 {
+		return nil
+	}
+	return &h.elements.Value()[0]
+}
+
+// Frees all of the memory used by this heap. After this method is called the
+// heap must not be used again.
+func (h *Heap[T]) Free() {
+	offheap.FreeSlice(h.store, h.elements)
+	*h = Heap[T]{}
+}
+
+// If every element is in use, doubles the capacity of the heap
+func (h *Heap[T]) grow() {
+	elements := h.elements.Value()
+	if h.length < len(elements) {
+		return
+	}
+
+	newElementsRef := offheap.AllocSlice[T](h.store, len(elements)*2, len(elements)*2)
+	copy(newElementsRef.Value(), elements)
+
+	offheap.FreeSlice(h.store, h.elements)
+	h.elements = newElementsRef
+}
+
+// Moves the element at i up the heap until its parent is not greater than it
+func (h *Heap[T]) up(elements []T, i int) {
+	for i > 0 {
+		parent := (i - 1) / 2
+		if !h.less(elements[i], elements[parent]) {
+			return
+		}
+		elements[i], elements[parent] = elements[parent], elements[i]
+		i = parent
+	}
+}
+
+// Moves the element at i down the heap until neither of its children are
+// less than it
+func (h *Heap[T]) down(elements []T, i int) {
+	for {
+		smallest := i
+		left, right := 2*i+1, 2*i+2
+		if left < h.length && h.less(elements[left], elements[smallest]) {
+			smallest = left
+		}
+		if right < h.length && h.less(elements[right], elements[smallest]) {
+			smallest = right
+		}
+		if smallest == i {
+			return
+		}
+		elements[i], elements[smallest] = elements[smallest], elements[i]
+		i = smallest
+	}
+}
@@ -0,0 +1,105 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+package heap
+
+import (
+	"math/rand"
+	"slices"
+	"testing"
+
+	"github.com/fmstephe/memorymanager/offheap"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testEvent struct {
+	deadline int64
+	id       int
+}
+
+func lessDeadline(a, b testEvent) bool {
+	return a.deadline < b.deadline
+}
+
+// Show that an empty heap can't be popped or peeked
+func TestEmpty(t *testing.T) {
+	h := New(lessDeadline)
+	defer h.Free()
+
+	assert.Equal(t, 0, h.Len())
+	assert.Nil(t, h.Peek())
+
+	value, ok := h.Pop()
+	assert.False(t, ok)
+	assert.Equal(t, testEvent{}, value)
+}
+
+// Show that elements are popped in order, regardless of the order they were
+// pushed in
+func TestPushPop(t *testing.T) {
+	h := New(lessDeadline)
+	defer h.Free()
+
+	r := rand.New(rand.NewSource(1))
+	count := initialCapacity*10 + 7
+	for i, deadline := range r.Perm(count) {
+		h.Push(testEvent{deadline: int64(deadline), id: i})
+	}
+	assert.Equal(t, count, h.Len())
+
+	for i := range count {
+		assert.Equal(t, int64(i), h.Peek().deadline)
+		value, ok := h.Pop()
+		require.True(t, ok)
+		assert.Equal(t, int64(i), value.deadline)
+	}
+	assert.Equal(t, 0, h.Len())
+}
+
+// Show that Free releases every allocation made by the heap
+func TestFree(t *testing.T) {
+	store := offheap.New()
+	defer func() {
+		assert.NoError(t, store.Destroy())
+	}()
+
+	h := NewWithStore(store, lessDeadline)
+	for i := range 1000 {
+		h.Push(testEvent{deadline: int64(i)})
+	}
+	assert.Equal(t, 1, store.TotalStats().Live)
+
+	h.Free()
+	assert.Equal(t, 0, store.TotalStats().Live)
+}
+
+// Randomly push and pop a heap, checking its contents against a sorted slice
+func TestRandomOperations(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+
+	h := New(lessDeadline)
+	defer h.Free()
+	expected := []int64{}
+
+	for i := range 100_000 {
+		switch r.Intn(5) {
+		case 0, 1, 2:
+			// Pushes are a little more likely than pops so the heap
+			// will grow over time
+			deadline := r.Int63n(1000)
+			h.Push(testEvent{deadline: deadline, id: i})
+			idx, _ := slices.BinarySearch(expected, deadline)
+			expected = slices.Insert(expected, idx, deadline)
+		case 3, 4:
+			value, ok := h.Pop()
+			require.Equal(t, len(expected) > 0, ok)
+			if ok {
+				require.Equal(t, expected[0], value.deadline)
+				expected = expected[1:]
+			}
+		}
+		require.Equal(t, len(expected), h.Len())
+	}
+}
@@ -0,0 +1,88 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+package intern
+
+import (
+	"io"
+	"net/netip"
+
+	"github.com/fmstephe/memorymanager/offheap"
+	"github.com/fmstephe/memorymanager/pkg/intern/internbase"
+)
+
+type addrInterner struct {
+	interner internbase.InternerWithComparableId[addrKey]
+}
+
+// Returns an interner for netip.Addr values, formatted using
+// netip.Addr.String().
+//
+// IPv6 addresses with a zone are never interned, their strings are allocated
+// every time.
+func NewAddrInterner(config internbase.Config) Interner[netip.Addr] {
+	return &addrInterner{
+		interner: internbase.NewInternerWithComparableId[addrKey](config, addrKey.String),
+	}
+}
+
+func (i *addrInterner) Get(value netip.Addr) string {
+	if value.Zone() != "" {
+		return value.String()
+	}
+	return i.interner.Get(newAddrKey(value))
+}
+
+func (i *addrInterner) GetRef(value netip.Addr) (offheap.RefString, bool) {
+	if value.Zone() != "" {
+		return offheap.RefString{}, false
+	}
+	return i.interner.GetRef(newAddrKey(value))
+}
+
+func (i *addrInterner) WriteDictionary(w io.Writer) error {
+	return i.interner.WriteDictionary(w)
+}
+
+func (i *addrInterner) LoadDictionary(r io.Reader) error {
+	return i.interner.LoadDictionary(r)
+}
+
+func (i *addrInterner) GetStats() internbase.StatsSummary {
+	return i.interner.GetStats()
+}
+
+func (i *addrInterner) StatsString() string {
+	return i.interner.StatsString()
+}
+
+// The identity of a netip.Addr without a zone. Unlike netip.Addr this type
+// contains no pointers, so it can be written to a dictionary.
+type addrKey struct {
+	ip [16]byte
+	// Either 4 or 6, or 0 for the invalid zero netip.Addr
+	version uint8
+}
+
+func newAddrKey(addr netip.Addr) addrKey {
+	key := addrKey{ip: addr.As16()}
+	switch {
+	case addr.Is4():
+		key.version = 4
+	case addr.Is6():
+		key.version = 6
+	}
+	return key
+}
+
+func (k addrKey) String() string {
+	switch k.version {
+	case 4:
+		return netip.AddrFrom16(k.ip).Unmap().String()
+	case 6:
+		return netip.AddrFrom16(k.ip).String()
+	default:
+		return netip.Addr{}.String()
+	}
+}
@@ -0,0 +1,81 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+package intern
+
+import (
+	"bytes"
+	"net/netip"
+	"testing"
+
+	"github.com/fmstephe/memorymanager/pkg/intern/internbase"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddrInterner_Interned(t *testing.T) {
+	interner := NewAddrInterner(internbase.Config{MaxLen: 64, MaxBytes: 1024})
+	addr := netip.MustParseAddr("192.168.1.10")
+
+	DoTestGenericInterner_Interned(t, interner, addr, addr.String())
+}
+
+func TestAddrInterner_NotInternedMaxLen(t *testing.T) {
+	interner := NewAddrInterner(internbase.Config{MaxLen: 3, MaxBytes: 1024})
+	addr := netip.MustParseAddr("2001:db8::1")
+
+	DoTestGenericInterner_NotInternedMaxLen(t, interner, addr, addr.String())
+}
+
+func TestAddrInterner_NotInternedMaxBytes(t *testing.T) {
+	interner := NewAddrInterner(internbase.Config{MaxLen: 64, MaxBytes: 3})
+	addr := netip.MustParseAddr("2001:db8::1")
+
+	DoTestGenericInterner_NotInternedMaxBytes(t, interner, addr, addr.String())
+}
+
+// Demonstrate that IPv4, IPv6 and IPv4-mapped IPv6 addresses sharing the same
+// bytes are interned separately, and that addresses with a zone are never
+// interned
+func TestAddrInterner_Format(t *testing.T) {
+	interner := NewAddrInterner(internbase.Config{MaxLen: 64, MaxBytes: 1024})
+
+	for _, addr := range []netip.Addr{
+		{},
+		netip.MustParseAddr("1.2.3.4"),
+		netip.MustParseAddr("::ffff:1.2.3.4"),
+		netip.MustParseAddr("2001:db8::1"),
+		netip.MustParseAddr("::"),
+	} {
+		assert.Equal(t, addr.String(), interner.Get(addr))
+	}
+	assert.Equal(t, internbase.Stats{Interned: 5}, interner.GetStats().Total)
+
+	zoned := netip.MustParseAddr("fe80::1%eth0")
+	assert.Equal(t, "fe80::1%eth0", interner.Get(zoned))
+	_, ok := interner.GetRef(zoned)
+	assert.False(t, ok)
+	assert.Equal(t, internbase.Stats{Interned: 5}, interner.GetStats().Total)
+
+	// Addresses can be written to, and loaded from, a dictionary
+	buf := &bytes.Buffer{}
+	require.NoError(t, interner.WriteDictionary(buf))
+	loaded := NewAddrInterner(internbase.Config{MaxLen: 64, MaxBytes: 1024})
+	require.NoError(t, loaded.LoadDictionary(buf))
+	assert.Equal(t, "::ffff:1.2.3.4", loaded.Get(netip.MustParseAddr("::ffff:1.2.3.4")))
+	assert.Equal(t, internbase.Stats{Interned: 5, Returned: 1}, loaded.GetStats().Total)
+}
+
+// Assert that getting a string, where the value has already been interned,
+// does not allocate
+func TestAddrInterner_NoAllocations(t *testing.T) {
+	interner := NewAddrInterner(internbase.Config{MaxLen: 0, MaxBytes: 0})
+
+	addrs := make([]netip.Addr, 10_000)
+	for i := range addrs {
+		addrs[i] = netip.AddrFrom4([4]byte{10, 0, byte(i >> 8), byte(i)})
+	}
+
+	DoTestGenericInterner_NoAllocations(t, interner, addrs)
+}
@@ -0,0 +1,66 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+package intern
+
+import (
+	"io"
+	"strconv"
+
+	"github.com/fmstephe/memorymanager/offheap"
+	"github.com/fmstephe/memorymanager/pkg/intern/internbase"
+)
+
+type boolInterner struct {
+	interner internbase.InternerWithUint64Id[boolConverter]
+}
+
+func NewBoolInterner(config internbase.Config) Interner[bool] {
+	return &boolInterner{
+		interner: internbase.NewInternerWithUint64Id[boolConverter](config),
+	}
+}
+
+func (i *boolInterner) Get(value bool) string {
+	return i.interner.Get(boolConverter{value: value})
+}
+
+func (i *boolInterner) GetRef(value bool) (offheap.RefString, bool) {
+	return i.interner.GetRef(boolConverter{value: value})
+}
+
+func (i *boolInterner) WriteDictionary(w io.Writer) error {
+	return i.interner.WriteDictionary(w)
+}
+
+func (i *boolInterner) LoadDictionary(r io.Reader) error {
+	return i.interner.LoadDictionary(r)
+}
+
+func (i *boolInterner) GetStats() internbase.StatsSummary {
+	return i.interner.GetStats()
+}
+
+func (i *boolInterner) StatsString() string {
+	return i.interner.StatsString()
+}
+
+var _ internbase.ConverterWithUint64Id = boolConverter{}
+
+// A converter for bool values. Here the identity is 1 for true and 0 for
+// false.
+type boolConverter struct {
+	value bool
+}
+
+func (c boolConverter) Identity() uint64 {
+	if c.value {
+		return 1
+	}
+	return 0
+}
+
+func (c boolConverter) Append(buf []byte) []byte {
+	return strconv.AppendBool(buf, c.value)
+}
@@ -0,0 +1,25 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+package intern
+
+import (
+	"testing"
+
+	"github.com/fmstephe/memorymanager/pkg/intern/internbase"
+)
+
+func TestBoolInterner_Interned(t *testing.T) {
+	interner := NewBoolInterner(internbase.Config{MaxLen: 64, MaxBytes: 1024})
+
+	DoTestGenericInterner_Interned(t, interner, true, "true")
+}
+
+// Assert that getting a string, where the value has already been interned,
+// does not allocate
+func TestBoolInterner_NoAllocations(t *testing.T) {
+	interner := NewBoolInterner(internbase.Config{MaxLen: 0, MaxBytes: 0})
+
+	DoTestGenericInterner_NoAllocations(t, interner, []bool{true, false})
+}
@@ -5,6 +5,9 @@
 package intern
 
 import (
+	"io"
+
+	"github.com/fmstephe/memorymanager/offheap"
 	"github.com/fmstephe/memorymanager/pkg/intern/internbase"
 )
 
@@ -22,10 +25,26 @@ func (i *bytesInterner) Get(bytes []byte) string {
 	return i.interner.Get(newBytesConverter(bytes))
 }
 
+func (i *bytesInterner) GetRef(bytes []byte) (offheap.RefString, bool) {
+	return i.interner.GetRef(newBytesConverter(bytes))
+}
+
+func (i *bytesInterner) WriteDictionary(w io.Writer) error {
+	return i.interner.WriteDictionary(w)
+}
+
+func (i *bytesInterner) LoadDictionary(r io.Reader) error {
+	return i.interner.LoadDictionary(r)
+}
+
 func (i *bytesInterner) GetStats() internbase.StatsSummary {
 	return i.interner.GetStats()
 }
 
+func (i *bytesInterner) StatsString() string {
+	return i.interner.StatsString()
+}
+
 var _ internbase.ConverterWithBytesId = bytesConverter{}
 
 type bytesConverter struct {
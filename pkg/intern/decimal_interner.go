@@ -0,0 +1,100 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+package intern
+
+import (
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/fmstephe/memorymanager/offheap"
+	"github.com/fmstephe/memorymanager/pkg/intern/internbase"
+)
+
+// A fixed point decimal number, whose value is Value * 10^-Scale. For example
+// the Decimal{Value: 12345, Scale: 2} is 123.45.
+//
+// A negative Scale multiplies Value by a power of ten, so Decimal{Value: 12,
+// Scale: -2} is 1200.
+type Decimal struct {
+	Value int64
+	Scale int
+}
+
+type decimalInterner struct {
+	interner internbase.InternerWithComparableId[Decimal]
+}
+
+// Returns an interner for Decimal values. Each Decimal is formatted with
+// exactly Scale digits after the decimal point, so Decimal{Value: 1200,
+// Scale: 2} is formatted as "12.00" and Decimal{Value: 12, Scale: 0} as "12".
+//
+// Decimals with equal values, but different scales, are formatted and
+// interned separately.
+func NewDecimalInterner(config internbase.Config) Interner[Decimal] {
+	return &decimalInterner{
+		interner: internbase.NewInternerWithComparableId[Decimal](config, formatDecimal),
+	}
+}
+
+func (i *decimalInterner) Get(value Decimal) string {
+	return i.interner.Get(value)
+}
+
+func (i *decimalInterner) GetRef(value Decimal) (offheap.RefString, bool) {
+	return i.interner.GetRef(value)
+}
+
+func (i *decimalInterner) WriteDictionary(w io.Writer) error {
+	return i.interner.WriteDictionary(w)
+}
+
+func (i *decimalInterner) LoadDictionary(r io.Reader) error {
+	return i.interner.LoadDictionary(r)
+}
+
+func (i *decimalInterner) GetStats() internbase.StatsSummary {
+	return i.interner.GetStats()
+}
+
+func (i *decimalInterner) StatsString() string {
+	return i.interner.StatsString()
+}
+
+func formatDecimal(d Decimal) string {
+	// The absolute value, this conversion is correct even for
+	// math.MinInt64
+	abs := uint64(d.Value)
+	if d.Value < 0 {
+		abs = -abs
+	}
+	digits := strconv.FormatUint(abs, 10)
+
+	buf := make([]byte, 0, len(digits)+max(d.Scale, -d.Scale)+3)
+	if d.Value < 0 {
+		buf = append(buf, '-')
+	}
+
+	if d.Scale <= 0 {
+		buf = append(buf, digits...)
+		if d.Value != 0 {
+			for range -d.Scale {
+				buf = append(buf, '0')
+			}
+		}
+		return string(buf)
+	}
+
+	// Pad with leading zeros so there is at least one digit before the
+	// decimal point
+	if len(digits) <= d.Scale {
+		digits = strings.Repeat("0", d.Scale+1-len(digits)) + digits
+	}
+	point := len(digits) - d.Scale
+	buf = append(buf, digits[:point]...)
+	buf = append(buf, '.')
+	buf = append(buf, digits[point:]...)
+	return string(buf)
+}
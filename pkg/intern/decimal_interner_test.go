@@ -0,0 +1,69 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+package intern
+
+import (
+	"math"
+	"testing"
+
+	"github.com/fmstephe/memorymanager/pkg/intern/internbase"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecimalInterner_Interned(t *testing.T) {
+	interner := NewDecimalInterner(internbase.Config{MaxLen: 64, MaxBytes: 1024})
+
+	DoTestGenericInterner_Interned(t, interner, Decimal{Value: 12345, Scale: 2}, "123.45")
+}
+
+func TestDecimalInterner_NotInternedMaxLen(t *testing.T) {
+	interner := NewDecimalInterner(internbase.Config{MaxLen: 3, MaxBytes: 1024})
+
+	DoTestGenericInterner_NotInternedMaxLen(t, interner, Decimal{Value: 12345, Scale: 2}, "123.45")
+}
+
+func TestDecimalInterner_NotInternedMaxBytes(t *testing.T) {
+	interner := NewDecimalInterner(internbase.Config{MaxLen: 64, MaxBytes: 3})
+
+	DoTestGenericInterner_NotInternedMaxBytes(t, interner, Decimal{Value: 12345, Scale: 2}, "123.45")
+}
+
+func TestDecimalInterner_Format(t *testing.T) {
+	interner := NewDecimalInterner(internbase.Config{MaxLen: 64, MaxBytes: 1024})
+
+	for _, tc := range []struct {
+		value    Decimal
+		expected string
+	}{
+		{Decimal{Value: 0, Scale: 0}, "0"},
+		{Decimal{Value: 0, Scale: 2}, "0.00"},
+		{Decimal{Value: 0, Scale: -2}, "0"},
+		{Decimal{Value: 12, Scale: 0}, "12"},
+		{Decimal{Value: 1200, Scale: 2}, "12.00"},
+		{Decimal{Value: 5, Scale: 2}, "0.05"},
+		{Decimal{Value: -5, Scale: 2}, "-0.05"},
+		{Decimal{Value: 12345, Scale: 5}, "0.12345"},
+		{Decimal{Value: -12345, Scale: 3}, "-12.345"},
+		{Decimal{Value: 12, Scale: -2}, "1200"},
+		{Decimal{Value: -12, Scale: -2}, "-1200"},
+		{Decimal{Value: math.MaxInt64, Scale: 4}, "922337203685477.5807"},
+		{Decimal{Value: math.MinInt64, Scale: 4}, "-922337203685477.5808"},
+	} {
+		assert.Equal(t, tc.expected, interner.Get(tc.value))
+	}
+}
+
+// Assert that getting a string, where the value has already been interned,
+// does not allocate
+func TestDecimalInterner_NoAllocations(t *testing.T) {
+	interner := NewDecimalInterner(internbase.Config{MaxLen: 0, MaxBytes: 0})
+
+	decimals := make([]Decimal, 10_000)
+	for i := range decimals {
+		decimals[i] = Decimal{Value: int64(i), Scale: i % 4}
+	}
+
+	DoTestGenericInterner_NoAllocations(t, interner, decimals)
+}
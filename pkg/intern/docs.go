@@ -26,8 +26,16 @@
 // stored in an *offheap.Store. This means that there is no garbage collection
 // cost associated with keeping large numbers of interned strings.
 //
+// Interned strings can also be retrieved as an offheap.RefString using
+//
+//	someTypeInterner.GetRef(someTypeValue) (offheap.RefString, bool)
+//
+// This allows interned strings to be stored inside data structures which are
+// themselves allocated in an offheap.Store. If the value can't be interned
+// GetRef returns false, and the caller must allocate its own string.
+//
 // This package contains a number of pre-made interners for the types int64,
-// float64, time.Time, []byte and string. But this package also includes the
+// uint64, bool, float64, Decimal, netip.Addr, time.Time, []byte and string. But this package also includes the
 // tools to build custom interners for other types.
 //
 // Because the interned strings are manually managed, and we don't have a
@@ -46,7 +54,43 @@
 // have a stable set of common string values, then this interning approach will
 // be less effective.
 //
+// An interner starts out empty, so every string it produces is allocated
+// until the common strings have been interned. To avoid this cold-start
+// period a process can persist its interned strings using WriteDictionary,
+// and load them into a new interner on startup using LoadDictionary.
+//
+// For these workloads eviction can be enabled, see internbase.Config.Evict.
+// With eviction, interned strings which are no longer being used are evicted
+// and their bytes are reused to intern new strings. The cost is that interned
+// strings are held on the Go heap, rather than offheap, and that RefStrings
+// returned by the interner are freed when their string is evicted.
+//
+// # Concurrency
+//
+// Every interner is safe for concurrent use by multiple goroutines. Each
+// interner is divided into a number of shards, see internbase.Config.Shards,
+// and each value is always handled by the same shard. Each shard has its own
+// lock, its own statistics and, unless a Store is provided in the config, its
+// own offheap.Store. So goroutines interning values handled by different
+// shards never contend with each other.
+//
+// The strings returned by an interner are immutable, and can be shared freely
+// between goroutines. Interning a string happens before that string is
+// returned by any call to Get, in any goroutine, so the contents of an
+// interned string are always visible to every goroutine which receives it.
+// Only MaxBytes is shared across all shards, and it is maintained using
+// atomic operations.
+//
+// Values of any other comparable type can be interned using NewInterner, with
+// a function which converts each value to a string.
+//
 // It should be reasonably easy to create new interners using the types found
 // in the internbase package. Just following the implementation of the
 // interners found in this package.
+//
+// Interners for values identified by a uint64 look up each value by its
+// identity, and only format the value into a string when it isn't already
+// interned. A custom interner can do the same thing directly, using
+// internbase.InternerWithUint64Id.GetOrInsert with its own formatting
+// function.
 package intern
@@ -5,9 +5,11 @@
 package intern
 
 import (
+	"io"
 	"math"
 	"strconv"
 
+	"github.com/fmstephe/memorymanager/offheap"
 	"github.com/fmstephe/memorymanager/pkg/intern/internbase"
 )
 
@@ -31,15 +33,31 @@ func (i *float64Interner) Get(value float64) string {
 	return i.interner.Get(newFloat64Converter(value, i.fmt, i.prec, i.bitSize))
 }
 
+func (i *float64Interner) GetRef(value float64) (offheap.RefString, bool) {
+	return i.interner.GetRef(newFloat64Converter(value, i.fmt, i.prec, i.bitSize))
+}
+
+func (i *float64Interner) WriteDictionary(w io.Writer) error {
+	return i.interner.WriteDictionary(w)
+}
+
+func (i *float64Interner) LoadDictionary(r io.Reader) error {
+	return i.interner.LoadDictionary(r)
+}
+
 func (i *float64Interner) GetStats() internbase.StatsSummary {
 	return i.interner.GetStats()
 }
 
+func (i *float64Interner) StatsString() string {
+	return i.interner.StatsString()
+}
+
 var _ internbase.ConverterWithUint64Id = float64Converter{}
 
 // A flexible converter for float64 values. Here the identity is generated by a
 // call to math.Float64bits(...) and we convert the value into a string using
-// strconv.FormatFloat(...)
+// strconv.AppendFloat(...)
 type float64Converter struct {
 	value   float64
 	fmt     byte
@@ -60,6 +78,6 @@ func (c float64Converter) Identity() uint64 {
 	return math.Float64bits(c.value)
 }
 
-func (c float64Converter) String() string {
-	return strconv.FormatFloat(c.value, c.fmt, c.prec, c.bitSize)
+func (c float64Converter) Append(buf []byte) []byte {
+	return strconv.AppendFloat(buf, c.value, c.fmt, c.prec, c.bitSize)
 }
@@ -0,0 +1,53 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+package intern
+
+import (
+	"io"
+
+	"github.com/fmstephe/memorymanager/offheap"
+	"github.com/fmstephe/memorymanager/pkg/intern/internbase"
+)
+
+type genericInterner[K comparable] struct {
+	interner internbase.InternerWithComparableId[K]
+}
+
+// Returns an interner for values of any comparable type K, such as UUIDs,
+// decimal types or IP addresses. The function toString converts each value
+// to its string representation. It is only called for values which have not
+// already been interned.
+//
+// The function toString must always produce the same string for equal
+// values.
+func NewInterner[K comparable](config internbase.Config, toString func(K) string) Interner[K] {
+	return &genericInterner[K]{
+		interner: internbase.NewInternerWithComparableId[K](config, toString),
+	}
+}
+
+func (i *genericInterner[K]) Get(value K) string {
+	return i.interner.Get(value)
+}
+
+func (i *genericInterner[K]) GetRef(value K) (offheap.RefString, bool) {
+	return i.interner.GetRef(value)
+}
+
+func (i *genericInterner[K]) WriteDictionary(w io.Writer) error {
+	return i.interner.WriteDictionary(w)
+}
+
+func (i *genericInterner[K]) LoadDictionary(r io.Reader) error {
+	return i.interner.LoadDictionary(r)
+}
+
+func (i *genericInterner[K]) GetStats() internbase.StatsSummary {
+	return i.interner.GetStats()
+}
+
+func (i *genericInterner[K]) StatsString() string {
+	return i.interner.StatsString()
+}
@@ -0,0 +1,120 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+package intern
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/fmstephe/memorymanager/pkg/intern/internbase"
+	"github.com/stretchr/testify/assert"
+)
+
+type uuid [16]byte
+
+func uuidString(u uuid) string {
+	return fmt.Sprintf("%x-%x-%x-%x-%x", u[0:4], u[4:6], u[6:8], u[8:10], u[10:])
+}
+
+func makeUUID(i int) uuid {
+	u := uuid{}
+	u[0] = byte(i)
+	u[15] = byte(i >> 8)
+	return u
+}
+
+func TestGenericInterner_Interned(t *testing.T) {
+	interner := NewInterner(internbase.Config{MaxLen: 64, MaxBytes: 1024}, uuidString)
+	u := makeUUID(1234)
+
+	DoTestGenericInterner_Interned(t, interner, u, uuidString(u))
+}
+
+func TestGenericInterner_NotInternedMaxLen(t *testing.T) {
+	interner := NewInterner(internbase.Config{MaxLen: 3, MaxBytes: 1024}, uuidString)
+	u := makeUUID(1234)
+
+	DoTestGenericInterner_NotInternedMaxLen(t, interner, u, uuidString(u))
+}
+
+func TestGenericInterner_NotInternedMaxBytes(t *testing.T) {
+	interner := NewInterner(internbase.Config{MaxLen: 64, MaxBytes: 3}, uuidString)
+	u := makeUUID(1234)
+
+	DoTestGenericInterner_NotInternedMaxBytes(t, interner, u, uuidString(u))
+}
+
+type decimal struct {
+	value int64
+	scale int
+	unit  string
+}
+
+func decimalString(d decimal) string {
+	return strconv.FormatInt(d.value, 10) + "e-" + strconv.Itoa(d.scale) + d.unit
+}
+
+// Demonstrate that values containing pointers, here a string, are interned
+// correctly across many shards
+func TestGenericInterner_ValuesWithPointers(t *testing.T) {
+	interner := NewInterner(internbase.Config{MaxLen: 64, MaxBytes: 1 << 20, Shards: 16}, decimalString)
+
+	units := []string{"m", "kg", "s"}
+	for range 2 {
+		for i := range 1000 {
+			// The unit is cloned so that equal values have
+			// different in-memory representations
+			d := decimal{value: int64(i), scale: i % 5, unit: strings.Clone(units[i%3])}
+			assert.Equal(t, decimalString(d), interner.Get(d))
+		}
+	}
+
+	// Each value may be interned in more than one shard, but every value
+	// is interned at least once
+	stats := interner.GetStats()
+	assert.Equal(t, 2000, stats.Total.Interned+stats.Total.Returned)
+	assert.GreaterOrEqual(t, stats.Total.Interned, 1000)
+}
+
+// Assert that getting a string, where the value has already been interned,
+// does not allocate
+func TestGenericInterner_NoAllocations(t *testing.T) {
+	interner := NewInterner(internbase.Config{MaxLen: 0, MaxBytes: 0}, uuidString)
+
+	uuids := make([]uuid, 10_000)
+	for i := range uuids {
+		uuids[i] = makeUUID(i)
+	}
+
+	DoTestGenericInterner_NoAllocations(t, interner, uuids)
+}
+
+// Demonstrate that interned uuid strings can be persisted and loaded into a
+// new interner
+func TestGenericInterner_Dictionary(t *testing.T) {
+	uuids := make([]uuid, 1000)
+	for i := range uuids {
+		uuids[i] = makeUUID(i)
+	}
+
+	newInterner := func() Interner[uuid] {
+		return NewInterner(internbase.Config{MaxLen: 64, MaxBytes: 1 << 20, Shards: 4}, uuidString)
+	}
+	DoTestGenericInterner_Dictionary(t, newInterner, uuids, uuidString)
+}
+
+// Demonstrate that values containing pointers can't be written to, or loaded
+// from, a dictionary
+func TestGenericInterner_DictionaryWithPointers(t *testing.T) {
+	interner := NewInterner(internbase.Config{MaxLen: 64, MaxBytes: 1 << 20}, decimalString)
+	interner.Get(decimal{value: 1, scale: 2, unit: "kg"})
+
+	buf := &bytes.Buffer{}
+	assert.Error(t, interner.WriteDictionary(buf))
+	assert.Error(t, interner.LoadDictionary(buf))
+}
@@ -0,0 +1,129 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+package intern
+
+import (
+	"testing"
+	"time"
+	"unsafe"
+
+	"github.com/fmstephe/memorymanager/pkg/intern/internbase"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// A converter which counts the number of times it formats its value
+type countingConverter struct {
+	value   time.Time
+	formats *int
+}
+
+func (c countingConverter) Identity() uint64 {
+	return uint64(c.value.UnixNano())
+}
+
+func (c countingConverter) Append(buf []byte) []byte {
+	*c.formats++
+	return c.value.AppendFormat(buf, time.RFC3339Nano)
+}
+
+// Show that a value is only formatted when it isn't already interned
+func TestInternerWithUint64Id_FormatsOnlyOnMiss(t *testing.T) {
+	interner := internbase.NewInternerWithUint64Id[countingConverter](internbase.Config{MaxLen: 64, MaxBytes: 1024})
+	formats := 0
+	converter := countingConverter{value: time.Now(), formats: &formats}
+	expected := converter.value.Format(time.RFC3339Nano)
+
+	assert.Equal(t, expected, interner.Get(converter))
+	assert.Equal(t, 1, formats)
+
+	for range 10 {
+		assert.Equal(t, expected, interner.Get(converter))
+		ref, ok := interner.GetRef(converter)
+		require.True(t, ok)
+		assert.Equal(t, expected, ref.Value())
+	}
+	assert.Equal(t, 1, formats)
+
+	assert.Equal(t, internbase.Stats{Interned: 1, Returned: 20}, interner.GetStats().Total)
+}
+
+// Show that a value which can't be interned is formatted every time, and the
+// correct string is still returned
+func TestInternerWithUint64Id_FormatsNotInterned(t *testing.T) {
+	interner := internbase.NewInternerWithUint64Id[countingConverter](internbase.Config{MaxLen: 3, MaxBytes: 1024})
+	formats := 0
+	converter := countingConverter{value: time.Now(), formats: &formats}
+	expected := converter.value.Format(time.RFC3339Nano)
+
+	first := interner.Get(converter)
+	second := interner.Get(converter)
+	assert.Equal(t, expected, first)
+	assert.Equal(t, expected, second)
+	assert.Equal(t, 2, formats)
+
+	// The strings returned are independent copies, not views of the
+	// interner's formatting buffer
+	assert.NotSame(t, unsafe.StringData(first), unsafe.StringData(second))
+
+	assert.Equal(t, internbase.Stats{MaxLenExceeded: 2}, interner.GetStats().Total)
+}
+
+// Demonstrate that a custom interner can be built with Lookup and
+// GetOrInsert, without writing a converter type
+func TestInternerWithUint64Id_GetOrInsert(t *testing.T) {
+	interner := internbase.NewInternerWithUint64Id[countingConverter](internbase.Config{MaxLen: 64, MaxBytes: 1024})
+	formats := 0
+	formatDuration := func(d time.Duration) func([]byte) []byte {
+		return func(buf []byte) []byte {
+			formats++
+			return append(buf, d.String()...)
+		}
+	}
+
+	d := 1500 * time.Millisecond
+
+	// Nothing is interned yet
+	_, ok := interner.Lookup(uint64(d))
+	assert.False(t, ok)
+
+	str := interner.GetOrInsert(uint64(d), formatDuration(d))
+	assert.Equal(t, "1.5s", str)
+	assert.Equal(t, 1, formats)
+
+	// The interned string is found, without formatting it again
+	ref, ok := interner.Lookup(uint64(d))
+	require.True(t, ok)
+	refStr := ref.Value()
+	assert.Same(t, unsafe.StringData(str), unsafe.StringData(refStr))
+
+	str2 := interner.GetOrInsert(uint64(d), formatDuration(d))
+	assert.Same(t, unsafe.StringData(str), unsafe.StringData(str2))
+
+	ref, ok = interner.GetRefOrInsert(uint64(d), formatDuration(d))
+	require.True(t, ok)
+	refStr = ref.Value()
+	assert.Same(t, unsafe.StringData(str), unsafe.StringData(refStr))
+	assert.Equal(t, 1, formats)
+
+	assert.Equal(t, internbase.Stats{Interned: 1, Returned: 3}, interner.GetStats().Total)
+}
+
+// Show that GetRefOrInsert returns false when the string can't be interned
+func TestInternerWithUint64Id_GetRefOrInsertNotInterned(t *testing.T) {
+	interner := internbase.NewInternerWithUint64Id[countingConverter](internbase.Config{MaxLen: 64, MaxBytes: 3})
+
+	ref, ok := interner.GetRefOrInsert(42, func(buf []byte) []byte {
+		return append(buf, "forty-two"...)
+	})
+	assert.False(t, ok)
+	assert.True(t, ref.IsNil())
+
+	assert.Equal(t, "forty-two", interner.GetOrInsert(42, func(buf []byte) []byte {
+		return append(buf, "forty-two"...)
+	}))
+
+	assert.Equal(t, internbase.Stats{UsedBytesExceeded: 2}, interner.GetStats().Total)
+}
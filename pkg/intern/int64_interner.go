@@ -5,8 +5,10 @@
 package intern
 
 import (
+	"io"
 	"strconv"
 
+	"github.com/fmstephe/memorymanager/offheap"
 	"github.com/fmstephe/memorymanager/pkg/intern/internbase"
 )
 
@@ -26,10 +28,26 @@ func (i *int64Interner) Get(value int64) string {
 	return i.interner.Get(newInt64Converter(value, i.base))
 }
 
+func (i *int64Interner) GetRef(value int64) (offheap.RefString, bool) {
+	return i.interner.GetRef(newInt64Converter(value, i.base))
+}
+
+func (i *int64Interner) WriteDictionary(w io.Writer) error {
+	return i.interner.WriteDictionary(w)
+}
+
+func (i *int64Interner) LoadDictionary(r io.Reader) error {
+	return i.interner.LoadDictionary(r)
+}
+
 func (i *int64Interner) GetStats() internbase.StatsSummary {
 	return i.interner.GetStats()
 }
 
+func (i *int64Interner) StatsString() string {
+	return i.interner.StatsString()
+}
+
 var _ internbase.ConverterWithUint64Id = int64Converter{}
 
 // A converter for int64 values. Here the identity is just the value itself.
@@ -49,6 +67,6 @@ func (c int64Converter) Identity() uint64 {
 	return uint64(c.value)
 }
 
-func (c int64Converter) String() string {
-	return strconv.FormatInt(c.value, c.base)
+func (c int64Converter) Append(buf []byte) []byte {
+	return strconv.AppendInt(buf, c.value, c.base)
 }
@@ -132,3 +132,17 @@ func TestInt64Interner_NoAllocations(t *testing.T) {
 
 	DoTestGenericInterner_NoAllocations(t, interner, ints)
 }
+
+// Demonstrate that interned int strings can be persisted and loaded into a
+// new interner
+func TestInt64Interner_Dictionary(t *testing.T) {
+	ints := make([]int64, 1000)
+	for i := range ints {
+		ints[i] = int64(i * 7)
+	}
+
+	newInterner := func() Interner[int64] {
+		return NewInt64Interner(internbase.Config{MaxLen: 64, MaxBytes: 1 << 20, Shards: 4}, 16)
+	}
+	DoTestGenericInterner_Dictionary(t, newInterner, ints, func(value int64) string { return strconv.FormatInt(value, 16) })
+}
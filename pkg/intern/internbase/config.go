@@ -25,6 +25,25 @@ type Config struct {
 	// exhaustion.
 	MaxBytes int
 
+	// Enables eviction of interned strings. Without eviction, once
+	// MaxBytes is reached no new strings will be interned.
+	//
+	// With eviction each shard keeps two generations of interned strings.
+	// Each generation may use an equal share of half of MaxBytes. When a
+	// shard's current generation is full the shard frees its older
+	// generation, returning those bytes to the budget, and starts a new
+	// generation. Strings from the older generation which are requested
+	// again are copied into the current generation, so frequently used
+	// strings survive eviction.
+	//
+	// With eviction interned strings are held on the Go heap, rather than
+	// in the offheap Store, so an evicted string which is still in use is
+	// never freed and the strings returned by the interner may be retained
+	// indefinitely. Only the RefStrings returned by GetRef, and similar
+	// methods, are allocated in the Store, and these are freed when their
+	// string is evicted. Eviction requires MaxBytes > 0.
+	Evict bool
+
 	// Defines the number shards used internally to determine the level of
 	// available concurrency for the interner.
 	//
@@ -38,8 +57,10 @@ type Config struct {
 
 	// Defines the offheap store to use for allocating interned strings.
 	//
-	// If nil then a new store will be created internally. Only needed if
-	// you want to share a single offheap store across multiple interners.
+	// If nil then a new store will be created internally for each shard,
+	// so that shards never contend with each other when allocating. Only
+	// needed if you want to share a single offheap store across multiple
+	// interners, or across the shards of an interner.
 	Store *offheap.Store
 }
 
@@ -51,6 +72,16 @@ func (c *Config) getMaxBytes() int {
 	return c.MaxBytes
 }
 
+func (c *Config) getEvict() bool {
+	return c.Evict && c.MaxBytes > 0
+}
+
+// Returns the number of bytes each generation of each shard may use when
+// eviction is enabled
+func (c *Config) getGenerationBytes(shards int) int {
+	return max(c.MaxBytes/(2*shards), 1)
+}
+
 func (c *Config) getShards() int {
 	if c.Shards <= 0 {
 		c.Shards = runtime.NumCPU()
@@ -59,9 +90,10 @@ func (c *Config) getShards() int {
 	return nextPowerOfTwo(c.Shards)
 }
 
-func (c *Config) getStore() *offheap.Store {
+// Returns the store to be used by a single shard
+func (c *Config) getShardStore() *offheap.Store {
 	if c.Store == nil {
-		c.Store = offheap.New()
+		return offheap.New()
 	}
 	return c.Store
 }
@@ -0,0 +1,173 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+package internbase
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// A dictionary is a persisted table of interned strings, which can be loaded
+// into a new interner to avoid the cold-start period where nothing has been
+// interned yet.
+//
+// The format of a dictionary is
+//
+//	magic      - the 8 bytes "mmintern"
+//	version    - uvarint
+//	key size   - uvarint, the number of bytes in each entry's key
+//	entries    - until the end of the dictionary, each one being
+//		key    - key size bytes
+//		length - uvarint, the length of the string
+//		string - length bytes
+//
+// The key is whatever identity the interner needs, beyond the string itself,
+// to intern the string again. Interners which identify strings by their bytes
+// have a key size of 0.
+const (
+	dictionaryMagic   = "mmintern"
+	dictionaryVersion = 1
+	// A sanity limit on the length of a string read from a dictionary, to
+	// avoid enormous allocations when reading a corrupt dictionary
+	maxDictionaryStringLength = 1 << 30
+)
+
+type dictionaryWriter struct {
+	w       *bufio.Writer
+	keySize int
+	buf     [binary.MaxVarintLen64]byte
+}
+
+// Returns a new dictionaryWriter, after writing the dictionary header to w.
+func newDictionaryWriter(w io.Writer, keySize int) (*dictionaryWriter, error) {
+	d := &dictionaryWriter{
+		w:       bufio.NewWriter(w),
+		keySize: keySize,
+	}
+
+	if _, err := d.w.WriteString(dictionaryMagic); err != nil {
+		return nil, err
+	}
+	if err := d.writeUvarint(dictionaryVersion); err != nil {
+		return nil, err
+	}
+	if err := d.writeUvarint(uint64(keySize)); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// Writes a single dictionary entry. The length of key must be the key size
+// of this dictionary.
+func (d *dictionaryWriter) write(key []byte, str string) error {
+	if len(key) != d.keySize {
+		panic(fmt.Errorf("dictionary key size %d does not match %d", len(key), d.keySize))
+	}
+
+	if _, err := d.w.Write(key); err != nil {
+		return err
+	}
+	if err := d.writeUvarint(uint64(len(str))); err != nil {
+		return err
+	}
+	_, err := d.w.WriteString(str)
+	return err
+}
+
+// Flushes any buffered entries to the underlying io.Writer.
+func (d *dictionaryWriter) flush() error {
+	return d.w.Flush()
+}
+
+func (d *dictionaryWriter) writeUvarint(value uint64) error {
+	n := binary.PutUvarint(d.buf[:], value)
+	_, err := d.w.Write(d.buf[:n])
+	return err
+}
+
+type dictionaryReader struct {
+	r   *bufio.Reader
+	key []byte
+	str []byte
+}
+
+// Returns a new dictionaryReader, after reading and validating the
+// dictionary header from r.
+func newDictionaryReader(r io.Reader, keySize int) (*dictionaryReader, error) {
+	d := &dictionaryReader{
+		r:   bufio.NewReader(r),
+		key: make([]byte, keySize),
+	}
+
+	magic := make([]byte, len(dictionaryMagic))
+	if _, err := io.ReadFull(d.r, magic); err != nil {
+		return nil, fmt.Errorf("cannot read dictionary header %w", err)
+	}
+	if string(magic) != dictionaryMagic {
+		return nil, fmt.Errorf("not an interner dictionary, found magic %q", magic)
+	}
+
+	version, err := binary.ReadUvarint(d.r)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read dictionary version %w", err)
+	}
+	if version != dictionaryVersion {
+		return nil, fmt.Errorf("unsupported dictionary version %d", version)
+	}
+
+	dictKeySize, err := binary.ReadUvarint(d.r)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read dictionary key size %w", err)
+	}
+	if dictKeySize != uint64(keySize) {
+		return nil, fmt.Errorf("dictionary key size %d does not match %d, the dictionary was written by a different type of interner", dictKeySize, keySize)
+	}
+
+	return d, nil
+}
+
+// Reads the next dictionary entry. The key and string returned are only
+// valid until the next call to read. When there are no more entries io.EOF
+// is returned.
+func (d *dictionaryReader) read() (key []byte, str []byte, err error) {
+	// Check for the end of the dictionary, which must fall between entries
+	if _, err := d.r.Peek(1); err != nil {
+		return nil, nil, err
+	}
+
+	if _, err := io.ReadFull(d.r, d.key); err != nil {
+		return nil, nil, fmt.Errorf("cannot read dictionary key %w", unexpectedEOF(err))
+	}
+
+	length, err := binary.ReadUvarint(d.r)
+	if err != nil {
+		return nil, nil, fmt.Errorf("cannot read dictionary string length %w", unexpectedEOF(err))
+	}
+	if length > maxDictionaryStringLength {
+		return nil, nil, fmt.Errorf("dictionary string length %d exceeds maximum %d", length, maxDictionaryStringLength)
+	}
+
+	if uint64(cap(d.str)) < length {
+		d.str = make([]byte, length)
+	}
+	d.str = d.str[:length]
+	if _, err := io.ReadFull(d.r, d.str); err != nil {
+		return nil, nil, fmt.Errorf("cannot read dictionary string %w", unexpectedEOF(err))
+	}
+
+	return d.key, d.str, nil
+}
+
+// Once an entry has been started, reaching the end of the dictionary means
+// the dictionary is truncated
+func unexpectedEOF(err error) error {
+	if errors.Is(err, io.EOF) {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
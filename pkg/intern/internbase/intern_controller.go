@@ -60,3 +60,9 @@ func (c *internController) canInternUsedBytes(str string) bool {
 	// str can be interned, and the additional bytes have been accounted for
 	return true
 }
+
+// Returns bytes, previously accounted for by canInternUsedBytes, to the
+// available budget
+func (c *internController) releaseUsedBytes(bytes int) {
+	c.usedBytes.Add(-int64(bytes))
+}
@@ -0,0 +1,220 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+package internbase
+
+import (
+	"strings"
+	"unsafe"
+
+	"github.com/fmstephe/memorymanager/offheap"
+)
+
+// The interned strings of a single shard.
+//
+// Without eviction all strings are interned in the current generation, and
+// are allocated in the offheap Store. They are never freed, so the strings
+// returned by the interner, which refer to the Store's memory, remain valid
+// forever.
+//
+// With eviction the shard keeps a current and a previous generation. When the
+// current generation reaches generationBytes the previous generation is
+// evicted and the current generation becomes the previous generation. An
+// evicted string may have been returned as a Go string, which must never
+// change, so with eviction strings are held on the Go heap, where they are
+// collected once they are no longer used. An offheap copy of a string is only
+// allocated when a RefString is requested, see ref, and is freed when the
+// string is evicted.
+//
+// This type is not safe for concurrent use, it is protected by the lock of
+// the shard which owns it.
+type internedStrings[K comparable] struct {
+	controller *internController
+	store      *offheap.Store
+	evict      bool
+	// The number of bytes a generation may grow to before it is rotated
+	generationBytes int
+	//
+	current       map[K]internedString
+	currentBytes  int
+	previous      map[K]internedString
+	previousBytes int
+	// The number of strings in the previous generation which have been
+	// promoted into the current generation, and so are held twice
+	promoted int
+}
+
+// A single interned string
+type internedString struct {
+	// The string allocated in the offheap Store. Without eviction every
+	// string is allocated here. With eviction this is nil until a RefString
+	// is requested.
+	ref offheap.RefString
+	// With eviction, the string held on the Go heap
+	str string
+}
+
+func newInternedStrings[K comparable](controller *internController, store *offheap.Store, evict bool, generationBytes int) internedStrings[K] {
+	return internedStrings[K]{
+		controller:      controller,
+		store:           store,
+		evict:           evict,
+		generationBytes: generationBytes,
+		current:         make(map[K]internedString),
+		previous:        make(map[K]internedString),
+	}
+}
+
+// Returns the string interned with key. If the string is found in the
+// previous generation it is promoted into the current generation, if there
+// are enough bytes available.
+func (s *internedStrings[K]) lookup(key K) (string, bool) {
+	if interned, ok := s.current[key]; ok {
+		return s.value(interned), true
+	}
+
+	interned, ok := s.previous[key]
+	if !ok {
+		return "", false
+	}
+
+	// Promote the string into the current generation. The string is held
+	// on the Go heap, so the current generation can share it. The previous
+	// generation's entry is left in place, until the previous generation
+	// is evicted, as its RefString may still be in use.
+	if !s.controller.canInternUsedBytes(interned.str) {
+		return interned.str, true
+	}
+	s.current[key] = internedString{str: interned.str}
+	s.currentBytes += len(interned.str)
+	s.promoted++
+	return interned.str, true
+}
+
+// Returns the RefString for the string interned with key, which must have
+// just been found by lookup, or added. With eviction the RefString is
+// allocated the first time it is requested. The bytes for the RefString were
+// reserved when the string was added, so it is not counted again.
+func (s *internedStrings[K]) ref(key K) (offheap.RefString, bool) {
+	generation := s.current
+	interned, ok := generation[key]
+	if !ok {
+		generation = s.previous
+		interned, ok = generation[key]
+	}
+	if !ok {
+		return offheap.RefString{}, false
+	}
+
+	if interned.ref.IsNil() {
+		interned.ref = offheap.AllocStringFromString(s.store, interned.str)
+		generation[key] = interned
+	}
+	return interned.ref, true
+}
+
+// Returns the value of interned
+func (s *internedStrings[K]) value(interned internedString) string {
+	if s.evict {
+		return interned.str
+	}
+	return interned.ref.Value()
+}
+
+// Indicates whether there are enough bytes available to intern str. If
+// eviction is enabled, and the current generation is full, the generations
+// are rotated first to make space.
+func (s *internedStrings[K]) reserve(str string, stats *Stats) bool {
+	if s.evict && s.currentBytes > 0 && s.currentBytes+len(str) > s.generationBytes {
+		s.rotate(stats)
+	}
+	return s.controller.canInternUsedBytes(str)
+}
+
+// Adds a copy of str to the current generation, returning the interned
+// string. The bytes for str must have been reserved.
+func (s *internedStrings[K]) add(key K, str string) string {
+	interned := internedString{}
+	if s.evict {
+		interned.str = strings.Clone(str)
+	} else {
+		interned.ref = offheap.AllocStringFromString(s.store, str)
+	}
+	s.current[key] = interned
+	s.currentBytes += len(str)
+	return s.value(interned)
+}
+
+// Interns str with key, as if it had been submitted to the interner, unless
+// key is already interned. Strings which can't be interned are skipped.
+func (s *internedStrings[K]) load(key K, str []byte, stats *Stats) {
+	if _, ok := s.current[key]; ok {
+		return
+	}
+	if _, ok := s.previous[key]; ok {
+		return
+	}
+
+	unsafeStr := unsafe.String(unsafe.SliceData(str), len(str))
+	if !s.controller.canInternMaxLen(unsafeStr) {
+		return
+	}
+	if !s.reserve(unsafeStr, stats) {
+		return
+	}
+
+	s.add(key, unsafeStr)
+	stats.Interned++
+}
+
+// Calls f for every interned string, stopping at the first error returned.
+// Strings in the previous generation are included, unless they have already
+// been promoted into the current generation.
+func (s *internedStrings[K]) each(f func(key K, str string) error) error {
+	for key, interned := range s.current {
+		if err := f(key, s.value(interned)); err != nil {
+			return err
+		}
+	}
+	for key, interned := range s.previous {
+		if _, ok := s.current[key]; ok {
+			continue
+		}
+		if err := f(key, s.value(interned)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Returns the number of strings, and bytes, currently held. Promoted strings
+// are only counted once.
+func (s *internedStrings[K]) contents() ShardContents {
+	return ShardContents{
+		Strings:   len(s.current) + len(s.previous) - s.promoted,
+		UsedBytes: s.currentBytes + s.previousBytes,
+	}
+}
+
+// Evicts the previous generation, and starts a new current generation. The
+// evicted strings held on the Go heap are simply dropped, only their
+// RefStrings are freed.
+func (s *internedStrings[K]) rotate(stats *Stats) {
+	for _, interned := range s.previous {
+		if !interned.ref.IsNil() {
+			offheap.FreeString(s.store, interned.ref)
+		}
+	}
+	stats.Evicted += len(s.previous)
+	stats.Rotations++
+	s.controller.releaseUsedBytes(s.previousBytes)
+
+	// The old previous map is cleared and reused for the new current
+	// generation
+	clear(s.previous)
+	s.previous, s.current = s.current, s.previous
+	s.previousBytes, s.currentBytes = s.currentBytes, 0
+	// The new current generation is empty, so nothing has been promoted
+	s.promoted = 0
+}
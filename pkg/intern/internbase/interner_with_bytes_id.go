@@ -5,6 +5,7 @@
 package internbase
 
 import (
+	"io"
 	"sync"
 	"unsafe"
 
@@ -32,25 +33,22 @@ type ConverterWithBytesId interface {
 type InternerWithBytesId[C ConverterWithBytesId] struct {
 	indexMask  uint64
 	controller *internController
-	store      *offheap.Store
 	shards     []internerWithBytesIdShard
 }
 
 // Construct a new InternerWithBytesId with the provided config.
 func NewInternerWithBytesId[C ConverterWithBytesId](config Config) InternerWithBytesId[C] {
 	controller := newController(config.getMaxLen(), config.getMaxBytes())
-	store := config.getStore()
 	shardCount := config.getShards()
 
 	shards := make([]internerWithBytesIdShard, nextPowerOfTwo(shardCount))
 	for i := range shards {
-		shards[i] = newInternerWithBytesIdShard(controller, store)
+		shards[i] = newInternerWithBytesIdShard(controller, config.getShardStore(), config.getEvict(), config.getGenerationBytes(shardCount))
 	}
 
 	return InternerWithBytesId[C]{
 		indexMask:  uint64(shardCount - 1),
 		controller: controller,
-		store:      store,
 		shards:     shards,
 	}
 }
@@ -67,13 +65,79 @@ func (i *InternerWithBytesId[C]) Get(converter C) string {
 	return i.shards[idx].get(hash, bytes)
 }
 
+// Returns the interned RefString for converter, interning it if possible.
+//
+// If the string can't be interned, because it is too long, there is no space
+// left to intern it, or its hash collides with another interned string, then
+// a nil RefString and false are returned. The empty string is never interned.
+//
+// If eviction is enabled the returned RefString will be freed when it is
+// evicted, using it after that point will panic. The strings returned by Get
+// are never freed.
+func (i *InternerWithBytesId[C]) GetRef(converter C) (offheap.RefString, bool) {
+	bytes := converter.Identity()
+	hash := xxhash.Sum64(bytes)
+	idx := i.getIndex(hash)
+	return i.shards[idx].getRef(hash, bytes)
+}
+
+// Writes every interned string to w as a dictionary, which can be loaded into
+// another interner using LoadDictionary.
+func (i *InternerWithBytesId[C]) WriteDictionary(w io.Writer) error {
+	d, err := newDictionaryWriter(w, 0)
+	if err != nil {
+		return err
+	}
+	for idx := range i.shards {
+		if err := i.shards[idx].writeDictionary(d); err != nil {
+			return err
+		}
+	}
+	return d.flush()
+}
+
+// Interns every string in the dictionary read from r, which was written by
+// WriteDictionary. Strings which are already interned, or which can't be
+// interned because of MaxLen or MaxBytes, are skipped.
+func (i *InternerWithBytesId[C]) LoadDictionary(r io.Reader) error {
+	d, err := newDictionaryReader(r, 0)
+	if err != nil {
+		return err
+	}
+	for {
+		_, bytes, err := d.read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if len(bytes) == 0 {
+			// The empty string is never interned
+			continue
+		}
+		hash := xxhash.Sum64(bytes)
+		idx := i.getIndex(hash)
+		i.shards[idx].load(hash, bytes)
+	}
+}
+
 // Retrieves the summarised stats for interned strings
 func (i *InternerWithBytesId[C]) GetStats() StatsSummary {
-	intShards := make([]Stats, 0, len(i.shards))
+	shardStats := make([]Stats, 0, len(i.shards))
+	shardContents := make([]ShardContents, 0, len(i.shards))
 	for idx := range i.shards {
-		intShards = append(intShards, i.shards[idx].getStats())
+		stats, contents := i.shards[idx].getStats()
+		shardStats = append(shardStats, stats)
+		shardContents = append(shardContents, contents)
 	}
-	return MakeSummary(intShards, i.controller.getUsedBytes())
+	return makeSummary(shardStats, shardContents, i.controller)
+}
+
+// Returns a single line human-readable summary of the stats, suitable for
+// periodic logging.
+func (i *InternerWithBytesId[C]) StatsString() string {
+	return i.GetStats().String()
 }
 
 func (i *InternerWithBytesId[C]) getIndex(hash uint64) uint64 {
@@ -85,16 +149,16 @@ type internerWithBytesIdShard struct {
 	store      *offheap.Store
 	//
 	lock     sync.Mutex
-	interned map[uint64]offheap.RefString
+	interned internedStrings[uint64]
 	stats    Stats
 }
 
-func newInternerWithBytesIdShard(controller *internController, store *offheap.Store) internerWithBytesIdShard {
+func newInternerWithBytesIdShard(controller *internController, store *offheap.Store, evict bool, generationBytes int) internerWithBytesIdShard {
 	return internerWithBytesIdShard{
 		controller: controller,
 		store:      store,
 		//
-		interned: make(map[uint64]offheap.RefString),
+		interned: newInternedStrings[uint64](controller, store, evict, generationBytes),
 	}
 }
 
@@ -102,53 +166,89 @@ func (i *internerWithBytesIdShard) get(hash uint64, bytes []byte) string {
 	i.lock.Lock()
 	defer i.lock.Unlock()
 
+	if interned, ok := i.intern(hash, bytes); ok {
+		return interned
+	}
+	if len(bytes) == 0 {
+		return ""
+	}
+	// Can't intern this string. Return string copy
+	return string(bytes)
+}
+
+func (i *internerWithBytesIdShard) getRef(hash uint64, bytes []byte) (offheap.RefString, bool) {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	if _, ok := i.intern(hash, bytes); !ok {
+		return offheap.RefString{}, false
+	}
+	return i.interned.ref(hash)
+}
+
+// Returns the interned string for bytes, interning it if possible. If bytes
+// can't be interned false is returned. Must be called while holding the
+// shard's lock.
+func (i *internerWithBytesIdShard) intern(hash uint64, bytes []byte) (string, bool) {
 	if len(bytes) == 0 {
 		// We hardcode the empty string case here
 		i.stats.Returned++
-		return ""
+		return "", false
 	}
 
 	unsafeStr := unsafe.String(&bytes[0], len(bytes))
-	if refString, ok := i.interned[hash]; ok {
-		internedStr := refString.Value()
+	if interned, ok := i.interned.lookup(hash); ok {
 		// Because two different strings _might_ have the same hash we
 		// test that the interned string and the submitted string are
 		// equal.
-		if internedStr == unsafeStr {
+		if interned == unsafeStr {
 			// Return the interned version of the string
 			i.stats.Returned++
-			return internedStr
+			return interned, true
 		}
-		// Hash collision, can't intern this string.  Return string
-		// copy
+		// Hash collision, can't intern this string.
 		i.stats.HashCollision++
-		return string(bytes)
+		return "", false
 	}
 
 	if !i.controller.canInternMaxLen(unsafeStr) {
-		// Too long, can't intern this string. Return string copy
+		// Too long, can't intern this string.
 		i.stats.MaxLenExceeded++
-		return string(bytes)
+		return "", false
 	}
 
-	if !i.controller.canInternUsedBytes(unsafeStr) {
-		// Too many bytes interned, can't intern this string. Return
-		// string copy
+	if !i.interned.reserve(unsafeStr, &i.stats) {
+		// Too many bytes interned, can't intern this string.
 		i.stats.UsedBytesExceeded++
-		return string(bytes)
+		return "", false
 	}
 
 	// intern string and then return interned version
-	refString := offheap.AllocStringFromBytes(i.store, bytes)
-	i.interned[hash] = refString
+	interned := i.interned.add(hash, unsafeStr)
 
 	i.stats.Interned++
-	return refString.Value()
+	return interned, true
+}
+
+func (i *internerWithBytesIdShard) writeDictionary(d *dictionaryWriter) error {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	return i.interned.each(func(_ uint64, str string) error {
+		return d.write(nil, str)
+	})
+}
+
+func (i *internerWithBytesIdShard) load(hash uint64, bytes []byte) {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	i.interned.load(hash, bytes, &i.stats)
 }
 
-func (i *internerWithBytesIdShard) getStats() Stats {
+func (i *internerWithBytesIdShard) getStats() (Stats, ShardContents) {
 	i.lock.Lock()
 	defer i.lock.Unlock()
 
-	return i.stats
+	return i.stats, i.interned.contents()
 }
@@ -0,0 +1,283 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+package internbase
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"sync"
+	"unsafe"
+
+	xxhash "github.com/cespare/xxhash/v2"
+	"github.com/fmstephe/memorymanager/offheap"
+)
+
+// A InternerWithComparableId is the type which manages the interning of
+// strings for values of any comparable type K. Each value is its own
+// identity, and is converted to a string by a user provided function.
+//
+// Values are spread across shards by hashing their in-memory representation.
+// Values which are equal, but have different in-memory representations, may
+// be interned once in each shard they are assigned to. Examples include
+// types containing strings, which are equal when their contents are equal
+// regardless of where those contents are stored, and float values 0 and -0.
+// The correct string is always returned in these cases, but the string may be
+// interned more than once.
+//
+// Values are used as keys in Go maps, so types containing pointers will
+// place some load on the garbage collector.
+type InternerWithComparableId[K comparable] struct {
+	indexMask  uint64
+	controller *internController
+	toString   func(K) string
+	shards     []internerWithComparableIdShard[K]
+}
+
+// Construct a new InternerWithComparableId with the provided config, using
+// toString to convert values to strings.
+func NewInternerWithComparableId[K comparable](config Config, toString func(K) string) InternerWithComparableId[K] {
+	controller := newController(config.getMaxLen(), config.getMaxBytes())
+	shardCount := config.getShards()
+
+	shards := make([]internerWithComparableIdShard[K], shardCount)
+	for i := range shards {
+		shards[i] = newInternerWithComparableIdShard[K](controller, config.getShardStore(), config.getEvict(), config.getGenerationBytes(shardCount))
+	}
+
+	return InternerWithComparableId[K]{
+		indexMask:  uint64(shardCount - 1),
+		controller: controller,
+		toString:   toString,
+		shards:     shards,
+	}
+}
+
+// Returns the string representation of value.
+//
+// The string value may be retrieved from an interning cache or stored in the
+// cache.  Regardless of whether the string is or was interned, the correct
+// string value is returned.
+func (i *InternerWithComparableId[K]) Get(value K) string {
+	idx := i.getIndex(value)
+	return i.shards[idx].get(value, i.toString)
+}
+
+// Returns the interned RefString for value, interning it if possible.
+//
+// If the string can't be interned, because it is too long or there is no
+// space left to intern it, then a nil RefString and false are returned.
+//
+// If eviction is enabled the returned RefString will be freed when it is
+// evicted, using it after that point will panic. The strings returned by Get
+// are never freed.
+func (i *InternerWithComparableId[K]) GetRef(value K) (offheap.RefString, bool) {
+	idx := i.getIndex(value)
+	return i.shards[idx].getRef(value, i.toString)
+}
+
+// Writes every interned string, along with the value it was interned for, to
+// w as a dictionary, which can be loaded into another interner using
+// LoadDictionary.
+//
+// Values are written using their in-memory representation, so an error is
+// returned if K contains pointers, including strings, slices and maps.
+func (i *InternerWithComparableId[K]) WriteDictionary(w io.Writer) error {
+	if err := i.dictionaryErr(); err != nil {
+		return err
+	}
+
+	d, err := newDictionaryWriter(w, i.keySize())
+	if err != nil {
+		return err
+	}
+	for idx := range i.shards {
+		if err := i.shards[idx].writeDictionary(d); err != nil {
+			return err
+		}
+	}
+	return d.flush()
+}
+
+// Interns every string in the dictionary read from r, which was written by
+// WriteDictionary. Strings which are already interned, or which can't be
+// interned because of MaxLen or MaxBytes, are skipped.
+//
+// The strings are interned without calling toString, so the dictionary must
+// have been written by an interner for the same type K, with an equivalent
+// toString function.
+func (i *InternerWithComparableId[K]) LoadDictionary(r io.Reader) error {
+	if err := i.dictionaryErr(); err != nil {
+		return err
+	}
+
+	d, err := newDictionaryReader(r, i.keySize())
+	if err != nil {
+		return err
+	}
+	for {
+		key, str, err := d.read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		var value K
+		copy(unsafe.Slice((*byte)(unsafe.Pointer(&value)), unsafe.Sizeof(value)), key)
+		idx := i.getIndex(value)
+		i.shards[idx].load(value, str)
+	}
+}
+
+// Retrieves the summarised stats for interned strings
+func (i *InternerWithComparableId[K]) GetStats() StatsSummary {
+	shardStats := make([]Stats, 0, len(i.shards))
+	shardContents := make([]ShardContents, 0, len(i.shards))
+	for idx := range i.shards {
+		stats, contents := i.shards[idx].getStats()
+		shardStats = append(shardStats, stats)
+		shardContents = append(shardContents, contents)
+	}
+	return makeSummary(shardStats, shardContents, i.controller)
+}
+
+// Returns a single line human-readable summary of the stats, suitable for
+// periodic logging.
+func (i *InternerWithComparableId[K]) StatsString() string {
+	return i.GetStats().String()
+}
+
+func (i *InternerWithComparableId[K]) getIndex(value K) uint64 {
+	if i.indexMask == 0 {
+		return 0
+	}
+	bytes := unsafe.Slice((*byte)(unsafe.Pointer(&value)), unsafe.Sizeof(value))
+	return i.indexMask & xxhash.Sum64(bytes)
+}
+
+func (i *InternerWithComparableId[K]) keySize() int {
+	var value K
+	return int(unsafe.Sizeof(value))
+}
+
+// Returns an error if values of type K can't be written to a dictionary
+func (i *InternerWithComparableId[K]) dictionaryErr() error {
+	var value K
+	valueType := reflect.TypeOf(&value).Elem()
+	if containsPointers(valueType) {
+		return fmt.Errorf("cannot use dictionary with type %v containing pointers", valueType)
+	}
+	return nil
+}
+
+// Indicates whether values of valueType contain any pointers.
+func containsPointers(valueType reflect.Type) bool {
+	switch valueType.Kind() {
+	case reflect.Array:
+		return valueType.Len() > 0 && containsPointers(valueType.Elem())
+	case reflect.Struct:
+		for idx := range valueType.NumField() {
+			if containsPointers(valueType.Field(idx).Type) {
+				return true
+			}
+		}
+		return false
+	case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64, reflect.Complex64, reflect.Complex128:
+		return false
+	default:
+		return true
+	}
+}
+
+type internerWithComparableIdShard[K comparable] struct {
+	controller *internController
+	store      *offheap.Store
+	//
+	lock     sync.Mutex
+	interned internedStrings[K]
+	stats    Stats
+}
+
+func newInternerWithComparableIdShard[K comparable](controller *internController, store *offheap.Store, evict bool, generationBytes int) internerWithComparableIdShard[K] {
+	return internerWithComparableIdShard[K]{
+		controller: controller,
+		store:      store,
+		//
+		interned: newInternedStrings[K](controller, store, evict, generationBytes),
+	}
+}
+
+func (i *internerWithComparableIdShard[K]) get(value K, toString func(K) string) string {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	str, _ := i.intern(value, toString)
+	return str
+}
+
+func (i *internerWithComparableIdShard[K]) getRef(value K, toString func(K) string) (offheap.RefString, bool) {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	if _, ok := i.intern(value, toString); !ok {
+		return offheap.RefString{}, false
+	}
+	return i.interned.ref(value)
+}
+
+// Returns the interned string, interning it if possible. If the string can't
+// be interned the uninterned string and false are returned. Must be called
+// while holding the shard's lock.
+func (i *internerWithComparableIdShard[K]) intern(value K, toString func(K) string) (string, bool) {
+	if interned, ok := i.interned.lookup(value); ok {
+		i.stats.Returned++
+		return interned, true
+	}
+
+	str := toString(value)
+
+	if !i.controller.canInternMaxLen(str) {
+		i.stats.MaxLenExceeded++
+		return str, false
+	}
+
+	if !i.interned.reserve(str, &i.stats) {
+		i.stats.UsedBytesExceeded++
+		return str, false
+	}
+
+	// intern string and then return interned version
+	interned := i.interned.add(value, str)
+
+	i.stats.Interned++
+	return interned, true
+}
+
+func (i *internerWithComparableIdShard[K]) writeDictionary(d *dictionaryWriter) error {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	return i.interned.each(func(value K, str string) error {
+		key := unsafe.Slice((*byte)(unsafe.Pointer(&value)), unsafe.Sizeof(value))
+		return d.write(key, str)
+	})
+}
+
+func (i *internerWithComparableIdShard[K]) load(value K, str []byte) {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	i.interned.load(value, str, &i.stats)
+}
+
+func (i *internerWithComparableIdShard[K]) getStats() (Stats, ShardContents) {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	return i.stats, i.interned.contents()
+}
@@ -5,7 +5,10 @@
 package internbase
 
 import (
+	"encoding/binary"
+	"io"
 	"sync"
+	"unsafe"
 
 	"github.com/fmstephe/memorymanager/offheap"
 )
@@ -15,34 +18,36 @@ import (
 //
 // A good example of this is an actual uint64 value. Another example would be a
 // time.Time value which is identified by its UnixNanos() value.
+//
+// The interned string is looked up using only the identity. The value is only
+// formatted, by appending its string to buf, when it isn't already interned.
+// Formatting into a buffer owned by the interner means that a newly interned
+// value is formatted exactly once, and never allocated as a Go string.
 type ConverterWithUint64Id interface {
 	Identity() uint64
-	String() string
+	Append(buf []byte) []byte
 }
 
 // A InternerWithUint64Id is the type which manages the interning of strings.
 type InternerWithUint64Id[C ConverterWithUint64Id] struct {
 	indexMask  uint64
 	controller *internController
-	store      *offheap.Store
 	shards     []internerWithUint64IdShard[C]
 }
 
 // Construct a new InternerWithUint64Id with the provided config.
 func NewInternerWithUint64Id[C ConverterWithUint64Id](config Config) InternerWithUint64Id[C] {
 	controller := newController(config.getMaxLen(), config.getMaxBytes())
-	store := config.getStore()
 	shardCount := config.getShards()
 
 	shards := make([]internerWithUint64IdShard[C], shardCount)
 	for i := range shards {
-		shards[i] = newInternerWithUint64IdShard[C](controller, store)
+		shards[i] = newInternerWithUint64IdShard[C](controller, config.getShardStore(), config.getEvict(), config.getGenerationBytes(shardCount))
 	}
 
 	return InternerWithUint64Id[C]{
 		indexMask:  uint64(shardCount - 1),
 		controller: controller,
-		store:      store,
 		shards:     shards,
 	}
 }
@@ -57,13 +62,111 @@ func (i *InternerWithUint64Id[C]) Get(converter C) string {
 	return i.shards[idx].get(converter)
 }
 
+// Returns the interned RefString for converter, interning it if possible.
+//
+// If the string can't be interned, because it is too long or there is no
+// space left to intern it, then a nil RefString and false are returned.
+//
+// If eviction is enabled the returned RefString will be freed when it is
+// evicted, using it after that point will panic. The strings returned by Get
+// are never freed.
+func (i *InternerWithUint64Id[C]) GetRef(converter C) (offheap.RefString, bool) {
+	idx := i.getIndex(converter.Identity())
+	return i.shards[idx].getRef(converter)
+}
+
+// Returns the string interned for identity, if there is one. Nothing is
+// interned by Lookup, it is the first half of GetOrInsert.
+//
+// If eviction is enabled the returned RefString will be freed when it is
+// evicted, using it after that point will panic. The strings returned by Get
+// are never freed.
+func (i *InternerWithUint64Id[C]) Lookup(identity uint64) (offheap.RefString, bool) {
+	idx := i.getIndex(identity)
+	return i.shards[idx].lookup(identity)
+}
+
+// Returns the string interned for identity. If identity isn't interned yet,
+// format is called to append its string to buf, and the string is interned if
+// possible.
+//
+// This allows custom interners to be built without writing a converter type.
+// Like Get, format is only called when identity is not already interned, so
+// an expensive formatting function, e.g. time.Time.AppendFormat, is skipped
+// whenever the string is found. Every value with the same identity must be
+// formatted to the same string.
+//
+// format is called while the shard's lock is held, so it must not use this
+// interner.
+func (i *InternerWithUint64Id[C]) GetOrInsert(identity uint64, format func(buf []byte) []byte) string {
+	idx := i.getIndex(identity)
+	return i.shards[idx].getOrInsert(identity, format)
+}
+
+// Returns the interned RefString for identity, interning it if possible,
+// see GetOrInsert and GetRef.
+func (i *InternerWithUint64Id[C]) GetRefOrInsert(identity uint64, format func(buf []byte) []byte) (offheap.RefString, bool) {
+	idx := i.getIndex(identity)
+	return i.shards[idx].getRefOrInsert(identity, format)
+}
+
+// Writes every interned string, along with its identity, to w as a
+// dictionary, which can be loaded into another interner using LoadDictionary.
+func (i *InternerWithUint64Id[C]) WriteDictionary(w io.Writer) error {
+	d, err := newDictionaryWriter(w, 8)
+	if err != nil {
+		return err
+	}
+	for idx := range i.shards {
+		if err := i.shards[idx].writeDictionary(d); err != nil {
+			return err
+		}
+	}
+	return d.flush()
+}
+
+// Interns every string in the dictionary read from r, which was written by
+// WriteDictionary. Strings which are already interned, or which can't be
+// interned because of MaxLen or MaxBytes, are skipped.
+//
+// The strings are interned without being converted again, so the dictionary
+// must have been written by an interner which converts values to strings in
+// the same way, e.g. an int64 interner using the same base.
+func (i *InternerWithUint64Id[C]) LoadDictionary(r io.Reader) error {
+	d, err := newDictionaryReader(r, 8)
+	if err != nil {
+		return err
+	}
+	for {
+		key, str, err := d.read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		identity := binary.LittleEndian.Uint64(key)
+		idx := i.getIndex(identity)
+		i.shards[idx].load(identity, str)
+	}
+}
+
 // Retrieves the summarised stats for interned int strings
 func (i *InternerWithUint64Id[C]) GetStats() StatsSummary {
-	intShards := make([]Stats, 0, len(i.shards))
+	shardStats := make([]Stats, 0, len(i.shards))
+	shardContents := make([]ShardContents, 0, len(i.shards))
 	for idx := range i.shards {
-		intShards = append(intShards, i.shards[idx].getStats())
+		stats, contents := i.shards[idx].getStats()
+		shardStats = append(shardStats, stats)
+		shardContents = append(shardContents, contents)
 	}
-	return MakeSummary(intShards, i.controller.getUsedBytes())
+	return makeSummary(shardStats, shardContents, i.controller)
+}
+
+// Returns a single line human-readable summary of the stats, suitable for
+// periodic logging.
+func (i *InternerWithUint64Id[C]) StatsString() string {
+	return i.GetStats().String()
 }
 
 func (i *InternerWithUint64Id[C]) getIndex(hash uint64) uint64 {
@@ -75,16 +178,18 @@ type internerWithUint64IdShard[C ConverterWithUint64Id] struct {
 	store      *offheap.Store
 	//
 	lock     sync.Mutex
-	interned map[uint64]offheap.RefString
+	interned internedStrings[uint64]
 	stats    Stats
+	// Values are formatted into buf before being interned
+	buf []byte
 }
 
-func newInternerWithUint64IdShard[C ConverterWithUint64Id](controller *internController, store *offheap.Store) internerWithUint64IdShard[C] {
+func newInternerWithUint64IdShard[C ConverterWithUint64Id](controller *internController, store *offheap.Store, evict bool, generationBytes int) internerWithUint64IdShard[C] {
 	return internerWithUint64IdShard[C]{
 		controller: controller,
 		store:      store,
 		//
-		interned: make(map[uint64]offheap.RefString),
+		interned: newInternedStrings[uint64](controller, store, evict, generationBytes),
 	}
 }
 
@@ -92,37 +197,120 @@ func (i *internerWithUint64IdShard[C]) get(converter C) string {
 	i.lock.Lock()
 	defer i.lock.Unlock()
 
+	str, _ := i.intern(converter)
+	return str
+}
+
+func (i *internerWithUint64IdShard[C]) getRef(converter C) (offheap.RefString, bool) {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	if _, ok := i.intern(converter); !ok {
+		return offheap.RefString{}, false
+	}
+	return i.interned.ref(converter.Identity())
+}
+
+func (i *internerWithUint64IdShard[C]) lookup(identity uint64) (offheap.RefString, bool) {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	if _, ok := i.interned.lookup(identity); !ok {
+		return offheap.RefString{}, false
+	}
+	i.stats.Returned++
+	return i.interned.ref(identity)
+}
+
+func (i *internerWithUint64IdShard[C]) getOrInsert(identity uint64, format func(buf []byte) []byte) string {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	str, _ := i.internWith(identity, format)
+	return str
+}
+
+func (i *internerWithUint64IdShard[C]) getRefOrInsert(identity uint64, format func(buf []byte) []byte) (offheap.RefString, bool) {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	if _, ok := i.internWith(identity, format); !ok {
+		return offheap.RefString{}, false
+	}
+	return i.interned.ref(identity)
+}
+
+// Returns the interned string, interning it if possible. If the string can't
+// be interned a copy of the string and false are returned. Must be called
+// while holding the shard's lock.
+func (i *internerWithUint64IdShard[C]) intern(converter C) (string, bool) {
 	identity := converter.Identity()
 
-	if refString, ok := i.interned[identity]; ok {
+	if interned, ok := i.interned.lookup(identity); ok {
 		i.stats.Returned++
-		return refString.Value()
+		return interned, true
 	}
 
-	str := converter.String()
+	i.buf = converter.Append(i.buf[:0])
+	return i.insert(identity)
+}
 
-	if !i.controller.canInternMaxLen(str) {
+// Like intern, but the string is formatted by format rather than a
+// converter. Must be called while holding the shard's lock.
+func (i *internerWithUint64IdShard[C]) internWith(identity uint64, format func(buf []byte) []byte) (string, bool) {
+	if interned, ok := i.interned.lookup(identity); ok {
+		i.stats.Returned++
+		return interned, true
+	}
+
+	i.buf = format(i.buf[:0])
+	return i.insert(identity)
+}
+
+// Interns the string which has just been formatted into buf, if possible. If
+// the string can't be interned a copy of the string and false are returned.
+// Must be called while holding the shard's lock.
+func (i *internerWithUint64IdShard[C]) insert(identity uint64) (string, bool) {
+	unsafeStr := unsafe.String(unsafe.SliceData(i.buf), len(i.buf))
+
+	if !i.controller.canInternMaxLen(unsafeStr) {
 		i.stats.MaxLenExceeded++
-		return str
+		return string(i.buf), false
 	}
 
-	if !i.controller.canInternUsedBytes(str) {
+	if !i.interned.reserve(unsafeStr, &i.stats) {
 		i.stats.UsedBytesExceeded++
-		return str
+		return string(i.buf), false
 	}
 
 	// intern int-string and then return interned version
-	refString := offheap.AllocStringFromString(i.store, str)
-	i.interned[identity] = refString
+	interned := i.interned.add(identity, unsafeStr)
 
-	interned := refString.Value()
 	i.stats.Interned++
-	return interned
+	return interned, true
+}
+
+func (i *internerWithUint64IdShard[C]) writeDictionary(d *dictionaryWriter) error {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	key := make([]byte, 8)
+	return i.interned.each(func(identity uint64, str string) error {
+		binary.LittleEndian.PutUint64(key, identity)
+		return d.write(key, str)
+	})
+}
+
+func (i *internerWithUint64IdShard[C]) load(identity uint64, str []byte) {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	i.interned.load(identity, str, &i.stats)
 }
 
-func (i *internerWithUint64IdShard[C]) getStats() Stats {
+func (i *internerWithUint64IdShard[C]) getStats() (Stats, ShardContents) {
 	i.lock.Lock()
 	defer i.lock.Unlock()
 
-	return i.stats
+	return i.stats, i.interned.contents()
 }
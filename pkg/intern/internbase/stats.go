@@ -4,17 +4,83 @@
 
 package internbase
 
+import (
+	"fmt"
+	"strconv"
+)
+
 // A summary of the stats for a specific type of interned converter.
 //
 // UsedBytes stat is global across all converters.
 //
+// MaxBytes is the configured limit on UsedBytes, <= 0 means unlimited.
+//
+// Strings is the number of interned strings currently held across all shards.
+//
 // Total is sum across all shards of the fields in Stats.
 //
 // Shards holds the individual shard Stats.
+//
+// ShardContents holds the strings and bytes held by each shard, in the same
+// order as Shards.
 type StatsSummary struct {
+	UsedBytes     int
+	MaxBytes      int
+	Strings       int
+	Total         Stats
+	Shards        []Stats
+	ShardContents []ShardContents
+}
+
+// The interned strings currently held by a single shard.
+//
+// Strings is the number of distinct interned strings held by the shard.
+//
+// UsedBytes is the number of bytes used by the shard's interned strings. With
+// eviction a string which is promoted into the current generation is counted
+// in each generation, until the older generation is evicted.
+type ShardContents struct {
+	Strings   int
 	UsedBytes int
-	Total     Stats
-	Shards    []Stats
+}
+
+// Returns the fraction of all lookups which returned a previously interned
+// string. Returns 0 if there have been no lookups.
+func (s StatsSummary) HitRate() float64 {
+	return s.Total.HitRate()
+}
+
+// Returns the average length of the interned strings currently held. Returns
+// 0 if no strings are held.
+func (s StatsSummary) AverageLength() float64 {
+	if s.Strings == 0 {
+		return 0
+	}
+	return float64(s.UsedBytes) / float64(s.Strings)
+}
+
+// Returns UsedBytes as a fraction of MaxBytes. Returns 0 if MaxBytes is
+// unlimited.
+func (s StatsSummary) FillRatio() float64 {
+	if s.MaxBytes <= 0 {
+		return 0
+	}
+	return float64(s.UsedBytes) / float64(s.MaxBytes)
+}
+
+// Returns a single line human-readable summary, suitable for periodic
+// logging.
+func (s StatsSummary) String() string {
+	maxBytes := "unlimited"
+	if s.MaxBytes > 0 {
+		maxBytes = strconv.Itoa(s.MaxBytes)
+	}
+	return fmt.Sprintf(
+		"strings=%d used-bytes=%d max-bytes=%s fill=%.1f%% avg-len=%.1f hit-rate=%.1f%% returned=%d interned=%d max-len-exceeded=%d used-bytes-exceeded=%d hash-collision=%d evicted=%d rotations=%d shards=%d",
+		s.Strings, s.UsedBytes, maxBytes, s.FillRatio()*100, s.AverageLength(), s.HitRate()*100,
+		s.Total.Returned, s.Total.Interned, s.Total.MaxLenExceeded, s.Total.UsedBytesExceeded, s.Total.HashCollision, s.Total.Evicted, s.Total.Rotations,
+		len(s.Shards),
+	)
 }
 
 // The statistics capturing the runtime behaviour of the interner.
@@ -32,12 +98,36 @@ type StatsSummary struct {
 //
 // HashCollision indicates the number of strings not interned because of a hash
 // collision.
+//
+// Evicted indicates the number of interned strings which have been freed by
+// eviction.
+//
+// Rotations indicates the number of times a shard has started a new
+// generation of interned strings, evicting its oldest generation.
 type Stats struct {
 	Returned          int
 	Interned          int
 	MaxLenExceeded    int
 	UsedBytesExceeded int
 	HashCollision     int
+	Evicted           int
+	Rotations         int
+}
+
+// Returns the number of strings submitted to the interner, whether or not
+// they were interned.
+func (s Stats) Lookups() int {
+	return s.Returned + s.Interned + s.MaxLenExceeded + s.UsedBytesExceeded + s.HashCollision
+}
+
+// Returns the fraction of all lookups which returned a previously interned
+// string. Returns 0 if there have been no lookups.
+func (s Stats) HitRate() float64 {
+	lookups := s.Lookups()
+	if lookups == 0 {
+		return 0
+	}
+	return float64(s.Returned) / float64(lookups)
 }
 
 func MakeSummary(shards []Stats, usedBytes int) StatsSummary {
@@ -49,6 +139,8 @@ func MakeSummary(shards []Stats, usedBytes int) StatsSummary {
 		total.MaxLenExceeded += shards[i].MaxLenExceeded
 		total.UsedBytesExceeded += shards[i].UsedBytesExceeded
 		total.HashCollision += shards[i].HashCollision
+		total.Evicted += shards[i].Evicted
+		total.Rotations += shards[i].Rotations
 	}
 
 	return StatsSummary{
@@ -57,3 +149,15 @@ func MakeSummary(shards []Stats, usedBytes int) StatsSummary {
 		Shards:    shards,
 	}
 }
+
+// Returns a summary of shards, including the derived contents and limits
+// which aren't captured by MakeSummary.
+func makeSummary(shards []Stats, contents []ShardContents, controller *internController) StatsSummary {
+	summary := MakeSummary(shards, controller.getUsedBytes())
+	summary.MaxBytes = int(controller.maxBytes)
+	summary.ShardContents = contents
+	for i := range contents {
+		summary.Strings += contents[i].Strings
+	}
+	return summary
+}
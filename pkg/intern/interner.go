@@ -4,9 +4,31 @@
 
 package intern
 
-import "github.com/fmstephe/memorymanager/pkg/intern/internbase"
+import (
+	"io"
+
+	"github.com/fmstephe/memorymanager/offheap"
+	"github.com/fmstephe/memorymanager/pkg/intern/internbase"
+)
 
 type Interner[T any] interface {
+	// Returns the string representation of t, interned if possible.
 	Get(t T) string
+	// Returns the interned RefString for t, and true, if t's string
+	// representation can be interned. Otherwise a nil RefString and false
+	// are returned. This allows interned strings to be stored in offheap
+	// data structures.
+	GetRef(t T) (offheap.RefString, bool)
+	// Writes every interned string to w, so that they can be loaded into
+	// a new interner with LoadDictionary. This allows a process to persist
+	// its interned strings and re-load them on startup.
+	WriteDictionary(w io.Writer) error
+	// Interns every string in a dictionary written by WriteDictionary.
+	// The dictionary must have been written by an interner of the same
+	// type and configuration.
+	LoadDictionary(r io.Reader) error
 	GetStats() internbase.StatsSummary
+	// Returns a single line human-readable summary of GetStats(), suitable
+	// for periodic logging.
+	StatsString() string
 }
@@ -5,11 +5,13 @@
 package intern
 
 import (
+	"bytes"
 	"testing"
 	"unsafe"
 
 	"github.com/fmstephe/memorymanager/pkg/intern/internbase"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func DoTestGenericInterner_Interned[T any](t *testing.T, interner Interner[T], val T, strVal string) {
@@ -36,6 +38,17 @@ func DoTestGenericInterner_Interned[T any](t *testing.T, interner Interner[T], v
 	expectedStats = internbase.Stats{Interned: 1, Returned: 1}
 	stats = interner.GetStats()
 	assert.Equal(t, expectedStats, stats.Total)
+
+	// GetRef returns a RefString pointing to the same interned string
+	ref, ok := interner.GetRef(val)
+	assert.True(t, ok)
+	refVal := ref.Value()
+	assert.Equal(t, strVal, refVal)
+	assert.Same(t, unsafe.StringData(internedVal), unsafe.StringData(refVal))
+
+	expectedStats = internbase.Stats{Interned: 1, Returned: 2}
+	stats = interner.GetStats()
+	assert.Equal(t, expectedStats, stats.Total)
 }
 
 func DoTestGenericInterner_NotInternedMaxLen[T any](t *testing.T, interner Interner[T], val T, strVal string) {
@@ -62,6 +75,15 @@ func DoTestGenericInterner_NotInternedMaxLen[T any](t *testing.T, interner Inter
 	expectedStats = internbase.Stats{MaxLenExceeded: 2}
 	stats = interner.GetStats()
 	assert.Equal(t, expectedStats, stats.Total)
+
+	// GetRef can't return a RefString for a string which wasn't interned
+	ref, ok := interner.GetRef(val)
+	assert.False(t, ok)
+	assert.True(t, ref.IsNil())
+
+	expectedStats = internbase.Stats{MaxLenExceeded: 3}
+	stats = interner.GetStats()
+	assert.Equal(t, expectedStats, stats.Total)
 }
 
 func DoTestGenericInterner_NotInternedMaxBytes[T any](t *testing.T, interner Interner[T], val T, strVal string) {
@@ -88,6 +110,15 @@ func DoTestGenericInterner_NotInternedMaxBytes[T any](t *testing.T, interner Int
 	expectedStats = internbase.Stats{UsedBytesExceeded: 2}
 	stats = interner.GetStats()
 	assert.Equal(t, expectedStats, stats.Total)
+
+	// GetRef can't return a RefString for a string which wasn't interned
+	ref, ok := interner.GetRef(val)
+	assert.False(t, ok)
+	assert.True(t, ref.IsNil())
+
+	expectedStats = internbase.Stats{UsedBytesExceeded: 3}
+	stats = interner.GetStats()
+	assert.Equal(t, expectedStats, stats.Total)
 }
 
 /*
@@ -193,3 +224,37 @@ func DoTestGenericInterner_NoAllocations[T any](t *testing.T, interner Interner[
 	// allocate
 	assert.Equal(t, 0.0, avgAllocs)
 }
+
+// Assert that a dictionary written by one interner can be loaded into a new
+// interner, and that every value is then returned from the new interner
+// without being interned again
+func DoTestGenericInterner_Dictionary[T any](t *testing.T, newInterner func() Interner[T], vals []T, toString func(T) string) {
+	t.Helper()
+
+	interner := newInterner()
+	for _, val := range vals {
+		interner.Get(val)
+	}
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, interner.WriteDictionary(buf))
+
+	loaded := newInterner()
+	require.NoError(t, loaded.LoadDictionary(bytes.NewReader(buf.Bytes())))
+
+	expectedStats := internbase.Stats{Interned: len(vals)}
+	assert.Equal(t, expectedStats, loaded.GetStats().Total)
+	assert.Equal(t, interner.GetStats().UsedBytes, loaded.GetStats().UsedBytes)
+
+	for _, val := range vals {
+		assert.Equal(t, toString(val), loaded.Get(val))
+	}
+
+	// Every value was already interned by the dictionary
+	expectedStats = internbase.Stats{Interned: len(vals), Returned: len(vals)}
+	assert.Equal(t, expectedStats, loaded.GetStats().Total)
+
+	// Loading the same dictionary again doesn't intern anything new
+	require.NoError(t, loaded.LoadDictionary(bytes.NewReader(buf.Bytes())))
+	assert.Equal(t, expectedStats, loaded.GetStats().Total)
+}
@@ -5,8 +5,10 @@
 package intern
 
 import (
+	"io"
 	"unsafe"
 
+	"github.com/fmstephe/memorymanager/offheap"
 	"github.com/fmstephe/memorymanager/pkg/intern/internbase"
 )
 
@@ -24,10 +26,26 @@ func (i *stringInterner) Get(str string) string {
 	return i.interner.Get(newStringConverter(str))
 }
 
+func (i *stringInterner) GetRef(str string) (offheap.RefString, bool) {
+	return i.interner.GetRef(newStringConverter(str))
+}
+
+func (i *stringInterner) WriteDictionary(w io.Writer) error {
+	return i.interner.WriteDictionary(w)
+}
+
+func (i *stringInterner) LoadDictionary(r io.Reader) error {
+	return i.interner.LoadDictionary(r)
+}
+
 func (i *stringInterner) GetStats() internbase.StatsSummary {
 	return i.interner.GetStats()
 }
 
+func (i *stringInterner) StatsString() string {
+	return i.interner.StatsString()
+}
+
 var _ internbase.ConverterWithBytesId = stringConverter{}
 
 type stringConverter struct {
@@ -75,3 +75,28 @@ func BenchmarkStringInterner_AllInterned10K(b *testing.B) {
 		}
 	}
 }
+
+// Benchmark getting already interned values from many goroutines at once.
+func BenchmarkStringInterner_AllInterned10K_Parallel(b *testing.B) {
+	interner := NewStringInterner(internbase.Config{MaxLen: 0, MaxBytes: 0})
+
+	strings := make([]string, 10_000)
+	for i := range strings {
+		strings[i] = strconv.Itoa(i)
+	}
+
+	for _, stringVal := range strings {
+		interner.Get(stringVal)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			interner.Get(strings[i%len(strings)])
+			i++
+		}
+	})
+}
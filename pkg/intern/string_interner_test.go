@@ -5,11 +5,18 @@
 package intern
 
 import (
+	"bytes"
+	"fmt"
+	"io"
 	"strconv"
+	"sync"
 	"testing"
+	"unsafe"
 
+	"github.com/fmstephe/memorymanager/offheap"
 	"github.com/fmstephe/memorymanager/pkg/intern/internbase"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestStringInterner_Interned_EmptySlice(t *testing.T) {
@@ -233,3 +240,243 @@ func TestStringInterner_NoAllocations(t *testing.T) {
 
 	DoTestGenericInterner_NoAllocations(t, interner, strings)
 }
+
+// This test demonstrates that with eviction enabled an interner continues to
+// intern new strings after MaxBytes is reached, and that frequently used
+// strings survive eviction
+func TestStringInterner_Evict(t *testing.T) {
+	store := offheap.NewSized(1 << 8)
+	defer func() {
+		assert.NoError(t, store.Destroy())
+	}()
+
+	interner := NewStringInterner(internbase.Config{MaxLen: 64, MaxBytes: 100, Shards: 1, Evict: true, Store: store})
+
+	hot := "hot string"
+	for i := range 1000 {
+		// Each string is 10 bytes long
+		str := fmt.Sprintf("string%04d", i)
+		assert.Equal(t, str, interner.Get(str))
+		assert.Equal(t, hot, interner.Get(hot))
+	}
+
+	stats := interner.GetStats()
+	assert.Equal(t, 0, stats.Total.UsedBytesExceeded)
+	assert.Equal(t, 1000+1, stats.Total.Interned)
+	assert.Equal(t, 999, stats.Total.Returned)
+	assert.Greater(t, stats.Total.Rotations, 0)
+	assert.Greater(t, stats.Total.Evicted, 0)
+	assert.LessOrEqual(t, stats.UsedBytes, 100)
+
+	// With eviction strings returned by Get are held on the Go heap, so
+	// nothing has been allocated in the store
+	assert.Equal(t, 0, store.TotalStats().Live)
+
+	// The hot string was interned once, and then promoted across
+	// generations as needed
+	hotStr := interner.Get(hot)
+	assert.Equal(t, hot, hotStr)
+	assert.Same(t, unsafe.StringData(hotStr), unsafe.StringData(interner.Get(hot)))
+}
+
+// This test demonstrates that a string returned by an interner with eviction
+// enabled is unchanged after it has been evicted, and the bytes it used have
+// been reused by later strings
+func TestStringInterner_Evict_RetainedString(t *testing.T) {
+	store := offheap.NewSized(1 << 8)
+	defer func() {
+		assert.NoError(t, store.Destroy())
+	}()
+
+	interner := NewStringInterner(internbase.Config{MaxLen: 64, MaxBytes: 100, Shards: 1, Evict: true, Store: store})
+
+	retained := interner.Get("retained00")
+	retainedRef, ok := interner.GetRef("retained00")
+	require.True(t, ok)
+
+	// Intern enough strings to rotate the generations many times, both
+	// with Get and GetRef
+	for i := range 100 {
+		str := fmt.Sprintf("string%04d", i)
+		assert.Equal(t, str, interner.Get(str))
+		ref, ok := interner.GetRef(str)
+		require.True(t, ok)
+		assert.Equal(t, str, ref.Value())
+	}
+	require.GreaterOrEqual(t, interner.GetStats().Total.Rotations, 2)
+
+	// The retained string is unchanged
+	assert.Equal(t, "retained00", retained)
+
+	// The retained RefString has been freed, and the RefStrings of evicted
+	// strings don't accumulate in the store
+	assert.Panics(t, func() { retainedRef.Value() })
+	assert.LessOrEqual(t, store.TotalStats().Live, 100/10)
+}
+
+// This test demonstrates that without eviction MaxBytes is a hard limit
+func TestStringInterner_NoEvict(t *testing.T) {
+	interner := NewStringInterner(internbase.Config{MaxLen: 64, MaxBytes: 100, Shards: 1})
+
+	for i := range 1000 {
+		str := fmt.Sprintf("string%04d", i)
+		assert.Equal(t, str, interner.Get(str))
+	}
+
+	stats := interner.GetStats()
+	assert.Equal(t, 10, stats.Total.Interned)
+	assert.Equal(t, 990, stats.Total.UsedBytesExceeded)
+	assert.Equal(t, 0, stats.Total.Rotations)
+	assert.Equal(t, 0, stats.Total.Evicted)
+}
+
+// This test demonstrates that many goroutines can intern the same strings
+// concurrently, and that every goroutine receives the correct strings
+func TestStringInterner_Concurrent(t *testing.T) {
+	interner := NewStringInterner(internbase.Config{MaxLen: 64, MaxBytes: 1 << 20, Shards: 8})
+
+	strs := make([]string, 1000)
+	for i := range strs {
+		strs[i] = fmt.Sprintf("concurrent-%d", i)
+	}
+
+	const goroutines = 16
+	wg := sync.WaitGroup{}
+	wg.Add(goroutines)
+	for g := range goroutines {
+		go func() {
+			defer wg.Done()
+			for i := range strs {
+				str := strs[(i+g*37)%len(strs)]
+				assert.Equal(t, str, interner.Get(str))
+			}
+		}()
+	}
+	wg.Wait()
+
+	stats := interner.GetStats()
+	assert.Equal(t, len(strs), stats.Total.Interned)
+	assert.Equal(t, len(strs)*(goroutines-1), stats.Total.Returned)
+	assert.Len(t, stats.Shards, 8)
+}
+
+// Demonstrate that interned strings can be persisted and loaded into a new
+// interner
+func TestStringInterner_Dictionary(t *testing.T) {
+	strs := make([]string, 1000)
+	for i := range strs {
+		strs[i] = fmt.Sprintf("dictionary-%d", i)
+	}
+
+	newInterner := func() Interner[string] {
+		return NewStringInterner(internbase.Config{MaxLen: 64, MaxBytes: 1 << 20, Shards: 4})
+	}
+	DoTestGenericInterner_Dictionary(t, newInterner, strs, func(str string) string { return str })
+}
+
+// Demonstrate that invalid dictionaries are rejected
+func TestStringInterner_DictionaryInvalid(t *testing.T) {
+	interner := NewStringInterner(internbase.Config{MaxLen: 64, MaxBytes: 1 << 20})
+	interner.Get("a string")
+	interner.Get("another string")
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, interner.WriteDictionary(buf))
+	dictionary := buf.Bytes()
+
+	// Not a dictionary
+	assert.Error(t, NewStringInterner(internbase.Config{}).LoadDictionary(bytes.NewReader([]byte("not a dictionary"))))
+
+	// A truncated dictionary
+	err := NewStringInterner(internbase.Config{}).LoadDictionary(bytes.NewReader(dictionary[:len(dictionary)-1]))
+	assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+
+	// A dictionary written by an interner with a different identity
+	assert.Error(t, NewInt64Interner(internbase.Config{}, 10).LoadDictionary(bytes.NewReader(dictionary)))
+
+	// Strings which are too long for the loading interner are skipped
+	short := NewStringInterner(internbase.Config{MaxLen: 8, MaxBytes: 1 << 20})
+	require.NoError(t, short.LoadDictionary(bytes.NewReader(dictionary)))
+	assert.Equal(t, internbase.Stats{Interned: 1}, short.GetStats().Total)
+}
+
+// Demonstrate the derived statistics and per-shard breakdown of an interner
+func TestStringInterner_DerivedStats(t *testing.T) {
+	interner := NewStringInterner(internbase.Config{MaxLen: 8, MaxBytes: 1000, Shards: 4})
+
+	// Nothing has been looked up yet
+	stats := interner.GetStats()
+	assert.Equal(t, 0.0, stats.HitRate())
+	assert.Equal(t, 0.0, stats.AverageLength())
+	assert.Equal(t, 0.0, stats.FillRatio())
+
+	// 100 strings of length 5 are interned, then returned 3 times each
+	for range 4 {
+		for i := range 100 {
+			interner.Get(fmt.Sprintf("s-%03d", i))
+		}
+	}
+	// 100 strings are too long to be interned
+	for i := range 100 {
+		interner.Get(fmt.Sprintf("too-long-%d", i))
+	}
+
+	stats = interner.GetStats()
+	assert.Equal(t, 1000, stats.MaxBytes)
+	assert.Equal(t, 500, stats.UsedBytes)
+	assert.Equal(t, 100, stats.Strings)
+	assert.Equal(t, 500, stats.Total.Lookups())
+	assert.Equal(t, 0.6, stats.HitRate())
+	assert.Equal(t, 5.0, stats.AverageLength())
+	assert.Equal(t, 0.5, stats.FillRatio())
+
+	// The shard contents sum to the totals
+	require.Len(t, stats.ShardContents, 4)
+	shardStrings := 0
+	shardBytes := 0
+	for _, contents := range stats.ShardContents {
+		shardStrings += contents.Strings
+		shardBytes += contents.UsedBytes
+	}
+	assert.Equal(t, stats.Strings, shardStrings)
+	assert.Equal(t, stats.UsedBytes, shardBytes)
+
+	assert.Equal(t,
+		"strings=100 used-bytes=500 max-bytes=1000 fill=50.0% avg-len=5.0 hit-rate=60.0% returned=300 interned=100 max-len-exceeded=100 used-bytes-exceeded=0 hash-collision=0 evicted=0 rotations=0 shards=4",
+		interner.StatsString())
+
+	unlimited := NewStringInterner(internbase.Config{})
+	assert.Contains(t, unlimited.StatsString(), "max-bytes=unlimited")
+	assert.Equal(t, 0.0, unlimited.GetStats().FillRatio())
+}
+
+// Show that a string promoted from the previous generation into the current
+// generation is only counted once, although its bytes are counted in both
+// generations
+func TestStringInterner_DerivedStats_Promoted(t *testing.T) {
+	interner := NewStringInterner(internbase.Config{MaxLen: 64, MaxBytes: 100, Shards: 1, Evict: true})
+
+	// Each generation holds 5 strings of 10 bytes
+	for i := range 6 {
+		interner.Get(fmt.Sprintf("string%04d", i))
+	}
+	require.Equal(t, 1, interner.GetStats().Total.Rotations)
+
+	// Promote a string from the previous generation
+	interner.Get("string0000")
+
+	stats := interner.GetStats()
+	assert.Equal(t, 6, stats.Strings)
+	assert.Equal(t, 70, stats.UsedBytes)
+	assert.Equal(t, []internbase.ShardContents{{Strings: 6, UsedBytes: 70}}, stats.ShardContents)
+
+	// Once the generation holding the duplicate is evicted the counts match
+	// again
+	for i := range 4 {
+		interner.Get(fmt.Sprintf("string%04d", 100+i))
+	}
+	require.Equal(t, 2, interner.GetStats().Total.Rotations)
+	stats = interner.GetStats()
+	assert.Equal(t, 6, stats.Strings)
+	assert.Equal(t, 60, stats.UsedBytes)
+}
@@ -5,8 +5,10 @@
 package intern
 
 import (
+	"io"
 	"time"
 
+	"github.com/fmstephe/memorymanager/offheap"
 	"github.com/fmstephe/memorymanager/pkg/intern/internbase"
 )
 
@@ -26,10 +28,26 @@ func (i *timeInterner) Get(value time.Time) string {
 	return i.interner.Get(newTimeConverter(value, i.format))
 }
 
+func (i *timeInterner) GetRef(value time.Time) (offheap.RefString, bool) {
+	return i.interner.GetRef(newTimeConverter(value, i.format))
+}
+
+func (i *timeInterner) WriteDictionary(w io.Writer) error {
+	return i.interner.WriteDictionary(w)
+}
+
+func (i *timeInterner) LoadDictionary(r io.Reader) error {
+	return i.interner.LoadDictionary(r)
+}
+
 func (i *timeInterner) GetStats() internbase.StatsSummary {
 	return i.interner.GetStats()
 }
 
+func (i *timeInterner) StatsString() string {
+	return i.interner.StatsString()
+}
+
 var _ internbase.ConverterWithUint64Id = timeConverter{}
 
 // Converter for time.Time. The int64 UnixNano() value is used to uniquely
@@ -55,6 +73,6 @@ func (c timeConverter) Identity() uint64 {
 	return uint64(c.value.UnixNano())
 }
 
-func (c timeConverter) String() string {
-	return c.value.Format(c.format)
+func (c timeConverter) Append(buf []byte) []byte {
+	return c.value.AppendFormat(buf, c.format)
 }
@@ -0,0 +1,72 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+package intern
+
+import (
+	"io"
+	"strconv"
+
+	"github.com/fmstephe/memorymanager/offheap"
+	"github.com/fmstephe/memorymanager/pkg/intern/internbase"
+)
+
+type uint64Interner struct {
+	interner internbase.InternerWithUint64Id[uint64Converter]
+	base     int
+}
+
+func NewUint64Interner(config internbase.Config, base int) Interner[uint64] {
+	return &uint64Interner{
+		interner: internbase.NewInternerWithUint64Id[uint64Converter](config),
+		base:     base,
+	}
+}
+
+func (i *uint64Interner) Get(value uint64) string {
+	return i.interner.Get(newUint64Converter(value, i.base))
+}
+
+func (i *uint64Interner) GetRef(value uint64) (offheap.RefString, bool) {
+	return i.interner.GetRef(newUint64Converter(value, i.base))
+}
+
+func (i *uint64Interner) WriteDictionary(w io.Writer) error {
+	return i.interner.WriteDictionary(w)
+}
+
+func (i *uint64Interner) LoadDictionary(r io.Reader) error {
+	return i.interner.LoadDictionary(r)
+}
+
+func (i *uint64Interner) GetStats() internbase.StatsSummary {
+	return i.interner.GetStats()
+}
+
+func (i *uint64Interner) StatsString() string {
+	return i.interner.StatsString()
+}
+
+var _ internbase.ConverterWithUint64Id = uint64Converter{}
+
+// A converter for uint64 values. Here the identity is just the value itself.
+type uint64Converter struct {
+	value uint64
+	base  int
+}
+
+func newUint64Converter(value uint64, base int) uint64Converter {
+	return uint64Converter{
+		value: value,
+		base:  base,
+	}
+}
+
+func (c uint64Converter) Identity() uint64 {
+	return c.value
+}
+
+func (c uint64Converter) Append(buf []byte) []byte {
+	return strconv.AppendUint(buf, c.value, c.base)
+}
@@ -0,0 +1,50 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+package intern
+
+import (
+	"math"
+	"strconv"
+	"testing"
+
+	"github.com/fmstephe/memorymanager/pkg/intern/internbase"
+)
+
+func TestUint64Interner_Interned(t *testing.T) {
+	interner := NewUint64Interner(internbase.Config{MaxLen: 64, MaxBytes: 1024}, 10)
+	uintVal := uint64(math.MaxUint64)
+	internedUint := strconv.FormatUint(uintVal, 10)
+
+	DoTestGenericInterner_Interned(t, interner, uintVal, internedUint)
+}
+
+func TestUint64Interner_NotInternedMaxLen(t *testing.T) {
+	interner := NewUint64Interner(internbase.Config{MaxLen: 3, MaxBytes: 1024}, 10)
+	uintVal := uint64(12345)
+	internedUint := strconv.FormatUint(uintVal, 10)
+
+	DoTestGenericInterner_NotInternedMaxLen(t, interner, uintVal, internedUint)
+}
+
+func TestUint64Interner_NotInternedMaxBytes(t *testing.T) {
+	interner := NewUint64Interner(internbase.Config{MaxLen: 64, MaxBytes: 3}, 10)
+	uintVal := uint64(12345)
+	internedUint := strconv.FormatUint(uintVal, 10)
+
+	DoTestGenericInterner_NotInternedMaxBytes(t, interner, uintVal, internedUint)
+}
+
+// Assert that getting a string, where the value has already been interned,
+// does not allocate
+func TestUint64Interner_NoAllocations(t *testing.T) {
+	interner := NewUint64Interner(internbase.Config{MaxLen: 0, MaxBytes: 0}, 10)
+
+	uints := make([]uint64, 10_000)
+	for i := range uints {
+		uints[i] = math.MaxUint64 - uint64(i)
+	}
+
+	DoTestGenericInterner_NoAllocations(t, interner, uints)
+}
@@ -0,0 +1,114 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+package linkedlist
+
+import (
+	"github.com/fmstephe/memorymanager/offheap"
+)
+
+// An Iterator walks over the nodes of a list from head to tail. Unlike Survey
+// and Filter, an iterator allows inspection, mutation and removal of nodes to
+// be interleaved in a single pass over the list.
+//
+//	it := l.Iterator(store)
+//	for it.Next() {
+//		d := it.Value()
+//		if done(d) {
+//			it.Remove()
+//		}
+//	}
+//
+// Iteration can be stopped at any time simply by not calling Next again.
+//
+// Nodes pushed onto the list while iterating will not be visited. The list
+// must not be modified during iteration, except via the iterator's Remove
+// method or by pushing new nodes.
+type Iterator[O any] struct {
+	list  *List[O]
+	store *Store[O]
+
+	// The last node of the list when iteration started, iteration stops
+	// after this node is visited
+	last offheap.RefObject[node[O]]
+
+	// The node whose data is returned by Value
+	current offheap.RefObject[node[O]]
+	// The node following current, recorded before current can be removed
+	next offheap.RefObject[node[O]]
+
+	started bool
+	done    bool
+	removed bool
+}
+
+// Returns a new Iterator positioned before the first node of this list. Next
+// must be called before the first node can be accessed.
+func (l *List[O]) Iterator(store *Store[O]) Iterator[O] {
+	it := Iterator[O]{
+		list:  l,
+		store: store,
+	}
+
+	if l.IsEmpty() {
+		it.done = true
+		return it
+	}
+
+	head := l.getReference()
+	it.last = head.Value().prev
+	it.next = head
+	return it
+}
+
+// Advances the iterator to the next node in the list. Returns false when
+// there are no more nodes to visit.
+func (it *Iterator[O]) Next() bool {
+	if it.done {
+		return false
+	}
+
+	if it.started && it.current == it.last {
+		it.done = true
+		it.current = offheap.RefObject[node[O]]{}
+		return false
+	}
+
+	it.started = true
+	it.removed = false
+	it.current = it.next
+	it.next = it.current.Value().next
+	return true
+}
+
+// Returns a pointer to the embedded data of the current node. The data can be
+// mutated via this pointer.
+//
+// Value must only be called after Next has returned true, and must not be
+// called after the current node has been removed.
+func (it *Iterator[O]) Value() *O {
+	it.assertCurrent()
+	return it.current.Value().getData()
+}
+
+// Removes the current node from the list. The node is freed and its memory
+// returned to the store. Iteration continues from the following node on the
+// next call to Next.
+//
+// Remove must only be called after Next has returned true, and may only be
+// called once for each node.
+func (it *Iterator[O]) Remove() {
+	it.assertCurrent()
+	it.list.remove(it.store, it.current)
+	it.removed = true
+}
+
+func (it *Iterator[O]) assertCurrent() {
+	if !it.started || it.done {
+		panic("iterator is not positioned on a node, Next must return true first")
+	}
+	if it.removed {
+		panic("the iterator's current node has been removed")
+	}
+}
@@ -0,0 +1,104 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+package linkedlist
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Show that iterating over an empty list visits nothing
+func TestIterator_Empty(t *testing.T) {
+	store := New[TestListData]()
+	l := store.NewList()
+
+	it := l.Iterator(store)
+	assert.False(t, it.Next())
+	assert.False(t, it.Next())
+	assert.Panics(t, func() { it.Value() })
+	assert.Panics(t, func() { it.Remove() })
+}
+
+// Show that an iterator visits every node in order, and that nodes can be
+// mutated via Value
+func TestIterator_VisitAll(t *testing.T) {
+	store := New[TestListData]()
+	l := makeList(store, []int{1, 2, 3, 4, 5})
+
+	visited := []int{}
+	it := l.Iterator(store)
+	for it.Next() {
+		visited = append(visited, it.Value().intField)
+		it.Value().intField *= 10
+	}
+	assert.False(t, it.Next())
+
+	assert.Equal(t, []int{1, 2, 3, 4, 5}, visited)
+	assertContains(t, l, store, []int{10, 20, 30, 40, 50})
+}
+
+// Show that we can remove any combination of nodes while iterating
+func TestIterator_Remove(t *testing.T) {
+	values := []int{1, 2, 3, 4, 5}
+	// Every subset of values is represented by the bits in mask
+	for mask := range 1 << len(values) {
+		store := New[TestListData]()
+		l := makeList(store, values)
+
+		expected := []int{}
+		visited := []int{}
+		it := l.Iterator(store)
+		for i := 0; it.Next(); i++ {
+			visited = append(visited, it.Value().intField)
+			if mask&(1<<i) != 0 {
+				it.Remove()
+				assert.Panics(t, func() { it.Value() })
+				assert.Panics(t, func() { it.Remove() })
+			} else {
+				expected = append(expected, values[i])
+			}
+		}
+
+		assert.Equal(t, values, visited)
+		assertContains(t, l, store, expected)
+		assert.Equal(t, len(expected) == 0, l.IsEmpty())
+	}
+}
+
+// Show that we can stop iterating early
+func TestIterator_EarlyTermination(t *testing.T) {
+	store := New[TestListData]()
+	l := makeList(store, []int{1, 2, 3, 4, 5})
+
+	visited := []int{}
+	it := l.Iterator(store)
+	for it.Next() {
+		visited = append(visited, it.Value().intField)
+		if it.Value().intField == 3 {
+			break
+		}
+	}
+
+	assert.Equal(t, []int{1, 2, 3}, visited)
+	assertContains(t, l, store, []int{1, 2, 3, 4, 5})
+}
+
+// Show that nodes pushed while iterating are not visited
+func TestIterator_PushWhileIterating(t *testing.T) {
+	store := New[TestListData]()
+	l := makeList(store, []int{1, 2, 3})
+
+	visited := []int{}
+	it := l.Iterator(store)
+	for it.Next() {
+		visited = append(visited, it.Value().intField)
+		l.PushTail(store).intField = it.Value().intField * 10
+		l.PushHead(store).intField = it.Value().intField * 100
+	}
+
+	assert.Equal(t, []int{1, 2, 3}, visited)
+	assertContains(t, l, store, []int{300, 200, 100, 1, 2, 3, 10, 20, 30})
+}
@@ -63,11 +63,8 @@ func (l *List[O]) setReference(r offheap.RefObject[node[O]]) {
 // the embedded data is returned. The embedded data can then be mutated via
 // this pointer.
 func (l *List[O]) PushHead(store *Store[O]) *O {
-	newR := offheap.AllocObject[node[O]](store.nodeStore)
-	newNode := newR.Value()
-	l.pushTail(store, newR, newNode)
-	l.setReference(newR)
-	return newNode.getData()
+	r := l.PushHeadRef(store)
+	return r.Get(store)
 }
 
 // Pushes a single new node into the last position of an existing list. The
@@ -75,10 +72,29 @@ func (l *List[O]) PushHead(store *Store[O]) *O {
 // the embedded data is returned. The embedded data can then be mutated via
 // this pointer.
 func (l *List[O]) PushTail(store *Store[O]) *O {
+	r := l.PushTailRef(store)
+	return r.Get(store)
+}
+
+// Pushes a single new node into the first position of an existing list, like
+// PushHead. A NodeRef for the new node is returned, which can be retained to
+// access, move or remove the node later.
+func (l *List[O]) PushHeadRef(store *Store[O]) NodeRef[O] {
+	newR := offheap.AllocObject[node[O]](store.nodeStore)
+	newNode := newR.Value()
+	l.pushTail(store, newR, newNode)
+	l.setReference(newR)
+	return NodeRef[O]{ref: newR}
+}
+
+// Pushes a single new node into the last position of an existing list, like
+// PushTail. A NodeRef for the new node is returned, which can be retained to
+// access, move or remove the node later.
+func (l *List[O]) PushTailRef(store *Store[O]) NodeRef[O] {
 	newR := offheap.AllocObject[node[O]](store.nodeStore)
 	newNode := newR.Value()
 	l.pushTail(store, newR, newNode)
-	return newNode.getData()
+	return NodeRef[O]{ref: newR}
 }
 
 func (l *List[O]) pushTail(store *Store[O], newR offheap.RefObject[node[O]], newNode *node[O]) {
@@ -131,6 +147,35 @@ func (l *List[O]) RemoveTail(store *Store[O]) {
 	l.remove(store, origin.prev)
 }
 
+// Removes the first node in the list, returning a copy of its embedded data.
+// If the list is empty the zero value of O and false are returned.
+func (l *List[O]) PopHead(store *Store[O]) (O, bool) {
+	if l.IsEmpty() {
+		var zero O
+		return zero, false
+	}
+
+	ref := l.getReference()
+	data := *ref.Value().getData()
+	l.remove(store, ref)
+	return data, true
+}
+
+// Removes the last node in the list, returning a copy of its embedded data.
+// If the list is empty the zero value of O and false are returned.
+func (l *List[O]) PopTail(store *Store[O]) (O, bool) {
+	if l.IsEmpty() {
+		var zero O
+		return zero, false
+	}
+
+	head := l.getReference()
+	ref := head.Value().prev
+	data := *ref.Value().getData()
+	l.remove(store, ref)
+	return data, true
+}
+
 func (l *List[O]) remove(store *Store[O], r offheap.RefObject[node[O]]) {
 	n := r.Value()
 	if n.prev == r && n.next == r {
@@ -304,6 +304,45 @@ func TestAppend(t *testing.T) {
 	}
 }
 
+// Show that we can pop nodes from the head and tail of a list, receiving
+// their data, until the list is empty
+func TestPopHeadPopTail(t *testing.T) {
+	store := New[TestListData]()
+	l := makeList(store, []int{1, 2, 3, 4, 5})
+
+	data, ok := l.PopHead(store)
+	assert.True(t, ok)
+	assert.Equal(t, TestListData{intField: 1}, data)
+	assertContains(t, l, store, []int{2, 3, 4, 5})
+
+	data, ok = l.PopTail(store)
+	assert.True(t, ok)
+	assert.Equal(t, TestListData{intField: 5}, data)
+	assertContains(t, l, store, []int{2, 3, 4})
+
+	data, ok = l.PopTail(store)
+	assert.True(t, ok)
+	assert.Equal(t, TestListData{intField: 4}, data)
+
+	data, ok = l.PopHead(store)
+	assert.True(t, ok)
+	assert.Equal(t, TestListData{intField: 2}, data)
+
+	data, ok = l.PopHead(store)
+	assert.True(t, ok)
+	assert.Equal(t, TestListData{intField: 3}, data)
+	assert.True(t, l.IsEmpty())
+
+	// Popping from an empty list returns nothing
+	data, ok = l.PopHead(store)
+	assert.False(t, ok)
+	assert.Equal(t, TestListData{}, data)
+
+	data, ok = l.PopTail(store)
+	assert.False(t, ok)
+	assert.Equal(t, TestListData{}, data)
+}
+
 func makeList(store *Store[TestListData], datas []int) List[TestListData] {
 	l := store.NewList()
 
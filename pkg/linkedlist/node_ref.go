@@ -0,0 +1,89 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+package linkedlist
+
+import (
+	"github.com/fmstephe/memorymanager/offheap"
+)
+
+// A NodeRef is a stable reference to a single node in a list. Unlike the *O
+// returned by PushHead and PushTail, a NodeRef can be retained, and can be
+// stored in offheap memory because it contains no Go pointers. A NodeRef
+// allows a specific node to be accessed, moved or removed in O(1).
+//
+// A NodeRef must only be used with the list which contains its node. Once the
+// node is removed from its list, by any means, the NodeRef must never be used
+// again. A best effort has been made to panic if it is.
+type NodeRef[O any] struct {
+	ref offheap.RefObject[node[O]]
+}
+
+// Returns a pointer to the embedded data of the node. The data can be
+// mutated via this pointer.
+func (r *NodeRef[O]) Get(store *Store[O]) *O {
+	return r.ref.Value().getData()
+}
+
+// Returns true if this NodeRef does not refer to a node, false otherwise.
+func (r *NodeRef[O]) IsNil() bool {
+	return r.ref.IsNil()
+}
+
+// Returns a NodeRef for the first node in the list. If the list is empty a nil
+// NodeRef is returned.
+func (l *List[O]) HeadRef(store *Store[O]) NodeRef[O] {
+	return NodeRef[O]{ref: l.getReference()}
+}
+
+// Returns a NodeRef for the last node in the list. If the list is empty a nil
+// NodeRef is returned.
+func (l *List[O]) TailRef(store *Store[O]) NodeRef[O] {
+	if l.IsEmpty() {
+		return NodeRef[O]{}
+	}
+	head := l.getReference()
+	return NodeRef[O]{ref: head.Value().prev}
+}
+
+// Removes the node referenced by r from the list. The node is freed and its
+// memory returned to the store. After this method is called r must never be
+// used again.
+func (l *List[O]) Remove(store *Store[O], r NodeRef[O]) {
+	l.remove(store, r.ref)
+}
+
+// Moves the node referenced by r to the first position in the list. The
+// NodeRef remains valid.
+func (l *List[O]) MoveToHead(store *Store[O], r NodeRef[O]) {
+	if r.ref == l.getReference() {
+		return
+	}
+	l.MoveToTail(store, r)
+	// The list is circular, so the tail node is the node before head
+	l.setReference(r.ref)
+}
+
+// Moves the node referenced by r to the last position in the list. The
+// NodeRef remains valid.
+func (l *List[O]) MoveToTail(store *Store[O], r NodeRef[O]) {
+	head := l.getReference()
+	n := r.ref.Value()
+	if n.next == head {
+		// r is already the tail, this includes a list of one node
+		return
+	}
+	if r.ref == head {
+		// The list is circular, so making the next node the head makes
+		// the old head the tail
+		l.setReference(n.next)
+		return
+	}
+
+	// Unlink r, the list still contains head so it can't become empty
+	n.prev.Value().next = n.next
+	n.next.Value().prev = n.prev
+
+	l.pushTail(store, r.ref, n)
+}
@@ -0,0 +1,154 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+package linkedlist
+
+import (
+	"math/rand"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Returns the embedded data values of l, in order from head to tail
+func listOrder(l List[TestListData], store *Store[TestListData]) []int {
+	values := []int{}
+	l.Survey(store, func(d *TestListData) bool {
+		values = append(values, d.intField)
+		return true
+	})
+	return values
+}
+
+// Show that NodeRefs returned by push operations can be used to access the
+// pushed nodes, and that head and tail refs are nil for an empty list
+func TestNodeRef_PushGet(t *testing.T) {
+	store := New[TestListData]()
+	l := store.NewList()
+
+	head := l.HeadRef(store)
+	assert.True(t, head.IsNil())
+	tail := l.TailRef(store)
+	assert.True(t, tail.IsNil())
+
+	r1 := l.PushTailRef(store)
+	r1.Get(store).intField = 1
+	r2 := l.PushHeadRef(store)
+	r2.Get(store).intField = 2
+	r3 := l.PushTailRef(store)
+	r3.Get(store).intField = 3
+
+	assert.Equal(t, []int{2, 1, 3}, listOrder(l, store))
+	assert.Equal(t, r2, l.HeadRef(store))
+	assert.Equal(t, r3, l.TailRef(store))
+}
+
+// Show that NodeRefs can be removed from any position, and can't be used
+// after they are removed
+func TestNodeRef_Remove(t *testing.T) {
+	store := New[TestListData]()
+	l := store.NewList()
+
+	refs := []NodeRef[TestListData]{}
+	for i := range 5 {
+		r := l.PushTailRef(store)
+		r.Get(store).intField = i
+		refs = append(refs, r)
+	}
+
+	l.Remove(store, refs[2])
+	assert.Equal(t, []int{0, 1, 3, 4}, listOrder(l, store))
+	assert.Panics(t, func() { refs[2].Get(store) })
+
+	l.Remove(store, refs[0])
+	l.Remove(store, refs[4])
+	assert.Equal(t, []int{1, 3}, listOrder(l, store))
+
+	l.Remove(store, refs[1])
+	l.Remove(store, refs[3])
+	assert.True(t, l.IsEmpty())
+}
+
+// Show that NodeRefs can be moved to the head and tail of a list
+func TestNodeRef_Move(t *testing.T) {
+	store := New[TestListData]()
+	l := store.NewList()
+
+	refs := []NodeRef[TestListData]{}
+	for i := range 5 {
+		r := l.PushTailRef(store)
+		r.Get(store).intField = i
+		refs = append(refs, r)
+	}
+
+	l.MoveToHead(store, refs[2])
+	assert.Equal(t, []int{2, 0, 1, 3, 4}, listOrder(l, store))
+	l.MoveToHead(store, refs[2])
+	assert.Equal(t, []int{2, 0, 1, 3, 4}, listOrder(l, store))
+	l.MoveToHead(store, refs[4])
+	assert.Equal(t, []int{4, 2, 0, 1, 3}, listOrder(l, store))
+
+	l.MoveToTail(store, refs[0])
+	assert.Equal(t, []int{4, 2, 1, 3, 0}, listOrder(l, store))
+	l.MoveToTail(store, refs[0])
+	assert.Equal(t, []int{4, 2, 1, 3, 0}, listOrder(l, store))
+	l.MoveToTail(store, refs[4])
+	assert.Equal(t, []int{2, 1, 3, 0, 4}, listOrder(l, store))
+
+	// A list with a single node
+	single := store.NewList()
+	r := single.PushTailRef(store)
+	r.Get(store).intField = 7
+	single.MoveToHead(store, r)
+	single.MoveToTail(store, r)
+	assert.Equal(t, []int{7}, listOrder(single, store))
+}
+
+// Randomly push, move and remove nodes, checking the list's order against a
+// slice
+func TestNodeRef_Random(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	store := New[TestListData]()
+	l := store.NewList()
+
+	expected := []int{}
+	refs := map[int]NodeRef[TestListData]{}
+	next := 0
+
+	for range 10_000 {
+		if len(expected) == 0 || r.Intn(4) == 0 {
+			var ref NodeRef[TestListData]
+			if r.Intn(2) == 0 {
+				ref = l.PushHeadRef(store)
+				expected = slices.Insert(expected, 0, next)
+			} else {
+				ref = l.PushTailRef(store)
+				expected = append(expected, next)
+			}
+			ref.Get(store).intField = next
+			refs[next] = ref
+			next++
+			continue
+		}
+
+		idx := r.Intn(len(expected))
+		value := expected[idx]
+		ref := refs[value]
+		expected = slices.Delete(expected, idx, idx+1)
+		switch r.Intn(3) {
+		case 0:
+			l.MoveToHead(store, ref)
+			expected = slices.Insert(expected, 0, value)
+		case 1:
+			l.MoveToTail(store, ref)
+			expected = append(expected, value)
+		case 2:
+			l.Remove(store, ref)
+			delete(refs, value)
+		}
+		require.Equal(t, expected, listOrder(l, store))
+	}
+}
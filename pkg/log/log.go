@@ -0,0 +1,271 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+// The log package provides an append-only log of byte records stored
+// offheap.
+//
+// Each record is identified by its offset, the position of the record in the
+// log. Offsets are assigned in increasing order, and a record's offset never
+// changes. This makes a Log a useful building block for write-ahead logs and
+// event buffers, which may hold very large numbers of records without adding
+// to the cost of garbage collection.
+//
+// Records are stored in a chain of segments, each segment is a single offheap
+// allocation. When a record doesn't fit in the remaining space of the last
+// segment a new segment is started. Records never span segments, a record
+// larger than the configured segment size is stored in a segment of its own.
+//
+// Each record is preceded by a header containing its length and, if enabled,
+// a CRC-32 checksum of its contents. Checksums are verified when a record is
+// read.
+package log
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"math"
+	"sort"
+
+	"github.com/fmstephe/memorymanager/offheap"
+)
+
+const (
+	// The segment size used if Config.SegmentSize is not set
+	defaultSegmentSize = 1 << 16
+	// The number of bytes used to store the length of a record
+	lengthSize = 4
+	// The number of bytes used to store the checksum of a record
+	checksumSize = 4
+	// The number of segments in a new Log's chain of segments
+	initialSegments = 4
+)
+
+// Returned when reading a record whose contents don't match its checksum
+var ErrChecksum = errors.New("record checksum mismatch")
+
+type Config struct {
+	// The size, in bytes, of each segment. Larger segments waste less
+	// space at the end of each segment, but each segment allocation is
+	// larger.
+	//
+	// <= 0 indicates the default segment size of 64KB.
+	SegmentSize int
+
+	// Enables a CRC-32 checksum for each record, which is verified when
+	// the record is read. Each checksum adds 4 bytes to each record.
+	Checksums bool
+}
+
+func (c Config) getSegmentSize() int {
+	if c.SegmentSize <= 0 {
+		return defaultSegmentSize
+	}
+	return c.SegmentSize
+}
+
+// A single offheap allocation, containing a sequence of records
+type segment struct {
+	// The offset of the first byte of this segment
+	start uint64
+	// The number of bytes of data written to
+	used int
+	data offheap.RefSlice[byte]
+}
+
+// An append-only log of byte records.
+//
+// A Log is not safe for concurrent use.
+type Log struct {
+	store       *offheap.Store
+	segmentSize int
+	headerSize  int
+	checksums   bool
+
+	// The chain of segments, only the first segmentCount are in use
+	segments     offheap.RefSlice[segment]
+	segmentCount int
+	// The number of records in the log
+	records int
+}
+
+// Returns a new, empty, Log.
+func New(config Config) *Log {
+	return NewWithStore(offheap.New(), config)
+}
+
+// Returns a new, empty, Log which allocates from store. This allows many logs
+// to share the same offheap memory.
+func NewWithStore(store *offheap.Store, config Config) *Log {
+	headerSize := lengthSize
+	if config.Checksums {
+		headerSize += checksumSize
+	}
+
+	return &Log{
+		store:       store,
+		segmentSize: config.getSegmentSize(),
+		headerSize:  headerSize,
+		checksums:   config.Checksums,
+		segments:    offheap.AllocSlice[segment](store, initialSegments, initialSegments),
+	}
+}
+
+// Returns the number of records in the log
+func (l *Log) Len() int {
+	return l.records
+}
+
+// Returns the offset at which the next record will be appended. Every record
+// in the log has an offset less than this.
+func (l *Log) End() uint64 {
+	if l.segmentCount == 0 {
+		return 0
+	}
+	last := l.usedSegments()[l.segmentCount-1]
+	return last.start + uint64(last.used)
+}
+
+// Appends a copy of data to the log, returning the offset of the new record.
+func (l *Log) Append(data []byte) uint64 {
+	if uint64(len(data)) > math.MaxUint32 {
+		panic(fmt.Errorf("record length (%d) is too large, the maximum is %d", len(data), uint64(math.MaxUint32)))
+	}
+
+	recordSize := l.headerSize + len(data)
+	seg := l.segmentFor(recordSize)
+
+	offset := seg.start + uint64(seg.used)
+	record := seg.data.Value()[seg.used : seg.used+recordSize]
+	binary.LittleEndian.PutUint32(record, uint32(len(data)))
+	if l.checksums {
+		binary.LittleEndian.PutUint32(record[lengthSize:], crc32.ChecksumIEEE(data))
+	}
+	copy(record[l.headerSize:], data)
+
+	seg.used += recordSize
+	l.records++
+	return offset
+}
+
+// Returns the record at offset, which must be an offset returned by Append.
+// The returned slice refers directly to the log's offheap memory, it must not
+// be modified, and must not be used after the log is freed.
+//
+// An error is returned if offset is outside the log, or if checksums are
+// enabled and the record's contents don't match its checksum.
+func (l *Log) Read(offset uint64) ([]byte, error) {
+	data, _, err := l.read(offset)
+	return data, err
+}
+
+// Returns the offset of the record following the record at offset. If the
+// record at offset is the last record in the log false is returned. Together
+// with an offset of 0, Next can be used to visit every record in the log
+//
+//	for offset, ok := uint64(0), l.Len() > 0; ok; offset, ok = l.Next(offset) {
+//		data, err := l.Read(offset)
+//		...
+//	}
+func (l *Log) Next(offset uint64) (uint64, bool) {
+	_, next, err := l.read(offset)
+	if err != nil && !errors.Is(err, ErrChecksum) {
+		return 0, false
+	}
+	return next, next < l.End()
+}
+
+// Frees all of the memory used by this log. After this method is called the
+// log must not be used again.
+func (l *Log) Free() {
+	for _, seg := range l.usedSegments() {
+		offheap.FreeSlice(l.store, seg.data)
+	}
+	offheap.FreeSlice(l.store, l.segments)
+	*l = Log{}
+}
+
+// Returns the record at offset, and the offset immediately following it
+func (l *Log) read(offset uint64) (data []byte, next uint64, err error) {
+	seg, ok := l.findSegment(offset)
+	if !ok {
+		return nil, 0, fmt.Errorf("offset %d is outside the log, which ends at %d", offset, l.End())
+	}
+
+	pos := int(offset - seg.start)
+	if pos+l.headerSize > seg.used {
+		return nil, 0, fmt.Errorf("offset %d does not contain a record header", offset)
+	}
+
+	segData := seg.data.Value()[:seg.used]
+	length := int(binary.LittleEndian.Uint32(segData[pos:]))
+	end := pos + l.headerSize + length
+	if end > seg.used {
+		return nil, 0, fmt.Errorf("record at offset %d with length %d extends past the end of its segment", offset, length)
+	}
+
+	data = segData[pos+l.headerSize : end : end]
+	next = seg.start + uint64(end)
+	if l.checksums {
+		if checksum := binary.LittleEndian.Uint32(segData[pos+lengthSize:]); checksum != crc32.ChecksumIEEE(data) {
+			return nil, next, fmt.Errorf("record at offset %d %w", offset, ErrChecksum)
+		}
+	}
+	return data, next, nil
+}
+
+// Returns the segments in use
+func (l *Log) usedSegments() []segment {
+	return l.segments.Value()[:l.segmentCount]
+}
+
+// Returns the segment containing offset, if offset is in the log
+func (l *Log) findSegment(offset uint64) (*segment, bool) {
+	segments := l.usedSegments()
+	idx := sort.Search(len(segments), func(i int) bool {
+		return segments[i].start > offset
+	}) - 1
+	if idx < 0 || offset >= segments[idx].start+uint64(segments[idx].used) {
+		return nil, false
+	}
+	return &segments[idx], true
+}
+
+// Returns the last segment, if it has room for recordSize bytes, or a new
+// segment which does
+func (l *Log) segmentFor(recordSize int) *segment {
+	if l.segmentCount > 0 {
+		last := &l.usedSegments()[l.segmentCount-1]
+		if len(last.data.Value())-last.used >= recordSize {
+			return last
+		}
+	}
+
+	start := l.End()
+	l.growSegments()
+
+	size := max(l.segmentSize, recordSize)
+	segments := l.segments.Value()
+	segments[l.segmentCount] = segment{
+		start: start,
+		data:  offheap.AllocSlice[byte](l.store, size, size),
+	}
+	l.segmentCount++
+	return &segments[l.segmentCount-1]
+}
+
+// If every segment is in use, doubles the number of segments in the chain
+func (l *Log) growSegments() {
+	segments := l.segments.Value()
+	if l.segmentCount < len(segments) {
+		return
+	}
+
+	newSegmentsRef := offheap.AllocSlice[segment](l.store, len(segments)*2, len(segments)*2)
+	copy(newSegmentsRef.Value(), segments)
+
+	offheap.FreeSlice(l.store, l.segments)
+	l.segments = newSegmentsRef
+}
@@ -0,0 +1,122 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+package log
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/fmstephe/memorymanager/offheap"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Show that an empty log contains no records
+func TestEmpty(t *testing.T) {
+	l := New(Config{})
+	defer l.Free()
+
+	assert.Equal(t, 0, l.Len())
+	assert.Equal(t, uint64(0), l.End())
+
+	_, err := l.Read(0)
+	assert.Error(t, err)
+	_, ok := l.Next(0)
+	assert.False(t, ok)
+}
+
+// Show that records can be read back by their offsets, across many segments
+// and with records larger than a segment
+func TestAppendRead(t *testing.T) {
+	for _, checksums := range []bool{false, true} {
+		t.Run(fmt.Sprintf("checksums=%v", checksums), func(t *testing.T) {
+			r := rand.New(rand.NewSource(1))
+			l := New(Config{SegmentSize: 256, Checksums: checksums})
+			defer l.Free()
+
+			records := [][]byte{}
+			offsets := []uint64{}
+			for range 1000 {
+				// Mostly small records, with some empty records
+				// and some larger than a segment
+				record := make([]byte, r.Intn(64))
+				if r.Intn(20) == 0 {
+					record = make([]byte, 300+r.Intn(300))
+				}
+				r.Read(record)
+
+				offset := l.Append(record)
+				if len(offsets) > 0 {
+					require.Greater(t, offset, offsets[len(offsets)-1])
+				}
+				records = append(records, record)
+				offsets = append(offsets, offset)
+			}
+			assert.Equal(t, 1000, l.Len())
+			assert.Greater(t, l.segmentCount, 100)
+
+			for i, offset := range offsets {
+				data, err := l.Read(offset)
+				require.NoError(t, err)
+				assert.Equal(t, records[i], data)
+			}
+
+			// Next visits every record in order
+			i := 0
+			for offset, ok := uint64(0), l.Len() > 0; ok; offset, ok = l.Next(offset) {
+				require.Equal(t, offsets[i], offset)
+				i++
+			}
+			assert.Equal(t, len(records), i)
+
+			_, err := l.Read(l.End())
+			assert.Error(t, err)
+		})
+	}
+}
+
+// Show that corrupted records are detected when checksums are enabled
+func TestChecksum(t *testing.T) {
+	l := New(Config{Checksums: true})
+	defer l.Free()
+
+	first := l.Append([]byte("first record"))
+	second := l.Append([]byte("second record"))
+
+	// Corrupt the first record in place
+	data, err := l.Read(first)
+	require.NoError(t, err)
+	data[0] = 'F'
+
+	_, err = l.Read(first)
+	assert.ErrorIs(t, err, ErrChecksum)
+
+	// A corrupted record can still be skipped over
+	next, ok := l.Next(first)
+	assert.True(t, ok)
+	assert.Equal(t, second, next)
+
+	data, err = l.Read(second)
+	require.NoError(t, err)
+	assert.Equal(t, "second record", string(data))
+}
+
+// Show that Free releases every allocation made by the log
+func TestFree(t *testing.T) {
+	store := offheap.New()
+	defer func() {
+		assert.NoError(t, store.Destroy())
+	}()
+
+	l := NewWithStore(store, Config{SegmentSize: 128})
+	for range 1000 {
+		l.Append([]byte("some record data"))
+	}
+	assert.Greater(t, store.TotalStats().Live, 1)
+
+	l.Free()
+	assert.Equal(t, 0, store.TotalStats().Live)
+}
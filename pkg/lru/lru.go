@@ -0,0 +1,286 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+// The lru package provides a fixed capacity least recently used cache whose
+// keys and values are stored offheap.
+//
+// Each entry is a single offheap node, containing the key and value. Nodes
+// are found through a hash index, an offheap slice of buckets each holding a
+// chain of nodes. Nodes are also linked, from most to least recently used,
+// in an intrusive doubly linked list. When the cache is full, putting a new
+// entry evicts the least recently used entry.
+//
+// Keys are hashed using their in-memory representation, and compared using
+// ==. Keys which are equal, but have different in-memory representations,
+// such as the float values 0 and -0, will be cached as separate entries. The
+// padding bytes of a struct key, and its blank fields, have arbitrary
+// contents, so key types containing padding or blank fields can't be hashed
+// and are rejected by New.
+package lru
+
+import (
+	"fmt"
+	"reflect"
+	"unsafe"
+
+	xxhash "github.com/cespare/xxhash/v2"
+	"github.com/fmstephe/flib/fmath"
+	"github.com/fmstephe/memorymanager/offheap"
+)
+
+// A single cache entry
+type node[K comparable, V any] struct {
+	key   K
+	value V
+	// The next node in the same hash bucket
+	chain offheap.RefObject[node[K, V]]
+	// The adjacent nodes in the recency list, prev is more recently used
+	// and next is less recently used
+	prev offheap.RefObject[node[K, V]]
+	next offheap.RefObject[node[K, V]]
+}
+
+// A least recently used cache, holding at most capacity entries. The types K
+// and V must not contain any pointers.
+//
+// A Cache is not safe for concurrent use.
+type Cache[K comparable, V any] struct {
+	store    *offheap.Store
+	capacity int
+	onEvict  func(key K, value V)
+
+	// The hash index, each bucket is the first node in a chain of nodes
+	buckets offheap.RefSlice[offheap.RefObject[node[K, V]]]
+	mask    uint64
+
+	// The most recently used node
+	head offheap.RefObject[node[K, V]]
+	// The least recently used node
+	tail offheap.RefObject[node[K, V]]
+	// The number of entries in the cache
+	length int
+}
+
+// Returns a new, empty, Cache which holds at most capacity entries. If
+// onEvict is not nil it is called with the key and value of each entry
+// evicted to make room for a new entry. If the type K contains padding, or
+// blank fields, this function panics.
+func New[K comparable, V any](capacity int, onEvict func(key K, value V)) *Cache[K, V] {
+	return NewWithStore[K, V](offheap.New(), capacity, onEvict)
+}
+
+// Returns a new, empty, Cache which allocates from store. This allows many
+// caches to share the same offheap memory. See New.
+func NewWithStore[K comparable, V any](store *offheap.Store, capacity int, onEvict func(key K, value V)) *Cache[K, V] {
+	if capacity < 1 {
+		panic(fmt.Errorf("capacity (%d) must be at least 1", capacity))
+	}
+	if keyType := reflect.TypeFor[K](); hasPadding(keyType) {
+		panic(fmt.Errorf("key type %s contains padding, or blank fields, and can't be hashed", keyType))
+	}
+
+	bucketCount := int(fmath.NxtPowerOfTwo(int64(capacity)))
+	buckets := offheap.AllocSlice[offheap.RefObject[node[K, V]]](store, bucketCount, bucketCount)
+	clear(buckets.Value())
+
+	return &Cache[K, V]{
+		store:    store,
+		capacity: capacity,
+		onEvict:  onEvict,
+		buckets:  buckets,
+		mask:     uint64(bucketCount - 1),
+	}
+}
+
+// Returns the number of entries in the cache
+func (c *Cache[K, V]) Len() int {
+	return c.length
+}
+
+// Returns the maximum number of entries the cache can hold
+func (c *Cache[K, V]) Capacity() int {
+	return c.capacity
+}
+
+// Returns the value cached for key, and marks key as the most recently used
+// entry. If key is not cached the zero value of V and false are returned.
+func (c *Cache[K, V]) Get(key K) (V, bool) {
+	r := c.find(key)
+	if r.IsNil() {
+		var zero V
+		return zero, false
+	}
+
+	c.moveToFront(r)
+	return r.Value().value, true
+}
+
+// Returns the value cached for key, without changing how recently key was
+// used. If key is not cached the zero value of V and false are returned.
+func (c *Cache[K, V]) Peek(key K) (V, bool) {
+	r := c.find(key)
+	if r.IsNil() {
+		var zero V
+		return zero, false
+	}
+
+	return r.Value().value, true
+}
+
+// Caches value for key, and marks key as the most recently used entry. If key
+// is already cached its value is replaced. Otherwise, if the cache is full,
+// the least recently used entry is evicted first.
+func (c *Cache[K, V]) Put(key K, value V) {
+	if r := c.find(key); !r.IsNil() {
+		r.Value().value = value
+		c.moveToFront(r)
+		return
+	}
+
+	if c.length == c.capacity {
+		c.evict()
+	}
+
+	bucket := c.bucket(key)
+	r := offheap.AllocObjectFrom(c.store, node[K, V]{
+		key:   key,
+		value: value,
+		chain: *bucket,
+	})
+	*bucket = r
+	c.pushFront(r)
+	c.length++
+}
+
+// Removes the entry for key from the cache. Returns false if key was not
+// cached. The eviction callback is not called for removed entries.
+func (c *Cache[K, V]) Remove(key K) bool {
+	r := c.find(key)
+	if r.IsNil() {
+		return false
+	}
+
+	c.remove(r)
+	return true
+}
+
+// Frees all of the memory used by this cache. The eviction callback is not
+// called. After this method is called the cache must not be used again.
+func (c *Cache[K, V]) Free() {
+	for r := c.head; !r.IsNil(); {
+		next := r.Value().next
+		offheap.FreeObject(c.store, r)
+		r = next
+	}
+	offheap.FreeSlice(c.store, c.buckets)
+	*c = Cache[K, V]{}
+}
+
+// Indicates whether values of t contain any bytes which aren't compared by ==,
+// i.e. the padding between and after struct fields, or blank fields
+func hasPadding(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Struct:
+		offset := uintptr(0)
+		for i := range t.NumField() {
+			field := t.Field(i)
+			if field.Name == "_" || field.Offset != offset || hasPadding(field.Type) {
+				return true
+			}
+			offset += field.Type.Size()
+		}
+		return offset != t.Size()
+	case reflect.Array:
+		return t.Len() > 0 && hasPadding(t.Elem())
+	default:
+		return false
+	}
+}
+
+// Returns the bucket which key belongs in
+func (c *Cache[K, V]) bucket(key K) *offheap.RefObject[node[K, V]] {
+	bytes := unsafe.Slice((*byte)(unsafe.Pointer(&key)), unsafe.Sizeof(key))
+	return &c.buckets.Value()[xxhash.Sum64(bytes)&c.mask]
+}
+
+// Returns the node for key, or a nil reference if key is not cached
+func (c *Cache[K, V]) find(key K) offheap.RefObject[node[K, V]] {
+	for r := *c.bucket(key); !r.IsNil(); {
+		n := r.Value()
+		if n.key == key {
+			return r
+		}
+		r = n.chain
+	}
+	return offheap.RefObject[node[K, V]]{}
+}
+
+// Removes the least recently used entry, calling the eviction callback
+func (c *Cache[K, V]) evict() {
+	r := c.tail
+	n := r.Value()
+	key, value := n.key, n.value
+
+	c.remove(r)
+
+	if c.onEvict != nil {
+		c.onEvict(key, value)
+	}
+}
+
+// Unlinks r from its bucket and the recency list, and frees it
+func (c *Cache[K, V]) remove(r offheap.RefObject[node[K, V]]) {
+	n := r.Value()
+
+	// Unlink from the bucket's chain
+	link := c.bucket(n.key)
+	for *link != r {
+		link = &link.Value().chain
+	}
+	*link = n.chain
+
+	c.unlink(r)
+	offheap.FreeObject(c.store, r)
+	c.length--
+}
+
+// Marks r as the most recently used node
+func (c *Cache[K, V]) moveToFront(r offheap.RefObject[node[K, V]]) {
+	if r == c.head {
+		return
+	}
+	c.unlink(r)
+	c.pushFront(r)
+}
+
+// Inserts r at the front of the recency list
+func (c *Cache[K, V]) pushFront(r offheap.RefObject[node[K, V]]) {
+	n := r.Value()
+	n.prev = offheap.RefObject[node[K, V]]{}
+	n.next = c.head
+
+	if c.head.IsNil() {
+		c.tail = r
+	} else {
+		c.head.Value().prev = r
+	}
+	c.head = r
+}
+
+// Removes r from the recency list
+func (c *Cache[K, V]) unlink(r offheap.RefObject[node[K, V]]) {
+	n := r.Value()
+
+	if n.prev.IsNil() {
+		c.head = n.next
+	} else {
+		n.prev.Value().next = n.next
+	}
+
+	if n.next.IsNil() {
+		c.tail = n.prev
+	} else {
+		n.next.Value().prev = n.prev
+	}
+}
@@ -0,0 +1,246 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+package lru
+
+import (
+	"math/rand"
+	"slices"
+	"testing"
+
+	"github.com/fmstephe/memorymanager/offheap"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testKey struct {
+	a, b int32
+}
+
+type testValue struct {
+	id    int
+	value float64
+}
+
+// Show that a cache must have a capacity of at least 1
+func TestNew_InvalidCapacity(t *testing.T) {
+	assert.Panics(t, func() { New[int, int](0, nil) })
+	assert.Panics(t, func() { New[int, int](-1, nil) })
+}
+
+// Show that values put into a cache can be retrieved, and that missing keys
+// Show that key types whose in-memory representation includes bytes which
+// aren't compared by == are rejected
+func TestNew_PaddedKey(t *testing.T) {
+	type padded struct {
+		a int8
+		b int64
+	}
+	type trailing struct {
+		a int64
+		b int8
+	}
+	type blank struct {
+		a int32
+		_ int32
+	}
+	type nested struct {
+		a [2]trailing
+	}
+	assert.Panics(t, func() { New[padded, int](1, nil) })
+	assert.Panics(t, func() { New[trailing, int](1, nil) })
+	assert.Panics(t, func() { New[blank, int](1, nil) })
+	assert.Panics(t, func() { New[nested, int](1, nil) })
+
+	type packed struct {
+		a [3]int8
+		b int8
+		c int32
+		d [2]testKey
+	}
+	for _, c := range []interface{ Free() }{
+		New[packed, int](1, nil),
+		New[testKey, int](1, nil),
+		New[[4]int16, int](1, nil),
+		New[float64, int](1, nil),
+	} {
+		c.Free()
+	}
+}
+
+// are reported
+func TestPutGet(t *testing.T) {
+	c := New[testKey, testValue](100, nil)
+	defer c.Free()
+
+	assert.Equal(t, 0, c.Len())
+	assert.Equal(t, 100, c.Capacity())
+
+	value, ok := c.Get(testKey{1, 2})
+	assert.False(t, ok)
+	assert.Equal(t, testValue{}, value)
+
+	for i := range 100 {
+		c.Put(testKey{int32(i), int32(-i)}, testValue{id: i})
+	}
+	assert.Equal(t, 100, c.Len())
+
+	for i := range 100 {
+		value, ok := c.Get(testKey{int32(i), int32(-i)})
+		require.True(t, ok)
+		assert.Equal(t, i, value.id)
+	}
+
+	// Putting an existing key replaces its value
+	c.Put(testKey{5, -5}, testValue{id: 500})
+	value, ok = c.Get(testKey{5, -5})
+	require.True(t, ok)
+	assert.Equal(t, 500, value.id)
+	assert.Equal(t, 100, c.Len())
+}
+
+// Show that the least recently used entry is evicted, and passed to the
+// eviction callback, when a full cache is put into
+func TestEviction(t *testing.T) {
+	evicted := []int{}
+	c := New[int, testValue](3, func(key int, value testValue) {
+		assert.Equal(t, key, value.id)
+		evicted = append(evicted, key)
+	})
+	defer c.Free()
+
+	c.Put(1, testValue{id: 1})
+	c.Put(2, testValue{id: 2})
+	c.Put(3, testValue{id: 3})
+	assert.Empty(t, evicted)
+
+	// 1 is now the most recently used
+	_, ok := c.Get(1)
+	require.True(t, ok)
+
+	c.Put(4, testValue{id: 4})
+	assert.Equal(t, []int{2}, evicted)
+
+	// Peeking doesn't change recency, so 3 is evicted next
+	_, ok = c.Peek(3)
+	require.True(t, ok)
+	c.Put(5, testValue{id: 5})
+	assert.Equal(t, []int{2, 3}, evicted)
+
+	// Updating 1 makes it the most recently used
+	c.Put(4, testValue{id: 4})
+	c.Put(1, testValue{id: 1})
+	c.Put(6, testValue{id: 6})
+	assert.Equal(t, []int{2, 3, 5}, evicted)
+
+	assert.Equal(t, 3, c.Len())
+	for _, key := range []int{1, 4, 6} {
+		_, ok := c.Peek(key)
+		assert.True(t, ok)
+	}
+}
+
+// Show that removed entries are no longer cached, and are not passed to the
+// eviction callback
+func TestRemove(t *testing.T) {
+	c := New[int, testValue](10, func(key int, value testValue) {
+		t.Fatalf("unexpected eviction of %d", key)
+	})
+	defer c.Free()
+
+	for i := range 10 {
+		c.Put(i, testValue{id: i})
+	}
+
+	assert.True(t, c.Remove(3))
+	assert.False(t, c.Remove(3))
+	assert.False(t, c.Remove(100))
+	assert.Equal(t, 9, c.Len())
+
+	_, ok := c.Get(3)
+	assert.False(t, ok)
+
+	// There is space for one more entry without evicting
+	c.Put(10, testValue{id: 10})
+	assert.Equal(t, 10, c.Len())
+}
+
+// Show that Free releases every allocation made by the cache
+func TestFree(t *testing.T) {
+	store := offheap.New()
+	defer func() {
+		assert.NoError(t, store.Destroy())
+	}()
+
+	c := NewWithStore[int, testValue](store, 50, nil)
+	for i := range 200 {
+		c.Put(i, testValue{id: i})
+	}
+	assert.Greater(t, store.TotalStats().Live, 0)
+
+	c.Free()
+	assert.Equal(t, 0, store.TotalStats().Live)
+}
+
+// Randomly put, get and remove keys in a cache, checking its contents against
+// a simple slice based cache
+func TestRandomOperations(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+
+	const capacity = 64
+	expectedEvicted := []int{}
+	evicted := []int{}
+	c := New[int, testValue](capacity, func(key int, value testValue) {
+		assert.Equal(t, key, value.id)
+		evicted = append(evicted, key)
+	})
+	defer c.Free()
+
+	// Keys ordered from most to least recently used
+	expected := []int{}
+	moveToFront := func(key int) {
+		idx := slices.Index(expected, key)
+		expected = slices.Delete(expected, idx, idx+1)
+		expected = slices.Insert(expected, 0, key)
+	}
+
+	for range 100_000 {
+		key := r.Intn(capacity * 2)
+		switch r.Intn(3) {
+		case 0:
+			c.Put(key, testValue{id: key})
+			if slices.Contains(expected, key) {
+				moveToFront(key)
+			} else {
+				if len(expected) == capacity {
+					expectedEvicted = append(expectedEvicted, expected[capacity-1])
+					expected = expected[:capacity-1]
+				}
+				expected = slices.Insert(expected, 0, key)
+			}
+		case 1:
+			value, ok := c.Get(key)
+			require.Equal(t, slices.Contains(expected, key), ok)
+			if ok {
+				assert.Equal(t, key, value.id)
+				moveToFront(key)
+			}
+		case 2:
+			removed := c.Remove(key)
+			require.Equal(t, slices.Contains(expected, key), removed)
+			if removed {
+				idx := slices.Index(expected, key)
+				expected = slices.Delete(expected, idx, idx+1)
+			}
+		}
+		require.Equal(t, len(expected), c.Len())
+	}
+
+	assert.Equal(t, expectedEvicted, evicted)
+	for _, key := range expected {
+		value, ok := c.Peek(key)
+		require.True(t, ok)
+		assert.Equal(t, key, value.id)
+	}
+}
@@ -0,0 +1,193 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+package quadtree
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+
+	"github.com/fmstephe/memorymanager/offheap"
+)
+
+// A single element to be loaded into a tree at the point (X,Y)
+type PointData[T any] struct {
+	X    float64
+	Y    float64
+	Data T
+}
+
+// Returns a new Tree containing all of the elements in points.
+//
+// This produces the same tree as calling NewTree(view) and then inserting
+// each element individually. But instead of traversing from the root for
+// every insert, the points are partitioned recursively into the quarters of
+// each node, and each node is built exactly once. Elements which share the
+// same location are gathered together into a single allocation.
+//
+// The order of points is modified by this function, but the elements are not
+// retained. If any point lies outside of view an error is returned and no
+// tree is built.
+func BulkLoad[T any](view View, points []PointData[T]) (*Tree[T], error) {
+	return BulkLoadWithConfig(view, DefaultConfig(), points)
+}
+
+// Returns a new Tree, whose leaves are split according to config, containing
+// all of the elements in points. See BulkLoad and NewTreeWithConfig.
+func BulkLoadWithConfig[T any](view View, config Config, points []PointData[T]) (*Tree[T], error) {
+	for i := range points {
+		if !view.containsPoint(points[i].X, points[i].Y) {
+			return nil, fmt.Errorf("cannot insert x(%f) y(%f) into view %s", points[i].X, points[i].Y, view)
+		}
+	}
+
+	store := newTreeStore[T](config)
+	scratch := make([]PointData[T], len(points))
+	st := buildInternal(view, 0, points, scratch, store)
+	return &Tree[T]{
+		store:         store,
+		treeReference: st,
+		view:          view,
+	}, nil
+}
+
+// Builds the subtree for view, containing all the elements in points. If
+// points can fit into a single leaf, or a leaf at this depth can't be split,
+// then a leaf is built, otherwise an internal node is built.
+func buildNode[T any](view View, depth int, points, scratch []PointData[T], store *nodeStore[T]) offheap.RefObject[node[T]] {
+	if fitsInLeaf(points, store.config.LeafSize) || !store.config.canSplit(view, depth) {
+		return buildLeaf(view, depth, points, store)
+	}
+	return buildInternal(view, depth, points, scratch, store)
+}
+
+// Builds an internal node for view, with each of its children built from the
+// points which lie in that child's view.
+func buildInternal[T any](view View, depth int, points, scratch []PointData[T], store *nodeStore[T]) offheap.RefObject[node[T]] {
+	nodeR, newNode := store.allocNode(view, depth)
+	newNode.cachedCount = int64(len(points))
+
+	views := view.quarters()
+	parts := partition(views, points, scratch[:len(points)])
+	for i := range views {
+		newNode.children[i] = buildNode(views[i], depth+1, parts[i], scratch, store)
+	}
+	return nodeR
+}
+
+// Builds a leaf node for view. Points are stably sorted by location so that
+// all of the elements at each location are adjacent and can be copied into a
+// single list. Locations which don't fit in the leaf are stored as overflow
+// points.
+func buildLeaf[T any](view View, depth int, points []PointData[T], store *nodeStore[T]) offheap.RefObject[node[T]] {
+	leafR := store.allocLeaf(view, depth)
+	leaf := leafR.Value()
+	leaf.cachedCount = int64(len(points))
+
+	slices.SortStableFunc(points, comparePointData[T])
+
+	psIdx := 0
+	for start := 0; start < len(points); {
+		end := start + 1
+		for end < len(points) && comparePointData(points[start], points[end]) == 0 {
+			end++
+		}
+
+		list := offheap.AllocSlice[T](store.nodes, end-start, end-start)
+		listSlc := list.Value()
+		for i := range listSlc {
+			listSlc[i] = points[start+i].Data
+		}
+
+		p := point[T]{x: points[start].X, y: points[start].Y, list: list}
+		if psIdx < store.config.LeafSize {
+			leaf.ps[psIdx] = p
+			psIdx++
+		} else {
+			if leaf.overflow.IsNil() {
+				leaf.overflow = offheap.AllocSlice[point[T]](store.nodes, 0, 1)
+			}
+			leaf.overflow = offheap.Append(store.nodes, leaf.overflow, p)
+		}
+
+		start = end
+	}
+	return leafR
+}
+
+// Indicates whether points contains at most leafSize distinct locations, so
+// they can be stored in a single leaf.
+func fitsInLeaf[T any](points []PointData[T], leafSize int) bool {
+	var locs [LEAF_SIZE]location
+	distinct := 0
+	for i := range points {
+		loc := location{x: points[i].X, y: points[i].Y}
+		if slices.Contains(locs[:distinct], loc) {
+			continue
+		}
+		if distinct == leafSize {
+			return false
+		}
+		locs[distinct] = loc
+		distinct++
+	}
+	return true
+}
+
+type location struct {
+	x, y float64
+}
+
+// Reorders points so that the points belonging to each view are contiguous,
+// and returns the sub-slice of points for each view. Each point is assigned to
+// the first view which contains it, matching the behaviour of node.insert(...).
+//
+// The reordering is stable, so elements sharing a location keep their
+// relative order, just as they would if they were inserted individually.
+// Scratch must be at least as long as points, its contents are overwritten.
+func partition[T any](views [4]View, points, scratch []PointData[T]) [4][]PointData[T] {
+	counts := [4]int{}
+	for i := range points {
+		counts[viewIndex(views, points[i])]++
+	}
+
+	offsets := [4]int{}
+	for i := 1; i < len(views); i++ {
+		offsets[i] = offsets[i-1] + counts[i-1]
+	}
+
+	parts := [4][]PointData[T]{}
+	for i := range views {
+		parts[i] = points[offsets[i] : offsets[i]+counts[i]]
+	}
+
+	for i := range points {
+		idx := viewIndex(views, points[i])
+		scratch[offsets[idx]] = points[i]
+		offsets[idx]++
+	}
+	copy(points, scratch)
+
+	return parts
+}
+
+// Returns the index of the first view containing p. If no view contains p,
+// which can happen due to floating point imprecision at the edges of views,
+// the last view is chosen.
+func viewIndex[T any](views [4]View, p PointData[T]) int {
+	for i := range views[:len(views)-1] {
+		if views[i].containsPoint(p.X, p.Y) {
+			return i
+		}
+	}
+	return len(views) - 1
+}
+
+func comparePointData[T any](a, b PointData[T]) int {
+	if c := cmp.Compare(a.X, b.X); c != 0 {
+		return c
+	}
+	return cmp.Compare(a.Y, b.Y)
+}
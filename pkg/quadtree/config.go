@@ -0,0 +1,63 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+package quadtree
+
+import (
+	"fmt"
+)
+
+// Controls when the leaves of a Tree are split, see NewTreeWithConfig.
+//
+// A leaf which can't be split, because of MaxDepth or MinViewSize, keeps
+// accepting new locations beyond LeafSize. Surveying such a leaf visits
+// every location it holds, so these limits trade survey precision for
+// protection against very deep trees. This is useful when many distinct
+// locations are clustered extremely closely together.
+type Config struct {
+	// The number of distinct locations a leaf holds before it is split.
+	// Must be between 1 and LEAF_SIZE.
+	LeafSize int
+	// The maximum depth of any leaf, the root of the tree has depth 0 and
+	// its children have depth 1. A leaf at MaxDepth is never split. If
+	// MaxDepth is 0 the depth of the tree is not limited.
+	MaxDepth int
+	// A leaf is never split if its children would be narrower, or shorter,
+	// than MinViewSize. If MinViewSize is 0 leaves are split regardless of
+	// their size.
+	MinViewSize float64
+}
+
+// Returns the Config used by NewTree
+func DefaultConfig() Config {
+	return Config{
+		LeafSize: LEAF_SIZE,
+	}
+}
+
+func (c Config) validate() error {
+	if c.LeafSize < 1 || c.LeafSize > LEAF_SIZE {
+		return fmt.Errorf("leaf size (%d) must be between 1 and %d", c.LeafSize, LEAF_SIZE)
+	}
+	if c.MaxDepth < 0 {
+		return fmt.Errorf("max depth (%d) must not be negative", c.MaxDepth)
+	}
+	if c.MinViewSize < 0 {
+		return fmt.Errorf("min view size (%f) must not be negative", c.MinViewSize)
+	}
+	return nil
+}
+
+// Indicates whether a leaf with view, at depth, can be split
+func (c Config) canSplit(view View, depth int) bool {
+	if c.MaxDepth != 0 && depth >= c.MaxDepth {
+		return false
+	}
+	if c.MinViewSize != 0 {
+		if (view.rx-view.lx)/2 < c.MinViewSize || (view.ty-view.by)/2 < c.MinViewSize {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,127 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+// The geo package provides utilities for using a quadtree.Tree to store
+// geographic coordinates.
+//
+// Following quadtree.NewLongLatView, x coordinates are longitudes, west to
+// east (-180...180), and y coordinates are latitudes, north to south
+// (90...-90). All angles are in degrees and all distances are in meters.
+//
+// A quadtree.View can't cross the antimeridian, at ±180° longitude. Regions
+// which do cross it are described by two views, one on each side of the
+// antimeridian, see Views.
+package geo
+
+import (
+	"math"
+
+	"github.com/fmstephe/memorymanager/pkg/quadtree"
+)
+
+// The mean radius of the earth in meters
+const EarthRadius = 6_371_008.8
+
+// Returns the great circle distance, in meters, between two points. The
+// distance is calculated using the haversine formula, which treats the earth
+// as a sphere. The error this introduces is at most around 0.5%.
+func Distance(lon1, lat1, lon2, lat2 float64) float64 {
+	phi1 := radians(lat1)
+	phi2 := radians(lat2)
+	dPhi := radians(lat2 - lat1)
+	dLambda := radians(lon2 - lon1)
+
+	a := math.Sin(dPhi/2)*math.Sin(dPhi/2) +
+		math.Cos(phi1)*math.Cos(phi2)*math.Sin(dLambda/2)*math.Sin(dLambda/2)
+	// Rounding errors can push a just past 1 for antipodal points
+	a = min(a, 1)
+	return 2 * EarthRadius * math.Asin(math.Sqrt(a))
+}
+
+// Returns lon normalised into the range [-180, 180).
+func NormaliseLongitude(lon float64) float64 {
+	lon = math.Mod(lon+180, 360)
+	if lon < 0 {
+		lon += 360
+	}
+	return lon - 180
+}
+
+// Returns the views covering the region from west to east, and north to
+// south. Longitudes are normalised, so the region may cross the
+// antimeridian. A region which crosses the antimeridian is covered by two
+// views, otherwise a single view is returned. Latitudes are clamped to the
+// range [-90, 90].
+//
+// A region whose width is 360° or more covers every longitude.
+func Views(west, east, north, south float64) []quadtree.View {
+	north = min(north, 90)
+	south = max(south, -90)
+
+	if east-west >= 360 {
+		return []quadtree.View{quadtree.NewView(-180, 180, north, south)}
+	}
+
+	west = NormaliseLongitude(west)
+	east = NormaliseLongitude(east)
+	if west <= east {
+		return []quadtree.View{quadtree.NewView(west, east, north, south)}
+	}
+
+	// The region crosses the antimeridian
+	return []quadtree.View{
+		quadtree.NewView(west, 180, north, south),
+		quadtree.NewView(-180, east, north, south),
+	}
+}
+
+// Returns the views covering every point within radius meters of (lon,lat).
+// The views bound a circle on the surface of the earth, so they also cover
+// some points which are further than radius from (lon,lat), see
+// SurveyRadius.
+//
+// If the circle contains either pole the views cover every longitude.
+func RadiusViews(lon, lat, radius float64) []quadtree.View {
+	// The angular radius of the circle
+	angle := radius / EarthRadius
+	latDelta := degrees(angle)
+
+	north := lat + latDelta
+	south := lat - latDelta
+	if north >= 90 || south <= -90 {
+		return Views(-180, 180, north, south)
+	}
+
+	// The widest longitude span of the circle, which is at a latitude
+	// slightly closer to the pole than lat
+	lonDelta := degrees(math.Asin(math.Sin(angle) / math.Cos(radians(lat))))
+	return Views(lon-lonDelta, lon+lonDelta, north, south)
+}
+
+// Applies fun to every element of tree within radius meters of (lon,lat).
+// Surveying stops early if fun returns false. Regions which cross the
+// antimeridian, or contain a pole, are surveyed correctly.
+func SurveyRadius[T any](tree *quadtree.Tree[T], lon, lat, radius float64, fun func(lon, lat float64, data *T) bool) {
+	stopped := false
+	for _, view := range RadiusViews(lon, lat, radius) {
+		tree.Survey(view, func(x, y float64, data *T) bool {
+			if Distance(lon, lat, x, y) > radius {
+				return true
+			}
+			stopped = !fun(x, y, data)
+			return !stopped
+		})
+		if stopped {
+			return
+		}
+	}
+}
+
+func radians(deg float64) float64 {
+	return deg * math.Pi / 180
+}
+
+func degrees(rad float64) float64 {
+	return rad * 180 / math.Pi
+}
@@ -0,0 +1,123 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+package geo
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/fmstephe/memorymanager/pkg/quadtree"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Show that distances between well known points are calculated correctly
+func TestDistance(t *testing.T) {
+	// London to Paris is about 344km
+	assert.InDelta(t, 343_900, Distance(-0.1278, 51.5074, 2.3522, 48.8566), 1_000)
+	// The distance is symmetric
+	assert.Equal(t, Distance(-0.1278, 51.5074, 2.3522, 48.8566), Distance(2.3522, 48.8566, -0.1278, 51.5074))
+	// One degree of latitude is about 111km
+	assert.InDelta(t, 111_195, Distance(0, 0, 0, 1), 1)
+	// Points either side of the antimeridian are close
+	assert.InDelta(t, 111_195*2, Distance(179, 0, -179, 0), 1)
+	// Antipodal points are half the circumference apart
+	assert.InDelta(t, math.Pi*EarthRadius, Distance(0, 0, 180, 0), 1)
+	assert.Equal(t, 0.0, Distance(12, 34, 12, 34))
+}
+
+// Show that longitudes are normalised into [-180, 180)
+func TestNormaliseLongitude(t *testing.T) {
+	for _, tc := range []struct{ lon, expected float64 }{
+		{0, 0},
+		{179, 179},
+		{180, -180},
+		{-180, -180},
+		{190, -170},
+		{-190, 170},
+		{540, -180},
+		{-725, -5},
+	} {
+		assert.Equal(t, tc.expected, NormaliseLongitude(tc.lon), "%f", tc.lon)
+	}
+}
+
+// Show that regions crossing the antimeridian are split into two views
+func TestViews(t *testing.T) {
+	views := Views(10, 20, 50, 40)
+	assert.Equal(t, []quadtree.View{quadtree.NewView(10, 20, 50, 40)}, views)
+
+	views = Views(170, 190, 50, 40)
+	assert.Equal(t, []quadtree.View{
+		quadtree.NewView(170, 180, 50, 40),
+		quadtree.NewView(-180, -170, 50, 40),
+	}, views)
+
+	views = Views(-190, -170, 100, -100)
+	assert.Equal(t, []quadtree.View{
+		quadtree.NewView(170, 180, 90, -90),
+		quadtree.NewView(-180, -170, 90, -90),
+	}, views)
+
+	views = Views(-200, 200, 10, -10)
+	assert.Equal(t, []quadtree.View{quadtree.NewView(-180, 180, 10, -10)}, views)
+}
+
+// Show that circles containing a pole cover every longitude
+func TestRadiusViews_Pole(t *testing.T) {
+	views := RadiusViews(45, 89, 200_000)
+	require.Len(t, views, 1)
+	assert.Equal(t, -180.0, views[0].Left())
+	assert.Equal(t, 180.0, views[0].Right())
+	assert.Equal(t, 90.0, views[0].Top())
+}
+
+// Show that SurveyRadius finds exactly the points within the radius, including
+// near the antimeridian, by comparing against a brute force search
+func TestSurveyRadius(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+
+	type location struct {
+		lon, lat float64
+	}
+
+	tree := quadtree.NewTree[location](quadtree.NewLongLatView())
+	locations := []location{}
+	for range 10_000 {
+		loc := location{
+			lon: r.Float64()*360 - 180,
+			lat: r.Float64()*180 - 90,
+		}
+		locations = append(locations, loc)
+		require.NoError(t, tree.Insert(loc.lon, loc.lat, loc))
+	}
+
+	for _, centre := range []location{{0, 0}, {179.5, 10}, {-179.5, -10}, {30, 85}, {-100, -88}} {
+		for _, radius := range []float64{10_000, 500_000, 2_000_000} {
+			expected := []location{}
+			for _, loc := range locations {
+				if Distance(centre.lon, centre.lat, loc.lon, loc.lat) <= radius {
+					expected = append(expected, loc)
+				}
+			}
+
+			found := []location{}
+			SurveyRadius(tree, centre.lon, centre.lat, radius, func(lon, lat float64, data *location) bool {
+				found = append(found, *data)
+				return true
+			})
+			assert.ElementsMatch(t, expected, found, "centre %v radius %f", centre, radius)
+		}
+	}
+
+	// Surveying stops early when fun returns false
+	visited := 0
+	SurveyRadius(tree, 179.5, 0, 2_000_000, func(lon, lat float64, data *location) bool {
+		visited++
+		return false
+	})
+	assert.Equal(t, 1, visited)
+}
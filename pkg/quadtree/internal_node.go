@@ -29,10 +29,32 @@ func (np *point[T]) sameLoc(x, y float64) bool {
 	return np.x == x && np.y == y
 }
 
+// Calls fun on each element stored at this point
+func (np *point[T]) survey(fun func(x, y float64, data *T) bool) bool {
+	listSlc := np.list.Value()
+	for i := range listSlc {
+		if !fun(np.x, np.y, &listSlc[i]) {
+			return false
+		}
+	}
+	return true
+}
+
 func (np *point[T]) String() string {
 	return fmt.Sprintf("(%v,%.3f,%.3f)", np.list, np.x, np.y)
 }
 
+// An element with an extent, rather than a single point
+type box[T any] struct {
+	view View
+	data T
+}
+
+// Returns the x,y coordinates of the centre of this box
+func (b *box[T]) centre() (x, y float64) {
+	return b.view.lx + (b.view.rx-b.view.lx)/2, b.view.by + (b.view.ty-b.view.by)/2
+}
+
 const LEAF_SIZE = 16
 
 // node structs make up the body of a quadtree.
@@ -50,6 +72,9 @@ type node[T any] struct {
 	// question.
 	cachedCount int64
 
+	// The depth of this node, the root of the tree has depth 0
+	depth int32
+
 	// A node is either a leaf, containing actual data, or an internal node
 	// containing subtrees.
 	isLeaf bool
@@ -57,17 +82,26 @@ type node[T any] struct {
 	// Used if this node is a leaf
 	ps [LEAF_SIZE]point[T]
 
+	// Used if this node is a leaf which can't be split, see Config. Holds
+	// the points which don't fit in ps.
+	overflow offheap.RefSlice[point[T]]
+
 	// Used if this node is not a leaf
 	children [4]offheap.RefObject[node[T]]
+
+	// Boxes whose view is contained by this node's view, but which are not
+	// contained by any single child's view. Used by both leaf and internal
+	// nodes.
+	boxes offheap.RefSlice[box[T]]
 }
 
 // Build an internal node, including allocating all of the children of this node.
 // All of the child nodes are leaf nodes.
 func makeNode[T any](view View, store *nodeStore[T]) offheap.RefObject[node[T]] {
-	nodeR, newNode := store.allocNode(view)
+	nodeR, newNode := store.allocNode(view, 0)
 	views := view.quarters()
 	for i, view := range views {
-		leafReference := store.allocLeaf(view)
+		leafReference := store.allocLeaf(view, 1)
 		newNode.children[i] = leafReference
 	}
 	return nodeR
@@ -75,12 +109,14 @@ func makeNode[T any](view View, store *nodeStore[T]) offheap.RefObject[node[T]]
 
 // Inserts list into the single child subtree whose view contains (x,y)
 func (n *node[T]) insert(x, y float64, list offheap.RefSlice[T], store *nodeStore[T]) {
-	// We are adding an element to this node or one of its children, increment the count
-	n.cachedCount++
+	// We are adding elements to this node or one of its children, increment
+	// the count. The list may contain more than one element when it is
+	// being re-inserted by convertToInternal(...)
+	n.cachedCount += int64(len(list.Value()))
 
 	if n.isLeaf {
 		// Node is a leaf - try to insert data directly into leaf
-		for i := range n.ps {
+		for i := range n.ps[:store.config.LeafSize] {
 			if n.ps[i].isEmpty() {
 				n.ps[i].x = x
 				n.ps[i].y = y
@@ -93,6 +129,13 @@ func (n *node[T]) insert(x, y float64, list offheap.RefSlice[T], store *nodeStor
 			}
 		}
 
+		if !store.config.canSplit(n.view, int(n.depth)) {
+			// This leaf can't be split, store the data in the
+			// overflow points
+			n.insertOverflow(x, y, list, store)
+			return
+		}
+
 		// If we reach here then this leaf is full, convert to internal node
 		n.convertToInternal(store)
 		// After converting to internal node we fall down and execute internal node flow below
@@ -109,18 +152,67 @@ func (n *node[T]) insert(x, y float64, list offheap.RefSlice[T], store *nodeStor
 	panic("unreachable")
 }
 
+// Inserts list into the overflow points of this leaf
+func (n *node[T]) insertOverflow(x, y float64, list offheap.RefSlice[T], store *nodeStore[T]) {
+	overflowSlc := n.overflowPoints()
+	for i := range overflowSlc {
+		if overflowSlc[i].sameLoc(x, y) {
+			overflowSlc[i].list = offheap.AppendSlice(store.nodes, overflowSlc[i].list, list.Value())
+			return
+		}
+	}
+
+	if n.overflow.IsNil() {
+		n.overflow = offheap.AllocSlice[point[T]](store.nodes, 0, 1)
+	}
+	n.overflow = offheap.Append(store.nodes, n.overflow, point[T]{x: x, y: y, list: list})
+}
+
+// Returns the overflow points of this leaf, which is nil unless this leaf
+// can't be split
+func (n *node[T]) overflowPoints() []point[T] {
+	if n.overflow.IsNil() {
+		return nil
+	}
+	return n.overflow.Value()
+}
+
+// Inserts b into the smallest subtree whose view contains the whole of b.
+func (n *node[T]) insertBox(b box[T], store *nodeStore[T]) {
+	// We are adding an element to this node or one of its children, increment the count
+	n.cachedCount++
+
+	if !n.isLeaf {
+		// Node is internal - find a subtree which can contain the box
+		for i := range n.children {
+			childNode := n.children[i].Value()
+			if childNode.view.containsView(b.view) {
+				childNode.insertBox(b, store)
+				return
+			}
+		}
+	}
+
+	// Either this node is a leaf, or the box spans more than one child.
+	// The box is stored at this node.
+	if n.boxes.IsNil() {
+		n.boxes = offheap.AllocSlice[box[T]](store.nodes, 0, 1)
+	}
+	n.boxes = offheap.Append(store.nodes, n.boxes, b)
+}
+
 // Converts an existing leaf node to an internal node.  To do this we allocate
 // a new set of leaf nodes and reinsert all of the data into these leaves.
 func (n *node[T]) convertToInternal(store *nodeStore[T]) {
 	n.isLeaf = false
 	views := n.view.quarters()
 	for i, view := range views {
-		leafReference := store.allocLeaf(view)
+		leafReference := store.allocLeaf(view, int(n.depth)+1)
 		n.children[i] = leafReference
 	}
 
 	// re-insert data for the new leaves
-	for i := range n.ps {
+	for i := range n.ps[:store.config.LeafSize] {
 		p := &n.ps[i]
 		x := p.x
 		y := p.y
@@ -135,16 +227,19 @@ func (n *node[T]) convertToInternal(store *nodeStore[T]) {
 	}
 }
 
-// Calls survey on each child subtree whose view overlaps with view
-func (n *node[T]) survey(view View, fun func(x, y float64, data *T) bool, store *nodeStore[T]) bool {
+// Calls survey on each child subtree whose view overlaps with s
+func (n *node[T]) survey(s shape, fun func(x, y float64, data *T) bool, store *nodeStore[T]) bool {
+	if !n.surveyBoxes(s, fun) {
+		return false
+	}
+
 	// Survey each point in this leaf
 	if n.isLeaf {
-		for i := range n.ps {
-			p := &n.ps[i]
-			if !p.isEmpty() && view.containsPoint(p.x, p.y) {
-				listSlc := p.list.Value()
-				for i := range listSlc {
-					if !fun(p.x, p.y, &listSlc[i]) {
+		for _, ps := range [2][]point[T]{n.ps[:], n.overflowPoints()} {
+			for i := range ps {
+				p := &ps[i]
+				if !p.isEmpty() && s.containsPoint(p.x, p.y) {
+					if !p.survey(fun) {
 						return false
 					}
 				}
@@ -156,8 +251,51 @@ func (n *node[T]) survey(view View, fun func(x, y float64, data *T) bool, store
 	// Survey each subtree
 	for _, r := range n.children {
 		st := r.Value()
-		if view.overlaps(st.view) {
-			if !st.survey(view, fun, store) {
+		if s.overlaps(st.view) {
+			if !st.survey(s, fun, store) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Returns the point at exactly (x,y) in this subtree, or nil if no elements
+// are stored at (x,y). The point is found in the same child subtree which
+// insert chooses for (x,y).
+func (n *node[T]) find(x, y float64) *point[T] {
+	if n.isLeaf {
+		for _, ps := range [2][]point[T]{n.ps[:], n.overflowPoints()} {
+			for i := range ps {
+				if !ps[i].isEmpty() && ps[i].sameLoc(x, y) {
+					return &ps[i]
+				}
+			}
+		}
+		return nil
+	}
+
+	for i := range n.children {
+		childNode := n.children[i].Value()
+		if childNode.view.containsPoint(x, y) {
+			return childNode.find(x, y)
+		}
+	}
+	return nil
+}
+
+// Calls fun on each box stored at this node which overlaps with s, but not
+// on any box stored in this node's subtrees
+func (n *node[T]) surveyBoxes(s shape, fun func(x, y float64, data *T) bool) bool {
+	if n.boxes.IsNil() {
+		return true
+	}
+	boxesSlc := n.boxes.Value()
+	for i := range boxesSlc {
+		b := &boxesSlc[i]
+		if s.overlaps(b.view) {
+			x, y := b.centre()
+			if !fun(x, y, &b.data) {
 				return false
 			}
 		}
@@ -172,20 +310,31 @@ func (n *node[T]) count(view View, store *nodeStore[T]) int64 {
 		return n.cachedCount
 	}
 
+	// count the boxes stored at this node
+	counted := int64(0)
+	if !n.boxes.IsNil() {
+		boxesSlc := n.boxes.Value()
+		for i := range boxesSlc {
+			if view.overlaps(boxesSlc[i].view) {
+				counted++
+			}
+		}
+	}
+
 	// count individual leaf elements
 	if n.isLeaf {
-		counted := int64(0)
-		for i := range n.ps {
-			p := &n.ps[i]
-			if !p.isEmpty() && view.containsPoint(p.x, p.y) {
-				counted += int64(len(p.list.Value()))
+		for _, ps := range [2][]point[T]{n.ps[:], n.overflowPoints()} {
+			for i := range ps {
+				p := &ps[i]
+				if !p.isEmpty() && view.containsPoint(p.x, p.y) {
+					counted += int64(len(p.list.Value()))
+				}
 			}
 		}
 		return counted
 	}
 
 	// Collect the count of the subtrees
-	counted := int64(0)
 	for _, r := range n.children {
 		st := r.Value()
 		if view.overlaps(st.view) {
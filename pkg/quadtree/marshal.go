@@ -0,0 +1,355 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+package quadtree
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"unsafe"
+
+	"github.com/fmstephe/memorymanager/offheap"
+)
+
+const marshalMagic = "quadtree"
+
+const marshalVersion = uint8(2)
+
+// Writes the entire tree, including its node structure and every stored
+// element, to w. The tree can be rebuilt by calling Unmarshal.
+//
+// Elements are written as their raw in-memory bytes. Because elements can't
+// contain pointers this is safe, but it means that the written tree can only
+// be read back on a machine with the same architecture, i.e. the same
+// endianness and word size, and with exactly the same type T.
+//
+// It is safe to call Marshal while other goroutines are surveying the tree.
+func (r *Tree[T]) Marshal(w io.Writer) error {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	enc := &encoder{w: bufio.NewWriter(w)}
+	enc.writeBytes([]byte(marshalMagic))
+	enc.writeUint8(marshalVersion)
+	enc.writeUint64(uint64(unsafe.Sizeof(*new(T))))
+	enc.writeView(r.view)
+	enc.writeConfig(r.store.config)
+
+	st := r.treeReference.Value()
+	st.marshal(enc)
+
+	if enc.err != nil {
+		return enc.err
+	}
+	return enc.w.Flush()
+}
+
+// Reads a tree previously written by Marshal. The type T must be exactly the
+// same type that was used for the marshalled tree.
+func Unmarshal[T any](rd io.Reader) (*Tree[T], error) {
+	dec := &decoder{r: bufio.NewReader(rd)}
+
+	magic := make([]byte, len(marshalMagic))
+	dec.readBytes(magic)
+	version := dec.readUint8()
+	size := dec.readUint64()
+	view := dec.readView()
+	if dec.err != nil {
+		return nil, dec.err
+	}
+	if string(magic) != marshalMagic {
+		return nil, fmt.Errorf("cannot unmarshal quadtree, bad header %q", magic)
+	}
+	if version != marshalVersion {
+		return nil, fmt.Errorf("cannot unmarshal quadtree, unsupported version %d", version)
+	}
+	if expected := uint64(unsafe.Sizeof(*new(T))); size != expected {
+		return nil, fmt.Errorf("cannot unmarshal quadtree, element size %d does not match %d", size, expected)
+	}
+
+	config := dec.readConfig()
+	if dec.err != nil {
+		return nil, dec.err
+	}
+	if err := config.validate(); err != nil {
+		return nil, fmt.Errorf("cannot unmarshal quadtree, %w", err)
+	}
+
+	store := newTreeStore[T](config)
+	st := unmarshalNode(dec, 0, store)
+	if dec.err != nil {
+		if err := store.nodes.Destroy(); err != nil {
+			return nil, errors.Join(dec.err, err)
+		}
+		return nil, dec.err
+	}
+	return &Tree[T]{
+		store:         store,
+		treeReference: st,
+		view:          view,
+	}, nil
+}
+
+// Writes this node and all of its children to enc.
+func (n *node[T]) marshal(enc *encoder) {
+	enc.writeBool(n.isLeaf)
+	enc.writeView(n.view)
+	enc.writeUint64(uint64(n.cachedCount))
+
+	if n.boxes.IsNil() {
+		enc.writeUint64(0)
+	} else {
+		boxesSlc := n.boxes.Value()
+		enc.writeUint64(uint64(len(boxesSlc)))
+		for i := range boxesSlc {
+			enc.writeView(boxesSlc[i].view)
+			enc.writeBytes(elementBytes(&boxesSlc[i].data, 1))
+		}
+	}
+
+	if n.isLeaf {
+		for i := range n.ps {
+			marshalPoint(enc, &n.ps[i])
+		}
+		overflowSlc := n.overflowPoints()
+		enc.writeUint64(uint64(len(overflowSlc)))
+		for i := range overflowSlc {
+			marshalPoint(enc, &overflowSlc[i])
+		}
+		return
+	}
+
+	for _, r := range n.children {
+		r.Value().marshal(enc)
+	}
+}
+
+// Writes a single point, and its list of elements, to enc.
+func marshalPoint[T any](enc *encoder, p *point[T]) {
+	if p.isEmpty() {
+		enc.writeUint64(0)
+		return
+	}
+	listSlc := p.list.Value()
+	enc.writeUint64(uint64(len(listSlc)))
+	enc.writeFloat64(p.x)
+	enc.writeFloat64(p.y)
+	enc.writeBytes(elementBytes(&listSlc[0], len(listSlc)))
+}
+
+// Reads a node, and all of its children, written by node.marshal(...).
+//
+// The lengths read are not trusted. Each slice is only allocated once all of
+// its values have been read, see readSlice, so corrupt lengths can't allocate
+// more memory than the input actually contains.
+func unmarshalNode[T any](dec *decoder, depth int, store *nodeStore[T]) offheap.RefObject[node[T]] {
+	isLeaf := dec.readBool()
+	view := dec.readView()
+	if dec.err != nil {
+		// Without this check corrupt data would allow us to recurse
+		// forever
+		return offheap.RefObject[node[T]]{}
+	}
+
+	var nodeR offheap.RefObject[node[T]]
+	var n *node[T]
+	if isLeaf {
+		nodeR = store.allocLeaf(view, depth)
+		n = nodeR.Value()
+	} else {
+		nodeR, n = store.allocNode(view, depth)
+	}
+	n.cachedCount = int64(dec.readUint64())
+
+	if boxCount := dec.readInt(); boxCount > 0 {
+		n.boxes = readSlice(dec, store, boxCount, func(b *box[T]) {
+			b.view = dec.readView()
+			dec.readBytes(elementBytes(&b.data, 1))
+		})
+	}
+
+	if isLeaf {
+		for i := range n.ps {
+			if i < store.config.LeafSize {
+				unmarshalPoint(dec, &n.ps[i], store)
+			} else if dec.readInt() != 0 {
+				// Only the first LeafSize locations of a leaf are
+				// ever used
+				dec.fail(fmt.Errorf("cannot unmarshal quadtree, leaf holds more than %d locations", store.config.LeafSize))
+			}
+		}
+		if overflowCount := dec.readInt(); overflowCount > 0 {
+			n.overflow = readSlice(dec, store, overflowCount, func(p *point[T]) {
+				unmarshalPoint(dec, p, store)
+			})
+		}
+		return nodeR
+	}
+
+	for i := range n.children {
+		n.children[i] = unmarshalNode(dec, depth+1, store)
+	}
+	return nodeR
+}
+
+// Reads a single point, written by marshalPoint(...), into p.
+func unmarshalPoint[T any](dec *decoder, p *point[T], store *nodeStore[T]) {
+	listLen := dec.readInt()
+	if listLen == 0 {
+		return
+	}
+	p.x = dec.readFloat64()
+	p.y = dec.readFloat64()
+	p.list = readSlice(dec, store, listLen, func(data *T) {
+		dec.readBytes(elementBytes(data, 1))
+	})
+}
+
+// Reads count values, using read, into a new slice allocated in the store.
+// The values are collected on the heap, and the slice is only allocated once
+// every value has been read. So a corrupt count fails once the input runs out,
+// instead of allocating the memory the count asks for. Returns a nil slice if
+// the values can't be read.
+func readSlice[T, E any](dec *decoder, store *nodeStore[T], count int, read func(*E)) offheap.RefSlice[E] {
+	values := []E{}
+	for range count {
+		var value E
+		read(&value)
+		if dec.err != nil {
+			return offheap.RefSlice[E]{}
+		}
+		values = append(values, value)
+	}
+	return offheap.AllocSliceFromSlice(store.nodes, values)
+}
+
+// Returns the raw bytes of count elements starting at first
+func elementBytes[T any](first *T, count int) []byte {
+	size := int(unsafe.Sizeof(*first))
+	return unsafe.Slice((*byte)(unsafe.Pointer(first)), size*count)
+}
+
+// Writes values to w. Once a write fails every subsequent write is skipped
+// and err holds the first error encountered.
+type encoder struct {
+	w   *bufio.Writer
+	buf [8]byte
+	err error
+}
+
+func (e *encoder) writeBytes(bytes []byte) {
+	if e.err != nil {
+		return
+	}
+	_, e.err = e.w.Write(bytes)
+}
+
+func (e *encoder) writeUint8(value uint8) {
+	e.writeBytes([]byte{value})
+}
+
+func (e *encoder) writeBool(value bool) {
+	if value {
+		e.writeUint8(1)
+	} else {
+		e.writeUint8(0)
+	}
+}
+
+func (e *encoder) writeUint64(value uint64) {
+	binary.LittleEndian.PutUint64(e.buf[:], value)
+	e.writeBytes(e.buf[:])
+}
+
+func (e *encoder) writeFloat64(value float64) {
+	e.writeUint64(math.Float64bits(value))
+}
+
+func (e *encoder) writeConfig(c Config) {
+	e.writeUint64(uint64(c.LeafSize))
+	e.writeUint64(uint64(c.MaxDepth))
+	e.writeFloat64(c.MinViewSize)
+}
+
+func (e *encoder) writeView(v View) {
+	e.writeFloat64(v.lx)
+	e.writeFloat64(v.rx)
+	e.writeFloat64(v.ty)
+	e.writeFloat64(v.by)
+}
+
+// Reads values from r. Once a read fails every subsequent read returns a zero
+// value and err holds the first error encountered.
+type decoder struct {
+	r   *bufio.Reader
+	buf [8]byte
+	err error
+}
+
+func (d *decoder) readBytes(bytes []byte) {
+	if d.err != nil {
+		clear(bytes)
+		return
+	}
+	if _, err := io.ReadFull(d.r, bytes); err != nil {
+		d.err = fmt.Errorf("cannot unmarshal quadtree: %w", err)
+		clear(bytes)
+	}
+}
+
+func (d *decoder) readUint8() uint8 {
+	d.readBytes(d.buf[:1])
+	return d.buf[0]
+}
+
+func (d *decoder) readBool() bool {
+	return d.readUint8() != 0
+}
+
+func (d *decoder) readUint64() uint64 {
+	d.readBytes(d.buf[:])
+	return binary.LittleEndian.Uint64(d.buf[:])
+}
+
+// Records err, unless an earlier error has already been recorded
+func (d *decoder) fail(err error) {
+	if d.err == nil {
+		d.err = err
+	}
+}
+
+// Reads a non-negative length value. Lengths which don't fit into an int are
+// treated as corrupt data.
+func (d *decoder) readInt() int {
+	value := d.readUint64()
+	if value > math.MaxInt32 {
+		d.fail(fmt.Errorf("cannot unmarshal quadtree, bad length %d", value))
+		return 0
+	}
+	return int(value)
+}
+
+func (d *decoder) readFloat64() float64 {
+	return math.Float64frombits(d.readUint64())
+}
+
+func (d *decoder) readConfig() Config {
+	return Config{
+		LeafSize:    d.readInt(),
+		MaxDepth:    d.readInt(),
+		MinViewSize: d.readFloat64(),
+	}
+}
+
+func (d *decoder) readView() View {
+	lx := d.readFloat64()
+	rx := d.readFloat64()
+	ty := d.readFloat64()
+	by := d.readFloat64()
+	return View{lx: lx, rx: rx, ty: ty, by: by}
+}
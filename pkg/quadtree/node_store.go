@@ -9,27 +9,38 @@ import (
 )
 
 type nodeStore[T any] struct {
-	nodes *offheap.Store
+	nodes  *offheap.Store
+	config Config
 }
 
-func newTreeStore[T any]() *nodeStore[T] {
+func newTreeStore[T any](config Config) *nodeStore[T] {
+	if err := config.validate(); err != nil {
+		panic(err)
+	}
 	return &nodeStore[T]{
-		nodes: offheap.New(),
+		nodes:  offheap.New(),
+		config: config,
 	}
 }
 
-func (s *nodeStore[T]) allocNode(view View) (offheap.RefObject[node[T]], *node[T]) {
+func (s *nodeStore[T]) allocNode(view View, depth int) (offheap.RefObject[node[T]], *node[T]) {
 	r := offheap.AllocObject[node[T]](s.nodes)
 	newNode := r.Value()
+	// Offheap allocations aren't zeroed, and node memory may have been
+	// used by a list freed while appending
+	*newNode = node[T]{}
 	newNode.view = view
+	newNode.depth = int32(depth)
 	newNode.isLeaf = false
 	return r, newNode
 }
 
-func (s *nodeStore[T]) allocLeaf(view View) offheap.RefObject[node[T]] {
+func (s *nodeStore[T]) allocLeaf(view View, depth int) offheap.RefObject[node[T]] {
 	r := offheap.AllocObject[node[T]](s.nodes)
 	newLeaf := r.Value()
+	*newLeaf = node[T]{}
 	newLeaf.view = view
+	newLeaf.depth = int32(depth)
 	newLeaf.isLeaf = true
 	return r
 }
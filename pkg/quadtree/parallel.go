@@ -0,0 +1,121 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+package quadtree
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+// The number of subtrees each worker is given to survey, on average, by
+// SurveyParallel. Having more subtrees than workers means that a worker which
+// finishes a small subtree can move on to another, so the work stays balanced
+// when the elements are unevenly spread across the tree.
+const subtreesPerWorker = 4
+
+// Applies fun to every element occurring within view in this tree, like
+// Survey, using up to workers goroutines.
+//
+// The tree is partitioned into subtrees which overlap view, and the subtrees
+// are surveyed concurrently. This speeds up surveys of large views, such as
+// analytical scans over every element in the tree. Surveys of small views,
+// which only touch a few nodes, are better served by Survey.
+//
+// fun is called concurrently by many goroutines and must be safe for
+// concurrent use. Elements are visited in no particular order. If fun returns
+// false the survey stops, but calls to fun already in progress on other
+// goroutines complete, and fun may be called a few more times before every
+// goroutine stops.
+//
+// Like Survey, fun must never call any method of the tree. Panics if workers
+// is less than 1.
+func (r *Tree[T]) SurveyParallel(view View, workers int, fun func(x, y float64, data *T) bool) {
+	if workers < 1 {
+		panic(fmt.Errorf("cannot survey with %d workers, must be at least 1", workers))
+	}
+
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	st := r.treeReference.Value()
+	if workers == 1 {
+		st.survey(view, fun, r.store)
+		return
+	}
+
+	stopped := atomic.Bool{}
+	stoppable := func(x, y float64, data *T) bool {
+		if stopped.Load() {
+			return false
+		}
+		if !fun(x, y, data) {
+			stopped.Store(true)
+			return false
+		}
+		return true
+	}
+
+	subtrees, ok := st.partition(view, workers*subtreesPerWorker, stoppable)
+	if !ok {
+		return
+	}
+
+	next := atomic.Int64{}
+	wg := sync.WaitGroup{}
+	for range min(workers, len(subtrees)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				i := int(next.Add(1) - 1)
+				if i >= len(subtrees) || stopped.Load() {
+					return
+				}
+				subtrees[i].survey(view, stoppable, r.store)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// Splits the subtree rooted at this node into at least target subtrees which
+// overlap view, where possible, to be surveyed independently. The subtree
+// with the most elements is split first, so the subtrees are roughly the same
+// size.
+//
+// When a subtree is split the boxes stored at its root are not part of any of
+// its children, so fun is applied to those boxes here. Returns false if fun
+// returned false.
+func (n *node[T]) partition(view View, target int, fun func(x, y float64, data *T) bool) ([]*node[T], bool) {
+	subtrees := []*node[T]{n}
+	for len(subtrees) < target {
+		// Find the largest subtree which can still be split
+		largest := -1
+		for i, st := range subtrees {
+			if !st.isLeaf && (largest == -1 || st.cachedCount > subtrees[largest].cachedCount) {
+				largest = i
+			}
+		}
+		if largest == -1 {
+			break
+		}
+
+		st := subtrees[largest]
+		if !st.surveyBoxes(view, fun) {
+			return nil, false
+		}
+
+		subtrees[largest] = subtrees[len(subtrees)-1]
+		subtrees = subtrees[:len(subtrees)-1]
+		for _, r := range st.children {
+			child := r.Value()
+			if view.overlaps(child.view) {
+				subtrees = append(subtrees, child)
+			}
+		}
+	}
+	return subtrees, true
+}
@@ -6,12 +6,30 @@ package quadtree
 
 import (
 	"fmt"
+	"sync"
 
 	"github.com/fmstephe/memorymanager/offheap"
 )
 
 // This struct is the exported root of a quad tree
+//
+// A Tree may be shared between goroutines. Any number of goroutines may
+// survey or count the tree at the same time, while another goroutine inserts
+// into the tree. Inserts wait for in progress surveys to complete, and surveys
+// wait for in progress inserts to complete, so surveys always see a consistent
+// tree. Concurrent inserts are safe, but are serialised.
+//
+// Survey functions are called while surveying goroutines hold a read lock on
+// the tree. This means that survey functions must never call any method of
+// the tree, including Count, Survey and Marshal. Inserting into the tree will
+// deadlock. The read lock is not reentrant, so a nested read waits for any
+// insert which is waiting for the survey to complete, and will also deadlock.
+// Survey functions are passed pointers to the stored elements, if more than
+// one goroutine is surveying the tree then mutating elements via these
+// pointers is a data race. Elements can be safely mutated with Update.
 type Tree[T any] struct {
+	// lock protects every node and element in the tree
+	lock          sync.RWMutex
 	store         *nodeStore[T]
 	treeReference offheap.RefObject[node[T]]
 	view          View
@@ -21,7 +39,13 @@ type Tree[T any] struct {
 //
 // A Tree node is initialised and the tree is ready for service.
 func NewTree[T any](view View) *Tree[T] {
-	store := newTreeStore[T]()
+	return NewTreeWithConfig[T](view, DefaultConfig())
+}
+
+// Returns a new, empty, Tree whose leaves are split according to config. If
+// config is invalid this function panics.
+func NewTreeWithConfig[T any](view View, config Config) *Tree[T] {
+	store := newTreeStore[T](config)
 	st := makeNode[T](view, store)
 	return &Tree[T]{
 		store:         store,
@@ -35,20 +59,147 @@ func (r *Tree[T]) Insert(x, y float64, data T) error {
 	if !r.view.containsPoint(x, y) {
 		return fmt.Errorf("cannot insert x(%f) y(%f) into view %s", x, y, r.view)
 	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
 	list := r.store.newSlice(data)
 	st := r.treeReference.Value()
 	st.insert(x, y, list, r.store)
 	return nil
 }
 
+// Inserts data into this tree, occupying the entire extent of view.
+//
+// Surveys will include data if the surveyed region overlaps any part of view.
+// When data is passed to a survey function the x,y coordinates are the centre
+// of view. Circle surveys are exact, but polygon surveys include data if view
+// overlaps the bounding box of the polygon.
+func (r *Tree[T]) InsertBox(view View, data T) error {
+	if !r.view.containsView(view) {
+		return fmt.Errorf("cannot insert view %s into view %s", view, r.view)
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	st := r.treeReference.Value()
+	st.insertBox(box[T]{view: view, data: data}, r.store)
+	return nil
+}
+
+// Applies fn to each element stored at exactly (x,y) in this tree, allowing
+// the elements to be modified in place. Stops early if fn returns false.
+// Returns true if any elements are stored at (x,y), and false otherwise.
+//
+// Only elements inserted with Insert are updated, elements inserted with
+// InsertBox are never updated. Update waits for in progress surveys to
+// complete, like Insert, so it is safe to update elements which other
+// goroutines are surveying. Like survey functions, fn must never call any
+// method of the tree.
+func (r *Tree[T]) Update(x, y float64, fn func(data *T) bool) bool {
+	if !r.view.containsPoint(x, y) {
+		return false
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	st := r.treeReference.Value()
+	p := st.find(x, y)
+	if p == nil {
+		return false
+	}
+	p.survey(func(_, _ float64, data *T) bool {
+		return fn(data)
+	})
+	return true
+}
+
 // Applies fun to every element occurring within view in this tree
 func (r *Tree[T]) Survey(view View, fun func(x, y float64, data *T) bool) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
 	st := r.treeReference.Value()
 	st.survey(view, fun, r.store)
 }
 
+// Applies fun to at most limit elements occurring within view in this tree.
+// Surveying stops early if fun returns false. Returns the number of elements
+// fun was applied to. A negative limit applies fun to every element.
+func (r *Tree[T]) SurveyLimit(view View, limit int, fun func(x, y float64, data *T) bool) int {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	return r.surveyLimit(view, limit, fun)
+}
+
+// Returns a copy of at most limit elements occurring within view in this
+// tree. A negative limit returns every element.
+//
+// The elements are counted, using the tree's cached counts, before they are
+// collected. This means the returned slice is allocated once, at the right
+// size, and surveying stops as soon as limit elements are collected.
+func (r *Tree[T]) Collect(view View, limit int) []T {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	st := r.treeReference.Value()
+	size := st.count(view, r.store)
+	if limit >= 0 {
+		size = min(size, int64(limit))
+	}
+
+	collected := make([]T, 0, size)
+	r.surveyLimit(view, int(size), func(x, y float64, data *T) bool {
+		collected = append(collected, *data)
+		return true
+	})
+	return collected
+}
+
+func (r *Tree[T]) surveyLimit(view View, limit int, fun func(x, y float64, data *T) bool) int {
+	if limit == 0 {
+		return 0
+	}
+
+	surveyed := 0
+	st := r.treeReference.Value()
+	st.survey(view, func(x, y float64, data *T) bool {
+		surveyed++
+		return fun(x, y, data) && surveyed != limit
+	}, r.store)
+	return surveyed
+}
+
+// Applies fun to every element occurring within the circle centred on (x,y)
+// with radius in this tree. Points exactly radius distance from (x,y) are
+// included.
+func (r *Tree[T]) SurveyCircle(x, y, radius float64, fun func(x, y float64, data *T) bool) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	st := r.treeReference.Value()
+	st.survey(newCircle(x, y, radius), fun, r.store)
+}
+
+// Applies fun to every element occurring within the polygon described by
+// points in this tree. The last point is implicitly connected to the first,
+// so there is no need to repeat the first point at the end of points.
+//
+// The polygon must have at least 3 points. Points lying exactly on the edges
+// of the polygon may or may not be included.
+func (r *Tree[T]) SurveyPolygon(points []Point, fun func(x, y float64, data *T) bool) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	st := r.treeReference.Value()
+	st.survey(newPolygon(points), fun, r.store)
+}
+
 // Applies fun to every element occurring within view in this tree
 func (r *Tree[T]) Count(view View) int64 {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
 	st := r.treeReference.Value()
 	return st.count(view, r.store)
 }
@@ -59,6 +210,9 @@ func (r *Tree[T]) View() View {
 }
 
 func (r *Tree[T]) String() string {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
 	st := r.treeReference.Value()
 	return st.String()
 }
@@ -0,0 +1,127 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+package quadtree
+
+import (
+	"fmt"
+	"math"
+)
+
+// A shape is any region which can be used to survey a tree. The tree uses
+// overlaps to decide which subtrees must be visited, and containsPoint to
+// decide which individual points are included in the survey.
+//
+// It is acceptable for overlaps to report true for a view which doesn't
+// actually contain any part of the shape, this will only make surveys slower.
+// It is never acceptable for overlaps to report false for a view which does
+// contain part of the shape.
+type shape interface {
+	overlaps(v View) bool
+	containsPoint(x, y float64) bool
+}
+
+var _ shape = View{}
+var _ shape = circle{}
+var _ shape = polygon{}
+
+// A simple x,y coordinate. Used to describe the vertices of a polygon.
+type Point struct {
+	X float64
+	Y float64
+}
+
+// A circle with its centre at (x,y). All points whose distance from the
+// centre is less than or equal to radius lie inside the circle.
+type circle struct {
+	x      float64
+	y      float64
+	radius float64
+}
+
+func newCircle(x, y, radius float64) circle {
+	if radius < 0 || math.IsNaN(radius) {
+		panic(fmt.Sprintf("Cannot create circle with invalid radius. x : %10.3f y : %10.3f radius : %10.3f", x, y, radius))
+	}
+	return circle{
+		x:      x,
+		y:      y,
+		radius: radius,
+	}
+}
+
+// Indicates whether the point (x,y) lies inside this circle
+func (c circle) containsPoint(x, y float64) bool {
+	dx := x - c.x
+	dy := y - c.y
+	return dx*dx+dy*dy <= c.radius*c.radius
+}
+
+// Indicates whether any part of v lies inside this circle. We find the point
+// in v which is closest to the centre of the circle and test whether that
+// point is inside the circle.
+func (c circle) overlaps(v View) bool {
+	nx := math.Max(v.lx, math.Min(c.x, v.rx))
+	ny := math.Max(v.by, math.Min(c.y, v.ty))
+	return c.containsPoint(nx, ny)
+}
+
+// A simple polygon defined by its vertices. The last vertex is implicitly
+// connected back to the first. Polygons may be concave, but self-intersecting
+// polygons will produce surprising results.
+type polygon struct {
+	points []Point
+	bounds View
+}
+
+func newPolygon(points []Point) polygon {
+	if len(points) < 3 {
+		panic(fmt.Sprintf("Cannot create polygon with fewer than 3 points. points : %v", points))
+	}
+
+	lx, rx := points[0].X, points[0].X
+	ty, by := points[0].Y, points[0].Y
+	for _, p := range points[1:] {
+		lx = math.Min(lx, p.X)
+		rx = math.Max(rx, p.X)
+		ty = math.Max(ty, p.Y)
+		by = math.Min(by, p.Y)
+	}
+
+	return polygon{
+		points: points,
+		bounds: NewView(lx, rx, ty, by),
+	}
+}
+
+// Indicates whether the point (x,y) lies inside this polygon. This uses the
+// even-odd rule, casting a ray rightwards from (x,y) and counting the number
+// of edges it crosses.
+func (p polygon) containsPoint(x, y float64) bool {
+	if !p.bounds.containsPoint(x, y) {
+		return false
+	}
+
+	inside := false
+	j := len(p.points) - 1
+	for i := range p.points {
+		pi := p.points[i]
+		pj := p.points[j]
+		if (pi.Y > y) != (pj.Y > y) {
+			crossX := pi.X + (y-pi.Y)*(pj.X-pi.X)/(pj.Y-pi.Y)
+			if x < crossX {
+				inside = !inside
+			}
+		}
+		j = i
+	}
+	return inside
+}
+
+// Indicates whether v overlaps the bounding box of this polygon. This is
+// conservative, some views reported as overlapping won't actually contain any
+// part of the polygon.
+func (p polygon) overlaps(v View) bool {
+	return p.bounds.overlaps(v)
+}
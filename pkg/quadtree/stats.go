@@ -0,0 +1,90 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+package quadtree
+
+import (
+	"github.com/fmstephe/memorymanager/offheap"
+)
+
+// Describes the shape and memory use of a Tree, see Tree.Stats.
+type TreeStats struct {
+	// The number of nodes, both internal nodes and leaves
+	Nodes int
+	// The number of leaf nodes
+	Leaves int
+	// The number of leaves at each depth, LeafDepths[d] is the number of
+	// leaves at depth d. The root of the tree has depth 0.
+	LeafDepths []int
+	// The number of leaves which can't be split, and hold more locations
+	// than their Config's LeafSize
+	OverflowLeaves int
+
+	// The number of elements stored in the tree, both points and boxes
+	Elements int64
+	// The number of distinct point locations stored in the tree
+	Locations int
+	// The number of elements inserted with InsertBox
+	Boxes int
+
+	// The number of bytes mapped from the operating system by the tree's
+	// Store
+	MappedBytes int
+	// The number of bytes used by the tree's live allocations
+	LiveBytes int
+	// The statistics for each size class of the tree's Store. These show
+	// which size classes the tree's memory is spent on, which is useful for
+	// tuning slab sizes.
+	Store offheap.StatsSnapshot
+}
+
+// Returns statistics describing the shape and memory use of this tree. The
+// whole tree is traversed, so this can be slow for very large trees.
+func (r *Tree[T]) Stats() TreeStats {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	stats := TreeStats{}
+	st := r.treeReference.Value()
+	st.stats(&stats)
+
+	stats.Elements = st.cachedCount
+	stats.Store = r.store.nodes.StatsSnapshot()
+	total := stats.Store.Total()
+	stats.MappedBytes = total.MappedBytes
+	stats.LiveBytes = total.LiveBytes
+	return stats
+}
+
+// Adds this node, and all of its children, to stats
+func (n *node[T]) stats(stats *TreeStats) {
+	stats.Nodes++
+	if !n.boxes.IsNil() {
+		stats.Boxes += len(n.boxes.Value())
+	}
+
+	if !n.isLeaf {
+		for _, r := range n.children {
+			r.Value().stats(stats)
+		}
+		return
+	}
+
+	stats.Leaves++
+	for len(stats.LeafDepths) <= int(n.depth) {
+		stats.LeafDepths = append(stats.LeafDepths, 0)
+	}
+	stats.LeafDepths[n.depth]++
+
+	if !n.overflow.IsNil() {
+		stats.OverflowLeaves++
+	}
+	for _, ps := range [2][]point[T]{n.ps[:], n.overflowPoints()} {
+		for i := range ps {
+			if !ps[i].isEmpty() {
+				stats.Locations++
+			}
+		}
+	}
+}
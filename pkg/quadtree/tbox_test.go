@@ -0,0 +1,120 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+package quadtree
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Test that we can insert a single box into the tree and then retrieve it
+func TestOneBox(t *testing.T) {
+	for _, tree := range buildTestTrees() {
+		bv := subView(tree.View())
+		err := tree.InsertBox(bv, -1)
+		assert.NoError(t, err)
+
+		fun, results := SliceSurvey[int]()
+		tree.Survey(tree.View(), fun)
+		assert.Equal(t, []int{-1}, *results)
+
+		// The box is reported at its centre
+		tree.Survey(tree.View(), func(x, y float64, _ *int) bool {
+			assert.Equal(t, bv.lx+(bv.rx-bv.lx)/2, x)
+			assert.Equal(t, bv.by+(bv.ty-bv.by)/2, y)
+			return true
+		})
+
+		assert.Equal(t, int64(1), tree.Count(tree.View()))
+	}
+}
+
+// Show that any insert of a box which is not contained in the view of a tree
+// returns and error
+func TestBadInsertBox(t *testing.T) {
+	v1, v2 := disjoint()
+	tree := NewTree[int](v1)
+	err := tree.InsertBox(v2, -1)
+	assert.Error(t, err)
+
+	// A box which is only partially contained also returns an error
+	err = tree.InsertBox(NewView(v1.lx-1, v1.rx, v1.ty, v1.by), -1)
+	assert.Error(t, err)
+}
+
+// Tests that we can add a large number of random boxes and points to a tree
+// and survey and count them using random views
+func TestScatterBoxes(t *testing.T) {
+	for _, tree := range buildTestTrees() {
+		ps := fillView(tree.View(), 1000)
+		for i, p := range ps {
+			err := tree.Insert(p.x, p.y, i)
+			assert.NoError(t, err)
+		}
+
+		boxes := []View{}
+		for i := range 1000 {
+			bv := subView(tree.View())
+			boxes = append(boxes, bv)
+			err := tree.InsertBox(bv, len(ps)+i)
+			assert.NoError(t, err)
+		}
+
+		assert.Equal(t, int64(len(ps)+len(boxes)), tree.Count(tree.View()))
+
+		for range 100 {
+			sv := subView(tree.View())
+
+			expected := []int{}
+			for i, p := range ps {
+				if sv.containsPoint(p.x, p.y) {
+					expected = append(expected, i)
+				}
+			}
+			for i, bv := range boxes {
+				if sv.overlaps(bv) {
+					expected = append(expected, len(ps)+i)
+				}
+			}
+
+			fun, results := SliceSurvey[int]()
+			tree.Survey(sv, fun)
+			assert.ElementsMatch(t, expected, *results)
+
+			assert.Equal(t, int64(len(expected)), tree.Count(sv))
+		}
+	}
+}
+
+// Tests that circle surveys find exactly the boxes which overlap the circle
+func TestSurveyCircleBoxes(t *testing.T) {
+	for _, tree := range buildTestTrees() {
+		boxes := []View{}
+		for i := range 1000 {
+			bv := subView(tree.View())
+			boxes = append(boxes, bv)
+			err := tree.InsertBox(bv, i)
+			assert.NoError(t, err)
+		}
+
+		for range 10 {
+			x, y := randomPosition(tree.View())
+			radius := testRand.Float64() * (tree.View().rx - tree.View().lx) / 4
+			c := newCircle(x, y, radius)
+
+			expected := []int{}
+			for i, bv := range boxes {
+				if c.overlaps(bv) {
+					expected = append(expected, i)
+				}
+			}
+
+			fun, results := SliceSurvey[int]()
+			tree.SurveyCircle(x, y, radius, fun)
+			assert.ElementsMatch(t, expected, *results)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+package quadtree
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func buildTestPointData(v View, c int) []PointData[int] {
+	ps := fillView(v, c)
+	points := make([]PointData[int], 0, len(ps)*dups)
+	for i, p := range ps {
+		// Include some duplicate locations
+		for j := 0; j < 1+(i%dups); j++ {
+			points = append(points, PointData[int]{X: p.x, Y: p.y, Data: len(points)})
+		}
+	}
+	return points
+}
+
+// Tests that a bulk loaded tree contains exactly the same elements as a tree
+// built by inserting each element individually
+func TestBulkLoad(t *testing.T) {
+	for _, tree := range buildTestTrees() {
+		points := buildTestPointData(tree.View(), 2000)
+		for _, p := range points {
+			err := tree.Insert(p.X, p.Y, p.Data)
+			assert.NoError(t, err)
+		}
+
+		bulkTree, err := BulkLoad(tree.View(), points)
+		assert.NoError(t, err)
+
+		assert.Equal(t, tree.Count(tree.View()), bulkTree.Count(bulkTree.View()))
+
+		for range 100 {
+			sv := subView(tree.View())
+
+			assert.Equal(t, tree.Count(sv), bulkTree.Count(sv))
+
+			fun, expected := SliceSurvey[int]()
+			tree.Survey(sv, fun)
+
+			fun, results := SliceSurvey[int]()
+			bulkTree.Survey(sv, fun)
+
+			assert.ElementsMatch(t, *expected, *results)
+		}
+	}
+}
+
+// Tests that elements sharing a location are surveyed in the order they
+// appeared in points
+func TestBulkLoad_DuplicateOrder(t *testing.T) {
+	view := NewView(0, 1, 1, 0)
+	points := []PointData[int]{}
+	for i := range LEAF_SIZE * 4 {
+		points = append(points, PointData[int]{X: 0.5, Y: 0.5, Data: i})
+		points = append(points, PointData[int]{X: 0.25, Y: 0.25, Data: -i})
+	}
+
+	tree, err := BulkLoad(view, points)
+	assert.NoError(t, err)
+
+	fun, results := SliceSurvey[int]()
+	tree.Survey(NewView(0.5, 0.5, 0.5, 0.5), fun)
+
+	expected := []int{}
+	for i := range LEAF_SIZE * 4 {
+		expected = append(expected, i)
+	}
+	assert.Equal(t, expected, *results)
+}
+
+// Show that bulk loading any point which is not contained in the view returns
+// an error
+func TestBulkLoad_BadPoint(t *testing.T) {
+	v1, v2 := disjoint()
+	points := []PointData[int]{}
+	for _, p := range fillView(v1, 100) {
+		points = append(points, PointData[int]{X: p.x, Y: p.y})
+	}
+	x, y := randomPosition(v2)
+	points = append(points, PointData[int]{X: x, Y: y})
+
+	_, err := BulkLoad(v1, points)
+	assert.Error(t, err)
+}
+
+// Show that we can bulk load an empty tree
+func TestBulkLoad_Empty(t *testing.T) {
+	view := NewView(0, 1, 1, 0)
+	tree, err := BulkLoad[int](view, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), tree.Count(view))
+
+	// The empty tree is ready for service
+	assert.NoError(t, tree.Insert(0.5, 0.5, 1))
+	assert.Equal(t, int64(1), tree.Count(view))
+}
+
+func BenchmarkInsert(b *testing.B) {
+	view := NewLongLatView()
+	points := buildTestPointData(view, 100_000)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for range b.N {
+		tree := NewTree[int](view)
+		for _, p := range points {
+			tree.Insert(p.X, p.Y, p.Data)
+		}
+	}
+}
+
+func BenchmarkBulkLoad(b *testing.B) {
+	view := NewLongLatView()
+	points := buildTestPointData(view, 100_000)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for range b.N {
+		BulkLoad(view, points)
+	}
+}
@@ -0,0 +1,146 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+package quadtree
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testConfigs() []Config {
+	return []Config{
+		DefaultConfig(),
+		{LeafSize: 1},
+		{LeafSize: 4, MaxDepth: 2},
+		{LeafSize: LEAF_SIZE, MaxDepth: 1},
+		{LeafSize: 8, MinViewSize: 1},
+	}
+}
+
+// Returns the depth of the deepest leaf under n
+func treeDepth[T any](n *node[T]) int {
+	if n.isLeaf {
+		return int(n.depth)
+	}
+	deepest := 0
+	for _, r := range n.children {
+		deepest = max(deepest, treeDepth(r.Value()))
+	}
+	return deepest
+}
+
+// Returns the smallest width, or height, of any leaf under n
+func smallestLeaf[T any](n *node[T]) float64 {
+	if n.isLeaf {
+		return min(n.view.rx-n.view.lx, n.view.ty-n.view.by)
+	}
+	smallest := n.view.rx - n.view.lx
+	for _, r := range n.children {
+		smallest = min(smallest, smallestLeaf(r.Value()))
+	}
+	return smallest
+}
+
+// Show that invalid configs are rejected
+func TestConfig_Invalid(t *testing.T) {
+	view := NewView(0, 10, 10, 0)
+	assert.Panics(t, func() { NewTreeWithConfig[int](view, Config{}) })
+	assert.Panics(t, func() { NewTreeWithConfig[int](view, Config{LeafSize: LEAF_SIZE + 1}) })
+	assert.Panics(t, func() { NewTreeWithConfig[int](view, Config{LeafSize: 1, MaxDepth: -1}) })
+	assert.Panics(t, func() { NewTreeWithConfig[int](view, Config{LeafSize: 1, MinViewSize: -1}) })
+}
+
+// Show that a tree with any config contains exactly the elements inserted into
+// it, and that bulk loading with the same config gives the same elements
+func TestConfig_InsertSurvey(t *testing.T) {
+	view := NewView(0, 10, 10, 0)
+	for _, config := range testConfigs() {
+		tree := NewTreeWithConfig[int](view, config)
+		points := buildTestPointData(view, 2000)
+		for _, p := range points {
+			require.NoError(t, tree.Insert(p.X, p.Y, p.Data))
+		}
+
+		bulkTree, err := BulkLoadWithConfig(view, config, points)
+		require.NoError(t, err)
+
+		assert.Equal(t, int64(len(points)), tree.Count(view))
+		assert.Equal(t, int64(len(points)), bulkTree.Count(view))
+
+		for range 100 {
+			sv := subView(view)
+
+			expected := []int{}
+			for _, p := range points {
+				if sv.containsPoint(p.X, p.Y) {
+					expected = append(expected, p.Data)
+				}
+			}
+
+			assert.ElementsMatch(t, expected, tree.Collect(sv, -1))
+			assert.ElementsMatch(t, expected, bulkTree.Collect(sv, -1))
+			assert.Equal(t, int64(len(expected)), tree.Count(sv))
+			assert.Equal(t, int64(len(expected)), bulkTree.Count(sv))
+		}
+	}
+}
+
+// Show that MaxDepth limits the depth of the tree
+func TestConfig_MaxDepth(t *testing.T) {
+	view := NewView(0, 10, 10, 0)
+	config := Config{LeafSize: 4, MaxDepth: 3}
+	tree := NewTreeWithConfig[int](view, config)
+	for i, p := range fillView(view, 5000) {
+		require.NoError(t, tree.Insert(p.x, p.y, i))
+	}
+
+	assert.Equal(t, 3, treeDepth(tree.treeReference.Value()))
+	assert.Equal(t, int64(5000), tree.Count(view))
+}
+
+// Show that MinViewSize stops clustered locations from producing a deep tree
+func TestConfig_MinViewSize(t *testing.T) {
+	view := NewView(0, 1000, 1000, 0)
+	cluster := NewView(500, 500.001, 500.001, 500)
+
+	unlimited := NewTree[int](view)
+	limited := NewTreeWithConfig[int](view, Config{LeafSize: LEAF_SIZE, MinViewSize: 10})
+	for i, p := range fillView(cluster, 1000) {
+		require.NoError(t, unlimited.Insert(p.x, p.y, i))
+		require.NoError(t, limited.Insert(p.x, p.y, i))
+	}
+
+	assert.Less(t, smallestLeaf(unlimited.treeReference.Value()), 0.001)
+	assert.GreaterOrEqual(t, smallestLeaf(limited.treeReference.Value()), 10.0)
+	assert.Less(t, treeDepth(limited.treeReference.Value()), treeDepth(unlimited.treeReference.Value()))
+	assert.ElementsMatch(t, unlimited.Collect(view, -1), limited.Collect(view, -1))
+}
+
+// Show that the config, and any overflow points, survive marshalling
+func TestConfig_MarshalUnmarshal(t *testing.T) {
+	view := NewView(0, 10, 10, 0)
+	config := Config{LeafSize: 2, MaxDepth: 2, MinViewSize: 0.5}
+	tree := NewTreeWithConfig[int](view, config)
+	for _, p := range buildTestPointData(view, 500) {
+		require.NoError(t, tree.Insert(p.X, p.Y, p.Data))
+	}
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, tree.Marshal(buf))
+	readTree, err := Unmarshal[int](buf)
+	require.NoError(t, err)
+
+	assert.Equal(t, config, readTree.store.config)
+	assert.Equal(t, surveyWithLocations(tree, view), surveyWithLocations(readTree, view))
+
+	// New inserts into the unmarshalled tree respect the config
+	for i, p := range fillView(view, 500) {
+		require.NoError(t, readTree.Insert(p.x, p.y, i))
+	}
+	assert.Equal(t, 2, treeDepth(readTree.treeReference.Value()))
+}
@@ -0,0 +1,164 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+package quadtree
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type marshalTestData struct {
+	id    int64
+	flag  bool
+	value float32
+}
+
+// Tests that an unmarshalled tree contains exactly the same elements, at the
+// same locations, as the tree which was marshalled
+func TestMarshalUnmarshal(t *testing.T) {
+	for _, view := range []View{
+		NewView(0, 10, 10, 0),
+		NewView(-1e10, 1e10, 500.00000001, -500.00000001),
+	} {
+		tree := NewTree[marshalTestData](view)
+		for i, p := range fillView(view, 1000) {
+			for j := 0; j < 1+(i%dups); j++ {
+				err := tree.Insert(p.x, p.y, marshalTestData{id: int64(i), flag: j%2 == 0, value: float32(j)})
+				require.NoError(t, err)
+			}
+		}
+		for i := range 100 {
+			err := tree.InsertBox(subView(view), marshalTestData{id: int64(-i)})
+			require.NoError(t, err)
+		}
+
+		buf := &bytes.Buffer{}
+		require.NoError(t, tree.Marshal(buf))
+
+		readTree, err := Unmarshal[marshalTestData](buf)
+		require.NoError(t, err)
+		assert.Equal(t, tree.View(), readTree.View())
+
+		for range 100 {
+			sv := subView(view)
+			assert.Equal(t, tree.Count(sv), readTree.Count(sv))
+			assert.Equal(t, surveyWithLocations(tree, sv), surveyWithLocations(readTree, sv))
+		}
+
+		// The unmarshalled tree is ready for service
+		x, y := randomPosition(view)
+		assert.NoError(t, readTree.Insert(x, y, marshalTestData{}))
+		assert.Equal(t, tree.Count(view)+1, readTree.Count(view))
+	}
+}
+
+// Show that we can marshal and unmarshal an empty tree
+func TestMarshalUnmarshal_Empty(t *testing.T) {
+	tree := NewTree[int](NewLongLatView())
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, tree.Marshal(buf))
+
+	readTree, err := Unmarshal[int](buf)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), readTree.Count(readTree.View()))
+}
+
+// Show that unmarshalling with the wrong type returns an error
+func TestUnmarshal_WrongType(t *testing.T) {
+	tree := NewTree[int64](NewLongLatView())
+	require.NoError(t, tree.Insert(1, 1, 1))
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, tree.Marshal(buf))
+
+	_, err := Unmarshal[int32](buf)
+	assert.Error(t, err)
+}
+
+// Show that unmarshalling bad data returns an error
+func TestUnmarshal_BadData(t *testing.T) {
+	tree := NewTree[int](NewLongLatView())
+	for i, p := range fillView(tree.View(), 100) {
+		require.NoError(t, tree.Insert(p.x, p.y, i))
+	}
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, tree.Marshal(buf))
+	data := buf.Bytes()
+
+	// Bad header
+	badHeader := bytes.Clone(data)
+	badHeader[0]++
+	_, err := Unmarshal[int](bytes.NewReader(badHeader))
+	assert.Error(t, err)
+
+	// Truncated data
+	for _, size := range []int{0, 1, len(data) / 2, len(data) - 1} {
+		_, err := Unmarshal[int](bytes.NewReader(data[:size]))
+		assert.Error(t, err)
+	}
+}
+
+// Show that corrupt lengths return an error, rather than allocating the
+// memory they ask for
+func TestUnmarshal_CorruptLengths(t *testing.T) {
+	tree := NewTreeWithConfig[int](NewLongLatView(), Config{LeafSize: 2})
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, tree.Marshal(buf))
+	data := buf.Bytes()
+
+	// The header is the magic, version, element size, view and config. The
+	// root node follows, with its isLeaf flag, view, count and box count,
+	// and then its first child, a leaf.
+	header := len(marshalMagic) + 1 + 8 + 32 + 24
+	leaf := header + 1 + 32 + 8 + 8
+	boxes := leaf + 1 + 32 + 8
+	points := boxes + 8
+	overflow := points + LEAF_SIZE*8
+
+	for _, tc := range []struct {
+		name   string
+		offset int
+		length uint64
+	}{
+		{"boxes", boxes, math.MaxInt32},
+		{"point", points, math.MaxInt32},
+		{"point beyond leaf size", points + 2*8, 1},
+		{"overflow", overflow, 1 << 30},
+		{"too large", overflow, math.MaxUint64},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			corrupt := bytes.Clone(data)
+			binary.LittleEndian.PutUint64(corrupt[tc.offset:], tc.length)
+			_, err := Unmarshal[int](bytes.NewReader(corrupt))
+			assert.Error(t, err)
+		})
+	}
+
+	// The uncorrupted data is read successfully
+	_, err := Unmarshal[int](bytes.NewReader(data))
+	assert.NoError(t, err)
+}
+
+type locatedData[T any] struct {
+	x, y float64
+	data T
+}
+
+func surveyWithLocations[T any](tree *Tree[T], view View) []locatedData[T] {
+	results := []locatedData[T]{}
+	tree.Survey(view, func(x, y float64, data *T) bool {
+		results = append(results, locatedData[T]{x: x, y: y, data: *data})
+		return true
+	})
+	return results
+}
@@ -0,0 +1,89 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+package quadtree
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Returns a survey function which is safe for concurrent use, and the
+// elements it collects
+func syncSliceSurvey[K any]() (fun func(x, y float64, e *K) bool, colP *[]K) {
+	lock := sync.Mutex{}
+	col := []K{}
+	colP = &col
+	fun = func(x, y float64, e *K) bool {
+		lock.Lock()
+		defer lock.Unlock()
+		*colP = append(*colP, *e)
+		return true
+	}
+	return fun, colP
+}
+
+// Demonstrate that a parallel survey finds exactly the same points and boxes
+// as a survey, for any number of workers
+// This test should be run with -race
+func TestSurveyParallel(t *testing.T) {
+	for _, tree := range buildTestTrees() {
+		ps := fillView(tree.View(), 1000)
+		for i, p := range ps {
+			err := tree.Insert(p.x, p.y, i)
+			assert.NoError(t, err)
+		}
+		for i := range 200 {
+			err := tree.InsertBox(subView(tree.View()), len(ps)+i)
+			assert.NoError(t, err)
+		}
+
+		views := []View{tree.View()}
+		for range 20 {
+			views = append(views, subView(tree.View()))
+		}
+
+		for _, sv := range views {
+			fun, expected := SliceSurvey[int]()
+			tree.Survey(sv, fun)
+
+			for _, workers := range []int{1, 2, 3, 8, 64} {
+				fun, results := syncSliceSurvey[int]()
+				tree.SurveyParallel(sv, workers, fun)
+				assert.ElementsMatch(t, *expected, *results)
+			}
+		}
+	}
+}
+
+// Show that a parallel survey stops early when the survey function returns
+// false
+func TestSurveyParallel_Stop(t *testing.T) {
+	tree := NewTree[int](NewView(0, 1, 1, 0))
+	for i, p := range fillView(tree.View(), 10_000) {
+		err := tree.Insert(p.x, p.y, i)
+		assert.NoError(t, err)
+	}
+
+	surveyed := atomic.Int64{}
+	tree.SurveyParallel(tree.View(), 4, func(_, _ float64, _ *int) bool {
+		return surveyed.Add(1) < 10
+	})
+	// Workers which were already calling the survey function finish their
+	// call, but the survey stops long before every element is visited
+	assert.GreaterOrEqual(t, surveyed.Load(), int64(10))
+	assert.Less(t, surveyed.Load(), int64(100))
+
+	// An empty tree, and a view which misses the tree, visit nothing
+	empty := NewTree[int](NewView(0, 1, 1, 0))
+	fun, results := syncSliceSurvey[int]()
+	empty.SurveyParallel(empty.View(), 4, fun)
+	tree.SurveyParallel(NewView(2, 3, 3, 2), 4, fun)
+	assert.Empty(t, *results)
+
+	assert.Panics(t, func() { tree.SurveyParallel(tree.View(), 0, fun) })
+}
@@ -0,0 +1,147 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+package quadtree
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const readers = 4
+
+// Demonstrate that a single goroutine can insert into a tree while many
+// goroutines survey and count that tree. Each reader checks that the number of
+// elements it sees never decreases, and that the survey agrees with the count
+// taken before it.
+// This test should be run with -race
+func TestInsertWhileSurveying_Race(t *testing.T) {
+	tree := NewTree[int](NewView(0, 1, 1, 0))
+	ps := fillView(tree.View(), 1000)
+
+	barrier := sync.WaitGroup{}
+	barrier.Add(1)
+
+	done := atomic.Bool{}
+
+	complete := sync.WaitGroup{}
+	for range readers {
+		complete.Add(1)
+		go func() {
+			defer complete.Done()
+			barrier.Wait()
+
+			lastCount := int64(0)
+			for !done.Load() {
+				count := tree.Count(tree.View())
+				assert.GreaterOrEqual(t, count, lastCount)
+				lastCount = count
+
+				surveyed := int64(0)
+				tree.Survey(tree.View(), func(_, _ float64, data *int) bool {
+					assert.GreaterOrEqual(t, *data, 0)
+					surveyed++
+					return true
+				})
+				assert.GreaterOrEqual(t, surveyed, count)
+			}
+		}()
+	}
+
+	barrier.Done()
+
+	for i, p := range ps {
+		err := tree.Insert(p.x, p.y, i)
+		assert.NoError(t, err)
+	}
+	done.Store(true)
+
+	complete.Wait()
+
+	assert.Equal(t, int64(len(ps)), tree.Count(tree.View()))
+}
+
+// Demonstrate that elements can be updated while many goroutines survey the
+// tree. Each reader checks that it never sees a partially updated point.
+// This test should be run with -race
+func TestUpdateWhileSurveying_Race(t *testing.T) {
+	tree := NewTree[int](NewView(0, 1, 1, 0))
+	ps := fillView(tree.View(), 100)
+	for _, p := range ps {
+		for range dups {
+			assert.NoError(t, tree.Insert(p.x, p.y, 0))
+		}
+	}
+
+	done := atomic.Bool{}
+	complete := sync.WaitGroup{}
+	for range readers {
+		complete.Add(1)
+		go func() {
+			defer complete.Done()
+			for !done.Load() {
+				tree.SurveyLimit(tree.View(), -1, func(_, _ float64, data *int) bool {
+					assert.GreaterOrEqual(t, *data, 0)
+					return true
+				})
+				for _, p := range ps {
+					values := map[int]bool{}
+					tree.Survey(NewView(p.x, p.x, p.y, p.y), func(_, _ float64, data *int) bool {
+						values[*data] = true
+						return true
+					})
+					assert.Len(t, values, 1)
+				}
+			}
+		}()
+	}
+
+	for round := 1; round <= 10; round++ {
+		for _, p := range ps {
+			assert.True(t, tree.Update(p.x, p.y, func(data *int) bool {
+				*data = round
+				return true
+			}))
+		}
+	}
+	done.Store(true)
+
+	complete.Wait()
+}
+
+// Demonstrate why survey functions must never call any method of the tree.
+// An insert which queues for the lock during a survey prevents any new read
+// lock from being taken, so a nested Count or Survey would wait for the
+// insert, which waits for the survey. Once the survey returns the queued
+// insert completes.
+// This test should be run with -race
+func TestInsertQueuedDuringSurvey_Race(t *testing.T) {
+	tree := NewTree[int](NewView(0, 1, 1, 0))
+	assert.NoError(t, tree.Insert(0.5, 0.5, 1))
+
+	inserted := make(chan struct{})
+	surveyed := 0
+	tree.Survey(tree.View(), func(_, _ float64, _ *int) bool {
+		go func() {
+			defer close(inserted)
+			assert.NoError(t, tree.Insert(0.25, 0.25, 2))
+		}()
+		// Wait until the insert is queued, after which a nested read
+		// lock can't be taken
+		for tree.lock.TryRLock() {
+			tree.lock.RUnlock()
+			runtime.Gosched()
+		}
+		surveyed++
+		return true
+	})
+	<-inserted
+
+	assert.Equal(t, 1, surveyed)
+	assert.Equal(t, int64(2), tree.Count(tree.View()))
+}
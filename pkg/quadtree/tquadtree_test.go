@@ -228,3 +228,136 @@ func subView(v View) View {
 	ty := testRand.Float64()*(v.ty-by) + by
 	return NewView(lx, rx, ty, by)
 }
+
+// Show that Collect returns at most limit elements, and every element when
+// limit is negative
+func TestCollect(t *testing.T) {
+	for _, tree := range buildTestTrees() {
+		ps := fillView(tree.View(), 100)
+		for i, p := range ps {
+			assert.NoError(t, tree.Insert(p.x, p.y, i))
+		}
+
+		all := tree.Collect(tree.View(), -1)
+		assert.Len(t, all, 100)
+		assert.ElementsMatch(t, all, tree.Collect(tree.View(), 1000))
+		assert.Empty(t, tree.Collect(tree.View(), 0))
+
+		limited := tree.Collect(tree.View(), 10)
+		assert.Len(t, limited, 10)
+		assert.Subset(t, all, limited)
+
+		// Only the elements within the collected view are returned
+		fun, expected := SliceSurvey[int]()
+		tv := tree.View()
+		view := NewView(tv.lx, (tv.lx+tv.rx)/2, tv.ty, tv.by)
+		tree.Survey(view, fun)
+		assert.ElementsMatch(t, *expected, tree.Collect(view, -1))
+	}
+}
+
+// Show that SurveyLimit stops after limit elements, or when the survey
+// function returns false
+func TestSurveyLimit(t *testing.T) {
+	tree := NewTree[int](NewView(0, 10, 10, 0))
+	for i, p := range fillView(tree.View(), 100) {
+		assert.NoError(t, tree.Insert(p.x, p.y, i))
+	}
+
+	visited := 0
+	count := func(x, y float64, data *int) bool {
+		visited++
+		return true
+	}
+	assert.Equal(t, 100, tree.SurveyLimit(tree.View(), -1, count))
+	assert.Equal(t, 100, visited)
+
+	visited = 0
+	assert.Equal(t, 25, tree.SurveyLimit(tree.View(), 25, count))
+	assert.Equal(t, 25, visited)
+
+	visited = 0
+	assert.Equal(t, 0, tree.SurveyLimit(tree.View(), 0, count))
+	assert.Equal(t, 0, visited)
+
+	visited = 0
+	stop := func(x, y float64, data *int) bool {
+		visited++
+		return visited < 5
+	}
+	assert.Equal(t, 5, tree.SurveyLimit(tree.View(), 25, stop))
+	assert.Equal(t, 5, visited)
+}
+
+// Demonstrate that Update modifies every element stored at exactly the given
+// point, and nothing else, for trees which split and trees with overflowing
+// leaves
+func TestUpdate(t *testing.T) {
+	for _, config := range testConfigs() {
+		tree := NewTreeWithConfig[int](NewView(0, 10, 10, 0), config)
+		ps := fillView(tree.View(), 200)
+		for i, p := range ps {
+			// Each point stores dups elements
+			for range dups {
+				assert.NoError(t, tree.Insert(p.x, p.y, i))
+			}
+		}
+		assert.NoError(t, tree.InsertBox(NewView(1, 3, 3, 1), -1))
+
+		for i, p := range ps {
+			if i%2 == 0 {
+				updated := 0
+				assert.True(t, tree.Update(p.x, p.y, func(data *int) bool {
+					*data = -(*data + 1000)
+					updated++
+					return true
+				}))
+				assert.Equal(t, dups, updated)
+			}
+		}
+
+		fun, results := SliceSurvey[int]()
+		tree.Survey(tree.View(), fun)
+		assert.Len(t, *results, len(ps)*dups+1)
+		counts := map[int]int{}
+		for _, data := range *results {
+			counts[data]++
+		}
+		for i := range ps {
+			if i%2 == 0 {
+				assert.Equal(t, dups, counts[-(i+1000)])
+				assert.Zero(t, counts[i])
+			} else {
+				assert.Equal(t, dups, counts[i])
+			}
+		}
+		// The box is never updated
+		assert.Equal(t, 1, counts[-1])
+	}
+}
+
+// Show that Update stops early when fn returns false, and reports whether
+// any elements were stored at the point
+func TestUpdate_Missing(t *testing.T) {
+	tree := NewTree[int](NewView(0, 10, 10, 0))
+	assert.NoError(t, tree.Insert(5, 5, 1))
+	assert.NoError(t, tree.Insert(5, 5, 2))
+	assert.NoError(t, tree.Insert(5, 5, 3))
+	// The centre of this box is (2,2)
+	assert.NoError(t, tree.InsertBox(NewView(1, 3, 3, 1), 4))
+
+	updated := 0
+	assert.True(t, tree.Update(5, 5, func(data *int) bool {
+		updated++
+		return false
+	}))
+	assert.Equal(t, 1, updated)
+
+	fail := func(*int) bool {
+		assert.Fail(t, "no element should be updated")
+		return true
+	}
+	assert.False(t, tree.Update(5, 5.0001, fail))
+	assert.False(t, tree.Update(2, 2, fail))
+	assert.False(t, tree.Update(11, 5, fail))
+}
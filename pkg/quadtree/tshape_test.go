@@ -0,0 +1,139 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+package quadtree
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCircleContainsPoint(t *testing.T) {
+	c := newCircle(10, 10, 5)
+
+	for _, p := range []tpoint{{10, 10}, {15, 10}, {5, 10}, {10, 15}, {10, 5}, {13, 13}} {
+		assert.True(t, c.containsPoint(p.x, p.y), "circle %#v should contain point %#v", c, p)
+	}
+	for _, p := range []tpoint{{15.1, 10}, {4.9, 10}, {10, 15.1}, {10, 4.9}, {14, 14}} {
+		assert.False(t, c.containsPoint(p.x, p.y), "circle %#v should not contain point %#v", c, p)
+	}
+}
+
+func TestCircleOverlaps(t *testing.T) {
+	c := newCircle(10, 10, 5)
+
+	for _, v := range []View{
+		// Contains the centre
+		NewView(9, 11, 11, 9),
+		// Contains the entire circle
+		NewView(0, 20, 20, 0),
+		// Touches the edge of the circle
+		NewView(15, 20, 20, 0),
+		// Cuts through the side of the circle
+		NewView(0, 6, 20, 0),
+	} {
+		assert.True(t, c.overlaps(v), "circle %#v should overlap view %s", c, v)
+	}
+
+	for _, v := range []View{
+		// Completely to the right of the circle
+		NewView(15.1, 20, 20, 0),
+		// Inside the bounding box of the circle, but outside the circle
+		NewView(14, 15, 15, 14),
+	} {
+		assert.False(t, c.overlaps(v), "circle %#v should not overlap view %s", c, v)
+	}
+}
+
+func TestIllegalCircle(t *testing.T) {
+	require.Panics(t, func() {
+		newCircle(0, 0, -1)
+	})
+}
+
+func TestPolygonContainsPoint(t *testing.T) {
+	// A concave 'L' shaped polygon
+	p := newPolygon([]Point{{0, 0}, {10, 0}, {10, 5}, {5, 5}, {5, 10}, {0, 10}})
+
+	for _, tp := range []tpoint{{1, 1}, {9, 1}, {9, 4}, {1, 9}, {4, 9}, {4, 4}} {
+		assert.True(t, p.containsPoint(tp.x, tp.y), "polygon %#v should contain point %#v", p, tp)
+	}
+	for _, tp := range []tpoint{{6, 6}, {9, 9}, {-1, 1}, {11, 1}, {1, 11}, {1, -1}} {
+		assert.False(t, p.containsPoint(tp.x, tp.y), "polygon %#v should not contain point %#v", p, tp)
+	}
+}
+
+func TestIllegalPolygon(t *testing.T) {
+	require.Panics(t, func() {
+		newPolygon([]Point{})
+	})
+	require.Panics(t, func() {
+		newPolygon([]Point{{0, 0}, {1, 1}})
+	})
+}
+
+// Tests that surveying a circle finds exactly the elements which lie inside
+// that circle
+func TestSurveyCircle(t *testing.T) {
+	testTrees := buildTestTrees()
+	for _, tree := range testTrees {
+		ps := fillView(tree.View(), 1000)
+		for i, p := range ps {
+			err := tree.Insert(p.x, p.y, i)
+			assert.NoError(t, err)
+		}
+
+		for range 10 {
+			x, y := randomPosition(tree.View())
+			radius := testRand.Float64() * (tree.View().rx - tree.View().lx) / 2
+			c := newCircle(x, y, radius)
+
+			expected := []int{}
+			for i, p := range ps {
+				if c.containsPoint(p.x, p.y) {
+					expected = append(expected, i)
+				}
+			}
+
+			fun, results := SliceSurvey[int]()
+			tree.SurveyCircle(x, y, radius, fun)
+			assert.ElementsMatch(t, expected, *results)
+		}
+	}
+}
+
+// Tests that surveying a polygon finds exactly the elements which lie inside
+// that polygon
+func TestSurveyPolygon(t *testing.T) {
+	testTrees := buildTestTrees()
+	for _, tree := range testTrees {
+		ps := fillView(tree.View(), 1000)
+		for i, p := range ps {
+			err := tree.Insert(p.x, p.y, i)
+			assert.NoError(t, err)
+		}
+
+		for range 10 {
+			points := []Point{}
+			for range 5 {
+				x, y := randomPosition(tree.View())
+				points = append(points, Point{X: x, Y: y})
+			}
+			poly := newPolygon(points)
+
+			expected := []int{}
+			for i, p := range ps {
+				if poly.containsPoint(p.x, p.y) {
+					expected = append(expected, i)
+				}
+			}
+
+			fun, results := SliceSurvey[int]()
+			tree.SurveyPolygon(points, fun)
+			assert.ElementsMatch(t, expected, *results)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+package quadtree
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Show that the stats of an empty tree describe the root and its four leaves
+func TestStats_Empty(t *testing.T) {
+	tree := NewTree[int](NewView(0, 10, 10, 0))
+	stats := tree.Stats()
+
+	assert.Equal(t, 5, stats.Nodes)
+	assert.Equal(t, 4, stats.Leaves)
+	assert.Equal(t, []int{0, 4}, stats.LeafDepths)
+	assert.Equal(t, int64(0), stats.Elements)
+	assert.Equal(t, 0, stats.Locations)
+	assert.Equal(t, 0, stats.Boxes)
+	assert.Equal(t, 0, stats.OverflowLeaves)
+	assert.Greater(t, stats.MappedBytes, 0)
+	assert.Greater(t, stats.LiveBytes, 0)
+	assert.Equal(t, stats.LiveBytes, stats.Store.Total().LiveBytes)
+}
+
+// Show that the stats of a populated tree are consistent with its contents
+func TestStats(t *testing.T) {
+	for _, config := range testConfigs() {
+		view := NewView(0, 10, 10, 0)
+		tree := NewTreeWithConfig[int](view, config)
+
+		points := buildTestPointData(view, 1000)
+		for _, p := range points {
+			require.NoError(t, tree.Insert(p.X, p.Y, p.Data))
+		}
+		for i := range 50 {
+			require.NoError(t, tree.InsertBox(subView(view), i))
+		}
+
+		stats := tree.Stats()
+		assert.Equal(t, int64(len(points)+50), stats.Elements)
+		assert.Equal(t, 1000, stats.Locations)
+		assert.Equal(t, 50, stats.Boxes)
+
+		// Every internal node has four children
+		internal := stats.Nodes - stats.Leaves
+		assert.Equal(t, internal*4+1, stats.Nodes)
+
+		leaves := 0
+		for _, count := range stats.LeafDepths {
+			leaves += count
+		}
+		assert.Equal(t, stats.Leaves, leaves)
+		if config.MaxDepth != 0 {
+			assert.LessOrEqual(t, len(stats.LeafDepths)-1, config.MaxDepth)
+		}
+		if config.MaxDepth == 1 {
+			assert.Greater(t, stats.OverflowLeaves, 0)
+		}
+
+		// The live bytes grow as more elements are inserted
+		before := stats.LiveBytes
+		for i, p := range fillView(view, 1000) {
+			require.NoError(t, tree.Insert(p.x, p.y, i))
+		}
+		assert.Greater(t, tree.Stats().LiveBytes, before)
+	}
+}
@@ -45,6 +45,14 @@ func TestNewView(t *testing.T) {
 	}
 }
 
+func TestViewCoordinates(t *testing.T) {
+	v := NewView(-1.5, 2.5, 3.5, -4.5)
+	require.Equal(t, -1.5, v.Left())
+	require.Equal(t, 2.5, v.Right())
+	require.Equal(t, 3.5, v.Top())
+	require.Equal(t, -4.5, v.Bottom())
+}
+
 func TestIllegalView(t *testing.T) {
 	for _, testValue := range []View{
 		{5.5, 5.4, 5.96, 3.45},
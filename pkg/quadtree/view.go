@@ -55,6 +55,26 @@ func NewLongLatView() View {
 	}
 }
 
+// Returns the left most x coordinate of this View
+func (v View) Left() float64 {
+	return v.lx
+}
+
+// Returns the right most x coordinate of this View
+func (v View) Right() float64 {
+	return v.rx
+}
+
+// Returns the top most y coordinate of this View
+func (v View) Top() float64 {
+	return v.ty
+}
+
+// Returns the bottom most y coordinate of this View
+func (v View) Bottom() float64 {
+	return v.by
+}
+
 // Indicates whether this View containsPoint the point (x,y)
 func (v View) containsPoint(x, y float64) bool {
 	return x >= v.lx && x <= v.rx && y >= v.by && y <= v.ty
@@ -0,0 +1,319 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+package rtree
+
+import (
+	"cmp"
+	"math"
+	"slices"
+
+	"github.com/fmstephe/memorymanager/offheap"
+)
+
+// The maximum number of entries in a node before it must be split
+const MAX_ENTRIES = 16
+
+// The minimum number of entries in each node created by a split. The R* tree
+// paper found that 40% of MAX_ENTRIES performs well.
+const MIN_ENTRIES = MAX_ENTRIES * 2 / 5
+
+// node structs make up the body of an rtree.
+// A node is either a leaf node, which contains actual data points.
+//
+// Or an internal node, internal nodes contain between MIN_ENTRIES and
+// MAX_ENTRIES children. For an internal node the bounds of each entry is the
+// smallest rect which contains every point in that child's subtree.
+//
+// Each array has one extra slot, so that a node can briefly hold one more
+// entry than is allowed before it is split.
+type node[T any] struct {
+	// A node is either a leaf, containing actual data, or an internal node
+	// containing subtrees.
+	isLeaf bool
+
+	// The number of entries in use in this node
+	size int
+
+	// This is a count of the number of elements stored under this node. We
+	// cache it to avoid needing to traverse the tree to answer this
+	// question.
+	cachedCount int64
+
+	// Used by both leaf and internal nodes. For a leaf each bounds is the
+	// zero area rect of a point.
+	bounds [MAX_ENTRIES + 1]rect
+
+	// Used if this node is a leaf
+	data [MAX_ENTRIES + 1]T
+
+	// Used if this node is not a leaf
+	children [MAX_ENTRIES + 1]offheap.RefObject[node[T]]
+}
+
+func allocNode[T any](isLeaf bool, store *offheap.Store) (offheap.RefObject[node[T]], *node[T]) {
+	r := offheap.AllocObject[node[T]](store)
+	newNode := r.Value()
+	newNode.isLeaf = isLeaf
+	newNode.size = 0
+	newNode.cachedCount = 0
+	return r, newNode
+}
+
+// Returns the smallest rect containing every entry in this node. Must not be
+// called on an empty node.
+func (n *node[T]) boundingRect() rect {
+	bounds := n.bounds[0]
+	for i := 1; i < n.size; i++ {
+		bounds = bounds.union(n.bounds[i])
+	}
+	return bounds
+}
+
+// Inserts data at (x,y) into this subtree. If this node overflows it is split
+// and the newly allocated sibling node is returned, the caller must add the
+// sibling to its own entries. If no split occurs a nil reference is returned.
+func (n *node[T]) insert(x, y float64, data T, store *offheap.Store) offheap.RefObject[node[T]] {
+	// We are adding an element to this node or one of its children, increment the count
+	n.cachedCount++
+
+	pr := pointRect(x, y)
+
+	if n.isLeaf {
+		n.bounds[n.size] = pr
+		n.data[n.size] = data
+		n.size++
+	} else {
+		idx := n.chooseSubtree(pr)
+		child := n.children[idx].Value()
+		childSibling := child.insert(x, y, data, store)
+		n.bounds[idx] = child.boundingRect()
+
+		if !childSibling.IsNil() {
+			n.bounds[n.size] = childSibling.Value().boundingRect()
+			n.children[n.size] = childSibling
+			n.size++
+		}
+	}
+
+	if n.size > MAX_ENTRIES {
+		return n.split(store)
+	}
+	return offheap.RefObject[node[T]]{}
+}
+
+// Chooses the entry whose subtree pr should be inserted into.
+//
+// Following the R* tree, if the children are leaves we choose the entry whose
+// overlap with its siblings is enlarged least. Otherwise we choose the entry
+// whose area is enlarged least. Ties are resolved by choosing the smallest
+// area.
+func (n *node[T]) chooseSubtree(pr rect) int {
+	childrenAreLeaves := n.children[0].Value().isLeaf
+
+	best := 0
+	bestOverlap := math.Inf(1)
+	bestEnlargement := math.Inf(1)
+	bestArea := math.Inf(1)
+	for i := 0; i < n.size; i++ {
+		enlarged := n.bounds[i].union(pr)
+		area := n.bounds[i].area()
+		enlargement := enlarged.area() - area
+
+		overlap := 0.0
+		if childrenAreLeaves {
+			for j := 0; j < n.size; j++ {
+				if j == i {
+					continue
+				}
+				overlap += enlarged.overlapArea(n.bounds[j]) - n.bounds[i].overlapArea(n.bounds[j])
+			}
+		}
+
+		if cmp.Or(
+			cmp.Compare(overlap, bestOverlap),
+			cmp.Compare(enlargement, bestEnlargement),
+			cmp.Compare(area, bestArea),
+		) < 0 {
+			best = i
+			bestOverlap = overlap
+			bestEnlargement = enlargement
+			bestArea = area
+		}
+	}
+	return best
+}
+
+// Splits an overflowing node in two, using the R* tree split algorithm. This
+// node keeps the first group of entries and a new sibling node is allocated
+// for the second group, the sibling is returned.
+func (n *node[T]) split(store *offheap.Store) offheap.RefObject[node[T]] {
+	order, splitAt := n.chooseSplit()
+
+	old := *n
+	siblingR, sibling := allocNode[T](n.isLeaf, store)
+
+	n.size = 0
+	n.cachedCount = 0
+	for i, idx := range order {
+		target := n
+		if i >= splitAt {
+			target = sibling
+		}
+
+		target.bounds[target.size] = old.bounds[idx]
+		if old.isLeaf {
+			target.data[target.size] = old.data[idx]
+			target.cachedCount++
+		} else {
+			target.children[target.size] = old.children[idx]
+			target.cachedCount += old.children[idx].Value().cachedCount
+		}
+		target.size++
+	}
+
+	return siblingR
+}
+
+// Chooses how the entries of an overflowing node are divided into two groups.
+// The order of entries is returned, entries before splitAt form the first
+// group and the remainder form the second.
+//
+// First we choose the axis to split along, this is the axis where the sum of
+// the margins of every possible distribution is smallest. Then along that
+// axis we choose the distribution with the least overlap between the two
+// groups, ties are resolved by choosing the smallest total area.
+func (n *node[T]) chooseSplit() (order [MAX_ENTRIES + 1]int, splitAt int) {
+	bestMargin := math.Inf(1)
+	var axisOrders [2][MAX_ENTRIES + 1]int
+	for _, axis := range []int{xAxis, yAxis} {
+		orders := [2][MAX_ENTRIES + 1]int{
+			n.sortedEntries(axis, true),
+			n.sortedEntries(axis, false),
+		}
+
+		margin := 0.0
+		for _, o := range orders {
+			for k := MIN_ENTRIES; k <= len(o)-MIN_ENTRIES; k++ {
+				first, second := n.groupBounds(o, k)
+				margin += first.margin() + second.margin()
+			}
+		}
+
+		if margin < bestMargin {
+			bestMargin = margin
+			axisOrders = orders
+		}
+	}
+
+	bestOverlap := math.Inf(1)
+	bestArea := math.Inf(1)
+	for _, o := range axisOrders {
+		for k := MIN_ENTRIES; k <= len(o)-MIN_ENTRIES; k++ {
+			first, second := n.groupBounds(o, k)
+			overlap := first.overlapArea(second)
+			area := first.area() + second.area()
+			if cmp.Or(cmp.Compare(overlap, bestOverlap), cmp.Compare(area, bestArea)) < 0 {
+				bestOverlap = overlap
+				bestArea = area
+				order = o
+				splitAt = k
+			}
+		}
+	}
+
+	return order, splitAt
+}
+
+const (
+	xAxis = iota
+	yAxis
+)
+
+// Returns the indices of this node's entries, sorted by either the lower or
+// upper edge of their bounds along axis.
+func (n *node[T]) sortedEntries(axis int, byLower bool) [MAX_ENTRIES + 1]int {
+	order := [MAX_ENTRIES + 1]int{}
+	for i := range order {
+		order[i] = i
+	}
+
+	key := func(r rect) float64 {
+		switch {
+		case axis == xAxis && byLower:
+			return r.lx
+		case axis == xAxis:
+			return r.rx
+		case byLower:
+			return r.by
+		default:
+			return r.ty
+		}
+	}
+
+	slices.SortFunc(order[:], func(a, b int) int {
+		return cmp.Compare(key(n.bounds[a]), key(n.bounds[b]))
+	})
+	return order
+}
+
+// Returns the bounds of the two groups formed by dividing order at splitAt
+func (n *node[T]) groupBounds(order [MAX_ENTRIES + 1]int, splitAt int) (first, second rect) {
+	first = n.bounds[order[0]]
+	for _, idx := range order[1:splitAt] {
+		first = first.union(n.bounds[idx])
+	}
+
+	second = n.bounds[order[splitAt]]
+	for _, idx := range order[splitAt+1:] {
+		second = second.union(n.bounds[idx])
+	}
+
+	return first, second
+}
+
+// Calls fun on each element in this subtree lying within view
+func (n *node[T]) survey(view rect, fun func(x, y float64, data *T) bool) bool {
+	for i := 0; i < n.size; i++ {
+		if !view.overlaps(n.bounds[i]) {
+			continue
+		}
+
+		if n.isLeaf {
+			if !fun(n.bounds[i].lx, n.bounds[i].ty, &n.data[i]) {
+				return false
+			}
+			continue
+		}
+
+		if !n.children[i].Value().survey(view, fun) {
+			return false
+		}
+	}
+	return true
+}
+
+func (n *node[T]) count(view rect) int64 {
+	counted := int64(0)
+	for i := 0; i < n.size; i++ {
+		if !view.overlaps(n.bounds[i]) {
+			continue
+		}
+
+		if n.isLeaf {
+			counted++
+			continue
+		}
+
+		child := n.children[i].Value()
+		// In the case that the counting view completely covers this
+		// child then we can just quickly use the cached count
+		if view.contains(n.bounds[i]) {
+			counted += child.cachedCount
+		} else {
+			counted += child.count(view)
+		}
+	}
+	return counted
+}
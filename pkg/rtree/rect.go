@@ -0,0 +1,71 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+package rtree
+
+import (
+	"math"
+
+	"github.com/fmstephe/memorymanager/pkg/quadtree"
+)
+
+// A rect is an axis aligned rectangle, with the same layout and invariants as
+// quadtree.View. A rect is used both as the bounding box of a subtree and, with
+// zero area, as the location of an individual point.
+type rect struct {
+	lx float64
+	rx float64
+	ty float64
+	by float64
+}
+
+func pointRect(x, y float64) rect {
+	return rect{lx: x, rx: x, ty: y, by: y}
+}
+
+func viewRect(v quadtree.View) rect {
+	return rect{lx: v.Left(), rx: v.Right(), ty: v.Top(), by: v.Bottom()}
+}
+
+// Returns the smallest rect which contains both r and o
+func (r rect) union(o rect) rect {
+	return rect{
+		lx: math.Min(r.lx, o.lx),
+		rx: math.Max(r.rx, o.rx),
+		ty: math.Max(r.ty, o.ty),
+		by: math.Min(r.by, o.by),
+	}
+}
+
+func (r rect) area() float64 {
+	return (r.rx - r.lx) * (r.ty - r.by)
+}
+
+// Returns half the perimeter of r, the R* tree literature calls this the
+// margin.
+func (r rect) margin() float64 {
+	return (r.rx - r.lx) + (r.ty - r.by)
+}
+
+// Returns the area of the region shared by r and o. If they don't overlap the
+// area is 0.
+func (r rect) overlapArea(o rect) float64 {
+	width := math.Min(r.rx, o.rx) - math.Max(r.lx, o.lx)
+	height := math.Min(r.ty, o.ty) - math.Max(r.by, o.by)
+	if width <= 0 || height <= 0 {
+		return 0
+	}
+	return width * height
+}
+
+// Indicates whether r and o share any points, including points on their
+// borders.
+func (r rect) overlaps(o rect) bool {
+	return r.lx <= o.rx && o.lx <= r.rx && r.by <= o.ty && o.by <= r.ty
+}
+
+// Indicates whether r contains the entirety of o
+func (r rect) contains(o rect) bool {
+	return o.lx >= r.lx && o.rx <= r.rx && o.ty <= r.ty && o.by >= r.by
+}
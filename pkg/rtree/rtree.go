@@ -0,0 +1,100 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+// The rtree package provides a spatial index with the same Insert, Survey and
+// Count API as the quadtree package. Like the quadtree, all of the nodes and
+// elements of the tree are stored offheap.
+//
+// A quadtree always divides space into equal quarters, regardless of the data
+// stored in it. This works well for evenly distributed data, but for highly
+// skewed data, such as city centres with millions of points and rural areas
+// with almost none, a quadtree becomes deep and unbalanced. An rtree groups
+// nearby points into bounding boxes which adapt to the data, and is always
+// balanced.
+//
+// This is an R* tree, using the R* tree algorithms for choosing insertion
+// subtrees and for splitting nodes. Forced reinsertion of entries from
+// overflowing nodes is not performed.
+package rtree
+
+import (
+	"fmt"
+	"math"
+	"sync"
+
+	"github.com/fmstephe/memorymanager/offheap"
+	"github.com/fmstephe/memorymanager/pkg/quadtree"
+)
+
+// This struct is the exported root of an rtree
+//
+// A Tree has the same concurrency guarantees as a quadtree.Tree. Any number of
+// goroutines may survey or count the tree at the same time, while another
+// goroutine inserts into the tree. Survey functions must never call any
+// method of the tree, including Count and Survey. Inserting will deadlock,
+// and because the read lock held while surveying is not reentrant a nested
+// read will deadlock if another goroutine is waiting to insert.
+type Tree[T any] struct {
+	// lock protects every node and element in the tree
+	lock          sync.RWMutex
+	store         *offheap.Store
+	treeReference offheap.RefObject[node[T]]
+}
+
+// Returns a new Tree ready for use as an empty rtree
+//
+// Unlike a quadtree an rtree doesn't have a fixed view, any point can be
+// inserted.
+func NewTree[T any]() *Tree[T] {
+	store := offheap.New()
+	st, _ := allocNode[T](true, store)
+	return &Tree[T]{
+		store:         store,
+		treeReference: st,
+	}
+}
+
+// Inserts data into this tree
+func (r *Tree[T]) Insert(x, y float64, data T) error {
+	if math.IsNaN(x) || math.IsNaN(y) {
+		return fmt.Errorf("cannot insert x(%f) y(%f) into rtree", x, y)
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	st := r.treeReference.Value()
+	sibling := st.insert(x, y, data, r.store)
+	if sibling.IsNil() {
+		return nil
+	}
+
+	// The root was split, the tree grows by one level
+	rootR, root := allocNode[T](false, r.store)
+	root.bounds[0] = st.boundingRect()
+	root.children[0] = r.treeReference
+	root.bounds[1] = sibling.Value().boundingRect()
+	root.children[1] = sibling
+	root.size = 2
+	root.cachedCount = st.cachedCount + sibling.Value().cachedCount
+	r.treeReference = rootR
+	return nil
+}
+
+// Applies fun to every element occurring within view in this tree
+func (r *Tree[T]) Survey(view quadtree.View, fun func(x, y float64, data *T) bool) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	st := r.treeReference.Value()
+	st.survey(viewRect(view), fun)
+}
+
+// Returns the number of elements occurring within view in this tree
+func (r *Tree[T]) Count(view quadtree.View) int64 {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	st := r.treeReference.Value()
+	return st.count(viewRect(view))
+}
@@ -0,0 +1,200 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+package rtree
+
+import (
+	"math"
+	"math/rand"
+	"runtime"
+	"slices"
+	"testing"
+
+	"github.com/fmstephe/memorymanager/pkg/quadtree"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testRand will produce the same random numbers every time
+var testRand = rand.New(rand.NewSource(1))
+
+type tpoint struct {
+	x, y float64
+}
+
+// Show that an empty tree can be surveyed and counted
+func TestEmpty(t *testing.T) {
+	tree := NewTree[int]()
+
+	fun, results := quadtree.SliceSurvey[int]()
+	tree.Survey(quadtree.NewLongLatView(), fun)
+	assert.Empty(t, *results)
+	assert.Equal(t, int64(0), tree.Count(quadtree.NewLongLatView()))
+}
+
+// Show that points with NaN coordinates can't be inserted
+func TestBadInsert(t *testing.T) {
+	tree := NewTree[int]()
+	assert.Error(t, tree.Insert(math.NaN(), 0, 1))
+	assert.Error(t, tree.Insert(0, math.NaN(), 1))
+}
+
+// Tests that we can add a large number of random elements to a tree and
+// survey and count them using random views
+func TestScatter(t *testing.T) {
+	view := quadtree.NewView(-100, 100, 100, -100)
+	ps := []tpoint{}
+	for range 10_000 {
+		ps = append(ps, randomPosition(view))
+	}
+	testScatter(t, view, ps)
+}
+
+// Tests that a heavily skewed dataset, with most points clustered in a tiny
+// region, can be surveyed and counted correctly
+func TestScatterSkewed(t *testing.T) {
+	view := quadtree.NewView(-100, 100, 100, -100)
+	cluster := quadtree.NewView(10, 10.001, 20.001, 20)
+	ps := []tpoint{}
+	for i := range 10_000 {
+		if i%100 == 0 {
+			ps = append(ps, randomPosition(view))
+		} else {
+			ps = append(ps, randomPosition(cluster))
+		}
+	}
+	testScatter(t, view, ps)
+}
+
+// Tests that many elements at exactly the same location can be stored
+func TestDuplicates(t *testing.T) {
+	view := quadtree.NewView(-100, 100, 100, -100)
+	ps := []tpoint{}
+	for range 1000 {
+		ps = append(ps, tpoint{x: 1, y: 1})
+	}
+	testScatter(t, view, ps)
+}
+
+func testScatter(t *testing.T, view quadtree.View, ps []tpoint) {
+	tree := NewTree[int]()
+	for i, p := range ps {
+		require.NoError(t, tree.Insert(p.x, p.y, i))
+	}
+	assert.Equal(t, int64(len(ps)), tree.Count(view))
+	assertInvariants(t, tree)
+
+	for range 100 {
+		sv := subView(view)
+
+		expected := []int{}
+		for i, p := range ps {
+			if viewRect(sv).overlaps(pointRect(p.x, p.y)) {
+				expected = append(expected, i)
+			}
+		}
+
+		fun, results := quadtree.SliceSurvey[int]()
+		tree.Survey(sv, fun)
+		slices.Sort(*results)
+		assert.Equal(t, expected, *results)
+
+		assert.Equal(t, int64(len(expected)), tree.Count(sv))
+	}
+}
+
+// Demonstrate that we can terminate a Survey by having the survey func return false
+func TestLimitedSurvey(t *testing.T) {
+	view := quadtree.NewView(-100, 100, 100, -100)
+	tree := NewTree[int]()
+	for i := range 1000 {
+		p := randomPosition(view)
+		require.NoError(t, tree.Insert(p.x, p.y, i))
+	}
+	for i := range 1000 {
+		fun, results := quadtree.LimitSurvey[int](i)
+		tree.Survey(view, fun)
+		assert.Len(t, *results, i)
+	}
+}
+
+// Walks the entire tree checking that every node has a legal number of
+// entries, that every entry's bounds contains its subtree, that cached counts
+// are correct and that all leaves are at the same depth
+func assertInvariants(t *testing.T, tree *Tree[int]) {
+	root := tree.treeReference.Value()
+	leafDepth := -1
+	var walk func(n *node[int], depth int) (rect, int64)
+	walk = func(n *node[int], depth int) (rect, int64) {
+		assert.LessOrEqual(t, n.size, MAX_ENTRIES)
+		if n != root {
+			assert.GreaterOrEqual(t, n.size, MIN_ENTRIES)
+		}
+
+		if n.isLeaf {
+			if leafDepth == -1 {
+				leafDepth = depth
+			}
+			assert.Equal(t, leafDepth, depth)
+			assert.Equal(t, int64(n.size), n.cachedCount)
+			return n.boundingRect(), int64(n.size)
+		}
+
+		count := int64(0)
+		for i := 0; i < n.size; i++ {
+			childBounds, childCount := walk(n.children[i].Value(), depth+1)
+			assert.Equal(t, childBounds, n.bounds[i])
+			count += childCount
+		}
+		assert.Equal(t, count, n.cachedCount)
+		return n.boundingRect(), count
+	}
+	walk(root, 0)
+}
+
+func randomPosition(v quadtree.View) tpoint {
+	x := testRand.Float64()*(v.Right()-v.Left()) + v.Left()
+	y := testRand.Float64()*(v.Top()-v.Bottom()) + v.Bottom()
+	return tpoint{x: x, y: y}
+}
+
+func subView(v quadtree.View) quadtree.View {
+	lx := testRand.Float64()*(v.Right()-v.Left()) + v.Left()
+	rx := testRand.Float64()*(v.Right()-lx) + lx
+	by := testRand.Float64()*(v.Top()-v.Bottom()) + v.Bottom()
+	ty := testRand.Float64()*(v.Top()-by) + by
+	return quadtree.NewView(lx, rx, ty, by)
+}
+
+// Demonstrate why survey functions must never call any method of the tree.
+// An insert which queues for the lock during a survey prevents any new read
+// lock from being taken, so a nested Count or Survey would deadlock. Once the
+// survey returns the queued insert completes.
+// This test should be run with -race
+func TestInsertQueuedDuringSurvey(t *testing.T) {
+	tree := NewTree[int]()
+	require.NoError(t, tree.Insert(0.5, 0.5, 1))
+	view := quadtree.NewView(0, 1, 1, 0)
+
+	inserted := make(chan struct{})
+	surveyed := 0
+	tree.Survey(view, func(_, _ float64, _ *int) bool {
+		go func() {
+			defer close(inserted)
+			assert.NoError(t, tree.Insert(0.25, 0.25, 2))
+		}()
+		// Wait until the insert is queued, after which a nested read
+		// lock can't be taken
+		for tree.lock.TryRLock() {
+			tree.lock.RUnlock()
+			runtime.Gosched()
+		}
+		surveyed++
+		return true
+	})
+	<-inserted
+
+	assert.Equal(t, 1, surveyed)
+	assert.Equal(t, int64(2), tree.Count(view))
+}
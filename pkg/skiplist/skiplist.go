@@ -0,0 +1,314 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+// The skiplist package provides an ordered map whose keys and values are
+// stored offheap.
+//
+// A SkipList is made of nodes linked in a number of levels. Every node is
+// linked in the bottom level, which holds every entry in key order. Each
+// higher level links a random subset, about a quarter, of the nodes in the
+// level below it. Searches start in the highest level, and drop down a level
+// whenever the next node would overshoot the key being searched for. Gets,
+// puts and deletes are O(log n) on average.
+//
+// Unlike a balanced tree a skip list is never rebalanced. Inserting or
+// deleting an entry only changes the links of the nodes immediately before
+// it, which makes range deletes and ordered iteration simple and cheap.
+package skiplist
+
+import (
+	"math/bits"
+	"sync"
+
+	"github.com/fmstephe/memorymanager/offheap"
+)
+
+// The maximum number of levels in a skip list. With each level linking a
+// quarter of the nodes below it, this is enough for far more entries than can
+// be stored in memory.
+const maxLevel = 24
+
+// A single entry in the skip list. The length of next is the height of the
+// node, next[i] is the following node in level i.
+type node[K, V any] struct {
+	key   K
+	value V
+	next  offheap.RefSlice[offheap.RefObject[node[K, V]]]
+}
+
+// An ordered map from keys of type K to values of type V, ordered by a user
+// supplied compare function. The types K and V must not contain any
+// pointers.
+//
+// Any number of goroutines may get and survey entries at the same time, while
+// a single goroutine puts or deletes entries. Survey functions must never call
+// any method of the skip list, including Get and Survey. Putting or deleting
+// entries will deadlock, and because the read lock held while surveying is
+// not reentrant a nested read will deadlock if another goroutine is waiting to
+// put or delete.
+type SkipList[K, V any] struct {
+	// lock protects every node in the skip list
+	lock    sync.RWMutex
+	store   *offheap.Store
+	compare func(a, b K) int
+
+	// The first node in each level, head[i] is nil if level i is empty
+	head offheap.RefSlice[offheap.RefObject[node[K, V]]]
+	// The number of levels which contain nodes
+	levels int
+	// The number of entries in the skip list
+	length int
+
+	// The state of the random number generator which chooses node heights
+	random uint64
+}
+
+// Returns a new, empty, SkipList ordered by compare. compare must return a
+// negative number if a is before b, a positive number if a is after b and 0
+// if a and b are the same key.
+func New[K, V any](compare func(a, b K) int) *SkipList[K, V] {
+	return NewWithStore[K, V](offheap.New(), compare)
+}
+
+// Returns a new, empty, SkipList which allocates from store. This allows many
+// skip lists to share the same offheap memory. See New.
+func NewWithStore[K, V any](store *offheap.Store, compare func(a, b K) int) *SkipList[K, V] {
+	head := offheap.AllocSlice[offheap.RefObject[node[K, V]]](store, maxLevel, maxLevel)
+	clear(head.Value())
+
+	return &SkipList[K, V]{
+		store:   store,
+		compare: compare,
+		head:    head,
+		random:  0x9E3779B97F4A7C15,
+	}
+}
+
+// Returns the number of entries in the skip list
+func (l *SkipList[K, V]) Len() int {
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+
+	return l.length
+}
+
+// Returns the value stored for key. If key is not in the skip list the zero
+// value of V and false are returned.
+func (l *SkipList[K, V]) Get(key K) (V, bool) {
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+
+	r := l.find(key, nil)
+	if r.IsNil() {
+		var zero V
+		return zero, false
+	}
+
+	n := r.Value()
+	if l.compare(n.key, key) != 0 {
+		var zero V
+		return zero, false
+	}
+	return n.value, true
+}
+
+// Stores value for key. If key is already in the skip list its value is
+// replaced and true is returned, otherwise false is returned.
+func (l *SkipList[K, V]) Put(key K, value V) bool {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	var update [maxLevel][]offheap.RefObject[node[K, V]]
+	if r := l.find(key, &update); !r.IsNil() {
+		if n := r.Value(); l.compare(n.key, key) == 0 {
+			n.value = value
+			return true
+		}
+	}
+
+	height := l.randomHeight()
+	for ; l.levels < height; l.levels++ {
+		update[l.levels] = l.head.Value()
+	}
+
+	newR := offheap.AllocObject[node[K, V]](l.store)
+	newNode := newR.Value()
+	*newNode = node[K, V]{
+		key:   key,
+		value: value,
+		next:  offheap.AllocSlice[offheap.RefObject[node[K, V]]](l.store, height, height),
+	}
+
+	// Link the new node after its predecessor in every level it belongs to
+	next := newNode.next.Value()
+	for level := range height {
+		next[level] = update[level][level]
+		update[level][level] = newR
+	}
+
+	l.length++
+	return false
+}
+
+// Removes key from the skip list. Returns false if key was not in the skip
+// list.
+func (l *SkipList[K, V]) Delete(key K) bool {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	var update [maxLevel][]offheap.RefObject[node[K, V]]
+	r := l.find(key, &update)
+	if r.IsNil() || l.compare(r.Value().key, key) != 0 {
+		return false
+	}
+
+	l.unlink(r, &update)
+	l.shrinkLevels()
+	return true
+}
+
+// Removes every entry whose key is greater than or equal to from, and less
+// than to. Returns the number of entries removed.
+func (l *SkipList[K, V]) DeleteRange(from, to K) int {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	var update [maxLevel][]offheap.RefObject[node[K, V]]
+	r := l.find(from, &update)
+
+	// Every node between the predecessors in update and the current node
+	// has been removed, so update remains correct for each following node
+	removed := 0
+	for !r.IsNil() {
+		n := r.Value()
+		if l.compare(n.key, to) >= 0 {
+			break
+		}
+		next := n.next.Value()[0]
+		l.unlink(r, &update)
+		removed++
+		r = next
+	}
+
+	l.shrinkLevels()
+	return removed
+}
+
+// Calls fun with every entry in the skip list, in key order. Iteration stops
+// early if fun returns false. Returns false if iteration was stopped early,
+// true otherwise.
+func (l *SkipList[K, V]) Survey(fun func(key K, value V) bool) bool {
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+
+	return l.survey(l.head.Value()[0], nil, fun)
+}
+
+// Calls fun with every entry whose key is greater than or equal to from, and
+// less than to, in key order. Iteration stops early if fun returns false.
+// Returns false if iteration was stopped early, true otherwise.
+func (l *SkipList[K, V]) SurveyRange(from, to K, fun func(key K, value V) bool) bool {
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+
+	return l.survey(l.find(from, nil), &to, fun)
+}
+
+// Frees all of the memory used by this skip list. After this method is called
+// the skip list must not be used again.
+func (l *SkipList[K, V]) Free() {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	for r := l.head.Value()[0]; !r.IsNil(); {
+		n := r.Value()
+		next := n.next.Value()[0]
+		offheap.FreeSlice(l.store, n.next)
+		offheap.FreeObject(l.store, r)
+		r = next
+	}
+	offheap.FreeSlice(l.store, l.head)
+	l.head = offheap.RefSlice[offheap.RefObject[node[K, V]]]{}
+	l.levels = 0
+	l.length = 0
+}
+
+// Returns the first node whose key is greater than or equal to key, or a nil
+// reference if there is no such node. If update is not nil, update[i] is set
+// to the next links of the last node in level i whose key is less than key,
+// or to head if there is no such node.
+func (l *SkipList[K, V]) find(key K, update *[maxLevel][]offheap.RefObject[node[K, V]]) offheap.RefObject[node[K, V]] {
+	prev := l.head.Value()
+	for level := l.levels - 1; level >= 0; level-- {
+		for {
+			next := prev[level]
+			if next.IsNil() {
+				break
+			}
+			n := next.Value()
+			if l.compare(n.key, key) >= 0 {
+				break
+			}
+			prev = n.next.Value()
+		}
+		if update != nil {
+			update[level] = prev
+		}
+	}
+	if l.levels == 0 {
+		return offheap.RefObject[node[K, V]]{}
+	}
+	return prev[0]
+}
+
+// Unlinks r from every level it belongs to, and frees it. update must contain
+// the predecessors of r, see find.
+func (l *SkipList[K, V]) unlink(r offheap.RefObject[node[K, V]], update *[maxLevel][]offheap.RefObject[node[K, V]]) {
+	n := r.Value()
+	for level, next := range n.next.Value() {
+		if update[level][level] == r {
+			update[level][level] = next
+		}
+	}
+	offheap.FreeSlice(l.store, n.next)
+	offheap.FreeObject(l.store, r)
+	l.length--
+}
+
+// Removes empty levels from the top of the skip list
+func (l *SkipList[K, V]) shrinkLevels() {
+	head := l.head.Value()
+	for l.levels > 0 && head[l.levels-1].IsNil() {
+		l.levels--
+	}
+}
+
+// Calls fun with every entry from r onwards, stopping before the first key
+// which is not less than to. If to is nil every entry from r is visited.
+func (l *SkipList[K, V]) survey(r offheap.RefObject[node[K, V]], to *K, fun func(key K, value V) bool) bool {
+	for !r.IsNil() {
+		n := r.Value()
+		if to != nil && l.compare(n.key, *to) >= 0 {
+			return true
+		}
+		if !fun(n.key, n.value) {
+			return false
+		}
+		r = n.next.Value()[0]
+	}
+	return true
+}
+
+// Returns a random height for a new node, between 1 and maxLevel. Each
+// additional level is a quarter as likely as the level below it.
+func (l *SkipList[K, V]) randomHeight() int {
+	// xorshift64
+	l.random ^= l.random << 13
+	l.random ^= l.random >> 7
+	l.random ^= l.random << 17
+
+	// Each pair of trailing zero bits adds a level, the high bit caps the
+	// height at maxLevel
+	return 1 + bits.TrailingZeros64(l.random|1<<(2*(maxLevel-1)))/2
+}
@@ -0,0 +1,270 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+package skiplist
+
+import (
+	"cmp"
+	"math/rand"
+	"runtime"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/fmstephe/memorymanager/offheap"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Returns every key in l, in the order they are surveyed
+func keys(l *SkipList[int, int]) []int {
+	ks := []int{}
+	l.Survey(func(key, value int) bool {
+		ks = append(ks, key)
+		return true
+	})
+	return ks
+}
+
+// Show that entries can be put, got and deleted, and that iteration is in key
+// order
+func TestPutGetDelete(t *testing.T) {
+	l := New[int, int](cmp.Compare[int])
+	defer l.Free()
+
+	_, ok := l.Get(1)
+	assert.False(t, ok)
+	assert.False(t, l.Delete(1))
+	assert.Equal(t, []int{}, keys(l))
+
+	for _, k := range []int{5, 3, 9, 1, 7} {
+		assert.False(t, l.Put(k, k*10))
+	}
+	assert.Equal(t, 5, l.Len())
+	assert.Equal(t, []int{1, 3, 5, 7, 9}, keys(l))
+
+	value, ok := l.Get(7)
+	require.True(t, ok)
+	assert.Equal(t, 70, value)
+	_, ok = l.Get(4)
+	assert.False(t, ok)
+
+	// Putting an existing key replaces its value
+	assert.True(t, l.Put(7, 700))
+	value, ok = l.Get(7)
+	require.True(t, ok)
+	assert.Equal(t, 700, value)
+	assert.Equal(t, 5, l.Len())
+
+	assert.True(t, l.Delete(3))
+	assert.False(t, l.Delete(3))
+	assert.Equal(t, []int{1, 5, 7, 9}, keys(l))
+	assert.Equal(t, 4, l.Len())
+}
+
+// Show that SurveyRange visits only the keys in [from, to), and that surveys
+// can be stopped early
+func TestSurveyRange(t *testing.T) {
+	l := New[int, int](cmp.Compare[int])
+	defer l.Free()
+
+	for i := range 100 {
+		l.Put(i*2, i)
+	}
+
+	visited := []int{}
+	assert.True(t, l.SurveyRange(11, 21, func(key, value int) bool {
+		assert.Equal(t, key/2, value)
+		visited = append(visited, key)
+		return true
+	}))
+	assert.Equal(t, []int{12, 14, 16, 18, 20}, visited)
+
+	visited = []int{}
+	assert.False(t, l.SurveyRange(0, 1000, func(key, value int) bool {
+		visited = append(visited, key)
+		return key < 4
+	}))
+	assert.Equal(t, []int{0, 2, 4}, visited)
+
+	assert.True(t, l.SurveyRange(500, 1000, func(key, value int) bool {
+		t.Fatalf("unexpected key %d", key)
+		return true
+	}))
+}
+
+// Show that DeleteRange removes only the keys in [from, to)
+func TestDeleteRange(t *testing.T) {
+	l := New[int, int](cmp.Compare[int])
+	defer l.Free()
+
+	for i := range 100 {
+		l.Put(i, i)
+	}
+
+	assert.Equal(t, 10, l.DeleteRange(20, 30))
+	assert.Equal(t, 0, l.DeleteRange(20, 30))
+	assert.Equal(t, 90, l.Len())
+
+	expected := []int{}
+	for i := range 100 {
+		if i < 20 || i >= 30 {
+			expected = append(expected, i)
+		}
+	}
+	assert.Equal(t, expected, keys(l))
+
+	assert.Equal(t, 90, l.DeleteRange(-1, 100))
+	assert.Equal(t, []int{}, keys(l))
+	assert.Equal(t, 0, l.Len())
+
+	// The skip list can be used again after being emptied
+	l.Put(1, 1)
+	assert.Equal(t, []int{1}, keys(l))
+}
+
+// Show that Free releases every allocation made by the skip list
+func TestFree(t *testing.T) {
+	store := offheap.New()
+	defer func() {
+		assert.NoError(t, store.Destroy())
+	}()
+
+	l := NewWithStore[int, int](store, cmp.Compare[int])
+	for i := range 1000 {
+		l.Put(i, i)
+	}
+	l.DeleteRange(100, 200)
+	assert.Greater(t, store.TotalStats().Live, 0)
+
+	l.Free()
+	assert.Equal(t, 0, store.TotalStats().Live)
+}
+
+// Randomly put, delete and delete ranges of keys, checking the skip list's
+// contents against a map
+func TestRandomOperations(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	l := New[int, int](cmp.Compare[int])
+	defer l.Free()
+
+	expected := map[int]int{}
+	for i := range 20_000 {
+		key := r.Intn(1000)
+		switch r.Intn(10) {
+		case 0:
+			to := key + r.Intn(20)
+			removed := 0
+			for k := range expected {
+				if k >= key && k < to {
+					delete(expected, k)
+					removed++
+				}
+			}
+			require.Equal(t, removed, l.DeleteRange(key, to))
+		case 1, 2, 3:
+			_, ok := expected[key]
+			delete(expected, key)
+			require.Equal(t, ok, l.Delete(key))
+		default:
+			_, ok := expected[key]
+			expected[key] = i
+			require.Equal(t, ok, l.Put(key, i))
+		}
+		require.Equal(t, len(expected), l.Len())
+	}
+
+	expectedKeys := []int{}
+	for k, v := range expected {
+		expectedKeys = append(expectedKeys, k)
+		value, ok := l.Get(k)
+		require.True(t, ok)
+		assert.Equal(t, v, value)
+	}
+	slices.Sort(expectedKeys)
+	assert.Equal(t, expectedKeys, keys(l))
+}
+
+// Show that many goroutines can read a skip list while another goroutine
+// writes to it
+func TestConcurrentReaders(t *testing.T) {
+	l := New[int, int](cmp.Compare[int])
+	defer l.Free()
+
+	// Even keys are always present, odd keys are put and deleted
+	for i := range 1000 {
+		l.Put(i*2, i*2)
+	}
+
+	const readers = 4
+	done := make(chan struct{})
+	wg := sync.WaitGroup{}
+	wg.Add(readers)
+	for range readers {
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				for i := range 1000 {
+					value, ok := l.Get(i * 2)
+					assert.True(t, ok)
+					assert.Equal(t, i*2, value)
+				}
+				prev := -1
+				l.Survey(func(key, value int) bool {
+					assert.Less(t, prev, key)
+					prev = key
+					return true
+				})
+			}
+		}()
+	}
+
+	for range 20 {
+		for i := range 1000 {
+			l.Put(i*2+1, i)
+		}
+		for i := range 1000 {
+			l.Delete(i*2 + 1)
+		}
+	}
+	close(done)
+	wg.Wait()
+}
+
+// Demonstrate why survey functions must never call any method of the skip
+// list. A put which queues for the lock during a survey prevents any new read
+// lock from being taken, so a nested Get or Survey would deadlock. Once the
+// survey returns the queued put completes.
+// This test should be run with -race
+func TestPutQueuedDuringSurvey(t *testing.T) {
+	l := New[int, int](cmp.Compare[int])
+	defer l.Free()
+	l.Put(1, 1)
+
+	put := make(chan struct{})
+	surveyed := 0
+	l.Survey(func(_, _ int) bool {
+		go func() {
+			defer close(put)
+			l.Put(2, 2)
+		}()
+		// Wait until the put is queued, after which a nested read lock
+		// can't be taken
+		for l.lock.TryRLock() {
+			l.lock.RUnlock()
+			runtime.Gosched()
+		}
+		surveyed++
+		return true
+	})
+	<-put
+
+	assert.Equal(t, 1, surveyed)
+	assert.Equal(t, []int{1, 2}, keys(l))
+}
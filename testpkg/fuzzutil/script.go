@@ -0,0 +1,254 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+package fuzzutil
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// A Step which can be written as a line of a script, see TestRun.Script.
+type ScriptStep interface {
+	Step
+	// Returns the line of script which describes this step
+	ScriptLine() ScriptLine
+}
+
+// A single step of a script. A step is written as its name followed by its
+// arguments, separated by spaces, e.g.
+//
+//	alloc 12 7
+//	append-string 3 "hello"
+//
+// String arguments are written as quoted Go strings, so they can contain any
+// bytes.
+type ScriptLine struct {
+	Name string
+	Args []string
+}
+
+// Returns a ScriptLine for the step name. Each string argument is quoted,
+// every other argument is formatted using fmt.Sprint.
+func NewScriptLine(name string, args ...any) ScriptLine {
+	line := ScriptLine{
+		Name: name,
+		Args: make([]string, 0, len(args)),
+	}
+	for _, arg := range args {
+		if str, ok := arg.(string); ok {
+			line.Args = append(line.Args, strconv.Quote(str))
+		} else {
+			line.Args = append(line.Args, fmt.Sprint(arg))
+		}
+	}
+	return line
+}
+
+func (l ScriptLine) String() string {
+	if len(l.Args) == 0 {
+		return l.Name
+	}
+	return l.Name + " " + strings.Join(l.Args, " ")
+}
+
+// A Script is a human-readable description of a TestRun, with one line per
+// step. A failing TestRun can be written as a Script, minimised with
+// Minimize, committed as a regression test and run again with
+// NewTestRunFromScript.
+type Script []ScriptLine
+
+// Returns the script, with one step per line
+func (s Script) String() string {
+	b := strings.Builder{}
+	for _, line := range s {
+		b.WriteString(line.String())
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// Parses a script written by Script.String. Blank lines, and lines starting
+// with #, are ignored.
+func ParseScript(text string) (Script, error) {
+	script := Script{}
+	for lineNum, text := range strings.Split(text, "\n") {
+		text = strings.TrimSpace(text)
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		tokens, err := splitScriptLine(text)
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse script line %d: %w", lineNum+1, err)
+		}
+		script = append(script, ScriptLine{Name: tokens[0], Args: tokens[1:]})
+	}
+	return script, nil
+}
+
+// Splits text into space separated tokens, where quoted strings are a single
+// token
+func splitScriptLine(text string) ([]string, error) {
+	tokens := []string{}
+	for text != "" {
+		var token string
+		if text[0] == '"' {
+			quoted, err := strconv.QuotedPrefix(text)
+			if err != nil {
+				return nil, fmt.Errorf("bad quoted string %s", text)
+			}
+			token = quoted
+		} else {
+			token, _, _ = strings.Cut(text, " ")
+		}
+		tokens = append(tokens, token)
+		text = strings.TrimLeft(text[len(token):], " ")
+	}
+	return tokens, nil
+}
+
+// Reads the arguments of a single line of a script, in the same way that a
+// ByteConsumer reads the bytes of a single step. The first error found is
+// returned by Err.
+type ScriptArgs struct {
+	args []string
+	err  error
+}
+
+// Returns the number of arguments not yet read
+func (a *ScriptArgs) Len() int {
+	return len(a.args)
+}
+
+func (a *ScriptArgs) Byte() byte {
+	return byte(a.uint(8))
+}
+
+func (a *ScriptArgs) Uint16() uint16 {
+	return uint16(a.uint(16))
+}
+
+func (a *ScriptArgs) Uint32() uint32 {
+	return uint32(a.uint(32))
+}
+
+func (a *ScriptArgs) Uint64() uint64 {
+	return a.uint(64)
+}
+
+func (a *ScriptArgs) Int() int {
+	value, err := strconv.Atoi(a.next())
+	a.setErr(err)
+	return value
+}
+
+// Returns the next argument, which must be a quoted string
+func (a *ScriptArgs) Text() string {
+	value, err := strconv.Unquote(a.next())
+	a.setErr(err)
+	return value
+}
+
+// Returns the first error found while reading the arguments
+func (a *ScriptArgs) Err() error {
+	return a.err
+}
+
+func (a *ScriptArgs) uint(bitSize int) uint64 {
+	value, err := strconv.ParseUint(a.next(), 10, bitSize)
+	a.setErr(err)
+	return value
+}
+
+func (a *ScriptArgs) next() string {
+	if len(a.args) == 0 {
+		a.setErr(fmt.Errorf("missing argument"))
+		return ""
+	}
+	arg := a.args[0]
+	a.args = a.args[1:]
+	return arg
+}
+
+func (a *ScriptArgs) setErr(err error) {
+	if a.err == nil {
+		a.err = err
+	}
+}
+
+// Makes the Step described by a single line of a script
+type StepParser func(args *ScriptArgs) Step
+
+// Returns a new TestRun made up of the steps in script. Each step is made by
+// the parser with the same name as the step.
+func NewTestRunFromScript(script Script, parsers map[string]StepParser, cleanup func()) (*TestRun, error) {
+	tr := &TestRun{
+		steps:   make([]Step, 0, len(script)),
+		cleanup: cleanup,
+	}
+	for i, line := range script {
+		parser, ok := parsers[line.Name]
+		if !ok {
+			return nil, fmt.Errorf("cannot parse step %d, unknown step %q", i, line.Name)
+		}
+
+		args := &ScriptArgs{args: line.Args}
+		step := parser(args)
+		if args.Len() != 0 {
+			args.setErr(fmt.Errorf("%d unused arguments", args.Len()))
+		}
+		if err := args.Err(); err != nil {
+			return nil, fmt.Errorf("cannot parse step %d %q: %w", i, line, err)
+		}
+		tr.steps = append(tr.steps, step)
+	}
+	return tr, nil
+}
+
+// Returns the script describing every step of this TestRun. Every step must
+// implement ScriptStep.
+func (t *TestRun) Script() (Script, error) {
+	script := make(Script, 0, len(t.steps))
+	for i, step := range t.steps {
+		scriptStep, ok := step.(ScriptStep)
+		if !ok {
+			return nil, fmt.Errorf("step %d (%T) cannot be written to a script", i, step)
+		}
+		script = append(script, scriptStep.ScriptLine())
+	}
+	return script, nil
+}
+
+// Returns a smaller script which still fails. Steps are removed from the
+// script, first in large chunks and then one at a time, keeping each removal
+// for which fails still returns true. fails will usually build a new TestRun
+// from the script, run it and report whether it panicked.
+//
+// If s doesn't fail then s is returned unchanged.
+func (s Script) Minimize(fails func(Script) bool) Script {
+	if !fails(s) {
+		return s
+	}
+
+	for chunk := len(s) / 2; chunk > 0; {
+		removed := false
+		for start := 0; start < len(s); {
+			end := min(start+chunk, len(s))
+			candidate := append(append(Script{}, s[:start]...), s[end:]...)
+			if fails(candidate) {
+				s = candidate
+				removed = true
+			} else {
+				start = end
+			}
+		}
+		// Keep trying single steps until nothing more can be removed
+		if !removed || chunk > 1 {
+			chunk /= 2
+		}
+	}
+	return s
+}
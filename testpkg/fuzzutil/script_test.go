@@ -0,0 +1,155 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+package fuzzutil
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// A step which records its arguments when it is run
+type recordStep struct {
+	log   *[]string
+	value uint32
+	text  string
+}
+
+func (s *recordStep) DoStep() {
+	*s.log = append(*s.log, s.text)
+}
+
+func (s *recordStep) ScriptLine() ScriptLine {
+	return NewScriptLine("record", s.value, s.text)
+}
+
+func recordParsers(log *[]string) map[string]StepParser {
+	return map[string]StepParser{
+		"record": func(args *ScriptArgs) Step {
+			return &recordStep{log: log, value: args.Uint32(), text: args.Text()}
+		},
+	}
+}
+
+// Demonstrate that a TestRun can be written as a script, and that the script
+// can be parsed back into an identical TestRun
+func TestScript_RoundTrip(t *testing.T) {
+	log := []string{}
+	stepMaker := func(byteConsumer *ByteConsumer) Step {
+		return &recordStep{
+			log:   &log,
+			value: byteConsumer.Uint32(),
+			text:  string(byteConsumer.Bytes(int(byteConsumer.Byte() % 8))),
+		}
+	}
+
+	for _, bytes := range MakeRandomTestCases() {
+		log = log[:0]
+		tr := NewTestRun(bytes, stepMaker, func() {})
+		tr.Run()
+		expected := append([]string{}, log...)
+
+		script, err := tr.Script()
+		require.NoError(t, err)
+
+		parsed, err := ParseScript(script.String())
+		require.NoError(t, err)
+		assert.Equal(t, script.String(), parsed.String())
+
+		log = log[:0]
+		replayed, err := NewTestRunFromScript(parsed, recordParsers(&log), func() {})
+		require.NoError(t, err)
+		replayed.Run()
+		assert.Equal(t, expected, log)
+	}
+}
+
+// Show that quoted string arguments can contain spaces, quotes and arbitrary
+// bytes, and that comments and blank lines are ignored
+func TestParseScript(t *testing.T) {
+	script, err := ParseScript(`
+# A comment
+record 1 "two words"
+
+record 2   "\"quoted\"\x00\xff"
+`)
+	require.NoError(t, err)
+	assert.Equal(t, Script{
+		{Name: "record", Args: []string{"1", `"two words"`}},
+		{Name: "record", Args: []string{"2", `"\"quoted\"\x00\xff"`}},
+	}, script)
+
+	log := []string{}
+	tr, err := NewTestRunFromScript(script, recordParsers(&log), func() {})
+	require.NoError(t, err)
+	tr.Run()
+	assert.Equal(t, []string{"two words", "\"quoted\"\x00\xff"}, log)
+}
+
+// Show that badly formed scripts are rejected
+func TestParseScript_Errors(t *testing.T) {
+	_, err := ParseScript(`record 1 "unterminated`)
+	assert.Error(t, err)
+
+	log := []string{}
+	for _, text := range []string{
+		`unknown 1 "a"`,
+		`record 1`,
+		`record x "a"`,
+		`record 1 a`,
+		`record 1 "a" 2`,
+		`record 4294967296 "a"`,
+	} {
+		script, err := ParseScript(text)
+		require.NoError(t, err)
+		_, err = NewTestRunFromScript(script, recordParsers(&log), func() {})
+		assert.Error(t, err, text)
+	}
+}
+
+// Show that a step which can't be written to a script is reported
+func TestScript_NotScriptable(t *testing.T) {
+	tr := NewTestRun([]byte{1}, func(byteConsumer *ByteConsumer) Step {
+		byteConsumer.Byte()
+		return nopStep{}
+	}, func() {})
+
+	_, err := tr.Script()
+	assert.Error(t, err)
+}
+
+type nopStep struct{}
+
+func (nopStep) DoStep() {}
+
+// Demonstrate that Minimize removes every step which isn't needed for the
+// script to fail
+func TestScript_Minimize(t *testing.T) {
+	script := Script{}
+	for i := range 100 {
+		script = append(script, NewScriptLine("record", i, ""))
+	}
+
+	// The script fails whenever it contains both step 17 and step 62
+	fails := func(s Script) bool {
+		found := 0
+		for _, line := range s {
+			if line.Args[0] == "17" || line.Args[0] == "62" {
+				found++
+			}
+		}
+		return found == 2
+	}
+
+	minimized := script.Minimize(fails)
+	assert.Equal(t, Script{
+		NewScriptLine("record", 17, ""),
+		NewScriptLine("record", 62, ""),
+	}, minimized)
+
+	// A script which doesn't fail is unchanged
+	assert.Equal(t, script, script.Minimize(func(Script) bool { return false }))
+}
@@ -4,6 +4,11 @@
 
 package fuzzutil
 
+import (
+	"runtime/debug"
+	"testing"
+)
+
 type TestRun struct {
 	steps   []Step
 	cleanup func()
@@ -31,6 +36,24 @@ func (t *TestRun) Run() {
 	}
 }
 
+// Runs every step, like Run. If a step panics tb fails, reporting the panic
+// along with the script for this run, so that the failure can be understood
+// without decoding the fuzzer's bytes, see TestRun.Script.
+func (t *TestRun) RunOrFail(tb testing.TB) {
+	tb.Helper()
+	defer func() {
+		if r := recover(); r != nil {
+			stack := debug.Stack()
+			script, err := t.Script()
+			if err != nil {
+				tb.Fatalf("%v\n%s\ncannot write script: %v", r, stack, err)
+			}
+			tb.Fatalf("%v\n%s\nfailing script:\n%s", r, stack, script)
+		}
+	}()
+	t.Run()
+}
+
 type Step interface {
 	DoStep()
 }
@@ -0,0 +1,76 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+package fuzzutil
+
+import "fmt"
+
+// A kind of step which a fuzzer can make, see NewWeightedStepMaker.
+type StepKind struct {
+	// The name of the step, as written in a script
+	Name string
+	// How often this kind of step is chosen, relative to the other kinds.
+	// A kind with a weight of 0 is never chosen.
+	Weight int
+	// Makes a step of this kind from the fuzzer's bytes
+	Make func(*ByteConsumer) Step
+	// Makes a step of this kind from a line of a script
+	Parse StepParser
+}
+
+// Returns a step maker which uses a single byte to choose between kinds, each
+// kind being chosen in proportion to its weight.
+//
+// Panics if the total weight of kinds is 0, or more than 256.
+func NewWeightedStepMaker(kinds []StepKind) func(*ByteConsumer) Step {
+	// choices maps each value of the chooser byte, modulo the total
+	// weight, to a kind
+	choices := []func(*ByteConsumer) Step{}
+	for _, kind := range kinds {
+		if kind.Weight < 0 {
+			panic(fmt.Errorf("step %q has negative weight %d", kind.Name, kind.Weight))
+		}
+		for range kind.Weight {
+			choices = append(choices, kind.Make)
+		}
+	}
+	if len(choices) == 0 || len(choices) > 256 {
+		panic(fmt.Errorf("total step weight must be between 1 and 256, found %d", len(choices)))
+	}
+
+	return func(byteConsumer *ByteConsumer) Step {
+		chooser := byteConsumer.Byte()
+		return choices[int(chooser)%len(choices)](byteConsumer)
+	}
+}
+
+// Returns the parsers for every kind, by name, for use with
+// NewTestRunFromScript. Kinds with a weight of 0 are included, so a script
+// can contain steps which are never chosen by the fuzzer.
+func StepParsers(kinds []StepKind) map[string]StepParser {
+	parsers := make(map[string]StepParser, len(kinds))
+	for _, kind := range kinds {
+		parsers[kind.Name] = kind.Parse
+	}
+	return parsers
+}
+
+// Returns a copy of kinds where the weight of each kind named in weights is
+// replaced. Panics if weights names a kind which doesn't exist.
+func Reweight(kinds []StepKind, weights map[string]int) []StepKind {
+	reweighted := append([]StepKind{}, kinds...)
+	for name, weight := range weights {
+		found := false
+		for i := range reweighted {
+			if reweighted[i].Name == name {
+				reweighted[i].Weight = weight
+				found = true
+			}
+		}
+		if !found {
+			panic(fmt.Errorf("cannot reweight unknown step %q", name))
+		}
+	}
+	return reweighted
+}
@@ -0,0 +1,104 @@
+// Copyright 2024 Francis Michael Stephens. All rights reserved.  Use of this
+// source code is governed by an MIT license that can be found in the LICENSE
+// file.
+
+package fuzzutil
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type namedStep string
+
+func (namedStep) DoStep() {}
+
+func namedKind(name string, weight int) StepKind {
+	return StepKind{
+		Name:   name,
+		Weight: weight,
+		Make: func(*ByteConsumer) Step {
+			return namedStep(name)
+		},
+		Parse: func(*ScriptArgs) Step {
+			return namedStep(name)
+		},
+	}
+}
+
+// Demonstrate that each kind of step is chosen in proportion to its weight
+func TestWeightedStepMaker(t *testing.T) {
+	kinds := []StepKind{
+		namedKind("a", 1),
+		namedKind("b", 0),
+		namedKind("c", 3),
+	}
+	stepMaker := NewWeightedStepMaker(kinds)
+
+	// Every chooser byte, repeated enough times to divide evenly between
+	// the weights
+	bytes := []byte{}
+	for range 4 {
+		for b := range 256 {
+			bytes = append(bytes, byte(b))
+		}
+	}
+
+	counts := map[Step]int{}
+	byteConsumer := NewByteConsumer(bytes)
+	for byteConsumer.Len() > 0 {
+		counts[stepMaker(byteConsumer)]++
+	}
+
+	assert.Equal(t, map[Step]int{
+		namedStep("a"): 256,
+		namedStep("c"): 768,
+	}, counts)
+}
+
+// Show that invalid weights are rejected
+func TestWeightedStepMaker_BadWeights(t *testing.T) {
+	assert.Panics(t, func() { NewWeightedStepMaker(nil) })
+	assert.Panics(t, func() { NewWeightedStepMaker([]StepKind{namedKind("a", 0)}) })
+	assert.Panics(t, func() { NewWeightedStepMaker([]StepKind{namedKind("a", -1), namedKind("b", 2)}) })
+	assert.Panics(t, func() { NewWeightedStepMaker([]StepKind{namedKind("a", 200), namedKind("b", 57)}) })
+	assert.NotPanics(t, func() { NewWeightedStepMaker([]StepKind{namedKind("a", 200), namedKind("b", 56)}) })
+}
+
+// Demonstrate that Reweight replaces the weights of named kinds, without
+// modifying the original kinds
+func TestReweight(t *testing.T) {
+	kinds := []StepKind{
+		namedKind("a", 1),
+		namedKind("b", 2),
+	}
+
+	reweighted := Reweight(kinds, map[string]int{"b": 0})
+	assert.Equal(t, 1, reweighted[0].Weight)
+	assert.Equal(t, 0, reweighted[1].Weight)
+	assert.Equal(t, 2, kinds[1].Weight)
+
+	// Only a can be chosen now
+	stepMaker := NewWeightedStepMaker(reweighted)
+	for b := range 256 {
+		assert.Equal(t, namedStep("a"), stepMaker(NewByteConsumer([]byte{byte(b)})))
+	}
+
+	assert.Panics(t, func() { Reweight(kinds, map[string]int{"unknown": 1}) })
+}
+
+// Show that scripts can contain steps of every kind, even kinds which the
+// fuzzer never chooses
+func TestStepParsers(t *testing.T) {
+	kinds := []StepKind{
+		namedKind("a", 1),
+		namedKind("b", 0),
+	}
+
+	script, err := ParseScript("a\nb\n")
+	assert.NoError(t, err)
+	tr, err := NewTestRunFromScript(script, StepParsers(kinds), func() {})
+	assert.NoError(t, err)
+	assert.Equal(t, []Step{namedStep("a"), namedStep("b")}, tr.steps)
+}